@@ -2,9 +2,14 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -12,157 +17,408 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "loadtest" {
+		os.Exit(runLoadTest(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "seed" {
+		os.Exit(runSeed(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "backfill" {
+		os.Exit(runBackfill(os.Args[2:]))
+	}
+
+	requireAll := flag.Bool("require-all", false, "fail startup when any dependency is unavailable")
+	flag.Parse()
+
 	cfg, err := internal.LoadConfig()
 	if err != nil {
 		panic("Failed to load config: " + err.Error())
 	}
 
 	logger := internal.NewLogger(cfg)
-	metrics := internal.NewMetricsCollector(logger)
+	metrics := internal.NewMetricsCollector(logger, internal.SystemClock)
 
 	logger.Info("service_starting").
 		Component("main").
 		Operation("startup").
 		Meta("port", cfg.AppPort).
 		Meta("environment", cfg.AppEnv).
+		Meta("require_all", *requireAll).
 		Log()
 
-	var dbManager *internal.DatabaseManager
-	if cfg.DatabaseEnabled {
-		dbManager = internal.NewDatabaseManager(cfg)
-		if dbManager != nil {
-			defer dbManager.Close()
-			logger.Info("database_connected").Component("database").Log()
-		} else {
-			logger.Warn("database_connection_failed").Component("database").Log()
-		}
+	if cfg.IsSandbox() {
+		internal.SetVerboseErrors(true)
+		logger.Warn("sandbox_mode_enabled").
+			Component("main").
+			Operation("startup").
+			Meta("rate_limit_multiplier", cfg.SandboxRateLimitMultiplier).
+			Log()
 	}
 
-	cacheManager := internal.NewCacheManager(cfg, dbManager)
-	rateLimiter := internal.NewRateLimiter(cfg, logger)
+	if cfg.UsesRiotFixtures() {
+		logger.Warn("riot_fixture_mode_enabled").
+			Component("main").
+			Operation("startup").
+			Meta("fixtures_dir", cfg.RiotFixturesDir).
+			Log()
+	}
+
+	shutdownTracing, err := internal.InitTracing(context.Background(), cfg, logger)
+	if err != nil {
+		logger.Error("tracing_init_failed").
+			Component("tracing").
+			Operation("init").
+			Err(err).
+			Log()
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
+	profiler := internal.NewProfiler(cfg, logger)
+	profiler.StartMemoryProfiling()
+	profiler.StartPeriodicMemoryLogging()
+
+	redisProvider := internal.NewRedisProvider(cfg)
+	defer redisProvider.Close()
+	metrics.SetRedisProvider(redisProvider)
+
+	cacheManager := internal.NewCacheManager(cfg, redisProvider.Client(), nil, internal.SystemClock)
+	rateLimiter := internal.NewRateLimiter(cfg, redisProvider.Client(), logger)
 	riotClient := internal.NewRiotAPIClient(cfg, cacheManager, logger, metrics)
+	scheduler := internal.NewScheduler(cfg.SchedulerJitter, internal.SystemClock, logger)
 
+	var dbManager *internal.DatabaseManager
+	var schemaErr error
 	var natsClient *internal.NATSClient
+
+	boot := internal.NewBootstrapper(cfg, logger, *requireAll)
+	timeouts := internal.NewTimeoutMiddleware(cfg, logger, metrics)
+	branchTimeouts := internal.NewBranchTimeouts(cfg, logger)
+	router := internal.NewRouter(logger)
+	server := startServer(cfg, boot.Gate(timeouts.Handler(router)), logger)
+
+	boot.Step("redis", false, redisProvider.Ping)
+
+	if cfg.CacheEnabled && cfg.CacheBackend == internal.CacheBackendMemcached {
+		boot.Step("memcached", false, cacheManager.Ping)
+	}
+	if cfg.CacheEnabled && cfg.CacheBackend != internal.CacheBackendMemcached {
+		boot.Step("cache_schema", false, func(ctx context.Context) error {
+			deleted, err := cacheManager.InvalidateStaleSchemas(ctx)
+			if len(deleted) > 0 {
+				logger.Info("cache_schema_invalidated").
+					Component("cache").
+					Operation("invalidate_schema").
+					Meta("deleted_by_type", deleted).
+					Log()
+			}
+			return err
+		})
+	}
+
+	if cfg.DatabaseEnabled {
+		boot.Step("database", false, func(ctx context.Context) error {
+			db, err := internal.ConnectDatabase(cfg)
+			if err != nil {
+				return err
+			}
+			if cfg.DatabaseAutoMigrate {
+				if err := db.Migrate(ctx); err != nil {
+					db.Close()
+					return err
+				}
+			}
+			schemaErr = db.CheckSchemaCompatibility(ctx)
+			if errors.Is(schemaErr, internal.ErrSchemaMismatch) && cfg.SchemaMismatchMode == internal.SchemaMismatchReadOnly {
+				logger.Warn("schema_mismatch_read_only").
+					Component("database").
+					Err(schemaErr).
+					Log()
+				db.Close()
+				if db, err = internal.ConnectDatabaseReadOnly(cfg); err != nil {
+					return err
+				}
+				schemaErr = nil
+			} else if schemaErr != nil {
+				db.Close()
+				return schemaErr
+			}
+			if err := db.AttachReplica(cfg.PostgresReplicaDSN); err != nil {
+				logger.Warn("database_replica_unavailable").
+					Component("database").
+					Err(err).
+					Log()
+			}
+			dbManager = db
+			cacheManager.SetDatabase(dbManager)
+			riotClient.SetDatabase(dbManager)
+			return nil
+		})
+
+		// The database step itself stays optional, but a reachable database
+		// with the wrong schema must stop the service instead of being skipped.
+		boot.Step("schema_compatibility", true, func(ctx context.Context) error {
+			if errors.Is(schemaErr, internal.ErrSchemaMismatch) {
+				return schemaErr
+			}
+			return nil
+		})
+	}
+
 	if cfg.NATSUrl != "" {
-		natsClient, err = internal.NewNATSClient(cfg)
-		if err != nil {
-			logger.Error("nats_connection_failed").
-				Component("nats").
-				Err(err).
-				Log()
-		} else {
-			defer natsClient.Conn.Close()
+		boot.Step("nats", false, func(ctx context.Context) error {
+			client, err := internal.NewNATSClient(cfg, logger, metrics)
+			if err != nil {
+				return err
+			}
+			natsClient = client
+			natsClient.SetDeduplicator(internal.NewTaskDeduplicator(cfg, redisProvider.Client(), logger))
 			riotClient.SetNATSClient(natsClient)
-			setupNATSWorkers(natsClient, riotClient, cacheManager, logger)
-			scheduleLeagueUpdates(natsClient, cfg.RiotRegion, logger)
-			logger.Info("nats_connected").Component("nats").Log()
-		}
+			return nil
+		})
+
+		boot.Step("summoner_name_worker", false, func(ctx context.Context) error {
+			if natsClient == nil {
+				return errors.New("nats client unavailable")
+			}
+			return natsClient.StartSummonerNameWorker(riotClient, cacheManager)
+		})
+
+		boot.Step("league_update_worker", false, func(ctx context.Context) error {
+			if natsClient == nil {
+				return errors.New("nats client unavailable")
+			}
+			refresher := internal.NewLeagueRefresher(cfg, riotClient, cacheManager, logger)
+			if err := natsClient.StartLeagueUpdateWorker(refresher); err != nil {
+				return err
+			}
+			claims := internal.NewLeagueScheduleClaims(cfg, redisProvider.Client())
+			internal.RegisterLeagueUpdateTasks(scheduler, natsClient, claims, cfg)
+			return nil
+		})
 	}
 
-	middleware := internal.NewLoggingMiddleware(logger, metrics)
-	setupRoutes(riotClient, rateLimiter, middleware, logger, metrics)
-	startServer(cfg.AppPort, logger)
-}
+	var staticData *internal.StaticDataService
+	if cfg.StaticDataEnabled {
+		staticData = internal.NewStaticDataService(cfg, logger)
+		scheduler.Register(staticData.Task())
 
-func setupNATSWorkers(natsClient *internal.NATSClient, riotClient *internal.RiotAPIClient, cache *internal.CacheManager, logger *internal.Logger) {
-	if _, err := natsClient.StartSummonerNameWorker(riotClient, cache); err != nil {
-		logger.Error("summoner_name_worker_failed").
-			Component("nats").
-			Operation("start_worker").
-			Err(err).
-			Log()
-	} else {
-		logger.Info("summoner_name_worker_started").
-			Component("nats").
-			Operation("start_worker").
-			Log()
+		boot.Step("static_data", false, staticData.Refresh)
 	}
 
-	if _, err := natsClient.StartLeagueUpdateWorker(riotClient, cache); err != nil {
-		logger.Error("league_update_worker_failed").
-			Component("nats").
-			Operation("start_worker").
+	if err := boot.Run(context.Background()); err != nil {
+		logger.Error("bootstrap_failed").
+			Component("main").
+			Operation("startup").
 			Err(err).
 			Log()
-	} else {
-		logger.Info("league_update_worker_started").
-			Component("nats").
-			Operation("start_worker").
-			Log()
+		os.Exit(1)
 	}
-}
 
-func scheduleLeagueUpdates(natsClient *internal.NATSClient, region string, logger *internal.Logger) {
-	ticker := time.NewTicker(30 * time.Minute)
-	go func() {
-		defer ticker.Stop()
-		for range ticker.C {
-			tasks := []internal.LeagueUpdateTask{
-				{Type: "challenger", Region: region},
-				{Type: "grandmaster", Region: region},
-				{Type: "master", Region: region},
-			}
+	if cfg.MetricsPersistenceEnabled && cfg.CacheEnabled {
+		metrics.EnablePersistence(cacheManager, cfg.MetricsSnapshotInterval, cfg.MetricsInstanceID)
+		defer metrics.StopPersistence(context.Background())
+	}
 
-			for _, task := range tasks {
-				if err := natsClient.PublishLeagueUpdateTask(task); err != nil {
-					logger.Error("league_update_task_failed").
-						Component("nats").
-						Operation("publish_task").
-						Err(err).
-						Meta("task_type", task.Type).
-						Log()
-				} else {
-					logger.Debug("league_update_task_published").
-						Component("nats").
-						Operation("publish_task").
-						Meta("task_type", task.Type).
-						Log()
-				}
+	if dbManager != nil {
+		defer dbManager.Close()
+	}
+	// Background writers are not started against a read-only database.
+	var summonerCachePruner *internal.SummonerCachePruner
+	importer := internal.NewWatchlistImporter(dbManager, riotClient, logger)
+	if dbManager != nil && !dbManager.ReadOnly {
+		if cfg.SchedulerPersistent {
+			scheduler.SetStore(dbManager)
+		}
+		if natsClient != nil {
+			natsClient.SetDeadLetterStore(dbManager)
+		}
+		if cfg.RiotJournalEnabled {
+			riotClient.SetJournal(internal.NewRiotJournal(dbManager, logger, 1000))
+		}
+
+		scheduler.Register(internal.NewWatchlistRefresher(cfg, dbManager, riotClient, natsClient, logger).Task())
+		scheduler.Register(importer.Task())
+		if cfg.SummonerCachePruneEnabled {
+			summonerCachePruner = internal.NewSummonerCachePruner(cfg, dbManager, logger)
+			scheduler.Register(summonerCachePruner.Task())
+		}
+		if natsClient != nil {
+			scheduler.Register(internal.NewOutboxRelay(cfg, dbManager, natsClient, logger).Task())
+
+			ingester := internal.NewMatchIngester(cfg, dbManager, riotClient, logger)
+			if err := natsClient.StartMatchIngestWorker(ingester); err != nil {
+				logger.Error("match_ingest_worker_failed").
+					Component("main").
+					Operation("startup").
+					Err(err).
+					Log()
 			}
 		}
-	}()
+	}
+	if natsClient != nil {
+		if notifier := internal.NewNotifier(cfg, logger); notifier.Enabled() {
+			if err := natsClient.StartNotificationWorker(notifier); err != nil {
+				logger.Error("notification_worker_failed").
+					Component("main").
+					Operation("startup").
+					Err(err).
+					Log()
+			}
+		}
+		defer func() {
+			if err := natsClient.Drain(cfg.NATSDrainTimeout); err != nil {
+				logger.Error("nats_drain_failed").
+					Component("nats").
+					Operation("drain").
+					Err(err).
+					Log()
+			}
+		}()
+	}
+
+	if cfg.CacheEnabled && cfg.CacheVerifyEnabled {
+		scheduler.Register(internal.NewCacheVerifier(cfg, cacheManager, riotClient, metrics, logger).Task())
+	}
+	// Pruning relies on SCAN, which memcached does not offer.
+	if cfg.CacheEnabled && cfg.CachePruneEnabled && cfg.CacheBackend != internal.CacheBackendMemcached {
+		scheduler.Register(internal.NewCachePruner(cfg, cacheManager, metrics, logger).Task())
+	}
+
+	sloTracker := internal.NewSLOTracker(cfg, logger)
+	if sloTracker != nil {
+		metrics.SetSLOTracker(sloTracker)
+		scheduler.Register(sloTracker.Task())
+	}
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	importer.Start()
+	defer importer.Stop()
 
-	logger.Info("league_update_scheduler_started").
-		Component("scheduler").
-		Operation("start").
-		Meta("interval", "30m").
+	middleware := internal.NewLoggingMiddleware(logger, metrics, internal.SystemClock, internal.UUIDGenerator)
+	recovery := internal.NewRecoveryMiddleware(logger, metrics)
+	responseCache := internal.NewResponseCache(cfg, cacheManager, logger, metrics)
+	endpointLimits := internal.NewEndpointLimiter(cfg, logger, metrics)
+	auth := internal.NewAuthenticator(cfg, dbManager, logger, metrics)
+	if err := auth.SetNATSClient(natsClient); err != nil {
+		logger.Error("api_key_revocation_subscribe_failed").
+			Component("auth").
+			Operation("startup").
+			Err(err).
+			Log()
+	}
+	operatorGuard := internal.NewOperatorGuard(cfg, logger)
+	deprecations := internal.NewDeprecationLayer(cfg, logger, metrics)
+	placementStats := internal.NewPlacementStats(cfg, dbManager, cacheManager, logger)
+	verifier := internal.NewVerifier(cfg, dbManager, riotClient, logger)
+
+	configWatcher := internal.NewConfigWatcher(cfg, logger)
+	configWatcher.Subscribe("logger", logger.ApplyConfig)
+	configWatcher.Subscribe("rate_limiter", rateLimiter.ApplyConfig)
+	configWatcher.Subscribe("riot_client", riotClient.ApplyConfig)
+	configWatcher.Subscribe("response_cache", responseCache.ApplyConfig)
+	configWatcher.Subscribe("auth", auth.ApplyConfig)
+	configWatcher.Subscribe("placement_stats", placementStats.ApplyConfig)
+	configWatcher.Subscribe("scheduler", scheduler.ApplyConfig)
+	defer configWatcher.WatchSignals()()
+
+	setupRoutes(router, cfg, riotClient, cacheManager, dbManager, natsClient, staticData, placementStats, branchTimeouts, verifier, importer, scheduler, rateLimiter, endpointLimits, auth, operatorGuard, deprecations, middleware, recovery, responseCache, configWatcher, boot, summonerCachePruner, sloTracker, logger, metrics)
+	boot.MarkReady()
+
+	logger.Info("service_ready").
+		Component("main").
+		Operation("startup").
 		Log()
+
+	waitForShutdown(server, logger)
 }
 
-func setupRoutes(riotClient *internal.RiotAPIClient, rateLimiter *internal.RateLimiter, middleware *internal.LoggingMiddleware, logger *internal.Logger, metrics *internal.MetricsCollector) {
-	http.HandleFunc("/healthz", middleware.Handler(internal.HealthHandler(logger)))
-	http.HandleFunc("/summoner", middleware.Handler(internal.SummonerHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/search/player", middleware.Handler(internal.SearchPlayerHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/league/challenger", middleware.Handler(internal.ChallengerHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/league/grandmaster", middleware.Handler(internal.GrandmasterHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/league/master", middleware.Handler(internal.MasterHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/league/entries", middleware.Handler(internal.EntriesHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/league/by-puuid", middleware.Handler(internal.LeagueByPUUIDHandler(riotClient, rateLimiter, logger)))
-	http.HandleFunc("/metrics", middleware.Handler(internal.MetricsHandler(logger, metrics)))
+func setupRoutes(router *internal.Router, cfg *internal.Config, riotClient *internal.RiotAPIClient, cacheManager *internal.CacheManager, dbManager *internal.DatabaseManager, natsClient *internal.NATSClient, staticData *internal.StaticDataService, placementStats *internal.PlacementStats, branchTimeouts *internal.BranchTimeouts, verifier *internal.Verifier, importer *internal.WatchlistImporter, scheduler *internal.Scheduler, rateLimiter *internal.RateLimiter, endpointLimits *internal.EndpointLimiter, auth *internal.Authenticator, operatorGuard *internal.OperatorGuard, deprecations *internal.DeprecationLayer, middleware *internal.LoggingMiddleware, recovery *internal.RecoveryMiddleware, responseCache *internal.ResponseCache, configWatcher *internal.ConfigWatcher, boot *internal.Bootstrapper, summonerCachePruner *internal.SummonerCachePruner, sloTracker *internal.SLOTracker, logger *internal.Logger, metrics *internal.MetricsCollector) {
+	get := internal.Methods(http.MethodGet)
+	post := internal.Methods(http.MethodPost)
+	getPost := internal.Methods(http.MethodGet, http.MethodPost)
+	getPostDelete := internal.Methods(http.MethodGet, http.MethodPost, http.MethodDelete)
 
-	logger.Info("routes_configured").Component("http").Log()
-}
+	root := router.Group("", middleware.Handler, recovery.Handler)
+	root.Handle("/healthz", internal.HealthHandler(natsClient, scheduler, logger), get)
+
+	reader := root.Group("", auth.Require(internal.RoleReadOnly))
+	reader.Handle(internal.LeagueEntriesStreamPath, internal.EntriesStreamHandler(riotClient, rateLimiter, cfg, logger), get, internal.Use(endpointLimits.Class("stream")), internal.Timeout(0))
+
+	api := reader.Group("", deprecations.Handler)
+	api.Handle("/scaling/signals", internal.ScalingSignalsHandler(logger, metrics), get)
+	api.Handle("/schemas", internal.SchemaHandler(logger), get)
+	api.Handle("/schemas/", internal.SchemaHandler(logger), get)
+
+	lookup := api.Group("", endpointLimits.Class("lookup"))
+	lookup.Handle("/summoner", internal.SummonerHandler(riotClient, placementStats, branchTimeouts, rateLimiter, logger), get)
+	lookup.Handle("/search/player", internal.SearchPlayerHandler(riotClient, branchTimeouts, rateLimiter, logger), get)
+	lookup.Handle("/players/by-puuid/{puuid}", internal.WithPathParams(internal.SummonerHandler(riotClient, placementStats, branchTimeouts, rateLimiter, logger), "puuid"), get)
+	lookup.Handle("/players/{gameName}/{tagLine}", internal.WithPathParams(internal.SearchPlayerHandler(riotClient, branchTimeouts, rateLimiter, logger), "gameName", "tagLine"), get)
+	lookup.Handle("/player/global-search", internal.GlobalSearchHandler(riotClient, branchTimeouts, rateLimiter, logger), get)
+	lookup.Handle("/search/autocomplete", internal.AutocompleteHandler(cacheManager, rateLimiter, logger), get)
+	lookup.Handle("/league/by-puuid", internal.LeagueByPUUIDHandler(riotClient, rateLimiter, logger), get)
+	lookup.Handle("/player/placements", internal.PlacementsHandler(placementStats, dbManager, rateLimiter, logger), get)
+	lookup.Handle("/player/projection", internal.ProjectionHandler(dbManager, rateLimiter, logger), get)
+	lookup.Handle("/graphql", internal.GraphQLHandler(riotClient, rateLimiter, logger), getPost)
+	lookup.Handle("/watchlist", internal.WatchlistHandler(dbManager, riotClient, rateLimiter, logger), getPostDelete)
+	lookup.Handle("/watchlist/import", internal.WatchlistImportHandler(importer, rateLimiter, logger), getPost)
+	lookup.Handle("/verification", internal.VerificationHandler(verifier, rateLimiter, logger), getPost)
+	lookup.Handle("/verification/check", internal.VerificationCheckHandler(verifier, rateLimiter, logger), post)
 
-func startServer(port string, logger *internal.Logger) {
-	if port == "" {
-		port = "8000"
+	cached := api.Group("", responseCache.Handler)
+	cached.Handle("/stats/meta/top-players", internal.MetaTopPlayersHandler(riotClient, dbManager, rateLimiter, logger), get, internal.Use(endpointLimits.Class("lookup")))
+
+	league := cached.Group("/league", endpointLimits.Class("league"))
+	league.Handle("/challenger", internal.ChallengerHandler(riotClient, rateLimiter, logger), get)
+	league.Handle("/challenger/diff", internal.ChallengerDiffHandler(riotClient, rateLimiter, logger), get)
+	league.Handle("/grandmaster", internal.GrandmasterHandler(riotClient, rateLimiter, logger), get)
+	league.Handle("/master", internal.MasterHandler(riotClient, rateLimiter, logger), get)
+	league.Handle("/entries", internal.EntriesHandler(riotClient, rateLimiter, logger), get)
+
+	if staticData != nil {
+		static := api.Group("/static", endpointLimits.Class("static"))
+		static.Handle("/units", internal.StaticUnitsHandler(staticData, rateLimiter, logger), get)
+		static.Handle("/traits", internal.StaticTraitsHandler(staticData, rateLimiter, logger), get)
+		static.Handle("/items", internal.StaticItemsHandler(staticData, rateLimiter, logger), get)
 	}
 
-	server := &http.Server{
-		Addr:         ":" + port,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+	ops := root.Group("", operatorGuard.Handler)
+	metricsRoutes := ops.Group("/metrics", auth.Require(internal.RoleReadOnly), deprecations.Handler)
+	metricsRoutes.Handle("", internal.MetricsHandler(logger, metrics), get)
+	if sloTracker != nil {
+		metricsRoutes.Handle("/slo", internal.SLOHandler(logger, sloTracker), get)
+	}
+
+	admin := ops.Group("/admin", auth.Require(internal.RoleAdmin), deprecations.Handler, endpointLimits.Class("admin"))
+	admin.Handle("/crawl/status", internal.CrawlStatusHandler(dbManager, logger), get)
+	admin.Handle("/api-keys", internal.APIKeysHandler(auth, dbManager, logger, metrics), getPostDelete)
+	admin.Handle("/db/stats", internal.DatabaseStatsHandler(dbManager, summonerCachePruner, logger), get)
+	admin.Handle("/riot-usage", internal.RiotUsageHandler(dbManager, logger), get)
+	admin.Handle("/dlq", internal.DeadLetterHandler(dbManager, natsClient, logger), getPost)
+	admin.Handle("/config/reload", internal.ConfigReloadHandler(configWatcher, logger), post)
+	admin.Handle("/ops", internal.OpsHandler(configWatcher, boot, dbManager, logger), get)
+
+	if cfg.PProfEnabled {
+		ops.Handle("/debug/pprof/", internal.PProfHandler(logger), internal.Use(auth.Require(internal.RoleAdmin)), internal.Timeout(internal.PProfTimeout))
 	}
 
+	logger.Info("routes_configured").Component("http").Log()
+}
+
+func startServer(cfg *internal.Config, handler http.Handler, logger *internal.Logger) *http.Server {
+	server := internal.NewHTTPServer(cfg, handler)
+
 	go func() {
 		logger.Info("server_starting").
 			Component("http").
 			Operation("listen").
-			Meta("port", port).
+			Meta("addr", server.Addr).
+			Meta("scheme", internal.HTTPServerScheme(cfg)).
 			Log()
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := internal.ServeHTTPServer(cfg, server); err != nil && err != http.ErrServerClosed {
 			logger.Error("server_start_failed").
 				Component("http").
 				Operation("listen").
@@ -172,6 +428,10 @@ func startServer(port string, logger *internal.Logger) {
 		}
 	}()
 
+	return server
+}
+
+func waitForShutdown(server *http.Server, logger *internal.Logger) {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
@@ -198,3 +458,189 @@ func startServer(port string, logger *internal.Logger) {
 		Operation("shutdown").
 		Log()
 }
+
+func runLoadTest(args []string) int {
+	fs := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	target := fs.String("target", "http://localhost:8000", "base URL of the running instance")
+	rps := fs.Int("rps", 20, "requests per second to offer")
+	duration := fs.Duration("duration", 30*time.Second, "how long to generate load")
+	concurrency := fs.Int("concurrency", 0, "maximum in-flight requests (defaults to rps)")
+	timeout := fs.Duration("timeout", 10*time.Second, "per-request timeout")
+	apiKey := fs.String("api-key", os.Getenv("TFT_CORE_API_KEY"), "API key sent as X-API-Key")
+	puuids := fs.String("puuids", "", "comma-separated PUUIDs enabling player lookup scenarios")
+	maxErrorRate := fs.Float64("max-error-rate", 1, "exit non-zero when the error rate exceeds this fraction")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+
+	var puuidList []string
+	for _, puuid := range strings.Split(*puuids, ",") {
+		if puuid = strings.TrimSpace(puuid); puuid != "" {
+			puuidList = append(puuidList, puuid)
+		}
+	}
+
+	opts := internal.LoadTestOptions{
+		Target:      *target,
+		APIKey:      *apiKey,
+		RPS:         *rps,
+		Duration:    *duration,
+		Concurrency: *concurrency,
+		Timeout:     *timeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	report, err := internal.RunLoadTest(ctx, opts, internal.DefaultLoadTestScenarios(puuidList))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "loadtest:", err)
+		return 2
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(report)
+	} else {
+		report.Print(os.Stdout)
+	}
+
+	if report.Total.Requests == 0 || report.Total.ErrorRate > *maxErrorRate {
+		return 1
+	}
+	return 0
+}
+
+func runSeed(args []string) int {
+	fs := flag.NewFlagSet("seed", flag.ExitOnError)
+	region := fs.String("region", "", "platform the data is seeded for (defaults to RIOT_REGION)")
+	players := fs.Int("players", 60, "number of players to generate")
+	matches := fs.Int("matches", 40, "number of matches to generate")
+	ttl := fs.Duration("ttl", 24*time.Hour, "TTL of the seeded cache keys")
+	seed := fs.Int64("seed", 1, "random seed; the same seed produces the same data")
+	fs.Parse(args)
+
+	// Seeding never calls Riot, so it should not require a key.
+	if os.Getenv("RIOT_API_KEY") == "" && os.Getenv("RIOT_MODE") == "" {
+		os.Setenv("RIOT_MODE", internal.RiotModeFixtures)
+	}
+
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "seed:", err)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	redisProvider := internal.NewRedisProvider(cfg)
+	defer redisProvider.Close()
+	if err := redisProvider.Ping(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "seed: redis:", err)
+		return 1
+	}
+	cacheManager := internal.NewCacheManager(cfg, redisProvider.Client(), nil, internal.SystemClock)
+
+	var dbManager *internal.DatabaseManager
+	if cfg.DatabaseEnabled {
+		if dbManager, err = internal.ConnectDatabase(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "seed: database:", err)
+			return 1
+		}
+		defer dbManager.Close()
+		if err := dbManager.Migrate(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, "seed: migrate:", err)
+			return 1
+		}
+		cacheManager.SetDatabase(dbManager)
+	}
+
+	report, err := internal.Seed(ctx, cfg, cacheManager, dbManager, internal.SeedOptions{
+		Region:  *region,
+		Players: *players,
+		Matches: *matches,
+		TTL:     *ttl,
+		Seed:    *seed,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "seed:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	return 0
+}
+
+func runBackfill(args []string) int {
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	source := fs.String("source", internal.BackfillSourceWatchlist, "players to backfill: watchlist or ladder")
+	tiers := fs.String("tiers", "", "comma-separated apex tiers for -source ladder (defaults to all)")
+	matches := fs.Int("matches", 20, "latest matches to archive per player (1-200)")
+	rps := fs.Float64("rps", 0.5, "maximum Riot requests per second (0 disables pacing)")
+	limit := fs.Int("limit", 0, "stop after this many players (0 = all)")
+	fs.Parse(args)
+
+	cfg, err := internal.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backfill:", err)
+		return 2
+	}
+	if !cfg.DatabaseEnabled {
+		fmt.Fprintln(os.Stderr, "backfill: DATABASE_ENABLED must be true")
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	logger := internal.NewLogger(cfg)
+	redisProvider := internal.NewRedisProvider(cfg)
+	defer redisProvider.Close()
+	cacheManager := internal.NewCacheManager(cfg, redisProvider.Client(), nil, internal.SystemClock)
+
+	dbManager, err := internal.ConnectDatabase(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backfill: database:", err)
+		return 1
+	}
+	defer dbManager.Close()
+	if err := dbManager.Migrate(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "backfill: migrate:", err)
+		return 1
+	}
+	cacheManager.SetDatabase(dbManager)
+
+	riotClient := internal.NewRiotAPIClient(cfg, cacheManager, logger, nil)
+	riotClient.SetDatabase(dbManager)
+	ingester := internal.NewMatchIngester(cfg, dbManager, riotClient, logger)
+
+	opts := internal.BackfillOptions{
+		Source:  *source,
+		Matches: *matches,
+		RPS:     *rps,
+		Limit:   *limit,
+	}
+	if *tiers != "" {
+		opts.Tiers = strings.Split(*tiers, ",")
+	}
+
+	report, err := internal.RunMatchBackfill(ctx, ingester, opts, func(progress internal.BackfillReport) {
+		fmt.Fprintf(os.Stderr, "backfill: %d/%d players, %d ingested, %d archived, %d skipped, %d failed (%s)\n",
+			progress.Processed, progress.Players, progress.Ingested, progress.Archived, progress.Skipped, progress.Failed, progress.Elapsed)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "backfill:", err)
+		return 1
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if report.Interrupted || report.Failed > 0 {
+		return 1
+	}
+	return 0
+}
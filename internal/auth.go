@@ -0,0 +1,423 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	RoleReadOnly = "read-only"
+	RoleAdmin    = "admin"
+
+	PrincipalKey contextKey = "principal"
+)
+
+var roleRateLimitMultipliers = map[string]int{
+	RoleReadOnly: 2,
+	RoleAdmin:    10,
+}
+
+type Principal struct {
+	KeyID string `json:"keyId"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+}
+
+func (p *Principal) HasRole(role string) bool {
+	return p.Role == RoleAdmin || p.Role == role
+}
+
+func (p *Principal) RateLimitMultiplier() int {
+	if multiplier, exists := roleRateLimitMultipliers[p.Role]; exists {
+		return multiplier
+	}
+	return 1
+}
+
+func GetPrincipal(ctx context.Context) *Principal {
+	if principal, ok := ctx.Value(PrincipalKey).(*Principal); ok {
+		return principal
+	}
+	return nil
+}
+
+// AnonymousTenant owns data created without an API key, including watchlist
+// entries added before data was scoped per key.
+const AnonymousTenant = "anonymous"
+
+// TenantID is the key data is scoped to: the API key of the request, or the
+// shared anonymous tenant when auth is disabled or anonymous access is used.
+func TenantID(ctx context.Context) string {
+	if principal := GetPrincipal(ctx); principal != nil {
+		return principal.KeyID
+	}
+	return AnonymousTenant
+}
+
+type APIKey struct {
+	ID               int64      `json:"id"`
+	Name             string     `json:"name"`
+	Role             string     `json:"role"`
+	Prefix           string     `json:"prefix"`
+	CreatedAt        time.Time  `json:"createdAt"`
+	RevokedAt        *time.Time `json:"revokedAt,omitempty"`
+	Requests         int64      `json:"requests"`
+	WatchlistPlayers int        `json:"watchlistPlayers"`
+}
+
+func validRole(role string) bool {
+	return role == RoleReadOnly || role == RoleAdmin
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "tft_" + hex.EncodeToString(buf), nil
+}
+
+func parseStaticAPIKeys(value string) (map[string]*Principal, error) {
+	keys := make(map[string]*Principal)
+	if strings.TrimSpace(value) == "" {
+		return keys, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		parts := strings.SplitN(strings.TrimSpace(rule), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("invalid api key rule %q, expected name:role:key", rule)
+		}
+		if !validRole(parts[1]) {
+			return nil, fmt.Errorf("invalid role %q for api key %s", parts[1], parts[0])
+		}
+		keys[hashAPIKey(parts[2])] = &Principal{KeyID: "static:" + parts[0], Name: parts[0], Role: parts[1]}
+	}
+
+	return keys, nil
+}
+
+func (dm *DatabaseManager) CreateAPIKey(ctx context.Context, name, role, keyHash, prefix string) (*APIKey, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "create_api_key")
+	defer span.End()
+
+	key := &APIKey{Name: name, Role: role, Prefix: prefix}
+	err := dm.DB.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, role, key_hash, prefix)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, name, role, keyHash, prefix).Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	return key, nil
+}
+
+func (dm *DatabaseManager) ListAPIKeys(ctx context.Context) ([]APIKey, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "list_api_keys")
+	defer span.End()
+
+	rows, err := dm.DB.QueryContext(ctx, `
+		SELECT id, name, role, prefix, created_at, revoked_at
+		FROM api_keys
+		ORDER BY id
+	`)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		var key APIKey
+		var revokedAt sql.NullTime
+		if err := rows.Scan(&key.ID, &key.Name, &key.Role, &key.Prefix, &key.CreatedAt, &revokedAt); err != nil {
+			return nil, err
+		}
+		if revokedAt.Valid {
+			key.RevokedAt = &revokedAt.Time
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+func (dm *DatabaseManager) RevokeAPIKey(ctx context.Context, id int64) (bool, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "revoke_api_key")
+	defer span.End()
+
+	result, err := dm.DB.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND revoked_at IS NULL
+	`, id)
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+
+	affected, _ := result.RowsAffected()
+	return affected > 0, nil
+}
+
+func (dm *DatabaseManager) FindAPIKey(ctx context.Context, keyHash string) (*Principal, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "find_api_key")
+	defer span.End()
+
+	var id int64
+	principal := &Principal{}
+	err := dm.DB.QueryRowContext(ctx, `
+		SELECT id, name, role FROM api_keys
+		WHERE key_hash = $1 AND revoked_at IS NULL
+	`, keyHash).Scan(&id, &principal.Name, &principal.Role)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	principal.KeyID = strconv.FormatInt(id, 10)
+	return principal, nil
+}
+
+type cachedPrincipal struct {
+	principal *Principal
+	expiresAt time.Time
+}
+
+type Authenticator struct {
+	enabled        bool
+	allowAnonymous bool
+	// operatorGuarded is set when OPERATOR_TOKEN or OPERATOR_ALLOWED_IPS
+	// already close the admin routes while AUTH_ENABLED is off.
+	operatorGuarded bool
+	static          map[string]*Principal
+	db              *DatabaseManager
+	cacheTTL        reloadableDuration
+	logger          *Logger
+	metrics         *MetricsCollector
+	natsClient      *NATSClient
+
+	// cached holds keys found in the database; unknown keys are not cached,
+	// so presenting random keys cannot grow it.
+	cached map[string]cachedPrincipal
+	mu     sync.Mutex
+}
+
+func NewAuthenticator(cfg *Config, db *DatabaseManager, logger *Logger, metrics *MetricsCollector) *Authenticator {
+	static, err := parseStaticAPIKeys(cfg.APIKeys)
+	if err != nil {
+		logger.Error("api_keys_invalid").
+			Component("auth").
+			Operation("init").
+			Err(err).
+			Log()
+		static = map[string]*Principal{}
+	}
+
+	return &Authenticator{
+		enabled:         cfg.AuthEnabled,
+		allowAnonymous:  cfg.AuthAllowAnonymous,
+		operatorGuarded: cfg.OperatorToken != "" || cfg.OperatorAllowedIPs != "",
+		static:          static,
+		db:              db,
+		cacheTTL:        newReloadableDuration(cfg.APIKeyCacheTTL),
+		logger:          logger,
+		metrics:         metrics,
+		cached:          make(map[string]cachedPrincipal),
+	}
+}
+
+// ApplyConfig changes the TTL for keys cached from now on; entries already
+// cached keep their expiry.
+func (a *Authenticator) ApplyConfig(cfg *Config) {
+	a.cacheTTL.Store(cfg.APIKeyCacheTTL)
+}
+
+// Enabled reports whether AUTH_ENABLED is on; with it off every request
+// passes, so managing keys is refused.
+func (a *Authenticator) Enabled() bool {
+	return a != nil && a.enabled
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	return ""
+}
+
+// Require is Handler as a Router middleware.
+func (a *Authenticator) Require(role string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return a.Handler(role, next)
+	}
+}
+
+func (a *Authenticator) Handler(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+		if !a.enabled {
+			// With nothing else in front of them, admin routes would be open
+			// to anyone.
+			if role == RoleAdmin && !a.operatorGuarded {
+				a.reject(w, r, NewAPIError("Admin routes require AUTH_ENABLED, OPERATOR_TOKEN or OPERATOR_ALLOWED_IPS", http.StatusForbidden))
+				return
+			}
+			next(w, r)
+			return
+		}
+
+		key := apiKeyFromRequest(r)
+		if key == "" {
+			if role == RoleReadOnly && a.allowAnonymous {
+				next(w, r)
+				return
+			}
+			a.reject(w, r, NewAPIError("API key required", http.StatusUnauthorized))
+			return
+		}
+
+		principal, err := a.resolve(r.Context(), key)
+		if err != nil {
+			a.logger.Error("api_key_lookup_failed").
+				Component("auth").
+				Operation("authenticate").
+				Request("", "", GetRequestID(r.Context())).
+				Err(err).
+				Log()
+			a.reject(w, r, NewAPIError("Authentication unavailable", http.StatusServiceUnavailable).WithCause(err))
+			return
+		}
+		if principal == nil {
+			a.reject(w, r, NewAPIError("Invalid API key", http.StatusUnauthorized))
+			return
+		}
+		if !principal.HasRole(role) {
+			a.reject(w, r, NewAPIError("Insufficient permissions", http.StatusForbidden))
+			return
+		}
+
+		if a.metrics != nil {
+			a.metrics.RecordAPIKeyRequest(principal.KeyID)
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), PrincipalKey, principal)))
+	}
+}
+
+func (a *Authenticator) resolve(ctx context.Context, key string) (*Principal, error) {
+	keyHash := hashAPIKey(key)
+	if principal, exists := a.static[keyHash]; exists {
+		return principal, nil
+	}
+	if a.db == nil || !a.db.Enabled {
+		return nil, nil
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	entry, exists := a.cached[keyHash]
+	a.mu.Unlock()
+	if exists && now.Before(entry.expiresAt) {
+		return entry.principal, nil
+	}
+
+	principal, err := a.db.FindAPIKey(ctx, keyHash)
+	if err != nil || principal == nil {
+		return principal, err
+	}
+
+	a.mu.Lock()
+	for hash, entry := range a.cached {
+		if !now.Before(entry.expiresAt) {
+			delete(a.cached, hash)
+		}
+	}
+	a.cached[keyHash] = cachedPrincipal{principal: principal, expiresAt: now.Add(a.cacheTTL.Load())}
+	a.mu.Unlock()
+	return principal, nil
+}
+
+func (a *Authenticator) invalidate() {
+	a.mu.Lock()
+	a.cached = make(map[string]cachedPrincipal)
+	a.mu.Unlock()
+}
+
+// SetNATSClient makes revocations reach every instance: each one drops its
+// cached keys when any of them revokes a key. Without NATS, a revoked key
+// keeps working on other instances until API_KEY_CACHE_TTL.
+func (a *Authenticator) SetNATSClient(natsClient *NATSClient) error {
+	if natsClient == nil || natsClient.Conn == nil {
+		return nil
+	}
+	if _, err := natsClient.Conn.Subscribe(apiKeyRevokedSubject, func(*nats.Msg) { a.invalidate() }); err != nil {
+		return err
+	}
+	a.natsClient = natsClient
+	return nil
+}
+
+// Revoked drops the cached keys here and announces the revocation to the
+// other instances.
+func (a *Authenticator) Revoked(ctx context.Context, keyID string) {
+	a.invalidate()
+	if a.natsClient == nil {
+		return
+	}
+
+	data, err := json.Marshal(APIKeyRevokedEvent{KeyID: keyID, RevokedAt: time.Now().UTC()})
+	if err == nil {
+		err = a.natsClient.Publish(ctx, apiKeyRevokedSubject, data)
+	}
+	if err != nil {
+		a.logger.Warn("api_key_revocation_publish_failed").
+			Component("auth").
+			Operation("revoke_key").
+			Request("", "", GetRequestID(ctx)).
+			Meta("key_id", keyID).
+			Err(err).
+			Log()
+	}
+}
+
+func (a *Authenticator) reject(w http.ResponseWriter, r *http.Request, err APIError) {
+	a.logger.Warn("auth_rejected").
+		Component("auth").
+		Operation("authenticate").
+		Request(r.UserAgent(), r.RemoteAddr, GetRequestID(r.Context())).
+		Meta("path", r.URL.Path).
+		Meta("status", err.Status).
+		Log()
+
+	setCORSHeaders(w, r)
+	writeError(w, err, a.logger, r)
+}
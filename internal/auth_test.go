@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseStaticAPIKeys(t *testing.T) {
+	keys, err := parseStaticAPIKeys("dashboard:read-only:abc123, ops:admin:s3cr:et")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("parseStaticAPIKeys() returned %d keys, expected 2", len(keys))
+	}
+
+	ops := keys[hashAPIKey("s3cr:et")]
+	if ops == nil || ops.Name != "ops" || ops.Role != RoleAdmin {
+		t.Errorf("ops key = %+v, expected admin principal named ops", ops)
+	}
+
+	for _, value := range []string{"dashboard:read-only", "dashboard:owner:abc", ":admin:abc"} {
+		if _, err := parseStaticAPIKeys(value); err == nil {
+			t.Errorf("parseStaticAPIKeys(%q) expected error but got nil", value)
+		}
+	}
+}
+
+func TestAuthenticator_Handler(t *testing.T) {
+	cfg := &Config{
+		AuthEnabled:        true,
+		AuthAllowAnonymous: true,
+		APIKeys:            "reader:read-only:reader-key,ops:admin:admin-key",
+	}
+	auth := NewAuthenticator(cfg, nil, newTestLogger(), nil)
+
+	tests := []struct {
+		name     string
+		role     string
+		key      string
+		expected int
+		keyID    string
+	}{
+		{name: "anonymous read", role: RoleReadOnly, expected: http.StatusOK},
+		{name: "anonymous admin", role: RoleAdmin, expected: http.StatusUnauthorized},
+		{name: "unknown key", role: RoleReadOnly, key: "nope", expected: http.StatusUnauthorized},
+		{name: "read-only key on admin", role: RoleAdmin, key: "reader-key", expected: http.StatusForbidden},
+		{name: "read-only key on read", role: RoleReadOnly, key: "reader-key", expected: http.StatusOK, keyID: "static:reader"},
+		{name: "admin key on read", role: RoleReadOnly, key: "admin-key", expected: http.StatusOK, keyID: "static:ops"},
+		{name: "admin key on admin", role: RoleAdmin, key: "admin-key", expected: http.StatusOK, keyID: "static:ops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var principal *Principal
+			handler := auth.Handler(tt.role, func(w http.ResponseWriter, r *http.Request) {
+				principal = GetPrincipal(r.Context())
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/crawl/status", nil)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expected)
+			}
+			if tt.keyID != "" && (principal == nil || principal.KeyID != tt.keyID) {
+				t.Errorf("principal = %+v, expected key %s", principal, tt.keyID)
+			}
+		})
+	}
+}
+
+func TestAuthenticator_BearerAndDisabled(t *testing.T) {
+	auth := NewAuthenticator(&Config{AuthEnabled: true, APIKeys: "ops:admin:admin-key"}, nil, newTestLogger(), nil)
+	handler := auth.Handler(RoleAdmin, func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/api-keys", nil)
+	req.Header.Set("Authorization", "Bearer admin-key")
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("bearer status = %d, expected %d", rec.Code, http.StatusOK)
+	}
+
+	disabled := []struct {
+		name     string
+		cfg      *Config
+		role     string
+		expected int
+	}{
+		{name: "admin without any guard", cfg: &Config{}, role: RoleAdmin, expected: http.StatusForbidden},
+		{name: "admin behind operator token", cfg: &Config{OperatorToken: "secret"}, role: RoleAdmin, expected: http.StatusOK},
+		{name: "admin behind operator allowlist", cfg: &Config{OperatorAllowedIPs: "10.0.0.0/8"}, role: RoleAdmin, expected: http.StatusOK},
+		{name: "read only", cfg: &Config{}, role: RoleReadOnly, expected: http.StatusOK},
+	}
+	for _, tt := range disabled {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := NewAuthenticator(tt.cfg, nil, newTestLogger(), nil)
+			rec := httptest.NewRecorder()
+			auth.Handler(tt.role, func(w http.ResponseWriter, r *http.Request) {})(rec, httptest.NewRequest(http.MethodPost, "/admin/dlq", nil))
+			if rec.Code != tt.expected {
+				t.Errorf("disabled status = %d, expected %d", rec.Code, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTenantID(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal *Principal
+		expected  string
+	}{
+		{name: "anonymous", principal: nil, expected: AnonymousTenant},
+		{name: "database key", principal: &Principal{KeyID: "42", Role: RoleReadOnly}, expected: "42"},
+		{name: "static key", principal: &Principal{KeyID: "static:ops", Role: RoleAdmin}, expected: "static:ops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.principal != nil {
+				ctx = context.WithValue(ctx, PrincipalKey, tt.principal)
+			}
+			if result := TenantID(ctx); result != tt.expected {
+				t.Errorf("TenantID() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAPIKeysHandler_RefusedWhileAuthDisabled(t *testing.T) {
+	disabled := NewAuthenticator(&Config{AuthEnabled: false}, nil, newTestLogger(), nil)
+	handler := APIKeysHandler(disabled, &DatabaseManager{Enabled: true}, newTestLogger(), nil)
+
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(method, "/admin/api-keys", strings.NewReader(`{"name":"x","role":"admin"}`)))
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Errorf("%s status = %d, expected %d", method, rec.Code, http.StatusServiceUnavailable)
+		}
+	}
+}
+
+func TestAuthenticator_CachesOnlyKnownKeys(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	auth := NewAuthenticator(&Config{AuthEnabled: true, APIKeyCacheTTL: time.Minute}, db, newTestLogger(), nil)
+	created, err := db.CreateAPIKey(t.Context(), "tenant", RoleReadOnly, hashAPIKey("known-key"), "known-key")
+	if err != nil {
+		t.Fatalf("CreateAPIKey() error = %v", err)
+	}
+
+	for i := range 3 {
+		if principal, err := auth.resolve(t.Context(), fmt.Sprintf("unknown-%d", i)); err != nil || principal != nil {
+			t.Fatalf("resolve(unknown) = %+v, %v", principal, err)
+		}
+	}
+	if principal, err := auth.resolve(t.Context(), "known-key"); err != nil || principal == nil {
+		t.Fatalf("resolve(known) = %+v, %v", principal, err)
+	}
+	if len(auth.cached) != 1 {
+		t.Errorf("cached %d keys, expected only the known one", len(auth.cached))
+	}
+
+	rec := httptest.NewRecorder()
+	APIKeysHandler(auth, db, newTestLogger(), nil)(rec, httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/admin/api-keys?id=%d", created.ID), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if principal, _ := auth.resolve(t.Context(), "known-key"); principal != nil {
+		t.Errorf("revoked key still resolves to %+v", principal)
+	}
+}
@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	StartupStarting = "starting"
+	StartupReady    = "ready"
+	StartupFailed   = "failed"
+
+	stepPending   = "pending"
+	stepRunning   = "running"
+	stepRetrying  = "retrying"
+	stepCompleted = "completed"
+	stepSkipped   = "skipped"
+	stepFailed    = "failed"
+)
+
+type BootstrapStep struct {
+	Name     string
+	Required bool
+	Run      func(ctx context.Context) error
+}
+
+type StepProgress struct {
+	Name      string `json:"name"`
+	Required  bool   `json:"required"`
+	Status    string `json:"status"`
+	Attempts  int    `json:"attempts"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+type StartupProgress struct {
+	Status    string         `json:"status"`
+	StartedAt time.Time      `json:"startedAt"`
+	ReadyAt   *time.Time     `json:"readyAt,omitempty"`
+	Deadline  *time.Time     `json:"deadline,omitempty"`
+	Steps     []StepProgress `json:"steps"`
+}
+
+type Bootstrapper struct {
+	logger         *Logger
+	steps          []BootstrapStep
+	requireAll     bool
+	maxAttempts    int
+	waitTimeout    time.Duration
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+
+	progress StartupProgress
+	mu       sync.RWMutex
+}
+
+// NewBootstrapper retries each step until it succeeds, STARTUP_MAX_ATTEMPTS
+// is reached (0 means no limit) or STARTUP_WAIT_TIMEOUT has elapsed since Run
+// started. Every step gets at least one attempt.
+func NewBootstrapper(cfg *Config, logger *Logger, requireAll bool) *Bootstrapper {
+	maxAttempts := cfg.StartupMaxAttempts
+	if maxAttempts < 0 {
+		maxAttempts = 0
+	}
+	if maxAttempts == 0 && cfg.StartupWaitTimeout <= 0 {
+		maxAttempts = 1
+	}
+
+	return &Bootstrapper{
+		logger:         logger,
+		requireAll:     requireAll,
+		maxAttempts:    maxAttempts,
+		waitTimeout:    cfg.StartupWaitTimeout,
+		initialBackoff: cfg.StartupRetryBackoff,
+		maxBackoff:     30 * time.Second,
+		progress:       StartupProgress{Status: StartupStarting, StartedAt: time.Now().UTC(), Steps: []StepProgress{}},
+	}
+}
+
+func (b *Bootstrapper) Step(name string, required bool, run func(ctx context.Context) error) {
+	b.steps = append(b.steps, BootstrapStep{Name: name, Required: required, Run: run})
+
+	b.mu.Lock()
+	b.progress.Steps = append(b.progress.Steps, StepProgress{Name: name, Required: required, Status: stepPending})
+	b.mu.Unlock()
+}
+
+func (b *Bootstrapper) Run(ctx context.Context) error {
+	var deadline time.Time
+	if b.waitTimeout > 0 {
+		deadline = time.Now().Add(b.waitTimeout)
+		b.mu.Lock()
+		utc := deadline.UTC()
+		b.progress.Deadline = &utc
+		b.mu.Unlock()
+	}
+
+	for i, step := range b.steps {
+		start := time.Now()
+		b.updateStep(i, stepRunning, 0, nil)
+		err := b.runWithRetry(ctx, i, step, deadline)
+
+		if err == nil {
+			b.logger.Info("bootstrap_step_completed").
+				Component("bootstrap").
+				Operation(step.Name).
+				Duration(time.Since(start)).
+				Log()
+			continue
+		}
+
+		if step.Required || b.requireAll {
+			b.markStep(i, stepFailed)
+			b.setStatus(StartupFailed)
+			b.logger.Error("bootstrap_step_failed").
+				Component("bootstrap").
+				Operation(step.Name).
+				Err(err).
+				Meta("required", true).
+				Log()
+			return fmt.Errorf("bootstrap step %s failed: %w", step.Name, err)
+		}
+
+		b.markStep(i, stepSkipped)
+		b.logger.Warn("bootstrap_step_skipped").
+			Component("bootstrap").
+			Operation(step.Name).
+			Err(err).
+			Meta("required", false).
+			Log()
+	}
+	return nil
+}
+
+func (b *Bootstrapper) runWithRetry(ctx context.Context, index int, step BootstrapStep, deadline time.Time) error {
+	backoff := b.initialBackoff
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = step.Run(ctx); err == nil {
+			b.updateStep(index, stepCompleted, attempt, nil)
+			return nil
+		}
+		b.updateStep(index, stepRetrying, attempt, err)
+
+		if b.maxAttempts > 0 && attempt >= b.maxAttempts {
+			break
+		}
+		if !deadline.IsZero() && time.Now().Add(backoff).After(deadline) {
+			break
+		}
+
+		b.logger.Warn("bootstrap_step_retry").
+			Component("bootstrap").
+			Operation(step.Name).
+			Err(err).
+			Meta("attempt", attempt).
+			Meta("max_attempts", b.maxAttempts).
+			Meta("backoff", backoff.String()).
+			Log()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > b.maxBackoff {
+			backoff = b.maxBackoff
+		}
+	}
+
+	return err
+}
+
+func (b *Bootstrapper) updateStep(index int, status string, attempts int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	step := &b.progress.Steps[index]
+	step.Status = status
+	if attempts > 0 {
+		step.Attempts = attempts
+	}
+	if err != nil {
+		step.LastError = err.Error()
+	} else if status == stepCompleted {
+		step.LastError = ""
+	}
+}
+
+func (b *Bootstrapper) markStep(index int, status string) {
+	b.mu.Lock()
+	b.progress.Steps[index].Status = status
+	b.mu.Unlock()
+}
+
+func (b *Bootstrapper) setStatus(status string) {
+	b.mu.Lock()
+	b.progress.Status = status
+	b.mu.Unlock()
+}
+
+// MarkReady is called once routes are registered, after Run has returned.
+func (b *Bootstrapper) MarkReady() {
+	b.mu.Lock()
+	now := time.Now().UTC()
+	b.progress.Status = StartupReady
+	b.progress.ReadyAt = &now
+	b.mu.Unlock()
+}
+
+func (b *Bootstrapper) Ready() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.progress.Status == StartupReady
+}
+
+func (b *Bootstrapper) Progress() StartupProgress {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	progress := b.progress
+	progress.Steps = append([]StepProgress(nil), b.progress.Steps...)
+	return progress
+}
+
+// StartupHandler serves /startupz: 200 once the service is ready, 503 with
+// per-step progress while dependencies are still being waited on.
+func StartupHandler(boot *Bootstrapper) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		progress := boot.Progress()
+
+		status := http.StatusOK
+		if progress.Status != StartupReady {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(progress)
+	}
+}
+
+// Gate lets the HTTP server listen before bootstrap finishes: /startupz is
+// always served and every other path answers 503 until MarkReady.
+func (b *Bootstrapper) Gate(next http.Handler) http.Handler {
+	startup := StartupHandler(b)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/startupz" {
+			startup(w, r)
+			return
+		}
+		if !b.Ready() {
+			w.Header().Set("Retry-After", "1")
+			writeError(w, NewAPIError("Service starting", http.StatusServiceUnavailable), b.logger, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBootstrapper_Run(t *testing.T) {
+	failing := func(ctx context.Context) error { return errors.New("unavailable") }
+	passing := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		name       string
+		required   bool
+		requireAll bool
+		run        func(ctx context.Context) error
+		expectErr  bool
+	}{
+		{
+			name:      "passing step",
+			run:       passing,
+			expectErr: false,
+		},
+		{
+			name:      "optional failing step is skipped",
+			run:       failing,
+			expectErr: false,
+		},
+		{
+			name:      "required failing step aborts",
+			required:  true,
+			run:       failing,
+			expectErr: true,
+		},
+		{
+			name:       "require all makes optional steps mandatory",
+			requireAll: true,
+			run:        failing,
+			expectErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{StartupMaxAttempts: 2, StartupRetryBackoff: time.Millisecond}
+			boot := NewBootstrapper(cfg, newTestLogger(), tt.requireAll)
+			boot.Step("dependency", tt.required, tt.run)
+
+			err := boot.Run(context.Background())
+			if tt.expectErr && err == nil {
+				t.Error("expected error but got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("expected no error but got: %v", err)
+			}
+		})
+	}
+}
+
+func TestBootstrapper_LogsSteps(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	boot := NewBootstrapper(&Config{StartupMaxAttempts: 1}, logger, false)
+	boot.Step("redis", false, func(ctx context.Context) error { return nil })
+	boot.Step("nats", false, func(ctx context.Context) error { return errors.New("connection refused") })
+
+	if err := boot.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	logs.Expect(t, expectedEvent{Level: LogLevelInfo, Message: "bootstrap_step_completed", Component: "bootstrap", Operation: "redis"})
+	entry := logs.Expect(t, expectedEvent{Level: LogLevelWarn, Message: "bootstrap_step_skipped", Component: "bootstrap", Operation: "nats", Meta: map[string]interface{}{"required": false}})
+	if entry.Error != "connection refused" {
+		t.Errorf("skipped step error = %q, expected the step error", entry.Error)
+	}
+}
+
+func TestBootstrapper_RetriesUntilSuccess(t *testing.T) {
+	cfg := &Config{StartupMaxAttempts: 3, StartupRetryBackoff: time.Millisecond}
+	boot := NewBootstrapper(cfg, newTestLogger(), true)
+
+	attempts := 0
+	boot.Step("flaky", true, func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("not ready")
+		}
+		return nil
+	})
+
+	if err := boot.Run(context.Background()); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("attempts = %v, expected 3", attempts)
+	}
+}
+
+func TestBootstrapper_StopsRetryingAtWaitTimeout(t *testing.T) {
+	cfg := &Config{StartupWaitTimeout: 30 * time.Millisecond, StartupRetryBackoff: 5 * time.Millisecond}
+	boot := NewBootstrapper(cfg, newTestLogger(), false)
+
+	attempts := 0
+	boot.Step("redis", false, func(ctx context.Context) error {
+		attempts++
+		return errors.New("connection refused")
+	})
+
+	start := time.Now()
+	if err := boot.Run(context.Background()); err != nil {
+		t.Fatalf("expected optional step to be skipped, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Run() took %v, expected to stop near the wait timeout", elapsed)
+	}
+	if attempts < 2 {
+		t.Errorf("attempts = %d, expected retries within the wait timeout", attempts)
+	}
+
+	step := boot.Progress().Steps[0]
+	if step.Status != stepSkipped || step.Attempts != attempts || step.LastError != "connection refused" {
+		t.Errorf("unexpected step progress %+v", step)
+	}
+}
+
+func TestBootstrapper_Gate(t *testing.T) {
+	cfg := &Config{StartupMaxAttempts: 1}
+	boot := NewBootstrapper(cfg, newTestLogger(), false)
+	boot.Step("database", true, func(ctx context.Context) error { return nil })
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := boot.Gate(next)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	if rec := request("/league/challenger"); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("before ready: status %d, expected 503 with Retry-After", rec.Code)
+	}
+	rec := request("/startupz")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("startupz before ready: status %d, expected 503", rec.Code)
+	}
+	var progress StartupProgress
+	if err := json.Unmarshal(rec.Body.Bytes(), &progress); err != nil || progress.Status != StartupStarting || progress.Steps[0].Status != stepPending {
+		t.Errorf("unexpected progress %+v (%v)", progress, err)
+	}
+
+	if err := boot.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if rec := request("/league/challenger"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("before MarkReady: status %d, expected 503", rec.Code)
+	}
+
+	boot.MarkReady()
+	if rec := request("/league/challenger"); rec.Code != http.StatusOK {
+		t.Errorf("after ready: status %d, expected 200", rec.Code)
+	}
+	if rec := request("/startupz"); rec.Code != http.StatusOK {
+		t.Errorf("startupz after ready: status %d, expected 200", rec.Code)
+	}
+}
@@ -3,111 +3,546 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+const (
+	CacheBackendRedis     = "redis"
+	CacheBackendMemcached = "memcached"
+)
+
+var ErrCacheMiss = errors.New("cache miss")
+
+type redisCacheBackend struct {
+	client *redis.Client
+}
+
+func (rb *redisCacheBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := rb.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	}
+	return data, err
+}
+
+func (rb *redisCacheBackend) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	values, err := rb.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string][]byte, len(values))
+	for i, value := range values {
+		if data, ok := value.(string); ok {
+			found[keys[i]] = []byte(data)
+		}
+	}
+	return found, nil
+}
+
+func (rb *redisCacheBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return rb.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (rb *redisCacheBackend) Delete(ctx context.Context, keys ...string) error {
+	return rb.client.Del(ctx, keys...).Err()
+}
+
+func (rb *redisCacheBackend) Ping(ctx context.Context) error {
+	return rb.client.Ping(ctx).Err()
+}
+
+// CacheManager stores values through the configured backend. The summoner
+// name index and key sampling rely on Redis sorted sets and SCAN, so they are
+// only available with the Redis backend (redis is nil otherwise).
 type CacheManager struct {
-	redis    *redis.Client
-	database *DatabaseManager
-	enabled  bool
+	backend       CacheBackend
+	redis         *redis.Client
+	database      *DatabaseManager
+	persistent    PersistentStore
+	enabled       bool
+	pendingMaxAge time.Duration
+
+	retryBaseBackoff time.Duration
+	retryMaxBackoff  time.Duration
+
+	earlyBeta float64
+	clock     Clock
+	random    func() float64
 }
 
-func NewCacheManager(cfg *Config, db *DatabaseManager) *CacheManager {
+func NewCacheManager(cfg *Config, client *redis.Client, db *DatabaseManager, clock Clock) *CacheManager {
+	clock = clockOrSystem(clock)
+
+	var backend CacheBackend
 	var redisClient *redis.Client
 	if cfg.CacheEnabled {
-		redisClient = redis.NewClient(&redis.Options{
-			Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
-			Password: cfg.RedisPassword,
-			DB:       cfg.RedisDB,
-		})
+		switch cfg.CacheBackend {
+		case CacheBackendMemcached:
+			// Config validation guarantees the server list parses.
+			servers, _ := parseMemcachedServers(cfg.MemcachedServers)
+			backend = NewMemcacheBackend(servers, cfg.MemcachedTimeout)
+		default:
+			redisClient = client
+			backend = &redisCacheBackend{client: redisClient}
+		}
+		if cfg.LocalCacheSize > 0 && cfg.LocalCacheTTL > 0 {
+			backend = newLocalCacheBackend(backend, cfg.LocalCacheSize, cfg.LocalCacheTTL, clock)
+		}
+	}
+
+	cm := &CacheManager{
+		backend:       backend,
+		redis:         redisClient,
+		enabled:       cfg.CacheEnabled,
+		pendingMaxAge: cfg.NamePendingMaxAge,
+
+		retryBaseBackoff: cfg.NameRetryBaseBackoff,
+		retryMaxBackoff:  cfg.NameRetryMaxBackoff,
+		earlyBeta:        cfg.CacheEarlyRefreshBeta,
+		clock:            clock,
+		random:           rand.Float64,
 	}
+	cm.SetDatabase(db)
+	return cm
+}
 
-	return &CacheManager{
-		redis:    redisClient,
-		database: db,
-		enabled:  cfg.CacheEnabled,
+// SetDatabase makes db the fallback for summoner names and the persistent
+// store behind Persist and Restore.
+func (cm *CacheManager) SetDatabase(db *DatabaseManager) {
+	cm.database = db
+	cm.persistent = nil
+	if db != nil && db.Enabled {
+		cm.persistent = db
 	}
 }
 
+func (cm *CacheManager) Ping(ctx context.Context) error {
+	if !cm.enabled || cm.backend == nil {
+		return nil
+	}
+	return cm.backend.Ping(ctx)
+}
+
 func (cm *CacheManager) Get(ctx context.Context, key string, result interface{}) error {
-	if !cm.enabled {
-		return redis.Nil
+	if !cm.enabled || cm.backend == nil {
+		return ErrCacheMiss
 	}
 
-	data, err := cm.redis.Get(ctx, key).Result()
+	ctx, span := startCacheSpan(ctx, "get", key)
+	defer span.End()
+
+	data, err := cm.backend.Get(ctx, key)
 	if err != nil {
+		if err != ErrCacheMiss {
+			recordSpanError(span, err)
+		}
+		span.SetAttributes(attribute.Bool("cache.hit", false))
 		return err
 	}
+	span.SetAttributes(attribute.Bool("cache.hit", true))
 
-	return json.Unmarshal([]byte(data), result)
+	return json.Unmarshal(data, result)
 }
 
 func (cm *CacheManager) Set(ctx context.Context, key string, data interface{}, ttl time.Duration) error {
-	if !cm.enabled {
+	if !cm.enabled || cm.backend == nil {
 		return nil
 	}
 
+	ctx, span := startCacheSpan(ctx, "set", key)
+	defer span.End()
+
 	jsonData, err := json.Marshal(data)
 	if err != nil {
+		recordSpanError(span, err)
 		return err
 	}
 
-	return cm.redis.Set(ctx, key, jsonData, ttl).Err()
+	err = cm.backend.Set(ctx, key, jsonData, ttl)
+	recordSpanError(span, err)
+	return err
 }
 
+func (cm *CacheManager) Delete(ctx context.Context, keys ...string) error {
+	if !cm.enabled || cm.backend == nil {
+		return nil
+	}
+	return cm.backend.Delete(ctx, keys...)
+}
+
+func (cm *CacheManager) SampleKeys(ctx context.Context, pattern string, count int) ([]string, error) {
+	if !cm.enabled || cm.redis == nil || count <= 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	var cursor uint64
+	for {
+		batch, next, err := cm.redis.Scan(ctx, cursor, pattern, 500).Result()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, batch...)
+		cursor = next
+		if cursor == 0 || len(keys) >= count*20 {
+			break
+		}
+	}
+
+	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+	if len(keys) > count {
+		keys = keys[:count]
+	}
+	return keys, nil
+}
+
+// cacheKeyTypes lists the first segment of every key built with Key. The
+// cache pruner deletes tft:* keys whose type is not listed here, so a new key
+// type must be added before it is written and a retired one removed.
+var cacheKeyTypes = []string{
+	"summoner", "account_puuid", "account_name", "account_name_miss",
+	"challenger", "grandmaster", "master", "entries", "league_by_puuid", "match",
+	"summoner_name", "summoner_name_pending", "summoner_name_failures",
+	"summoner_name_index", "summoner_name_index_seen", "summoner_name_index_member",
+	"summoner_profile", "placements", "response", "metrics", "lock", "shard", "dedup", "meta",
+}
+
+// cacheKeyType returns the segment after "tft:", e.g. "summoner" for
+// tft:summoner:br1:<puuid>.
+func cacheKeyType(key string) string {
+	rest := strings.TrimPrefix(key, "tft:")
+	if i := strings.Index(rest, ":"); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
+
+// ScanKeys runs one SCAN step; it returns nothing without the Redis backend.
+func (cm *CacheManager) ScanKeys(ctx context.Context, cursor uint64, pattern string, count int64) ([]string, uint64, error) {
+	if !cm.enabled || cm.redis == nil {
+		return nil, 0, nil
+	}
+	return cm.redis.Scan(ctx, cursor, pattern, count).Result()
+}
+
+// Key joins parts under tft:, adding the schema version of the key type
+// (parts[0]) once it has been bumped past 1; see cacheSchemaVersions.
 func (cm *CacheManager) Key(parts ...string) string {
 	key := "tft"
-	for _, part := range parts {
+	for i, part := range parts {
 		key = fmt.Sprintf("%s:%s", key, part)
+		if i == 0 && cacheSchemaVersions[part] > 1 {
+			key = fmt.Sprintf("%s:v%d", key, cacheSchemaVersions[part])
+		}
 	}
 	return key
 }
 
 func (cm *CacheManager) GetSummonerName(ctx context.Context, puuid string) (string, error) {
-	// Try Redis first
-	if cm.enabled && cm.redis != nil {
+	ctx, span := startCacheSpan(ctx, "get_summoner_name", cm.Key("summoner_name", puuid))
+	defer span.End()
+
+	// Try the cache first
+	if cm.enabled && cm.backend != nil {
 		key := cm.Key("summoner_name", puuid)
-		name, err := cm.redis.Get(ctx, key).Result()
-		if err == nil && name != "" && name != "Loading..." {
-			return name, nil
+		name, err := cm.backend.Get(ctx, key)
+		if err == nil && len(name) > 0 {
+			return string(name), nil
 		}
 	}
 
-	// Try PostgreSQL if Redis fails or has Loading...
+	// Try PostgreSQL if the cache misses
 	if cm.database != nil && cm.database.Enabled {
-		name, err := cm.database.GetSummonerName(puuid)
+		name, err := cm.database.GetSummonerName(ctx, puuid)
 		if err == nil && name != "" {
-			// Cache the result in Redis for next time
-			if cm.enabled && cm.redis != nil {
+			// Cache the result for next time
+			if cm.enabled && cm.backend != nil {
 				key := cm.Key("summoner_name", puuid)
-				cm.redis.Set(ctx, key, name, 24*time.Hour)
+				cm.backend.Set(ctx, key, []byte(name), 24*time.Hour)
 			}
 			return name, nil
 		}
 	}
 
-	return "", redis.Nil
+	return "", ErrCacheMiss
+}
+
+// GetSummonerNames resolves many names with one cache round trip and, for
+// the misses, one database query. PUUIDs without a name are left out.
+func (cm *CacheManager) GetSummonerNames(ctx context.Context, puuids []string) (map[string]string, error) {
+	names := make(map[string]string, len(puuids))
+	if len(puuids) == 0 {
+		return names, nil
+	}
+
+	ctx, span := startCacheSpan(ctx, "get_summoner_names", cm.Key("summoner_name"))
+	defer span.End()
+	span.SetAttributes(attribute.Int("cache.keys", len(puuids)))
+
+	missing := puuids
+	if cm.enabled && cm.backend != nil {
+		keys := make([]string, len(puuids))
+		for i, puuid := range puuids {
+			keys[i] = cm.Key("summoner_name", puuid)
+		}
+		values, err := cm.backend.GetMulti(ctx, keys)
+		if err != nil {
+			recordSpanError(span, err)
+		}
+
+		missing = make([]string, 0, len(puuids))
+		for i, puuid := range puuids {
+			if name := values[keys[i]]; len(name) > 0 {
+				names[puuid] = string(name)
+			} else {
+				missing = append(missing, puuid)
+			}
+		}
+	}
+
+	if len(missing) == 0 || cm.database == nil || !cm.database.Enabled {
+		return names, nil
+	}
+
+	stored, err := cm.database.GetSummonerNames(ctx, missing)
+	if err != nil {
+		recordSpanError(span, err)
+		return names, err
+	}
+	for puuid, name := range stored {
+		names[puuid] = name
+		if cm.enabled && cm.backend != nil {
+			cm.backend.Set(ctx, cm.Key("summoner_name", puuid), []byte(name), 24*time.Hour)
+		}
+	}
+	return names, nil
 }
 
 func (cm *CacheManager) SetSummonerName(ctx context.Context, puuid, name string) error {
-	// Save to Redis
-	if cm.enabled && cm.redis != nil {
+	// Save to the cache
+	if cm.enabled && cm.backend != nil {
 		key := cm.Key("summoner_name", puuid)
-		cm.redis.Set(ctx, key, name, 24*time.Hour)
+		cm.backend.Set(ctx, key, []byte(name), 24*time.Hour)
+		cm.IndexSummonerName(ctx, puuid, name)
 	}
 
 	// Save to PostgreSQL
 	if cm.database != nil && cm.database.Enabled {
 		gameName, tagLine := parseName(name)
-		return cm.database.SetSummonerName(puuid, gameName, tagLine, "", "BR1")
+		return cm.database.SetSummonerName(ctx, puuid, gameName, tagLine, "", "BR1")
 	}
 
 	return nil
 }
 
+func (cm *CacheManager) GetSummonerNamePending(ctx context.Context, puuid string) (*SummonerNamePending, error) {
+	var pending SummonerNamePending
+	if err := cm.Get(ctx, cm.Key("summoner_name_pending", puuid), &pending); err != nil {
+		return nil, err
+	}
+	return &pending, nil
+}
+
+// PendingSummonerNames returns which of the PUUIDs already have a name
+// lookup in flight, in one round trip.
+func (cm *CacheManager) PendingSummonerNames(ctx context.Context, puuids []string) map[string]bool {
+	pending := make(map[string]bool)
+	if !cm.enabled || cm.backend == nil || len(puuids) == 0 {
+		return pending
+	}
+
+	keys := make([]string, len(puuids))
+	for i, puuid := range puuids {
+		keys[i] = cm.Key("summoner_name_pending", puuid)
+	}
+	values, _ := cm.backend.GetMulti(ctx, keys)
+	for i, puuid := range puuids {
+		if _, ok := values[keys[i]]; ok {
+			pending[puuid] = true
+		}
+	}
+	return pending
+}
+
+func (cm *CacheManager) MarkSummonerNamePending(ctx context.Context, puuid string) (*SummonerNamePending, error) {
+	pending := &SummonerNamePending{
+		Status:     NameStatusPending,
+		EnqueuedAt: cm.clock.Now().UTC(),
+		Attempts:   1,
+	}
+
+	var failures SummonerNameFailures
+	if err := cm.Get(ctx, cm.Key("summoner_name_failures", puuid), &failures); err == nil {
+		pending.Attempts = failures.Count + 1
+	}
+
+	return pending, cm.Set(ctx, cm.Key("summoner_name_pending", puuid), pending, cm.pendingMaxAge)
+}
+
+func (cm *CacheManager) MarkSummonerNameFailed(ctx context.Context, puuid string) (time.Duration, error) {
+	failuresKey := cm.Key("summoner_name_failures", puuid)
+
+	var failures SummonerNameFailures
+	cm.Get(ctx, failuresKey, &failures)
+	failures.Count++
+	failures.LastFailure = cm.clock.Now().UTC()
+
+	if err := cm.Set(ctx, failuresKey, failures, cm.retryMaxBackoff*2); err != nil {
+		return 0, err
+	}
+
+	backoff := nameRetryBackoff(failures.Count, cm.retryBaseBackoff, cm.retryMaxBackoff)
+
+	pending, err := cm.GetSummonerNamePending(ctx, puuid)
+	if err != nil {
+		pending = &SummonerNamePending{EnqueuedAt: failures.LastFailure, Attempts: failures.Count}
+	}
+	pending.Status = NameStatusFailed
+	pending.RetryAfter = failures.LastFailure.Add(backoff)
+
+	return backoff, cm.Set(ctx, cm.Key("summoner_name_pending", puuid), pending, backoff)
+}
+
+func (cm *CacheManager) ClearSummonerNamePending(ctx context.Context, puuid string) error {
+	return cm.Delete(ctx,
+		cm.Key("summoner_name_pending", puuid),
+		cm.Key("summoner_name_failures", puuid),
+	)
+}
+
+func nameRetryBackoff(failures int, base, max time.Duration) time.Duration {
+	if failures < 1 {
+		failures = 1
+	}
+
+	backoff := base
+	for i := 1; i < failures; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+// IndexSummonerName adds name to the autocomplete index. Every write also
+// scores the member by time in summoner_name_index_seen, so
+// PruneSummonerNameIndex can drop names not seen for summonerCacheMaxAge,
+// the age past which names are no longer served; the member keys expire on
+// their own after the same period.
+func (cm *CacheManager) IndexSummonerName(ctx context.Context, puuid, name string) error {
+	if !cm.enabled || cm.redis == nil || name == "" {
+		return nil
+	}
+
+	indexKey := cm.Key("summoner_name_index")
+	seenKey := cm.Key("summoner_name_index_seen")
+	memberKey := cm.Key("summoner_name_index_member", puuid)
+	member := buildNameIndexMember(name, puuid)
+
+	previous, err := cm.redis.Get(ctx, memberKey).Result()
+
+	pipe := cm.redis.TxPipeline()
+	if err == nil && previous != "" && previous != member {
+		pipe.ZRem(ctx, indexKey, previous)
+		pipe.ZRem(ctx, seenKey, previous)
+	}
+	pipe.ZAdd(ctx, indexKey, redis.Z{Score: 0, Member: member})
+	pipe.ZAdd(ctx, seenKey, redis.Z{Score: float64(cm.clock.Now().Unix()), Member: member})
+	pipe.Set(ctx, memberKey, member, summonerCacheMaxAge)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// PruneSummonerNameIndex removes the names last indexed before cutoff from
+// the autocomplete index and returns how many were removed.
+func (cm *CacheManager) PruneSummonerNameIndex(ctx context.Context, cutoff time.Time) (int, error) {
+	if !cm.enabled || cm.redis == nil {
+		return 0, nil
+	}
+
+	seenKey := cm.Key("summoner_name_index_seen")
+	stale, err := cm.redis.ZRangeByScore(ctx, seenKey, &redis.ZRangeBy{
+		Min: "-inf",
+		Max: "(" + strconv.FormatInt(cutoff.Unix(), 10),
+	}).Result()
+	if err != nil || len(stale) == 0 {
+		return 0, err
+	}
+
+	members := make([]interface{}, len(stale))
+	for i, member := range stale {
+		members[i] = member
+	}
+	pipe := cm.redis.TxPipeline()
+	pipe.ZRem(ctx, cm.Key("summoner_name_index"), members...)
+	pipe.ZRem(ctx, seenKey, members...)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+func (cm *CacheManager) SearchSummonerNames(ctx context.Context, prefix string, limit int) ([]SummonerNameMatch, error) {
+	if !cm.enabled || cm.redis == nil {
+		return []SummonerNameMatch{}, nil
+	}
+
+	normalized := normalizeIndexName(prefix)
+	members, err := cm.redis.ZRangeByLex(ctx, cm.Key("summoner_name_index"), &redis.ZRangeBy{
+		Min:   "[" + normalized,
+		Max:   "[" + normalized + "\xff",
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]SummonerNameMatch, 0, len(members))
+	for _, member := range members {
+		if match, ok := parseNameIndexMember(member); ok {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
+
+func normalizeIndexName(name string) string {
+	return strings.ToLower(normalizeRiotIDPart(name))
+}
+
+func buildNameIndexMember(name, puuid string) string {
+	return normalizeIndexName(name) + "\x00" + name + "\x00" + puuid
+}
+
+func parseNameIndexMember(member string) (SummonerNameMatch, bool) {
+	parts := strings.Split(member, "\x00")
+	if len(parts) != 3 {
+		return SummonerNameMatch{}, false
+	}
+
+	gameName, tagLine := parseName(parts[1])
+	return SummonerNameMatch{
+		PUUID:    parts[2],
+		Name:     parts[1],
+		GameName: gameName,
+		TagLine:  tagLine,
+	}, true
+}
+
 func parseName(fullName string) (gameName, tagLine string) {
 	parts := splitName(fullName)
 	if len(parts) == 2 {
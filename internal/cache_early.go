@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"time"
+)
+
+// earlyExpiryEntry wraps values written with SetEarly. Delta is how long the
+// value took to compute, ExpiresAt when the key expires in the backend.
+type earlyExpiryEntry struct {
+	Value     json.RawMessage `json:"value"`
+	DeltaMs   int64           `json:"delta_ms"`
+	ExpiresAt int64           `json:"expires_at"`
+}
+
+// SetEarly stores data like Set, along with delta, the time it took to
+// compute, so GetEarly can decide to refresh it before it expires.
+func (cm *CacheManager) SetEarly(ctx context.Context, key string, data interface{}, ttl, delta time.Duration) error {
+	if !cm.enabled || cm.backend == nil {
+		return nil
+	}
+
+	value, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return cm.Set(ctx, key, earlyExpiryEntry{
+		Value:     value,
+		DeltaMs:   delta.Milliseconds(),
+		ExpiresAt: cm.clock.Now().Add(ttl).UnixMilli(),
+	}, ttl)
+}
+
+// GetEarly reads a value written by SetEarly. refresh reports that the
+// caller should recompute the value although it is still cached: following
+// XFetch, each read refreshes with a probability that grows as the expiry
+// approaches and with how slow the value is to compute, so a popular key is
+// refreshed by one caller shortly before it expires instead of by every
+// caller at once right after. The cached value is decoded into result either
+// way, so a failed refresh can still serve it. With Redis, a short lock
+// keeps concurrent readers that draw an early refresh from all acting on it.
+//
+// Values written by Set are read as plain values that never refresh early.
+func (cm *CacheManager) GetEarly(ctx context.Context, key string, result interface{}) (refresh bool, err error) {
+	var raw json.RawMessage
+	if err := cm.Get(ctx, key, &raw); err != nil {
+		return false, err
+	}
+
+	var entry earlyExpiryEntry
+	if json.Unmarshal(raw, &entry) != nil || entry.ExpiresAt == 0 || entry.Value == nil {
+		return false, json.Unmarshal(raw, result)
+	}
+	if err := json.Unmarshal(entry.Value, result); err != nil {
+		return false, err
+	}
+
+	if !cm.shouldRefreshEarly(entry) {
+		return false, nil
+	}
+	return cm.claimEarlyRefresh(ctx, key, time.Duration(entry.DeltaMs)*time.Millisecond), nil
+}
+
+// shouldRefreshEarly is the XFetch test: now - delta*beta*ln(rand) >= expiry.
+func (cm *CacheManager) shouldRefreshEarly(entry earlyExpiryEntry) bool {
+	if cm.earlyBeta <= 0 || entry.DeltaMs <= 0 {
+		return false
+	}
+
+	r := cm.random()
+	if r <= 0 {
+		r = math.SmallestNonzeroFloat64
+	}
+	gap := -float64(entry.DeltaMs) * cm.earlyBeta * math.Log(r)
+	return float64(cm.clock.Now().UnixMilli())+gap >= float64(entry.ExpiresAt)
+}
+
+// claimEarlyRefresh lets one caller refresh key; the lock outlives a
+// refresh that takes a few times delta and then frees the key for another
+// try. Without Redis every caller that draws a refresh is let through.
+func (cm *CacheManager) claimEarlyRefresh(ctx context.Context, key string, delta time.Duration) bool {
+	if cm.redis == nil {
+		return true
+	}
+
+	lockTTL := max(4*delta, time.Second)
+	claimed, err := cm.redis.SetNX(ctx, cm.Key("lock", "refresh", strings.TrimPrefix(key, "tft:")), 1, lockTTL).Result()
+	if err != nil {
+		return false
+	}
+	return claimed
+}
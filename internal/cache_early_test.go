@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheManager_ShouldRefreshEarly(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	expiresAt := now.Add(500 * time.Millisecond).UnixMilli()
+
+	tests := []struct {
+		name     string
+		beta     float64
+		delta    int64
+		random   float64
+		expected bool
+	}{
+		// -ln(0.5) * 1000ms ≈ 693ms, past the expiry 500ms away.
+		{name: "unlucky draw near expiry", beta: 1, delta: 1000, random: 0.5, expected: true},
+		// -ln(0.9) * 1000ms ≈ 105ms.
+		{name: "lucky draw near expiry", beta: 1, delta: 1000, random: 0.9, expected: false},
+		{name: "higher beta refreshes earlier", beta: 5, delta: 1000, random: 0.9, expected: true},
+		{name: "fast recompute", beta: 1, delta: 10, random: 0.5, expected: false},
+		{name: "disabled", beta: 0, delta: 1000, random: 0.01, expected: false},
+		{name: "zero draw", beta: 1, delta: 1000, random: 0, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &CacheManager{earlyBeta: tt.beta, clock: newFakeClock(now), random: func() float64 { return tt.random }}
+			entry := earlyExpiryEntry{DeltaMs: tt.delta, ExpiresAt: expiresAt}
+			if got := cm.shouldRefreshEarly(entry); got != tt.expected {
+				t.Errorf("shouldRefreshEarly() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func newEarlyRefreshClient(t *testing.T, fixturesDir string, random float64) (*RiotAPIClient, *CacheManager) {
+	server := newFakeMemcached(t)
+	cfg := &Config{
+		RiotMode:              RiotModeFixtures,
+		RiotFixturesDir:       fixturesDir,
+		RiotRegion:            "BR1",
+		RiotBaseURL:           fixtureBaseURL,
+		RiotHTTPTimeout:       time.Second,
+		CacheEnabled:          true,
+		CacheBackend:          CacheBackendMemcached,
+		MemcachedServers:      server.Addr(),
+		MemcachedTimeout:      time.Second,
+		CacheEarlyRefreshBeta: 1,
+	}
+	cache := NewCacheManager(cfg, nil, nil, nil)
+	cache.random = func() float64 { return random }
+	return NewRiotAPIClient(cfg, cache, newTestLogger(), nil), cache
+}
+
+func TestCacheManager_GetEarly(t *testing.T) {
+	_, cache := newEarlyRefreshClient(t, t.TempDir(), 0.5)
+	ctx := t.Context()
+
+	var value map[string]int
+	if _, err := cache.GetEarly(ctx, "tft:test:missing", &value); err != ErrCacheMiss {
+		t.Errorf("GetEarly(missing) error = %v, expected ErrCacheMiss", err)
+	}
+
+	cache.SetEarly(ctx, "tft:test:fresh", map[string]int{"a": 1}, time.Hour, time.Second)
+	refresh, err := cache.GetEarly(ctx, "tft:test:fresh", &value)
+	if err != nil || refresh || value["a"] != 1 {
+		t.Errorf("GetEarly(fresh) = %v, %v, %v, expected the value without a refresh", value, refresh, err)
+	}
+
+	cache.SetEarly(ctx, "tft:test:expiring", map[string]int{"b": 2}, 100*time.Millisecond, time.Second)
+	refresh, err = cache.GetEarly(ctx, "tft:test:expiring", &value)
+	if err != nil || !refresh || value["b"] != 2 {
+		t.Errorf("GetEarly(expiring) = %v, %v, %v, expected the value and a refresh", value, refresh, err)
+	}
+
+	cache.Set(ctx, "tft:test:plain", []int{1, 2}, time.Hour)
+	var plain []int
+	refresh, err = cache.GetEarly(ctx, "tft:test:plain", &plain)
+	if err != nil || refresh || len(plain) != 2 {
+		t.Errorf("GetEarly(plain) = %v, %v, %v, expected values written by Set to read as before", plain, refresh, err)
+	}
+}
+
+func TestHighTierLeague_EarlyRefresh(t *testing.T) {
+	tests := []struct {
+		name        string
+		fixturesDir string
+		expected    string
+	}{
+		{name: "refreshed from riot", fixturesDir: "../fixtures/riot", expected: "fixture"},
+		{name: "riot failing keeps the cached league", fixturesDir: "", expected: "cached"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := tt.fixturesDir
+			if dir == "" {
+				dir = t.TempDir()
+			}
+			client, cache := newEarlyRefreshClient(t, dir, 0.01)
+			cache.SetEarly(t.Context(), cache.Key("challenger", "BR1"), ChallengerLeague{Name: "cached", Tier: "CHALLENGER"}, 100*time.Millisecond, time.Second)
+
+			league, err := client.GetChallengerLeague(t.Context())
+			if err != nil {
+				t.Fatalf("GetChallengerLeague() error = %v", err)
+			}
+			got := "fixture"
+			if league.Name == "cached" {
+				got = "cached"
+			}
+			if got != tt.expected {
+				t.Errorf("served the %s league, expected the %s one", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const cachePruneBatchSize = 100
+
+// CachePruner deletes tft:* keys whose type is no longer written by the
+// service, e.g. after a key layout change, or whose schema version is not the
+// current one for their type. Each run scans at most maxKeys
+// keys and the SCAN cursor carries over to the next run, so large keyspaces
+// are covered over several runs without a long blocking pass. Each run also
+// drops names not indexed within summonerCacheMaxAge from the autocomplete
+// index.
+type CachePruner struct {
+	cache     *CacheManager
+	metrics   *MetricsCollector
+	logger    *Logger
+	interval  time.Duration
+	scanCount int64
+	maxKeys   int
+	known     map[string]bool
+	cursor    uint64
+}
+
+func NewCachePruner(cfg *Config, cache *CacheManager, metrics *MetricsCollector, logger *Logger) *CachePruner {
+	known := make(map[string]bool, len(cacheKeyTypes)+1)
+	for _, keyType := range cacheKeyTypes {
+		known[keyType] = true
+	}
+	// Rate limit counters share the Redis instance and the tft: namespace by
+	// default.
+	if strings.HasPrefix(cfg.RateLimitRedisPrefix, "tft:") {
+		known[cacheKeyType(cfg.RateLimitRedisPrefix)] = true
+	}
+
+	scanCount := int64(cfg.CachePruneScanCount)
+	if scanCount < 1 {
+		scanCount = 500
+	}
+	maxKeys := cfg.CachePruneMaxKeys
+	if maxKeys < 1 {
+		maxKeys = 10000
+	}
+
+	return &CachePruner{
+		cache:     cache,
+		metrics:   metrics,
+		logger:    logger,
+		interval:  cfg.CachePruneInterval,
+		scanCount: scanCount,
+		maxKeys:   maxKeys,
+		known:     known,
+	}
+}
+
+func (cp *CachePruner) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "cache_prune",
+		Interval: cp.interval,
+		Run:      cp.Run,
+	}
+}
+
+func (cp *CachePruner) orphaned(key string) bool {
+	return !cp.known[cacheKeyType(key)] || cacheKeyStale(key)
+}
+
+func (cp *CachePruner) Run(ctx context.Context) error {
+	start := time.Now()
+	scanned, deleted := 0, 0
+	deletedByType := make(map[string]int)
+
+	var batch []string
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := cp.cache.Delete(ctx, batch...); err != nil {
+			return err
+		}
+		for _, key := range batch {
+			deletedByType[cacheKeyType(key)]++
+		}
+		deleted += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	var err error
+	for scanned < cp.maxKeys {
+		var keys []string
+		var next uint64
+		keys, next, err = cp.cache.ScanKeys(ctx, cp.cursor, "tft:*", cp.scanCount)
+		if err != nil {
+			break
+		}
+		cp.cursor = next
+		scanned += len(keys)
+
+		for _, key := range keys {
+			if !cp.orphaned(key) {
+				continue
+			}
+			batch = append(batch, key)
+			if len(batch) >= cachePruneBatchSize {
+				if err = flush(); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil || next == 0 {
+			break
+		}
+	}
+	if err == nil {
+		err = flush()
+	}
+	indexPruned := 0
+	if err == nil {
+		indexPruned, err = cp.cache.PruneSummonerNameIndex(ctx, time.Now().Add(-summonerCacheMaxAge))
+	}
+
+	if cp.metrics != nil {
+		for keyType, count := range deletedByType {
+			cp.metrics.RecordCachePrune(keyType, count)
+		}
+	}
+
+	if err != nil {
+		cp.logger.Warn("cache_prune_failed").
+			Component("cache_pruner").
+			Operation("prune").
+			Meta("scanned", scanned).
+			Meta("deleted", deleted).
+			Err(err).
+			Log()
+		return err
+	}
+
+	cp.logger.Info("cache_prune_completed").
+		Component("cache_pruner").
+		Operation("prune").
+		Meta("scanned", scanned).
+		Meta("deleted", deleted).
+		Meta("deleted_by_type", deletedByType).
+		Meta("name_index_pruned", indexPruned).
+		Meta("scan_complete", cp.cursor == 0).
+		Duration(time.Since(start)).
+		Log()
+	return nil
+}
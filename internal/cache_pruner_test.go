@@ -0,0 +1,50 @@
+package internal
+
+import "testing"
+
+func TestCacheKeyType(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{key: "tft:summoner:br1:abc", expected: "summoner"},
+		{key: "tft:summoner_name_index", expected: "summoner_name_index"},
+		{key: "tft:ratelimit:summoner:1", expected: "ratelimit"},
+		{key: "tft:", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if result := cacheKeyType(tt.key); result != tt.expected {
+				t.Errorf("cacheKeyType(%q) = %q, expected %q", tt.key, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCachePruner_Orphaned(t *testing.T) {
+	pruner := NewCachePruner(&Config{RateLimitRedisPrefix: "tft:ratelimit"}, nil, nil, newTestLogger())
+	cache := &CacheManager{}
+
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{key: cache.Key("summoner", "br1", "abc"), expected: false},
+		{key: cache.Key("response", "/league/challenger", ""), expected: false},
+		{key: cache.Key("metrics", "snapshot"), expected: false},
+		{key: cache.Key("summoner_name_index_seen"), expected: false},
+		{key: "tft:ratelimit:summoner:1", expected: false},
+		{key: "tft:summoner_v0:br1:abc", expected: true},
+		{key: "tft:league:br1", expected: true},
+		{key: "tft:summoner:v9:br1:abc", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if result := pruner.orphaned(tt.key); result != tt.expected {
+				t.Errorf("orphaned(%q) = %v, expected %v", tt.key, result, tt.expected)
+			}
+		})
+	}
+}
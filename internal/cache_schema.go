@@ -0,0 +1,122 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"strings"
+)
+
+// cacheSchemaVersions versions the JSON stored under each key type. Bump a
+// type's version whenever the struct cached under it changes shape: Key then
+// builds keys the old shape was never written to, so a new binary never reads
+// a value it would decode wrongly, and the leftovers are deleted by the
+// startup sweep and the cache pruner. Version 1 adds nothing to the key, so
+// keys written before versioning existed stay readable.
+var cacheSchemaVersions = map[string]int{
+	"summoner":         1,
+	"account_puuid":    1,
+	"account_name":     1,
+	"challenger":       1,
+	"grandmaster":      1,
+	"master":           1,
+	"entries":          1,
+	"league_by_puuid":  1,
+	"match":            1,
+	"summoner_profile": 1,
+	"placements":       1,
+	"response":         1,
+}
+
+// cacheKeyVersion reads the v<N> segment that follows the key type, and is 1
+// for keys without one.
+func cacheKeyVersion(key string) int {
+	segments := strings.SplitN(strings.TrimPrefix(key, "tft:"), ":", 3)
+	if len(segments) < 2 || len(segments[1]) < 2 || segments[1][0] != 'v' {
+		return 1
+	}
+	version, err := strconv.Atoi(segments[1][1:])
+	if err != nil || version < 2 {
+		return 1
+	}
+	return version
+}
+
+// cacheKeyStale reports whether key holds a schema version other than the
+// current one for its type.
+func cacheKeyStale(key string) bool {
+	version, ok := cacheSchemaVersions[cacheKeyType(key)]
+	return ok && cacheKeyVersion(key) != version
+}
+
+// InvalidateStaleSchemas deletes the keys of every type whose schema version
+// differs from the one recorded in Redis by the last instance that ran it,
+// then records the current versions. It returns the number of keys deleted
+// per type. Without the Redis backend there is nothing to scan; the versioned
+// keys alone keep old values from being read, and they expire with their TTL.
+func (cm *CacheManager) InvalidateStaleSchemas(ctx context.Context) (map[string]int, error) {
+	if !cm.enabled || cm.redis == nil {
+		return nil, nil
+	}
+
+	versionsKey := cm.Key("meta", "schema_versions")
+	recorded, err := cm.redis.HGetAll(ctx, versionsKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make(map[string]int)
+	for keyType, version := range cacheSchemaVersions {
+		current := strconv.Itoa(version)
+		if recorded[keyType] == current {
+			continue
+		}
+		// Nothing was ever written under another version.
+		if _, ok := recorded[keyType]; !ok && version == 1 {
+			if err := cm.redis.HSet(ctx, versionsKey, keyType, current).Err(); err != nil {
+				return deleted, err
+			}
+			continue
+		}
+
+		count, err := cm.deleteStaleKeys(ctx, keyType)
+		if count > 0 {
+			deleted[keyType] = count
+		}
+		if err != nil {
+			return deleted, err
+		}
+		if err := cm.redis.HSet(ctx, versionsKey, keyType, current).Err(); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+func (cm *CacheManager) deleteStaleKeys(ctx context.Context, keyType string) (int, error) {
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := cm.redis.Scan(ctx, cursor, "tft:"+keyType+":*", 1000).Result()
+		if err != nil {
+			return deleted, err
+		}
+
+		var stale []string
+		for _, key := range keys {
+			if cacheKeyStale(key) {
+				stale = append(stale, key)
+			}
+		}
+		if len(stale) > 0 {
+			if err := cm.Delete(ctx, stale...); err != nil {
+				return deleted, err
+			}
+			deleted += len(stale)
+		}
+
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
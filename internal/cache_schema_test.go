@@ -0,0 +1,74 @@
+package internal
+
+import "testing"
+
+func bumpCacheSchema(t *testing.T, keyType string, version int) {
+	t.Helper()
+	previous := cacheSchemaVersions[keyType]
+	cacheSchemaVersions[keyType] = version
+	t.Cleanup(func() { cacheSchemaVersions[keyType] = previous })
+}
+
+func TestCacheManager_KeySchemaVersion(t *testing.T) {
+	cache := &CacheManager{}
+	if key := cache.Key("match", "BR1_1"); key != "tft:match:BR1_1" {
+		t.Errorf("version 1 key = %q, expected tft:match:BR1_1", key)
+	}
+
+	bumpCacheSchema(t, "match", 2)
+	key := cache.Key("match", "BR1_1")
+	if key != "tft:match:v2:BR1_1" {
+		t.Errorf("version 2 key = %q, expected tft:match:v2:BR1_1", key)
+	}
+	if cacheKeyType(key) != "match" {
+		t.Errorf("cacheKeyType(%q) = %q, expected match", key, cacheKeyType(key))
+	}
+	if key := cache.Key("summoner", "br1", "abc"); key != "tft:summoner:br1:abc" {
+		t.Errorf("unbumped type key = %q, expected tft:summoner:br1:abc", key)
+	}
+}
+
+func TestCacheKeyStale(t *testing.T) {
+	bumpCacheSchema(t, "match", 3)
+
+	tests := []struct {
+		key      string
+		version  int
+		expected bool
+	}{
+		{key: "tft:match:v3:BR1_1", version: 3, expected: false},
+		{key: "tft:match:v2:BR1_1", version: 2, expected: true},
+		{key: "tft:match:BR1_1", version: 1, expected: true},
+		{key: "tft:summoner:br1:abc", version: 1, expected: false},
+		{key: "tft:summoner:v:abc", version: 1, expected: false},
+		{key: "tft:lock:refresh:match:BR1_1", version: 1, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if version := cacheKeyVersion(tt.key); version != tt.version {
+				t.Errorf("cacheKeyVersion(%q) = %d, expected %d", tt.key, version, tt.version)
+			}
+			if stale := cacheKeyStale(tt.key); stale != tt.expected {
+				t.Errorf("cacheKeyStale(%q) = %v, expected %v", tt.key, stale, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCacheSchemaVersions_KnownTypes(t *testing.T) {
+	pruner := NewCachePruner(&Config{}, nil, nil, newTestLogger())
+	for keyType := range cacheSchemaVersions {
+		if !pruner.known[keyType] {
+			t.Errorf("versioned key type %q is missing from cacheKeyTypes", keyType)
+		}
+	}
+}
+
+func TestInvalidateStaleSchemas_WithoutRedis(t *testing.T) {
+	cache := NewCacheManager(&Config{CacheEnabled: true, CacheBackend: CacheBackendMemcached, MemcachedServers: "127.0.0.1:1"}, nil, nil, nil)
+	deleted, err := cache.InvalidateStaleSchemas(t.Context())
+	if err != nil || deleted != nil {
+		t.Errorf("InvalidateStaleSchemas() = %v, %v; expected nothing to do without Redis", deleted, err)
+	}
+}
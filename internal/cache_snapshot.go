@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// persistedKeyTypes lists the key types Persist also writes to the
+// persistent store, so a cache miss (Redis restarted, flushed or evicting)
+// can be answered from Postgres before going to Riot.
+var persistedKeyTypes = map[string]bool{
+	"challenger":    true,
+	"grandmaster":   true,
+	"master":        true,
+	"entries":       true,
+	"account_puuid": true,
+}
+
+// cacheSnapshotRetention is the longest TTL a persisted key type is restored
+// with (accounts, 6h). Restore never serves an older snapshot, so the
+// SummonerCachePruner deletes those rows.
+const cacheSnapshotRetention = 6 * time.Hour
+
+// Persist saves data under key in the persistent store; like the cache it is
+// off with CACHE_ENABLED=false. Failures are only recorded on the span: the
+// value is already cached and Riot remains the source of truth.
+func (cm *CacheManager) Persist(ctx context.Context, key string, data interface{}) {
+	if !cm.enabled || cm.persistent == nil || !persistedKeyTypes[cacheKeyType(key)] {
+		return
+	}
+
+	ctx, span := startCacheSpan(ctx, "persist", key)
+	defer span.End()
+
+	value, err := json.Marshal(data)
+	if err == nil {
+		err = cm.persistent.SaveCacheSnapshot(ctx, key, value, cm.clock.Now().UTC())
+	}
+	recordSpanError(span, err)
+}
+
+// Restore reads key from the persistent store on a cache miss. A snapshot is
+// only used while it is younger than ttl, the TTL the value is cached with,
+// so the store never serves anything the cache would not have; it is copied
+// back into the cache for the rest of that TTL.
+func (cm *CacheManager) Restore(ctx context.Context, key string, result interface{}, ttl time.Duration) error {
+	if !cm.enabled || cm.persistent == nil || !persistedKeyTypes[cacheKeyType(key)] {
+		return ErrCacheMiss
+	}
+
+	ctx, span := startCacheSpan(ctx, "restore", key)
+	defer span.End()
+
+	value, fetchedAt, err := cm.persistent.LoadCacheSnapshot(ctx, key)
+	if err != nil {
+		if err != ErrCacheMiss {
+			recordSpanError(span, err)
+		}
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return err
+	}
+
+	remaining := ttl - cm.clock.Now().Sub(fetchedAt)
+	if remaining <= 0 {
+		span.SetAttributes(attribute.Bool("cache.hit", false))
+		return ErrCacheMiss
+	}
+	if err := json.Unmarshal(value, result); err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	span.SetAttributes(attribute.Bool("cache.hit", true))
+
+	if cm.backend != nil {
+		cm.backend.Set(ctx, key, value, remaining)
+	}
+	return nil
+}
+
+// LoadCacheSnapshot reads the primary, like GetLastKnownLeague: it runs on
+// cache misses that would otherwise go to Riot, and a lagging replica would
+// hand back snapshots the TTL check then throws away.
+func (dm *DatabaseManager) LoadCacheSnapshot(ctx context.Context, key string) ([]byte, time.Time, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "load_cache_snapshot")
+	defer span.End()
+
+	var value string
+	var fetchedAt time.Time
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		SELECT value, fetched_at FROM cache_snapshots WHERE cache_key = $1
+	`), key).Scan(&value, &fetchedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, time.Time{}, ErrCacheMiss
+	}
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, time.Time{}, err
+	}
+	return []byte(value), fetchedAt, nil
+}
+
+func (dm *DatabaseManager) SaveCacheSnapshot(ctx context.Context, key string, value []byte, fetchedAt time.Time) error {
+	if dm.ReadOnly {
+		return nil
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "save_cache_snapshot")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		INSERT INTO cache_snapshots (cache_key, value, fetched_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (cache_key) DO UPDATE SET
+			value = EXCLUDED.value,
+			fetched_at = EXCLUDED.fetched_at
+	`), key, string(value), fetchedAt)
+	recordSpanError(span, err)
+	return err
+}
+
+// DeleteStaleCacheSnapshots deletes up to limit snapshots fetched before
+// cutoff, oldest first, like DeleteStaleSummonerCache.
+func (dm *DatabaseManager) DeleteStaleCacheSnapshots(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	if !dm.Enabled {
+		return 0, nil
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "delete_stale_cache_snapshots")
+	defer span.End()
+
+	result, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		DELETE FROM cache_snapshots
+		WHERE cache_key IN (
+			SELECT cache_key FROM cache_snapshots
+			WHERE fetched_at < $1
+			ORDER BY fetched_at
+			LIMIT $2
+		)
+	`), cutoff, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
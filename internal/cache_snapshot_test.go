@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapStore is a PersistentStore over a map.
+type mapStore struct {
+	mu        sync.Mutex
+	values    map[string][]byte
+	fetchedAt map[string]time.Time
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{values: make(map[string][]byte), fetchedAt: make(map[string]time.Time)}
+}
+
+func (ms *mapStore) LoadCacheSnapshot(ctx context.Context, key string) ([]byte, time.Time, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	value, ok := ms.values[key]
+	if !ok {
+		return nil, time.Time{}, ErrCacheMiss
+	}
+	return value, ms.fetchedAt[key], nil
+}
+
+func (ms *mapStore) SaveCacheSnapshot(ctx context.Context, key string, value []byte, fetchedAt time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.values[key] = value
+	ms.fetchedAt[key] = fetchedAt
+	return nil
+}
+
+func newPersistentCache(clock Clock, store PersistentStore) (*CacheManager, *mapBackend) {
+	backend := newMapBackend()
+	return &CacheManager{backend: backend, persistent: store, enabled: true, clock: clock}, backend
+}
+
+func TestCacheManager_PersistRestore(t *testing.T) {
+	tests := []struct {
+		name      string
+		keyType   string
+		age       time.Duration
+		disabled  bool
+		restored  bool
+		persisted bool
+	}{
+		{name: "fresh league snapshot", keyType: "challenger", age: 10 * time.Minute, restored: true, persisted: true},
+		{name: "snapshot older than the ttl", keyType: "entries", age: 31 * time.Minute, persisted: true},
+		{name: "type not persisted", keyType: "match", age: time.Minute},
+		{name: "cache disabled", keyType: "account_puuid", age: time.Minute, disabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+			store := newMapStore()
+			cache, backend := newPersistentCache(clock, store)
+			cache.enabled = !tt.disabled
+			key := cache.Key(tt.keyType, "BR1", "p1")
+
+			cache.Persist(t.Context(), key, map[string]string{"puuid": "p1"})
+			if _, _, err := store.LoadCacheSnapshot(t.Context(), key); (err == nil) != tt.persisted {
+				t.Fatalf("persisted = %v, expected %v", err == nil, tt.persisted)
+			}
+
+			clock.Advance(tt.age)
+			var result map[string]string
+			err := cache.Restore(t.Context(), key, &result, 30*time.Minute)
+			if tt.restored != (err == nil) {
+				t.Fatalf("Restore() error = %v, expected restored = %v", err, tt.restored)
+			}
+			if !tt.restored {
+				return
+			}
+			if result["puuid"] != "p1" {
+				t.Errorf("result = %v", result)
+			}
+			if _, err := backend.Get(t.Context(), key); err != nil {
+				t.Error("expected the restored snapshot to be copied back into the cache")
+			}
+		})
+	}
+}
+
+func TestAccountByPUUID_ServedFromPersistentStore(t *testing.T) {
+	client := newCachedFixtureClient(t.TempDir())
+	store := newMapStore()
+	client.cache, _ = newPersistentCache(SystemClock, store)
+
+	key := client.cache.Key("account_puuid", client.region, "p1")
+	client.cache.Persist(t.Context(), key, AccountData{PUUID: "p1", GameName: "Stored", TagLine: "BR1"})
+
+	// The fixture directory is empty, so reaching Riot would fail.
+	account, err := client.GetAccountByPUUID(t.Context(), "p1")
+	if err != nil {
+		t.Fatalf("GetAccountByPUUID() error = %v", err)
+	}
+	if account.GameName != "Stored" {
+		t.Errorf("account = %+v, expected the stored snapshot", account)
+	}
+
+	if _, err := client.GetAccountByPUUID(t.Context(), "p2"); err == nil {
+		t.Error("expected a miss in both tiers to reach Riot and fail")
+	}
+}
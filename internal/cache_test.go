@@ -1,6 +1,10 @@
 package internal
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+	"time"
+)
 
 func TestCacheManager_Key(t *testing.T) {
 	cm := &CacheManager{}
@@ -136,3 +140,132 @@ func TestSplitName(t *testing.T) {
 		})
 	}
 }
+
+func TestNameRetryBackoff(t *testing.T) {
+	base := 5 * time.Minute
+	max := time.Hour
+
+	tests := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{
+			name:     "zero failures uses base",
+			failures: 0,
+			expected: 5 * time.Minute,
+		},
+		{
+			name:     "first failure uses base",
+			failures: 1,
+			expected: 5 * time.Minute,
+		},
+		{
+			name:     "second failure doubles",
+			failures: 2,
+			expected: 10 * time.Minute,
+		},
+		{
+			name:     "fourth failure",
+			failures: 4,
+			expected: 40 * time.Minute,
+		},
+		{
+			name:     "capped at max",
+			failures: 10,
+			expected: time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := nameRetryBackoff(tt.failures, base, max)
+			if result != tt.expected {
+				t.Errorf("nameRetryBackoff() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNameIndexMember(t *testing.T) {
+	tests := []struct {
+		name         string
+		fullName     string
+		puuid        string
+		expectedGame string
+		expectedTag  string
+	}{
+		{
+			name:         "name with tag",
+			fullName:     "Player#BR1",
+			puuid:        "puuid-1",
+			expectedGame: "Player",
+			expectedTag:  "BR1",
+		},
+		{
+			name:         "name with spaces",
+			fullName:     "Big Player#NA1",
+			puuid:        "puuid-2",
+			expectedGame: "Big Player",
+			expectedTag:  "NA1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member := buildNameIndexMember(tt.fullName, tt.puuid)
+			match, ok := parseNameIndexMember(member)
+			if !ok {
+				t.Fatal("parseNameIndexMember() failed to parse member")
+			}
+			if match.PUUID != tt.puuid {
+				t.Errorf("PUUID = %v, expected %v", match.PUUID, tt.puuid)
+			}
+			if match.GameName != tt.expectedGame || match.TagLine != tt.expectedTag {
+				t.Errorf("name = %v#%v, expected %v#%v", match.GameName, match.TagLine, tt.expectedGame, tt.expectedTag)
+			}
+		})
+	}
+}
+
+func TestCacheManager_BatchNameLookups(t *testing.T) {
+	server := newFakeMemcached(t)
+	cfg := &Config{CacheEnabled: true, CacheBackend: CacheBackendMemcached, MemcachedServers: server.Addr(), MemcachedTimeout: time.Second, NamePendingMaxAge: time.Minute}
+	cache := NewCacheManager(cfg, nil, nil, nil)
+	ctx := t.Context()
+
+	cache.SetSummonerName(ctx, "puuid-a", "Alpha#BR1")
+	cache.SetSummonerName(ctx, "puuid-c", "Charlie#NA1")
+	cache.MarkSummonerNamePending(ctx, "puuid-b")
+
+	names, err := cache.GetSummonerNames(ctx, []string{"puuid-a", "puuid-b", "puuid-c"})
+	if err != nil {
+		t.Fatalf("GetSummonerNames() error = %v", err)
+	}
+	expected := map[string]string{"puuid-a": "Alpha#BR1", "puuid-c": "Charlie#NA1"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("GetSummonerNames() = %v, expected %v", names, expected)
+	}
+
+	pending := cache.PendingSummonerNames(ctx, []string{"puuid-a", "puuid-b"})
+	if !reflect.DeepEqual(pending, map[string]bool{"puuid-b": true}) {
+		t.Errorf("PendingSummonerNames() = %v, expected only puuid-b", pending)
+	}
+
+	client := NewRiotAPIClient(&Config{RiotRegion: "BR1", RiotBaseURL: fixtureBaseURL}, cache, newTestLogger(), nil)
+	entries := []LeagueEntry{{PUUID: "puuid-a"}, {PUUID: "puuid-b"}, {}, {PUUID: "puuid-c", SummonerName: "Kept#BR1"}}
+	client.enrichEntries(ctx, entries, "CHALLENGER", 1)
+
+	if entries[0].SummonerName != "Alpha#BR1" || entries[0].NamePending {
+		t.Errorf("entry 0 = %+v, expected the cached name", entries[0])
+	}
+	if entries[1].SummonerName != "" || !entries[1].NamePending {
+		t.Errorf("entry 1 = %+v, expected a pending name", entries[1])
+	}
+	if entries[2].SummonerName != "Unknown" {
+		t.Errorf("entry 2 = %+v, expected Unknown without a PUUID", entries[2])
+	}
+	if entries[3].SummonerName != "Kept#BR1" || entries[3].Tier != "CHALLENGER" {
+		t.Errorf("entry 3 = %+v, expected the existing name to be kept", entries[3])
+	}
+}
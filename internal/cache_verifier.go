@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type CacheVerifier struct {
+	cache      *CacheManager
+	riotClient *RiotAPIClient
+	metrics    *MetricsCollector
+	logger     *Logger
+	region     string
+	sampleSize int
+	interval   time.Duration
+}
+
+type verificationCheck struct {
+	kind    string
+	prefix  string
+	drifted func(ctx context.Context, key, puuid string) (bool, error)
+}
+
+func NewCacheVerifier(cfg *Config, cache *CacheManager, riotClient *RiotAPIClient, metrics *MetricsCollector, logger *Logger) *CacheVerifier {
+	return &CacheVerifier{
+		cache:      cache,
+		riotClient: riotClient,
+		metrics:    metrics,
+		logger:     logger,
+		region:     cfg.RiotRegion,
+		sampleSize: cfg.CacheVerifySampleSize,
+		interval:   cfg.CacheVerifyInterval,
+	}
+}
+
+func (cv *CacheVerifier) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "cache_verification",
+		Interval: cv.interval,
+		Run:      cv.Run,
+	}
+}
+
+func (cv *CacheVerifier) checks() []verificationCheck {
+	return []verificationCheck{
+		{kind: "summoner", prefix: cv.cache.Key("summoner", cv.region) + ":", drifted: cv.summonerDrifted},
+		{kind: "account", prefix: cv.cache.Key("account_puuid", cv.region) + ":", drifted: cv.accountDrifted},
+	}
+}
+
+func (cv *CacheVerifier) Run(ctx context.Context) error {
+	for _, check := range cv.checks() {
+		keys, err := cv.cache.SampleKeys(ctx, check.prefix+"*", cv.sampleSize)
+		if err != nil {
+			return err
+		}
+
+		sampled, drifted := 0, 0
+		for _, key := range keys {
+			puuid := strings.TrimPrefix(key, check.prefix)
+
+			isDrifted, err := check.drifted(ctx, key, puuid)
+			if err != nil {
+				cv.logger.Warn("cache_verification_skipped").
+					Component("cache_verifier").
+					Operation("verify").
+					Cache(true, key).
+					Meta("kind", check.kind).
+					Err(err).
+					Log()
+				continue
+			}
+
+			sampled++
+			if isDrifted {
+				drifted++
+				cv.logger.Info("cache_drift_detected").
+					Component("cache_verifier").
+					Operation("verify").
+					Cache(true, key).
+					Meta("kind", check.kind).
+					Log()
+			}
+		}
+
+		if cv.metrics != nil {
+			cv.metrics.RecordCacheVerification(check.kind, sampled, drifted)
+		}
+	}
+	return nil
+}
+
+func (cv *CacheVerifier) summonerDrifted(ctx context.Context, key, puuid string) (bool, error) {
+	var cached Summoner
+	if err := cv.cache.Get(ctx, key, &cached); err != nil {
+		return false, err
+	}
+
+	fresh, err := cv.riotClient.FetchSummonerByPUUID(ctx, puuid)
+	if err != nil {
+		return isNotFound(err), ignoreNotFound(err)
+	}
+	return summonerDrifted(&cached, fresh), nil
+}
+
+func (cv *CacheVerifier) accountDrifted(ctx context.Context, key, puuid string) (bool, error) {
+	var cached AccountData
+	if err := cv.cache.Get(ctx, key, &cached); err != nil {
+		return false, err
+	}
+
+	fresh, err := cv.riotClient.FetchAccountByPUUID(ctx, puuid)
+	if err != nil {
+		return isNotFound(err), ignoreNotFound(err)
+	}
+	return accountDrifted(&cached, fresh), nil
+}
+
+func summonerDrifted(cached, fresh *Summoner) bool {
+	return cached.SummonerLevel != fresh.SummonerLevel || cached.ProfileIconID != fresh.ProfileIconID
+}
+
+func accountDrifted(cached, fresh *AccountData) bool {
+	return cached.GameName != fresh.GameName || cached.TagLine != fresh.TagLine
+}
+
+func isNotFound(err error) bool {
+	var upstream *RiotAPIError
+	return errors.As(err, &upstream) && upstream.StatusCode == http.StatusNotFound
+}
+
+func ignoreNotFound(err error) error {
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
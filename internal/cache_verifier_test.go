@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestSummonerDrifted(t *testing.T) {
+	tests := []struct {
+		name     string
+		cached   Summoner
+		fresh    Summoner
+		expected bool
+	}{
+		{
+			name:     "identical",
+			cached:   Summoner{PUUID: "p", SummonerLevel: 100, ProfileIconID: 7},
+			fresh:    Summoner{PUUID: "p", SummonerLevel: 100, ProfileIconID: 7},
+			expected: false,
+		},
+		{
+			name:     "revision date alone is not drift",
+			cached:   Summoner{PUUID: "p", SummonerLevel: 100, RevisionDate: 1},
+			fresh:    Summoner{PUUID: "p", SummonerLevel: 100, RevisionDate: 2},
+			expected: false,
+		},
+		{
+			name:     "level changed",
+			cached:   Summoner{PUUID: "p", SummonerLevel: 100},
+			fresh:    Summoner{PUUID: "p", SummonerLevel: 101},
+			expected: true,
+		},
+		{
+			name:     "icon changed",
+			cached:   Summoner{PUUID: "p", ProfileIconID: 7},
+			fresh:    Summoner{PUUID: "p", ProfileIconID: 8},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := summonerDrifted(&tt.cached, &tt.fresh)
+			if result != tt.expected {
+				t.Errorf("summonerDrifted() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestAccountDrifted(t *testing.T) {
+	tests := []struct {
+		name     string
+		cached   AccountData
+		fresh    AccountData
+		expected bool
+	}{
+		{
+			name:     "identical",
+			cached:   AccountData{PUUID: "p", GameName: "Player", TagLine: "BR1"},
+			fresh:    AccountData{PUUID: "p", GameName: "Player", TagLine: "BR1"},
+			expected: false,
+		},
+		{
+			name:     "renamed",
+			cached:   AccountData{PUUID: "p", GameName: "Player", TagLine: "BR1"},
+			fresh:    AccountData{PUUID: "p", GameName: "NewName", TagLine: "BR1"},
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := accountDrifted(&tt.cached, &tt.fresh)
+			if result != tt.expected {
+				t.Errorf("accountDrifted() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{
+			name:     "upstream 404",
+			err:      &RiotAPIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"},
+			expected: true,
+		},
+		{
+			name:     "wrapped upstream 404",
+			err:      fmt.Errorf("fetch: %w", &RiotAPIError{StatusCode: http.StatusNotFound}),
+			expected: true,
+		},
+		{
+			name:     "upstream 500",
+			err:      &RiotAPIError{StatusCode: http.StatusInternalServerError},
+			expected: false,
+		},
+		{
+			name:     "other error",
+			err:      fmt.Errorf("connection refused"),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := isNotFound(tt.err)
+			if result != tt.expected {
+				t.Errorf("isNotFound() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client is a thin typed client for a running tft-core instance.
+type Client struct {
+	baseURL    string
+	apiKey     string
+	userAgent  string
+	httpClient *http.Client
+}
+
+// Sentinel errors for use with errors.Is. A *ClientError matches the one its
+// error code maps to; other codes match none of them.
+var (
+	ErrNotFound    = errors.New("tft-core: not found")
+	ErrRateLimited = errors.New("tft-core: rate limited")
+	ErrUpstream    = errors.New("tft-core: upstream error")
+	ErrWrongRegion = errors.New("tft-core: player belongs to another region")
+)
+
+var clientErrorSentinels = map[string]error{
+	ErrorCodeNotFound:    ErrNotFound,
+	ErrorCodeRateLimited: ErrRateLimited,
+	ErrorCodeUpstream:    ErrUpstream,
+	ErrorCodeWrongRegion: ErrWrongRegion,
+}
+
+type ClientError struct {
+	Status int
+	// Code comes from the error catalog; when the body has none (e.g. a
+	// proxy answered) it is derived from Status.
+	Code string
+	// Region is the platform to ask instead, set with ErrWrongRegion.
+	Region string
+	Body   string
+}
+
+func (e *ClientError) Error() string {
+	return fmt.Sprintf("tft-core responded %d (%s): %s", e.Status, e.Code, e.Body)
+}
+
+func (e *ClientError) Is(target error) bool {
+	sentinel, ok := clientErrorSentinels[e.Code]
+	return ok && target == sentinel
+}
+
+func newClientError(status int, body []byte) *ClientError {
+	var payload struct {
+		Code   string `json:"code"`
+		Region string `json:"region"`
+	}
+	json.Unmarshal(body, &payload)
+	if payload.Code == "" {
+		payload.Code = errorCodeForStatus(status)
+	}
+	return &ClientError{Status: status, Code: payload.Code, Region: payload.Region, Body: strings.TrimSpace(string(body))}
+}
+
+func NewClient(baseURL, apiKey string, timeout time.Duration) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		userAgent:  "tft-core-client",
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (c *Client) Health(ctx context.Context) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := c.get(ctx, "/healthz", nil, &result)
+	return result, err
+}
+
+func (c *Client) Challenger(ctx context.Context) (*ChallengerLeague, error) {
+	var result ChallengerLeague
+	if err := c.get(ctx, "/league/challenger", nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) LeagueEntries(ctx context.Context, tier, division string, page int) (*LeagueEntriesResponse, error) {
+	query := url.Values{}
+	query.Set("tier", tier)
+	query.Set("division", division)
+	query.Set("page", strconv.Itoa(page))
+
+	var result LeagueEntriesResponse
+	if err := c.get(ctx, "/league/entries", query, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) Summoner(ctx context.Context, puuid string) (*Summoner, error) {
+	query := url.Values{}
+	query.Set("puuid", puuid)
+
+	var result Summoner
+	if err := c.get(ctx, "/summoner", query, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (c *Client) LeagueByPUUID(ctx context.Context, puuid string) ([]LeagueEntry, error) {
+	query := url.Values{}
+	query.Set("puuid", puuid)
+
+	var result []LeagueEntry
+	if err := c.get(ctx, "/league/by-puuid", query, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		return newClientError(resp.StatusCode, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_SentinelErrors(t *testing.T) {
+	logger := newTestLogger()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/summoner":
+			writeError(w, NewAPIError("Summoner not found", http.StatusNotFound), logger, r)
+		case "/league/challenger":
+			writeError(w, NewAPIError("Rate limit exceeded", http.StatusTooManyRequests), logger, r)
+		case "/league/by-puuid":
+			// A proxy in front of tft-core answers without an error code.
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+		case "/league/entries":
+			writeError(w, NewAPIError("Too many concurrent requests", http.StatusServiceUnavailable), logger, r)
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL, "", time.Second)
+	puuid := "fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixtureP"
+
+	_, err := client.Summoner(t.Context(), puuid)
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrUpstream) {
+		t.Errorf("Summoner() error = %v, expected ErrNotFound", err)
+	}
+
+	_, err = client.Challenger(t.Context())
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("Challenger() error = %v, expected ErrRateLimited", err)
+	}
+
+	_, err = client.LeagueByPUUID(t.Context(), puuid)
+	var clientErr *ClientError
+	if !errors.Is(err, ErrUpstream) || !errors.As(err, &clientErr) || clientErr.Code != ErrorCodeUpstream {
+		t.Errorf("LeagueByPUUID() error = %v, expected ErrUpstream derived from the status", err)
+	}
+
+	_, err = client.LeagueEntries(t.Context(), "GOLD", "I", 1)
+	if !errors.As(err, &clientErr) || clientErr.Code != ErrorCodeUnavailable {
+		t.Fatalf("LeagueEntries() error = %v, expected code %s", err, ErrorCodeUnavailable)
+	}
+	for _, sentinel := range []error{ErrNotFound, ErrRateLimited, ErrUpstream, ErrWrongRegion} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("unavailable error matched %v", sentinel)
+		}
+	}
+}
+
+func TestErrorCodeForStatus(t *testing.T) {
+	tests := []struct {
+		status   int
+		expected string
+	}{
+		{http.StatusNotFound, ErrorCodeNotFound},
+		{http.StatusBadGateway, ErrorCodeUpstream},
+		{http.StatusConflict, ErrorCodeInvalidRequest},
+		{http.StatusHTTPVersionNotSupported, ErrorCodeInternal},
+	}
+
+	for _, tt := range tests {
+		if code := errorCodeForStatus(tt.status); code != tt.expected {
+			t.Errorf("errorCodeForStatus(%d) = %q, expected %q", tt.status, code, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Clock is the time source of the scheduler, the cache, the metrics
+// collector and the logging middleware. Constructors take one so tests can
+// move time by hand; nil means SystemClock.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer is the part of *time.Timer the scheduler uses.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// Ticker is the part of *time.Ticker the metrics reporter uses.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// SystemClock reads the wall clock and starts runtime timers.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+func (systemClock) NewTicker(d time.Duration) Ticker { return systemTicker{time.NewTicker(d)} }
+
+type systemTimer struct{ *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+type systemTicker struct{ *time.Ticker }
+
+func (t systemTicker) C() <-chan time.Time { return t.Ticker.C }
+
+func clockOrSystem(clock Clock) Clock {
+	if clock == nil {
+		return SystemClock
+	}
+	return clock
+}
+
+// IDGenerator produces request IDs; nil means UUIDGenerator.
+type IDGenerator interface {
+	NewID() string
+}
+
+// UUIDGenerator returns random (version 4) UUIDs.
+var UUIDGenerator IDGenerator = uuidGenerator{}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() string { return uuid.New().String() }
+
+func idsOrUUID(ids IDGenerator) IDGenerator {
+	if ids == nil {
+		return UUIDGenerator
+	}
+	return ids
+}
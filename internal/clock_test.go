@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock only moves when Advance is called, firing the timers and tickers
+// that fall due on the way.
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	return c.add(d, 0)
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) Ticker {
+	return fakeTicker{c.add(d, d)}
+}
+
+func (c *fakeClock) add(d, period time.Duration) *fakeTimer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{clock: c, ch: make(chan time.Time, 1), period: period}
+	timer.schedule(d)
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+// Advance moves the clock forward by d. Like the runtime's, a ticker that
+// falls more than one tick behind drops the ticks its reader missed.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	for _, timer := range c.timers {
+		if !timer.active || timer.at.After(c.now) {
+			continue
+		}
+		select {
+		case timer.ch <- c.now:
+		default:
+		}
+		if timer.period <= 0 {
+			timer.active = false
+			continue
+		}
+		for !timer.at.After(c.now) {
+			timer.at = timer.at.Add(timer.period)
+		}
+	}
+}
+
+// waitForTimers blocks until n timers or tickers are active, so a test does
+// not advance the clock before a goroutine has started waiting on it.
+func (c *fakeClock) waitForTimers(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		c.mu.Lock()
+		active := 0
+		for _, timer := range c.timers {
+			if timer.active {
+				active++
+			}
+		}
+		c.mu.Unlock()
+		if active >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("fewer than %d timers started", n)
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	ch     chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.ch }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.active = false
+	return wasActive
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := t.active
+	t.schedule(d)
+	return wasActive
+}
+
+// schedule arms the timer; like the runtime's, one set to a duration that
+// is not positive fires straight away. The clock's lock must be held.
+func (t *fakeTimer) schedule(d time.Duration) {
+	t.at = t.clock.now.Add(d)
+	t.active = true
+	if d <= 0 && t.period <= 0 {
+		t.active = false
+		select {
+		case t.ch <- t.clock.now:
+		default:
+		}
+	}
+}
+
+type fakeTicker struct{ *fakeTimer }
+
+func (t fakeTicker) Stop() { t.fakeTimer.Stop() }
+
+func TestFakeClock(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	timer := clock.NewTimer(time.Minute)
+	ticker := clock.NewTicker(10 * time.Second)
+
+	fired := func(ch <-chan time.Time) bool {
+		select {
+		case <-ch:
+			return true
+		default:
+			return false
+		}
+	}
+
+	clock.Advance(59 * time.Second)
+	if fired(timer.C()) {
+		t.Error("timer fired before its duration")
+	}
+	if !fired(ticker.C()) {
+		t.Error("ticker did not fire")
+	}
+
+	clock.Advance(time.Second)
+	if !fired(timer.C()) || !fired(ticker.C()) {
+		t.Error("expected the timer and the ticker to fire at a minute")
+	}
+	if timer.Stop() {
+		t.Error("Stop() = true for a timer that already fired")
+	}
+
+	timer.Reset(time.Second)
+	ticker.Stop()
+	clock.Advance(time.Minute)
+	if !fired(timer.C()) || fired(ticker.C()) {
+		t.Error("expected the reset timer to fire and the stopped ticker not to")
+	}
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+var defaultBranchTimeouts = map[string]time.Duration{
+	"summoner": 1500 * time.Millisecond,
+	"league":   800 * time.Millisecond,
+	"history":  time.Second,
+	"form":     time.Second,
+	"region":   2 * time.Second,
+}
+
+// BranchTimeouts caps the optional sections of composite responses
+// (profile, search) so one slow upstream call degrades its own section.
+type BranchTimeouts struct {
+	timeouts map[string]time.Duration
+}
+
+// parseBranchTimeouts reads name=duration pairs; zero disables a branch's
+// own timeout, leaving only the request deadline.
+func parseBranchTimeouts(value string) (map[string]time.Duration, error) {
+	timeouts := make(map[string]time.Duration)
+	if strings.TrimSpace(value) == "" {
+		return timeouts, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		name, spec, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid branch timeout rule %q", rule)
+		}
+		timeout, err := time.ParseDuration(spec)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid timeout %q for branch %s", spec, name)
+		}
+		timeouts[name] = timeout
+	}
+	return timeouts, nil
+}
+
+func NewBranchTimeouts(cfg *Config, logger *Logger) *BranchTimeouts {
+	timeouts := make(map[string]time.Duration, len(defaultBranchTimeouts))
+	for name, timeout := range defaultBranchTimeouts {
+		timeouts[name] = timeout
+	}
+
+	overrides, err := parseBranchTimeouts(cfg.BranchTimeouts)
+	if err != nil {
+		logger.Error("branch_timeouts_invalid").
+			Component("composite").
+			Operation("init").
+			Err(err).
+			Meta("value", cfg.BranchTimeouts).
+			Log()
+	}
+	for name, timeout := range overrides {
+		timeouts[name] = timeout
+	}
+	return &BranchTimeouts{timeouts: timeouts}
+}
+
+func (bt *BranchTimeouts) For(name string) time.Duration {
+	if bt == nil {
+		return defaultBranchTimeouts[name]
+	}
+	return bt.timeouts[name]
+}
+
+const (
+	degradedTimeout = "timeout"
+	degradedError   = "error"
+)
+
+// DegradedSection names a section left empty because its branch failed.
+type DegradedSection struct {
+	Section string `json:"section"`
+	Reason  string `json:"reason"`
+}
+
+// Branch is one sub-call of a composite response. A failing required branch
+// fails the response and cancels the others; an optional one only degrades
+// its section. Required branches are bounded by the request deadline alone.
+type Branch struct {
+	Name     string
+	Required bool
+	Run      func(ctx context.Context) error
+}
+
+// runBranches runs the branches concurrently and returns once all of them
+// have finished, so nothing outlives the handler.
+func runBranches(ctx context.Context, timeouts *BranchTimeouts, logger *Logger, branches ...Branch) ([]DegradedSection, error) {
+	var mu sync.Mutex
+	var degraded []DegradedSection
+
+	g, ctx := errgroup.WithContext(ctx)
+	for _, branch := range branches {
+		g.Go(func() error {
+			if branch.Required {
+				return branch.Run(ctx)
+			}
+
+			branchCtx := ctx
+			if timeout := timeouts.For(branch.Name); timeout > 0 {
+				var cancel context.CancelFunc
+				branchCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			err := branch.Run(branchCtx)
+			if err == nil {
+				return nil
+			}
+
+			reason := degradedError
+			if errors.Is(err, context.DeadlineExceeded) || errors.Is(branchCtx.Err(), context.DeadlineExceeded) {
+				reason = degradedTimeout
+			}
+			logger.Warn("composite_branch_degraded").
+				Component("composite").
+				Operation(branch.Name).
+				Request("", "", GetRequestID(ctx)).
+				Meta("reason", reason).
+				Err(err).
+				Duration(time.Since(start)).
+				Log()
+
+			mu.Lock()
+			degraded = append(degraded, DegradedSection{Section: branch.Name, Reason: reason})
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	// Branches finish in any order; keep the declared one.
+	ordered := make([]DegradedSection, 0, len(degraded))
+	for _, branch := range branches {
+		for _, section := range degraded {
+			if section.Section == branch.Name {
+				ordered = append(ordered, section)
+			}
+		}
+	}
+	if len(ordered) == 0 {
+		return nil, nil
+	}
+	return ordered, nil
+}
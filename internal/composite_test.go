@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseBranchTimeouts(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  map[string]time.Duration
+		expectErr bool
+	}{
+		{value: "", expected: map[string]time.Duration{}},
+		{value: "league=500ms, history=0s", expected: map[string]time.Duration{"league": 500 * time.Millisecond, "history": 0}},
+		{value: "league", expectErr: true},
+		{value: "league=-1s", expectErr: true},
+		{value: "=1s", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		timeouts, err := parseBranchTimeouts(tt.value)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("parseBranchTimeouts(%q) error = %v, expectErr %v", tt.value, err, tt.expectErr)
+			continue
+		}
+		if !tt.expectErr && !reflect.DeepEqual(timeouts, tt.expected) {
+			t.Errorf("parseBranchTimeouts(%q) = %v, expected %v", tt.value, timeouts, tt.expected)
+		}
+	}
+}
+
+func TestNewBranchTimeouts(t *testing.T) {
+	timeouts := NewBranchTimeouts(&Config{BranchTimeouts: "league=200ms"}, newTestLogger())
+	if timeouts.For("league") != 200*time.Millisecond {
+		t.Errorf("league timeout = %v, expected the override", timeouts.For("league"))
+	}
+	if timeouts.For("history") != time.Second {
+		t.Errorf("history timeout = %v, expected the default", timeouts.For("history"))
+	}
+}
+
+func waitForCancel(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunBranches_DegradesOptionalSections(t *testing.T) {
+	timeouts := NewBranchTimeouts(&Config{BranchTimeouts: "league=20ms,history=0s"}, newTestLogger())
+
+	logger, logs := newCaptureLogger()
+	var summoner string
+	start := time.Now()
+	degraded, err := runBranches(t.Context(), timeouts, logger,
+		Branch{Name: "summoner", Required: true, Run: func(ctx context.Context) error {
+			summoner = "ok"
+			return nil
+		}},
+		Branch{Name: "league", Run: waitForCancel},
+		Branch{Name: "history", Run: func(ctx context.Context) error {
+			return errors.New("database unavailable")
+		}},
+	)
+	if err != nil {
+		t.Fatalf("runBranches() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("runBranches() took %v, expected the league timeout to cut it short", elapsed)
+	}
+	if summoner != "ok" {
+		t.Error("required branch result missing")
+	}
+
+	expected := []DegradedSection{
+		{Section: "league", Reason: degradedTimeout},
+		{Section: "history", Reason: degradedError},
+	}
+	if !reflect.DeepEqual(degraded, expected) {
+		t.Errorf("degraded = %+v, expected %+v", degraded, expected)
+	}
+
+	logs.Expect(t, expectedEvent{Level: LogLevelWarn, Message: "composite_branch_degraded", Component: "composite", Operation: "league", Meta: map[string]interface{}{"reason": degradedTimeout}})
+	entry := logs.Expect(t, expectedEvent{Level: LogLevelWarn, Message: "composite_branch_degraded", Component: "composite", Operation: "history", Meta: map[string]interface{}{"reason": degradedError}})
+	if entry.Error != "database unavailable" {
+		t.Errorf("history error = %q, expected the branch error", entry.Error)
+	}
+}
+
+func TestRunBranches_RequiredFailureCancelsOthers(t *testing.T) {
+	timeouts := NewBranchTimeouts(&Config{BranchTimeouts: "league=0s"}, newTestLogger())
+	failure := errors.New("riot responded 404")
+
+	degraded, err := runBranches(t.Context(), timeouts, newTestLogger(),
+		Branch{Name: "summoner", Required: true, Run: func(ctx context.Context) error {
+			return failure
+		}},
+		Branch{Name: "league", Run: waitForCancel},
+	)
+	if !errors.Is(err, failure) {
+		t.Errorf("runBranches() error = %v, expected the required branch error", err)
+	}
+	if degraded != nil {
+		t.Errorf("degraded = %+v, expected nil on failure", degraded)
+	}
+}
+
+func TestRunBranches_AllSucceed(t *testing.T) {
+	degraded, err := runBranches(t.Context(), nil, newTestLogger(),
+		Branch{Name: "league", Run: func(ctx context.Context) error { return nil }},
+	)
+	if err != nil || degraded != nil {
+		t.Errorf("runBranches() = %+v, %v, expected no degraded sections", degraded, err)
+	}
+}
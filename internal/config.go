@@ -2,8 +2,10 @@ package internal
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"strconv"
+	"time"
 )
 
 type Config struct {
@@ -11,80 +13,523 @@ type Config struct {
 	RiotRegion  string
 	RiotBaseURL string
 
-	PostgresHost     string
-	PostgresPort     string
-	PostgresUser     string
-	PostgresPassword string
-	PostgresDB       string
-	PostgresSSLMode  string
+	RiotMode        string
+	RiotFixturesDir string
+
+	RiotHTTPTimeout             time.Duration
+	RiotHTTPDialTimeout         time.Duration
+	RiotHTTPKeepAlive           time.Duration
+	RiotHTTPTLSHandshakeTimeout time.Duration
+	RiotHTTPIdleConnTimeout     time.Duration
+	RiotHTTPMaxIdleConns        int
+	RiotHTTPMaxIdleConnsPerHost int
+	RiotHTTPMaxConnsPerHost     int
+	RiotHTTP2Enabled            bool
+
+	PostgresHost       string
+	PostgresPort       string
+	PostgresUser       string
+	PostgresPassword   string
+	PostgresDB         string
+	PostgresSSLMode    string
+	PostgresReplicaDSN string
+
+	DatabaseDriver string
+	SQLitePath     string
 
 	RedisHost     string
 	RedisPort     string
 	RedisPassword string
 	RedisDB       int
 
+	RedisPoolSize     int
+	RedisMinIdleConns int
+	RedisPoolTimeout  time.Duration
+
+	CacheBackend     string
+	MemcachedServers string
+	MemcachedTimeout time.Duration
+
+	LocalCacheSize int
+	LocalCacheTTL  time.Duration
+
 	NATSUrl       string
 	NATSClusterID string
 	NATSClientID  string
 
-	RateLimitRedisPrefix string
+	NATSSummonerWorkers    int
+	NATSLeagueWorkers      int
+	NATSMatchIngestWorkers int
+	NATSMaxInFlight        int
+	NATSPendingLimit       int
+	NATSMaxReconnects      int
+	NATSReconnectWait      time.Duration
+	NATSDrainTimeout       time.Duration
+	NATSNameShards         int
+	NATSNameShardIDs       string
+	NATSNotifyWorkers      int
+	NATSDedupWindow        time.Duration
+
+	NATSTaskMaxAttempts     int
+	NATSTaskRetryBackoff    time.Duration
+	NATSTaskRetryMaxBackoff time.Duration
+
+	RateLimitRedisPrefix  string
+	RateLimitMethodLimits string
+	EndpointLimits        string
+	RequestTimeout        time.Duration
+	RequestTimeouts       string
+	BranchTimeouts        string
+	DeprecatedFields      string
+
+	AppPort     string
+	AppEnv      string
+	LogLevel    string
+	LogSampling string
+	ConfigFile  string
+
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HTTPMaxHeaderBytes    int
+	HTTPH2C               bool
+	TLSCertFile           string
+	TLSKeyFile            string
+
+	SandboxRateLimitMultiplier int
+
+	AuthEnabled        bool
+	AuthAllowAnonymous bool
+	APIKeys            string
+	APIKeyCacheTTL     time.Duration
+
+	OperatorToken      string
+	OperatorAllowedIPs string
+
+	CacheEnabled          bool
+	CacheEarlyRefreshBeta float64
+	ResponseCacheEnabled  bool
+	ResponseCacheTTL      time.Duration
+	DatabaseEnabled       bool
+	DatabaseAutoMigrate   bool
+	SchemaMismatchMode    string
+
+	AccountNegativeCacheTTL time.Duration
+
+	RiotJournalEnabled bool
+
+	WatchlistRefreshInterval time.Duration
+	WatchlistBatchSize       int
+	WatchlistMaxPlayers      int
+	WatchlistStreakThreshold int
+	OutboxRelayInterval      time.Duration
+	OutboxBatchSize          int
+	MatchIngestMaxMatches    int
+
+	NotifyWebhookURLs        string
+	NotifyWebhookSecret      string
+	NotifyDiscordWebhookURLs string
+	NotifyMaxAttempts        int
+	NotifyRetryBackoff       time.Duration
+	NotifyTimeout            time.Duration
+
+	LeagueSnapshotInterval  time.Duration
+	LeagueSnapshotRetention time.Duration
 
-	AppPort  string
-	AppEnv   string
-	LogLevel string
+	LeagueLastKnownGoodMaxAge time.Duration
 
-	CacheEnabled    bool
-	DatabaseEnabled bool
+	CacheVerifyEnabled    bool
+	CacheVerifyInterval   time.Duration
+	CacheVerifySampleSize int
+
+	CachePruneEnabled   bool
+	CachePruneInterval  time.Duration
+	CachePruneScanCount int
+	CachePruneMaxKeys   int
+
+	SummonerCachePruneEnabled    bool
+	SummonerCacheRetention       time.Duration
+	SummonerCachePruneInterval   time.Duration
+	SummonerCachePruneBatchSize  int
+	SummonerCachePruneMaxBatches int
+
+	StaticDataEnabled         bool
+	StaticDataURL             string
+	StaticDataLocale          string
+	StaticDataRefreshInterval time.Duration
+
+	PlacementStatsCacheTTL time.Duration
+	ProfileEnrichLimit     int
+	VerificationTTL        time.Duration
+
+	SchedulerJitter     float64
+	SchedulerPersistent bool
+	LeagueSchedules     []ScheduleConfig
+
+	LeagueRefreshRegions     string
+	LeagueRefreshConcurrency int
+	RiotRegions              string
+
+	CrossShardTimeout      time.Duration
+	CrossShardProbeRegions string
+
+	NamePendingMaxAge    time.Duration
+	NameRetryBaseBackoff time.Duration
+	NameRetryMaxBackoff  time.Duration
+
+	StartupMaxAttempts  int
+	StartupRetryBackoff time.Duration
+	StartupWaitTimeout  time.Duration
+
+	MetricsPersistenceEnabled bool
+	MetricsSnapshotInterval   time.Duration
+	MetricsInstanceID         string
+
+	TracingEnabled     bool
+	TracingSampleRatio float64
+	OTLPEndpoint       string
+	OTLPInsecure       bool
+
+	SLOEnabled            bool
+	SLOObjectives         string
+	SLOShortWindow        time.Duration
+	SLOLongWindow         time.Duration
+	SLOBurnRateAlert      float64
+	SLOEvaluationInterval time.Duration
+
+	PProfEnabled           bool
+	ProfilingEnabled       bool
+	ProfileUploadEndpoint  string
+	ProfileUploadBucket    string
+	ProfileUploadRegion    string
+	ProfileUploadPrefix    string
+	ProfileUploadAccessKey string
+	ProfileUploadSecretKey string
+	ProfileUploadTimeout   time.Duration
 }
 
 func LoadConfig() (*Config, error) {
-	redisDB, err := strconv.Atoi(getEnvDefault("REDIS_DB", "0"))
+	env := configEnv(nil)
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		overlay, err := readConfigFile(path)
+		if err != nil {
+			return nil, err
+		}
+		env = overlay
+	}
+
+	redisDB, err := strconv.Atoi(env.getDefault("REDIS_DB", "0"))
 	if err != nil {
 		return nil, errors.New("invalid REDIS_DB value")
 	}
 
 	cfg := &Config{
-		RiotAPIKey:  os.Getenv("RIOT_API_KEY"),
-		RiotRegion:  getEnvDefault("RIOT_REGION", "BR1"),
-		RiotBaseURL: os.Getenv("RIOT_BASE_URL"),
-
-		PostgresHost:     getEnvDefault("POSTGRES_HOST", "localhost"),
-		PostgresPort:     getEnvDefault("POSTGRES_PORT", "5432"),
-		PostgresUser:     os.Getenv("POSTGRES_USER"),
-		PostgresPassword: os.Getenv("POSTGRES_PASSWORD"),
-		PostgresDB:       os.Getenv("POSTGRES_DB"),
-		PostgresSSLMode:  getEnvDefault("POSTGRES_SSL_MODE", "disable"),
-
-		RedisHost:     getEnvDefault("REDIS_HOST", "localhost"),
-		RedisPort:     getEnvDefault("REDIS_PORT", "6379"),
-		RedisPassword: os.Getenv("REDIS_PASSWORD"),
+		RiotAPIKey:  env.get("RIOT_API_KEY"),
+		RiotRegion:  env.getDefault("RIOT_REGION", "BR1"),
+		RiotBaseURL: env.get("RIOT_BASE_URL"),
+
+		RiotMode:        env.getDefault("RIOT_MODE", RiotModeLive),
+		RiotFixturesDir: env.getDefault("RIOT_FIXTURES_DIR", "fixtures/riot"),
+
+		RiotHTTPTimeout:             env.getDuration("RIOT_HTTP_TIMEOUT", 10*time.Second),
+		RiotHTTPDialTimeout:         env.getDuration("RIOT_HTTP_DIAL_TIMEOUT", 5*time.Second),
+		RiotHTTPKeepAlive:           env.getDuration("RIOT_HTTP_KEEPALIVE", 30*time.Second),
+		RiotHTTPTLSHandshakeTimeout: env.getDuration("RIOT_HTTP_TLS_HANDSHAKE_TIMEOUT", 5*time.Second),
+		RiotHTTPIdleConnTimeout:     env.getDuration("RIOT_HTTP_IDLE_CONN_TIMEOUT", 90*time.Second),
+		RiotHTTPMaxIdleConns:        env.getInt("RIOT_HTTP_MAX_IDLE_CONNS", 100),
+		RiotHTTPMaxIdleConnsPerHost: env.getInt("RIOT_HTTP_MAX_IDLE_CONNS_PER_HOST", 32),
+		RiotHTTPMaxConnsPerHost:     env.getInt("RIOT_HTTP_MAX_CONNS_PER_HOST", 0),
+		RiotHTTP2Enabled:            env.getBool("RIOT_HTTP2_ENABLED", true),
+
+		PostgresHost:       env.getDefault("POSTGRES_HOST", "localhost"),
+		PostgresPort:       env.getDefault("POSTGRES_PORT", "5432"),
+		PostgresUser:       env.get("POSTGRES_USER"),
+		PostgresPassword:   env.get("POSTGRES_PASSWORD"),
+		PostgresDB:         env.get("POSTGRES_DB"),
+		PostgresSSLMode:    env.getDefault("POSTGRES_SSL_MODE", "disable"),
+		PostgresReplicaDSN: env.get("POSTGRES_REPLICA_DSN"),
+
+		DatabaseDriver: env.getDefault("DATABASE_DRIVER", DatabaseDriverPostgres),
+		SQLitePath:     env.getDefault("SQLITE_PATH", "tft-core.db"),
+
+		RedisHost:     env.getDefault("REDIS_HOST", "localhost"),
+		RedisPort:     env.getDefault("REDIS_PORT", "6379"),
+		RedisPassword: env.get("REDIS_PASSWORD"),
 		RedisDB:       redisDB,
 
-		NATSUrl:       getEnvDefault("NATS_URL", "nats://localhost:4222"),
-		NATSClusterID: getEnvDefault("NATS_CLUSTER_ID", "tft-cluster"),
-		NATSClientID:  getEnvDefault("NATS_CLIENT_ID", "tft-service"),
+		RedisPoolSize:     env.getInt("REDIS_POOL_SIZE", 20),
+		RedisMinIdleConns: env.getInt("REDIS_MIN_IDLE_CONNS", 2),
+		RedisPoolTimeout:  env.getDuration("REDIS_POOL_TIMEOUT", 4*time.Second),
+
+		CacheBackend:     env.getDefault("CACHE_BACKEND", CacheBackendRedis),
+		MemcachedServers: env.getDefault("MEMCACHED_SERVERS", "localhost:11211"),
+		MemcachedTimeout: env.getDuration("MEMCACHED_TIMEOUT", 500*time.Millisecond),
+
+		LocalCacheSize: env.getInt("LOCAL_CACHE_SIZE", 10000),
+		LocalCacheTTL:  env.getDuration("LOCAL_CACHE_TTL", 5*time.Second),
+
+		NATSUrl:       env.getDefault("NATS_URL", "nats://localhost:4222"),
+		NATSClusterID: env.getDefault("NATS_CLUSTER_ID", "tft-cluster"),
+		NATSClientID:  env.getDefault("NATS_CLIENT_ID", "tft-service"),
+
+		NATSSummonerWorkers:    env.getInt("NATS_SUMMONER_WORKERS", 4),
+		NATSLeagueWorkers:      env.getInt("NATS_LEAGUE_WORKERS", 1),
+		NATSMatchIngestWorkers: env.getInt("NATS_MATCH_INGEST_WORKERS", 2),
+		NATSMaxInFlight:        env.getInt("NATS_MAX_IN_FLIGHT", 100),
+		NATSPendingLimit:       env.getInt("NATS_PENDING_LIMIT", 1000),
+		NATSMaxReconnects:      env.getInt("NATS_MAX_RECONNECTS", -1),
+		NATSReconnectWait:      env.getDuration("NATS_RECONNECT_WAIT", 2*time.Second),
+		NATSDrainTimeout:       env.getDuration("NATS_DRAIN_TIMEOUT", 20*time.Second),
+		NATSNameShards:         env.getInt("NATS_NAME_SHARDS", 0),
+		NATSNameShardIDs:       env.get("NATS_NAME_SHARD_IDS"),
+		NATSNotifyWorkers:      env.getInt("NATS_NOTIFY_WORKERS", 2),
+		NATSDedupWindow:        env.getDuration("NATS_DEDUP_WINDOW", 30*time.Second),
+
+		NATSTaskMaxAttempts:     env.getInt("NATS_TASK_MAX_ATTEMPTS", 3),
+		NATSTaskRetryBackoff:    env.getDuration("NATS_TASK_RETRY_BACKOFF", time.Second),
+		NATSTaskRetryMaxBackoff: env.getDuration("NATS_TASK_RETRY_MAX_BACKOFF", 30*time.Second),
+
+		RateLimitRedisPrefix:  env.getDefault("RATE_LIMIT_REDIS_PREFIX", "tft:ratelimit"),
+		RateLimitMethodLimits: env.get("RATE_LIMIT_METHOD_LIMITS"),
+		EndpointLimits:        env.get("ENDPOINT_LIMITS"),
+		RequestTimeout:        env.getDuration("REQUEST_TIMEOUT", 8*time.Second),
+		RequestTimeouts:       env.get("REQUEST_TIMEOUTS"),
+		BranchTimeouts:        env.get("BRANCH_TIMEOUTS"),
+		DeprecatedFields:      env.get("DEPRECATED_FIELDS"),
+
+		AppPort:     env.getDefault("APP_PORT", "8000"),
+		AppEnv:      env.getDefault("APP_ENV", "development"),
+		LogLevel:    env.getDefault("LOG_LEVEL", "info"),
+		LogSampling: env.get("LOG_SAMPLING"),
+		ConfigFile:  os.Getenv("CONFIG_FILE"),
+
+		HTTPReadHeaderTimeout: env.getDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		HTTPReadTimeout:       env.getDuration("HTTP_READ_TIMEOUT", 10*time.Second),
+		HTTPWriteTimeout:      env.getDuration("HTTP_WRITE_TIMEOUT", 10*time.Second),
+		HTTPIdleTimeout:       env.getDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		HTTPMaxHeaderBytes:    env.getInt("HTTP_MAX_HEADER_BYTES", 1<<20),
+		HTTPH2C:               env.getBool("HTTP_H2C", false),
+		TLSCertFile:           env.get("TLS_CERT_FILE"),
+		TLSKeyFile:            env.get("TLS_KEY_FILE"),
+
+		SandboxRateLimitMultiplier: env.getInt("SANDBOX_RATE_LIMIT_MULTIPLIER", 100),
+
+		AuthEnabled:        env.getBool("AUTH_ENABLED", false),
+		AuthAllowAnonymous: env.getBool("AUTH_ALLOW_ANONYMOUS", true),
+		APIKeys:            env.get("API_KEYS"),
+		APIKeyCacheTTL:     env.getDuration("API_KEY_CACHE_TTL", time.Minute),
+
+		OperatorToken:      env.get("OPERATOR_TOKEN"),
+		OperatorAllowedIPs: env.get("OPERATOR_ALLOWED_IPS"),
+
+		CacheEnabled:          env.getBool("CACHE_ENABLED", true),
+		CacheEarlyRefreshBeta: env.getFloat("CACHE_EARLY_REFRESH_BETA", 1.0),
+		ResponseCacheEnabled:  env.getBool("RESPONSE_CACHE_ENABLED", true),
+		ResponseCacheTTL:      env.getDuration("RESPONSE_CACHE_TTL", 5*time.Second),
+		DatabaseEnabled:       env.getBool("DATABASE_ENABLED", true),
+		DatabaseAutoMigrate:   env.getBool("DATABASE_AUTO_MIGRATE", true),
+		SchemaMismatchMode:    env.getDefault("SCHEMA_MISMATCH_MODE", SchemaMismatchRefuse),
+
+		AccountNegativeCacheTTL: env.getDuration("ACCOUNT_NEGATIVE_CACHE_TTL", 5*time.Minute),
+
+		RiotJournalEnabled: env.getBool("RIOT_JOURNAL_ENABLED", false),
+
+		WatchlistRefreshInterval: env.getDuration("WATCHLIST_REFRESH_INTERVAL", 15*time.Minute),
+		WatchlistBatchSize:       env.getInt("WATCHLIST_BATCH_SIZE", 50),
+		WatchlistMaxPlayers:      env.getInt("WATCHLIST_MAX_PLAYERS", 100),
+		WatchlistStreakThreshold: env.getInt("WATCHLIST_STREAK_THRESHOLD", 5),
+		OutboxRelayInterval:      env.getDuration("OUTBOX_RELAY_INTERVAL", 5*time.Second),
+		OutboxBatchSize:          env.getInt("OUTBOX_BATCH_SIZE", 100),
+		MatchIngestMaxMatches:    env.getInt("MATCH_INGEST_MAX_MATCHES", 20),
+
+		NotifyWebhookURLs:        env.get("NOTIFY_WEBHOOK_URLS"),
+		NotifyWebhookSecret:      env.get("NOTIFY_WEBHOOK_SECRET"),
+		NotifyDiscordWebhookURLs: env.get("NOTIFY_DISCORD_WEBHOOK_URLS"),
+		NotifyMaxAttempts:        env.getInt("NOTIFY_MAX_ATTEMPTS", 5),
+		NotifyRetryBackoff:       env.getDuration("NOTIFY_RETRY_BACKOFF", time.Second),
+		NotifyTimeout:            env.getDuration("NOTIFY_TIMEOUT", 5*time.Second),
+
+		LeagueSnapshotInterval:  env.getDuration("LEAGUE_SNAPSHOT_INTERVAL", 30*time.Minute),
+		LeagueSnapshotRetention: env.getDuration("LEAGUE_SNAPSHOT_RETENTION", 14*24*time.Hour),
 
-		RateLimitRedisPrefix: getEnvDefault("RATE_LIMIT_REDIS_PREFIX", "tft:ratelimit"),
+		LeagueLastKnownGoodMaxAge: env.getDuration("LEAGUE_LAST_KNOWN_GOOD_MAX_AGE", 24*time.Hour),
 
-		AppPort:  getEnvDefault("APP_PORT", "8000"),
-		AppEnv:   getEnvDefault("APP_ENV", "development"),
-		LogLevel: getEnvDefault("LOG_LEVEL", "info"),
+		CacheVerifyEnabled:    env.getBool("CACHE_VERIFY_ENABLED", true),
+		CacheVerifyInterval:   env.getDuration("CACHE_VERIFY_INTERVAL", 10*time.Minute),
+		CacheVerifySampleSize: env.getInt("CACHE_VERIFY_SAMPLE_SIZE", 5),
 
-		CacheEnabled:    getBoolEnvDefault("CACHE_ENABLED", true),
-		DatabaseEnabled: getBoolEnvDefault("DATABASE_ENABLED", true),
+		CachePruneEnabled:   env.getBool("CACHE_PRUNE_ENABLED", true),
+		CachePruneInterval:  env.getDuration("CACHE_PRUNE_INTERVAL", 6*time.Hour),
+		CachePruneScanCount: env.getInt("CACHE_PRUNE_SCAN_COUNT", 500),
+		CachePruneMaxKeys:   env.getInt("CACHE_PRUNE_MAX_KEYS", 10000),
+
+		SummonerCachePruneEnabled:    env.getBool("SUMMONER_CACHE_PRUNE_ENABLED", true),
+		SummonerCacheRetention:       env.getDuration("SUMMONER_CACHE_RETENTION", 30*24*time.Hour),
+		SummonerCachePruneInterval:   env.getDuration("SUMMONER_CACHE_PRUNE_INTERVAL", 6*time.Hour),
+		SummonerCachePruneBatchSize:  env.getInt("SUMMONER_CACHE_PRUNE_BATCH_SIZE", 1000),
+		SummonerCachePruneMaxBatches: env.getInt("SUMMONER_CACHE_PRUNE_MAX_BATCHES", 50),
+
+		StaticDataEnabled:         env.getBool("STATIC_DATA_ENABLED", true),
+		StaticDataURL:             env.getDefault("STATIC_DATA_URL", "https://ddragon.leagueoflegends.com"),
+		StaticDataLocale:          env.getDefault("STATIC_DATA_LOCALE", "en_US"),
+		StaticDataRefreshInterval: env.getDuration("STATIC_DATA_REFRESH_INTERVAL", 6*time.Hour),
+
+		PlacementStatsCacheTTL: env.getDuration("PLACEMENT_STATS_CACHE_TTL", 5*time.Minute),
+		ProfileEnrichLimit:     env.getInt("PROFILE_ENRICH_LIMIT", defaultProfileEnrichLimit),
+		VerificationTTL:        env.getDuration("VERIFICATION_TTL", 15*time.Minute),
+
+		SchedulerJitter:     env.getFloat("SCHEDULER_JITTER", 0.1),
+		SchedulerPersistent: env.getBool("SCHEDULER_PERSISTENT", true),
+		LeagueSchedules:     loadLeagueSchedules(env),
+
+		LeagueRefreshRegions:     env.get("LEAGUE_REFRESH_REGIONS"),
+		LeagueRefreshConcurrency: env.getInt("LEAGUE_REFRESH_CONCURRENCY", 4),
+		RiotRegions:              env.get("RIOT_REGIONS"),
+
+		CrossShardTimeout:      env.getDuration("CROSS_SHARD_TIMEOUT", time.Second),
+		CrossShardProbeRegions: env.get("CROSS_SHARD_PROBE_REGIONS"),
+
+		NamePendingMaxAge:    env.getDuration("NAME_PENDING_MAX_AGE", 10*time.Minute),
+		NameRetryBaseBackoff: env.getDuration("NAME_RETRY_BASE_BACKOFF", 5*time.Minute),
+		NameRetryMaxBackoff:  env.getDuration("NAME_RETRY_MAX_BACKOFF", 24*time.Hour),
+
+		StartupMaxAttempts:  env.getInt("STARTUP_MAX_ATTEMPTS", 0),
+		StartupRetryBackoff: env.getDuration("STARTUP_RETRY_BACKOFF", time.Second),
+		StartupWaitTimeout:  env.getDuration("STARTUP_WAIT_TIMEOUT", 60*time.Second),
+
+		MetricsPersistenceEnabled: env.getBool("METRICS_PERSISTENCE_ENABLED", true),
+		MetricsSnapshotInterval:   env.getDuration("METRICS_SNAPSHOT_INTERVAL", time.Minute),
+		MetricsInstanceID:         env.getDefault("METRICS_INSTANCE_ID", "default"),
+
+		TracingEnabled:     env.getBool("TRACING_ENABLED", false),
+		TracingSampleRatio: env.getFloat("TRACING_SAMPLE_RATIO", 1.0),
+		OTLPEndpoint:       env.getDefault("OTEL_EXPORTER_OTLP_ENDPOINT", "localhost:4318"),
+		OTLPInsecure:       env.getBool("OTEL_EXPORTER_OTLP_INSECURE", true),
+
+		SLOEnabled:            env.getBool("SLO_ENABLED", true),
+		SLOObjectives:         env.getDefault("SLO_OBJECTIVES", "*=99.5:1s@99"),
+		SLOShortWindow:        env.getDuration("SLO_SHORT_WINDOW", 5*time.Minute),
+		SLOLongWindow:         env.getDuration("SLO_LONG_WINDOW", time.Hour),
+		SLOBurnRateAlert:      env.getFloat("SLO_BURN_RATE_ALERT", 14.4),
+		SLOEvaluationInterval: env.getDuration("SLO_EVALUATION_INTERVAL", time.Minute),
+
+		PProfEnabled:           env.getBool("ENABLE_PPROF", false),
+		ProfilingEnabled:       env.getBool("ENABLE_PROFILING", false),
+		ProfileUploadEndpoint:  env.get("PROFILE_UPLOAD_ENDPOINT"),
+		ProfileUploadBucket:    env.get("PROFILE_UPLOAD_BUCKET"),
+		ProfileUploadRegion:    env.getDefault("PROFILE_UPLOAD_REGION", "us-east-1"),
+		ProfileUploadPrefix:    env.get("PROFILE_UPLOAD_PREFIX"),
+		ProfileUploadAccessKey: env.get("PROFILE_UPLOAD_ACCESS_KEY"),
+		ProfileUploadSecretKey: env.get("PROFILE_UPLOAD_SECRET_KEY"),
+		ProfileUploadTimeout:   env.getDuration("PROFILE_UPLOAD_TIMEOUT", 30*time.Second),
+	}
+
+	// Sandbox is for local development: it never talks to the live Riot API
+	// and never asks for API keys, whatever the rest of the environment says.
+	if cfg.IsSandbox() {
+		cfg.AuthEnabled = false
+		cfg.RiotMode = RiotModeFixtures
+	}
+
+	if cfg.UsesRiotFixtures() && cfg.RiotBaseURL == "" {
+		cfg.RiotBaseURL = fixtureBaseURL
 	}
 
 	return cfg, cfg.validate()
 }
 
+func (c *Config) IsSandbox() bool {
+	return c.AppEnv == "sandbox"
+}
+
+func (c *Config) UsesRiotFixtures() bool {
+	return c.RiotMode == RiotModeFixtures
+}
+
 func (c *Config) validate() error {
-	if c.RiotAPIKey == "" {
-		return errors.New("RIOT_API_KEY is required")
+	switch c.RiotMode {
+	case "", RiotModeLive:
+		if c.RiotAPIKey == "" {
+			return errors.New("RIOT_API_KEY is required")
+		}
+		if c.RiotBaseURL == "" {
+			return errors.New("RIOT_BASE_URL is required")
+		}
+	case RiotModeFixtures:
+		if c.RiotFixturesDir == "" {
+			return errors.New("RIOT_FIXTURES_DIR is required when RIOT_MODE is fixtures")
+		}
+	default:
+		return fmt.Errorf("RIOT_MODE must be %s or %s", RiotModeLive, RiotModeFixtures)
+	}
+	switch c.DatabaseDriver {
+	case "", DatabaseDriverPostgres, DatabaseDriverSQLite:
+	default:
+		return fmt.Errorf("DATABASE_DRIVER must be %s or %s", DatabaseDriverPostgres, DatabaseDriverSQLite)
+	}
+	if c.DatabaseDriver == DatabaseDriverSQLite && !sqliteDriverLinked() {
+		return errors.New("DATABASE_DRIVER=sqlite requires a binary built with -tags sqlite")
+	}
+	if c.NATSNameShards < 0 {
+		return errors.New("NATS_NAME_SHARDS must not be negative")
+	}
+	if c.NATSNameShards > 0 {
+		if _, err := parseShardIDs(c.NATSNameShardIDs, c.NATSNameShards); err != nil {
+			return fmt.Errorf("NATS_NAME_SHARD_IDS: %w", err)
+		}
+	}
+	if c.NATSDedupWindow < 0 {
+		return errors.New("NATS_DEDUP_WINDOW must not be negative")
+	}
+	if c.NATSTaskMaxAttempts < 0 {
+		return errors.New("NATS_TASK_MAX_ATTEMPTS must not be negative")
+	}
+	if c.NATSTaskRetryBackoff < 0 || c.NATSTaskRetryMaxBackoff < 0 {
+		return errors.New("NATS_TASK_RETRY_BACKOFF and NATS_TASK_RETRY_MAX_BACKOFF must not be negative")
+	}
+	// A window as long as a league interval would drop the next scheduled run.
+	for _, schedule := range c.LeagueSchedules {
+		if c.NATSDedupWindow > 0 && schedule.Enabled && schedule.Interval > 0 && c.NATSDedupWindow >= schedule.Interval {
+			return fmt.Errorf("NATS_DEDUP_WINDOW must be shorter than the %s league interval (%s)", schedule.Task, schedule.Interval)
+		}
+	}
+	// The snapshot ticker cannot run on a non-positive interval.
+	if c.MetricsPersistenceEnabled && c.MetricsSnapshotInterval <= 0 {
+		return errors.New("METRICS_SNAPSHOT_INTERVAL must be positive when METRICS_PERSISTENCE_ENABLED is true")
+	}
+	if c.RequestTimeout < 0 {
+		return errors.New("REQUEST_TIMEOUT must not be negative")
+	}
+	if c.HTTPReadHeaderTimeout < 0 || c.HTTPReadTimeout < 0 || c.HTTPWriteTimeout < 0 || c.HTTPIdleTimeout < 0 {
+		return errors.New("HTTP_READ_HEADER_TIMEOUT, HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT and HTTP_IDLE_TIMEOUT must not be negative")
+	}
+	// A handler still running when the write deadline passes can no longer
+	// send its 504, and the client sees a dropped connection instead.
+	if c.HTTPWriteTimeout > 0 && c.RequestTimeout >= c.HTTPWriteTimeout {
+		return errors.New("REQUEST_TIMEOUT must be shorter than HTTP_WRITE_TIMEOUT")
+	}
+	if c.HTTPMaxHeaderBytes < 0 {
+		return errors.New("HTTP_MAX_HEADER_BYTES must not be negative")
 	}
-	if c.RiotBaseURL == "" {
-		return errors.New("RIOT_BASE_URL is required")
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
 	}
-	if c.DatabaseEnabled {
+	if c.HTTPH2C && c.TLSCertFile != "" {
+		return errors.New("HTTP_H2C is for cleartext listeners; with TLS_CERT_FILE, HTTP/2 is negotiated over TLS")
+	}
+	if _, err := parseRouteTimeouts(c.RequestTimeouts); err != nil {
+		return fmt.Errorf("REQUEST_TIMEOUTS: %w", err)
+	}
+	if _, err := parseBranchTimeouts(c.BranchTimeouts); err != nil {
+		return fmt.Errorf("BRANCH_TIMEOUTS: %w", err)
+	}
+	switch c.SchemaMismatchMode {
+	case "", SchemaMismatchRefuse, SchemaMismatchReadOnly:
+	default:
+		return fmt.Errorf("SCHEMA_MISMATCH_MODE must be %s or %s", SchemaMismatchRefuse, SchemaMismatchReadOnly)
+	}
+	if c.DatabaseEnabled && c.DatabaseDriver != DatabaseDriverSQLite {
 		if c.PostgresUser == "" {
 			return errors.New("POSTGRES_USER is required when database is enabled")
 		}
@@ -95,20 +540,141 @@ func (c *Config) validate() error {
 			return errors.New("POSTGRES_DATABASE is required when database is enabled")
 		}
 	}
+	switch c.CacheBackend {
+	case "", CacheBackendRedis:
+	case CacheBackendMemcached:
+		if _, err := parseMemcachedServers(c.MemcachedServers); err != nil {
+			return fmt.Errorf("MEMCACHED_SERVERS: %w", err)
+		}
+	default:
+		return fmt.Errorf("CACHE_BACKEND must be %s or %s", CacheBackendRedis, CacheBackendMemcached)
+	}
+	if _, err := parseLogSampling(c.LogSampling); err != nil {
+		return fmt.Errorf("LOG_SAMPLING: %w", err)
+	}
+	if _, err := parseIPAllowlist(c.OperatorAllowedIPs); err != nil {
+		return fmt.Errorf("OPERATOR_ALLOWED_IPS: %w", err)
+	}
+	if _, err := parseNotifyURLs(c.NotifyWebhookURLs); err != nil {
+		return fmt.Errorf("NOTIFY_WEBHOOK_URLS: %w", err)
+	}
+	if _, err := parseNotifyURLs(c.NotifyDiscordWebhookURLs); err != nil {
+		return fmt.Errorf("NOTIFY_DISCORD_WEBHOOK_URLS: %w", err)
+	}
+	if c.NotifyMaxAttempts < 0 {
+		return errors.New("NOTIFY_MAX_ATTEMPTS must not be negative")
+	}
+	if c.LeagueLastKnownGoodMaxAge < 0 {
+		return fmt.Errorf("LEAGUE_LAST_KNOWN_GOOD_MAX_AGE must not be negative")
+	}
+	if c.CacheEarlyRefreshBeta < 0 {
+		return errors.New("CACHE_EARLY_REFRESH_BETA must not be negative")
+	}
+	if c.ProfileEnrichLimit < 0 || c.ProfileEnrichLimit > leagueEntriesPageSize {
+		return fmt.Errorf("PROFILE_ENRICH_LIMIT must be between 0 and %d", leagueEntriesPageSize)
+	}
+	if _, err := parseSLOObjectives(c.SLOObjectives); err != nil {
+		return fmt.Errorf("SLO_OBJECTIVES: %w", err)
+	}
+	if c.SLOEnabled {
+		// Buckets are a minute wide and kept for the long window.
+		if c.SLOShortWindow < sloBucketWidth || c.SLOLongWindow <= c.SLOShortWindow || c.SLOLongWindow > 24*time.Hour {
+			return errors.New("SLO_SHORT_WINDOW must be at least 1m and shorter than SLO_LONG_WINDOW, which must not exceed 24h")
+		}
+		if c.SLOBurnRateAlert <= 0 {
+			return errors.New("SLO_BURN_RATE_ALERT must be positive")
+		}
+	}
+	// Profiles expose memory contents; never serve them unauthenticated.
+	if c.PProfEnabled && !c.AuthEnabled && c.OperatorToken == "" && c.OperatorAllowedIPs == "" {
+		return errors.New("ENABLE_PPROF requires AUTH_ENABLED, OPERATOR_TOKEN or OPERATOR_ALLOWED_IPS")
+	}
+	if c.ProfileUploadEndpoint != "" {
+		if _, err := parseProfileUploadEndpoint(c.ProfileUploadEndpoint); err != nil {
+			return fmt.Errorf("PROFILE_UPLOAD_ENDPOINT: %w", err)
+		}
+		if c.ProfileUploadBucket == "" || c.ProfileUploadAccessKey == "" || c.ProfileUploadSecretKey == "" {
+			return errors.New("PROFILE_UPLOAD_BUCKET, PROFILE_UPLOAD_ACCESS_KEY and PROFILE_UPLOAD_SECRET_KEY are required with PROFILE_UPLOAD_ENDPOINT")
+		}
+	}
+	if c.LocalCacheSize < 0 {
+		return errors.New("LOCAL_CACHE_SIZE must not be negative")
+	}
+	if c.SummonerCachePruneEnabled && c.SummonerCacheRetention < summonerCacheMaxAge {
+		return fmt.Errorf("SUMMONER_CACHE_RETENTION must be at least %s, the age up to which cached names are still served", summonerCacheMaxAge)
+	}
+	if _, err := parseLeagueRefreshRegions(c.LeagueRefreshRegions, c.RiotRegion); err != nil {
+		return fmt.Errorf("LEAGUE_REFRESH_REGIONS: %w", err)
+	}
+	if _, err := parseRiotRegions(c.RiotRegions, c.RiotRegion); err != nil {
+		return fmt.Errorf("RIOT_REGIONS: %w", err)
+	}
+	if _, err := parseRegionList(c.CrossShardProbeRegions, nil); err != nil {
+		return fmt.Errorf("CROSS_SHARD_PROBE_REGIONS: %w", err)
+	}
+	// Fanning every task out to all regions would make each sharded task
+	// refresh every region again.
+	if c.RiotRegions != "" && c.LeagueRefreshRegions != "" {
+		return errors.New("RIOT_REGIONS and LEAGUE_REFRESH_REGIONS cannot be used together")
+	}
 	return nil
 }
 
-func getEnvDefault(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+// configEnv is a CONFIG_FILE overlay: keys present in it take precedence over
+// the process environment, and keys absent from it fall back to the
+// environment and then to the defaults.
+type configEnv map[string]string
+
+func (e configEnv) get(key string) string {
+	if value, ok := e[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+func (e configEnv) getDefault(key, defaultValue string) string {
+	if value := e.get(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
-func getBoolEnvDefault(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
+func (e configEnv) getBool(key string, defaultValue bool) bool {
+	value := e.get(key)
 	if value == "" {
 		return defaultValue
 	}
 	return value == "true"
 }
+
+func (e configEnv) getInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(e.get(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func (e configEnv) getDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(e.get(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func (e configEnv) getFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(e.get(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDefault(key, defaultValue string) string {
+	return configEnv(nil).getDefault(key, defaultValue)
+}
+
+func getBoolEnvDefault(key string, defaultValue bool) bool {
+	return configEnv(nil).getBool(key, defaultValue)
+}
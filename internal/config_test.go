@@ -3,6 +3,7 @@ package internal
 import (
 	"os"
 	"testing"
+	"time"
 )
 
 func TestGetEnvDefault(t *testing.T) {
@@ -139,6 +140,187 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "sqlite driver only when linked in",
+			config: Config{
+				RiotAPIKey:     "test-key",
+				RiotBaseURL:    "https://test.api.com",
+				DatabaseDriver: DatabaseDriverSQLite,
+			},
+			expectErr: !sqliteDriverLinked(),
+		},
+		{
+			name: "fixture mode without riot api key",
+			config: Config{
+				RiotMode:        RiotModeFixtures,
+				RiotFixturesDir: "fixtures/riot",
+				RiotBaseURL:     fixtureBaseURL,
+			},
+			expectErr: false,
+		},
+		{
+			name: "fixture mode without fixtures dir",
+			config: Config{
+				RiotMode:    RiotModeFixtures,
+				RiotBaseURL: fixtureBaseURL,
+			},
+			expectErr: true,
+		},
+		{
+			name: "metrics persistence without a snapshot interval",
+			config: Config{
+				RiotAPIKey:                "test-key",
+				RiotBaseURL:               "https://test.api.com",
+				MetricsPersistenceEnabled: true,
+			},
+			expectErr: true,
+		},
+		{
+			name: "metrics snapshot interval ignored without persistence",
+			config: Config{
+				RiotAPIKey:  "test-key",
+				RiotBaseURL: "https://test.api.com",
+			},
+			expectErr: false,
+		},
+		{
+			name: "unknown riot mode",
+			config: Config{
+				RiotMode:    "replay",
+				RiotAPIKey:  "test-key",
+				RiotBaseURL: "https://test.api.com",
+			},
+			expectErr: true,
+		},
+		{
+			name: "riot regions with an unknown region",
+			config: Config{
+				RiotAPIKey:  "test-key",
+				RiotBaseURL: "https://test.api.com",
+				RiotRegions: "BR1,MARS1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "riot regions with league refresh regions",
+			config: Config{
+				RiotAPIKey:           "test-key",
+				RiotBaseURL:          "https://test.api.com",
+				RiotRegion:           "BR1",
+				RiotRegions:          "BR1,NA1",
+				LeagueRefreshRegions: "NA1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "cross shard probe with an unknown region",
+			config: Config{
+				RiotAPIKey:             "test-key",
+				RiotBaseURL:            "https://test.api.com",
+				CrossShardProbeRegions: "NA1,MARS1",
+			},
+			expectErr: true,
+		},
+		{
+			name: "summoner cache retention shorter than the read window",
+			config: Config{
+				RiotAPIKey:                "test-key",
+				RiotBaseURL:               "https://test.api.com",
+				SummonerCachePruneEnabled: true,
+				SummonerCacheRetention:    24 * time.Hour,
+			},
+			expectErr: true,
+		},
+		{
+			name: "dedup window as long as a league interval",
+			config: Config{
+				RiotAPIKey:      "test-key",
+				RiotBaseURL:     "https://test.api.com",
+				NATSDedupWindow: time.Minute,
+				LeagueSchedules: []ScheduleConfig{{Task: "challenger", Enabled: true, Interval: time.Minute}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative task retry backoff",
+			config: Config{
+				RiotAPIKey:           "test-key",
+				RiotBaseURL:          "https://test.api.com",
+				NATSTaskRetryBackoff: -time.Second,
+			},
+			expectErr: true,
+		},
+		{
+			name: "negative last known good max age",
+			config: Config{
+				RiotAPIKey:                "test-key",
+				RiotBaseURL:               "https://test.api.com",
+				LeagueLastKnownGoodMaxAge: -time.Hour,
+			},
+			expectErr: true,
+		},
+		{
+			name: "slo short window longer than the long window",
+			config: Config{
+				RiotAPIKey:       "test-key",
+				RiotBaseURL:      "https://test.api.com",
+				SLOEnabled:       true,
+				SLOObjectives:    "*=99.5",
+				SLOShortWindow:   2 * time.Hour,
+				SLOLongWindow:    time.Hour,
+				SLOBurnRateAlert: 14.4,
+			},
+			expectErr: true,
+		},
+		{
+			name: "pprof without any operator or api key auth",
+			config: Config{
+				RiotAPIKey:   "test-key",
+				RiotBaseURL:  "https://test.api.com",
+				PProfEnabled: true,
+			},
+			expectErr: true,
+		},
+		{
+			name: "request timeout outlasting the write timeout",
+			config: Config{
+				RiotAPIKey:       "test-key",
+				RiotBaseURL:      "https://test.api.com",
+				RequestTimeout:   10 * time.Second,
+				HTTPWriteTimeout: 10 * time.Second,
+			},
+			expectErr: true,
+		},
+		{
+			name: "tls cert without key",
+			config: Config{
+				RiotAPIKey:  "test-key",
+				RiotBaseURL: "https://test.api.com",
+				TLSCertFile: "/etc/tls/tls.crt",
+			},
+			expectErr: true,
+		},
+		{
+			name: "h2c with tls",
+			config: Config{
+				RiotAPIKey:  "test-key",
+				RiotBaseURL: "https://test.api.com",
+				HTTPH2C:     true,
+				TLSCertFile: "/etc/tls/tls.crt",
+				TLSKeyFile:  "/etc/tls/tls.key",
+			},
+			expectErr: true,
+		},
+		{
+			name: "profile upload without credentials",
+			config: Config{
+				RiotAPIKey:            "test-key",
+				RiotBaseURL:           "https://test.api.com",
+				ProfileUploadEndpoint: "https://s3.example.com",
+				ProfileUploadBucket:   "profiles",
+			},
+			expectErr: true,
+		},
 		{
 			name: "database enabled but missing postgres user",
 			config: Config{
@@ -177,3 +359,27 @@ func TestConfig_Validate(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadConfig_SandboxForcesFixturesWithoutAuth(t *testing.T) {
+	t.Setenv("APP_ENV", "sandbox")
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("RIOT_MODE", RiotModeLive)
+	t.Setenv("RIOT_API_KEY", "")
+	t.Setenv("RIOT_BASE_URL", "")
+	t.Setenv("DATABASE_ENABLED", "false")
+	t.Setenv("CONFIG_FILE", "")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.AuthEnabled {
+		t.Error("AuthEnabled = true, expected sandbox to disable auth")
+	}
+	if cfg.RiotMode != RiotModeFixtures {
+		t.Errorf("RiotMode = %q, expected %q", cfg.RiotMode, RiotModeFixtures)
+	}
+	if cfg.RiotBaseURL != fixtureBaseURL {
+		t.Errorf("RiotBaseURL = %q, expected %q", cfg.RiotBaseURL, fixtureBaseURL)
+	}
+}
@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// reloadableSettings are the keys a reload may change at runtime. Anything
+// else read by LoadConfig still requires a restart to take effect.
+var reloadableSettings = []struct {
+	key   string
+	value func(*Config) string
+}{
+	{"LOG_LEVEL", func(c *Config) string { return c.LogLevel }},
+	{"LOG_SAMPLING", func(c *Config) string { return c.LogSampling }},
+	{"RATE_LIMIT_METHOD_LIMITS", func(c *Config) string { return c.RateLimitMethodLimits }},
+	{"RESPONSE_CACHE_TTL", func(c *Config) string { return c.ResponseCacheTTL.String() }},
+	{"API_KEY_CACHE_TTL", func(c *Config) string { return c.APIKeyCacheTTL.String() }},
+	{"ACCOUNT_NEGATIVE_CACHE_TTL", func(c *Config) string { return c.AccountNegativeCacheTTL.String() }},
+	{"LEAGUE_LAST_KNOWN_GOOD_MAX_AGE", func(c *Config) string { return c.LeagueLastKnownGoodMaxAge.String() }},
+	{"PLACEMENT_STATS_CACHE_TTL", func(c *Config) string { return c.PlacementStatsCacheTTL.String() }},
+	{"WATCHLIST_REFRESH_INTERVAL", func(c *Config) string { return c.WatchlistRefreshInterval.String() }},
+	{"OUTBOX_RELAY_INTERVAL", func(c *Config) string { return c.OutboxRelayInterval.String() }},
+	{"CACHE_VERIFY_INTERVAL", func(c *Config) string { return c.CacheVerifyInterval.String() }},
+	{"CACHE_PRUNE_INTERVAL", func(c *Config) string { return c.CachePruneInterval.String() }},
+	{"SUMMONER_CACHE_PRUNE_INTERVAL", func(c *Config) string { return c.SummonerCachePruneInterval.String() }},
+	{"STATIC_DATA_REFRESH_INTERVAL", func(c *Config) string { return c.StaticDataRefreshInterval.String() }},
+}
+
+// readConfigFile parses KEY=VALUE lines from path into an overlay that
+// LoadConfig reads ahead of the process environment. The environment itself
+// is never modified, so a key removed from the file reverts on the next load.
+func readConfigFile(path string) (configEnv, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open config file: %w", err)
+	}
+	defer file.Close()
+
+	overlay := make(configEnv)
+	scanner := bufio.NewScanner(file)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("config file %s line %d: expected KEY=VALUE", path, number)
+		}
+		overlay[key] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return overlay, nil
+}
+
+// reloadableDuration is a duration setting read on hot paths and replaced by
+// config reloads.
+type reloadableDuration struct {
+	nanos int64
+}
+
+func newReloadableDuration(d time.Duration) reloadableDuration {
+	return reloadableDuration{nanos: int64(d)}
+}
+
+func (rd *reloadableDuration) Load() time.Duration {
+	return time.Duration(atomic.LoadInt64(&rd.nanos))
+}
+
+func (rd *reloadableDuration) Store(d time.Duration) {
+	atomic.StoreInt64(&rd.nanos, int64(d))
+}
+
+// settingSource records who last set a setting and when.
+type settingSource struct {
+	by string
+	at time.Time
+}
+
+type configSubscriber struct {
+	name  string
+	apply func(*Config)
+}
+
+// ConfigWatcher reloads configuration from the environment and CONFIG_FILE and
+// hands the new Config to subscribed components when a reloadable setting
+// changed.
+type ConfigWatcher struct {
+	logger *Logger
+
+	mu          sync.Mutex
+	startup     *Config
+	current     *Config
+	sources     map[string]settingSource
+	startedAt   time.Time
+	subscribers []configSubscriber
+}
+
+func NewConfigWatcher(cfg *Config, logger *Logger) *ConfigWatcher {
+	return &ConfigWatcher{
+		startup:   cfg,
+		current:   cfg,
+		sources:   make(map[string]settingSource),
+		startedAt: time.Now().UTC(),
+		logger:    logger,
+	}
+}
+
+func (cw *ConfigWatcher) Subscribe(name string, apply func(*Config)) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.subscribers = append(cw.subscribers, configSubscriber{name: name, apply: apply})
+}
+
+func (cw *ConfigWatcher) Current() *Config {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.current
+}
+
+// Reload returns the reloadable keys that changed, recording by as the one who
+// changed them. An invalid configuration is rejected as a whole and the
+// running settings are kept.
+func (cw *ConfigWatcher) Reload(by string) ([]string, error) {
+	next, err := LoadConfig()
+	if err != nil {
+		cw.logger.Error("config_reload_failed").
+			Component("config").
+			Operation("reload").
+			Meta("by", by).
+			Err(err).
+			Log()
+		return nil, err
+	}
+
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	changed := changedSettings(cw.current, next)
+	if len(changed) == 0 {
+		return changed, nil
+	}
+
+	for _, subscriber := range cw.subscribers {
+		subscriber.apply(next)
+		cw.logger.Debug("config_subscriber_applied").
+			Component("config").
+			Operation("reload").
+			Meta("subscriber", subscriber.name).
+			Log()
+	}
+	cw.current = next
+
+	now := time.Now().UTC()
+	for _, key := range changed {
+		cw.sources[key] = settingSource{by: by, at: now}
+	}
+
+	cw.logger.Info("config_reloaded").
+		Component("config").
+		Operation("reload").
+		Meta("changed", changed).
+		Meta("by", by).
+		Meta("subscribers", len(cw.subscribers)).
+		Log()
+	return changed, nil
+}
+
+// WatchSignals reloads on every SIGHUP until stop is called.
+func (cw *ConfigWatcher) WatchSignals() (stop func()) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-hup:
+				cw.Reload("SIGHUP")
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(hup)
+		close(done)
+	}
+}
+
+func changedSettings(prev, next *Config) []string {
+	changed := []string{}
+	for _, setting := range reloadableSettings {
+		if setting.value(prev) != setting.value(next) {
+			changed = append(changed, setting.key)
+		}
+	}
+
+	intervals := make(map[string]time.Duration, len(prev.LeagueSchedules))
+	for _, schedule := range prev.LeagueSchedules {
+		intervals[schedule.Task] = schedule.Interval
+	}
+	for _, schedule := range next.LeagueSchedules {
+		if intervals[schedule.Task] != schedule.Interval {
+			changed = append(changed, "SCHEDULE_"+strings.ToUpper(schedule.Task)+"_INTERVAL")
+		}
+	}
+	return changed
+}
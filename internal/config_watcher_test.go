@@ -0,0 +1,186 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReadConfigFile(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "info")
+	t.Setenv("RESPONSE_CACHE_TTL", "5s")
+
+	path := filepath.Join(t.TempDir(), "tft-core.env")
+	content := "# runtime overrides\nLOG_LEVEL = debug\n\nRESPONSE_CACHE_TTL=\"30s\"\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	overlay, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("readConfigFile() error = %v", err)
+	}
+	if got := overlay.get("LOG_LEVEL"); got != "debug" {
+		t.Errorf("LOG_LEVEL = %q, expected debug", got)
+	}
+	if got := overlay.getDuration("RESPONSE_CACHE_TTL", 0); got != 30*time.Second {
+		t.Errorf("RESPONSE_CACHE_TTL = %v, expected 30s", got)
+	}
+	if got := os.Getenv("LOG_LEVEL"); got != "info" {
+		t.Errorf("process LOG_LEVEL = %q, expected the file to leave it untouched", got)
+	}
+
+	if err := os.WriteFile(path, []byte("LOG_LEVEL\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readConfigFile(path); err == nil {
+		t.Error("expected error for a line without '='")
+	}
+}
+
+func TestLoadConfig_RemovedFileKeyReverts(t *testing.T) {
+	t.Setenv("RIOT_API_KEY", "test-key")
+	t.Setenv("RIOT_BASE_URL", "https://br1.api.riotgames.com")
+	t.Setenv("DATABASE_ENABLED", "false")
+	t.Setenv("LOG_LEVEL", "warn")
+	t.Setenv("RESPONSE_CACHE_TTL", "")
+
+	path := filepath.Join(t.TempDir(), "tft-core.env")
+	t.Setenv("CONFIG_FILE", path)
+	if err := os.WriteFile(path, []byte("LOG_LEVEL=debug\nRESPONSE_CACHE_TTL=45s\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "debug" || cfg.ResponseCacheTTL != 45*time.Second {
+		t.Fatalf("LoadConfig() = %q, %v; expected the file values", cfg.LogLevel, cfg.ResponseCacheTTL)
+	}
+
+	if err := os.WriteFile(path, []byte("# emptied\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err = LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.LogLevel != "warn" {
+		t.Errorf("LogLevel = %q, expected the environment value once removed from the file", cfg.LogLevel)
+	}
+	if cfg.ResponseCacheTTL != 5*time.Second {
+		t.Errorf("ResponseCacheTTL = %v, expected the default once removed from the file", cfg.ResponseCacheTTL)
+	}
+}
+
+func TestChangedSettings(t *testing.T) {
+	prev := &Config{
+		LogLevel:         "info",
+		ResponseCacheTTL: 5 * time.Second,
+		LeagueSchedules:  []ScheduleConfig{{Task: "challenger", Interval: 30 * time.Minute}},
+	}
+
+	tests := []struct {
+		name     string
+		mutate   func(*Config)
+		expected []string
+	}{
+		{name: "unchanged", mutate: func(c *Config) {}, expected: []string{}},
+		{name: "restart only setting", mutate: func(c *Config) { c.AppPort = "9000" }, expected: []string{}},
+		{
+			name: "log level and ttl",
+			mutate: func(c *Config) {
+				c.LogLevel = "debug"
+				c.ResponseCacheTTL = time.Minute
+			},
+			expected: []string{"LOG_LEVEL", "RESPONSE_CACHE_TTL"},
+		},
+		{
+			name: "league schedule",
+			mutate: func(c *Config) {
+				c.LeagueSchedules = []ScheduleConfig{{Task: "challenger", Interval: 10 * time.Minute}}
+			},
+			expected: []string{"SCHEDULE_CHALLENGER_INTERVAL"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := *prev
+			tt.mutate(&next)
+			if got := changedSettings(prev, &next); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("changedSettings() = %v, expected %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConfigWatcher_Reload(t *testing.T) {
+	t.Setenv("RIOT_API_KEY", "test-key")
+	t.Setenv("RIOT_BASE_URL", "https://br1.api.riotgames.com")
+	t.Setenv("DATABASE_ENABLED", "false")
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("LOG_LEVEL", "error")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	logger, logs := newCaptureLogger()
+	logger.SetLevel(LogLevelError)
+	watcher := NewConfigWatcher(cfg, logger)
+
+	var applied []string
+	watcher.Subscribe("logger", func(c *Config) {
+		applied = append(applied, c.LogLevel)
+		logger.SetLevel(LogLevel(c.LogLevel))
+	})
+
+	changed, err := watcher.Reload("test")
+	if err != nil || len(changed) != 0 || len(applied) != 0 {
+		t.Fatalf("Reload() without changes = %v, %v; subscribers called %d times", changed, err, len(applied))
+	}
+
+	t.Setenv("LOG_LEVEL", "debug")
+	changed, err = watcher.Reload("test")
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !reflect.DeepEqual(changed, []string{"LOG_LEVEL"}) || !reflect.DeepEqual(applied, []string{"debug"}) {
+		t.Errorf("Reload() changed %v, applied %v", changed, applied)
+	}
+	if !logger.shouldLog(LogLevelDebug) || watcher.Current().LogLevel != "debug" {
+		t.Error("expected debug level to be active after reload")
+	}
+	logs.Expect(t, expectedEvent{Level: LogLevelInfo, Message: "config_reloaded", Component: "config", Operation: "reload", Meta: map[string]interface{}{"by": "test", "changed": []string{"LOG_LEVEL"}}})
+
+	t.Setenv("RIOT_API_KEY", "")
+	if _, err := watcher.Reload("test"); err == nil {
+		t.Error("expected invalid configuration to be rejected")
+	}
+	if watcher.Current().LogLevel != "debug" {
+		t.Error("rejected reload must keep the current configuration")
+	}
+}
+
+func TestScheduler_ApplyConfig(t *testing.T) {
+	scheduler := NewScheduler(0, nil, newTestLogger())
+	scheduler.Register(ScheduledTask{Name: "watchlist_refresh", Interval: 15 * time.Minute})
+	scheduler.Register(ScheduledTask{Name: "league_update_challenger", Interval: 30 * time.Minute})
+
+	scheduler.ApplyConfig(&Config{
+		WatchlistRefreshInterval: 5 * time.Minute,
+		LeagueSchedules:          []ScheduleConfig{{Task: "challenger", Interval: 0}},
+	})
+
+	if got := scheduler.interval("watchlist_refresh"); got != 5*time.Minute {
+		t.Errorf("watchlist_refresh interval = %v, expected 5m", got)
+	}
+	if got := scheduler.interval("league_update_challenger"); got != 30*time.Minute {
+		t.Errorf("league_update_challenger interval = %v, expected non-positive interval to be ignored", got)
+	}
+}
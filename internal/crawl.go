@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"context"
+	"time"
+)
+
+type CrawlCoverage struct {
+	Region          string    `json:"region"`
+	Tier            string    `json:"tier"`
+	Division        string    `json:"division"`
+	PagesCompleted  int       `json:"pagesCompleted"`
+	HighestPage     int       `json:"highestPage"`
+	EntriesCount    int       `json:"entriesDiscovered"`
+	ReachedLastPage bool      `json:"reachedLastPage"`
+	FirstCrawledAt  time.Time `json:"firstCrawledAt"`
+	LastCrawledAt   time.Time `json:"lastCrawledAt"`
+}
+
+func (dm *DatabaseManager) RecordCrawlPage(ctx context.Context, region, tier, division string, page, entriesCount int, hasMore bool) error {
+	if dm == nil || !dm.Enabled {
+		return nil
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "record_crawl_page")
+	defer span.End()
+
+	query := `
+		INSERT INTO crawl_pages (region, tier, division, page, entries_count, has_more)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (region, tier, division, page) DO UPDATE SET
+			entries_count = $5,
+			has_more = $6,
+			crawled_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := dm.DB.ExecContext(ctx, query, region, tier, division, page, entriesCount, hasMore)
+	recordSpanError(span, err)
+	return err
+}
+
+func (dm *DatabaseManager) GetCrawlCoverage(ctx context.Context, region string) ([]CrawlCoverage, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_crawl_coverage")
+	defer span.End()
+
+	query := `
+		SELECT region, tier, division,
+			COUNT(*),
+			MAX(page),
+			SUM(entries_count),
+			BOOL_OR(NOT has_more),
+			MIN(crawled_at),
+			MAX(crawled_at)
+		FROM crawl_pages
+		WHERE ($1 = '' OR region = $1)
+		GROUP BY region, tier, division
+		ORDER BY region, tier, division
+	`
+
+	rows, err := dm.queryAnalytics(ctx, query, region)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	coverage := []CrawlCoverage{}
+	for rows.Next() {
+		var c CrawlCoverage
+		if err := rows.Scan(
+			&c.Region,
+			&c.Tier,
+			&c.Division,
+			&c.PagesCompleted,
+			&c.HighestPage,
+			&c.EntriesCount,
+			&c.ReachedLastPage,
+			&c.FirstCrawledAt,
+			&c.LastCrawledAt,
+		); err != nil {
+			return nil, err
+		}
+		coverage = append(coverage, c)
+	}
+
+	return coverage, rows.Err()
+}
@@ -1,19 +1,28 @@
 package internal
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	_ "github.com/lib/pq"
 )
 
 type DatabaseManager struct {
-	DB      *sql.DB
-	Enabled bool
+	DB       *sql.DB
+	Enabled  bool
+	Dialect  string
+	ReadOnly bool
+
+	replica        *readReplica
+	watchlistQuota int
 }
 
+const summonerCacheMaxAge = 7 * 24 * time.Hour
+
 type SummonerCacheEntry struct {
 	PUUID       string
 	GameName    string
@@ -30,6 +39,29 @@ func NewDatabaseManager(cfg *Config) *DatabaseManager {
 		return &DatabaseManager{Enabled: false}
 	}
 
+	dm, err := ConnectDatabase(cfg)
+	if err != nil {
+		log.Printf("Error connecting to database: %v", err)
+		return &DatabaseManager{Enabled: false}
+	}
+	return dm
+}
+
+func ConnectDatabase(cfg *Config) (*DatabaseManager, error) {
+	return connectDatabase(cfg, false)
+}
+
+// ConnectDatabaseReadOnly opens sessions the database itself keeps read-only,
+// used when the schema does not match this binary.
+func ConnectDatabaseReadOnly(cfg *Config) (*DatabaseManager, error) {
+	return connectDatabase(cfg, true)
+}
+
+func connectDatabase(cfg *Config, readOnly bool) (*DatabaseManager, error) {
+	if cfg.DatabaseDriver == DatabaseDriverSQLite {
+		return connectSQLite(cfg, readOnly)
+	}
+
 	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.PostgresHost,
 		cfg.PostgresPort,
@@ -38,11 +70,14 @@ func NewDatabaseManager(cfg *Config) *DatabaseManager {
 		cfg.PostgresDB,
 		cfg.PostgresSSLMode,
 	)
+	if readOnly {
+		// lib/pq forwards unknown keys as session parameters.
+		dsn += " default_transaction_read_only=on"
+	}
 
 	db, err := sql.Open("postgres", dsn)
 	if err != nil {
-		log.Printf("Error connecting to database: %v", err)
-		return &DatabaseManager{Enabled: false}
+		return nil, err
 	}
 
 	db.SetMaxOpenConns(25)
@@ -50,30 +85,37 @@ func NewDatabaseManager(cfg *Config) *DatabaseManager {
 	db.SetConnMaxLifetime(30 * time.Minute)
 
 	if err := db.Ping(); err != nil {
-		log.Printf("Error pinging database: %v", err)
-		return &DatabaseManager{Enabled: false}
+		db.Close()
+		return nil, err
 	}
 
 	log.Println("Database connected successfully")
 	return &DatabaseManager{
-		DB:      db,
-		Enabled: true,
-	}
+		DB:             db,
+		Enabled:        true,
+		Dialect:        DatabaseDriverPostgres,
+		ReadOnly:       readOnly,
+		watchlistQuota: cfg.WatchlistMaxPlayers,
+	}, nil
 }
 
-func (dm *DatabaseManager) GetSummonerName(puuid string) (string, error) {
+func (dm *DatabaseManager) GetSummonerName(ctx context.Context, puuid string) (string, error) {
 	if !dm.Enabled {
 		return "", fmt.Errorf("database not enabled")
 	}
 
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_summoner_name")
+	defer span.End()
+
 	var entry SummonerCacheEntry
 	query := `
 		SELECT puuid, game_name, tag_line, summoner_id, region, last_updated, created_at
 		FROM summoner_cache 
-		WHERE puuid = $1 AND last_updated > NOW() - INTERVAL '7 days'
+		WHERE puuid = $1 AND last_updated > $2
 	`
 
-	err := dm.DB.QueryRow(query, puuid).Scan(
+	cutoff := time.Now().UTC().Add(-summonerCacheMaxAge)
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(query), puuid, cutoff).Scan(
 		&entry.PUUID,
 		&entry.GameName,
 		&entry.TagLine,
@@ -84,17 +126,68 @@ func (dm *DatabaseManager) GetSummonerName(puuid string) (string, error) {
 	)
 
 	if err != nil {
+		if err != sql.ErrNoRows {
+			recordSpanError(span, err)
+		}
 		return "", err
 	}
 
 	return fmt.Sprintf("%s#%s", entry.GameName, entry.TagLine), nil
 }
 
-func (dm *DatabaseManager) SetSummonerName(puuid, gameName, tagLine, summonerID, region string) error {
+// GetSummonerNames is the batch form of GetSummonerName; PUUIDs without a
+// fresh entry are left out.
+func (dm *DatabaseManager) GetSummonerNames(ctx context.Context, puuids []string) (map[string]string, error) {
+	if !dm.Enabled {
+		return nil, fmt.Errorf("database not enabled")
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_summoner_names")
+	defer span.End()
+
+	placeholders := make([]string, len(puuids))
+	args := make([]interface{}, 0, len(puuids)+1)
+	args = append(args, time.Now().UTC().Add(-summonerCacheMaxAge))
+	for i, puuid := range puuids {
+		placeholders[i] = fmt.Sprintf("$%d", i+2)
+		args = append(args, puuid)
+	}
+
+	query := `
+		SELECT puuid, game_name, tag_line
+		FROM summoner_cache
+		WHERE last_updated > $1 AND puuid IN (` + strings.Join(placeholders, ", ") + `)
+	`
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(query), args...)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	names := make(map[string]string, len(puuids))
+	for rows.Next() {
+		var puuid, gameName, tagLine string
+		if err := rows.Scan(&puuid, &gameName, &tagLine); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		names[puuid] = fmt.Sprintf("%s#%s", gameName, tagLine)
+	}
+	err = rows.Err()
+	recordSpanError(span, err)
+	return names, err
+}
+
+func (dm *DatabaseManager) SetSummonerName(ctx context.Context, puuid, gameName, tagLine, summonerID, region string) error {
 	if !dm.Enabled {
 		return nil
 	}
 
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "set_summoner_name")
+	defer span.End()
+
 	query := `
 		INSERT INTO summoner_cache (puuid, game_name, tag_line, summoner_id, region) 
 		VALUES ($1, $2, $3, $4, $5)
@@ -106,8 +199,9 @@ func (dm *DatabaseManager) SetSummonerName(puuid, gameName, tagLine, summonerID,
 			last_updated = CURRENT_TIMESTAMP
 	`
 
-	_, err := dm.DB.Exec(query, puuid, gameName, tagLine, summonerID, region)
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(query), puuid, gameName, tagLine, summonerID, region)
 	if err != nil {
+		recordSpanError(span, err)
 		log.Printf("Error saving summoner cache: %v", err)
 		return err
 	}
@@ -116,8 +210,144 @@ func (dm *DatabaseManager) SetSummonerName(puuid, gameName, tagLine, summonerID,
 	return nil
 }
 
+// DeleteStaleSummonerCache deletes up to limit rows last refreshed before
+// cutoff, oldest first.
+func (dm *DatabaseManager) DeleteStaleSummonerCache(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+	if !dm.Enabled {
+		return 0, nil
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "delete_stale_summoner_cache")
+	defer span.End()
+
+	query := `
+		DELETE FROM summoner_cache
+		WHERE puuid IN (
+			SELECT puuid FROM summoner_cache
+			WHERE last_updated < $1
+			ORDER BY last_updated
+			LIMIT $2
+		)
+	`
+
+	result, err := dm.DB.ExecContext(ctx, dm.rebind(query), cutoff, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// TableStats reports row counts per table. Postgres also reports dead tuples,
+// on-disk size and the last autovacuum; SQLite only counts rows.
+type TableStats struct {
+	Table          string     `json:"table"`
+	LiveRows       int64      `json:"liveRows"`
+	DeadRows       int64      `json:"deadRows"`
+	SizeBytes      int64      `json:"sizeBytes,omitempty"`
+	LastAutovacuum *time.Time `json:"lastAutovacuum,omitempty"`
+}
+
+func (dm *DatabaseManager) TableStats(ctx context.Context) ([]TableStats, error) {
+	if !dm.Enabled {
+		return nil, fmt.Errorf("database not enabled")
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "table_stats")
+	defer span.End()
+
+	var stats []TableStats
+	var err error
+	if dm.Dialect == DatabaseDriverSQLite {
+		stats, err = dm.sqliteTableStats(ctx)
+	} else {
+		stats, err = dm.postgresTableStats(ctx)
+	}
+	recordSpanError(span, err)
+	return stats, err
+}
+
+func (dm *DatabaseManager) postgresTableStats(ctx context.Context) ([]TableStats, error) {
+	rows, err := dm.DB.QueryContext(ctx, `
+		SELECT relname, n_live_tup, n_dead_tup, pg_total_relation_size(relid), last_autovacuum
+		FROM pg_stat_user_tables
+		ORDER BY relname
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stats []TableStats
+	for rows.Next() {
+		var table TableStats
+		var lastAutovacuum sql.NullTime
+		if err := rows.Scan(&table.Table, &table.LiveRows, &table.DeadRows, &table.SizeBytes, &lastAutovacuum); err != nil {
+			return nil, err
+		}
+		if lastAutovacuum.Valid {
+			table.LastAutovacuum = &lastAutovacuum.Time
+		}
+		stats = append(stats, table)
+	}
+	return stats, rows.Err()
+}
+
+func (dm *DatabaseManager) sqliteTableStats(ctx context.Context) ([]TableStats, error) {
+	rows, err := dm.DB.QueryContext(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Names come from sqlite_master, so quoting them is enough.
+	stats := make([]TableStats, 0, len(tables))
+	for _, name := range tables {
+		table := TableStats{Table: name}
+		if err := dm.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+name+`"`).Scan(&table.LiveRows); err != nil {
+			return nil, err
+		}
+		stats = append(stats, table)
+	}
+	return stats, nil
+}
+
+// StaleSummonerCacheRows counts the rows the next prune run would target.
+func (dm *DatabaseManager) StaleSummonerCacheRows(ctx context.Context, cutoff time.Time) (int64, error) {
+	if !dm.Enabled {
+		return 0, fmt.Errorf("database not enabled")
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "count_stale_summoner_cache")
+	defer span.End()
+
+	var count int64
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`SELECT COUNT(*) FROM summoner_cache WHERE last_updated < $1`), cutoff).Scan(&count)
+	recordSpanError(span, err)
+	return count, err
+}
+
 func (dm *DatabaseManager) Close() {
 	if dm.Enabled && dm.DB != nil {
 		dm.DB.Close()
 	}
+	if dm.replica != nil {
+		dm.replica.db.Close()
+	}
 }
@@ -0,0 +1,368 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	deadLetterSubjectPrefix = "tft.dlq."
+	maxDeadLetterRequeue    = 100
+)
+
+// TaskRetryPolicy bounds how often a worker retries a failed task before it
+// is dead-lettered. Backoff doubles after every attempt up to MaxBackoff.
+type TaskRetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+}
+
+func (p TaskRetryPolicy) attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// delay is the wait before the retry that follows attempt (1-based).
+func (p TaskRetryPolicy) delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay > 0; i++ {
+		delay *= 2
+		if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+			return p.MaxBackoff
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return delay
+}
+
+type permanentTaskError struct {
+	err error
+}
+
+func (e *permanentTaskError) Error() string { return e.err.Error() }
+func (e *permanentTaskError) Unwrap() error { return e.err }
+
+// permanentTask marks a task error that retrying cannot fix, such as a
+// payload that does not decode, so the task is dead-lettered at once.
+func permanentTask(err error) error {
+	return &permanentTaskError{err: err}
+}
+
+// DeadLetter is a worker task that failed every attempt.
+type DeadLetter struct {
+	ID         int64      `json:"id"`
+	Subject    string     `json:"subject"`
+	Worker     string     `json:"worker"`
+	Payload    string     `json:"payload"`
+	Attempts   int        `json:"attempts"`
+	LastError  string     `json:"lastError"`
+	FailedAt   time.Time  `json:"failedAt"`
+	RequeuedAt *time.Time `json:"requeuedAt,omitempty"`
+}
+
+type deadLetterStore interface {
+	AddDeadLetter(ctx context.Context, letter DeadLetter) error
+}
+
+// SetDeadLetterStore makes workers keep exhausted tasks in db. Without it
+// they are published to tft.dlq.<subject> for whoever listens there.
+func (nc *NATSClient) SetDeadLetterStore(db *DatabaseManager) {
+	nc.deadLetters = db
+}
+
+// handleTask runs process until it succeeds, fails permanently or runs out
+// of attempts, and dead-letters the task in the last two cases.
+func (nc *NATSClient) handleTask(ctx context.Context, worker string, msg *nats.Msg, process func(ctx context.Context) error) {
+	attempts := nc.retryPolicy.attempts()
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		if err = process(ctx); err == nil {
+			return
+		}
+		var permanent *permanentTaskError
+		if errors.As(err, &permanent) || attempt >= attempts || nc.draining.Load() {
+			break
+		}
+
+		delay := nc.retryPolicy.delay(attempt)
+		nc.logger.Warn("worker_task_retry").
+			Component("nats").
+			Operation(worker).
+			Meta("subject", msg.Subject).
+			Meta("attempt", attempt).
+			Meta("retry_in", delay.String()).
+			Err(err).
+			Log()
+		if !nc.waitRetry(ctx, delay) {
+			break
+		}
+	}
+
+	// The task's own context may be the reason retrying stopped.
+	nc.deadLetter(context.WithoutCancel(ctx), worker, msg, attempt, err)
+}
+
+// waitRetry waits delay before the next attempt. It reports false as soon as
+// ctx is done or draining starts, so the task is dead-lettered right away
+// instead of holding up shutdown.
+func (nc *NATSClient) waitRetry(ctx context.Context, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-nc.drainStarted():
+		return false
+	}
+}
+
+func (nc *NATSClient) deadLetter(ctx context.Context, worker string, msg *nats.Msg, attempts int, taskErr error) {
+	if nc.metrics != nil {
+		nc.metrics.RecordDeadLetter(worker)
+	}
+
+	letter := DeadLetter{
+		Subject:   msg.Subject,
+		Worker:    worker,
+		Payload:   string(msg.Data),
+		Attempts:  attempts,
+		LastError: taskErr.Error(),
+		FailedAt:  time.Now().UTC(),
+	}
+
+	destination := "database"
+	var err error
+	if nc.deadLetters != nil {
+		err = nc.deadLetters.AddDeadLetter(ctx, letter)
+	}
+	if nc.deadLetters == nil || err != nil {
+		destination = deadLetterSubjectPrefix + msg.Subject
+		dlq := nats.NewMsg(destination)
+		dlq.Data = msg.Data
+		dlq.Header.Set("Tft-Worker", worker)
+		dlq.Header.Set("Tft-Attempts", strconv.Itoa(attempts))
+		dlq.Header.Set("Tft-Error", letter.LastError)
+		err = errors.Join(err, nc.Conn.PublishMsg(dlq))
+	}
+
+	builder := nc.logger.Error("worker_task_dead_lettered").
+		Component("nats").
+		Operation(worker).
+		Meta("subject", msg.Subject).
+		Meta("attempts", attempts).
+		Meta("destination", destination).
+		Err(taskErr)
+	if err != nil {
+		builder = builder.Meta("dead_letter_error", err.Error())
+	}
+	builder.Log()
+}
+
+func (dm *DatabaseManager) AddDeadLetter(ctx context.Context, letter DeadLetter) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "add_dead_letter")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		INSERT INTO dead_letter_tasks (subject, worker, payload, attempts, last_error, failed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`), letter.Subject, letter.Worker, letter.Payload, letter.Attempts, letter.LastError, letter.FailedAt)
+	recordSpanError(span, err)
+	return err
+}
+
+// ListDeadLetters returns the newest dead letters first, only those not yet
+// requeued unless includeRequeued is set.
+func (dm *DatabaseManager) ListDeadLetters(ctx context.Context, worker string, includeRequeued bool, limit int) ([]DeadLetter, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "list_dead_letters")
+	defer span.End()
+
+	query := `
+		SELECT id, subject, worker, payload, attempts, last_error, failed_at, requeued_at
+		FROM dead_letter_tasks
+		WHERE ($1 = '' OR worker = $1)`
+	if !includeRequeued {
+		query += ` AND requeued_at IS NULL`
+	}
+	query += ` ORDER BY id DESC LIMIT $2`
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(query), worker, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	letters := []DeadLetter{}
+	for rows.Next() {
+		var letter DeadLetter
+		var requeuedAt sql.NullTime
+		if err := rows.Scan(&letter.ID, &letter.Subject, &letter.Worker, &letter.Payload, &letter.Attempts, &letter.LastError, &letter.FailedAt, &requeuedAt); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		if requeuedAt.Valid {
+			letter.RequeuedAt = &requeuedAt.Time
+		}
+		letters = append(letters, letter)
+	}
+	err = rows.Err()
+	recordSpanError(span, err)
+	return letters, err
+}
+
+// ClaimDeadLetter marks a pending dead letter as requeued and returns it.
+// It returns sql.ErrNoRows when the letter does not exist or was already
+// requeued, so two admins requeueing at once publish it only once.
+func (dm *DatabaseManager) ClaimDeadLetter(ctx context.Context, id int64) (*DeadLetter, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "claim_dead_letter")
+	defer span.End()
+
+	var letter DeadLetter
+	var requeuedAt time.Time
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		UPDATE dead_letter_tasks SET requeued_at = $2
+		WHERE id = $1 AND requeued_at IS NULL
+		RETURNING id, subject, worker, payload, attempts, last_error, failed_at, requeued_at
+	`), id, time.Now().UTC()).Scan(&letter.ID, &letter.Subject, &letter.Worker, &letter.Payload, &letter.Attempts, &letter.LastError, &letter.FailedAt, &requeuedAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			recordSpanError(span, err)
+		}
+		return nil, err
+	}
+	letter.RequeuedAt = &requeuedAt
+	return &letter, nil
+}
+
+// ReleaseDeadLetter undoes ClaimDeadLetter when publishing the task failed.
+func (dm *DatabaseManager) ReleaseDeadLetter(ctx context.Context, id int64) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "release_dead_letter")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		UPDATE dead_letter_tasks SET requeued_at = NULL WHERE id = $1
+	`), id)
+	recordSpanError(span, err)
+	return err
+}
+
+// DeadLetterHandler lists dead-lettered worker tasks (GET) and publishes
+// them again on their original subject (POST {"ids": [...]}).
+func DeadLetterHandler(db *DatabaseManager, natsClient *NATSClient, logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			listDeadLetters(db, logger, w, r)
+		case http.MethodPost:
+			requeueDeadLetters(db, natsClient, logger, w, r)
+		default:
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+		}
+	})
+}
+
+func listDeadLetters(db *DatabaseManager, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	v := NewValidator(r.URL.Query())
+	limit := v.IntRange("limit", 50, 1, 500)
+	includeRequeued := v.Bool("includeRequeued")
+	if err := v.Err(); err != nil {
+		writeError(w, err, logger, r)
+		return
+	}
+
+	letters, err := db.ListDeadLetters(r.Context(), r.URL.Query().Get("worker"), includeRequeued != nil && *includeRequeued, limit)
+	if err != nil {
+		logger.Error("dead_letters_list_failed").
+			Component("admin").
+			Operation("list_dead_letters").
+			Request("", "", GetRequestID(r.Context())).
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to load dead letters", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"deadLetters": letters, "count": len(letters)}, logger, r)
+}
+
+func requeueDeadLetters(db *DatabaseManager, natsClient *NATSClient, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	requestID := GetRequestID(r.Context())
+
+	if db.ReadOnly {
+		writeError(w, NewAPIError("Database is read-only", http.StatusServiceUnavailable), logger, r)
+		return
+	}
+	if natsClient == nil {
+		writeError(w, NewAPIError("NATS unavailable", http.StatusServiceUnavailable), logger, r)
+		return
+	}
+
+	var body struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeError(w, NewAPIError("invalid JSON body", http.StatusBadRequest), logger, r)
+		return
+	}
+	if len(body.IDs) == 0 || len(body.IDs) > maxDeadLetterRequeue {
+		writeError(w, NewAPIError("ids must list between 1 and "+strconv.Itoa(maxDeadLetterRequeue)+" dead letters", http.StatusBadRequest), logger, r)
+		return
+	}
+
+	requeued := []int64{}
+	notFound := []int64{}
+	for _, id := range body.IDs {
+		letter, err := db.ClaimDeadLetter(r.Context(), id)
+		if err == sql.ErrNoRows {
+			notFound = append(notFound, id)
+			continue
+		}
+		if err == nil {
+			if err = natsClient.Publish(r.Context(), letter.Subject, []byte(letter.Payload)); err != nil {
+				db.ReleaseDeadLetter(r.Context(), id)
+			}
+		}
+		if err != nil {
+			logger.Error("dead_letter_requeue_failed").
+				Component("admin").
+				Operation("requeue_dead_letters").
+				Request("", "", requestID).
+				Meta("dead_letter_id", id).
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to requeue dead letters", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+		requeued = append(requeued, id)
+	}
+
+	logger.Info("dead_letters_requeued").
+		Component("admin").
+		Operation("requeue_dead_letters").
+		Request("", "", requestID).
+		Meta("requeued", len(requeued)).
+		Meta("not_found", len(notFound)).
+		Log()
+
+	writeJSON(w, map[string]interface{}{"requeued": requeued, "notFound": notFound}, logger, r)
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestTaskRetryPolicy_Delay(t *testing.T) {
+	policy := TaskRetryPolicy{MaxAttempts: 5, Backoff: time.Second, MaxBackoff: 5 * time.Second}
+
+	tests := []struct {
+		attempt  int
+		expected time.Duration
+	}{
+		{attempt: 1, expected: time.Second},
+		{attempt: 2, expected: 2 * time.Second},
+		{attempt: 3, expected: 4 * time.Second},
+		{attempt: 4, expected: 5 * time.Second},
+		{attempt: 10, expected: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if delay := policy.delay(tt.attempt); delay != tt.expected {
+			t.Errorf("delay(%d) = %v, expected %v", tt.attempt, delay, tt.expected)
+		}
+	}
+	if attempts := (TaskRetryPolicy{}).attempts(); attempts != 1 {
+		t.Errorf("zero policy attempts = %d, expected 1", attempts)
+	}
+}
+
+type fakeDeadLetterStore struct {
+	letters []DeadLetter
+}
+
+func (f *fakeDeadLetterStore) AddDeadLetter(ctx context.Context, letter DeadLetter) error {
+	f.letters = append(f.letters, letter)
+	return nil
+}
+
+func TestNATSClient_HandleTask(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		err              error
+		expectedCalls    int
+		expectDeadLetter bool
+	}{
+		{name: "succeeds first time", expectedCalls: 1},
+		{name: "succeeds on retry", failures: 2, err: errors.New("riot unavailable"), expectedCalls: 3},
+		{name: "exhausts attempts", failures: 5, err: errors.New("riot unavailable"), expectedCalls: 3, expectDeadLetter: true},
+		{name: "permanent failure", failures: 5, err: permanentTask(errors.New("bad payload")), expectedCalls: 1, expectDeadLetter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, logs := newCaptureLogger()
+			store := &fakeDeadLetterStore{}
+			nc := &NATSClient{
+				logger:      logger,
+				metrics:     NewMetricsCollector(logger, nil),
+				retryPolicy: TaskRetryPolicy{MaxAttempts: 3},
+				deadLetters: store,
+			}
+			msg := &nats.Msg{Subject: "tft.league.update", Data: []byte(`{"type":"challenger"}`)}
+
+			calls := 0
+			nc.handleTask(t.Context(), "league_update", msg, func(ctx context.Context) error {
+				calls++
+				if calls <= tt.failures {
+					return tt.err
+				}
+				return nil
+			})
+
+			if calls != tt.expectedCalls {
+				t.Errorf("calls = %d, expected %d", calls, tt.expectedCalls)
+			}
+			if !tt.expectDeadLetter {
+				if len(store.letters) != 0 {
+					t.Errorf("dead letters = %+v, expected none", store.letters)
+				}
+				return
+			}
+			if len(store.letters) != 1 {
+				t.Fatalf("dead letters = %d, expected 1", len(store.letters))
+			}
+			letter := store.letters[0]
+			if letter.Subject != msg.Subject || letter.Worker != "league_update" || letter.Payload != string(msg.Data) || letter.Attempts != tt.expectedCalls || letter.LastError != tt.err.Error() {
+				t.Errorf("dead letter = %+v", letter)
+			}
+			logs.Expect(t, expectedEvent{Level: LogLevelError, Message: "worker_task_dead_lettered", Component: "nats", Operation: "league_update", Meta: map[string]interface{}{"destination": "database"}})
+		})
+	}
+}
+
+func TestNATSClient_HandleTaskStopsRetrying(t *testing.T) {
+	tests := []struct {
+		name string
+		stop func(nc *NATSClient, cancel context.CancelFunc)
+	}{
+		{name: "drain starts", stop: func(nc *NATSClient, cancel context.CancelFunc) {
+			nc.draining.Store(true)
+			close(nc.drainStarted())
+		}},
+		{name: "context canceled", stop: func(nc *NATSClient, cancel context.CancelFunc) { cancel() }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeDeadLetterStore{}
+			nc := &NATSClient{
+				logger:      newTestLogger(),
+				retryPolicy: TaskRetryPolicy{MaxAttempts: 3, Backoff: time.Hour},
+				deadLetters: store,
+			}
+			msg := &nats.Msg{Subject: "tft.league.update", Data: []byte(`{"type":"challenger"}`)}
+			ctx, cancel := context.WithCancel(t.Context())
+			defer cancel()
+
+			failed := make(chan struct{}, 3)
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				nc.handleTask(ctx, "league_update", msg, func(ctx context.Context) error {
+					failed <- struct{}{}
+					return errors.New("riot unavailable")
+				})
+			}()
+
+			<-failed
+			tt.stop(nc, cancel)
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("handleTask() kept waiting out the retry backoff")
+			}
+
+			if len(store.letters) != 1 || store.letters[0].Attempts != 1 {
+				t.Errorf("dead letters = %+v, expected one after the first attempt", store.letters)
+			}
+		})
+	}
+}
+
+func TestDeadLetterHandler_Unavailable(t *testing.T) {
+	tests := []struct {
+		name     string
+		db       *DatabaseManager
+		method   string
+		expected int
+	}{
+		{name: "no database", method: http.MethodGet, expected: http.StatusServiceUnavailable},
+		{name: "read-only requeue", db: &DatabaseManager{Enabled: true, ReadOnly: true}, method: http.MethodPost, expected: http.StatusServiceUnavailable},
+		{name: "requeue without nats", db: &DatabaseManager{Enabled: true}, method: http.MethodPost, expected: http.StatusServiceUnavailable},
+		{name: "unsupported method", db: &DatabaseManager{Enabled: true}, method: http.MethodDelete, expected: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			DeadLetterHandler(tt.db, nil, newTestLogger())(w, httptest.NewRequest(tt.method, "/admin/dlq", nil))
+			if w.Code != tt.expected {
+				t.Errorf("status = %d, expected %d", w.Code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FieldDeprecation struct {
+	Endpoint   string
+	Field      string
+	ReplacedBy string
+	Sunset     time.Time
+}
+
+// Fields listed here are only emitted under their new name by handlers; the
+// deprecation layer adds the old name back until the sunset date.
+var defaultFieldDeprecations = []FieldDeprecation{
+	{
+		Endpoint:   "/league/entries",
+		Field:      "hasMore",
+		ReplacedBy: "pagination.hasMore",
+		Sunset:     time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+const maxTrackedDeprecationConsumers = 10000
+
+type DeprecationLayer struct {
+	deprecations map[string][]FieldDeprecation
+	logger       *Logger
+	metrics      *MetricsCollector
+	now          func() time.Time
+
+	seen   map[string]bool
+	seenMu sync.Mutex
+}
+
+func NewDeprecationLayer(cfg *Config, logger *Logger, metrics *MetricsCollector) *DeprecationLayer {
+	configured, err := parseFieldDeprecations(cfg.DeprecatedFields)
+	if err != nil {
+		logger.Error("deprecated_fields_invalid").
+			Component("deprecation").
+			Operation("init").
+			Err(err).
+			Meta("value", cfg.DeprecatedFields).
+			Log()
+		configured = nil
+	}
+
+	byEndpoint := make(map[string][]FieldDeprecation)
+	for _, deprecation := range append(append([]FieldDeprecation{}, defaultFieldDeprecations...), configured...) {
+		byEndpoint[deprecation.Endpoint] = append(byEndpoint[deprecation.Endpoint], deprecation)
+	}
+
+	return &DeprecationLayer{
+		deprecations: byEndpoint,
+		logger:       logger,
+		metrics:      metrics,
+		now:          time.Now,
+		seen:         make(map[string]bool),
+	}
+}
+
+// parseFieldDeprecations reads rules in the form
+// endpoint:field=replacement@YYYY-MM-DD, separated by commas.
+func parseFieldDeprecations(value string) ([]FieldDeprecation, error) {
+	var deprecations []FieldDeprecation
+	if strings.TrimSpace(value) == "" {
+		return deprecations, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		rule = strings.TrimSpace(rule)
+		endpoint, spec, found := strings.Cut(rule, ":")
+		if !found || !strings.HasPrefix(endpoint, "/") {
+			return nil, fmt.Errorf("invalid deprecation rule %q", rule)
+		}
+
+		fields, sunsetStr, found := strings.Cut(spec, "@")
+		if !found {
+			return nil, fmt.Errorf("missing sunset date in deprecation rule %q", rule)
+		}
+		field, replacedBy, found := strings.Cut(fields, "=")
+		if !found || field == "" || replacedBy == "" {
+			return nil, fmt.Errorf("invalid field mapping in deprecation rule %q", rule)
+		}
+
+		sunset, err := time.Parse("2006-01-02", sunsetStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sunset date %q in deprecation rule %q", sunsetStr, rule)
+		}
+
+		deprecations = append(deprecations, FieldDeprecation{
+			Endpoint:   endpoint,
+			Field:      field,
+			ReplacedBy: replacedBy,
+			Sunset:     sunset,
+		})
+	}
+
+	return deprecations, nil
+}
+
+func (dl *DeprecationLayer) Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		active := dl.active(requestRoute(r))
+		if len(active) == 0 {
+			next(w, r)
+			return
+		}
+
+		recorder := newCaptureWriter()
+		next(recorder, r)
+
+		for header, values := range recorder.header {
+			w.Header()[header] = values
+		}
+
+		body := recorder.body.Bytes()
+		if recorder.status != http.StatusOK || !strings.HasPrefix(recorder.header.Get("Content-Type"), "application/json") {
+			w.WriteHeader(recorder.status)
+			w.Write(body)
+			return
+		}
+
+		sunset := active[0].Sunset
+		for _, deprecation := range active {
+			if deprecation.Sunset.Before(sunset) {
+				sunset = deprecation.Sunset
+			}
+		}
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+
+		if r.Header.Get("X-Omit-Deprecated") != "true" {
+			if rewritten, ok := dl.addAliases(body, active); ok {
+				body = rewritten
+				dl.recordUsage(r, active)
+			}
+		}
+
+		etag := computeETag(body)
+		w.Header().Set("ETag", etag)
+		if r.Method == http.MethodGet && etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		w.WriteHeader(recorder.status)
+		w.Write(body)
+	}
+}
+
+func (dl *DeprecationLayer) active(endpoint string) []FieldDeprecation {
+	now := dl.now()
+	var active []FieldDeprecation
+	for _, deprecation := range dl.deprecations[endpoint] {
+		if now.Before(deprecation.Sunset) {
+			active = append(active, deprecation)
+		}
+	}
+	return active
+}
+
+func (dl *DeprecationLayer) addAliases(body []byte, deprecations []FieldDeprecation) ([]byte, bool) {
+	var document interface{}
+	if err := json.Unmarshal(body, &document); err != nil {
+		return body, false
+	}
+
+	changed := false
+	for _, deprecation := range deprecations {
+		if aliasField(document, strings.Split(deprecation.Field, "."), strings.Split(deprecation.ReplacedBy, ".")) {
+			changed = true
+		}
+	}
+	if !changed {
+		return body, false
+	}
+
+	rewritten, err := json.Marshal(document)
+	if err != nil {
+		return body, false
+	}
+	return append(rewritten, '\n'), true
+}
+
+// aliasField copies the value at newPath to oldPath. Shared leading segments
+// are walked together so aliases inside arrays land on each element.
+func aliasField(node interface{}, oldPath, newPath []string) bool {
+	if items, ok := node.([]interface{}); ok {
+		changed := false
+		for _, item := range items {
+			if aliasField(item, oldPath, newPath) {
+				changed = true
+			}
+		}
+		return changed
+	}
+
+	object, ok := node.(map[string]interface{})
+	if !ok || len(oldPath) == 0 || len(newPath) == 0 {
+		return false
+	}
+
+	if len(oldPath) > 1 && len(newPath) > 1 && oldPath[0] == newPath[0] {
+		return aliasField(object[oldPath[0]], oldPath[1:], newPath[1:])
+	}
+	if len(oldPath) != 1 {
+		return false
+	}
+	if _, exists := object[oldPath[0]]; exists {
+		return false
+	}
+
+	value, found := lookupPath(object, newPath)
+	if !found {
+		return false
+	}
+	object[oldPath[0]] = value
+	return true
+}
+
+func lookupPath(object map[string]interface{}, path []string) (interface{}, bool) {
+	value, exists := object[path[0]]
+	if !exists {
+		return nil, false
+	}
+	if len(path) == 1 {
+		return value, true
+	}
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	return lookupPath(child, path[1:])
+}
+
+func deprecationConsumer(r *http.Request) string {
+	if principal := GetPrincipal(r.Context()); principal != nil {
+		return principal.KeyID
+	}
+	if userAgent := r.UserAgent(); userAgent != "" {
+		return "ua:" + userAgent
+	}
+	return "anonymous"
+}
+
+func (dl *DeprecationLayer) recordUsage(r *http.Request, deprecations []FieldDeprecation) {
+	consumer := deprecationConsumer(r)
+
+	for _, deprecation := range deprecations {
+		field := deprecation.Endpoint + "#" + deprecation.Field
+		if dl.metrics != nil {
+			dl.metrics.RecordDeprecatedFieldUsage(field, consumer)
+		}
+
+		key := field + "|" + consumer
+		dl.seenMu.Lock()
+		if len(dl.seen) >= maxTrackedDeprecationConsumers {
+			dl.seen = make(map[string]bool)
+		}
+		first := !dl.seen[key]
+		dl.seen[key] = true
+		dl.seenMu.Unlock()
+
+		if first {
+			dl.logger.Warn("deprecated_field_served").
+				Component("deprecation").
+				Operation("alias_field").
+				Request(r.UserAgent(), r.RemoteAddr, GetRequestID(r.Context())).
+				Meta("endpoint", deprecation.Endpoint).
+				Meta("field", deprecation.Field).
+				Meta("replaced_by", deprecation.ReplacedBy).
+				Meta("sunset", deprecation.Sunset.Format("2006-01-02")).
+				Meta("consumer", consumer).
+				Log()
+		}
+	}
+}
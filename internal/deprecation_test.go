@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseFieldDeprecations(t *testing.T) {
+	deprecations, err := parseFieldDeprecations("/summoner:summonerLevel=level@2027-01-01, /league/entries:entries.summonerId=entries.puuid@2026-12-31")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if len(deprecations) != 2 {
+		t.Fatalf("parseFieldDeprecations() returned %d rules, expected 2", len(deprecations))
+	}
+
+	expected := FieldDeprecation{
+		Endpoint:   "/summoner",
+		Field:      "summonerLevel",
+		ReplacedBy: "level",
+		Sunset:     time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if deprecations[0] != expected {
+		t.Errorf("rule = %+v, expected %+v", deprecations[0], expected)
+	}
+
+	for _, value := range []string{"summoner:a=b@2027-01-01", "/summoner:a=b", "/summoner:a@2027-01-01", "/summoner:a=b@soon"} {
+		if _, err := parseFieldDeprecations(value); err == nil {
+			t.Errorf("parseFieldDeprecations(%q) expected error but got nil", value)
+		}
+	}
+}
+
+func TestAliasField(t *testing.T) {
+	var document interface{}
+	json.Unmarshal([]byte(`{
+		"pagination": {"hasMore": true},
+		"entries": [{"puuid": "a"}, {"puuid": "b", "summonerId": "kept"}]
+	}`), &document)
+
+	if !aliasField(document, []string{"hasMore"}, []string{"pagination", "hasMore"}) {
+		t.Error("expected top-level alias to be added")
+	}
+	if !aliasField(document, []string{"entries", "summonerId"}, []string{"entries", "puuid"}) {
+		t.Error("expected array alias to be added")
+	}
+
+	root := document.(map[string]interface{})
+	if root["hasMore"] != true {
+		t.Errorf("hasMore = %v, expected true", root["hasMore"])
+	}
+	entries := root["entries"].([]interface{})
+	if entries[0].(map[string]interface{})["summonerId"] != "a" {
+		t.Errorf("entries[0].summonerId = %v, expected a", entries[0].(map[string]interface{})["summonerId"])
+	}
+	if entries[1].(map[string]interface{})["summonerId"] != "kept" {
+		t.Errorf("existing field was overwritten: %v", entries[1])
+	}
+}
+
+func TestDeprecationLayer_Handler(t *testing.T) {
+	layer := NewDeprecationLayer(&Config{}, newTestLogger(), nil)
+	handler := layer.Handler(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"pagination": map[string]interface{}{"hasMore": true}}, newTestLogger(), r)
+	})
+
+	tests := []struct {
+		name         string
+		now          time.Time
+		omit         bool
+		expectAlias  bool
+		expectSunset bool
+	}{
+		{name: "before sunset", now: time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), expectAlias: true, expectSunset: true},
+		{name: "consumer opted out", now: time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC), omit: true, expectSunset: true},
+		{name: "after sunset", now: time.Date(2027, time.May, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer.now = func() time.Time { return tt.now }
+
+			req := httptest.NewRequest(http.MethodGet, "/league/entries", nil)
+			if tt.omit {
+				req.Header.Set("X-Omit-Deprecated", "true")
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON body: %v", err)
+			}
+			if _, hasAlias := body["hasMore"]; hasAlias != tt.expectAlias {
+				t.Errorf("hasMore present = %v, expected %v", hasAlias, tt.expectAlias)
+			}
+			if (rec.Header().Get("Sunset") != "") != tt.expectSunset {
+				t.Errorf("Sunset header = %q, expected present = %v", rec.Header().Get("Sunset"), tt.expectSunset)
+			}
+		})
+	}
+}
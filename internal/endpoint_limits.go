@@ -0,0 +1,167 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type EndpointLimit struct {
+	MaxConcurrent int
+	MaxBodyBytes  int64
+}
+
+var defaultEndpointLimits = map[string]EndpointLimit{
+	"lookup": {MaxConcurrent: 50, MaxBodyBytes: 4 << 10},
+	"league": {MaxConcurrent: 20, MaxBodyBytes: 4 << 10},
+	"static": {MaxConcurrent: 20, MaxBodyBytes: 4 << 10},
+	// Streams hold their slot until the whole ladder is sent.
+	"stream": {MaxConcurrent: 4, MaxBodyBytes: 4 << 10},
+	"admin":  {MaxConcurrent: 2, MaxBodyBytes: 4 << 10},
+}
+
+type EndpointLimiter struct {
+	limits  map[string]EndpointLimit
+	slots   map[string]chan struct{}
+	logger  *Logger
+	metrics *MetricsCollector
+}
+
+func NewEndpointLimiter(cfg *Config, logger *Logger, metrics *MetricsCollector) *EndpointLimiter {
+	overrides, err := parseEndpointLimits(cfg.EndpointLimits)
+	if err != nil {
+		logger.Error("endpoint_limits_invalid").
+			Component("endpoint_limiter").
+			Operation("init").
+			Err(err).
+			Meta("value", cfg.EndpointLimits).
+			Log()
+		overrides = map[string]EndpointLimit{}
+	}
+
+	limits := make(map[string]EndpointLimit, len(defaultEndpointLimits))
+	for class, limit := range defaultEndpointLimits {
+		limits[class] = limit
+	}
+	for class, limit := range overrides {
+		limits[class] = limit
+	}
+
+	slots := make(map[string]chan struct{}, len(limits))
+	for class, limit := range limits {
+		slots[class] = make(chan struct{}, limit.MaxConcurrent)
+	}
+
+	return &EndpointLimiter{
+		limits:  limits,
+		slots:   slots,
+		logger:  logger,
+		metrics: metrics,
+	}
+}
+
+func parseEndpointLimits(value string) (map[string]EndpointLimit, error) {
+	limits := make(map[string]EndpointLimit)
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		class, spec, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found || class == "" {
+			return nil, fmt.Errorf("invalid endpoint limit rule %q", rule)
+		}
+
+		concurrentStr, bytesStr, hasBytes := strings.Cut(spec, ":")
+		concurrent, err := strconv.Atoi(concurrentStr)
+		if err != nil || concurrent <= 0 {
+			return nil, fmt.Errorf("invalid concurrency %q for class %s", concurrentStr, class)
+		}
+
+		limit := EndpointLimit{MaxConcurrent: concurrent, MaxBodyBytes: defaultEndpointLimits[class].MaxBodyBytes}
+		if hasBytes {
+			maxBytes, err := strconv.ParseInt(bytesStr, 10, 64)
+			if err != nil || maxBytes <= 0 {
+				return nil, fmt.Errorf("invalid body size %q for class %s", bytesStr, class)
+			}
+			limit.MaxBodyBytes = maxBytes
+		}
+		limits[class] = limit
+	}
+
+	return limits, nil
+}
+
+// Class is Handler as a Router middleware.
+func (el *EndpointLimiter) Class(class string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return el.Handler(class, next)
+	}
+}
+
+func (el *EndpointLimiter) Handler(class string, next http.HandlerFunc) http.HandlerFunc {
+	limit, exists := el.limits[class]
+	if !exists {
+		el.logger.Warn("endpoint_class_unknown").
+			Component("endpoint_limiter").
+			Operation("register").
+			Meta("class", class).
+			Log()
+		return next
+	}
+	slots := el.slots[class]
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if limit.MaxBodyBytes > 0 {
+			if r.ContentLength > limit.MaxBodyBytes {
+				el.reject(class, "body_too_large", w, r,
+					NewAPIError("Request body too large", http.StatusRequestEntityTooLarge))
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, limit.MaxBodyBytes)
+			}
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			w.Header().Set("Retry-After", "1")
+			el.reject(class, "concurrency", w, r,
+				NewAPIError("Too many concurrent requests", http.StatusServiceUnavailable))
+			return
+		}
+
+		el.recordConcurrency(class, len(slots), limit.MaxConcurrent)
+		defer func() {
+			<-slots
+			el.recordConcurrency(class, len(slots), limit.MaxConcurrent)
+		}()
+
+		next(w, r)
+	}
+}
+
+func (el *EndpointLimiter) recordConcurrency(class string, inUse, limit int) {
+	if el.metrics != nil {
+		el.metrics.RecordEndpointConcurrency(class, inUse, limit)
+	}
+}
+
+func (el *EndpointLimiter) reject(class, reason string, w http.ResponseWriter, r *http.Request, err APIError) {
+	if el.metrics != nil {
+		el.metrics.RecordEndpointRejection(class, reason)
+	}
+
+	el.logger.Warn("endpoint_limit_rejected").
+		Component("endpoint_limiter").
+		Operation("check_limit").
+		Request("", "", GetRequestID(r.Context())).
+		Meta("class", class).
+		Meta("reason", reason).
+		Log()
+
+	setCORSHeaders(w, r)
+	writeError(w, err, el.logger, r)
+}
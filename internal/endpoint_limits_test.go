@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseEndpointLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  map[string]EndpointLimit
+		expectErr bool
+	}{
+		{
+			name:     "empty value",
+			value:    "",
+			expected: map[string]EndpointLimit{},
+		},
+		{
+			name:  "concurrency keeps default body size",
+			value: "lookup=10",
+			expected: map[string]EndpointLimit{
+				"lookup": {MaxConcurrent: 10, MaxBodyBytes: 4 << 10},
+			},
+		},
+		{
+			name:  "concurrency and body size",
+			value: "lookup=10:1024, export=2:65536",
+			expected: map[string]EndpointLimit{
+				"lookup": {MaxConcurrent: 10, MaxBodyBytes: 1024},
+				"export": {MaxConcurrent: 2, MaxBodyBytes: 65536},
+			},
+		},
+		{
+			name:      "missing class",
+			value:     "=10",
+			expectErr: true,
+		},
+		{
+			name:      "invalid concurrency",
+			value:     "lookup=0",
+			expectErr: true,
+		},
+		{
+			name:      "invalid body size",
+			value:     "lookup=10:big",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseEndpointLimits(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseEndpointLimits() returned %d classes, expected %d", len(result), len(tt.expected))
+			}
+			for class, limit := range tt.expected {
+				if result[class] != limit {
+					t.Errorf("class %s = %+v, expected %+v", class, result[class], limit)
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointLimiter_RejectsWhenSaturated(t *testing.T) {
+	limiter := NewEndpointLimiter(&Config{EndpointLimits: "admin=1"}, newTestLogger(), nil)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := limiter.Handler("admin", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/admin/riot-usage", nil))
+		close(done)
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/admin/riot-usage", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header on rejection")
+	}
+
+	close(release)
+	<-done
+}
+
+func TestEndpointLimiter_RejectsLargeBody(t *testing.T) {
+	limiter := NewEndpointLimiter(&Config{EndpointLimits: "lookup=5:16"}, newTestLogger(), nil)
+
+	called := false
+	handler := limiter.Handler("lookup", func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/watchlist", strings.NewReader(`{"puuid":"a-very-long-puuid"}`)))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusRequestEntityTooLarge)
+	}
+	if called {
+		t.Error("handler should not run for oversized bodies")
+	}
+}
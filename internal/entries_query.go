@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	entriesSortLP      = "lp"
+	entriesSortWinrate = "winrate"
+)
+
+// EntriesQuery filters and sorts one page of league entries. It runs on the
+// page as fetched or cached, so filters never change what is cached and
+// pagination still follows Riot's pages.
+type EntriesQuery struct {
+	MinLP     int
+	MinWins   int
+	HotStreak *bool
+	Veteran   *bool
+	Sort      string
+}
+
+func parseEntriesQuery(query url.Values) (EntriesQuery, error) {
+	v := NewValidator(query)
+	q := EntriesQuery{
+		MinLP:     v.IntRange("minLp", 0, 0, 10000),
+		MinWins:   v.IntRange("minWins", 0, 0, 10000),
+		HotStreak: v.Bool("hotStreak"),
+		Veteran:   v.Bool("veteran"),
+	}
+	if query.Get("sort") != "" {
+		// Enum compares in upper case like the tier and division params.
+		q.Sort = strings.ToLower(v.Enum("sort", []string{"LP", "WINRATE"}))
+	}
+	return q, v.Err()
+}
+
+// Values encodes the query back into parameters for pagination links.
+func (q EntriesQuery) Values() url.Values {
+	values := url.Values{}
+	if q.MinLP > 0 {
+		values.Set("minLp", strconv.Itoa(q.MinLP))
+	}
+	if q.MinWins > 0 {
+		values.Set("minWins", strconv.Itoa(q.MinWins))
+	}
+	if q.HotStreak != nil {
+		values.Set("hotStreak", strconv.FormatBool(*q.HotStreak))
+	}
+	if q.Veteran != nil {
+		values.Set("veteran", strconv.FormatBool(*q.Veteran))
+	}
+	if q.Sort != "" {
+		values.Set("sort", q.Sort)
+	}
+	return values
+}
+
+func (q EntriesQuery) matches(entry *LeagueEntry) bool {
+	switch {
+	case entry.LeaguePoints < q.MinLP, entry.Wins < q.MinWins:
+		return false
+	case q.HotStreak != nil && entry.HotStreak != *q.HotStreak:
+		return false
+	case q.Veteran != nil && entry.Veteran != *q.Veteran:
+		return false
+	}
+	return true
+}
+
+// Apply returns the matching entries in a new slice, sorted when requested.
+// Sorting is stable so ties keep Riot's order.
+func (q EntriesQuery) Apply(entries []LeagueEntry) []LeagueEntry {
+	filtered := make([]LeagueEntry, 0, len(entries))
+	for i := range entries {
+		if q.matches(&entries[i]) {
+			filtered = append(filtered, entries[i])
+		}
+	}
+
+	switch q.Sort {
+	case entriesSortLP:
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return filtered[i].LeaguePoints > filtered[j].LeaguePoints
+		})
+	case entriesSortWinrate:
+		sort.SliceStable(filtered, func(i, j int) bool {
+			return entryWinrate(&filtered[i]) > entryWinrate(&filtered[j])
+		})
+	}
+	return filtered
+}
+
+func entryWinrate(entry *LeagueEntry) float64 {
+	games := entry.Wins + entry.Losses
+	if games == 0 {
+		return 0
+	}
+	return float64(entry.Wins) / float64(games)
+}
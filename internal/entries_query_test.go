@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEntriesQuery(t *testing.T) {
+	yes, no := true, false
+
+	tests := []struct {
+		raw       string
+		expected  EntriesQuery
+		expectErr bool
+	}{
+		{raw: "", expected: EntriesQuery{}},
+		{raw: "minLp=50&minWins=10&sort=WinRate", expected: EntriesQuery{MinLP: 50, MinWins: 10, Sort: entriesSortWinrate}},
+		{raw: "hotStreak=true&veteran=false&sort=lp", expected: EntriesQuery{HotStreak: &yes, Veteran: &no, Sort: entriesSortLP}},
+		{raw: "minLp=-1", expectErr: true},
+		{raw: "hotStreak=yes", expectErr: true},
+		{raw: "sort=name", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		query, _ := url.ParseQuery(tt.raw)
+		got, err := parseEntriesQuery(query)
+		if (err != nil) != tt.expectErr {
+			t.Errorf("parseEntriesQuery(%q) error = %v, expectErr %v", tt.raw, err, tt.expectErr)
+			continue
+		}
+		if !tt.expectErr && !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseEntriesQuery(%q) = %+v, expected %+v", tt.raw, got, tt.expected)
+		}
+	}
+}
+
+func TestEntriesQuery_Apply(t *testing.T) {
+	entries := []LeagueEntry{
+		{PUUID: "a", LeaguePoints: 20, Wins: 10, Losses: 10},
+		{PUUID: "b", LeaguePoints: 80, Wins: 30, Losses: 10, HotStreak: true},
+		{PUUID: "c", LeaguePoints: 50, Wins: 7, Losses: 1, Veteran: true},
+		{PUUID: "d", LeaguePoints: 80, Wins: 5, Losses: 15},
+	}
+	yes := true
+
+	tests := []struct {
+		name     string
+		query    EntriesQuery
+		expected []string
+	}{
+		{name: "no filters keeps riot order", query: EntriesQuery{}, expected: []string{"a", "b", "c", "d"}},
+		{name: "min lp", query: EntriesQuery{MinLP: 50}, expected: []string{"b", "c", "d"}},
+		{name: "min wins", query: EntriesQuery{MinWins: 10}, expected: []string{"a", "b"}},
+		{name: "hot streak", query: EntriesQuery{HotStreak: &yes}, expected: []string{"b"}},
+		{name: "veteran", query: EntriesQuery{Veteran: &yes}, expected: []string{"c"}},
+		{name: "lp desc keeps ties in order", query: EntriesQuery{Sort: entriesSortLP}, expected: []string{"b", "d", "c", "a"}},
+		{name: "winrate", query: EntriesQuery{Sort: entriesSortWinrate}, expected: []string{"c", "b", "a", "d"}},
+		{name: "filter then sort", query: EntriesQuery{MinLP: 50, Sort: entriesSortWinrate}, expected: []string{"c", "b", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := tt.query.Apply(entries)
+			ids := make([]string, 0, len(result))
+			for _, entry := range result {
+				ids = append(ids, entry.PUUID)
+			}
+			if !reflect.DeepEqual(ids, tt.expected) {
+				t.Errorf("Apply() = %v, expected %v", ids, tt.expected)
+			}
+		})
+	}
+
+	if entries[0].PUUID != "a" || entries[1].PUUID != "b" {
+		t.Error("Apply() reordered the input entries")
+	}
+}
+
+func TestEntriesQuery_PaginationLinks(t *testing.T) {
+	yes := true
+	query := EntriesQuery{MinLP: 50, HotStreak: &yes, Sort: entriesSortLP}
+
+	info := buildPageInfo("GOLD", "I", 2, 200, 0, 0, false)
+	info.SetLinks("/league/entries", query.Values())
+
+	if !strings.HasSuffix(info.Next, "&hotStreak=true&minLp=50&sort=lp") {
+		t.Errorf("Next = %q, expected the filters to be kept", info.Next)
+	}
+	link, _ := url.Parse(info.Next)
+	parsed, err := parseEntriesQuery(link.Query())
+	if err != nil || !reflect.DeepEqual(parsed, query) {
+		t.Errorf("query from next link = %+v (err %v), expected %+v", parsed, err, query)
+	}
+}
@@ -0,0 +1,222 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+const (
+	// LeagueEntriesStreamPath is exempt from REQUEST_TIMEOUT unless
+	// REQUEST_TIMEOUTS names it: the timeout middleware buffers responses,
+	// which would turn the stream back into one giant body.
+	LeagueEntriesStreamPath = "/league/entries/stream"
+	entriesStreamMaxPages   = 1000
+)
+
+// EntriesStreamStatus is the last line of every stream, so a consumer can
+// tell a complete ladder from one cut short.
+type EntriesStreamStatus struct {
+	Status  string `json:"status"`
+	Pages   int    `json:"pages"`
+	Entries int    `json:"entries"`
+	// NextPage is where to resume after an error or the maxPages limit.
+	NextPage int    `json:"nextPage,omitempty"`
+	Code     string `json:"code,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type entriesStreamPage struct {
+	page    int
+	entries []LeagueEntry
+	hasMore bool
+	err     error
+}
+
+// EntriesStreamHandler streams every entry of a tier and division as NDJSON,
+// one entry per line, paging through GetLeagueEntries (and so through the
+// same cache) under the hood. At most one page is fetched ahead of what the
+// client has read, so a slow reader slows the crawl instead of piling pages
+// up in memory, and each page is flushed as soon as it is written.
+func EntriesStreamHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, cfg *Config, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "entries", logger)(streamEntries(riotClient, cfg, logger)))
+}
+
+func streamEntries(riotClient *RiotAPIClient, cfg *Config, logger *Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := GetRequestID(r.Context())
+		query := r.URL.Query()
+
+		v := NewValidator(query)
+		tier := v.Enum("tier", validTiers)
+		division := v.Enum("division", validDivisions)
+		startPage := v.IntRange("startPage", 1, 1, entriesStreamMaxPages)
+		maxPages := v.IntRange("maxPages", entriesStreamMaxPages, 1, entriesStreamMaxPages)
+		if query.Get("sort") != "" {
+			v.Fail("sort", "is not supported when streaming; entries keep Riot's order")
+		}
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		filters, err := parseEntriesQuery(query)
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		pages := fetchEntriesPages(ctx, riotClient, tier, division, startPage, maxPages, cfg.RequestTimeout)
+
+		// The first page decides the status code; after it, errors can only
+		// be reported in the final line.
+		first := <-pages
+		if first.err != nil {
+			handleEntriesError(first.err, tier, division, startPage, requestID, logger, w, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.WriteHeader(http.StatusOK)
+
+		start := time.Now()
+		rc := http.NewResponseController(w)
+		encoder := json.NewEncoder(w)
+		status := EntriesStreamStatus{Status: "complete"}
+		lastPage := first
+
+		for page := first; ; {
+			if page.err != nil {
+				status.Status, status.NextPage = "error", page.page
+				status.Code, status.Error = errorCodeForStatus(http.StatusBadGateway), "Failed to fetch league entries"
+				handleStreamPageError(page.err, tier, division, page.page, requestID, logger)
+				break
+			}
+
+			if err := writeEntriesPage(rc, encoder, filters.Apply(page.entries), &status, cfg.HTTPWriteTimeout); err != nil {
+				logStreamAborted(err, tier, division, page.page, status, requestID, logger)
+				return
+			}
+			status.Pages++
+			lastPage = page
+
+			next, ok := <-pages
+			if !ok {
+				break
+			}
+			page = next
+		}
+
+		if status.Status == "complete" && lastPage.hasMore {
+			status.Status, status.NextPage = "truncated", lastPage.page+1
+		}
+		extendWriteDeadline(rc, cfg.HTTPWriteTimeout)
+		if err := encoder.Encode(status); err == nil {
+			rc.Flush()
+		}
+
+		logger.Info("entries_stream_completed").
+			Component("entries").
+			Operation("stream_entries").
+			Request("", "", requestID).
+			Game("", "", tier).
+			Meta("division", division).
+			Meta("status", status.Status).
+			Meta("pages", status.Pages).
+			Meta("entries_count", status.Entries).
+			Duration(time.Since(start)).
+			Log()
+	}
+}
+
+// fetchEntriesPages fetches pages from startPage until Riot runs out, a page
+// fails or maxPages have been fetched. The unbuffered channel keeps it at
+// most one page ahead of the writer.
+func fetchEntriesPages(ctx context.Context, riotClient *RiotAPIClient, tier, division string, startPage, maxPages int, pageTimeout time.Duration) <-chan entriesStreamPage {
+	pages := make(chan entriesStreamPage)
+	go func() {
+		defer close(pages)
+		for page := startPage; page < startPage+maxPages && page <= entriesStreamMaxPages; page++ {
+			fetched := fetchEntriesPage(ctx, riotClient, tier, division, page, pageTimeout)
+			select {
+			case pages <- fetched:
+			case <-ctx.Done():
+				return
+			}
+			if fetched.err != nil || !fetched.hasMore {
+				return
+			}
+		}
+	}()
+	return pages
+}
+
+func fetchEntriesPage(ctx context.Context, riotClient *RiotAPIClient, tier, division string, page int, timeout time.Duration) entriesStreamPage {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	result, err := riotClient.GetLeagueEntries(ctx, tier, division, page)
+	if err != nil {
+		return entriesStreamPage{page: page, err: err}
+	}
+	hasMore := len(result.Entries) == leagueEntriesPageSize
+	if result.Pagination != nil && result.Pagination.HasMoreKnown {
+		hasMore = result.Pagination.HasMore
+	}
+	return entriesStreamPage{page: page, entries: result.Entries, hasMore: hasMore}
+}
+
+func writeEntriesPage(rc *http.ResponseController, encoder *json.Encoder, entries []LeagueEntry, status *EntriesStreamStatus, writeTimeout time.Duration) error {
+	extendWriteDeadline(rc, writeTimeout)
+	for i := range entries {
+		if err := encoder.Encode(&entries[i]); err != nil {
+			return err
+		}
+		status.Entries++
+	}
+	if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	return nil
+}
+
+// extendWriteDeadline gives every page a full HTTP_WRITE_TIMEOUT, which
+// otherwise bounds the whole response.
+func extendWriteDeadline(rc *http.ResponseController, writeTimeout time.Duration) {
+	if writeTimeout > 0 {
+		rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+	}
+}
+
+func handleStreamPageError(err error, tier, division string, page int, requestID string, logger *Logger) {
+	logger.Error("entries_stream_page_failed").
+		Component("entries").
+		Operation("stream_entries").
+		Request("", "", requestID).
+		Game("", "", tier).
+		Meta("division", division).
+		Meta("page", page).
+		Err(err).
+		Log()
+}
+
+func logStreamAborted(err error, tier, division string, page int, status EntriesStreamStatus, requestID string, logger *Logger) {
+	logger.Warn("entries_stream_aborted").
+		Component("entries").
+		Operation("stream_entries").
+		Request("", "", requestID).
+		Game("", "", tier).
+		Meta("division", division).
+		Meta("page", page).
+		Meta("entries_count", status.Entries).
+		Err(err).
+		Log()
+}
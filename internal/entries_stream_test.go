@@ -0,0 +1,141 @@
+package internal
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeEntriesFixture(t *testing.T, dir string, page, count int) {
+	t.Helper()
+	entries := make([]LeagueEntry, count)
+	for i := range entries {
+		entries[i] = LeagueEntry{PUUID: fmt.Sprintf("p%d-%d", page, i), Tier: "GOLD", Rank: "I", LeaguePoints: i % 100}
+	}
+	body, _ := json.Marshal(entries)
+	writeFixture(t, dir, fmt.Sprintf("tft/league/v1/entries/GOLD/I_page=%d.json", page), string(body))
+}
+
+func readEntriesStream(t *testing.T, body string) ([]LeagueEntry, EntriesStreamStatus) {
+	t.Helper()
+	var entries []LeagueEntry
+	var status EntriesStreamStatus
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if strings.HasPrefix(string(line), `{"status"`) {
+			if err := json.Unmarshal(line, &status); err != nil {
+				t.Fatalf("status line %s: %v", line, err)
+			}
+			continue
+		}
+		var entry LeagueEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			t.Fatalf("entry line %s: %v", line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, status
+}
+
+func TestStreamEntries(t *testing.T) {
+	dir := t.TempDir()
+	writeEntriesFixture(t, dir, 1, leagueEntriesPageSize)
+	writeEntriesFixture(t, dir, 2, leagueEntriesPageSize)
+	writeEntriesFixture(t, dir, 3, 3)
+	handler := streamEntries(newCachedFixtureClient(dir), &Config{RequestTimeout: time.Second, HTTPWriteTimeout: time.Second}, newTestLogger())
+
+	tests := []struct {
+		name     string
+		query    string
+		entries  int
+		expected EntriesStreamStatus
+	}{
+		{
+			name:     "whole division",
+			query:    "tier=GOLD&division=I",
+			entries:  2*leagueEntriesPageSize + 3,
+			expected: EntriesStreamStatus{Status: "complete", Pages: 3, Entries: 2*leagueEntriesPageSize + 3},
+		},
+		{
+			name:     "resumed from a page",
+			query:    "tier=GOLD&division=I&startPage=3",
+			entries:  3,
+			expected: EntriesStreamStatus{Status: "complete", Pages: 1, Entries: 3},
+		},
+		{
+			name:     "limited pages",
+			query:    "tier=GOLD&division=I&maxPages=2",
+			entries:  2 * leagueEntriesPageSize,
+			expected: EntriesStreamStatus{Status: "truncated", Pages: 2, Entries: 2 * leagueEntriesPageSize, NextPage: 3},
+		},
+		{
+			name:     "filtered",
+			query:    "tier=GOLD&division=I&minLp=98",
+			entries:  8,
+			expected: EntriesStreamStatus{Status: "complete", Pages: 3, Entries: 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, LeagueEntriesStreamPath+"?"+tt.query, nil))
+
+			if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/x-ndjson" {
+				t.Fatalf("status = %d, content type %q, expected a 200 NDJSON stream", rec.Code, rec.Header().Get("Content-Type"))
+			}
+			if !rec.Flushed {
+				t.Error("expected the stream to be flushed")
+			}
+			entries, status := readEntriesStream(t, rec.Body.String())
+			if len(entries) != tt.entries || status != tt.expected {
+				t.Errorf("got %d entries and %+v, expected %d and %+v", len(entries), status, tt.entries, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStreamEntries_Errors(t *testing.T) {
+	dir := t.TempDir()
+	writeEntriesFixture(t, dir, 1, leagueEntriesPageSize)
+	handler := streamEntries(newCachedFixtureClient(dir), &Config{}, newTestLogger())
+
+	t.Run("later page fails", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, LeagueEntriesStreamPath+"?tier=GOLD&division=I", nil))
+
+		entries, status := readEntriesStream(t, rec.Body.String())
+		if rec.Code != http.StatusOK || len(entries) != leagueEntriesPageSize {
+			t.Fatalf("status = %d with %d entries, expected the first page streamed", rec.Code, len(entries))
+		}
+		if status.Status != "error" || status.NextPage != 2 || status.Code != errorCodeForStatus(http.StatusBadGateway) {
+			t.Errorf("status line = %+v, expected an error resuming at page 2", status)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected int
+	}{
+		{name: "first page fails", query: "tier=GOLD&division=II", expected: http.StatusBadGateway},
+		{name: "sort rejected", query: "tier=GOLD&division=I&sort=lp", expected: http.StatusBadRequest},
+		{name: "missing division", query: "tier=GOLD", expected: http.StatusBadRequest},
+		{name: "max pages out of range", query: "tier=GOLD&division=I&maxPages=0", expected: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, LeagueEntriesStreamPath+"?"+tt.query, nil))
+			if rec.Code != tt.expected {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package internal
+
+import "net/http"
+
+// Error codes returned in the "code" field of every error body. They are
+// part of the API: clients match on them, so existing codes must not change.
+const (
+	ErrorCodeInvalidRequest = "invalid_request"
+	ErrorCodeUnauthorized   = "unauthorized"
+	ErrorCodeForbidden      = "forbidden"
+	ErrorCodeNotFound       = "not_found"
+	ErrorCodeWrongRegion    = "wrong_region"
+	ErrorCodeRateLimited    = "rate_limited"
+	ErrorCodeInternal       = "internal_error"
+	ErrorCodeUpstream       = "upstream_error"
+	ErrorCodeUnavailable    = "unavailable"
+	ErrorCodeTimeout        = "timeout"
+)
+
+var errorCodesByStatus = map[int]string{
+	http.StatusBadRequest:          ErrorCodeInvalidRequest,
+	http.StatusUnauthorized:        ErrorCodeUnauthorized,
+	http.StatusForbidden:           ErrorCodeForbidden,
+	http.StatusNotFound:            ErrorCodeNotFound,
+	http.StatusMisdirectedRequest:  ErrorCodeWrongRegion,
+	http.StatusTooManyRequests:     ErrorCodeRateLimited,
+	http.StatusInternalServerError: ErrorCodeInternal,
+	http.StatusBadGateway:          ErrorCodeUpstream,
+	http.StatusServiceUnavailable:  ErrorCodeUnavailable,
+	http.StatusGatewayTimeout:      ErrorCodeTimeout,
+}
+
+// errorCodeForStatus falls back to the generic client or server code for
+// statuses the catalog does not list.
+func errorCodeForStatus(status int) string {
+	if code, ok := errorCodesByStatus[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return ErrorCodeInternal
+	}
+	return ErrorCodeInvalidRequest
+}
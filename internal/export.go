@@ -0,0 +1,235 @@
+package internal
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	ExportFormatJSON = "json"
+	ExportFormatCSV  = "csv"
+	ExportFormatXLSX = "xlsx"
+)
+
+var exportFormats = []string{ExportFormatJSON, ExportFormatCSV, ExportFormatXLSX}
+
+var exportContentTypes = map[string]string{
+	ExportFormatCSV:  "text/csv; charset=utf-8",
+	ExportFormatXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+var leaderboardColumns = []string{
+	"position", "puuid", "summoner_name", "tier", "rank", "league_points",
+	"wins", "losses", "win_rate", "hot_streak", "veteran", "fresh_blood", "inactive",
+}
+
+// exportFormat reads ?format=, defaulting to JSON.
+func exportFormat(query url.Values) (string, error) {
+	format := strings.ToLower(query.Get("format"))
+	if format == "" {
+		return ExportFormatJSON, nil
+	}
+	for _, candidate := range exportFormats {
+		if format == candidate {
+			return format, nil
+		}
+	}
+
+	v := NewValidator(query)
+	v.Fail("format", "must be one of "+strings.Join(exportFormats, ", "))
+	return "", v.Err()
+}
+
+func leaderboardRow(position int, entry LeagueEntry) []interface{} {
+	winRate := 0.0
+	if games := entry.Wins + entry.Losses; games > 0 {
+		winRate = float64(entry.Wins) / float64(games) * 100
+	}
+
+	return []interface{}{
+		position, entry.PUUID, entry.SummonerName, entry.Tier, entry.Rank, entry.LeaguePoints,
+		entry.Wins, entry.Losses, math.Round(winRate*10) / 10,
+		entry.HotStreak, entry.Veteran, entry.FreshBlood, entry.Inactive,
+	}
+}
+
+// writeLeaderboardExport streams entries as CSV or XLSX. Headers are sent
+// before the first row, so a failure halfway can only be logged.
+func writeLeaderboardExport(w http.ResponseWriter, r *http.Request, format, filename string, entries []LeagueEntry, logger *Logger) {
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+	w.WriteHeader(http.StatusOK)
+
+	rows := func(emit func([]interface{}) error) error {
+		for i, entry := range entries {
+			if err := emit(leaderboardRow(i+1, entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	var err error
+	if format == ExportFormatXLSX {
+		err = writeXLSX(w, filename, leaderboardColumns, rows)
+	} else {
+		err = writeCSV(w, leaderboardColumns, rows)
+	}
+	if err != nil {
+		logger.Error("export_write_failed").
+			Component("http").
+			Operation("export_"+format).
+			Request("", "", GetRequestID(r.Context())).
+			Err(err).
+			Log()
+	}
+}
+
+func writeCSV(w io.Writer, header []string, rows func(func([]interface{}) error) error) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	err := rows(func(row []interface{}) error {
+		for i, value := range row {
+			record[i] = fmt.Sprint(value)
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeXLSX writes a single-sheet workbook with inline strings, which keeps
+// the file streamable without a shared-strings table.
+func writeXLSX(w io.Writer, sheetName string, header []string, rows func(func([]interface{}) error) error) error {
+	zw := zip.NewWriter(w)
+
+	for _, part := range []struct{ name, body string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, xmlEscape(xlsxSheetName(sheetName)))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		fw, err := zw.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(fw, part.body); err != nil {
+			return err
+		}
+	}
+
+	sheet, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sheet, xlsxSheetHeader); err != nil {
+		return err
+	}
+
+	headerRow := make([]interface{}, len(header))
+	for i, column := range header {
+		headerRow[i] = column
+	}
+	if err := writeXLSXRow(sheet, 1, headerRow); err != nil {
+		return err
+	}
+
+	rowNumber := 1
+	err = rows(func(row []interface{}) error {
+		rowNumber++
+		return writeXLSXRow(sheet, rowNumber, row)
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(sheet, xlsxSheetFooter); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeXLSXRow(w io.Writer, number int, values []interface{}) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<row r="%d">`, number)
+	for _, value := range values {
+		switch v := value.(type) {
+		case int:
+			fmt.Fprintf(&b, `<c><v>%d</v></c>`, v)
+		case float64:
+			fmt.Fprintf(&b, `<c><v>%s</v></c>`, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			flag := 0
+			if v {
+				flag = 1
+			}
+			fmt.Fprintf(&b, `<c t="b"><v>%d</v></c>`, flag)
+		default:
+			fmt.Fprintf(&b, `<c t="inlineStr"><is><t>%s</t></is></c>`, xmlEscape(fmt.Sprint(v)))
+		}
+	}
+	b.WriteString(`</row>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+// xlsxSheetName applies Excel's sheet name rules: at most 31 characters and
+// none of []:*?/\.
+func xlsxSheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if len(name) > 31 {
+		name = name[:31]
+	}
+	if name == "" {
+		name = "Sheet1"
+	}
+	return name
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+const xlsxSheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+const xlsxSheetFooter = `</sheetData></worksheet>`
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExportFormat(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		expected  string
+		expectErr bool
+	}{
+		{name: "default json", query: "", expected: ExportFormatJSON},
+		{name: "csv", query: "format=csv", expected: ExportFormatCSV},
+		{name: "case insensitive", query: "format=XLSX", expected: ExportFormatXLSX},
+		{name: "unsupported", query: "format=pdf", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			format, err := exportFormat(query)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil || format != tt.expected {
+				t.Errorf("exportFormat(%q) = %q, %v, expected %q", tt.query, format, err, tt.expected)
+			}
+		})
+	}
+}
+
+var exportTestEntries = []LeagueEntry{
+	{PUUID: "puuid-1", SummonerName: "Player, \"One\"", Tier: "CHALLENGER", Rank: "I", LeaguePoints: 1200, Wins: 30, Losses: 10, HotStreak: true},
+	{PUUID: "puuid-2", SummonerName: "<Two>", Tier: "CHALLENGER", Rank: "I", LeaguePoints: 1100},
+}
+
+func TestWriteLeaderboardExport_CSV(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeLeaderboardExport(rec, httptest.NewRequest(http.MethodGet, "/league/challenger?format=csv", nil), ExportFormatCSV, "challenger", exportTestEntries, newTestLogger())
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %s, expected text/csv", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="challenger.csv"` {
+		t.Errorf("Content-Disposition = %s", cd)
+	}
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid csv: %v", err)
+	}
+	if len(records) != 3 || records[0][0] != "position" {
+		t.Fatalf("unexpected records %v", records)
+	}
+	if records[1][2] != `Player, "One"` || records[1][8] != "75" || records[1][9] != "true" {
+		t.Errorf("unexpected first row %v", records[1])
+	}
+}
+
+func TestWriteLeaderboardExport_XLSX(t *testing.T) {
+	rec := httptest.NewRecorder()
+	writeLeaderboardExport(rec, httptest.NewRequest(http.MethodGet, "/league/challenger?format=xlsx", nil), ExportFormatXLSX, "challenger", exportTestEntries, newTestLogger())
+
+	body := rec.Body.Bytes()
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatalf("invalid xlsx archive: %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, file := range zr.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", file.Name, err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[file.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("missing part %s", name)
+		}
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	if strings.Count(sheet, "<row ") != 3 {
+		t.Errorf("expected header plus 2 rows, got %s", sheet)
+	}
+	if !strings.Contains(sheet, "&lt;Two&gt;") || !strings.Contains(sheet, "<c><v>1200</v></c>") {
+		t.Errorf("sheet should escape strings and keep numbers numeric: %s", sheet)
+	}
+}
+
+func TestXLSXSheetName(t *testing.T) {
+	if got := xlsxSheetName("entries/DIAMOND:I"); got != "entries_DIAMOND_I" {
+		t.Errorf("xlsxSheetName() = %s", got)
+	}
+	if got := xlsxSheetName(strings.Repeat("a", 40)); len(got) != 31 {
+		t.Errorf("xlsxSheetName() length = %d, expected 31", len(got))
+	}
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// formWindow is how many recent games the form trend looks at.
+	formWindow = 20
+	// A slope of 0.05 placements per game is one full placement over the
+	// 20-game window.
+	formTrendThreshold = 0.05
+
+	formImproving = "improving"
+	formDeclining = "declining"
+	formStable    = "stable"
+)
+
+// PlacementForm is the placement trend over recent games. Trend is the
+// least-squares slope of placement per game in play order, so a negative
+// value means the player is finishing higher.
+type PlacementForm struct {
+	Games            int     `json:"games"`
+	AveragePlacement float64 `json:"averagePlacement"`
+	Trend            float64 `json:"trend"`
+	Direction        string  `json:"direction"`
+}
+
+// PlayerForm is the compact form summary included in profiles.
+type PlayerForm struct {
+	Matches          int             `json:"matches"`
+	AveragePlacement float64         `json:"averagePlacement"`
+	Top4Rate         float64         `json:"top4Rate"`
+	CurrentStreak    PlacementStreak `json:"currentStreak"`
+	CurrentWinStreak int             `json:"currentWinStreak"`
+	Recent           PlacementForm   `json:"recent"`
+	Description      string          `json:"description,omitempty"`
+}
+
+type StreakEvent struct {
+	PUUID            string          `json:"puuid"`
+	Region           string          `json:"region"`
+	Streak           PlacementStreak `json:"streak"`
+	CurrentWinStreak int             `json:"currentWinStreak"`
+	Form             PlacementForm   `json:"form"`
+	Message          string          `json:"message"`
+	NewMatchIDs      []string        `json:"newMatchIds,omitempty"`
+	OccurredAt       time.Time       `json:"occurredAt"`
+}
+
+// computePlacementForm expects records ordered from most recent to oldest and
+// only looks at the first formWindow of them.
+func computePlacementForm(records []PlacementRecord) PlacementForm {
+	if len(records) > formWindow {
+		records = records[:formWindow]
+	}
+	form := PlacementForm{Games: len(records), Direction: formStable}
+	if form.Games == 0 {
+		return form
+	}
+
+	// Index 0 is the oldest game so the slope follows play order.
+	n := float64(form.Games)
+	var sumX, sumY float64
+	for i := range records {
+		sumX += float64(i)
+		sumY += float64(records[form.Games-1-i].Placement)
+	}
+	meanX, meanY := sumX/n, sumY/n
+	form.AveragePlacement = roundTo(meanY, 2)
+
+	var covariance, variance float64
+	for i := range records {
+		dx := float64(i) - meanX
+		covariance += dx * (float64(records[form.Games-1-i].Placement) - meanY)
+		variance += dx * dx
+	}
+	if variance == 0 {
+		return form
+	}
+
+	form.Trend = roundTo(covariance/variance, 3)
+	switch {
+	case form.Trend <= -formTrendThreshold:
+		form.Direction = formImproving
+	case form.Trend >= formTrendThreshold:
+		form.Direction = formDeclining
+	}
+	return form
+}
+
+func newPlayerForm(summary *PlacementSummary) *PlayerForm {
+	return &PlayerForm{
+		Matches:          summary.Matches,
+		AveragePlacement: summary.AveragePlacement,
+		Top4Rate:         summary.Top4Rate,
+		CurrentStreak:    summary.CurrentStreak,
+		CurrentWinStreak: summary.CurrentWinStreak,
+		Recent:           summary.Form,
+		Description:      describeStreak(summary.CurrentStreak, summary.CurrentWinStreak),
+	}
+}
+
+// describeStreak phrases the streak for notifications, preferring a win
+// streak over the top 4 streak it is part of. Single games are not streaks.
+func describeStreak(streak PlacementStreak, winStreak int) string {
+	switch {
+	case winStreak >= 2:
+		return fmt.Sprintf("player is on a %d-game win streak", winStreak)
+	case streak.Length < 2:
+		return ""
+	case streak.Kind == streakTop4:
+		return fmt.Sprintf("player is on a %d-game top-4 streak", streak.Length)
+	default:
+		return fmt.Sprintf("player is on a %d-game bottom-4 streak", streak.Length)
+	}
+}
+
+// streakEvent reports a top 4 or bottom 4 streak of at least threshold games.
+// It is only built when the refresh found new matches, so each game that
+// extends a streak past the threshold produces one event.
+func streakEvent(entry *WatchlistEntry, summary *PlacementSummary, newMatchIDs []string, threshold int) *StreakEvent {
+	if threshold <= 0 || len(newMatchIDs) == 0 || summary.CurrentStreak.Length < threshold {
+		return nil
+	}
+
+	return &StreakEvent{
+		PUUID:            entry.PUUID,
+		Region:           entry.Region,
+		Streak:           summary.CurrentStreak,
+		CurrentWinStreak: summary.CurrentWinStreak,
+		Form:             summary.Form,
+		Message:          describeStreak(summary.CurrentStreak, 0),
+		NewMatchIDs:      newMatchIDs,
+		OccurredAt:       time.Now().UTC(),
+	}
+}
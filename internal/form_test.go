@@ -0,0 +1,89 @@
+package internal
+
+import "testing"
+
+func placementsNewestFirst(placements ...int) []PlacementRecord {
+	records := make([]PlacementRecord, len(placements))
+	for i, placement := range placements {
+		records[i] = PlacementRecord{Placement: placement}
+	}
+	return records
+}
+
+func TestComputePlacementForm(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   []PlacementRecord
+		games     int
+		average   float64
+		trend     float64
+		direction string
+	}{
+		{name: "no games", direction: formStable},
+		{name: "single game", records: placementsNewestFirst(3), games: 1, average: 3, direction: formStable},
+		{name: "improving", records: placementsNewestFirst(1, 2, 3, 4, 5), games: 5, average: 3, trend: -1, direction: formImproving},
+		{name: "declining", records: placementsNewestFirst(8, 6, 4, 2), games: 4, average: 5, trend: 2, direction: formDeclining},
+		{name: "flat", records: placementsNewestFirst(4, 4, 4, 4), games: 4, average: 4, direction: formStable},
+		{
+			name:      "only the last 20 games",
+			records:   append(placementsNewestFirst(4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4, 4), placementsNewestFirst(8, 8, 8)...),
+			games:     20,
+			average:   4,
+			direction: formStable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			form := computePlacementForm(tt.records)
+			if form.Games != tt.games || form.AveragePlacement != tt.average || form.Trend != tt.trend || form.Direction != tt.direction {
+				t.Errorf("computePlacementForm() = %+v, expected %d games, average %v, trend %v, %s", form, tt.games, tt.average, tt.trend, tt.direction)
+			}
+		})
+	}
+}
+
+func TestDescribeStreak(t *testing.T) {
+	tests := []struct {
+		streak    PlacementStreak
+		winStreak int
+		expected  string
+	}{
+		{streak: PlacementStreak{Kind: streakTop4, Length: 6}, expected: "player is on a 6-game top-4 streak"},
+		{streak: PlacementStreak{Kind: streakTop4, Length: 6}, winStreak: 3, expected: "player is on a 3-game win streak"},
+		{streak: PlacementStreak{Kind: streakBottom4, Length: 4}, expected: "player is on a 4-game bottom-4 streak"},
+		{streak: PlacementStreak{Kind: streakTop4, Length: 1}, winStreak: 1, expected: ""},
+	}
+
+	for _, tt := range tests {
+		if got := describeStreak(tt.streak, tt.winStreak); got != tt.expected {
+			t.Errorf("describeStreak(%+v, %d) = %q, expected %q", tt.streak, tt.winStreak, got, tt.expected)
+		}
+	}
+}
+
+func TestStreakEvent(t *testing.T) {
+	entry := &WatchlistEntry{PUUID: "p1", Region: "BR1"}
+	summary := computePlacementStats("p1", placementsNewestFirst(1, 1, 3, 2, 4, 2, 7, 5))
+
+	event := streakEvent(entry, &summary, []string{"BR1_9"}, 5)
+	if event == nil {
+		t.Fatal("expected an event for a 6-game top-4 streak")
+	}
+	if event.Streak != (PlacementStreak{Kind: streakTop4, Length: 6}) || event.CurrentWinStreak != 2 {
+		t.Errorf("event = %+v, expected top4 x6 with a 2-game win streak", event)
+	}
+	if event.Message != "player is on a 6-game top-4 streak" {
+		t.Errorf("Message = %q", event.Message)
+	}
+
+	if streakEvent(entry, &summary, nil, 5) != nil {
+		t.Error("expected no event without new matches")
+	}
+	if streakEvent(entry, &summary, []string{"BR1_9"}, 7) != nil {
+		t.Error("expected no event below the threshold")
+	}
+	if streakEvent(entry, &summary, []string{"BR1_9"}, 0) != nil {
+		t.Error("expected no event with the threshold disabled")
+	}
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// RegionLeague is a platform region where a player has TFT ranked entries.
+type RegionLeague struct {
+	Region  string        `json:"region"`
+	League  *LeagueEntry  `json:"league"`
+	Entries []LeagueEntry `json:"entries"`
+}
+
+// globalSearchRegions lists every platform region, sorted so responses are
+// stable.
+func globalSearchRegions() []string {
+	regions := make([]string, 0, len(accountAPIURLs))
+	for region := range accountAPIURLs {
+		regions = append(regions, region)
+	}
+	sort.Strings(regions)
+	return regions
+}
+
+func GlobalSearchHandler(riotClient *RiotAPIClient, branches *BranchTimeouts, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "search", logger)(globalSearch(riotClient, branches, logger)))
+}
+
+// globalSearch resolves the Riot ID once, since accounts are shared by every
+// platform, then asks all platforms for the PUUID's ranked entries at once.
+// A platform that fails or runs past the region branch timeout is listed in
+// degraded instead of failing the search.
+func globalSearch(riotClient *RiotAPIClient, branches *BranchTimeouts, logger *Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		requestID := GetRequestID(r.Context())
+
+		id, err := NewRiotID(r.URL.Query().Get("gameName"), r.URL.Query().Get("tagLine"))
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		logSearchRequest(id.GameName, id.TagLine, requestID, logger)
+
+		exact := r.URL.Query().Get("exact") == "true"
+		accountData, err := riotClient.GetAccountByRiotID(r.Context(), id, exact)
+		if err != nil {
+			handleAccountError(err, id.GameName, id.TagLine, requestID, logger, w, r)
+			return
+		}
+
+		regions, degraded := searchRegions(r.Context(), riotClient, accountData.PUUID, branches, logger)
+
+		logger.Info("player_global_search_success").
+			Component("search").
+			Operation("global_search").
+			Request("", "", requestID).
+			Game(accountData.PUUID, "", "").
+			Meta("regions_found", len(regions)).
+			Meta("regions_degraded", len(degraded)).
+			Log()
+
+		result := map[string]interface{}{
+			"account":  accountData,
+			"puuid":    accountData.PUUID,
+			"gameName": accountData.GameName,
+			"tagLine":  accountData.TagLine,
+			"regions":  regions,
+		}
+		if len(degraded) > 0 {
+			result["degraded"] = degraded
+		}
+		writeJSON(w, result, logger, r)
+	}
+}
+
+// searchRegions returns the regions with ranked entries for puuid, sorted by
+// region. A 404 means the player has never played on that platform.
+func searchRegions(ctx context.Context, riotClient *RiotAPIClient, puuid string, branches *BranchTimeouts, logger *Logger) ([]RegionLeague, []DegradedSection) {
+	var mu sync.Mutex
+	regions := make([]RegionLeague, 0)
+
+	searches := make([]Branch, 0, len(accountAPIURLs))
+	for _, region := range globalSearchRegions() {
+		searches = append(searches, Branch{Name: region, Run: func(ctx context.Context) error {
+			if timeout := branches.For("region"); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			entries, err := riotClient.ForRegion(region).GetLeagueByPUUID(ctx, puuid)
+			if err != nil {
+				if isRiotNotFound(err) {
+					return nil
+				}
+				return err
+			}
+			if len(entries) == 0 {
+				return nil
+			}
+
+			mu.Lock()
+			regions = append(regions, RegionLeague{Region: region, League: findTFTLeague(entries), Entries: entries})
+			mu.Unlock()
+			return nil
+		}})
+	}
+
+	degraded, _ := runBranches(ctx, branches, logger, searches...)
+	sort.Slice(regions, func(i, j int) bool { return regions[i].Region < regions[j].Region })
+	sort.Slice(degraded, func(i, j int) bool { return degraded[i].Section < degraded[j].Section })
+	return regions, degraded
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGlobalSearch(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "riot/account/v1/accounts/by-riot-id/Ranked/BR1.json", `{"puuid":"p1","gameName":"Ranked","tagLine":"BR1"}`)
+	writeFixture(t, dir, "riot/account/v1/accounts/by-riot-id/Casual/BR1.json", `{"puuid":"p2","gameName":"Casual","tagLine":"BR1"}`)
+	writeFixture(t, dir, "tft/league/v1/by-puuid/p1.json", `[
+		{"puuid":"p1","queueType":"RANKED_TFT_DOUBLE_UP","tier":"GOLD","rank":"II"},
+		{"puuid":"p1","queueType":"RANKED_TFT","tier":"DIAMOND","rank":"I","leaguePoints":40}
+	]`)
+	writeFixture(t, dir, "tft/league/v1/by-puuid/p2.json", `[]`)
+	handler := globalSearch(newCachedFixtureClient(dir), nil, newTestLogger())
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected int
+	}{
+		// Fixtures ignore the host, so every platform answers alike.
+		{name: "ranked everywhere", query: "gameName=Ranked&tagLine=BR1", status: http.StatusOK, expected: len(accountAPIURLs)},
+		{name: "no ranked entries", query: "gameName=Casual&tagLine=BR1", status: http.StatusOK, expected: 0},
+		{name: "unknown player", query: "gameName=Nobody&tagLine=BR1", status: http.StatusNotFound},
+		{name: "missing game name", query: "tagLine=BR1", status: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/player/global-search?"+tt.query, nil))
+			if rec.Code != tt.status {
+				t.Fatalf("status = %d, expected %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var result struct {
+				PUUID    string            `json:"puuid"`
+				Regions  []RegionLeague    `json:"regions"`
+				Degraded []DegradedSection `json:"degraded"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+				t.Fatal(err)
+			}
+			if result.Regions == nil || len(result.Regions) != tt.expected || len(result.Degraded) != 0 {
+				t.Fatalf("got %d regions and %v degraded, expected %d regions", len(result.Regions), result.Degraded, tt.expected)
+			}
+			for i, region := range result.Regions {
+				if i > 0 && result.Regions[i-1].Region >= region.Region {
+					t.Errorf("regions not sorted: %s after %s", region.Region, result.Regions[i-1].Region)
+				}
+				if region.League == nil || region.League.Tier != "DIAMOND" || len(region.Entries) != 2 {
+					t.Errorf("region %s = %+v, expected the RANKED_TFT entry picked out", region.Region, region)
+				}
+			}
+		})
+	}
+}
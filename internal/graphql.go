@@ -0,0 +1,1081 @@
+package internal
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// This file holds a small GraphQL executor: enough of the spec to serve
+// read-only queries (fields, arguments, variables, aliases, fragments and
+// @include/@skip) against the resolvers in graphql_schema.go. Mutations,
+// subscriptions and introspection beyond __typename are not supported.
+
+const (
+	gqlMaxDepth = 10
+	// gqlMaxCost bounds the upstream calls a query may make, estimated before
+	// it runs from each field's cost and the most items its lists can hold.
+	gqlMaxCost = 250
+	// gqlMaxAliases bounds how often one selection set may select the same
+	// field under different aliases.
+	gqlMaxAliases = 10
+	// gqlListConcurrency bounds how many items of a list resolve at once, and
+	// how many resolvers with a cost run at once across the whole query, so
+	// nested lists do not multiply the fan-out to Riot.
+	gqlListConcurrency = 8
+)
+
+type gqlTokenKind int
+
+const (
+	gqlEOF gqlTokenKind = iota
+	gqlPunct
+	gqlName
+	gqlInt
+	gqlFloat
+	gqlString
+)
+
+type gqlToken struct {
+	kind  gqlTokenKind
+	value string
+	pos   int
+}
+
+func gqlTokenize(source string) ([]gqlToken, error) {
+	var tokens []gqlToken
+	for i := 0; i < len(source); {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(source) && source[i] != '\n' && source[i] != '\r' {
+				i++
+			}
+		case strings.HasPrefix(source[i:], "..."):
+			tokens = append(tokens, gqlToken{gqlPunct, "...", i})
+			i += 3
+		case strings.IndexByte("!$():=@[]{}|&", c) >= 0:
+			tokens = append(tokens, gqlToken{gqlPunct, string(c), i})
+			i++
+		case c == '_' || 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z':
+			start := i
+			for i < len(source) && (source[i] == '_' || 'A' <= source[i] && source[i] <= 'Z' || 'a' <= source[i] && source[i] <= 'z' || '0' <= source[i] && source[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, gqlToken{gqlName, source[start:i], start})
+		case c == '-' || '0' <= c && c <= '9':
+			start := i
+			kind := gqlInt
+			i++
+			for i < len(source) {
+				d := source[i]
+				if '0' <= d && d <= '9' {
+					i++
+				} else if d == '.' || d == 'e' || d == 'E' || (d == '+' || d == '-') && (source[i-1] == 'e' || source[i-1] == 'E') {
+					kind = gqlFloat
+					i++
+				} else {
+					break
+				}
+			}
+			tokens = append(tokens, gqlToken{kind, source[start:i], start})
+		case c == '"':
+			if strings.HasPrefix(source[i:], `"""`) {
+				end := strings.Index(source[i+3:], `"""`)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated block string at %d", i)
+				}
+				tokens = append(tokens, gqlToken{gqlString, strings.TrimSpace(source[i+3 : i+3+end]), i})
+				i += end + 6
+				continue
+			}
+			start := i
+			i++
+			for i < len(source) && source[i] != '"' {
+				if source[i] == '\n' {
+					return nil, fmt.Errorf("unterminated string at %d", start)
+				}
+				if source[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(source) {
+				return nil, fmt.Errorf("unterminated string at %d", start)
+			}
+			value, err := gqlUnescape(source[start+1 : i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at %d", start)
+			}
+			tokens = append(tokens, gqlToken{gqlString, value, start})
+			i++
+		default:
+			r, _ := utf8.DecodeRuneInString(source[i:])
+			return nil, fmt.Errorf("unexpected character %q at %d", r, i)
+		}
+	}
+	return append(tokens, gqlToken{gqlEOF, "", len(source)}), nil
+}
+
+// gqlUnescape decodes the escapes of a GraphQL string body.
+func gqlUnescape(body string) (string, error) {
+	if !strings.Contains(body, `\`) {
+		return body, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' {
+			b.WriteByte(body[i])
+			continue
+		}
+		i++
+		if i >= len(body) {
+			return "", fmt.Errorf("dangling escape")
+		}
+		switch body[i] {
+		case '"', '\\', '/':
+			b.WriteByte(body[i])
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			hex := ""
+			if strings.HasPrefix(body[i+1:], "{") {
+				end := strings.IndexByte(body[i:], '}')
+				if end < 0 {
+					return "", fmt.Errorf("invalid unicode escape")
+				}
+				hex, i = body[i+2:i+end], i+end
+			} else if i+4 < len(body) {
+				hex, i = body[i+1:i+5], i+4
+			}
+			code, err := strconv.ParseUint(hex, 16, 32)
+			if err != nil || !utf8.ValidRune(rune(code)) {
+				return "", fmt.Errorf("invalid unicode escape")
+			}
+			b.WriteRune(rune(code))
+		default:
+			return "", fmt.Errorf("invalid escape \\%c", body[i])
+		}
+	}
+	return b.String(), nil
+}
+
+type gqlVariable string
+
+type gqlEnum string
+
+type gqlDirective struct {
+	name string
+	args map[string]interface{}
+}
+
+type gqlSelection struct {
+	// Exactly one of field, spread and inline applies.
+	alias      string
+	name       string
+	args       map[string]interface{}
+	selections []gqlSelection
+	directives []gqlDirective
+
+	spread string
+	inline bool
+}
+
+type gqlOperation struct {
+	kind       string
+	name       string
+	variables  map[string]interface{}
+	selections []gqlSelection
+}
+
+type gqlDocument struct {
+	operations []gqlOperation
+	fragments  map[string][]gqlSelection
+}
+
+type gqlParser struct {
+	tokens []gqlToken
+	pos    int
+}
+
+// GraphQLError is an entry of the response's errors list.
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func parseGraphQL(source string) (*gqlDocument, error) {
+	tokens, err := gqlTokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &gqlParser{tokens: tokens}
+	doc := &gqlDocument{fragments: make(map[string][]gqlSelection)}
+
+	for p.peek().kind != gqlEOF {
+		switch {
+		case p.peek().value == "{":
+			selections, err := p.selectionSet(0)
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, gqlOperation{kind: "query", selections: selections})
+		case p.peek().value == "fragment":
+			p.next()
+			name := p.next()
+			if name.kind != gqlName || name.value == "on" {
+				return nil, p.errorAt(name, "expected fragment name")
+			}
+			if on := p.next(); on.value != "on" {
+				return nil, p.errorAt(on, "expected \"on\"")
+			}
+			if typeName := p.next(); typeName.kind != gqlName {
+				return nil, p.errorAt(typeName, "expected type condition")
+			}
+			selections, err := p.selectionSet(0)
+			if err != nil {
+				return nil, err
+			}
+			if _, exists := doc.fragments[name.value]; exists {
+				return nil, fmt.Errorf("fragment %q is defined more than once", name.value)
+			}
+			doc.fragments[name.value] = selections
+		case p.peek().kind == gqlName:
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		default:
+			return nil, p.errorAt(p.peek(), "expected an operation or fragment")
+		}
+	}
+
+	if len(doc.operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *gqlParser) peek() gqlToken {
+	return p.tokens[p.pos]
+}
+
+func (p *gqlParser) next() gqlToken {
+	token := p.tokens[p.pos]
+	if token.kind != gqlEOF {
+		p.pos++
+	}
+	return token
+}
+
+func (p *gqlParser) expect(punct string) error {
+	if token := p.next(); token.kind != gqlPunct || token.value != punct {
+		return p.errorAt(token, fmt.Sprintf("expected %q", punct))
+	}
+	return nil
+}
+
+func (p *gqlParser) errorAt(token gqlToken, message string) error {
+	if token.kind == gqlEOF {
+		return fmt.Errorf("syntax error: %s, found end of document", message)
+	}
+	return fmt.Errorf("syntax error at %d: %s, found %q", token.pos, message, token.value)
+}
+
+func (p *gqlParser) operation() (gqlOperation, error) {
+	kind := p.next()
+	if kind.value != "query" && kind.value != "mutation" && kind.value != "subscription" {
+		return gqlOperation{}, p.errorAt(kind, "expected an operation type")
+	}
+	op := gqlOperation{kind: kind.value, variables: make(map[string]interface{})}
+	if p.peek().kind == gqlName {
+		op.name = p.next().value
+	}
+
+	if p.peek().value == "(" {
+		p.next()
+		for p.peek().value != ")" {
+			if err := p.expect("$"); err != nil {
+				return op, err
+			}
+			name := p.next()
+			if name.kind != gqlName {
+				return op, p.errorAt(name, "expected variable name")
+			}
+			if err := p.expect(":"); err != nil {
+				return op, err
+			}
+			if err := p.skipType(); err != nil {
+				return op, err
+			}
+			op.variables[name.value] = nil
+			if p.peek().value == "=" {
+				p.next()
+				value, err := p.value(true)
+				if err != nil {
+					return op, err
+				}
+				op.variables[name.value] = value
+			}
+		}
+		p.next()
+	}
+	if _, err := p.directives(); err != nil {
+		return op, err
+	}
+
+	selections, err := p.selectionSet(0)
+	op.selections = selections
+	return op, err
+}
+
+// skipType consumes a variable type; values are checked by the resolvers
+// that receive them rather than against the declared type.
+func (p *gqlParser) skipType() error {
+	if p.peek().value == "[" {
+		p.next()
+		if err := p.skipType(); err != nil {
+			return err
+		}
+		if err := p.expect("]"); err != nil {
+			return err
+		}
+	} else if token := p.next(); token.kind != gqlName {
+		return p.errorAt(token, "expected type")
+	}
+	if p.peek().value == "!" {
+		p.next()
+	}
+	return nil
+}
+
+func (p *gqlParser) selectionSet(depth int) ([]gqlSelection, error) {
+	if depth > gqlMaxDepth {
+		return nil, fmt.Errorf("query is nested deeper than %d levels", gqlMaxDepth)
+	}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	var selections []gqlSelection
+	for p.peek().value != "}" {
+		if p.peek().kind == gqlEOF {
+			return nil, p.errorAt(p.peek(), "expected \"}\"")
+		}
+
+		if p.peek().value == "..." {
+			p.next()
+			selection := gqlSelection{}
+			if p.peek().kind == gqlName && p.peek().value != "on" {
+				selection.spread = p.next().value
+			} else {
+				selection.inline = true
+				if p.peek().value == "on" {
+					p.next()
+					if token := p.next(); token.kind != gqlName {
+						return nil, p.errorAt(token, "expected type condition")
+					}
+				}
+			}
+			directives, err := p.directives()
+			if err != nil {
+				return nil, err
+			}
+			selection.directives = directives
+			if selection.inline {
+				if selection.selections, err = p.selectionSet(depth + 1); err != nil {
+					return nil, err
+				}
+			}
+			selections = append(selections, selection)
+			continue
+		}
+
+		name := p.next()
+		if name.kind != gqlName {
+			return nil, p.errorAt(name, "expected field name")
+		}
+		selection := gqlSelection{alias: name.value, name: name.value}
+		if p.peek().value == ":" {
+			p.next()
+			field := p.next()
+			if field.kind != gqlName {
+				return nil, p.errorAt(field, "expected field name")
+			}
+			selection.name = field.value
+		}
+
+		var err error
+		if p.peek().value == "(" {
+			if selection.args, err = p.arguments(); err != nil {
+				return nil, err
+			}
+		}
+		if selection.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		if p.peek().value == "{" {
+			if selection.selections, err = p.selectionSet(depth + 1); err != nil {
+				return nil, err
+			}
+		}
+		selections = append(selections, selection)
+	}
+	p.next()
+
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("selection sets must not be empty")
+	}
+	return selections, nil
+}
+
+func (p *gqlParser) arguments() (map[string]interface{}, error) {
+	p.next()
+	args := make(map[string]interface{})
+	for p.peek().value != ")" {
+		name := p.next()
+		if name.kind != gqlName {
+			return nil, p.errorAt(name, "expected argument name")
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(false)
+		if err != nil {
+			return nil, err
+		}
+		args[name.value] = value
+	}
+	p.next()
+	return args, nil
+}
+
+func (p *gqlParser) directives() ([]gqlDirective, error) {
+	var directives []gqlDirective
+	for p.peek().value == "@" {
+		p.next()
+		name := p.next()
+		if name.kind != gqlName {
+			return nil, p.errorAt(name, "expected directive name")
+		}
+		directive := gqlDirective{name: name.value}
+		if p.peek().value == "(" {
+			args, err := p.arguments()
+			if err != nil {
+				return nil, err
+			}
+			directive.args = args
+		}
+		directives = append(directives, directive)
+	}
+	return directives, nil
+}
+
+func (p *gqlParser) value(constant bool) (interface{}, error) {
+	token := p.next()
+	switch token.kind {
+	case gqlInt:
+		n, err := strconv.ParseInt(token.value, 10, 64)
+		if err != nil {
+			return nil, p.errorAt(token, "invalid integer")
+		}
+		return n, nil
+	case gqlFloat:
+		f, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, p.errorAt(token, "invalid number")
+		}
+		return f, nil
+	case gqlString:
+		return token.value, nil
+	case gqlName:
+		switch token.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		return gqlEnum(token.value), nil
+	}
+
+	switch token.value {
+	case "$":
+		if constant {
+			return nil, p.errorAt(token, "variables are not allowed in default values")
+		}
+		name := p.next()
+		if name.kind != gqlName {
+			return nil, p.errorAt(name, "expected variable name")
+		}
+		return gqlVariable(name.value), nil
+	case "[":
+		list := []interface{}{}
+		for p.peek().value != "]" {
+			if p.peek().kind == gqlEOF {
+				return nil, p.errorAt(p.peek(), "expected \"]\"")
+			}
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		p.next()
+		return list, nil
+	case "{":
+		object := map[string]interface{}{}
+		for p.peek().value != "}" {
+			name := p.next()
+			if name.kind != gqlName {
+				return nil, p.errorAt(name, "expected field name")
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			object[name.value] = item
+		}
+		p.next()
+		return object, nil
+	}
+	return nil, p.errorAt(token, "expected a value")
+}
+
+// gqlObject is an output type. Resolvers receive the value their parent
+// resolved; a field with an object type resolves to a value of that type, or
+// to a []interface{} of them when list is set.
+type gqlObject struct {
+	name   string
+	fields []*gqlField
+	byName map[string]*gqlField
+}
+
+type gqlField struct {
+	name        string
+	typ         string
+	description string
+	args        []gqlArgDef
+	object      *gqlObject
+	list        bool
+	resolve     func(ctx context.Context, source interface{}, args gqlArgs) (interface{}, error)
+
+	// cost is how many upstream calls one resolve makes at most; fields in
+	// the same group share one call per source value. items is how many
+	// values a list resolves to at most, given its own arguments and those
+	// of the field it belongs to. Both only feed the cost estimate.
+	cost  func(args gqlArgs) int
+	group string
+	items func(args, parent gqlArgs) int
+}
+
+type gqlArgDef struct {
+	name string
+	typ  string
+}
+
+func newGQLObject(name string, fields ...*gqlField) *gqlObject {
+	object := &gqlObject{name: name, byName: make(map[string]*gqlField, len(fields))}
+	object.define(fields...)
+	return object
+}
+
+// define adds fields after construction, for types that refer to each
+// other.
+func (o *gqlObject) define(fields ...*gqlField) {
+	for _, field := range fields {
+		o.fields = append(o.fields, field)
+		o.byName[field.name] = field
+	}
+}
+
+// gqlArgs holds a field's arguments with variables already substituted.
+type gqlArgs map[string]interface{}
+
+func (a gqlArgs) String(name string) (string, error) {
+	switch value := a[name].(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case gqlEnum:
+		return string(value), nil
+	default:
+		return "", NewAPIError(fmt.Sprintf("argument %q must be a string", name), http.StatusBadRequest)
+	}
+}
+
+func (a gqlArgs) Int(name string, defaultValue, min, max int) (int, error) {
+	var n int64
+	switch value := a[name].(type) {
+	case nil:
+		return defaultValue, nil
+	case int64:
+		n = value
+	case float64:
+		// Variables decoded from JSON arrive as floats.
+		if value != float64(int64(value)) {
+			return 0, NewAPIError(fmt.Sprintf("argument %q must be an integer", name), http.StatusBadRequest)
+		}
+		n = int64(value)
+	default:
+		return 0, NewAPIError(fmt.Sprintf("argument %q must be an integer", name), http.StatusBadRequest)
+	}
+	if n < int64(min) || n > int64(max) {
+		return 0, NewAPIError(fmt.Sprintf("argument %q must be between %d and %d", name, min, max), http.StatusBadRequest)
+	}
+	return int(n), nil
+}
+
+// gqlResponse is an object with the keys in selection order, as the spec
+// asks of serialized results.
+type gqlResponse struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (r *gqlResponse) set(key string, value interface{}) {
+	if _, exists := r.values[key]; !exists {
+		r.keys = append(r.keys, key)
+	}
+	r.values[key] = value
+}
+
+func (r *gqlResponse) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range r.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, _ := json.Marshal(key)
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, err := json.Marshal(r.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+type gqlExecutor struct {
+	fragments map[string][]gqlSelection
+	variables map[string]interface{}
+	toError   func(err error) GraphQLError
+	// slots bounds the resolvers with a cost running at once.
+	slots chan struct{}
+
+	mu     sync.Mutex
+	errors []GraphQLError
+}
+
+// gqlQuery is an operation that parsed, validated and fits the cost budget.
+type gqlQuery struct {
+	root       *gqlObject
+	selections []gqlSelection
+	ex         *gqlExecutor
+	// Cost is the estimated number of upstream calls, for rate limiting.
+	Cost int
+}
+
+// prepareGraphQL picks the operation named operationName (or the only one)
+// and checks it before anything runs. An error means the request itself is
+// invalid or too expensive.
+func prepareGraphQL(root *gqlObject, query, operationName string, variables map[string]interface{}) (*gqlQuery, error) {
+	doc, err := parseGraphQL(query)
+	if err != nil {
+		return nil, err
+	}
+
+	if operationName == "" && len(doc.operations) > 1 {
+		return nil, fmt.Errorf("operationName is required when the document has several operations")
+	}
+	var op *gqlOperation
+	for i := range doc.operations {
+		if operationName == "" || doc.operations[i].name == operationName {
+			op = &doc.operations[i]
+			break
+		}
+	}
+	if op == nil {
+		return nil, fmt.Errorf("unknown operation %q", operationName)
+	}
+	if op.kind != "query" {
+		return nil, fmt.Errorf("%s operations are not supported", op.kind)
+	}
+
+	resolved := make(map[string]interface{}, len(op.variables))
+	for name, defaultValue := range op.variables {
+		resolved[name] = defaultValue
+		if value, ok := variables[name]; ok {
+			resolved[name] = value
+		}
+	}
+
+	ex := &gqlExecutor{fragments: doc.fragments, variables: resolved, slots: make(chan struct{}, gqlListConcurrency)}
+	if err := ex.validate(root, op.selections, map[string]bool{}); err != nil {
+		return nil, err
+	}
+	cost, err := ex.cost(root, op.selections, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &gqlQuery{root: root, selections: op.selections, ex: ex, Cost: cost}, nil
+}
+
+// execute returns data together with the field errors.
+func (q *gqlQuery) execute(ctx context.Context, toError func(error) GraphQLError) (*gqlResponse, []GraphQLError) {
+	q.ex.toError = toError
+	data := q.ex.selectionSet(ctx, q.root, nil, q.selections, nil)
+	// List items resolve concurrently; sort so the errors read in list order.
+	slices.SortStableFunc(q.ex.errors, func(a, b GraphQLError) int { return gqlComparePaths(a.Path, b.Path) })
+	return data, q.ex.errors
+}
+
+// executeGraphQL prepares and runs a query. A non-nil error means the
+// request itself is invalid.
+func executeGraphQL(ctx context.Context, root *gqlObject, query, operationName string, variables map[string]interface{}, toError func(error) GraphQLError) (*gqlResponse, []GraphQLError, error) {
+	q, err := prepareGraphQL(root, query, operationName, variables)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, fieldErrors := q.execute(ctx, toError)
+	return data, fieldErrors, nil
+}
+
+func gqlComparePaths(a, b []interface{}) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		x, xInt := a[i].(int)
+		y, yInt := b[i].(int)
+		if xInt && yInt {
+			if c := cmp.Compare(x, y); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := strings.Compare(fmt.Sprint(a[i]), fmt.Sprint(b[i])); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// validate checks field and argument names up front, so a typo fails the
+// request instead of resolving everything else around a null.
+func (ex *gqlExecutor) validate(object *gqlObject, selections []gqlSelection, visiting map[string]bool) error {
+	for _, selection := range selections {
+		for _, directive := range selection.directives {
+			if err := ex.declared(directive.args["if"]); err != nil {
+				return err
+			}
+		}
+		switch {
+		case selection.spread != "":
+			fragment, ok := ex.fragments[selection.spread]
+			if !ok {
+				return fmt.Errorf("unknown fragment %q", selection.spread)
+			}
+			if visiting[selection.spread] {
+				return fmt.Errorf("fragment %q spreads itself", selection.spread)
+			}
+			visiting[selection.spread] = true
+			if err := ex.validate(object, fragment, visiting); err != nil {
+				return err
+			}
+			delete(visiting, selection.spread)
+		case selection.inline:
+			if err := ex.validate(object, selection.selections, visiting); err != nil {
+				return err
+			}
+		case selection.name == "__typename":
+		default:
+			field, ok := object.byName[selection.name]
+			if !ok {
+				return fmt.Errorf("cannot query field %q on type %q", selection.name, object.name)
+			}
+			for name, value := range selection.args {
+				if err := ex.declared(value); err != nil {
+					return err
+				}
+				known := false
+				for _, arg := range field.args {
+					known = known || arg.name == name
+				}
+				if !known {
+					return fmt.Errorf("unknown argument %q on field %q", name, object.name+"."+field.name)
+				}
+			}
+			if field.object == nil && selection.selections != nil {
+				return fmt.Errorf("field %q of type %s must not have a selection", selection.name, field.typ)
+			}
+			if field.object != nil && selection.selections == nil {
+				return fmt.Errorf("field %q of type %s must have a selection of subfields", selection.name, field.typ)
+			}
+			if field.object != nil {
+				if err := ex.validate(field.object, selection.selections, visiting); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// cost estimates the upstream calls selections make at most: each field's
+// own, plus its subselection's once per item it can resolve to. It stops as
+// soon as the estimate passes gqlMaxCost, so deep lists cannot overflow it.
+func (ex *gqlExecutor) cost(object *gqlObject, selections []gqlSelection, parent gqlArgs) (int, error) {
+	total := 0
+	selected := map[string]int{}
+	groups := map[string]bool{}
+	for _, selection := range ex.collect(selections, nil) {
+		if selection.name == "__typename" {
+			continue
+		}
+		if selected[selection.name]++; selected[selection.name] > gqlMaxAliases {
+			return 0, fmt.Errorf("field %q is selected more than %d times", selection.name, gqlMaxAliases)
+		}
+
+		field := object.byName[selection.name]
+		args := ex.args(selection)
+		if field.cost != nil && (field.group == "" || !groups[field.group]) {
+			total += field.cost(args)
+			if field.group != "" {
+				groups[field.group] = true
+			}
+		}
+		if field.object != nil {
+			sub, err := ex.cost(field.object, selection.selections, args)
+			if err != nil {
+				return 0, err
+			}
+			if field.list {
+				items := 1
+				if field.items != nil {
+					items = field.items(args, parent)
+				}
+				sub *= items
+			}
+			total += sub
+		}
+		if total > gqlMaxCost {
+			return 0, fmt.Errorf("query is too expensive: it may make more than %d upstream calls", gqlMaxCost)
+		}
+	}
+	return total, nil
+}
+
+func (ex *gqlExecutor) args(selection gqlSelection) gqlArgs {
+	args := make(gqlArgs, len(selection.args))
+	for name, value := range selection.args {
+		args[name] = ex.substitute(value)
+	}
+	return args
+}
+
+// declared rejects variables the operation does not declare.
+func (ex *gqlExecutor) declared(value interface{}) error {
+	switch v := value.(type) {
+	case gqlVariable:
+		if _, ok := ex.variables[string(v)]; !ok {
+			return fmt.Errorf("variable \"$%s\" is not defined", v)
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := ex.declared(item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := ex.declared(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (ex *gqlExecutor) substitute(value interface{}) interface{} {
+	switch v := value.(type) {
+	case gqlVariable:
+		return ex.variables[string(v)]
+	case []interface{}:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = ex.substitute(item)
+		}
+		return list
+	case map[string]interface{}:
+		object := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			object[key] = ex.substitute(item)
+		}
+		return object
+	}
+	return value
+}
+
+func (ex *gqlExecutor) included(directives []gqlDirective) bool {
+	for _, directive := range directives {
+		condition, _ := ex.substitute(directive.args["if"]).(bool)
+		if directive.name == "include" && !condition || directive.name == "skip" && condition {
+			return false
+		}
+	}
+	return true
+}
+
+// collect flattens fragments into the fields to resolve, in order.
+func (ex *gqlExecutor) collect(selections []gqlSelection, fields []gqlSelection) []gqlSelection {
+	for _, selection := range selections {
+		if !ex.included(selection.directives) {
+			continue
+		}
+		switch {
+		case selection.spread != "":
+			fields = ex.collect(ex.fragments[selection.spread], fields)
+		case selection.inline:
+			fields = ex.collect(selection.selections, fields)
+		default:
+			fields = append(fields, selection)
+		}
+	}
+	return fields
+}
+
+func (ex *gqlExecutor) fail(err error, path []interface{}) {
+	gqlErr := ex.toError(err)
+	gqlErr.Path = append([]interface{}(nil), path...)
+
+	ex.mu.Lock()
+	ex.errors = append(ex.errors, gqlErr)
+	ex.mu.Unlock()
+}
+
+func (ex *gqlExecutor) selectionSet(ctx context.Context, object *gqlObject, source interface{}, selections []gqlSelection, path []interface{}) *gqlResponse {
+	result := &gqlResponse{values: make(map[string]interface{})}
+	for _, selection := range ex.collect(selections, nil) {
+		if selection.name == "__typename" {
+			result.set(selection.alias, object.name)
+			continue
+		}
+
+		field := object.byName[selection.name]
+		fieldPath := append(path[:len(path):len(path)], selection.alias)
+
+		value, err := ex.resolve(ctx, field, source, ex.args(selection))
+		if err != nil {
+			ex.fail(err, fieldPath)
+			result.set(selection.alias, nil)
+			continue
+		}
+		result.set(selection.alias, ex.complete(ctx, field, value, selection.selections, fieldPath))
+	}
+	return result
+}
+
+// resolve runs a field's resolver, holding a slot while it has a cost.
+func (ex *gqlExecutor) resolve(ctx context.Context, field *gqlField, source interface{}, args gqlArgs) (interface{}, error) {
+	if field.cost != nil {
+		select {
+		case ex.slots <- struct{}{}:
+			defer func() { <-ex.slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return field.resolve(ctx, source, args)
+}
+
+func (ex *gqlExecutor) complete(ctx context.Context, field *gqlField, value interface{}, selections []gqlSelection, path []interface{}) interface{} {
+	if field.object == nil || value == nil {
+		return value
+	}
+	if !field.list {
+		return ex.selectionSet(ctx, field.object, value, selections, path)
+	}
+
+	items, _ := value.([]interface{})
+	results := make([]interface{}, len(items))
+	var g errgroup.Group
+	g.SetLimit(gqlListConcurrency)
+	for i, item := range items {
+		g.Go(func() error {
+			itemPath := append(path[:len(path):len(path)], i)
+			results[i] = ex.selectionSet(ctx, field.object, item, selections, itemPath)
+			return nil
+		})
+	}
+	g.Wait()
+	return results
+}
+
+// graphQLSchemaSDL prints the schema reachable from root in SDL.
+func graphQLSchemaSDL(root *gqlObject) string {
+	var b strings.Builder
+	seen := map[*gqlObject]bool{}
+	queue := []*gqlObject{root}
+	for len(queue) > 0 {
+		object := queue[0]
+		queue = queue[1:]
+		if seen[object] {
+			continue
+		}
+		seen[object] = true
+
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "type %s {\n", object.name)
+		for _, field := range object.fields {
+			if field.description != "" {
+				fmt.Fprintf(&b, "  # %s\n", field.description)
+			}
+			b.WriteString("  " + field.name)
+			if len(field.args) > 0 {
+				args := make([]string, len(field.args))
+				for i, arg := range field.args {
+					args[i] = arg.name + ": " + arg.typ
+				}
+				b.WriteString("(" + strings.Join(args, ", ") + ")")
+			}
+			b.WriteString(": " + field.typ + "\n")
+			if field.object != nil {
+				queue = append(queue, field.object)
+			}
+		}
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
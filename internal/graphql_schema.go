@@ -0,0 +1,608 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	gqlDefaultLeaderboardLimit = 10
+	gqlDefaultMatchCount       = 5
+	gqlMaxMatchCount           = 20
+	gqlMatchConcurrency        = 4
+	// Upper bounds for lists whose size no argument sets, for the query
+	// cost estimate: a player ranks in at most the ranked, Hyper Roll and
+	// Double Up queues, and a match has eight players.
+	gqlMaxLeagueEntries = 3
+	gqlMatchPlayers     = 8
+)
+
+func gqlCost(calls int) func(gqlArgs) int {
+	return func(gqlArgs) int { return calls }
+}
+
+func gqlItems(items int) func(gqlArgs, gqlArgs) int {
+	return func(gqlArgs, gqlArgs) int { return items }
+}
+
+// gqlPlayer resolves account and summoner data lazily and at most once, so a
+// query that selects only puuid and league never looks up the account.
+type gqlPlayer struct {
+	puuid  string
+	client *RiotAPIClient
+
+	mu       sync.Mutex
+	account  *AccountData
+	summoner *Summoner
+}
+
+func (p *gqlPlayer) getAccount(ctx context.Context) (*AccountData, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.account == nil {
+		account, err := p.client.GetAccountByPUUID(ctx, p.puuid)
+		if err != nil {
+			return nil, err
+		}
+		p.account = account
+	}
+	return p.account, nil
+}
+
+func (p *gqlPlayer) getSummoner(ctx context.Context) (*Summoner, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.summoner == nil {
+		summoner, err := p.client.GetSummonerByPUUID(ctx, p.puuid)
+		if err != nil {
+			return nil, err
+		}
+		p.summoner = summoner
+	}
+	return p.summoner, nil
+}
+
+type gqlLeaderboard struct {
+	tier     string
+	division string
+	page     int
+	entries  []LeagueEntry
+}
+
+func gqlList[T any](items []T) []interface{} {
+	list := make([]interface{}, len(items))
+	for i := range items {
+		list[i] = &items[i]
+	}
+	return list
+}
+
+func gqlScalar(name, typ string, get func(source interface{}) interface{}) *gqlField {
+	return &gqlField{
+		name: name,
+		typ:  typ,
+		resolve: func(_ context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+			return get(source), nil
+		},
+	}
+}
+
+// NewGraphQLSchema builds the Query type resolved against client and its
+// cache.
+func NewGraphQLSchema(client *RiotAPIClient) *gqlObject {
+	trait := newGQLObject("Trait",
+		gqlScalar("name", "String", func(s interface{}) interface{} { return s.(*MatchTrait).Name }),
+		gqlScalar("numUnits", "Int", func(s interface{}) interface{} { return s.(*MatchTrait).NumUnits }),
+		gqlScalar("style", "Int", func(s interface{}) interface{} { return s.(*MatchTrait).Style }),
+		gqlScalar("tierCurrent", "Int", func(s interface{}) interface{} { return s.(*MatchTrait).TierCurrent }),
+	)
+	unit := newGQLObject("Unit",
+		gqlScalar("characterId", "String", func(s interface{}) interface{} { return s.(*MatchUnit).CharacterID }),
+		gqlScalar("tier", "Int", func(s interface{}) interface{} { return s.(*MatchUnit).Tier }),
+		gqlScalar("rarity", "Int", func(s interface{}) interface{} { return s.(*MatchUnit).Rarity }),
+		gqlScalar("itemNames", "[String]", func(s interface{}) interface{} { return s.(*MatchUnit).ItemNames }),
+	)
+
+	player := newGQLObject("Player")
+	leagueEntry := newGQLObject("LeagueEntry")
+	match := newGQLObject("Match")
+	participant := newGQLObject("Participant")
+
+	newPlayer := func(puuid string) *gqlPlayer {
+		return &gqlPlayer{puuid: puuid, client: client}
+	}
+	playerField := func(puuid func(source interface{}) string) *gqlField {
+		return &gqlField{
+			name:   "player",
+			typ:    "Player",
+			object: player,
+			resolve: func(_ context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				return newPlayer(puuid(source)), nil
+			},
+		}
+	}
+	accountField := func(name string, get func(*AccountData) string) *gqlField {
+		return &gqlField{
+			name:  name,
+			typ:   "String",
+			cost:  gqlCost(1),
+			group: "account",
+			resolve: func(ctx context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				account, err := source.(*gqlPlayer).getAccount(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return get(account), nil
+			},
+		}
+	}
+	summonerField := func(name string, get func(*Summoner) int) *gqlField {
+		return &gqlField{
+			name:  name,
+			typ:   "Int",
+			cost:  gqlCost(1),
+			group: "summoner",
+			resolve: func(ctx context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				summoner, err := source.(*gqlPlayer).getSummoner(ctx)
+				if err != nil {
+					return nil, err
+				}
+				return get(summoner), nil
+			},
+		}
+	}
+
+	player.define(
+		gqlScalar("puuid", "String", func(s interface{}) interface{} { return s.(*gqlPlayer).puuid }),
+		accountField("gameName", func(a *AccountData) string { return a.GameName }),
+		accountField("tagLine", func(a *AccountData) string { return a.TagLine }),
+		accountField("riotId", func(a *AccountData) string { return a.GameName + "#" + a.TagLine }),
+		summonerField("summonerLevel", func(s *Summoner) int { return s.SummonerLevel }),
+		summonerField("profileIconId", func(s *Summoner) int { return s.ProfileIconID }),
+		&gqlField{
+			name:   "league",
+			typ:    "[LeagueEntry]",
+			object: leagueEntry,
+			list:   true,
+			cost:   gqlCost(1),
+			items:  gqlItems(gqlMaxLeagueEntries),
+			resolve: func(ctx context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				entries, err := client.GetLeagueByPUUID(ctx, source.(*gqlPlayer).puuid)
+				if err != nil {
+					return nil, err
+				}
+				return gqlList(entries), nil
+			},
+		},
+		&gqlField{
+			name:        "matches",
+			typ:         "[Match]",
+			description: fmt.Sprintf("Most recent first; count is 1-%d, default %d.", gqlMaxMatchCount, gqlDefaultMatchCount),
+			args:        []gqlArgDef{{name: "count", typ: "Int"}},
+			object:      match,
+			list:        true,
+			// The ids, then each match.
+			cost: func(args gqlArgs) int {
+				count, _ := args.Int("count", gqlDefaultMatchCount, 1, gqlMaxMatchCount)
+				return 1 + count
+			},
+			items: func(args, _ gqlArgs) int {
+				count, _ := args.Int("count", gqlDefaultMatchCount, 1, gqlMaxMatchCount)
+				return count
+			},
+			resolve: func(ctx context.Context, source interface{}, args gqlArgs) (interface{}, error) {
+				count, err := args.Int("count", gqlDefaultMatchCount, 1, gqlMaxMatchCount)
+				if err != nil {
+					return nil, err
+				}
+				ids, err := client.GetRecentMatchIDs(ctx, source.(*gqlPlayer).puuid, count)
+				if err != nil {
+					return nil, err
+				}
+
+				matches := make([]Match, len(ids))
+				g, gctx := errgroup.WithContext(ctx)
+				g.SetLimit(gqlMatchConcurrency)
+				for i, id := range ids {
+					g.Go(func() error {
+						m, err := client.GetMatch(gctx, id)
+						if err != nil {
+							return err
+						}
+						matches[i] = *m
+						return nil
+					})
+				}
+				if err := g.Wait(); err != nil {
+					return nil, err
+				}
+				return gqlList(matches), nil
+			},
+		},
+	)
+
+	entry := func(s interface{}) *LeagueEntry { return s.(*LeagueEntry) }
+	leagueEntry.define(
+		gqlScalar("puuid", "String", func(s interface{}) interface{} { return entry(s).PUUID }),
+		gqlScalar("summonerName", "String", func(s interface{}) interface{} { return entry(s).SummonerName }),
+		gqlScalar("tier", "String", func(s interface{}) interface{} { return entry(s).Tier }),
+		gqlScalar("rank", "String", func(s interface{}) interface{} { return entry(s).Rank }),
+		gqlScalar("leaguePoints", "Int", func(s interface{}) interface{} { return entry(s).LeaguePoints }),
+		gqlScalar("wins", "Int", func(s interface{}) interface{} { return entry(s).Wins }),
+		gqlScalar("losses", "Int", func(s interface{}) interface{} { return entry(s).Losses }),
+		gqlScalar("winRate", "Float", func(s interface{}) interface{} {
+			if games := entry(s).Wins + entry(s).Losses; games > 0 {
+				return math.Round(float64(entry(s).Wins)/float64(games)*1000) / 10
+			}
+			return 0.0
+		}),
+		gqlScalar("hotStreak", "Boolean", func(s interface{}) interface{} { return entry(s).HotStreak }),
+		gqlScalar("veteran", "Boolean", func(s interface{}) interface{} { return entry(s).Veteran }),
+		gqlScalar("freshBlood", "Boolean", func(s interface{}) interface{} { return entry(s).FreshBlood }),
+		gqlScalar("inactive", "Boolean", func(s interface{}) interface{} { return entry(s).Inactive }),
+		playerField(func(s interface{}) string { return entry(s).PUUID }),
+	)
+
+	m := func(s interface{}) *Match { return s.(*Match) }
+	match.define(
+		gqlScalar("id", "String", func(s interface{}) interface{} { return m(s).Metadata.MatchID }),
+		gqlScalar("playedAt", "String", func(s interface{}) interface{} { return m(s).PlayedAt().Format(time.RFC3339) }),
+		gqlScalar("gameLength", "Float", func(s interface{}) interface{} { return m(s).Info.GameLength }),
+		gqlScalar("gameVersion", "String", func(s interface{}) interface{} { return m(s).Info.GameVersion }),
+		gqlScalar("queueId", "Int", func(s interface{}) interface{} { return m(s).Info.QueueID }),
+		gqlScalar("setNumber", "Int", func(s interface{}) interface{} { return m(s).Info.TFTSetNumber }),
+		&gqlField{
+			name:   "participants",
+			typ:    "[Participant]",
+			object: participant,
+			list:   true,
+			items:  gqlItems(gqlMatchPlayers),
+			resolve: func(_ context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				return gqlList(m(source).Info.Participants), nil
+			},
+		},
+		&gqlField{
+			name:   "participant",
+			typ:    "Participant",
+			args:   []gqlArgDef{{name: "puuid", typ: "String!"}},
+			object: participant,
+			resolve: func(_ context.Context, source interface{}, args gqlArgs) (interface{}, error) {
+				puuid, err := args.String("puuid")
+				if err != nil {
+					return nil, err
+				}
+				if found := m(source).Participant(puuid); found != nil {
+					return found, nil
+				}
+				return nil, nil
+			},
+		},
+	)
+
+	part := func(s interface{}) *MatchParticipant { return s.(*MatchParticipant) }
+	participant.define(
+		gqlScalar("puuid", "String", func(s interface{}) interface{} { return part(s).PUUID }),
+		gqlScalar("placement", "Int", func(s interface{}) interface{} { return part(s).Placement }),
+		gqlScalar("level", "Int", func(s interface{}) interface{} { return part(s).Level }),
+		gqlScalar("lastRound", "Int", func(s interface{}) interface{} { return part(s).LastRound }),
+		gqlScalar("totalDamageToPlayers", "Int", func(s interface{}) interface{} { return part(s).TotalDamageToPlayers }),
+		&gqlField{
+			name:   "traits",
+			typ:    "[Trait]",
+			object: trait,
+			list:   true,
+			resolve: func(_ context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				return gqlList(part(source).Traits), nil
+			},
+		},
+		&gqlField{
+			name:   "units",
+			typ:    "[Unit]",
+			object: unit,
+			list:   true,
+			resolve: func(_ context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				return gqlList(part(source).Units), nil
+			},
+		},
+		playerField(func(s interface{}) string { return part(s).PUUID }),
+	)
+
+	leaderboard := newGQLObject("Leaderboard",
+		gqlScalar("tier", "String", func(s interface{}) interface{} { return s.(*gqlLeaderboard).tier }),
+		gqlScalar("division", "String", func(s interface{}) interface{} { return s.(*gqlLeaderboard).division }),
+		gqlScalar("page", "Int", func(s interface{}) interface{} { return s.(*gqlLeaderboard).page }),
+		&gqlField{
+			name:   "entries",
+			typ:    "[LeagueEntry]",
+			object: leagueEntry,
+			list:   true,
+			items: func(_, leaderboard gqlArgs) int {
+				limit, _ := leaderboard.Int("limit", gqlDefaultLeaderboardLimit, 1, leagueEntriesPageSize)
+				return limit
+			},
+			resolve: func(_ context.Context, source interface{}, _ gqlArgs) (interface{}, error) {
+				return gqlList(source.(*gqlLeaderboard).entries), nil
+			},
+		},
+	)
+
+	return newGQLObject("Query",
+		&gqlField{
+			name:        "player",
+			typ:         "Player",
+			description: "By puuid, or by gameName and tagLine; null when the player does not exist.",
+			args:        []gqlArgDef{{name: "puuid", typ: "String"}, {name: "gameName", typ: "String"}, {name: "tagLine", typ: "String"}},
+			object:      player,
+			// Only a Riot ID needs a lookup.
+			cost: func(args gqlArgs) int {
+				if puuid, _ := args.String("puuid"); puuid != "" {
+					return 0
+				}
+				return 1
+			},
+			resolve: func(ctx context.Context, _ interface{}, args gqlArgs) (interface{}, error) {
+				return resolveGQLPlayer(ctx, client, args)
+			},
+		},
+		&gqlField{
+			name:        "leaderboard",
+			typ:         "Leaderboard",
+			description: fmt.Sprintf("Apex tiers ignore division and page; limit is 1-%d, default %d.", leagueEntriesPageSize, gqlDefaultLeaderboardLimit),
+			args:        []gqlArgDef{{name: "tier", typ: "String!"}, {name: "division", typ: "String"}, {name: "page", typ: "Int"}, {name: "limit", typ: "Int"}},
+			object:      leaderboard,
+			cost:        gqlCost(1),
+			resolve: func(ctx context.Context, _ interface{}, args gqlArgs) (interface{}, error) {
+				return resolveGQLLeaderboard(ctx, client, args)
+			},
+		},
+		&gqlField{
+			name:   "match",
+			typ:    "Match",
+			args:   []gqlArgDef{{name: "id", typ: "String!"}},
+			object: match,
+			cost:   gqlCost(1),
+			resolve: func(ctx context.Context, _ interface{}, args gqlArgs) (interface{}, error) {
+				id, err := args.String("id")
+				if err != nil {
+					return nil, err
+				}
+				if id == "" {
+					return nil, NewAPIError("argument \"id\" is required", http.StatusBadRequest)
+				}
+				result, err := client.GetMatch(ctx, id)
+				if isNotFound(err) {
+					return nil, nil
+				}
+				return result, err
+			},
+		},
+	)
+}
+
+func resolveGQLPlayer(ctx context.Context, client *RiotAPIClient, args gqlArgs) (interface{}, error) {
+	puuid, err := args.String("puuid")
+	if err != nil {
+		return nil, err
+	}
+	gameName, err := args.String("gameName")
+	if err != nil {
+		return nil, err
+	}
+	tagLine, err := args.String("tagLine")
+	if err != nil {
+		return nil, err
+	}
+
+	if puuid != "" {
+		if !puuidPattern.MatchString(puuid) {
+			return nil, NewAPIError("argument \"puuid\" must be a 78-character PUUID", http.StatusBadRequest)
+		}
+		return &gqlPlayer{puuid: puuid, client: client}, nil
+	}
+	if gameName == "" || tagLine == "" {
+		return nil, NewAPIError("player needs puuid, or gameName and tagLine", http.StatusBadRequest)
+	}
+
+	id, err := NewRiotID(gameName, tagLine)
+	if err != nil {
+		return nil, NewAPIError(err.Error(), http.StatusBadRequest)
+	}
+	account, err := client.GetAccountByRiotID(ctx, id, false)
+	if isNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &gqlPlayer{puuid: account.PUUID, client: client, account: account}, nil
+}
+
+func resolveGQLLeaderboard(ctx context.Context, client *RiotAPIClient, args gqlArgs) (interface{}, error) {
+	tier, err := args.String("tier")
+	if err != nil {
+		return nil, err
+	}
+	division, err := args.String("division")
+	if err != nil {
+		return nil, err
+	}
+	page, err := args.Int("page", 1, 1, 1000)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := args.Int("limit", gqlDefaultLeaderboardLimit, 1, leagueEntriesPageSize)
+	if err != nil {
+		return nil, err
+	}
+	tier, division = strings.ToUpper(tier), strings.ToUpper(division)
+	if !slices.Contains(validTiers, tier) {
+		return nil, NewAPIError("argument \"tier\" must be one of "+strings.Join(validTiers, ", "), http.StatusBadRequest)
+	}
+
+	board := &gqlLeaderboard{tier: tier, page: 1}
+	switch tier {
+	case "CHALLENGER":
+		league, err := client.GetChallengerLeague(ctx)
+		if err != nil {
+			return nil, err
+		}
+		board.entries = league.Entries
+	case "GRANDMASTER":
+		league, err := client.GetGrandmasterLeague(ctx)
+		if err != nil {
+			return nil, err
+		}
+		board.entries = league.Entries
+	case "MASTER":
+		league, err := client.GetMasterLeague(ctx)
+		if err != nil {
+			return nil, err
+		}
+		board.entries = league.Entries
+	default:
+		if !slices.Contains(validDivisions, division) {
+			return nil, NewAPIError("argument \"division\" must be one of "+strings.Join(validDivisions, ", ")+" below MASTER", http.StatusBadRequest)
+		}
+		result, err := client.GetLeagueEntries(ctx, tier, division, page)
+		if err != nil {
+			return nil, err
+		}
+		board.division, board.page, board.entries = division, page, result.Entries
+	}
+
+	if len(board.entries) > limit {
+		board.entries = board.entries[:limit]
+	}
+	return board, nil
+}
+
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+type graphQLResponse struct {
+	Data   *gqlResponse   `json:"data"`
+	Errors []GraphQLError `json:"errors,omitempty"`
+}
+
+// GraphQLHandler serves POST /graphql with a JSON body and GET /graphql with
+// query parameters; a GET without a query returns the schema in SDL.
+func GraphQLHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	schema := NewGraphQLSchema(riotClient)
+	sdl := graphQLSchemaSDL(schema)
+
+	return withCORS(withRateLimit(rateLimiter, "graphql", logger)(func(w http.ResponseWriter, r *http.Request) {
+		requestID := GetRequestID(r.Context())
+
+		var req graphQLRequest
+		switch r.Method {
+		case http.MethodGet:
+			query := r.URL.Query()
+			req.Query = query.Get("query")
+			req.OperationName = query.Get("operationName")
+			if req.Query == "" {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				w.Write([]byte(sdl))
+				return
+			}
+			if variables := query.Get("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+					writeGraphQLRequestError(w, "variables must be a JSON object", logger, r)
+					return
+				}
+			}
+		case http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeGraphQLRequestError(w, "request body must be a JSON object with a query", logger, r)
+				return
+			}
+		default:
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+			return
+		}
+
+		query, err := prepareGraphQL(schema, req.Query, req.OperationName, req.Variables)
+		if err != nil {
+			writeGraphQLRequestError(w, err.Error(), logger, r)
+			return
+		}
+		// The request already paid one token; the rest of the estimate is
+		// charged before anything reaches Riot.
+		if query.Cost > 1 && !checkRateLimitN(rateLimiter, "graphql", query.Cost-1, logger, w, r) {
+			return
+		}
+
+		start := time.Now()
+		data, fieldErrors := query.execute(r.Context(), func(err error) GraphQLError {
+			return graphQLFieldError(err, requestID, logger)
+		})
+
+		logger.Info("graphql_query_completed").
+			Component("graphql").
+			Operation("execute").
+			Request("", "", requestID).
+			Duration(time.Since(start)).
+			Meta("operation_name", req.OperationName).
+			Meta("cost", query.Cost).
+			Meta("errors", len(fieldErrors)).
+			Log()
+
+		writeJSON(w, graphQLResponse{Data: data, Errors: fieldErrors}, logger, r)
+	}))
+}
+
+func writeGraphQLRequestError(w http.ResponseWriter, message string, logger *Logger, r *http.Request) {
+	logger.Info("graphql_request_invalid").
+		Component("graphql").
+		Operation("parse").
+		Request("", "", GetRequestID(r.Context())).
+		Meta("reason", message).
+		Log()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(graphQLResponse{Errors: []GraphQLError{{
+		Message:    message,
+		Extensions: map[string]interface{}{"code": errorCodeForStatus(http.StatusBadRequest)},
+	}}})
+}
+
+// graphQLFieldError maps a resolver error to the codes REST responses use;
+// causes stay in the logs.
+func graphQLFieldError(err error, requestID string, logger *Logger) GraphQLError {
+	status := http.StatusBadGateway
+	message := "Failed to fetch from Riot API"
+
+	var apiErr APIError
+	var upstream *RiotAPIError
+	switch {
+	case errors.As(err, &apiErr):
+		status, message = apiErr.Status, apiErr.Message
+	case errors.As(err, &upstream) && upstream.StatusCode == http.StatusNotFound:
+		status, message = http.StatusNotFound, "Not found"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		status, message = http.StatusGatewayTimeout, "Request timed out"
+	}
+
+	if status >= http.StatusInternalServerError {
+		logger.Error("graphql_field_failed").
+			Component("graphql").
+			Operation("resolve").
+			Request("", "", requestID).
+			Err(err).
+			Log()
+	}
+	return GraphQLError{Message: message, Extensions: map[string]interface{}{"code": errorCodeForStatus(status)}}
+}
@@ -0,0 +1,303 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseGraphQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		expectErr bool
+	}{
+		{name: "shorthand", query: `{ player(puuid: "p") { puuid } }`},
+		{name: "named with variables", query: `query Top($tier: String! = "CHALLENGER", $n: Int) { leaderboard(tier: $tier, limit: $n) { tier } }`},
+		{name: "fragments", query: `{ ...F ... on Query { a } } fragment F on Query { b @skip(if: false) }`},
+		{name: "comments and escapes", query: "# header\n{ a(s: \"tab\\t\\u00e9\") }"},
+		{name: "unterminated string", query: `{ a(s: "open) }`, expectErr: true},
+		{name: "unclosed selection", query: `{ a { b }`, expectErr: true},
+		{name: "empty", query: ``, expectErr: true},
+		{name: "variable in fragment default", query: `query($a: Int = $b) { a }`, expectErr: true},
+		{name: "too deep", query: strings.Repeat("{ a ", gqlMaxDepth+2) + strings.Repeat("}", gqlMaxDepth+2), expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseGraphQL(tt.query)
+			if tt.expectErr && err == nil {
+				t.Error("expected error but got nil")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func newToySchema() *gqlObject {
+	item := newGQLObject("Item",
+		gqlScalar("id", "Int", func(s interface{}) interface{} { return s }),
+		&gqlField{
+			name: "broken",
+			typ:  "String",
+			resolve: func(context.Context, interface{}, gqlArgs) (interface{}, error) {
+				return nil, errors.New("boom")
+			},
+		},
+	)
+	return newGQLObject("Query",
+		&gqlField{
+			name: "echo",
+			typ:  "String",
+			args: []gqlArgDef{{name: "value", typ: "String"}},
+			resolve: func(_ context.Context, _ interface{}, args gqlArgs) (interface{}, error) {
+				return args.String("value")
+			},
+		},
+		&gqlField{
+			name:   "items",
+			typ:    "[Item]",
+			object: item,
+			list:   true,
+			resolve: func(context.Context, interface{}, gqlArgs) (interface{}, error) {
+				return gqlList([]int{1, 2, 3}), nil
+			},
+		},
+	)
+}
+
+func TestExecuteGraphQL(t *testing.T) {
+	toError := func(err error) GraphQLError { return GraphQLError{Message: err.Error()} }
+
+	tests := []struct {
+		name       string
+		query      string
+		operation  string
+		variables  map[string]interface{}
+		expected   string
+		errorPaths []string
+		expectErr  bool
+	}{
+		{
+			name:     "field order follows the query",
+			query:    `{ z: echo(value: "1") a: echo(value: "2") __typename }`,
+			expected: `{"z":"1","a":"2","__typename":"Query"}`,
+		},
+		{
+			name:      "variables and directives",
+			query:     `query Q($v: String, $hide: Boolean!) { echo(value: $v) hidden: echo(value: "x") @include(if: $hide) }`,
+			variables: map[string]interface{}{"v": "hi", "hide": false},
+			expected:  `{"echo":"hi"}`,
+		},
+		{
+			name:     "fragments merge into the list items",
+			query:    `{ items { ...Ids } } fragment Ids on Item { id }`,
+			expected: `{"items":[{"id":1},{"id":2},{"id":3}]}`,
+		},
+		{
+			name:       "field errors are nulled with a path",
+			query:      `{ items { id broken } }`,
+			expected:   `{"items":[{"id":1,"broken":null},{"id":2,"broken":null},{"id":3,"broken":null}]}`,
+			errorPaths: []string{`["items",0,"broken"]`, `["items",1,"broken"]`, `["items",2,"broken"]`},
+		},
+		{
+			name:      "operation selection",
+			query:     `query A { a: echo(value: "a") } query B { b: echo(value: "b") }`,
+			operation: "B",
+			expected:  `{"b":"b"}`,
+		},
+		{name: "ambiguous operation", query: `query A { echo } query B { echo }`, expectErr: true},
+		{name: "unknown field", query: `{ items { name } }`, expectErr: true},
+		{name: "unknown argument", query: `{ echo(other: "x") }`, expectErr: true},
+		{name: "missing sub-selection", query: `{ items }`, expectErr: true},
+		{name: "mutation", query: `mutation { echo }`, expectErr: true},
+		{name: "undefined variable", query: `{ echo(value: $missing) }`, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, fieldErrors, err := executeGraphQL(t.Context(), newToySchema(), tt.query, tt.operation, tt.variables, toError)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			body, _ := json.Marshal(data)
+			if string(body) != tt.expected {
+				t.Errorf("data = %s, expected %s", body, tt.expected)
+			}
+			paths := make([]string, 0, len(fieldErrors))
+			for _, fieldErr := range fieldErrors {
+				path, _ := json.Marshal(fieldErr.Path)
+				paths = append(paths, string(path))
+			}
+			if strings.Join(paths, " ") != strings.Join(tt.errorPaths, " ") {
+				t.Errorf("error paths = %v, expected %v", paths, tt.errorPaths)
+			}
+		})
+	}
+}
+
+func TestGraphQLSchema_FixtureQueries(t *testing.T) {
+	schema := NewGraphQLSchema(newCachedFixtureClient("../fixtures/riot"))
+	toError := func(err error) GraphQLError { return graphQLFieldError(err, "test", newTestLogger()) }
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+		code     string
+	}{
+		{
+			name:     "leaderboard with nested players",
+			query:    `{ leaderboard(tier: "challenger", limit: 1) { tier entries { leaguePoints player { riotId summonerLevel } } } }`,
+			expected: `{"leaderboard":{"tier":"CHALLENGER","entries":[{"leaguePoints":1250,"player":{"riotId":"Fixture#BR1","summonerLevel":312}}]}}`,
+		},
+		{
+			name:     "player by riot id",
+			query:    `{ player(gameName: "Fixture", tagLine: "BR1") { gameName league { tier } } }`,
+			expected: `{"player":{"gameName":"Fixture","league":[{"tier":"CHALLENGER"}]}}`,
+		},
+		{
+			name:     "unknown player is null",
+			query:    `{ player(gameName: "Nobody", tagLine: "BR1") { puuid } }`,
+			expected: `{"player":null}`,
+		},
+		{
+			name:     "invalid argument",
+			query:    `{ leaderboard(tier: "GOLD", division: "V") { tier } }`,
+			expected: `{"leaderboard":null}`,
+			code:     errorCodeForStatus(http.StatusBadRequest),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, fieldErrors, err := executeGraphQL(t.Context(), schema, tt.query, "", nil, toError)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			body, _ := json.Marshal(data)
+			if string(body) != tt.expected {
+				t.Errorf("data = %s, expected %s", body, tt.expected)
+			}
+			if tt.code == "" && len(fieldErrors) > 0 {
+				t.Errorf("unexpected errors: %+v", fieldErrors)
+			}
+			if tt.code != "" && (len(fieldErrors) != 1 || fieldErrors[0].Extensions["code"] != tt.code) {
+				t.Errorf("errors = %+v, expected one with code %s", fieldErrors, tt.code)
+			}
+		})
+	}
+}
+
+func TestPrepareGraphQL_Cost(t *testing.T) {
+	schema := NewGraphQLSchema(nil)
+	tooMany := "{ " + strings.Repeat("leaderboard(tier: \"CHALLENGER\") { tier } ", gqlMaxAliases+1) + "}"
+	for i := range gqlMaxAliases + 1 {
+		tooMany = strings.Replace(tooMany, "{ leaderboard", fmt.Sprintf("{ l%d: leaderboard", i), 1)
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		expected  int
+		expectErr bool
+	}{
+		{name: "leaderboard alone", query: `{ leaderboard(tier: "CHALLENGER") { entries { puuid } } }`, expected: 1},
+		{name: "one account lookup per entry", query: `{ leaderboard(tier: "CHALLENGER", limit: 50) { entries { player { gameName tagLine riotId } } } }`, expected: 51},
+		{name: "account and summoner per entry", query: `{ leaderboard(tier: "CHALLENGER") { entries { player { riotId summonerLevel } } } }`, expected: 21},
+		{name: "player by puuid is free", query: `{ player(puuid: "p") { puuid } }`, expected: 0},
+		{name: "default match count", query: `{ player(gameName: "a", tagLine: "b") { matches { id } } }`, expected: 7},
+		{name: "participants multiply", query: `{ player(puuid: "p") { matches(count: 20) { participants { player { gameName } } } } }`, expected: 181},
+		{name: "count from a variable", query: `query($n: Int) { player(puuid: "p") { matches(count: $n) { id } } }`, variables: map[string]interface{}{"n": float64(10)}, expected: 11},
+		{name: "aliases add up", query: `{ a: match(id: "x") { id } b: match(id: "y") { id } }`, expected: 2},
+		{name: "fragments count", query: `{ ...M } fragment M on Query { match(id: "x") { participants { player { league { tier } } } } }`, expected: 9},
+		{name: "nested lists over budget", query: `{ leaderboard(tier: "CHALLENGER", limit: 200) { entries { player { matches { id } } } } }`, expectErr: true},
+		{name: "too many aliases", query: tooMany, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := prepareGraphQL(schema, tt.query, "", tt.variables)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("expected error but got cost %d", query.Cost)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if query.Cost != tt.expected {
+				t.Errorf("cost = %d, expected %d", query.Cost, tt.expected)
+			}
+		})
+	}
+}
+
+func TestExecuteGraphQL_BoundsCostlyResolvers(t *testing.T) {
+	var mu sync.Mutex
+	running, peak := 0, 0
+	slow := &gqlField{
+		name: "slow",
+		typ:  "Int",
+		cost: gqlCost(1),
+		resolve: func(context.Context, interface{}, gqlArgs) (interface{}, error) {
+			mu.Lock()
+			running++
+			peak = max(peak, running)
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return 1, nil
+		},
+	}
+	numbers := func(object *gqlObject) *gqlField {
+		return &gqlField{
+			name:   "numbers",
+			typ:    "[" + object.name + "]",
+			object: object,
+			list:   true,
+			resolve: func(context.Context, interface{}, gqlArgs) (interface{}, error) {
+				return gqlList(make([]int, 2*gqlListConcurrency)), nil
+			},
+		}
+	}
+	inner := newGQLObject("Inner", slow)
+	outer := newGQLObject("Outer", numbers(inner))
+	root := newGQLObject("Query", numbers(outer))
+
+	toError := func(err error) GraphQLError { return GraphQLError{Message: err.Error()} }
+	if _, fieldErrors, err := executeGraphQL(t.Context(), root, `{ numbers { numbers { slow } } }`, "", nil, toError); err != nil || len(fieldErrors) > 0 {
+		t.Fatalf("executeGraphQL() error = %v, field errors = %v", err, fieldErrors)
+	}
+	if peak > gqlListConcurrency {
+		t.Errorf("%d costly resolvers ran at once, expected at most %d", peak, gqlListConcurrency)
+	}
+}
+
+func TestGraphQLSchemaSDL(t *testing.T) {
+	sdl := graphQLSchemaSDL(NewGraphQLSchema(nil))
+	for _, expected := range []string{"type Query {", "leaderboard(tier: String!, division: String, page: Int, limit: Int): Leaderboard", "type Participant {"} {
+		if !strings.Contains(sdl, expected) {
+			t.Errorf("SDL is missing %q:\n%s", expected, sdl)
+		}
+	}
+}
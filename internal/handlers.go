@@ -1,8 +1,15 @@
 package internal
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -11,6 +18,8 @@ import (
 type APIError struct {
 	Message string `json:"message"`
 	Status  int    `json:"status"`
+	cause   error
+	fields  map[string]interface{}
 }
 
 func (e APIError) Error() string {
@@ -21,7 +30,49 @@ func NewAPIError(message string, status int) APIError {
 	return APIError{Message: message, Status: status}
 }
 
+func (e APIError) WithCause(err error) APIError {
+	e.cause = err
+	return e
+}
+
+// WithField adds a field to the error body next to error and code.
+func (e APIError) WithField(key string, value interface{}) APIError {
+	fields := make(map[string]interface{}, len(e.fields)+1)
+	for k, v := range e.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	e.fields = fields
+	return e
+}
+
+var verboseErrors bool
+
+func SetVerboseErrors(enabled bool) {
+	verboseErrors = enabled
+}
+
+func errorDetails(err error) map[string]interface{} {
+	details := map[string]interface{}{"cause": err.Error()}
+
+	var upstream *RiotAPIError
+	if errors.As(err, &upstream) {
+		details["upstream"] = map[string]interface{}{
+			"status": upstream.StatusCode,
+			"url":    upstream.URL,
+			"body":   upstream.Body,
+		}
+	}
+	return details
+}
+
 func writeError(w http.ResponseWriter, err error, logger *Logger, r *http.Request) {
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		writeProblem(w, verr, logger, r)
+		return
+	}
+
 	var apiErr APIError
 	if e, ok := err.(APIError); ok {
 		apiErr = e
@@ -42,40 +93,98 @@ func writeError(w http.ResponseWriter, err error, logger *Logger, r *http.Reques
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(apiErr.Status)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	body := map[string]interface{}{
 		"error":     apiErr.Message,
+		"code":      errorCodeForStatus(apiErr.Status),
 		"status":    apiErr.Status,
 		"timestamp": time.Now().Unix(),
 		"requestId": requestID,
-	})
+	}
+	for key, value := range apiErr.fields {
+		body[key] = value
+	}
+	if verboseErrors && apiErr.cause != nil {
+		body["details"] = errorDetails(apiErr.cause)
+	}
+	json.NewEncoder(w).Encode(body)
 }
 
 func writeJSON(w http.ResponseWriter, data interface{}, logger *Logger, r *http.Request) {
 	requestID := GetRequestID(r.Context())
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	body, err := json.Marshal(data)
+	if err != nil {
 		logger.Error("json_encode_failed").
 			Component("http").
 			Operation("write_json").
 			Request("", "", requestID).
 			Err(err).
 			Log()
-		writeError(w, NewAPIError("Failed to encode response", http.StatusInternalServerError), logger, r)
+		writeError(w, NewAPIError("Failed to encode response", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	etag := computeETag(body)
+	w.Header().Set("ETag", etag)
+	if w.Header().Get("Cache-Control") == "" {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if r.Method == http.MethodGet && etagMatches(r.Header.Get("If-None-Match"), etag) {
+		logger.Debug("etag_not_modified").
+			Component("http").
+			Operation("write_json").
+			Request("", "", requestID).
+			Meta("etag", etag).
+			Log()
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
+
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.Write(append(body, '\n'))
 }
 
-func withCORS(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		origin := r.Header.Get("Origin")
-		if origin == "" {
-			origin = "*"
+func computeETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
 		}
+	}
+	return false
+}
+
+func setCORSHeaders(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = "*"
+	}
+
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Operator-Token, If-None-Match")
+	w.Header().Set("Access-Control-Allow-Credentials", "true")
+	w.Header().Set("Access-Control-Expose-Headers", "ETag, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, Retry-After, X-Last-Known-Good, X-Last-Known-Good-Age")
+}
 
-		w.Header().Set("Access-Control-Allow-Origin", origin)
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
+func withCORS(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		setCORSHeaders(w, r)
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -86,6 +195,24 @@ func withCORS(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// WithPathParams copies path wildcards into the query string, so path routes
+// such as /players/{gameName}/{tagLine} reuse the query-param handlers and
+// their validation. Path values win over query parameters of the same name.
+func WithPathParams(next http.HandlerFunc, names ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		for _, name := range names {
+			query.Set(name, r.PathValue(name))
+		}
+
+		rewritten := *r.URL
+		rewritten.RawQuery = query.Encode()
+		r = r.WithContext(r.Context())
+		r.URL = &rewritten
+		next(w, r)
+	}
+}
+
 func withRateLimit(rateLimiter *RateLimiter, key string, logger *Logger) func(http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
@@ -98,9 +225,15 @@ func withRateLimit(rateLimiter *RateLimiter, key string, logger *Logger) func(ht
 }
 
 func checkRateLimit(rateLimiter *RateLimiter, key string, logger *Logger, w http.ResponseWriter, r *http.Request) bool {
+	return checkRateLimitN(rateLimiter, key, 1, logger, w, r)
+}
+
+// checkRateLimitN charges n tokens, for requests whose cost is only known
+// once they are parsed.
+func checkRateLimitN(rateLimiter *RateLimiter, key string, n int, logger *Logger, w http.ResponseWriter, r *http.Request) bool {
 	requestID := GetRequestID(r.Context())
 
-	allowed, err := rateLimiter.Allow(r.Context(), key)
+	result, err := rateLimiter.CheckN(r.Context(), key, n)
 	if err != nil {
 		logger.Error("rate_limiter_error").
 			Component("rate_limiter").
@@ -109,72 +242,159 @@ func checkRateLimit(rateLimiter *RateLimiter, key string, logger *Logger, w http
 			Err(err).
 			Meta("key", key).
 			Log()
-		writeError(w, NewAPIError("Rate limiter error", http.StatusInternalServerError), logger, r)
+		writeError(w, NewAPIError("Rate limiter error", http.StatusInternalServerError).WithCause(err), logger, r)
 		return false
 	}
 
-	if !allowed {
+	setRateLimitHeaders(w, result)
+	if !result.Allowed {
 		logger.Warn("rate_limit_exceeded").
 			Component("rate_limiter").
 			Operation("check_limit").
 			Request("", "", requestID).
 			Meta("key", key).
+			Meta("retry_after", result.RetryAfter.String()).
 			Log()
-		writeError(w, NewAPIError("Rate limit exceeded", http.StatusTooManyRequests), logger, r)
+		writeError(w, rateLimitError(result), logger, r)
 		return false
 	}
 
 	return true
 }
 
-func HealthHandler(logger *Logger) http.HandlerFunc {
+func setRateLimitHeaders(w http.ResponseWriter, result *RateLimitResult) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(result)))
+	}
+}
+
+// rateLimitError repeats the headers' guidance in the 429 body for clients
+// that only read JSON.
+func rateLimitError(result *RateLimitResult) APIError {
+	return NewAPIError("Rate limit exceeded", http.StatusTooManyRequests).
+		WithField("retryAfter", retryAfterSeconds(result)).
+		WithField("limit", result.Limit)
+}
+
+// retryAfterSeconds never tells a client to retry straight away, since a
+// token refilled within the second may already be taken by then.
+func retryAfterSeconds(result *RateLimitResult) int {
+	return max(ceilSeconds(result.RetryAfter), 1)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+func HealthHandler(natsClient *NATSClient, scheduler *Scheduler, logger *Logger) http.HandlerFunc {
 	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		natsStatus := natsClient.Status()
+		jobs := scheduler.Health(time.Now())
+
+		status := "ok"
+		if natsStatus != "connected" && natsStatus != "disabled" {
+			status = "degraded"
+		}
+		for _, job := range jobs {
+			if job.Stale {
+				status = "degraded"
+			}
+		}
+
 		logger.Debug("health_check").
 			Component("health").
 			Operation("check").
+			Meta("status", status).
 			Log()
 
 		writeJSON(w, map[string]interface{}{
-			"status":    "ok",
+			"status":    status,
 			"timestamp": time.Now().Unix(),
 			"services": map[string]string{
 				"redis": "connected",
-				"nats":  "connected",
+				"nats":  natsStatus,
 			},
+			"jobs": jobs,
 		}, logger, r)
 	})
 }
 
-func SummonerHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+func SummonerHandler(riotClient *RiotAPIClient, stats *PlacementStats, branches *BranchTimeouts, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
 	return withCORS(withRateLimit(rateLimiter, "summoner", logger)(func(w http.ResponseWriter, r *http.Request) {
-		puuid := r.URL.Query().Get("puuid")
+		v := NewValidator(r.URL.Query())
+		puuid := v.PUUID("puuid")
+		historyLimit := v.IntRange("history", 0, 0, 100)
+		formGames := v.IntRange("form", 0, 0, 100)
 		requestID := GetRequestID(r.Context())
 
-		if !validatePUUID(puuid, requestID, logger, w, r) {
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		if formGames > 0 && (riotClient.database == nil || !riotClient.database.Enabled) {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
 			return
 		}
 
 		logSummonerRequest(puuid, requestID, logger)
 
-		result, err := riotClient.GetSummonerByPUUID(puuid)
+		var profile SummonerProfile
+		calls := []Branch{{
+			Name:     "summoner",
+			Required: true,
+			Run: func(ctx context.Context) error {
+				result, err := riotClient.GetSummonerByPUUID(ctx, puuid)
+				profile.Summoner = result
+				return err
+			},
+		}}
+		if historyLimit > 0 {
+			calls = append(calls, Branch{Name: "history", Run: func(ctx context.Context) error {
+				history, err := riotClient.ProfileHistory(ctx, puuid, historyLimit)
+				if err == nil {
+					profile.ProfileHistory = history
+				}
+				return err
+			}})
+		}
+		if formGames > 0 {
+			calls = append(calls, Branch{Name: "form", Run: func(ctx context.Context) error {
+				summary, err := stats.Get(ctx, puuid, formGames)
+				if err == nil {
+					profile.Form = newPlayerForm(summary)
+				}
+				return err
+			}})
+		}
+
+		degraded, err := runBranches(r.Context(), branches, logger, calls...)
 		if err != nil {
+			if wrongRegion, found := wrongRegionError(r.Context(), riotClient, err, puuid); found {
+				writeError(w, wrongRegion, logger, r)
+				return
+			}
 			handleSummonerError(err, puuid, requestID, logger, w, r)
 			return
 		}
 
 		logSummonerSuccess(puuid, requestID, logger)
-		writeJSON(w, result, logger, r)
+		if historyLimit == 0 && formGames == 0 {
+			writeJSON(w, profile.Summoner, logger, r)
+			return
+		}
+		profile.Degraded = degraded
+		writeJSON(w, profile, logger, r)
 	}))
 }
 
-func validatePUUID(puuid, requestID string, logger *Logger, w http.ResponseWriter, r *http.Request) bool {
-	if puuid == "" {
-		logger.Warn("missing_puuid_parameter").
-			Component("summoner").
-			Operation("get_summoner").
-			Request("", "", requestID).
-			Log()
-		writeError(w, NewAPIError("puuid is required", http.StatusBadRequest), logger, r)
+func validatePUUID(puuid string, logger *Logger, w http.ResponseWriter, r *http.Request) bool {
+	v := NewValidator(nil)
+	v.CheckPUUID("puuid", puuid)
+	if err := v.Err(); err != nil {
+		writeError(w, err, logger, r)
 		return false
 	}
 	return true
@@ -209,7 +429,7 @@ func handleSummonerError(err error, puuid, requestID string, logger *Logger, w h
 		Game(puuid, "", "").
 		Err(err).
 		Log()
-	writeError(w, NewAPIError("Failed to fetch summoner data", http.StatusBadGateway), logger, r)
+	writeError(w, NewAPIError("Failed to fetch summoner data", http.StatusBadGateway).WithCause(err), logger, r)
 }
 
 func logSummonerSuccess(puuid, requestID string, logger *Logger) {
@@ -221,48 +441,31 @@ func logSummonerSuccess(puuid, requestID string, logger *Logger) {
 		Log()
 }
 
-func SearchPlayerHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+func SearchPlayerHandler(riotClient *RiotAPIClient, branches *BranchTimeouts, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
 	return withCORS(withRateLimit(rateLimiter, "search", logger)(func(w http.ResponseWriter, r *http.Request) {
-		gameName := r.URL.Query().Get("gameName")
-		tagLine := r.URL.Query().Get("tagLine")
 		requestID := GetRequestID(r.Context())
 
-		if err := validateSearchParams(gameName, &tagLine, requestID, logger); err.Message != "" {
+		id, err := NewRiotID(r.URL.Query().Get("gameName"), r.URL.Query().Get("tagLine"))
+		if err != nil {
 			writeError(w, err, logger, r)
 			return
 		}
 
-		logSearchRequest(gameName, tagLine, requestID, logger)
+		logSearchRequest(id.GameName, id.TagLine, requestID, logger)
 
-		accountData, err := riotClient.GetAccountByGameName(gameName, tagLine)
+		exact := r.URL.Query().Get("exact") == "true"
+		accountData, err := riotClient.GetAccountByRiotID(r.Context(), id, exact)
 		if err != nil {
-			handleAccountError(err, gameName, tagLine, requestID, logger, w, r)
+			handleAccountError(err, id.GameName, id.TagLine, requestID, logger, w, r)
 			return
 		}
 
-		result := buildSearchResult(accountData, riotClient)
-		logSearchSuccess(accountData.PUUID, gameName, tagLine, requestID, logger)
+		result := buildSearchResult(r.Context(), accountData, riotClient, branches, logger)
+		logSearchSuccess(accountData.PUUID, id.GameName, id.TagLine, requestID, logger)
 		writeJSON(w, result, logger, r)
 	}))
 }
 
-func validateSearchParams(gameName string, tagLine *string, requestID string, logger *Logger) APIError {
-	if gameName == "" {
-		logger.Warn("missing_gamename_parameter").
-			Component("search").
-			Operation("search_player").
-			Request("", "", requestID).
-			Log()
-		return NewAPIError("gameName is required", http.StatusBadRequest)
-	}
-
-	if *tagLine == "" {
-		*tagLine = "BR1"
-	}
-
-	return APIError{}
-}
-
 func logSearchRequest(gameName, tagLine, requestID string, logger *Logger) {
 	logger.Info("player_search_request").
 		Component("search").
@@ -295,14 +498,32 @@ func handleAccountError(err error, gameName, tagLine, requestID string, logger *
 		Meta("tag_line", tagLine).
 		Err(err).
 		Log()
-	writeError(w, NewAPIError("Failed to fetch account data", http.StatusBadGateway), logger, r)
+	writeError(w, NewAPIError("Failed to fetch account data", http.StatusBadGateway).WithCause(err), logger, r)
 }
 
-func buildSearchResult(accountData *AccountData, riotClient *RiotAPIClient) map[string]interface{} {
-	summonerData, _ := riotClient.GetSummonerByPUUID(accountData.PUUID)
-	leagueData, _ := riotClient.GetLeagueByPUUID(accountData.PUUID)
+// buildSearchResult fills summoner and league in parallel; either one that
+// fails or runs past its branch timeout is null and listed in degraded.
+func buildSearchResult(ctx context.Context, accountData *AccountData, riotClient *RiotAPIClient, branches *BranchTimeouts, logger *Logger) map[string]interface{} {
+	var summonerData *Summoner
+	var leagueData []LeagueEntry
+	degraded, _ := runBranches(ctx, branches, logger,
+		Branch{Name: "summoner", Run: func(ctx context.Context) error {
+			summoner, err := riotClient.GetSummonerByPUUID(ctx, accountData.PUUID)
+			if err == nil {
+				summonerData = summoner
+			}
+			return err
+		}},
+		Branch{Name: "league", Run: func(ctx context.Context) error {
+			league, err := riotClient.GetLeagueByPUUID(ctx, accountData.PUUID)
+			if err == nil {
+				leagueData = league
+			}
+			return err
+		}},
+	)
 
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"account":  accountData,
 		"summoner": summonerData,
 		"puuid":    accountData.PUUID,
@@ -310,6 +531,10 @@ func buildSearchResult(accountData *AccountData, riotClient *RiotAPIClient) map[
 		"tagLine":  accountData.TagLine,
 		"league":   findTFTLeague(leagueData),
 	}
+	if len(degraded) > 0 {
+		result["degraded"] = degraded
+	}
+	return result
 }
 
 func findTFTLeague(leagueData []LeagueEntry) *LeagueEntry {
@@ -336,17 +561,76 @@ func logSearchSuccess(puuid, gameName, tagLine, requestID string, logger *Logger
 		Log()
 }
 
+func AutocompleteHandler(cache *CacheManager, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "autocomplete", logger)(func(w http.ResponseWriter, r *http.Request) {
+		v := NewValidator(r.URL.Query())
+		query := v.Required("q")
+		v.MinLength("q", query, 2)
+		requestID := GetRequestID(r.Context())
+
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		limit := 10
+		if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+			limit = l
+		}
+		if limit > 25 {
+			limit = 25
+		}
+
+		matches, err := cache.SearchSummonerNames(r.Context(), query, limit)
+		if err != nil {
+			logger.Error("autocomplete_failed").
+				Component("search").
+				Operation("autocomplete").
+				Request("", "", requestID).
+				Meta("query", query).
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to search summoner names", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		logger.Info("autocomplete_success").
+			Component("search").
+			Operation("autocomplete").
+			Request("", "", requestID).
+			Meta("query", query).
+			Meta("results_count", len(matches)).
+			Log()
+
+		writeJSON(w, map[string]interface{}{
+			"query":   query,
+			"results": matches,
+		}, logger, r)
+	}))
+}
+
 func ChallengerHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
 	return withCORS(withRateLimit(rateLimiter, "challenger", logger)(func(w http.ResponseWriter, r *http.Request) {
 		requestID := GetRequestID(r.Context())
 
+		format, err := exportFormat(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		include, err := parseInclude(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
 		logger.Info("challenger_request").
 			Component("league").
 			Operation("get_challenger").
 			Request("", "", requestID).
 			Log()
 
-		result, err := riotClient.GetChallengerLeague()
+		result, err := riotClient.GetChallengerLeague(r.Context())
 		if err != nil {
 			logger.Error("challenger_fetch_failed").
 				Component("league").
@@ -354,7 +638,7 @@ func ChallengerHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logg
 				Request("", "", requestID).
 				Err(err).
 				Log()
-			writeError(w, NewAPIError("Failed to fetch challenger league", http.StatusBadGateway), logger, r)
+			writeError(w, NewAPIError("Failed to fetch challenger league", http.StatusBadGateway).WithCause(err), logger, r)
 			return
 		}
 
@@ -365,21 +649,106 @@ func ChallengerHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logg
 			Meta("entries_count", len(result.Entries)).
 			Log()
 
+		if include[includeProfile] {
+			riotClient.EnrichProfiles(r.Context(), result.Entries)
+		}
+
+		setLastKnownGoodHeader(w, result.LastKnownGood)
+		if format != ExportFormatJSON {
+			writeLeaderboardExport(w, r, format, "challenger", result.Entries, logger)
+			return
+		}
 		writeJSON(w, result, logger, r)
 	}))
 }
 
+// setLastKnownGoodHeader labels responses served from the persisted league,
+// which CSV exports cannot carry in the body. They are no-store so neither
+// the response cache nor a proxy keeps serving them once Riot is back.
+func setLastKnownGoodHeader(w http.ResponseWriter, lastKnownGood *LastKnownGood) {
+	if lastKnownGood != nil {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Last-Known-Good", lastKnownGood.FetchedAt.Format(time.RFC3339))
+		w.Header().Set("X-Last-Known-Good-Age", strconv.FormatInt(lastKnownGood.AgeSeconds, 10))
+	}
+}
+
+// ladderDiffTime accepts an absolute time (RFC3339 or unix seconds) or a
+// period back from now such as 24h or 7d.
+func ladderDiffTime(value string, now time.Time) (time.Time, bool) {
+	if at, err := parseTimeParam(value); err == nil {
+		return at, true
+	}
+	if period, err := parsePeriod(value); err == nil {
+		return now.Add(-period), true
+	}
+	return time.Time{}, false
+}
+
+func ChallengerDiffHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "challenger", logger)(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		now := time.Now().UTC()
+		v := NewValidator(query)
+
+		since, ok := ladderDiffTime(query.Get("since"), now)
+		if !ok {
+			v.Fail("since", "must be RFC3339, unix seconds or a period such as 24h or 7d")
+		}
+		until := now
+		if value := query.Get("until"); value != "" {
+			if until, ok = ladderDiffTime(value, now); !ok {
+				v.Fail("until", "must be RFC3339, unix seconds or a period such as 24h or 7d")
+			} else if !until.After(since) {
+				v.Fail("until", "must be after since")
+			}
+		}
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		diff, err := riotClient.LadderDiff(r.Context(), ladderSnapshotTier, since, until)
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		logger.Info("challenger_diff_success").
+			Component("league").
+			Operation("get_challenger_diff").
+			Request("", "", GetRequestID(r.Context())).
+			Meta("entered", len(diff.Entered)).
+			Meta("dropped", len(diff.Dropped)).
+			Meta("moved", len(diff.Movements)).
+			Log()
+
+		writeJSON(w, diff, logger, r)
+	}))
+}
+
 func GrandmasterHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
 	return withCORS(withRateLimit(rateLimiter, "grandmaster", logger)(func(w http.ResponseWriter, r *http.Request) {
 		requestID := GetRequestID(r.Context())
 
+		format, err := exportFormat(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		include, err := parseInclude(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
 		logger.Info("grandmaster_request").
 			Component("league").
 			Operation("get_grandmaster").
 			Request("", "", requestID).
 			Log()
 
-		result, err := riotClient.GetGrandmasterLeague()
+		result, err := riotClient.GetGrandmasterLeague(r.Context())
 		if err != nil {
 			logger.Error("grandmaster_fetch_failed").
 				Component("league").
@@ -387,7 +756,7 @@ func GrandmasterHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, log
 				Request("", "", requestID).
 				Err(err).
 				Log()
-			writeError(w, NewAPIError("Failed to fetch grandmaster league", http.StatusBadGateway), logger, r)
+			writeError(w, NewAPIError("Failed to fetch grandmaster league", http.StatusBadGateway).WithCause(err), logger, r)
 			return
 		}
 
@@ -398,6 +767,15 @@ func GrandmasterHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, log
 			Meta("entries_count", len(result.Entries)).
 			Log()
 
+		if include[includeProfile] {
+			riotClient.EnrichProfiles(r.Context(), result.Entries)
+		}
+
+		setLastKnownGoodHeader(w, result.LastKnownGood)
+		if format != ExportFormatJSON {
+			writeLeaderboardExport(w, r, format, "grandmaster", result.Entries, logger)
+			return
+		}
 		writeJSON(w, result, logger, r)
 	}))
 }
@@ -406,13 +784,24 @@ func MasterHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *
 	return withCORS(withRateLimit(rateLimiter, "master", logger)(func(w http.ResponseWriter, r *http.Request) {
 		requestID := GetRequestID(r.Context())
 
+		format, err := exportFormat(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		include, err := parseInclude(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
 		logger.Info("master_request").
 			Component("league").
 			Operation("get_master").
 			Request("", "", requestID).
 			Log()
 
-		result, err := riotClient.GetMasterLeague()
+		result, err := riotClient.GetMasterLeague(r.Context())
 		if err != nil {
 			logger.Error("master_fetch_failed").
 				Component("league").
@@ -420,7 +809,7 @@ func MasterHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *
 				Request("", "", requestID).
 				Err(err).
 				Log()
-			writeError(w, NewAPIError("Failed to fetch master league", http.StatusBadGateway), logger, r)
+			writeError(w, NewAPIError("Failed to fetch master league", http.StatusBadGateway).WithCause(err), logger, r)
 			return
 		}
 
@@ -431,54 +820,92 @@ func MasterHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *
 			Meta("entries_count", len(result.Entries)).
 			Log()
 
+		if include[includeProfile] {
+			riotClient.EnrichProfiles(r.Context(), result.Entries)
+		}
+
+		setLastKnownGoodHeader(w, result.LastKnownGood)
+		if format != ExportFormatJSON {
+			writeLeaderboardExport(w, r, format, "master", result.Entries, logger)
+			return
+		}
 		writeJSON(w, result, logger, r)
 	}))
 }
 
 func EntriesHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
 	return withCORS(withRateLimit(rateLimiter, "entries", logger)(func(w http.ResponseWriter, r *http.Request) {
-		tier := r.URL.Query().Get("tier")
-		division := r.URL.Query().Get("division")
-		pageStr := r.URL.Query().Get("page")
 		requestID := GetRequestID(r.Context())
 
-		page, err := validateEntriesParams(tier, division, pageStr, requestID, logger, w, r)
+		tier, division, page, err := validateEntriesParams(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		format, err := exportFormat(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		filters, err := parseEntriesQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+		include, err := parseInclude(r.URL.Query())
 		if err != nil {
+			writeError(w, err, logger, r)
 			return
 		}
 
 		logEntriesRequest(tier, division, page, requestID, logger)
 
-		result, err := riotClient.GetLeagueEntries(tier, division, page)
+		result, err := riotClient.GetLeagueEntries(r.Context(), tier, division, page)
 		if err != nil {
 			handleEntriesError(err, tier, division, page, requestID, logger, w, r)
 			return
 		}
 
+		result.Entries = filters.Apply(result.Entries)
+		if include[includeProfile] {
+			riotClient.EnrichProfiles(r.Context(), result.Entries)
+		}
+		if result.Pagination != nil {
+			links := filters.Values()
+			if include[includeProfile] {
+				links.Set("include", includeProfile)
+			}
+			result.Pagination.SetLinks(r.URL.Path, links)
+		}
+
 		logEntriesSuccess(tier, division, page, len(result.Entries), requestID, logger)
+		if format != ExportFormatJSON {
+			writeLeaderboardExport(w, r, format, "entries-"+tier+"-"+division+"-p"+strconv.Itoa(page), result.Entries, logger)
+			return
+		}
 		writeJSON(w, result, logger, r)
 	}))
 }
 
-func validateEntriesParams(tier, division, pageStr, requestID string, logger *Logger, w http.ResponseWriter, r *http.Request) (int, error) {
-	if tier == "" || division == "" {
-		logger.Warn("missing_tier_division_parameters").
-			Component("entries").
-			Operation("get_entries").
-			Request("", "", requestID).
-			Log()
-		writeError(w, NewAPIError("tier and division are required", http.StatusBadRequest), logger, r)
-		return 0, NewAPIError("validation failed", http.StatusBadRequest)
-	}
+func validateEntriesParams(query url.Values) (string, string, int, error) {
+	v := NewValidator(query)
 
-	page := 1
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		// A cursor is client-supplied like any other parameter, so it gets the
+		// same checks as tier, division and page.
+		cursor, err := DecodeEntriesCursor(cursorStr)
+		if err != nil || !slices.Contains(validTiers, cursor.Tier) || !slices.Contains(validDivisions, cursor.Division) || cursor.Page > 1000 {
+			v.Fail("cursor", "is not a valid pagination cursor")
+			return "", "", 0, v.Err()
 		}
+		return cursor.Tier, cursor.Division, cursor.Page, nil
 	}
 
-	return page, nil
+	tier := v.Enum("tier", validTiers)
+	division := v.Enum("division", validDivisions)
+	page := v.IntRange("page", 1, 1, 1000)
+
+	return tier, division, page, v.Err()
 }
 
 func logEntriesRequest(tier, division string, page int, requestID string, logger *Logger) {
@@ -502,7 +929,7 @@ func handleEntriesError(err error, tier, division string, page int, requestID st
 		Meta("page", page).
 		Err(err).
 		Log()
-	writeError(w, NewAPIError("Failed to fetch league entries", http.StatusBadGateway), logger, r)
+	writeError(w, NewAPIError("Failed to fetch league entries", http.StatusBadGateway).WithCause(err), logger, r)
 }
 
 func logEntriesSuccess(tier, division string, page, entriesCount int, requestID string, logger *Logger) {
@@ -519,10 +946,12 @@ func logEntriesSuccess(tier, division string, page, entriesCount int, requestID
 
 func LeagueByPUUIDHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
 	return withCORS(withRateLimit(rateLimiter, "league-by-puuid", logger)(func(w http.ResponseWriter, r *http.Request) {
-		puuid := r.URL.Query().Get("puuid")
+		v := NewValidator(r.URL.Query())
+		puuid := v.PUUID("puuid")
 		requestID := GetRequestID(r.Context())
 
-		if !validatePUUID(puuid, requestID, logger, w, r) {
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
 			return
 		}
 
@@ -533,7 +962,7 @@ func LeagueByPUUIDHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, l
 			Game(puuid, "", "").
 			Log()
 
-		result, err := riotClient.GetLeagueByPUUID(puuid)
+		result, err := riotClient.GetLeagueByPUUID(r.Context(), puuid)
 		if err != nil {
 			logger.Error("league_by_puuid_fetch_failed").
 				Component("league").
@@ -542,7 +971,7 @@ func LeagueByPUUIDHandler(riotClient *RiotAPIClient, rateLimiter *RateLimiter, l
 				Game(puuid, "", "").
 				Err(err).
 				Log()
-			writeError(w, NewAPIError("Failed to fetch league data", http.StatusBadGateway), logger, r)
+			writeError(w, NewAPIError("Failed to fetch league data", http.StatusBadGateway).WithCause(err), logger, r)
 			return
 		}
 
@@ -572,3 +1001,852 @@ func MetricsHandler(logger *Logger, metrics *MetricsCollector) http.HandlerFunc
 		writeJSON(w, metricsData, logger, r)
 	})
 }
+
+func ScalingSignalsHandler(logger *Logger, metrics *MetricsCollector) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		requestID := GetRequestID(r.Context())
+
+		logger.Debug("scaling_signals_request").
+			Component("metrics").
+			Operation("get_scaling_signals").
+			Request("", "", requestID).
+			Log()
+
+		writeJSON(w, metrics.GetScalingSignals(), logger, r)
+	})
+}
+
+func CrawlStatusHandler(db *DatabaseManager, logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		region := strings.ToUpper(r.URL.Query().Get("region"))
+		requestID := GetRequestID(r.Context())
+
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		coverage, err := db.GetCrawlCoverage(r.Context(), region)
+		if err != nil {
+			logger.Error("crawl_status_failed").
+				Component("admin").
+				Operation("crawl_status").
+				Request("", "", requestID).
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to load crawl status", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"region":   region,
+			"coverage": coverage,
+		}, logger, r)
+	})
+}
+
+// DatabaseStatsHandler reports table sizes and, when the summoner cache
+// pruner runs on this instance, its last run and the rows still past the
+// retention.
+func DatabaseStatsHandler(db *DatabaseManager, pruner *SummonerCachePruner, logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		requestID := GetRequestID(r.Context())
+
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		tables, err := db.TableStats(r.Context())
+		if err != nil {
+			logger.Error("db_stats_failed").
+				Component("admin").
+				Operation("db_stats").
+				Request("", "", requestID).
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to load database stats", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		response := map[string]interface{}{
+			"tables":      tables,
+			"generatedAt": time.Now().UTC(),
+		}
+		if pruner != nil {
+			stats := pruner.Stats()
+			stale, err := db.StaleSummonerCacheRows(r.Context(), pruner.cutoff(time.Now()))
+			if err != nil {
+				writeError(w, NewAPIError("Failed to load database stats", http.StatusInternalServerError).WithCause(err), logger, r)
+				return
+			}
+			response["summonerCachePrune"] = map[string]interface{}{
+				"stats":     stats,
+				"staleRows": stale,
+			}
+		}
+		writeJSON(w, response, logger, r)
+	})
+}
+
+func SchemaHandler(logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.Trim(strings.TrimPrefix(r.URL.Path, "/schemas"), "/")
+		requestID := GetRequestID(r.Context())
+
+		if path == "" {
+			writeJSON(w, map[string]interface{}{"schemas": listEventSchemas()}, logger, r)
+			return
+		}
+
+		parts := strings.Split(path, "/")
+		if len(parts) != 2 {
+			writeError(w, NewAPIError("schema path must be /schemas/{event}/{version}", http.StatusBadRequest), logger, r)
+			return
+		}
+
+		schema, found := findEventSchema(parts[0], parts[1])
+		if !found {
+			logger.Warn("schema_not_found").
+				Component("schemas").
+				Operation("get_schema").
+				Request("", "", requestID).
+				Meta("event", parts[0]).
+				Meta("version", parts[1]).
+				Log()
+			writeError(w, NewAPIError("Schema not found", http.StatusNotFound), logger, r)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/schema+json")
+		writeJSON(w, buildEventSchemaDocument(schema), logger, r)
+	})
+}
+
+func RiotUsageHandler(db *DatabaseManager, logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		requestID := GetRequestID(r.Context())
+
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		window, err := ParseTimeWindow(r.URL.Query(), 24*time.Hour, 7*24*time.Hour, time.Now())
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		usage, err := db.GetRiotUsage(r.Context(), window)
+		if err != nil {
+			logger.Error("riot_usage_failed").
+				Component("admin").
+				Operation("riot_usage").
+				Request("", "", requestID).
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to load Riot API usage", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"window":  window,
+			"methods": usage,
+		}, logger, r)
+	})
+}
+
+func ConfigReloadHandler(watcher *ConfigWatcher, logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+			return
+		}
+
+		changed, err := watcher.Reload(requestActor(r))
+		if err != nil {
+			writeError(w, NewAPIError("Configuration rejected: "+err.Error(), http.StatusUnprocessableEntity).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"changed":    changed,
+			"reloadedAt": time.Now().UTC(),
+		}, logger, r)
+	})
+}
+
+func WatchlistHandler(db *DatabaseManager, riotClient *RiotAPIClient, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "watchlist", logger)(func(w http.ResponseWriter, r *http.Request) {
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			listWatchlist(db, logger, w, r)
+		case http.MethodPost:
+			addToWatchlist(db, riotClient, logger, w, r)
+		case http.MethodDelete:
+			removeFromWatchlist(db, logger, w, r)
+		default:
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+		}
+	}))
+}
+
+func listWatchlist(db *DatabaseManager, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	limit, offset := 100, 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	tenant := TenantID(r.Context())
+	entries, err := db.ListWatchlist(r.Context(), tenant, limit, offset)
+	var used int
+	if err == nil {
+		used, err = db.CountWatchlist(r.Context(), tenant)
+	}
+	if err != nil {
+		logger.Error("watchlist_list_failed").
+			Component("watchlist").
+			Operation("list").
+			Request("", "", GetRequestID(r.Context())).
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to load watchlist", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"entries": entries,
+		"limit":   limit,
+		"offset":  offset,
+		"quota":   map[string]int{"used": used, "limit": db.WatchlistQuota()},
+	}, logger, r)
+}
+
+func watchlistPUUID(w http.ResponseWriter, r *http.Request) string {
+	if puuid := r.URL.Query().Get("puuid"); puuid != "" {
+		return puuid
+	}
+
+	var body struct {
+		PUUID string `json:"puuid"`
+	}
+	if r.Body != nil {
+		json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body)
+	}
+	return body.PUUID
+}
+
+func addToWatchlist(db *DatabaseManager, riotClient *RiotAPIClient, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	puuid := watchlistPUUID(w, r)
+	requestID := GetRequestID(r.Context())
+
+	if !validatePUUID(puuid, logger, w, r) {
+		return
+	}
+
+	if _, err := riotClient.GetAccountByPUUID(r.Context(), puuid); err != nil {
+		handleSummonerError(err, puuid, requestID, logger, w, r)
+		return
+	}
+
+	if err := db.AddToWatchlist(r.Context(), TenantID(r.Context()), puuid, riotClient.region); err != nil {
+		if errors.Is(err, ErrWatchlistQuotaExceeded) {
+			writeError(w, NewAPIError("Watchlist limit of "+strconv.Itoa(db.WatchlistQuota())+" players reached", http.StatusForbidden), logger, r)
+			return
+		}
+		logger.Error("watchlist_add_failed").
+			Component("watchlist").
+			Operation("add").
+			Request("", "", requestID).
+			Game(puuid, "", "").
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to add to watchlist", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	logger.Info("watchlist_added").
+		Component("watchlist").
+		Operation("add").
+		Request("", "", requestID).
+		Game(puuid, "", "").
+		Log()
+
+	writeJSON(w, map[string]interface{}{"puuid": puuid, "watching": true}, logger, r)
+}
+
+func removeFromWatchlist(db *DatabaseManager, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	puuid := r.URL.Query().Get("puuid")
+	requestID := GetRequestID(r.Context())
+
+	if !validatePUUID(puuid, logger, w, r) {
+		return
+	}
+
+	removed, err := db.RemoveFromWatchlist(r.Context(), TenantID(r.Context()), puuid)
+	if err != nil {
+		logger.Error("watchlist_remove_failed").
+			Component("watchlist").
+			Operation("remove").
+			Request("", "", requestID).
+			Game(puuid, "", "").
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to remove from watchlist", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+	if !removed {
+		writeError(w, NewAPIError("PUUID is not on the watchlist", http.StatusNotFound), logger, r)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{"puuid": puuid, "watching": false}, logger, r)
+}
+
+// WatchlistImportHandler accepts a CSV or JSON list of Riot IDs on POST and
+// reports the job on GET ?id=. Rows are resolved in the background.
+func WatchlistImportHandler(importer *WatchlistImporter, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "watchlist", logger)(func(w http.ResponseWriter, r *http.Request) {
+		if !importer.available() {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			getWatchlistImport(importer, logger, w, r)
+		case http.MethodPost:
+			submitWatchlistImport(importer, logger, w, r)
+		default:
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+		}
+	}))
+}
+
+func submitWatchlistImport(importer *WatchlistImporter, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, 256*1024))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, NewAPIError("Request body too large", http.StatusRequestEntityTooLarge), logger, r)
+			return
+		}
+		writeError(w, NewAPIError("Failed to read request body", http.StatusBadRequest), logger, r)
+		return
+	}
+
+	riotIDs, err := parseWatchlistImport(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(w, err, logger, r)
+		return
+	}
+
+	tenant := TenantID(r.Context())
+	job, err := importer.Submit(r.Context(), tenant, riotIDs)
+	if err != nil {
+		writeError(w, err, logger, r)
+		return
+	}
+
+	logger.Info("watchlist_import_started").
+		Component("watchlist").
+		Operation("import").
+		Request("", "", GetRequestID(r.Context())).
+		Meta("job_id", job.ID).
+		Meta("rows", job.Total).
+		Meta("tenant", tenant).
+		Log()
+
+	w.Header().Set("Location", "/watchlist/import?id="+strconv.FormatInt(job.ID, 10))
+	writeJSON(w, job, logger, r)
+}
+
+func getWatchlistImport(importer *WatchlistImporter, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id < 1 {
+		v := NewValidator(r.URL.Query())
+		v.Fail("id", "must be a positive integer")
+		writeError(w, v.Err(), logger, r)
+		return
+	}
+
+	job, err := importer.Get(r.Context(), TenantID(r.Context()), id)
+	if err != nil {
+		writeError(w, err, logger, r)
+		return
+	}
+	writeJSON(w, job, logger, r)
+}
+
+func parseSetParam(r *http.Request) (int, error) {
+	v := NewValidator(r.URL.Query())
+	set := v.IntRange("set", 0, 1, 99)
+	return set, v.Err()
+}
+
+type verificationRequest struct {
+	PUUID string `json:"puuid"`
+	Code  string `json:"code"`
+}
+
+func readVerificationRequest(w http.ResponseWriter, r *http.Request) verificationRequest {
+	var body verificationRequest
+	if r.Method != http.MethodGet && r.Body != nil {
+		json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body)
+	}
+	if puuid := r.URL.Query().Get("puuid"); puuid != "" {
+		body.PUUID = puuid
+	}
+	if code := r.URL.Query().Get("code"); code != "" {
+		body.Code = code
+	}
+	return body
+}
+
+// verificationPrincipal returns the API key the verification is scoped to;
+// anonymous callers cannot own a verification.
+func verificationPrincipal(verifier *Verifier, logger *Logger, w http.ResponseWriter, r *http.Request) *Principal {
+	if !verifier.available() {
+		writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+		return nil
+	}
+	principal := GetPrincipal(r.Context())
+	if principal == nil {
+		writeError(w, NewAPIError("API key required for verification", http.StatusUnauthorized), logger, r)
+		return nil
+	}
+	return principal
+}
+
+func writeVerificationError(err error, puuid string, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	if _, ok := err.(APIError); ok {
+		writeError(w, err, logger, r)
+		return
+	}
+	handleSummonerError(err, puuid, GetRequestID(r.Context()), logger, w, r)
+}
+
+func VerificationHandler(verifier *Verifier, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "verification", logger)(func(w http.ResponseWriter, r *http.Request) {
+		principal := verificationPrincipal(verifier, logger, w, r)
+		if principal == nil {
+			return
+		}
+
+		req := readVerificationRequest(w, r)
+		if !validatePUUID(req.PUUID, logger, w, r) {
+			return
+		}
+
+		var verification *PlayerVerification
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			verification, err = verifier.Get(r.Context(), principal.KeyID, req.PUUID)
+		case http.MethodPost:
+			verification, err = verifier.Start(r.Context(), principal.KeyID, req.PUUID)
+		default:
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+			return
+		}
+		if err != nil {
+			writeVerificationError(err, req.PUUID, logger, w, r)
+			return
+		}
+
+		writeJSON(w, verification, logger, r)
+	}))
+}
+
+func VerificationCheckHandler(verifier *Verifier, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "verification", logger)(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+			return
+		}
+
+		principal := verificationPrincipal(verifier, logger, w, r)
+		if principal == nil {
+			return
+		}
+
+		req := readVerificationRequest(w, r)
+		v := NewValidator(nil)
+		v.CheckPUUID("puuid", req.PUUID)
+		if req.Code == "" {
+			v.Fail("code", "is required")
+		}
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		verification, err := verifier.Check(r.Context(), principal.KeyID, req.PUUID, req.Code)
+		if err != nil {
+			writeVerificationError(err, req.PUUID, logger, w, r)
+			return
+		}
+
+		writeJSON(w, verification, logger, r)
+	}))
+}
+
+func StaticUnitsHandler(staticData *StaticDataService, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "static", logger)(func(w http.ResponseWriter, r *http.Request) {
+		set, err := parseSetParam(r)
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		version, set, units, err := staticData.Units(r.Context(), set)
+		if err != nil {
+			logStaticDataError(err, "units", r, logger)
+			writeError(w, NewAPIError("Static data unavailable", http.StatusBadGateway).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"version": version,
+			"set":     set,
+			"units":   units,
+		}, logger, r)
+	}))
+}
+
+func StaticTraitsHandler(staticData *StaticDataService, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "static", logger)(func(w http.ResponseWriter, r *http.Request) {
+		set, err := parseSetParam(r)
+		if err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		version, set, traits, err := staticData.Traits(r.Context(), set)
+		if err != nil {
+			logStaticDataError(err, "traits", r, logger)
+			writeError(w, NewAPIError("Static data unavailable", http.StatusBadGateway).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"version": version,
+			"set":     set,
+			"traits":  traits,
+		}, logger, r)
+	}))
+}
+
+func StaticItemsHandler(staticData *StaticDataService, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "static", logger)(func(w http.ResponseWriter, r *http.Request) {
+		version, items, err := staticData.Items(r.Context())
+		if err != nil {
+			logStaticDataError(err, "items", r, logger)
+			writeError(w, NewAPIError("Static data unavailable", http.StatusBadGateway).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"version": version,
+			"items":   items,
+		}, logger, r)
+	}))
+}
+
+func logStaticDataError(err error, kind string, r *http.Request, logger *Logger) {
+	logger.Error("static_data_failed").
+		Component("static_data").
+		Operation("get_"+kind).
+		Request("", "", GetRequestID(r.Context())).
+		Err(err).
+		Log()
+}
+
+func PlacementsHandler(stats *PlacementStats, db *DatabaseManager, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "placements", logger)(func(w http.ResponseWriter, r *http.Request) {
+		v := NewValidator(r.URL.Query())
+		puuid := v.PUUID("puuid")
+		count := v.IntRange("count", 20, 1, 100)
+		requestID := GetRequestID(r.Context())
+
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		summary, err := stats.Get(r.Context(), puuid, count)
+		if err != nil {
+			logger.Error("placements_failed").
+				Component("placements").
+				Operation("get_placements").
+				Request("", "", requestID).
+				Game(puuid, "", "").
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to load placements", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, summary, logger, r)
+	}))
+}
+
+// MetaTopPlayersHandler serves /stats/meta/top-players?trait= or ?unit=.
+func MetaTopPlayersHandler(riotClient *RiotAPIClient, db *DatabaseManager, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "meta", logger)(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		v := NewValidator(query)
+		trait := strings.TrimSpace(query.Get("trait"))
+		unit := strings.TrimSpace(query.Get("unit"))
+		days := v.IntRange("days", 14, 1, 90)
+		minGames := v.IntRange("min_games", 3, 1, 100)
+		limit := v.IntRange("limit", 10, 1, 50)
+		requestID := GetRequestID(r.Context())
+
+		kind, name := compKindTrait, trait
+		switch {
+		case trait != "" && unit != "":
+			v.Fail("unit", "cannot be combined with trait")
+		case trait == "" && unit == "":
+			v.Fail("trait", "trait or unit is required")
+		case unit != "":
+			kind, name = compKindUnit, unit
+		}
+
+		region := riotClient.region
+		if value := strings.ToUpper(strings.TrimSpace(query.Get("region"))); value != "" {
+			if _, known := accountAPIURLs[value]; known {
+				region = value
+			} else {
+				v.Fail("region", "unknown region")
+			}
+		}
+
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		since := time.Now().UTC().AddDate(0, 0, -days)
+		result, err := db.MetaTopPlayers(r.Context(), region, kind, name, since, minGames, limit)
+		if err != nil {
+			logger.Error("meta_top_players_failed").
+				Component("meta").
+				Operation("top_players").
+				Request("", "", requestID).
+				Game("", region, ladderSnapshotTier).
+				Meta("kind", kind).
+				Meta("name", name).
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to load meta players", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		writeJSON(w, result, logger, r)
+	}))
+}
+
+func ProjectionHandler(db *DatabaseManager, rateLimiter *RateLimiter, logger *Logger) http.HandlerFunc {
+	return withCORS(withRateLimit(rateLimiter, "projection", logger)(func(w http.ResponseWriter, r *http.Request) {
+		v := NewValidator(r.URL.Query())
+		puuid := v.PUUID("puuid")
+		horizon := v.IntRange("games", 10, 1, 50)
+		observations := v.IntRange("history", 50, 2, 500)
+		requestID := GetRequestID(r.Context())
+
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		if err := v.Err(); err != nil {
+			writeError(w, err, logger, r)
+			return
+		}
+
+		history, err := db.GetLeaguePointsHistory(r.Context(), puuid, observations)
+		if err != nil {
+			logger.Error("projection_history_failed").
+				Component("projection").
+				Operation("get_history").
+				Request("", "", requestID).
+				Game(puuid, "", "").
+				Err(err).
+				Log()
+			writeError(w, NewAPIError("Failed to load LP history", http.StatusInternalServerError).WithCause(err), logger, r)
+			return
+		}
+
+		projection, err := projectLP(puuid, history, horizon)
+		if errors.Is(err, ErrNotEnoughLPHistory) {
+			writeError(w, NewAPIError("Not enough LP history for this player; add it to the watchlist to start recording", http.StatusNotFound), logger, r)
+			return
+		}
+
+		writeJSON(w, projection, logger, r)
+	}))
+}
+
+func APIKeysHandler(auth *Authenticator, db *DatabaseManager, logger *Logger, metrics *MetricsCollector) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		// Without auth anyone reaching the route could mint an admin key that
+		// stays valid once auth is turned on.
+		if !auth.Enabled() {
+			writeError(w, NewAPIError("API key management requires AUTH_ENABLED=true", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+		if db == nil || !db.Enabled {
+			writeError(w, NewAPIError("Database unavailable", http.StatusServiceUnavailable), logger, r)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			listAPIKeys(db, logger, metrics, w, r)
+		case http.MethodPost:
+			createAPIKey(db, logger, w, r)
+		case http.MethodDelete:
+			revokeAPIKey(auth, db, logger, w, r)
+		default:
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+		}
+	})
+}
+
+func listAPIKeys(db *DatabaseManager, logger *Logger, metrics *MetricsCollector, w http.ResponseWriter, r *http.Request) {
+	keys, err := db.ListAPIKeys(r.Context())
+	if err != nil {
+		logger.Error("api_keys_list_failed").
+			Component("auth").
+			Operation("list_keys").
+			Request("", "", GetRequestID(r.Context())).
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to load API keys", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	if metrics != nil {
+		for i := range keys {
+			keys[i].Requests = metrics.APIKeyRequests(strconv.FormatInt(keys[i].ID, 10))
+		}
+	}
+
+	counts, err := db.WatchlistCountsByTenant(r.Context())
+	if err != nil {
+		logger.Warn("watchlist_usage_failed").
+			Component("auth").
+			Operation("list_keys").
+			Request("", "", GetRequestID(r.Context())).
+			Err(err).
+			Log()
+	}
+	for i := range keys {
+		keys[i].WatchlistPlayers = counts[strconv.FormatInt(keys[i].ID, 10)]
+	}
+
+	writeJSON(w, map[string]interface{}{"keys": keys}, logger, r)
+}
+
+func createAPIKey(db *DatabaseManager, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	requestID := GetRequestID(r.Context())
+
+	var body struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&body); err != nil {
+		writeError(w, NewAPIError("invalid JSON body", http.StatusBadRequest), logger, r)
+		return
+	}
+	if body.Name == "" {
+		writeError(w, NewAPIError("name is required", http.StatusBadRequest), logger, r)
+		return
+	}
+	if body.Role == "" {
+		body.Role = RoleReadOnly
+	}
+	if !validRole(body.Role) {
+		writeError(w, NewAPIError("role must be read-only or admin", http.StatusBadRequest), logger, r)
+		return
+	}
+
+	key, err := generateAPIKey()
+	if err != nil {
+		writeError(w, NewAPIError("Failed to generate API key", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	created, err := db.CreateAPIKey(r.Context(), body.Name, body.Role, hashAPIKey(key), key[:12])
+	if err != nil {
+		logger.Error("api_key_create_failed").
+			Component("auth").
+			Operation("create_key").
+			Request("", "", requestID).
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to create API key", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+
+	logger.Info("api_key_created").
+		Component("auth").
+		Operation("create_key").
+		Request("", "", requestID).
+		Meta("key_id", created.ID).
+		Meta("role", created.Role).
+		Log()
+
+	writeJSON(w, map[string]interface{}{
+		"key":    key,
+		"apiKey": created,
+	}, logger, r)
+}
+
+func revokeAPIKey(auth *Authenticator, db *DatabaseManager, logger *Logger, w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil || id < 1 {
+		writeError(w, NewAPIError("id must be a positive integer", http.StatusBadRequest), logger, r)
+		return
+	}
+
+	revoked, err := db.RevokeAPIKey(r.Context(), id)
+	if err != nil {
+		logger.Error("api_key_revoke_failed").
+			Component("auth").
+			Operation("revoke_key").
+			Request("", "", GetRequestID(r.Context())).
+			Meta("key_id", id).
+			Err(err).
+			Log()
+		writeError(w, NewAPIError("Failed to revoke API key", http.StatusInternalServerError).WithCause(err), logger, r)
+		return
+	}
+	if !revoked {
+		writeError(w, NewAPIError("API key not found or already revoked", http.StatusNotFound), logger, r)
+		return
+	}
+
+	auth.Revoked(r.Context(), strconv.FormatInt(id, 10))
+	writeJSON(w, map[string]interface{}{"id": id, "revoked": true}, logger, r)
+}
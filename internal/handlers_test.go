@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestLogger() *Logger {
+	return NewLogger(&Config{LogLevel: "error", AppEnv: "test"})
+}
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc123"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{
+			name:        "empty header",
+			ifNoneMatch: "",
+			expected:    false,
+		},
+		{
+			name:        "exact match",
+			ifNoneMatch: `"abc123"`,
+			expected:    true,
+		},
+		{
+			name:        "weak match",
+			ifNoneMatch: `W/"abc123"`,
+			expected:    true,
+		},
+		{
+			name:        "match in list",
+			ifNoneMatch: `"other", "abc123"`,
+			expected:    true,
+		},
+		{
+			name:        "wildcard",
+			ifNoneMatch: "*",
+			expected:    true,
+		},
+		{
+			name:        "no match",
+			ifNoneMatch: `"other"`,
+			expected:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := etagMatches(tt.ifNoneMatch, etag)
+			if result != tt.expected {
+				t.Errorf("etagMatches() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteJSON_ETag(t *testing.T) {
+	logger := newTestLogger()
+	data := map[string]string{"tier": "CHALLENGER"}
+
+	first := httptest.NewRecorder()
+	writeJSON(first, data, logger, httptest.NewRequest(http.MethodGet, "/league/challenger", nil))
+
+	etag := first.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header to be set")
+	}
+	if first.Code != http.StatusOK {
+		t.Errorf("status = %v, expected %v", first.Code, http.StatusOK)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/league/challenger", nil)
+	req.Header.Set("If-None-Match", etag)
+	second := httptest.NewRecorder()
+	writeJSON(second, data, logger, req)
+
+	if second.Code != http.StatusNotModified {
+		t.Errorf("status = %v, expected %v", second.Code, http.StatusNotModified)
+	}
+	if second.Body.Len() != 0 {
+		t.Errorf("expected empty body, got %q", second.Body.String())
+	}
+}
+
+func TestWriteError_VerboseDetails(t *testing.T) {
+	logger := newTestLogger()
+	cause := &RiotAPIError{StatusCode: http.StatusForbidden, Status: "403 Forbidden", Body: `{"status":{"message":"Forbidden"}}`}
+
+	tests := []struct {
+		name     string
+		verbose  bool
+		expected bool
+	}{
+		{
+			name:     "details hidden by default",
+			verbose:  false,
+			expected: false,
+		},
+		{
+			name:     "details exposed in sandbox",
+			verbose:  true,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetVerboseErrors(tt.verbose)
+			defer SetVerboseErrors(false)
+
+			rec := httptest.NewRecorder()
+			apiErr := NewAPIError("Failed to fetch summoner data", http.StatusBadGateway).WithCause(cause)
+			writeError(rec, apiErr, logger, httptest.NewRequest(http.MethodGet, "/summoner", nil))
+
+			var body map[string]interface{}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid JSON body: %v", err)
+			}
+
+			_, hasDetails := body["details"]
+			if hasDetails != tt.expected {
+				t.Errorf("details present = %v, expected %v", hasDetails, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteError_LogsAPIError(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	req := httptest.NewRequest(http.MethodGet, "/league/entries", nil)
+	req = req.WithContext(context.WithValue(req.Context(), RequestIDKey, "req-1"))
+
+	writeError(httptest.NewRecorder(), NewAPIError("Too many concurrent requests", http.StatusServiceUnavailable), logger, req)
+
+	entry := logs.Expect(t, expectedEvent{Level: LogLevelError, Message: "api_error", Component: "http", Operation: "write_error"})
+	if entry.Path != "/league/entries" || entry.StatusCode != http.StatusServiceUnavailable || entry.ErrorCode != "503" || entry.RequestID != "req-1" {
+		t.Errorf("api_error = %+v, expected path, status, error code and request id", entry)
+	}
+}
+
+func TestHealthHandler_NATSDisabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HealthHandler(nil, nil, newTestLogger())(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var body struct {
+		Status   string            `json:"status"`
+		Services map[string]string `json:"services"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+
+	if body.Status != "ok" {
+		t.Errorf("status = %v, expected %v", body.Status, "ok")
+	}
+	if body.Services["nats"] != "disabled" {
+		t.Errorf("nats = %v, expected %v", body.Services["nats"], "disabled")
+	}
+}
+
+func TestWithPathParams(t *testing.T) {
+	var query, route string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/players/{gameName}/{tagLine}", WithPathParams(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Encode()
+		route = requestRoute(r)
+	}, "gameName", "tagLine"))
+	mux.HandleFunc("/league/challenger", func(w http.ResponseWriter, r *http.Request) {
+		route = requestRoute(r)
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players/Some%20Player/BR1?exact=true&tagLine=NA1", nil))
+	if query != "exact=true&gameName=Some+Player&tagLine=BR1" {
+		t.Errorf("query = %q, expected path values to replace the query", query)
+	}
+	if route != "/players/{gameName}/{tagLine}" {
+		t.Errorf("route = %q, expected the pattern", route)
+	}
+
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/league/challenger?page=2", nil))
+	if route != "/league/challenger" {
+		t.Errorf("route = %q, expected the path", route)
+	}
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"math"
+	"strconv"
+)
+
+// latencyBucketBounds are the inclusive upper bounds, in milliseconds, of the
+// latency histogram buckets. Anything slower lands in a final overflow bucket.
+var latencyBucketBounds = []int64{1, 2, 5, 10, 20, 50, 100, 200, 300, 500, 750, 1000, 2000, 5000, 10000, 30000}
+
+// LatencyHistogram keeps fixed-size counts per bucket so memory stays
+// constant regardless of how many requests are recorded. Percentiles are
+// interpolated inside the matching bucket.
+type LatencyHistogram struct {
+	counts []int64
+	count  int64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+type HistogramBucket struct {
+	LE    string `json:"le"`
+	Count int64  `json:"count"`
+}
+
+type HistogramSnapshot struct {
+	Count   int64             `json:"count"`
+	AvgMs   float64           `json:"avg_ms"`
+	P50Ms   float64           `json:"p50_ms"`
+	P95Ms   float64           `json:"p95_ms"`
+	P99Ms   float64           `json:"p99_ms"`
+	MaxMs   int64             `json:"max_ms"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+func NewLatencyHistogram() *LatencyHistogram {
+	return &LatencyHistogram{counts: make([]int64, len(latencyBucketBounds)+1)}
+}
+
+func (h *LatencyHistogram) Observe(ms int64) {
+	if ms < 0 {
+		ms = 0
+	}
+
+	index := len(latencyBucketBounds)
+	for i, bound := range latencyBucketBounds {
+		if ms <= bound {
+			index = i
+			break
+		}
+	}
+	h.counts[index]++
+
+	if h.count == 0 || ms < h.min {
+		h.min = ms
+	}
+	if ms > h.max {
+		h.max = ms
+	}
+	h.count++
+	h.sum += ms
+}
+
+func (h *LatencyHistogram) Merge(other *LatencyHistogram) {
+	if other == nil || other.count == 0 {
+		return
+	}
+	for i := range h.counts {
+		h.counts[i] += other.counts[i]
+	}
+	if h.count == 0 || other.min < h.min {
+		h.min = other.min
+	}
+	if other.max > h.max {
+		h.max = other.max
+	}
+	h.count += other.count
+	h.sum += other.sum
+}
+
+func (h *LatencyHistogram) Count() int64 {
+	return h.count
+}
+
+func (h *LatencyHistogram) Average() float64 {
+	if h.count == 0 {
+		return 0
+	}
+	return float64(h.sum) / float64(h.count)
+}
+
+func (h *LatencyHistogram) Percentile(percentile float64) float64 {
+	if h.count == 0 {
+		return 0
+	}
+
+	rank := percentile * float64(h.count)
+	cumulative := int64(0)
+	for i, bucketCount := range h.counts {
+		if bucketCount == 0 {
+			continue
+		}
+		if float64(cumulative+bucketCount) < rank {
+			cumulative += bucketCount
+			continue
+		}
+
+		lower, upper := h.bucketRange(i)
+		fraction := (rank - float64(cumulative)) / float64(bucketCount)
+		value := float64(lower) + fraction*float64(upper-lower)
+		return math.Round(value*100) / 100
+	}
+	return float64(h.max)
+}
+
+// bucketRange narrows the bucket bounds with the observed min and max so
+// interpolation never reports a value outside what was recorded.
+func (h *LatencyHistogram) bucketRange(index int) (int64, int64) {
+	lower := int64(0)
+	if index > 0 {
+		lower = latencyBucketBounds[index-1]
+	}
+	upper := h.max
+	if index < len(latencyBucketBounds) && latencyBucketBounds[index] < upper {
+		upper = latencyBucketBounds[index]
+	}
+	if lower < h.min {
+		lower = h.min
+	}
+	if lower > upper {
+		lower = upper
+	}
+	return lower, upper
+}
+
+func (h *LatencyHistogram) Snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{
+		Count:   h.count,
+		AvgMs:   math.Round(h.Average()*100) / 100,
+		P50Ms:   h.Percentile(0.50),
+		P95Ms:   h.Percentile(0.95),
+		P99Ms:   h.Percentile(0.99),
+		MaxMs:   h.max,
+		Buckets: make([]HistogramBucket, 0, len(h.counts)),
+	}
+	for i, bucketCount := range h.counts {
+		le := "+Inf"
+		if i < len(latencyBucketBounds) {
+			le = strconv.FormatInt(latencyBucketBounds[i], 10)
+		}
+		snapshot.Buckets = append(snapshot.Buckets, HistogramBucket{LE: le, Count: bucketCount})
+	}
+	return snapshot
+}
@@ -0,0 +1,107 @@
+package internal
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLatencyHistogram_Percentile(t *testing.T) {
+	h := NewLatencyHistogram()
+	for ms := int64(1); ms <= 100; ms++ {
+		h.Observe(ms)
+	}
+
+	tests := []struct {
+		name       string
+		percentile float64
+		min        float64
+		max        float64
+	}{
+		{name: "p50", percentile: 0.50, min: 45, max: 55},
+		{name: "p95", percentile: 0.95, min: 90, max: 100},
+		{name: "p99", percentile: 0.99, min: 95, max: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := h.Percentile(tt.percentile)
+			if got < tt.min || got > tt.max {
+				t.Errorf("Percentile(%v) = %v, expected between %v and %v", tt.percentile, got, tt.min, tt.max)
+			}
+		})
+	}
+
+	if h.Average() != 50.5 {
+		t.Errorf("Average() = %v, expected 50.5", h.Average())
+	}
+}
+
+func TestLatencyHistogram_ClampsToObservedRange(t *testing.T) {
+	h := NewLatencyHistogram()
+	h.Observe(40000)
+	h.Observe(40000)
+
+	if got := h.Percentile(0.99); got != 40000 {
+		t.Errorf("Percentile(0.99) = %v, expected 40000 for overflow bucket", got)
+	}
+
+	single := NewLatencyHistogram()
+	single.Observe(150)
+	if got := single.Percentile(0.5); got != 150 {
+		t.Errorf("Percentile(0.5) = %v, expected 150", got)
+	}
+}
+
+func TestLatencyHistogram_Empty(t *testing.T) {
+	h := NewLatencyHistogram()
+	snapshot := h.Snapshot()
+
+	if snapshot.Count != 0 || snapshot.P95Ms != 0 || snapshot.AvgMs != 0 {
+		t.Errorf("unexpected snapshot for empty histogram: %+v", snapshot)
+	}
+	if len(snapshot.Buckets) != len(latencyBucketBounds)+1 || snapshot.Buckets[len(snapshot.Buckets)-1].LE != "+Inf" {
+		t.Errorf("unexpected buckets %+v", snapshot.Buckets)
+	}
+}
+
+func TestLatencyHistogram_Merge(t *testing.T) {
+	a := NewLatencyHistogram()
+	b := NewLatencyHistogram()
+	a.Observe(10)
+	b.Observe(3)
+	b.Observe(900)
+
+	a.Merge(b)
+	a.Merge(nil)
+
+	if a.Count() != 3 || a.max != 900 || a.min != 3 {
+		t.Errorf("unexpected merged histogram count=%d min=%d max=%d", a.Count(), a.min, a.max)
+	}
+}
+
+func TestMetricsCollector_LatencyIsBounded(t *testing.T) {
+	mc := &MetricsCollector{
+		logger:          newTestLogger(),
+		requestCount:    make(map[string]int64),
+		requestDuration: make(map[string]*LatencyHistogram),
+		apiErrors:       make(map[string]int64),
+	}
+
+	for i := 0; i < 5000; i++ {
+		mc.RecordRequest("summoner", time.Duration(i%300)*time.Millisecond, http.StatusOK)
+	}
+
+	histogram := mc.requestDuration["summoner"]
+	if len(histogram.counts) != len(latencyBucketBounds)+1 {
+		t.Errorf("bucket count grew to %d", len(histogram.counts))
+	}
+
+	latency := mc.copyLatency()["summoner"]
+	if latency.Count != 5000 || latency.MaxMs != 299 {
+		t.Errorf("unexpected latency snapshot %+v", latency)
+	}
+	if latency.P50Ms < 100 || latency.P50Ms > 200 {
+		t.Errorf("P50Ms = %v, expected around 150", latency.P50Ms)
+	}
+}
@@ -0,0 +1,70 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"time"
+)
+
+// newRiotHTTPClient builds the client used for Riot API calls. The default
+// transport keeps only two idle connections per host, which under load means
+// a fresh TCP and TLS handshake for most requests to the regional hosts.
+func newRiotHTTPClient(cfg *Config) *http.Client {
+	if cfg.UsesRiotFixtures() {
+		return &http.Client{
+			Timeout:   cfg.RiotHTTPTimeout,
+			Transport: newFixtureTransport(cfg.RiotFixturesDir),
+		}
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   cfg.RiotHTTPDialTimeout,
+		KeepAlive: cfg.RiotHTTPKeepAlive,
+	}
+
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     cfg.RiotHTTP2Enabled,
+		MaxIdleConns:          cfg.RiotHTTPMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.RiotHTTPMaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.RiotHTTPMaxConnsPerHost,
+		IdleConnTimeout:       cfg.RiotHTTPIdleConnTimeout,
+		TLSHandshakeTimeout:   cfg.RiotHTTPTLSHandshakeTimeout,
+		ExpectContinueTimeout: time.Second,
+	}
+	if !cfg.RiotHTTP2Enabled {
+		// A non-nil empty map is how net/http disables HTTP/2 negotiation.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{
+		Timeout:   cfg.RiotHTTPTimeout,
+		Transport: transport,
+	}
+}
+
+// withConnectionTrace records whether each request got a pooled connection
+// and how long it waited for one, which covers dial and TLS for new ones.
+func withConnectionTrace(ctx context.Context, target string, metrics *MetricsCollector) context.Context {
+	if metrics == nil {
+		return ctx
+	}
+
+	var getConnAt time.Time
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			getConnAt = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			var wait time.Duration
+			if !getConnAt.IsZero() {
+				wait = time.Since(getConnAt)
+			}
+			metrics.RecordOutboundConnection(target, info.Reused, info.WasIdle, wait)
+		},
+	})
+}
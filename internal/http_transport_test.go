@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRiotHTTPClient_ReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := newRiotHTTPClient(&Config{
+		RiotHTTPTimeout:             5 * time.Second,
+		RiotHTTPDialTimeout:         time.Second,
+		RiotHTTPMaxIdleConns:        10,
+		RiotHTTPMaxIdleConnsPerHost: 10,
+		RiotHTTPIdleConnTimeout:     time.Minute,
+	})
+	metrics := &MetricsCollector{connections: make(map[string]*ConnectionStats)}
+
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequestWithContext(withConnectionTrace(t.Context(), "riot_api", metrics), http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	stats := metrics.connections["riot_api"]
+	if stats == nil || stats.New != 1 || stats.Reused != 2 || stats.ReusedIdle != 2 {
+		t.Fatalf("connection stats = %+v, expected 1 new and 2 idle reuses", stats)
+	}
+	if stats.ReuseRate < 0.66 || stats.ReuseRate > 0.67 {
+		t.Errorf("reuse rate = %f, expected 2/3", stats.ReuseRate)
+	}
+}
+
+func TestRiotHTTPClient_DisablesHTTP2(t *testing.T) {
+	client := newRiotHTTPClient(&Config{RiotHTTP2Enabled: false})
+	transport := client.Transport.(*http.Transport)
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Errorf("HTTP/2 should be disabled: ForceAttemptHTTP2=%v TLSNextProto=%v", transport.ForceAttemptHTTP2, transport.TLSNextProto)
+	}
+
+	client = newRiotHTTPClient(&Config{RiotHTTP2Enabled: true})
+	transport = client.Transport.(*http.Transport)
+	if !transport.ForceAttemptHTTP2 || transport.TLSNextProto != nil {
+		t.Errorf("HTTP/2 should be enabled: ForceAttemptHTTP2=%v", transport.ForceAttemptHTTP2)
+	}
+}
@@ -2,16 +2,17 @@ package internal
 
 import (
 	"context"
+	"time"
 )
 
 type RiotAPI interface {
-	GetSummonerByPUUID(puuid string) (map[string]interface{}, error)
-	GetAccountByGameName(gameName, tagLine string) (*AccountData, error)
-	GetLeagueByPUUID(puuid string) ([]LeagueEntry, error)
-	GetChallengerLeague() (*ChallengerLeague, error)
-	GetGrandmasterLeague() (*GrandmasterLeague, error)
-	GetMasterLeague() (*MasterLeague, error)
-	GetLeagueEntries(tier, division string, page int) (*LeagueEntriesResponse, error)
+	GetSummonerByPUUID(ctx context.Context, puuid string) (*Summoner, error)
+	GetAccountByGameName(ctx context.Context, gameName, tagLine string) (*AccountData, error)
+	GetLeagueByPUUID(ctx context.Context, puuid string) ([]LeagueEntry, error)
+	GetChallengerLeague(ctx context.Context) (*ChallengerLeague, error)
+	GetGrandmasterLeague(ctx context.Context) (*GrandmasterLeague, error)
+	GetMasterLeague(ctx context.Context) (*MasterLeague, error)
+	GetLeagueEntries(ctx context.Context, tier, division string, page int) (*LeagueEntriesResponse, error)
 }
 
 type RateLimiterInterface interface {
@@ -19,7 +20,25 @@ type RateLimiterInterface interface {
 }
 
 type DatabaseInterface interface {
-	GetSummonerName(puuid string) (string, error)
-	SetSummonerName(puuid, gameName, tagLine, summonerID, region string) error
+	GetSummonerName(ctx context.Context, puuid string) (string, error)
+	SetSummonerName(ctx context.Context, puuid, gameName, tagLine, summonerID, region string) error
 	Close()
 }
+
+// CacheBackend is the key/value store behind CacheManager. Get returns
+// ErrCacheMiss when the key is absent; GetMulti leaves absent keys out of
+// its result.
+type CacheBackend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	Ping(ctx context.Context) error
+}
+
+// PersistentStore is the cold tier behind CacheManager for the key types in
+// persistedKeyTypes. Load returns ErrCacheMiss when the key was never saved.
+type PersistentStore interface {
+	LoadCacheSnapshot(ctx context.Context, key string) ([]byte, time.Time, error)
+	SaveCacheSnapshot(ctx context.Context, key string, value []byte, fetchedAt time.Time) error
+}
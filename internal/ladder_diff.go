@@ -0,0 +1,283 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Only the challenger ladder is snapshotted: it is small and fetched on every
+// league update, while master can hold thousands of entries.
+const ladderSnapshotTier = "CHALLENGER"
+
+type LadderSnapshotEntry struct {
+	PUUID        string `json:"puuid"`
+	LeaguePoints int    `json:"leaguePoints"`
+	Wins         int    `json:"wins"`
+	Losses       int    `json:"losses"`
+}
+
+type LadderSnapshot struct {
+	ID      int64
+	TakenAt time.Time
+	Entries []LadderSnapshotEntry
+}
+
+type LadderMovement struct {
+	PUUID            string `json:"puuid"`
+	PreviousLP       int    `json:"previousLp,omitempty"`
+	CurrentLP        int    `json:"currentLp,omitempty"`
+	LPDelta          int    `json:"lpDelta"`
+	PreviousPosition int    `json:"previousPosition,omitempty"`
+	CurrentPosition  int    `json:"currentPosition,omitempty"`
+	PositionDelta    int    `json:"positionDelta"`
+	GamesPlayed      int    `json:"gamesPlayed"`
+}
+
+type LadderDiff struct {
+	Region    string           `json:"region"`
+	Tier      string           `json:"tier"`
+	From      time.Time        `json:"from"`
+	To        time.Time        `json:"to"`
+	Entered   []LadderMovement `json:"entered"`
+	Dropped   []LadderMovement `json:"dropped"`
+	Movements []LadderMovement `json:"movements"`
+}
+
+// ladderPositions ranks entries by LP, highest first, starting at 1.
+func ladderPositions(entries []LadderSnapshotEntry) map[string]int {
+	ranked := make([]LadderSnapshotEntry, len(entries))
+	copy(ranked, entries)
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].LeaguePoints > ranked[j].LeaguePoints })
+
+	positions := make(map[string]int, len(ranked))
+	for i, entry := range ranked {
+		positions[entry.PUUID] = i + 1
+	}
+	return positions
+}
+
+// diffLadder compares two snapshots. Positive PositionDelta means the player
+// climbed. Movements only lists players whose LP or games changed, biggest LP
+// gain first.
+func diffLadder(from, to []LadderSnapshotEntry) (entered, dropped, movements []LadderMovement) {
+	previous := make(map[string]LadderSnapshotEntry, len(from))
+	for _, entry := range from {
+		previous[entry.PUUID] = entry
+	}
+	current := make(map[string]LadderSnapshotEntry, len(to))
+	for _, entry := range to {
+		current[entry.PUUID] = entry
+	}
+	previousPositions := ladderPositions(from)
+	currentPositions := ladderPositions(to)
+
+	entered, dropped, movements = []LadderMovement{}, []LadderMovement{}, []LadderMovement{}
+	for _, entry := range to {
+		before, existed := previous[entry.PUUID]
+		if !existed {
+			entered = append(entered, LadderMovement{
+				PUUID:           entry.PUUID,
+				CurrentLP:       entry.LeaguePoints,
+				LPDelta:         entry.LeaguePoints,
+				CurrentPosition: currentPositions[entry.PUUID],
+			})
+			continue
+		}
+
+		games := (entry.Wins + entry.Losses) - (before.Wins + before.Losses)
+		if entry.LeaguePoints == before.LeaguePoints && games == 0 {
+			continue
+		}
+		movements = append(movements, LadderMovement{
+			PUUID:            entry.PUUID,
+			PreviousLP:       before.LeaguePoints,
+			CurrentLP:        entry.LeaguePoints,
+			LPDelta:          entry.LeaguePoints - before.LeaguePoints,
+			PreviousPosition: previousPositions[entry.PUUID],
+			CurrentPosition:  currentPositions[entry.PUUID],
+			PositionDelta:    previousPositions[entry.PUUID] - currentPositions[entry.PUUID],
+			GamesPlayed:      games,
+		})
+	}
+	for _, entry := range from {
+		if _, stayed := current[entry.PUUID]; stayed {
+			continue
+		}
+		dropped = append(dropped, LadderMovement{
+			PUUID:            entry.PUUID,
+			PreviousLP:       entry.LeaguePoints,
+			LPDelta:          -entry.LeaguePoints,
+			PreviousPosition: previousPositions[entry.PUUID],
+		})
+	}
+
+	sort.SliceStable(entered, func(i, j int) bool { return entered[i].CurrentPosition < entered[j].CurrentPosition })
+	sort.SliceStable(dropped, func(i, j int) bool { return dropped[i].PreviousPosition < dropped[j].PreviousPosition })
+	sort.SliceStable(movements, func(i, j int) bool { return movements[i].LPDelta > movements[j].LPDelta })
+	return entered, dropped, movements
+}
+
+func (c *RiotAPIClient) recordLeagueSnapshot(ctx context.Context, tier string, entries []LeagueEntry) {
+	if tier != ladderSnapshotTier || c.database == nil || !c.database.Enabled || c.database.ReadOnly {
+		return
+	}
+
+	snapshot := make([]LadderSnapshotEntry, 0, len(entries))
+	for _, entry := range entries {
+		snapshot = append(snapshot, LadderSnapshotEntry{
+			PUUID:        entry.GetUniqueID(),
+			LeaguePoints: entry.LeaguePoints,
+			Wins:         entry.Wins,
+			Losses:       entry.Losses,
+		})
+	}
+
+	recorded, err := c.database.RecordLeagueSnapshot(ctx, c.region, tier, snapshot, time.Now().UTC(), c.snapshotInterval, c.snapshotRetention)
+	if err != nil {
+		c.logger.Error("league_snapshot_record_failed").
+			Component("riot_api").
+			Operation("record_snapshot").
+			Game("", c.region, tier).
+			Err(err).
+			Log()
+		return
+	}
+	if recorded {
+		c.logger.Debug("league_snapshot_recorded").
+			Component("riot_api").
+			Operation("record_snapshot").
+			Game("", c.region, tier).
+			Meta("entries", len(snapshot)).
+			Log()
+	}
+}
+
+// LadderDiff compares the last snapshot taken at or before since (or the
+// oldest one when none is that old) with the latest snapshot up to until.
+func (c *RiotAPIClient) LadderDiff(ctx context.Context, tier string, since, until time.Time) (*LadderDiff, error) {
+	if c.database == nil || !c.database.Enabled {
+		return nil, NewAPIError("Database unavailable", http.StatusServiceUnavailable)
+	}
+
+	from, err := c.database.GetLeagueSnapshot(ctx, c.region, tier, since, false)
+	if err == nil && from == nil {
+		from, err = c.database.GetLeagueSnapshot(ctx, c.region, tier, since, true)
+	}
+	var to *LadderSnapshot
+	if err == nil {
+		to, err = c.database.GetLeagueSnapshot(ctx, c.region, tier, until, false)
+	}
+	if err != nil {
+		return nil, NewAPIError("Failed to load ladder snapshots", http.StatusInternalServerError).WithCause(err)
+	}
+	if from == nil || to == nil || to.TakenAt.Before(from.TakenAt) {
+		return nil, NewAPIError("No ladder snapshots in the requested range", http.StatusNotFound)
+	}
+
+	diff := &LadderDiff{Region: c.region, Tier: tier, From: from.TakenAt, To: to.TakenAt}
+	diff.Entered, diff.Dropped, diff.Movements = diffLadder(from.Entries, to.Entries)
+	return diff, nil
+}
+
+// RecordLeagueSnapshot stores the ladder unless a snapshot newer than
+// minInterval exists, and drops snapshots older than retention.
+func (dm *DatabaseManager) RecordLeagueSnapshot(ctx context.Context, region, tier string, entries []LadderSnapshotEntry, takenAt time.Time, minInterval, retention time.Duration) (bool, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "record_league_snapshot")
+	defer span.End()
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var recent int
+	err = tx.QueryRowContext(ctx, dm.rebind(`
+		SELECT COUNT(*) FROM league_snapshots
+		WHERE region = $1 AND tier = $2 AND taken_at > $3
+	`), region, tier, takenAt.Add(-minInterval)).Scan(&recent)
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+	if recent > 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO league_snapshots (region, tier, taken_at, entries) VALUES ($1, $2, $3, $4)
+	`), region, tier, takenAt, string(data))
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+
+	if retention > 0 {
+		_, err = tx.ExecContext(ctx, dm.rebind(`
+			DELETE FROM league_snapshots WHERE region = $1 AND tier = $2 AND taken_at < $3
+		`), region, tier, takenAt.Add(-retention))
+		if err != nil {
+			recordSpanError(span, err)
+			return false, err
+		}
+	}
+
+	err = tx.Commit()
+	recordSpanError(span, err)
+	return err == nil, err
+}
+
+// GetLeagueSnapshot returns the latest snapshot taken at or before at, or with
+// after set the earliest one taken after it. It returns nil when none exists.
+func (dm *DatabaseManager) GetLeagueSnapshot(ctx context.Context, region, tier string, at time.Time, after bool) (*LadderSnapshot, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_league_snapshot")
+	defer span.End()
+
+	query := `
+		SELECT id, taken_at, entries FROM league_snapshots
+		WHERE region = $1 AND tier = $2 AND taken_at <= $3
+		ORDER BY taken_at DESC
+		LIMIT 1`
+	if after {
+		query = `
+		SELECT id, taken_at, entries FROM league_snapshots
+		WHERE region = $1 AND tier = $2 AND taken_at > $3
+		ORDER BY taken_at ASC
+		LIMIT 1`
+	}
+
+	rows, err := dm.queryAnalytics(ctx, dm.rebind(query), region, tier, at)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := rows.Err()
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	var snapshot LadderSnapshot
+	var data []byte
+	if err := rows.Scan(&snapshot.ID, &snapshot.TakenAt, &data); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &snapshot.Entries); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	return &snapshot, nil
+}
@@ -0,0 +1,67 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffLadder(t *testing.T) {
+	from := []LadderSnapshotEntry{
+		{PUUID: "a", LeaguePoints: 1200, Wins: 100, Losses: 80},
+		{PUUID: "b", LeaguePoints: 1100, Wins: 90, Losses: 90},
+		{PUUID: "c", LeaguePoints: 900, Wins: 50, Losses: 40},
+		{PUUID: "d", LeaguePoints: 800, Wins: 60, Losses: 60},
+	}
+	to := []LadderSnapshotEntry{
+		{PUUID: "a", LeaguePoints: 1200, Wins: 100, Losses: 80},
+		{PUUID: "b", LeaguePoints: 1000, Wins: 90, Losses: 92},
+		{PUUID: "c", LeaguePoints: 1300, Wins: 55, Losses: 41},
+		{PUUID: "e", LeaguePoints: 850, Wins: 70, Losses: 50},
+	}
+
+	entered, dropped, movements := diffLadder(from, to)
+
+	if len(entered) != 1 || entered[0].PUUID != "e" || entered[0].CurrentPosition != 4 || entered[0].LPDelta != 850 {
+		t.Errorf("entered = %+v, expected e at position 4", entered)
+	}
+	if len(dropped) != 1 || dropped[0].PUUID != "d" || dropped[0].PreviousPosition != 4 || dropped[0].LPDelta != -800 {
+		t.Errorf("dropped = %+v, expected d from position 4", dropped)
+	}
+	if len(movements) != 2 {
+		t.Fatalf("movements = %+v, expected b and c (a did not play)", movements)
+	}
+
+	c, b := movements[0], movements[1]
+	if c.PUUID != "c" || c.LPDelta != 400 || c.PreviousPosition != 3 || c.CurrentPosition != 1 || c.PositionDelta != 2 || c.GamesPlayed != 6 {
+		t.Errorf("movement c = %+v", c)
+	}
+	if b.PUUID != "b" || b.LPDelta != -100 || b.PositionDelta != -1 || b.GamesPlayed != 2 {
+		t.Errorf("movement b = %+v", b)
+	}
+}
+
+func TestLadderDiffTime(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{value: "2026-03-09T12:00:00Z", expected: time.Date(2026, 3, 9, 12, 0, 0, 0, time.UTC), ok: true},
+		{value: "1773057600", expected: time.Unix(1773057600, 0).UTC(), ok: true},
+		{value: "24h", expected: now.Add(-24 * time.Hour), ok: true},
+		{value: "7d", expected: now.Add(-7 * 24 * time.Hour), ok: true},
+		{value: "", ok: false},
+		{value: "yesterday", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result, ok := ladderDiffTime(tt.value, now)
+			if ok != tt.ok || !result.Equal(tt.expected) {
+				t.Errorf("ladderDiffTime(%q) = %v, %v, expected %v, %v", tt.value, result, ok, tt.expected, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// SaveLastKnownLeague keeps the latest league fetched from Riot for each
+// region and tier. Unlike the cache it never expires, so it can still be
+// served when both Redis and Riot are unavailable.
+func (dm *DatabaseManager) SaveLastKnownLeague(ctx context.Context, region, tier string, league *ChallengerLeague, fetchedAt time.Time) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "save_last_known_league")
+	defer span.End()
+
+	data, err := json.Marshal(league)
+	if err != nil {
+		return err
+	}
+
+	_, err = dm.DB.ExecContext(ctx, dm.rebind(`
+		INSERT INTO league_last_known_good (region, tier, league, fetched_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (region, tier) DO UPDATE SET
+			league = EXCLUDED.league,
+			fetched_at = EXCLUDED.fetched_at
+	`), region, tier, string(data), fetchedAt)
+	recordSpanError(span, err)
+	return err
+}
+
+// GetLastKnownLeague returns nil when no league was ever saved for the
+// region and tier. It reads the primary: it only runs when Riot is failing,
+// and a lagging replica would serve an even older league.
+func (dm *DatabaseManager) GetLastKnownLeague(ctx context.Context, region, tier string) (*ChallengerLeague, time.Time, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_last_known_league")
+	defer span.End()
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(`
+		SELECT league, fetched_at FROM league_last_known_good WHERE region = $1 AND tier = $2
+	`), region, tier)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, time.Time{}, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		err := rows.Err()
+		recordSpanError(span, err)
+		return nil, time.Time{}, err
+	}
+
+	var data []byte
+	var fetchedAt time.Time
+	if err := rows.Scan(&data, &fetchedAt); err != nil {
+		recordSpanError(span, err)
+		return nil, time.Time{}, err
+	}
+
+	var league ChallengerLeague
+	if err := json.Unmarshal(data, &league); err != nil {
+		recordSpanError(span, err)
+		return nil, time.Time{}, err
+	}
+	return &league, fetchedAt, nil
+}
+
+func (c *RiotAPIClient) saveLastKnownLeague(ctx context.Context, tier string, league *ChallengerLeague) {
+	if c.database == nil || !c.database.Enabled || c.database.ReadOnly {
+		return
+	}
+
+	if err := c.database.SaveLastKnownLeague(ctx, c.region, tier, league, time.Now().UTC()); err != nil {
+		c.logger.Error("league_last_known_good_save_failed").
+			Component("riot_api").
+			Operation("save_last_known_good").
+			Game("", c.region, tier).
+			Err(err).
+			Log()
+	}
+}
+
+// lastKnownLeague looks up the saved league after fetchErr, unless it is
+// older than LEAGUE_LAST_KNOWN_GOOD_MAX_AGE. The result is labeled and
+// deliberately not cached, so the next request tries Riot again.
+func (c *RiotAPIClient) lastKnownLeague(ctx context.Context, tier string, fetchErr error) *ChallengerLeague {
+	if c.database == nil || !c.database.Enabled {
+		return nil
+	}
+
+	league, fetchedAt, err := c.database.GetLastKnownLeague(ctx, c.region, tier)
+	if err != nil {
+		c.logger.Error("league_last_known_good_load_failed").
+			Component("riot_api").
+			Operation("load_last_known_good").
+			Game("", c.region, tier).
+			Err(err).
+			Log()
+		return nil
+	}
+	if league == nil {
+		return nil
+	}
+
+	age := time.Since(fetchedAt)
+	if maxAge := c.lastKnownGoodMaxAge.Load(); !lastKnownGoodFresh(age, maxAge) {
+		c.logger.Warn("league_last_known_good_too_old").
+			Component("riot_api").
+			Operation("load_last_known_good").
+			Game("", c.region, tier).
+			Meta("fetched_at", fetchedAt.UTC().Format(time.RFC3339)).
+			Meta("max_age", maxAge.String()).
+			Err(fetchErr).
+			Log()
+		return nil
+	}
+
+	league.LastKnownGood = &LastKnownGood{
+		FetchedAt:  fetchedAt.UTC(),
+		AgeSeconds: int64(age.Seconds()),
+	}
+	c.logger.Warn("league_last_known_good_served").
+		Component("riot_api").
+		Operation("load_last_known_good").
+		Game("", c.region, tier).
+		Meta("fetched_at", fetchedAt.UTC().Format(time.RFC3339)).
+		Err(fetchErr).
+		Log()
+	return league
+}
+
+// lastKnownGoodFresh reports whether a saved league is recent enough to
+// serve in place of a failed fetch. A zero maxAge accepts any age.
+func lastKnownGoodFresh(age, maxAge time.Duration) bool {
+	return maxAge <= 0 || age <= maxAge
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newCachedFixtureClient(dir string) *RiotAPIClient {
+	cfg := &Config{
+		RiotMode:        RiotModeFixtures,
+		RiotFixturesDir: dir,
+		RiotRegion:      "BR1",
+		RiotBaseURL:     fixtureBaseURL,
+		RiotHTTPTimeout: time.Second,
+	}
+	return NewRiotAPIClient(cfg, NewCacheManager(cfg, nil, nil, nil), newTestLogger(), nil)
+}
+
+func TestHighTierLeague_WithoutLastKnownGood(t *testing.T) {
+	client := newCachedFixtureClient("../fixtures/riot")
+	league, err := client.GetChallengerLeague(t.Context())
+	if err != nil {
+		t.Fatalf("GetChallengerLeague() error = %v", err)
+	}
+	if league.LastKnownGood != nil {
+		t.Errorf("fresh league labeled as last known good: %+v", league.LastKnownGood)
+	}
+
+	body, _ := json.Marshal(league)
+	if strings.Contains(string(body), "lastKnownGood") {
+		t.Errorf("fresh league JSON = %s, expected no lastKnownGood field", body)
+	}
+
+	// An empty fixture tree fails like a Riot outage; without a database
+	// there is nothing to fall back to.
+	if _, err := newCachedFixtureClient(t.TempDir()).GetMasterLeague(t.Context()); err == nil {
+		t.Error("expected the fetch error without a database")
+	}
+}
+
+func TestSetLastKnownGoodHeader(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	setLastKnownGoodHeader(recorder, nil)
+	if value := recorder.Header().Get("X-Last-Known-Good"); value != "" {
+		t.Errorf("header = %q without a last known good league", value)
+	}
+
+	fetchedAt := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	setLastKnownGoodHeader(recorder, &LastKnownGood{FetchedAt: fetchedAt, AgeSeconds: 5400})
+	if value := recorder.Header().Get("X-Last-Known-Good"); value != "2025-06-01T12:00:00Z" {
+		t.Errorf("header = %q, expected the fetch time", value)
+	}
+	if value := recorder.Header().Get("X-Last-Known-Good-Age"); value != "5400" {
+		t.Errorf("age header = %q, expected 5400", value)
+	}
+	if value := recorder.Header().Get("Cache-Control"); value != "no-store" {
+		t.Errorf("Cache-Control = %q, expected no-store", value)
+	}
+
+	setCORSHeaders(recorder, httptest.NewRequest(http.MethodGet, "/league/challenger", nil))
+	exposed := recorder.Header().Get("Access-Control-Expose-Headers")
+	for _, header := range []string{"X-Last-Known-Good", "X-Last-Known-Good-Age"} {
+		if !strings.Contains(exposed, header) {
+			t.Errorf("Access-Control-Expose-Headers = %q, expected %s for browser clients", exposed, header)
+		}
+	}
+}
+
+func TestLastKnownGoodFresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		age      time.Duration
+		maxAge   time.Duration
+		expected bool
+	}{
+		{name: "within max age", age: time.Hour, maxAge: 24 * time.Hour, expected: true},
+		{name: "at max age", age: 24 * time.Hour, maxAge: 24 * time.Hour, expected: true},
+		{name: "too old", age: 25 * time.Hour, maxAge: 24 * time.Hour, expected: false},
+		{name: "no limit", age: 90 * 24 * time.Hour, maxAge: 0, expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := lastKnownGoodFresh(tt.age, tt.maxAge); result != tt.expected {
+				t.Errorf("lastKnownGoodFresh(%v, %v) = %v, expected %v", tt.age, tt.maxAge, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"golang.org/x/sync/errgroup"
+)
+
+// parseLeagueRefreshRegions returns the regions each league update covers:
+// the home region first, then the extra regions in raw (comma separated)
+// without duplicates.
+func parseLeagueRefreshRegions(raw, home string) ([]string, error) {
+	return parseRegionList(raw, []string{home})
+}
+
+// parseRegionList appends the regions in raw (comma separated) to regions,
+// uppercased and without duplicates.
+func parseRegionList(raw string, regions []string) ([]string, error) {
+	seen := make(map[string]bool, len(regions))
+	for _, region := range regions {
+		seen[region] = true
+	}
+	for _, part := range strings.Split(raw, ",") {
+		region := strings.ToUpper(strings.TrimSpace(part))
+		if region == "" || seen[region] {
+			continue
+		}
+		if _, known := accountAPIURLs[region]; !known {
+			return nil, fmt.Errorf("unknown region %q", region)
+		}
+		seen[region] = true
+		regions = append(regions, region)
+	}
+	return regions, nil
+}
+
+// LeagueRefresher handles league update tasks. Each task is refreshed in all
+// of its regions in parallel, and every task running in the process draws
+// from one shared budget of concurrent refreshes, so adding league workers
+// or regions does not multiply the load on the Riot API.
+type LeagueRefresher struct {
+	riotClient *RiotAPIClient
+	cache      *CacheManager
+	logger     *Logger
+	budget     chan struct{}
+
+	mu      sync.Mutex
+	clients map[string]*RiotAPIClient
+}
+
+func NewLeagueRefresher(cfg *Config, riotClient *RiotAPIClient, cache *CacheManager, logger *Logger) *LeagueRefresher {
+	concurrency := cfg.LeagueRefreshConcurrency
+	if concurrency < 1 {
+		concurrency = 4
+	}
+
+	return &LeagueRefresher{
+		riotClient: riotClient,
+		cache:      cache,
+		logger:     logger,
+		budget:     make(chan struct{}, concurrency),
+		clients:    make(map[string]*RiotAPIClient),
+	}
+}
+
+// client builds region clients lazily so they pick up the database and NATS
+// connection attached to the home client during startup.
+func (lr *LeagueRefresher) client(region string) *RiotAPIClient {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	client, ok := lr.clients[region]
+	if !ok {
+		client = lr.riotClient.ForRegion(region)
+		lr.clients[region] = client
+	}
+	return client
+}
+
+// Refresh updates the task's league in every region. A failure in one region
+// does not stop the others; the errors are joined. Regions still waiting for
+// budget when the task deadline passes are skipped.
+func (lr *LeagueRefresher) Refresh(ctx context.Context, task LeagueUpdateTask) error {
+	if !isLeagueScheduleTask(task.Type) {
+		return fmt.Errorf("unknown league type %q", task.Type)
+	}
+
+	regions := task.Regions
+	if len(regions) == 0 {
+		regions = []string{task.Region}
+	}
+	if !task.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, task.Deadline)
+		defer cancel()
+	}
+
+	errs := make([]error, len(regions))
+	var g errgroup.Group
+	g.SetLimit(cap(lr.budget))
+	for i, region := range regions {
+		g.Go(func() error {
+			select {
+			case lr.budget <- struct{}{}:
+			case <-ctx.Done():
+				errs[i] = fmt.Errorf("%s: %w", region, ctx.Err())
+				return nil
+			}
+			defer func() { <-lr.budget }()
+			// select picks at random when the deadline and a free slot
+			// arrive together.
+			if err := ctx.Err(); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", region, err)
+				return nil
+			}
+
+			if err := lr.refreshRegion(ctx, task.Type, region); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", region, err)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+	return errors.Join(errs...)
+}
+
+func (lr *LeagueRefresher) refreshRegion(ctx context.Context, leagueType, region string) error {
+	client := lr.client(region)
+	start := time.Now()
+
+	var result interface{}
+	var lastKnownGood *LastKnownGood
+	var err error
+	switch leagueType {
+	case "challenger":
+		var league *ChallengerLeague
+		if league, err = client.GetChallengerLeague(ctx); err == nil {
+			result, lastKnownGood = league, league.LastKnownGood
+		}
+	case "grandmaster":
+		var league *GrandmasterLeague
+		if league, err = client.GetGrandmasterLeague(ctx); err == nil {
+			result, lastKnownGood = league, league.LastKnownGood
+		}
+	case "master":
+		var league *MasterLeague
+		if league, err = client.GetMasterLeague(ctx); err == nil {
+			result, lastKnownGood = league, league.LastKnownGood
+		}
+	}
+	if err != nil {
+		return err
+	}
+	// Caching the persisted league would hide it from the next refresh.
+	if lastKnownGood != nil {
+		return fmt.Errorf("riot unavailable, last known good league is from %s", lastKnownGood.FetchedAt.Format(time.RFC3339))
+	}
+	return cacheLeagueResult(ctx, lr.cache, leagueType, region, result, time.Since(start))
+}
+
+func (lr *LeagueRefresher) process(ctx context.Context, msg *nats.Msg) error {
+	var task LeagueUpdateTask
+	if err := json.Unmarshal(msg.Data, &task); err != nil {
+		lr.logger.Warn("league_update_invalid_task").
+			Component("league_refresh").
+			Operation("decode").
+			Err(err).
+			Log()
+		return permanentTask(err)
+	}
+
+	start := time.Now()
+	err := lr.Refresh(ctx, task)
+	if err != nil {
+		lr.logger.Error("league_update_failed").
+			Component("league_refresh").
+			Operation("refresh").
+			Game("", task.Region, task.Type).
+			Meta("regions", task.Regions).
+			Err(err).
+			Duration(time.Since(start)).
+			Log()
+		// Past its deadline the next scheduled task has taken over, so
+		// retrying would only duplicate it.
+		if !task.Deadline.IsZero() && !time.Now().Before(task.Deadline) {
+			return permanentTask(err)
+		}
+		return err
+	}
+
+	lr.logger.Info("league_update_completed").
+		Component("league_refresh").
+		Operation("refresh").
+		Game("", task.Region, task.Type).
+		Meta("regions", task.Regions).
+		Duration(time.Since(start)).
+		Log()
+	return nil
+}
+
+func isLeagueScheduleTask(task string) bool {
+	for _, known := range leagueScheduleTasks {
+		if task == known {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheLeagueResult(ctx context.Context, cacheManager *CacheManager, leagueType, region string, result interface{}, delta time.Duration) error {
+	cacheKey := cacheManager.Key(leagueType, region)
+	return cacheManager.SetEarly(ctx, cacheKey, result, 30*time.Minute, delta)
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseLeagueRefreshRegions(t *testing.T) {
+	tests := []struct {
+		raw       string
+		expected  []string
+		expectErr bool
+	}{
+		{raw: "", expected: []string{"BR1"}},
+		{raw: "na1, euw1", expected: []string{"BR1", "NA1", "EUW1"}},
+		{raw: "BR1,NA1,na1,", expected: []string{"BR1", "NA1"}},
+		{raw: "NA1,MARS1", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		regions, err := parseLeagueRefreshRegions(tt.raw, "BR1")
+		if (err != nil) != tt.expectErr {
+			t.Errorf("parseLeagueRefreshRegions(%q) error = %v, expectErr %v", tt.raw, err, tt.expectErr)
+			continue
+		}
+		if !tt.expectErr && !reflect.DeepEqual(regions, tt.expected) {
+			t.Errorf("parseLeagueRefreshRegions(%q) = %v, expected %v", tt.raw, regions, tt.expected)
+		}
+	}
+}
+
+// slowTransport delays each request and records the host and the peak number
+// of requests in flight.
+type slowTransport struct {
+	next  http.RoundTripper
+	delay time.Duration
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	hosts    map[string]int
+}
+
+func (st *slowTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	st.mu.Lock()
+	st.inFlight++
+	if st.inFlight > st.peak {
+		st.peak = st.inFlight
+	}
+	st.hosts[r.URL.Host]++
+	st.mu.Unlock()
+
+	time.Sleep(st.delay)
+
+	st.mu.Lock()
+	st.inFlight--
+	st.mu.Unlock()
+	return st.next.RoundTrip(r)
+}
+
+func newTestLeagueRefresher(t *testing.T, concurrency int, delay time.Duration) (*LeagueRefresher, *slowTransport) {
+	t.Helper()
+	cfg := &Config{
+		RiotMode:                 RiotModeFixtures,
+		RiotFixturesDir:          "../fixtures/riot",
+		RiotRegion:               "BR1",
+		RiotBaseURL:              fixtureBaseURL,
+		RiotHTTPTimeout:          5 * time.Second,
+		LeagueRefreshConcurrency: concurrency,
+	}
+	cache := NewCacheManager(cfg, nil, nil, nil)
+	client := NewRiotAPIClient(cfg, cache, newTestLogger(), nil)
+	transport := &slowTransport{next: client.client.Transport, delay: delay, hosts: make(map[string]int)}
+	client.client.Transport = transport
+	return NewLeagueRefresher(cfg, client, cache, newTestLogger()), transport
+}
+
+func TestLeagueRefresher_SharedBudget(t *testing.T) {
+	refresher, transport := newTestLeagueRefresher(t, 2, 20*time.Millisecond)
+	task := LeagueUpdateTask{Type: "challenger", Region: "BR1", Regions: []string{"BR1", "NA1", "EUW1", "KR"}}
+
+	// Two tasks at once must still share the budget of two.
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = refresher.Refresh(t.Context(), task)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+	}
+	if transport.peak > 2 {
+		t.Errorf("peak in-flight requests = %d, expected at most 2", transport.peak)
+	}
+	if transport.peak < 2 {
+		t.Errorf("peak in-flight requests = %d, expected regions to run in parallel", transport.peak)
+	}
+	for _, host := range []string{"fixtures.invalid", "na1.api.riotgames.com", "euw1.api.riotgames.com", "kr.api.riotgames.com"} {
+		if transport.hosts[host] != 2 {
+			t.Errorf("requests to %s = %d, expected 2", host, transport.hosts[host])
+		}
+	}
+}
+
+func TestLeagueRefresher_Deadline(t *testing.T) {
+	refresher, transport := newTestLeagueRefresher(t, 1, 50*time.Millisecond)
+	task := LeagueUpdateTask{
+		Type:     "master",
+		Region:   "BR1",
+		Regions:  []string{"BR1", "NA1", "EUW1"},
+		Deadline: time.Now().Add(30 * time.Millisecond),
+	}
+
+	err := refresher.Refresh(context.Background(), task)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Refresh() error = %v, expected the deadline to skip waiting regions", err)
+	}
+	if transport.hosts["euw1.api.riotgames.com"] != 0 {
+		t.Errorf("EUW1 was refreshed after the deadline")
+	}
+}
+
+func TestLeagueRefresher_UnknownType(t *testing.T) {
+	refresher, _ := newTestLeagueRefresher(t, 1, 0)
+	if err := refresher.Refresh(t.Context(), LeagueUpdateTask{Type: "diamond", Region: "BR1"}); err == nil {
+		t.Error("expected an error for an unknown league type")
+	}
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// parseRiotRegions returns the regions whose leagues this deployment
+// schedules. Without RIOT_REGIONS only the home region is scheduled.
+func parseRiotRegions(raw, home string) ([]string, error) {
+	regions, err := parseRegionList(raw, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 {
+		return []string{home}, nil
+	}
+	return regions, nil
+}
+
+// leagueScheduleName keeps the single-region task names used before
+// RIOT_REGIONS existed, so health checks and reloads keep matching them.
+func leagueScheduleName(task, region string, sharded bool) string {
+	if !sharded {
+		return "league_update_" + task
+	}
+	return "league_update_" + task + "_" + strings.ToLower(region)
+}
+
+// A claim outlives two intervals so the owner keeps it across jittered runs,
+// and another instance takes over once the owner has missed a run or two.
+const leagueClaimIntervals = 2
+
+// claimScript extends the claim when the caller already owns it and otherwise
+// takes it only if nobody does.
+var claimScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+return 0
+`)
+
+// LeagueScheduleClaims decides which instance publishes each region's league
+// updates. Every instance registers the same tasks; the one holding a
+// region's claim in Redis publishes and the others skip the run.
+type LeagueScheduleClaims struct {
+	client *redis.Client
+	owner  string
+}
+
+func NewLeagueScheduleClaims(cfg *Config, client *redis.Client) *LeagueScheduleClaims {
+	return &LeagueScheduleClaims{
+		client: client,
+		owner:  fmt.Sprintf("%s-%s", cfg.NATSClientID, uuid.NewString()),
+	}
+}
+
+func leagueClaimKey(task, region string) string {
+	return fmt.Sprintf("tft:lock:league_update:%s:%s", strings.ToLower(region), task)
+}
+
+// Claim reports whether this instance owns the region's schedule for task.
+// Without Redis there is nobody to coordinate with, so every claim succeeds.
+func (lc *LeagueScheduleClaims) Claim(ctx context.Context, task, region string, interval time.Duration) (bool, error) {
+	if lc == nil || lc.client == nil {
+		return true, nil
+	}
+
+	ttl := leagueClaimIntervals * interval
+	claimed, err := claimScript.Run(ctx, lc.client, []string{leagueClaimKey(task, region)}, lc.owner, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return claimed == 1, nil
+}
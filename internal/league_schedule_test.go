@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestParseRiotRegions(t *testing.T) {
+	tests := []struct {
+		raw       string
+		expected  []string
+		expectErr bool
+	}{
+		{raw: "", expected: []string{"BR1"}},
+		{raw: "na1, euw1", expected: []string{"NA1", "EUW1"}},
+		{raw: "BR1,KR,br1,", expected: []string{"BR1", "KR"}},
+		{raw: "NA1,MARS1", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		regions, err := parseRiotRegions(tt.raw, "BR1")
+		if (err != nil) != tt.expectErr {
+			t.Errorf("parseRiotRegions(%q) error = %v, expectErr %v", tt.raw, err, tt.expectErr)
+			continue
+		}
+		if !tt.expectErr && !reflect.DeepEqual(regions, tt.expected) {
+			t.Errorf("parseRiotRegions(%q) = %v, expected %v", tt.raw, regions, tt.expected)
+		}
+	}
+}
+
+func TestRegisterLeagueUpdateTasks(t *testing.T) {
+	schedules := []ScheduleConfig{
+		{Task: "challenger", Enabled: true, Interval: time.Minute},
+		{Task: "grandmaster", Enabled: false, Interval: time.Minute},
+	}
+
+	tests := []struct {
+		name        string
+		riotRegions string
+		expected    []string
+	}{
+		{name: "home region only", expected: []string{"league_update_challenger"}},
+		{
+			name:        "sharded by region",
+			riotRegions: "BR1,NA1",
+			expected:    []string{"league_update_challenger_br1", "league_update_challenger_na1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{RiotRegion: "BR1", RiotRegions: tt.riotRegions, LeagueSchedules: schedules}
+			scheduler := NewScheduler(0, nil, newTestLogger())
+			RegisterLeagueUpdateTasks(scheduler, nil, nil, cfg)
+
+			names := make([]string, 0, len(scheduler.tasks))
+			for _, task := range scheduler.tasks {
+				names = append(names, task.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("registered tasks = %v, expected %v", names, tt.expected)
+			}
+
+			cfg.LeagueSchedules = []ScheduleConfig{{Task: "challenger", Enabled: true, Interval: time.Hour}}
+			scheduler.ApplyConfig(cfg)
+			for _, name := range tt.expected {
+				if interval := scheduler.interval(name); interval != time.Hour {
+					t.Errorf("%s interval = %v after reload, expected 1h", name, interval)
+				}
+			}
+		})
+	}
+}
+
+func TestLeagueScheduleClaims_WithoutRedis(t *testing.T) {
+	claims := NewLeagueScheduleClaims(&Config{NATSClientID: "tft-service"}, nil)
+	claimed, err := claims.Claim(t.Context(), "challenger", "BR1", time.Minute)
+	if err != nil || !claimed {
+		t.Errorf("Claim() = %v, %v, expected every claim to succeed without Redis", claimed, err)
+	}
+}
+
+func TestLeagueClaimKey(t *testing.T) {
+	key := leagueClaimKey("master", "EUW1")
+	if key != "tft:lock:league_update:euw1:master" {
+		t.Errorf("leagueClaimKey() = %q", key)
+	}
+	if NewCachePruner(&Config{}, nil, nil, nil).orphaned(key) {
+		t.Errorf("claim key %q would be deleted by the cache pruner", key)
+	}
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type LoadTestOptions struct {
+	Target      string
+	APIKey      string
+	RPS         int
+	Duration    time.Duration
+	Concurrency int
+	Timeout     time.Duration
+}
+
+type LoadTestScenario struct {
+	Name   string
+	Weight int
+	Run    func(ctx context.Context, client *Client, rng *rand.Rand) error
+}
+
+type LatencySummary struct {
+	Requests  int            `json:"requests"`
+	Errors    int            `json:"errors"`
+	ErrorRate float64        `json:"errorRate"`
+	P50       time.Duration  `json:"p50"`
+	P90       time.Duration  `json:"p90"`
+	P95       time.Duration  `json:"p95"`
+	P99       time.Duration  `json:"p99"`
+	Max       time.Duration  `json:"max"`
+	Statuses  map[string]int `json:"statuses,omitempty"`
+}
+
+type LoadTestReport struct {
+	Target      string                    `json:"target"`
+	Duration    time.Duration             `json:"duration"`
+	TargetRPS   int                       `json:"targetRps"`
+	AchievedRPS float64                   `json:"achievedRps"`
+	Dropped     int                       `json:"dropped"`
+	Total       LatencySummary            `json:"total"`
+	Endpoints   map[string]LatencySummary `json:"endpoints"`
+}
+
+type loadTestSample struct {
+	scenario string
+	latency  time.Duration
+	outcome  string
+}
+
+func DefaultLoadTestScenarios(puuids []string) []LoadTestScenario {
+	scenarios := []LoadTestScenario{
+		{Name: "healthz", Weight: 1, Run: func(ctx context.Context, client *Client, _ *rand.Rand) error {
+			_, err := client.Health(ctx)
+			return err
+		}},
+		{Name: "league_challenger", Weight: 3, Run: func(ctx context.Context, client *Client, _ *rand.Rand) error {
+			_, err := client.Challenger(ctx)
+			return err
+		}},
+		{Name: "league_entries", Weight: 4, Run: func(ctx context.Context, client *Client, rng *rand.Rand) error {
+			// Apex tiers are served by their own endpoints and have no divisions.
+			tier := validTiers[rng.Intn(7)]
+			division := validDivisions[rng.Intn(len(validDivisions))]
+			_, err := client.LeagueEntries(ctx, tier, division, 1+rng.Intn(3))
+			return err
+		}},
+	}
+
+	if len(puuids) > 0 {
+		scenarios = append(scenarios,
+			LoadTestScenario{Name: "summoner", Weight: 2, Run: func(ctx context.Context, client *Client, rng *rand.Rand) error {
+				_, err := client.Summoner(ctx, puuids[rng.Intn(len(puuids))])
+				return err
+			}},
+			LoadTestScenario{Name: "league_by_puuid", Weight: 2, Run: func(ctx context.Context, client *Client, rng *rand.Rand) error {
+				_, err := client.LeagueByPUUID(ctx, puuids[rng.Intn(len(puuids))])
+				return err
+			}},
+		)
+	}
+
+	return scenarios
+}
+
+func pickScenario(scenarios []LoadTestScenario, rng *rand.Rand) LoadTestScenario {
+	total := 0
+	for _, scenario := range scenarios {
+		total += scenario.Weight
+	}
+	n := rng.Intn(total)
+	for _, scenario := range scenarios {
+		if n < scenario.Weight {
+			return scenario
+		}
+		n -= scenario.Weight
+	}
+	return scenarios[len(scenarios)-1]
+}
+
+func loadTestOutcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	var clientErr *ClientError
+	if errors.As(err, &clientErr) {
+		return fmt.Sprintf("%d", clientErr.Status)
+	}
+	if errors.Is(err, context.DeadlineExceeded) || strings.Contains(err.Error(), "Client.Timeout") {
+		return "timeout"
+	}
+	return "transport"
+}
+
+// RunLoadTest issues requests at a fixed rate rather than as fast as the
+// target answers, so slow responses show up as latency instead of lowering
+// the offered load. Ticks that find every worker busy are counted as dropped.
+func RunLoadTest(ctx context.Context, opts LoadTestOptions, scenarios []LoadTestScenario) (*LoadTestReport, error) {
+	if opts.RPS <= 0 {
+		return nil, fmt.Errorf("rps must be positive")
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive")
+	}
+	if len(scenarios) == 0 {
+		return nil, fmt.Errorf("no load test scenarios")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = opts.RPS
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+
+	client := NewClient(opts.Target, opts.APIKey, opts.Timeout)
+	client.userAgent = "tft-core-loadtest"
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	var (
+		samples []loadTestSample
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		dropped int
+	)
+	slots := make(chan struct{}, opts.Concurrency)
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	ticker := time.NewTicker(time.Second / time.Duration(opts.RPS))
+	defer ticker.Stop()
+
+	start := time.Now()
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-ticker.C:
+		}
+
+		select {
+		case slots <- struct{}{}:
+		default:
+			dropped++
+			continue
+		}
+
+		scenario := pickScenario(scenarios, rng)
+		workerRng := rand.New(rand.NewSource(rng.Int63()))
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			reqCtx, reqCancel := context.WithTimeout(context.Background(), opts.Timeout)
+			defer reqCancel()
+
+			began := time.Now()
+			err := scenario.Run(reqCtx, client, workerRng)
+			sample := loadTestSample{scenario: scenario.Name, latency: time.Since(began), outcome: loadTestOutcome(err)}
+
+			mu.Lock()
+			samples = append(samples, sample)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := &LoadTestReport{
+		Target:    opts.Target,
+		Duration:  elapsed,
+		TargetRPS: opts.RPS,
+		Dropped:   dropped,
+		Endpoints: make(map[string]LatencySummary),
+	}
+	if elapsed > 0 {
+		report.AchievedRPS = float64(len(samples)) / elapsed.Seconds()
+	}
+
+	byScenario := make(map[string][]loadTestSample)
+	for _, sample := range samples {
+		byScenario[sample.scenario] = append(byScenario[sample.scenario], sample)
+	}
+	for name, scenarioSamples := range byScenario {
+		report.Endpoints[name] = summarizeSamples(scenarioSamples)
+	}
+	report.Total = summarizeSamples(samples)
+
+	return report, nil
+}
+
+func summarizeSamples(samples []loadTestSample) LatencySummary {
+	summary := LatencySummary{Requests: len(samples), Statuses: make(map[string]int)}
+	if len(samples) == 0 {
+		return summary
+	}
+
+	latencies := make([]time.Duration, len(samples))
+	for i, sample := range samples {
+		latencies[i] = sample.latency
+		summary.Statuses[sample.outcome]++
+		if sample.outcome != "ok" {
+			summary.Errors++
+		}
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	summary.ErrorRate = float64(summary.Errors) / float64(len(samples))
+	summary.P50 = latencyPercentile(latencies, 50)
+	summary.P90 = latencyPercentile(latencies, 90)
+	summary.P95 = latencyPercentile(latencies, 95)
+	summary.P99 = latencyPercentile(latencies, 99)
+	summary.Max = latencies[len(latencies)-1]
+	return summary
+}
+
+// latencyPercentile uses the nearest-rank method on an already sorted slice.
+func latencyPercentile(sorted []time.Duration, percentile float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+func (r *LoadTestReport) Print(out io.Writer) {
+	fmt.Fprintf(out, "target:       %s\n", r.Target)
+	fmt.Fprintf(out, "duration:     %s\n", r.Duration.Round(time.Millisecond))
+	fmt.Fprintf(out, "rps:          %.1f achieved / %d target (%d dropped)\n", r.AchievedRPS, r.TargetRPS, r.Dropped)
+	fmt.Fprintf(out, "requests:     %d (%d errors, %.2f%%)\n\n", r.Total.Requests, r.Total.Errors, r.Total.ErrorRate*100)
+
+	fmt.Fprintf(out, "%-20s %8s %8s %10s %10s %10s %10s %10s\n", "endpoint", "reqs", "err%", "p50", "p90", "p95", "p99", "max")
+	names := make([]string, 0, len(r.Endpoints))
+	for name := range r.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		printLatencyRow(out, name, r.Endpoints[name])
+	}
+	printLatencyRow(out, "total", r.Total)
+}
+
+func printLatencyRow(out io.Writer, name string, summary LatencySummary) {
+	fmt.Fprintf(out, "%-20s %8d %7.2f%% %10s %10s %10s %10s %10s\n",
+		name, summary.Requests, summary.ErrorRate*100,
+		summary.P50.Round(time.Microsecond), summary.P90.Round(time.Microsecond),
+		summary.P95.Round(time.Microsecond), summary.P99.Round(time.Microsecond),
+		summary.Max.Round(time.Microsecond))
+}
@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentile(t *testing.T) {
+	sorted := make([]time.Duration, 100)
+	for i := range sorted {
+		sorted[i] = time.Duration(i+1) * time.Millisecond
+	}
+
+	tests := []struct {
+		name       string
+		values     []time.Duration
+		percentile float64
+		expected   time.Duration
+	}{
+		{name: "empty", values: nil, percentile: 50, expected: 0},
+		{name: "single value", values: []time.Duration{7 * time.Millisecond}, percentile: 99, expected: 7 * time.Millisecond},
+		{name: "p50", values: sorted, percentile: 50, expected: 50 * time.Millisecond},
+		{name: "p95", values: sorted, percentile: 95, expected: 95 * time.Millisecond},
+		{name: "p99", values: sorted, percentile: 99, expected: 99 * time.Millisecond},
+		{name: "p100", values: sorted, percentile: 100, expected: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latencyPercentile(tt.values, tt.percentile); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSummarizeSamples(t *testing.T) {
+	samples := []loadTestSample{
+		{scenario: "a", latency: 10 * time.Millisecond, outcome: "ok"},
+		{scenario: "a", latency: 20 * time.Millisecond, outcome: "ok"},
+		{scenario: "a", latency: 30 * time.Millisecond, outcome: "503"},
+		{scenario: "a", latency: 40 * time.Millisecond, outcome: "timeout"},
+	}
+
+	summary := summarizeSamples(samples)
+	if summary.Requests != 4 || summary.Errors != 2 {
+		t.Fatalf("expected 4 requests and 2 errors, got %+v", summary)
+	}
+	if summary.ErrorRate != 0.5 {
+		t.Errorf("expected error rate 0.5, got %v", summary.ErrorRate)
+	}
+	if summary.Max != 40*time.Millisecond || summary.P50 != 20*time.Millisecond {
+		t.Errorf("unexpected latencies %+v", summary)
+	}
+	if summary.Statuses["503"] != 1 || summary.Statuses["ok"] != 2 {
+		t.Errorf("unexpected statuses %v", summary.Statuses)
+	}
+}
+
+func TestRunLoadTest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-API-Key") != "secret" {
+			t.Errorf("expected api key header")
+		}
+		switch r.URL.Path {
+		case "/healthz":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/league/entries":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`{"error":"busy"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	scenarios := []LoadTestScenario{
+		{Name: "healthz", Weight: 1, Run: func(ctx context.Context, client *Client, _ *rand.Rand) error {
+			_, err := client.Health(ctx)
+			return err
+		}},
+		{Name: "league_entries", Weight: 1, Run: func(ctx context.Context, client *Client, _ *rand.Rand) error {
+			_, err := client.LeagueEntries(ctx, "GOLD", "I", 1)
+			return err
+		}},
+	}
+
+	report, err := RunLoadTest(context.Background(), LoadTestOptions{
+		Target:   server.URL,
+		APIKey:   "secret",
+		RPS:      200,
+		Duration: 200 * time.Millisecond,
+	}, scenarios)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if report.Total.Requests == 0 {
+		t.Fatal("expected requests to be issued")
+	}
+	if health := report.Endpoints["healthz"]; health.Requests > 0 && health.Errors != 0 {
+		t.Errorf("expected healthz without errors, got %+v", health)
+	}
+	if entries := report.Endpoints["league_entries"]; entries.Requests > 0 && entries.Statuses["503"] != entries.Requests {
+		t.Errorf("expected every entries request to fail with 503, got %+v", entries)
+	}
+}
+
+func TestRunLoadTestRejectsInvalidOptions(t *testing.T) {
+	scenarios := DefaultLoadTestScenarios(nil)
+
+	tests := []struct {
+		name string
+		opts LoadTestOptions
+	}{
+		{name: "zero rps", opts: LoadTestOptions{Target: "http://localhost", Duration: time.Second}},
+		{name: "zero duration", opts: LoadTestOptions{Target: "http://localhost", RPS: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := RunLoadTest(context.Background(), tt.opts, scenarios); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package internal
+
+import (
+	"container/list"
+	"context"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// localCacheKeyTypes are the key types kept in process: read far more often
+// than written and fine to serve a few seconds stale. Locks, pending markers
+// and counters always go to the shared backend.
+var localCacheKeyTypes = map[string]bool{
+	"challenger":       true,
+	"grandmaster":      true,
+	"master":           true,
+	"entries":          true,
+	"summoner":         true,
+	"summoner_name":    true,
+	"summoner_profile": true,
+	"account_puuid":    true,
+	"league_by_puuid":  true,
+	"match":            true,
+}
+
+const (
+	sketchDepth      = 4
+	sketchMaxCount   = 15
+	sketchResetRatio = 10
+)
+
+// frequencySketch is a count-min sketch of recent key accesses. Counters are
+// halved every sketchResetRatio*capacity increments, so popularity fades and
+// a key that was hot an hour ago does not keep its place forever.
+type frequencySketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+func newFrequencySketch(capacity int) *frequencySketch {
+	width := 16
+	for width < capacity {
+		width <<= 1
+	}
+	fs := &frequencySketch{mask: uint64(width - 1), resetAt: sketchResetRatio * capacity}
+	for i := range fs.rows {
+		fs.rows[i] = make([]uint8, width)
+	}
+	return fs
+}
+
+// indexes derives one slot per row from a single hash by double hashing.
+func (fs *frequencySketch) indexes(key string) [sketchDepth]uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	sum := h.Sum64()
+	low, high := sum&0xffffffff, sum>>32|1
+
+	var idx [sketchDepth]uint64
+	for i := range idx {
+		idx[i] = (low + uint64(i)*high) & fs.mask
+	}
+	return idx
+}
+
+func (fs *frequencySketch) Increment(key string) {
+	for row, i := range fs.indexes(key) {
+		if fs.rows[row][i] < sketchMaxCount {
+			fs.rows[row][i]++
+		}
+	}
+
+	fs.additions++
+	if fs.additions >= fs.resetAt {
+		for _, row := range fs.rows {
+			for i := range row {
+				row[i] >>= 1
+			}
+		}
+		fs.additions /= 2
+	}
+}
+
+func (fs *frequencySketch) Estimate(key string) uint8 {
+	estimate := uint8(sketchMaxCount)
+	for row, i := range fs.indexes(key) {
+		if count := fs.rows[row][i]; count < estimate {
+			estimate = count
+		}
+	}
+	return estimate
+}
+
+type localEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+// localCacheBackend is an in-process LRU tier in front of the shared backend.
+// When full, a new key only replaces the least recently used one if the
+// sketch has seen it more often, so a burst of one-off player lookups cannot
+// push out the leaderboards every request reads.
+type localCacheBackend struct {
+	next     CacheBackend
+	capacity int
+	ttl      time.Duration
+	clock    Clock
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+	sketch  *frequencySketch
+}
+
+func newLocalCacheBackend(next CacheBackend, capacity int, ttl time.Duration, clock Clock) *localCacheBackend {
+	return &localCacheBackend{
+		next:     next,
+		capacity: capacity,
+		ttl:      ttl,
+		clock:    clockOrSystem(clock),
+		entries:  make(map[string]*list.Element, capacity),
+		lru:      list.New(),
+		sketch:   newFrequencySketch(capacity),
+	}
+}
+
+func cachedLocally(key string) bool {
+	return localCacheKeyTypes[cacheKeyType(key)]
+}
+
+// lookup records the access and returns the live entry, if any.
+func (lc *localCacheBackend) lookup(key string) ([]byte, bool) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.sketch.Increment(key)
+	element, ok := lc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*localEntry)
+	if lc.clock.Now().After(entry.expires) {
+		lc.remove(element)
+		return nil, false
+	}
+	lc.lru.MoveToFront(element)
+	return entry.value, true
+}
+
+// admit stores the value unless the cache is full and the key is no more
+// popular than the entry it would evict. Updates to present keys always land.
+func (lc *localCacheBackend) admit(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 || ttl > lc.ttl {
+		ttl = lc.ttl
+	}
+	expires := lc.clock.Now().Add(ttl)
+
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if element, ok := lc.entries[key]; ok {
+		entry := element.Value.(*localEntry)
+		entry.value, entry.expires = value, expires
+		lc.lru.MoveToFront(element)
+		return
+	}
+
+	if lc.lru.Len() >= lc.capacity {
+		victim := lc.lru.Back()
+		entry := victim.Value.(*localEntry)
+		if lc.clock.Now().Before(entry.expires) && lc.sketch.Estimate(key) <= lc.sketch.Estimate(entry.key) {
+			return
+		}
+		lc.remove(victim)
+	}
+	lc.entries[key] = lc.lru.PushFront(&localEntry{key: key, value: value, expires: expires})
+}
+
+func (lc *localCacheBackend) remove(element *list.Element) {
+	lc.lru.Remove(element)
+	delete(lc.entries, element.Value.(*localEntry).key)
+}
+
+func (lc *localCacheBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	if !cachedLocally(key) {
+		return lc.next.Get(ctx, key)
+	}
+	if value, ok := lc.lookup(key); ok {
+		return value, nil
+	}
+
+	value, err := lc.next.Get(ctx, key)
+	if err == nil {
+		lc.admit(key, value, lc.ttl)
+	}
+	return value, err
+}
+
+func (lc *localCacheBackend) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	found := make(map[string][]byte, len(keys))
+	var missing []string
+	for _, key := range keys {
+		if cachedLocally(key) {
+			if value, ok := lc.lookup(key); ok {
+				found[key] = value
+				continue
+			}
+		}
+		missing = append(missing, key)
+	}
+	if len(missing) == 0 {
+		return found, nil
+	}
+
+	fetched, err := lc.next.GetMulti(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range fetched {
+		found[key] = value
+		if cachedLocally(key) {
+			lc.admit(key, value, lc.ttl)
+		}
+	}
+	return found, nil
+}
+
+func (lc *localCacheBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := lc.next.Set(ctx, key, value, ttl); err != nil {
+		return err
+	}
+	if cachedLocally(key) {
+		lc.admit(key, value, ttl)
+	}
+	return nil
+}
+
+func (lc *localCacheBackend) Delete(ctx context.Context, keys ...string) error {
+	lc.mu.Lock()
+	for _, key := range keys {
+		if element, ok := lc.entries[key]; ok {
+			lc.remove(element)
+		}
+	}
+	lc.mu.Unlock()
+	return lc.next.Delete(ctx, keys...)
+}
+
+func (lc *localCacheBackend) Ping(ctx context.Context) error {
+	return lc.next.Ping(ctx)
+}
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapBackend is a CacheBackend over a map that counts Get calls per key.
+type mapBackend struct {
+	mu     sync.Mutex
+	values map[string][]byte
+	ttls   map[string]time.Duration
+	gets   map[string]int
+}
+
+func newMapBackend() *mapBackend {
+	return &mapBackend{values: make(map[string][]byte), ttls: make(map[string]time.Duration), gets: make(map[string]int)}
+}
+
+func (mb *mapBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.gets[key]++
+	value, ok := mb.values[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (mb *mapBackend) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	found := make(map[string][]byte)
+	for _, key := range keys {
+		if value, err := mb.Get(ctx, key); err == nil {
+			found[key] = value
+		}
+	}
+	return found, nil
+}
+
+func (mb *mapBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	mb.values[key] = value
+	mb.ttls[key] = ttl
+	return nil
+}
+
+func (mb *mapBackend) Delete(ctx context.Context, keys ...string) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	for _, key := range keys {
+		delete(mb.values, key)
+		delete(mb.ttls, key)
+	}
+	return nil
+}
+
+func (mb *mapBackend) Ping(ctx context.Context) error { return nil }
+
+func (mb *mapBackend) ttl(key string) time.Duration {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.ttls[key]
+}
+
+func (mb *mapBackend) getCount(key string) int {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.gets[key]
+}
+
+func TestFrequencySketch(t *testing.T) {
+	fs := newFrequencySketch(16)
+	for i := 0; i < 5; i++ {
+		fs.Increment("tft:challenger:br1")
+	}
+	fs.Increment("tft:summoner:br1:once")
+
+	if got := fs.Estimate("tft:challenger:br1"); got < 5 {
+		t.Errorf("Estimate(hot) = %d, expected at least 5", got)
+	}
+	if got := fs.Estimate("tft:summoner:br1:once"); got < 1 || got >= 5 {
+		t.Errorf("Estimate(once) = %d, expected between 1 and 4", got)
+	}
+
+	// 160 increments in total trigger the reset that halves every counter.
+	for i := 0; i < 160-6; i++ {
+		fs.Increment("tft:master:br1")
+	}
+	if got := fs.Estimate("tft:challenger:br1"); got > 3 {
+		t.Errorf("Estimate(hot) after reset = %d, expected it halved", got)
+	}
+}
+
+func TestLocalCache_Admission(t *testing.T) {
+	next := newMapBackend()
+	local := newLocalCacheBackend(next, 2, time.Minute, nil)
+	ctx := t.Context()
+
+	hot := []string{"tft:challenger:br1", "tft:grandmaster:br1"}
+	for _, key := range hot {
+		local.Set(ctx, key, []byte(`{}`), time.Hour)
+		for i := 0; i < 3; i++ {
+			local.Get(ctx, key)
+		}
+	}
+
+	// One-off lookups must not displace the leaderboards.
+	for _, puuid := range []string{"a", "b", "c"} {
+		key := "tft:summoner:br1:" + puuid
+		local.Get(ctx, key)
+		local.Set(ctx, key, []byte(`{}`), time.Hour)
+	}
+	for _, key := range hot {
+		local.Get(ctx, key)
+		if next.getCount(key) != 0 {
+			t.Errorf("%s read from the backend %d times, expected it to stay local", key, next.getCount(key))
+		}
+	}
+
+	// A key read more often than the coldest entry gets in.
+	popular := "tft:summoner:br1:popular"
+	next.Set(ctx, popular, []byte(`{}`), time.Hour)
+	for i := 0; i < 6; i++ {
+		local.Get(ctx, popular)
+	}
+	reads := next.getCount(popular)
+	local.Get(ctx, popular)
+	if next.getCount(popular) != reads {
+		t.Errorf("popular key still read from the backend after %d reads", reads)
+	}
+}
+
+func TestLocalCache_KeyTypesAndDelete(t *testing.T) {
+	next := newMapBackend()
+	local := newLocalCacheBackend(next, 10, time.Minute, nil)
+	ctx := t.Context()
+
+	lock := "tft:lock:league_update:br1:challenger"
+	local.Set(ctx, lock, []byte(`"owner"`), time.Minute)
+	local.Get(ctx, lock)
+	local.Get(ctx, lock)
+	if next.getCount(lock) != 2 {
+		t.Errorf("lock reads from the backend = %d, expected every read to reach it", next.getCount(lock))
+	}
+
+	key := "tft:master:br1"
+	local.Set(ctx, key, []byte(`{}`), time.Hour)
+	local.Delete(ctx, key)
+	if _, err := local.Get(ctx, key); err != ErrCacheMiss {
+		t.Errorf("Get() after Delete error = %v, expected a miss", err)
+	}
+
+	values, err := local.GetMulti(ctx, []string{"tft:summoner_name:a", "tft:summoner_name:b"})
+	if err != nil || len(values) != 0 {
+		t.Fatalf("GetMulti() = %v, %v", values, err)
+	}
+	next.Set(ctx, "tft:summoner_name:a", []byte(`"Alpha#BR1"`), time.Hour)
+	local.GetMulti(ctx, []string{"tft:summoner_name:a"})
+	values, _ = local.GetMulti(ctx, []string{"tft:summoner_name:a"})
+	if string(values["tft:summoner_name:a"]) != `"Alpha#BR1"` || next.getCount("tft:summoner_name:a") != 2 {
+		t.Errorf("GetMulti() = %v after %d backend reads, expected the second to be local", values, next.getCount("tft:summoner_name:a"))
+	}
+}
+
+func TestLocalCache_Expiry(t *testing.T) {
+	next := newMapBackend()
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	local := newLocalCacheBackend(next, 10, 5*time.Second, clock)
+	ctx := t.Context()
+
+	key := "tft:challenger:br1"
+	next.Set(ctx, key, []byte(`{}`), time.Hour)
+	local.Get(ctx, key)
+
+	// The local copy lives for the local TTL, not the backend's hour.
+	clock.Advance(4 * time.Second)
+	local.Get(ctx, key)
+	if next.getCount(key) != 1 {
+		t.Errorf("backend reads within the local TTL = %d, expected 1", next.getCount(key))
+	}
+	clock.Advance(time.Second + time.Millisecond)
+	local.Get(ctx, key)
+	if next.getCount(key) != 2 {
+		t.Errorf("backend reads after the local TTL = %d, expected 2", next.getCount(key))
+	}
+}
@@ -2,8 +2,14 @@ package internal
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -17,51 +23,132 @@ const (
 )
 
 type LogEntry struct {
-	Timestamp  time.Time              `json:"timestamp"`
-	Level      LogLevel               `json:"level"`
-	Message    string                 `json:"message"`
-	Service    string                 `json:"service"`
-	Component  string                 `json:"component"`
-	Operation  string                 `json:"operation,omitempty"`
-	Duration   int64                  `json:"duration_ms,omitempty"`
-	StatusCode int                    `json:"status_code,omitempty"`
-	Method     string                 `json:"method,omitempty"`
-	Path       string                 `json:"path,omitempty"`
-	UserAgent  string                 `json:"user_agent,omitempty"`
-	RemoteAddr string                 `json:"remote_addr,omitempty"`
-	RequestID  string                 `json:"request_id,omitempty"`
-	CacheHit   *bool                  `json:"cache_hit,omitempty"`
-	CacheKey   string                 `json:"cache_key,omitempty"`
-	QueueDepth int                    `json:"queue_depth,omitempty"`
-	WorkerID   string                 `json:"worker_id,omitempty"`
-	TaskType   string                 `json:"task_type,omitempty"`
-	PUUID      string                 `json:"puuid,omitempty"`
-	Region     string                 `json:"region,omitempty"`
-	Tier       string                 `json:"tier,omitempty"`
-	Error      string                 `json:"error,omitempty"`
-	ErrorCode  string                 `json:"error_code,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	Timestamp     time.Time              `json:"timestamp"`
+	Level         LogLevel               `json:"level"`
+	Message       string                 `json:"message"`
+	Service       string                 `json:"service"`
+	Component     string                 `json:"component"`
+	Operation     string                 `json:"operation,omitempty"`
+	Duration      int64                  `json:"duration_ms,omitempty"`
+	StatusCode    int                    `json:"status_code,omitempty"`
+	RequestBytes  int64                  `json:"request_bytes,omitempty"`
+	ResponseBytes int64                  `json:"response_bytes,omitempty"`
+	Method        string                 `json:"method,omitempty"`
+	Path          string                 `json:"path,omitempty"`
+	UserAgent     string                 `json:"user_agent,omitempty"`
+	RemoteAddr    string                 `json:"remote_addr,omitempty"`
+	RequestID     string                 `json:"request_id,omitempty"`
+	CacheHit      *bool                  `json:"cache_hit,omitempty"`
+	CacheKey      string                 `json:"cache_key,omitempty"`
+	QueueDepth    int                    `json:"queue_depth,omitempty"`
+	WorkerID      string                 `json:"worker_id,omitempty"`
+	TaskType      string                 `json:"task_type,omitempty"`
+	PUUID         string                 `json:"puuid,omitempty"`
+	Region        string                 `json:"region,omitempty"`
+	Tier          string                 `json:"tier,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	ErrorCode     string                 `json:"error_code,omitempty"`
+	Metadata      map[string]interface{} `json:"metadata,omitempty"`
 }
 
 type Logger struct {
-	level       LogLevel
+	level       atomic.Value
 	service     string
 	environment string
 	logger      *log.Logger
+
+	hooksMu sync.RWMutex
+	hooks   []func(LogEntry)
+
+	// sampling holds a map[string]*sampledEvent keyed by message; it is
+	// replaced as a whole on reload.
+	sampling atomic.Value
 }
 
-func NewLogger(cfg *Config) *Logger {
-	level := LogLevel(cfg.LogLevel)
-	if level == "" {
-		level = LogLevelInfo
-	}
+// sampledEvent keeps one in rate entries of a message. seen counts every
+// entry that passed the level filter, kept or not.
+type sampledEvent struct {
+	rate uint64
+	seen atomic.Uint64
+}
 
-	return &Logger{
-		level:       level,
+func NewLogger(cfg *Config) *Logger {
+	l := &Logger{
 		service:     "tft-core",
 		environment: cfg.AppEnv,
 		logger:      log.New(os.Stdout, "", 0),
 	}
+	l.SetLevel(LogLevel(cfg.LogLevel))
+	// validate() rejects an invalid LOG_SAMPLING before the logger is used.
+	rates, _ := parseLogSampling(cfg.LogSampling)
+	l.SetSampling(rates)
+	return l
+}
+
+// ApplyConfig picks up LOG_LEVEL and LOG_SAMPLING on reload. Sampling counters
+// restart from zero.
+func (l *Logger) ApplyConfig(cfg *Config) {
+	l.SetLevel(LogLevel(cfg.LogLevel))
+	if rates, err := parseLogSampling(cfg.LogSampling); err == nil {
+		l.SetSampling(rates)
+	}
+}
+
+// parseLogSampling reads "message=N" rules separated by commas, e.g.
+// "cache_hit=100,cache_miss=10" to keep one in 100 cache_hit entries.
+func parseLogSampling(value string) (map[string]int, error) {
+	rates := make(map[string]int)
+	if strings.TrimSpace(value) == "" {
+		return rates, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		message, rateStr, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found || message == "" {
+			return nil, fmt.Errorf("invalid sampling rule %q, expected message=N", rule)
+		}
+		rate, err := strconv.Atoi(rateStr)
+		if err != nil || rate < 1 {
+			return nil, fmt.Errorf("invalid sampling rate %q for %s", rateStr, message)
+		}
+		rates[message] = rate
+	}
+	return rates, nil
+}
+
+// SetSampling keeps only one in N entries of each listed message. Kept
+// entries carry sample_rate and sample_seen, the number of entries of that
+// message so far, so totals can still be recovered from the logs.
+func (l *Logger) SetSampling(rates map[string]int) {
+	events := make(map[string]*sampledEvent, len(rates))
+	for message, rate := range rates {
+		if rate > 1 {
+			events[message] = &sampledEvent{rate: uint64(rate)}
+		}
+	}
+	l.sampling.Store(events)
+}
+
+// SetLevel changes the minimum level at runtime; an empty level means info.
+func (l *Logger) SetLevel(level LogLevel) {
+	if level == "" {
+		level = LogLevelInfo
+	}
+	l.level.Store(level)
+}
+
+// SetOutput redirects the JSON lines, e.g. to io.Discard in tests.
+func (l *Logger) SetOutput(w io.Writer) {
+	l.logger.SetOutput(w)
+}
+
+// AddHook passes every entry that passes the level filter to hook, complete
+// with timestamp and service. Tests use it to assert that an event is
+// emitted with the component, operation and fields operators rely on.
+func (l *Logger) AddHook(hook func(LogEntry)) {
+	l.hooksMu.Lock()
+	l.hooks = append(l.hooks, hook)
+	l.hooksMu.Unlock()
 }
 
 func (l *Logger) shouldLog(level LogLevel) bool {
@@ -71,7 +158,7 @@ func (l *Logger) shouldLog(level LogLevel) bool {
 		LogLevelWarn:  2,
 		LogLevelError: 3,
 	}
-	return levels[level] >= levels[l.level]
+	return levels[level] >= levels[l.level.Load().(LogLevel)]
 }
 
 func (l *Logger) log(entry LogEntry) {
@@ -79,6 +166,18 @@ func (l *Logger) log(entry LogEntry) {
 		return
 	}
 
+	var sampled *sampledEvent
+	if events, _ := l.sampling.Load().(map[string]*sampledEvent); events != nil {
+		sampled = events[entry.Message]
+	}
+	var seen uint64
+	if sampled != nil {
+		// The first entry of each window of rate is kept.
+		if seen = sampled.seen.Add(1); (seen-1)%sampled.rate != 0 {
+			return
+		}
+	}
+
 	entry.Timestamp = time.Now().UTC()
 	entry.Service = l.service
 
@@ -86,6 +185,16 @@ func (l *Logger) log(entry LogEntry) {
 		entry.Metadata = make(map[string]interface{})
 	}
 	entry.Metadata["environment"] = l.environment
+	if sampled != nil {
+		entry.Metadata["sample_rate"] = sampled.rate
+		entry.Metadata["sample_seen"] = seen
+	}
+
+	l.hooksMu.RLock()
+	for _, hook := range l.hooks {
+		hook(entry)
+	}
+	l.hooksMu.RUnlock()
 
 	jsonData, err := json.Marshal(entry)
 	if err != nil {
@@ -139,6 +248,13 @@ func (b *LogBuilder) HTTP(method, path string, statusCode int) *LogBuilder {
 	return b
 }
 
+// Bytes records request and response body sizes.
+func (b *LogBuilder) Bytes(request, response int64) *LogBuilder {
+	b.entry.RequestBytes = request
+	b.entry.ResponseBytes = response
+	return b
+}
+
 func (b *LogBuilder) Request(userAgent, remoteAddr, requestID string) *LogBuilder {
 	b.entry.UserAgent = userAgent
 	b.entry.RemoteAddr = remoteAddr
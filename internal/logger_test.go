@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// logCapture records the entries a logger emits so tests can assert on the
+// logging contract: event name, level, component, operation and fields.
+type logCapture struct {
+	mu      sync.Mutex
+	entries []LogEntry
+}
+
+// newCaptureLogger logs at debug level into a capture instead of stdout.
+func newCaptureLogger() (*Logger, *logCapture) {
+	logger := NewLogger(&Config{LogLevel: "debug", AppEnv: "test"})
+	logger.SetOutput(io.Discard)
+
+	capture := &logCapture{}
+	logger.AddHook(func(entry LogEntry) {
+		capture.mu.Lock()
+		capture.entries = append(capture.entries, entry)
+		capture.mu.Unlock()
+	})
+	return logger, capture
+}
+
+// expectedEvent describes an entry; empty fields are not compared and Meta
+// only needs to be a subset of the entry's metadata.
+type expectedEvent struct {
+	Level     LogLevel
+	Message   string
+	Component string
+	Operation string
+	Meta      map[string]interface{}
+}
+
+func (e expectedEvent) matches(entry LogEntry) bool {
+	switch {
+	case entry.Message != e.Message,
+		e.Level != "" && entry.Level != e.Level,
+		e.Component != "" && entry.Component != e.Component,
+		e.Operation != "" && entry.Operation != e.Operation:
+		return false
+	}
+	for key, value := range e.Meta {
+		if fmt.Sprint(entry.Metadata[key]) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Expect fails the test unless a matching entry was logged, and returns the
+// first one for further checks.
+func (lc *logCapture) Expect(t *testing.T, expected expectedEvent) LogEntry {
+	t.Helper()
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	var seen []string
+	for _, entry := range lc.entries {
+		if expected.matches(entry) {
+			return entry
+		}
+		seen = append(seen, fmt.Sprintf("%s %s %s/%s", entry.Level, entry.Message, entry.Component, entry.Operation))
+	}
+	t.Fatalf("no log entry matching %+v; logged:\n%s", expected, strings.Join(seen, "\n"))
+	return LogEntry{}
+}
+
+// Count returns how many entries carry message.
+func (lc *logCapture) Count(message string) int {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	count := 0
+	for _, entry := range lc.entries {
+		if entry.Message == message {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLogger_Hook(t *testing.T) {
+	logger, capture := newCaptureLogger()
+
+	logger.Debug("cache_probe").Component("cache").Operation("get").Meta("key", "tft:challenger:br1").Log()
+	logger.Error("cache_failed").Component("cache").Operation("set").Err(errors.New("timeout")).Log()
+
+	entry := capture.Expect(t, expectedEvent{
+		Level:     LogLevelDebug,
+		Message:   "cache_probe",
+		Component: "cache",
+		Operation: "get",
+		Meta:      map[string]interface{}{"key": "tft:challenger:br1"},
+	})
+	if entry.Service != "tft-core" || entry.Timestamp.IsZero() || entry.Metadata["environment"] != "test" {
+		t.Errorf("entry = %+v, expected service, timestamp and environment filled in", entry)
+	}
+	if got := capture.Expect(t, expectedEvent{Message: "cache_failed"}); got.Error != "timeout" {
+		t.Errorf("error = %q, expected timeout", got.Error)
+	}
+
+	// Entries below the level never reach hooks.
+	logger.SetLevel(LogLevelWarn)
+	logger.Info("ignored").Component("cache").Log()
+	if capture.Count("ignored") != 0 {
+		t.Error("hook received an entry below the logger level")
+	}
+}
+
+func TestParseLogSampling(t *testing.T) {
+	rates, err := parseLogSampling("cache_hit=100, cache_miss=10")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if rates["cache_hit"] != 100 || rates["cache_miss"] != 10 {
+		t.Errorf("parseLogSampling() = %v", rates)
+	}
+
+	for _, value := range []string{"cache_hit", "cache_hit=0", "=10", "cache_hit=often"} {
+		if _, err := parseLogSampling(value); err == nil {
+			t.Errorf("parseLogSampling(%q) expected error but got nil", value)
+		}
+	}
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	logger, capture := newCaptureLogger()
+	logger.SetSampling(map[string]int{"cache_hit": 4, "cache_miss": 1})
+
+	for i := 0; i < 10; i++ {
+		logger.Debug("cache_hit").Component("cache").Log()
+		logger.Debug("cache_miss").Component("cache").Log()
+	}
+
+	if got := capture.Count("cache_hit"); got != 3 {
+		t.Errorf("cache_hit entries = %d, expected 3 of 10 at 1 in 4", got)
+	}
+	if got := capture.Count("cache_miss"); got != 10 {
+		t.Errorf("cache_miss entries = %d, expected a rate of 1 to keep all", got)
+	}
+	capture.Expect(t, expectedEvent{Message: "cache_hit", Meta: map[string]interface{}{"sample_rate": 4, "sample_seen": 9}})
+	if entry := capture.Expect(t, expectedEvent{Message: "cache_miss"}); entry.Metadata["sample_rate"] != nil {
+		t.Errorf("unsampled entry metadata = %v, expected no sample fields", entry.Metadata)
+	}
+
+	// Entries below the level are not counted.
+	logger.SetLevel(LogLevelInfo)
+	logger.Debug("cache_hit").Component("cache").Log()
+	logger.SetLevel(LogLevelDebug)
+	logger.Debug("cache_hit").Component("cache").Log()
+	if got := capture.Count("cache_hit"); got != 3 {
+		t.Errorf("cache_hit entries = %d, expected the 11th entry dropped", got)
+	}
+
+	logger.ApplyConfig(&Config{LogLevel: "debug"})
+	logger.Debug("cache_hit").Component("cache").Log()
+	if got := capture.Count("cache_hit"); got != 4 {
+		t.Errorf("cache_hit entries = %d, expected sampling cleared by reload", got)
+	}
+}
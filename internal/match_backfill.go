@@ -0,0 +1,387 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	BackfillSourceWatchlist = "watchlist"
+	BackfillSourceLadder    = "ladder"
+
+	// backfillMaxRateLimitWaits is how many 429s in a row one player may hit
+	// before the backfill gives up on it.
+	backfillMaxRateLimitWaits = 5
+	// backfillRateLimitPause is the pause after a 429 without Retry-After.
+	backfillRateLimitPause = 10 * time.Second
+)
+
+// BackfillOptions controls the backfill command.
+type BackfillOptions struct {
+	Source string
+	// Tiers limits a ladder backfill; empty means every apex tier.
+	Tiers []string
+	// Matches is how many of each player's latest matches are archived.
+	Matches int
+	// RPS caps the Riot requests the backfill makes per second, leaving the
+	// rest of the key's budget to the running service.
+	RPS float64
+	// Limit stops after this many players; 0 walks all of them.
+	Limit int
+}
+
+// BackfillReport is both the progress reported after every player and the
+// final result.
+type BackfillReport struct {
+	Source      string `json:"source"`
+	Players     int    `json:"players"`
+	Processed   int    `json:"processed"`
+	Failed      int    `json:"failed"`
+	Ingested    int    `json:"ingested"`
+	Archived    int    `json:"archived"`
+	Skipped     int    `json:"skipped"`
+	RateLimited int    `json:"rateLimited"`
+	Interrupted bool   `json:"interrupted,omitempty"`
+	Elapsed     string `json:"elapsed"`
+}
+
+// backfillResult counts what happened to one player's matches: ingested ones
+// were newer than the ingest cursor and went through IngestMatch, archived
+// ones were older and only stored, skipped ones were already archived.
+type backfillResult struct {
+	ingested int
+	archived int
+	skipped  int
+}
+
+// requestPacer spaces Riot requests at least interval apart.
+type requestPacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newRequestPacer(rps float64) *requestPacer {
+	if rps <= 0 {
+		return &requestPacer{}
+	}
+	return &requestPacer{interval: time.Duration(float64(time.Second) / rps)}
+}
+
+func (p *requestPacer) Wait(ctx context.Context) error {
+	if p.interval <= 0 {
+		return ctx.Err()
+	}
+	now := time.Now()
+	if p.next.After(now) {
+		if err := sleepContext(ctx, p.next.Sub(now)); err != nil {
+			return err
+		}
+		now = p.next
+	}
+	p.next = now.Add(p.interval)
+	return nil
+}
+
+// Pause holds every following request back by d, after Riot said to slow down.
+func (p *requestPacer) Pause(d time.Duration) {
+	if until := time.Now().Add(d); until.After(p.next) {
+		p.next = until
+	}
+}
+
+func validateBackfillOptions(opts *BackfillOptions) error {
+	switch opts.Source {
+	case BackfillSourceWatchlist, BackfillSourceLadder:
+	default:
+		return fmt.Errorf("source must be %s or %s", BackfillSourceWatchlist, BackfillSourceLadder)
+	}
+	tiers := opts.Tiers
+	if len(tiers) == 0 {
+		tiers = leagueScheduleTasks
+	}
+	opts.Tiers = make([]string, len(tiers))
+	for i, tier := range tiers {
+		opts.Tiers[i] = strings.ToLower(strings.TrimSpace(tier))
+		if !isLeagueScheduleTask(opts.Tiers[i]) {
+			return fmt.Errorf("unknown tier %q; expected one of %s", tier, strings.Join(leagueScheduleTasks, ", "))
+		}
+	}
+	if opts.Matches < 1 || opts.Matches > 200 {
+		return errors.New("matches must be between 1 and 200")
+	}
+	if opts.Limit < 0 {
+		return errors.New("limit must not be negative")
+	}
+	return nil
+}
+
+// RunMatchBackfill archives the latest matches of every watchlisted or apex
+// ladder player in the client's region, one player at a time. progress, when
+// set, receives the running totals after each player. Cancelling ctx stops
+// the backfill and returns what was done so far; running it again skips
+// matches that are already archived.
+func RunMatchBackfill(ctx context.Context, ingester *MatchIngester, opts BackfillOptions, progress func(BackfillReport)) (*BackfillReport, error) {
+	if err := validateBackfillOptions(&opts); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	pacer := newRequestPacer(opts.RPS)
+	puuids, err := ingester.backfillPUUIDs(ctx, opts, pacer)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Limit > 0 && len(puuids) > opts.Limit {
+		puuids = puuids[:opts.Limit]
+	}
+
+	report := &BackfillReport{Source: opts.Source, Players: len(puuids)}
+	for _, puuid := range puuids {
+		result, rateLimited, err := ingester.backfillPlayerWithRetry(ctx, puuid, opts.Matches, pacer)
+		report.RateLimited += rateLimited
+		report.Ingested += result.ingested
+		report.Archived += result.archived
+		report.Skipped += result.skipped
+		if ctx.Err() != nil {
+			report.Interrupted = true
+			break
+		}
+
+		report.Processed++
+		if err != nil {
+			report.Failed++
+			ingester.logger.Warn("match_backfill_player_failed").
+				Component("match_ingest").
+				Operation("backfill").
+				Game(puuid, ingester.riotClient.region, "").
+				Err(err).
+				Log()
+		}
+		if progress != nil {
+			report.Elapsed = time.Since(start).Round(time.Second).String()
+			progress(*report)
+		}
+	}
+
+	report.Elapsed = time.Since(start).Round(time.Second).String()
+	ingester.logger.Info("match_backfill_completed").
+		Component("match_ingest").
+		Operation("backfill").
+		Meta("source", report.Source).
+		Meta("players", report.Players).
+		Meta("processed", report.Processed).
+		Meta("failed", report.Failed).
+		Meta("ingested", report.Ingested).
+		Meta("archived", report.Archived).
+		Meta("interrupted", report.Interrupted).
+		Duration(time.Since(start)).
+		Log()
+	return report, nil
+}
+
+func (mi *MatchIngester) backfillPUUIDs(ctx context.Context, opts BackfillOptions, pacer *requestPacer) ([]string, error) {
+	if opts.Source == BackfillSourceWatchlist {
+		return mi.db.WatchlistPUUIDs(ctx, mi.riotClient.region)
+	}
+
+	var puuids []string
+	for _, tier := range opts.Tiers {
+		if err := pacer.Wait(ctx); err != nil {
+			return nil, err
+		}
+		var entries []LeagueEntry
+		switch tier {
+		case "challenger":
+			league, err := mi.riotClient.GetChallengerLeague(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%s league: %w", tier, err)
+			}
+			entries = league.Entries
+		case "grandmaster":
+			league, err := mi.riotClient.GetGrandmasterLeague(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%s league: %w", tier, err)
+			}
+			entries = league.Entries
+		case "master":
+			league, err := mi.riotClient.GetMasterLeague(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("%s league: %w", tier, err)
+			}
+			entries = league.Entries
+		}
+
+		// Best players first, so a limited or interrupted run covers them.
+		entries = slices.Clone(entries)
+		slices.SortStableFunc(entries, func(a, b LeagueEntry) int { return b.LeaguePoints - a.LeaguePoints })
+		for _, entry := range entries {
+			if entry.PUUID != "" && !slices.Contains(puuids, entry.PUUID) {
+				puuids = append(puuids, entry.PUUID)
+			}
+		}
+	}
+	return puuids, nil
+}
+
+// backfillPlayerWithRetry waits out Riot's 429s instead of failing the
+// player, pausing the pacer so the next requests slow down too.
+func (mi *MatchIngester) backfillPlayerWithRetry(ctx context.Context, puuid string, count int, pacer *requestPacer) (backfillResult, int, error) {
+	var total backfillResult
+	rateLimited := 0
+	for {
+		result, err := mi.backfillPlayer(ctx, puuid, count, pacer)
+		total.ingested += result.ingested
+		total.archived += result.archived
+		total.skipped += result.skipped
+
+		var riotErr *RiotAPIError
+		if !errors.As(err, &riotErr) || riotErr.StatusCode != http.StatusTooManyRequests || rateLimited >= backfillMaxRateLimitWaits {
+			return total, rateLimited, err
+		}
+		rateLimited++
+
+		pause := riotErr.RetryAfter
+		if pause <= 0 {
+			pause = backfillRateLimitPause
+		}
+		mi.logger.Warn("match_backfill_rate_limited").
+			Component("match_ingest").
+			Operation("backfill").
+			Game(puuid, mi.riotClient.region, "").
+			Meta("pause", pause.String()).
+			Log()
+		pacer.Pause(pause)
+	}
+}
+
+// backfillPlayer archives the player's latest count matches, oldest first.
+// Matches newer than the ingest cursor go through IngestMatch exactly as the
+// worker would ingest them, cursor and event included; older ones are only
+// archived, so the cursor never moves back and no event is sent twice.
+func (mi *MatchIngester) backfillPlayer(ctx context.Context, puuid string, count int, pacer *requestPacer) (backfillResult, error) {
+	var result backfillResult
+
+	lastSeen, err := mi.db.GetMatchIngestCursor(ctx, puuid)
+	if err != nil {
+		return result, err
+	}
+	if err := pacer.Wait(ctx); err != nil {
+		return result, err
+	}
+	matchIDs, err := mi.riotClient.GetRecentMatchIDs(ctx, puuid, count)
+	if err != nil {
+		return result, err
+	}
+	archived, err := mi.db.ArchivedMatchIDs(ctx, matchIDs)
+	if err != nil {
+		return result, err
+	}
+
+	newIDs := matchIDsSince(matchIDs, lastSeen)
+	isNew := make(map[string]bool, len(newIDs))
+	for _, matchID := range newIDs {
+		isNew[matchID] = true
+	}
+
+	for i := len(matchIDs) - 1; i >= 0; i-- {
+		matchID := matchIDs[i]
+		if archived[matchID] && !isNew[matchID] {
+			result.skipped++
+			continue
+		}
+
+		if err := pacer.Wait(ctx); err != nil {
+			return result, err
+		}
+		match, err := mi.riotClient.GetMatch(ctx, matchID)
+		if err != nil {
+			return result, err
+		}
+
+		if isNew[matchID] {
+			if err := mi.db.IngestMatch(ctx, puuid, match, mi.ingestedEvent(puuid, match)); err != nil {
+				return result, err
+			}
+			result.ingested++
+			continue
+		}
+		if err := mi.db.ArchiveMatch(ctx, match); err != nil {
+			return result, err
+		}
+		result.archived++
+	}
+	return result, nil
+}
+
+// WatchlistPUUIDs lists every watched player in region, whichever tenants
+// watch them.
+func (dm *DatabaseManager) WatchlistPUUIDs(ctx context.Context, region string) ([]string, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "watchlist_puuids")
+	defer span.End()
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(`
+		SELECT puuid FROM watchlist WHERE region = $1 ORDER BY added_at, puuid
+	`), region)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	puuids := []string{}
+	for rows.Next() {
+		var puuid string
+		if err := rows.Scan(&puuid); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		puuids = append(puuids, puuid)
+	}
+	err = rows.Err()
+	recordSpanError(span, err)
+	return puuids, err
+}
+
+// ArchivedMatchIDs reports which of matchIDs are already in the archive.
+func (dm *DatabaseManager) ArchivedMatchIDs(ctx context.Context, matchIDs []string) (map[string]bool, error) {
+	archived := make(map[string]bool, len(matchIDs))
+	if len(matchIDs) == 0 {
+		return archived, nil
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "archived_match_ids")
+	defer span.End()
+
+	placeholders := make([]string, len(matchIDs))
+	args := make([]interface{}, len(matchIDs))
+	for i, matchID := range matchIDs {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = matchID
+	}
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(`
+		SELECT match_id FROM matches WHERE match_id IN (`+strings.Join(placeholders, ", ")+`)
+	`), args...)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var matchID string
+		if err := rows.Scan(&matchID); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		archived[matchID] = true
+	}
+	err = rows.Err()
+	recordSpanError(span, err)
+	return archived, err
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidateBackfillOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          BackfillOptions
+		expectedTiers []string
+		expectErr     bool
+	}{
+		{
+			name:          "watchlist defaults to every tier",
+			opts:          BackfillOptions{Source: BackfillSourceWatchlist, Matches: 20},
+			expectedTiers: []string{"challenger", "grandmaster", "master"},
+		},
+		{
+			name:          "tiers are normalized",
+			opts:          BackfillOptions{Source: BackfillSourceLadder, Tiers: []string{" Challenger", "MASTER"}, Matches: 200},
+			expectedTiers: []string{"challenger", "master"},
+		},
+		{name: "unknown source", opts: BackfillOptions{Source: "everyone", Matches: 20}, expectErr: true},
+		{name: "unknown tier", opts: BackfillOptions{Source: BackfillSourceLadder, Tiers: []string{"gold"}, Matches: 20}, expectErr: true},
+		{name: "too many matches", opts: BackfillOptions{Source: BackfillSourceLadder, Matches: 201}, expectErr: true},
+		{name: "negative limit", opts: BackfillOptions{Source: BackfillSourceLadder, Matches: 20, Limit: -1}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			err := validateBackfillOptions(&opts)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !slices.Equal(opts.Tiers, tt.expectedTiers) {
+				t.Errorf("tiers = %v, expected %v", opts.Tiers, tt.expectedTiers)
+			}
+		})
+	}
+	if !slices.Equal(leagueScheduleTasks, []string{"challenger", "grandmaster", "master"}) {
+		t.Errorf("validateBackfillOptions changed leagueScheduleTasks to %v", leagueScheduleTasks)
+	}
+}
+
+func TestRequestPacer(t *testing.T) {
+	pacer := newRequestPacer(100)
+	start := time.Now()
+	for range 3 {
+		if err := pacer.Wait(t.Context()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("three requests at 100 rps took %v, expected at least 20ms", elapsed)
+	}
+
+	pacer.Pause(time.Hour)
+	ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+	defer cancel()
+	if err := pacer.Wait(ctx); err == nil {
+		t.Error("Wait() after Pause() returned before the pause ended")
+	}
+
+	if err := newRequestPacer(0).Wait(t.Context()); err != nil {
+		t.Errorf("unpaced Wait() = %v, expected nil", err)
+	}
+}
+
+func TestBackfillPUUIDs_Ladder(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "tft/league/v1/challenger.json", `{"tier":"CHALLENGER","entries":[{"puuid":"p2","leaguePoints":900},{"puuid":"p1","leaguePoints":1200}]}`)
+	writeFixture(t, dir, "tft/league/v1/grandmaster.json", `{"tier":"GRANDMASTER","entries":[{"puuid":"p1","leaguePoints":1200},{"puuid":"p3","leaguePoints":600},{"leaguePoints":500}]}`)
+
+	ingester := NewMatchIngester(&Config{}, nil, newCachedFixtureClient(dir), newTestLogger())
+	opts := BackfillOptions{Source: BackfillSourceLadder, Tiers: []string{"challenger", "grandmaster"}, Matches: 20}
+
+	puuids, err := ingester.backfillPUUIDs(t.Context(), opts, newRequestPacer(0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"p1", "p2", "p3"}; !slices.Equal(puuids, expected) {
+		t.Errorf("puuids = %v, expected %v", puuids, expected)
+	}
+}
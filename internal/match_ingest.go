@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type MatchIngestedEvent struct {
+	MatchID    string    `json:"matchId"`
+	PUUID      string    `json:"puuid"`
+	Region     string    `json:"region"`
+	Set        int       `json:"set"`
+	Placement  int       `json:"placement"`
+	PlayedAt   time.Time `json:"playedAt"`
+	IngestedAt time.Time `json:"ingestedAt"`
+}
+
+// matchIDsSince returns the IDs newer than lastSeen, oldest first. Riot lists
+// match IDs newest first, so everything before lastSeen is new; when lastSeen
+// is not in the window every ID is new.
+func matchIDsSince(matchIDs []string, lastSeen string) []string {
+	var newIDs []string
+	for _, matchID := range matchIDs {
+		if matchID == lastSeen {
+			break
+		}
+		newIDs = append(newIDs, matchID)
+	}
+
+	for i, j := 0, len(newIDs)-1; i < j; i, j = i+1, j-1 {
+		newIDs[i], newIDs[j] = newIDs[j], newIDs[i]
+	}
+	return newIDs
+}
+
+// MatchIngester archives the matches a player played since the last ingest
+// and emits one tft.match.ingested event per archived match.
+type MatchIngester struct {
+	db         *DatabaseManager
+	riotClient *RiotAPIClient
+	logger     *Logger
+	maxMatches int
+}
+
+func NewMatchIngester(cfg *Config, db *DatabaseManager, riotClient *RiotAPIClient, logger *Logger) *MatchIngester {
+	maxMatches := cfg.MatchIngestMaxMatches
+	if maxMatches < 1 || maxMatches > 200 {
+		maxMatches = 20
+	}
+
+	return &MatchIngester{
+		db:         db,
+		riotClient: riotClient,
+		logger:     logger,
+		maxMatches: maxMatches,
+	}
+}
+
+// Ingest processes new matches oldest first and advances the cursor after
+// each one, so a failure part way through resumes from the first match that
+// was not stored.
+func (mi *MatchIngester) Ingest(ctx context.Context, puuid string) (int, error) {
+	lastSeen, err := mi.db.GetMatchIngestCursor(ctx, puuid)
+	if err != nil {
+		return 0, err
+	}
+
+	matchIDs, err := mi.riotClient.GetRecentMatchIDs(ctx, puuid, mi.maxMatches)
+	if err != nil {
+		return 0, err
+	}
+
+	ingested := 0
+	for _, matchID := range matchIDsSince(matchIDs, lastSeen) {
+		match, err := mi.riotClient.GetMatch(ctx, matchID)
+		if err != nil {
+			return ingested, err
+		}
+
+		event := mi.ingestedEvent(puuid, match)
+		if err := mi.db.IngestMatch(ctx, puuid, match, event); err != nil {
+			return ingested, err
+		}
+		ingested++
+	}
+	return ingested, nil
+}
+
+func (mi *MatchIngester) ingestedEvent(puuid string, match *Match) MatchIngestedEvent {
+	event := MatchIngestedEvent{
+		MatchID:    match.Metadata.MatchID,
+		PUUID:      puuid,
+		Region:     mi.riotClient.region,
+		Set:        match.Info.TFTSetNumber,
+		PlayedAt:   match.PlayedAt(),
+		IngestedAt: time.Now().UTC(),
+	}
+	if participant := match.Participant(puuid); participant != nil {
+		event.Placement = participant.Placement
+	}
+	return event
+}
+
+func (nc *NATSClient) StartMatchIngestWorker(ingester *MatchIngester) error {
+	handler := func(msg *nats.Msg) {
+		ctx, span := startConsumerSpan(msg)
+		defer span.End()
+		nc.handleTask(ctx, "match_ingest", msg, func(ctx context.Context) error {
+			return ingester.process(ctx, msg)
+		})
+	}
+
+	if _, err := nc.startWorkerPool("match_ingest", []string{matchIngestSubject}, "match-ingest-workers", nc.matchPool, handler); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (mi *MatchIngester) process(ctx context.Context, msg *nats.Msg) error {
+	var task MatchIngestTask
+	if err := json.Unmarshal(msg.Data, &task); err != nil || task.PUUID == "" {
+		if err == nil {
+			err = errors.New("missing puuid")
+		}
+		mi.logger.Warn("match_ingest_invalid_task").
+			Component("match_ingest").
+			Operation("decode").
+			Err(err).
+			Log()
+		return permanentTask(err)
+	}
+
+	start := time.Now()
+	ingested, err := mi.Ingest(ctx, task.PUUID)
+	if err != nil {
+		mi.logger.Warn("match_ingest_failed").
+			Component("match_ingest").
+			Operation("ingest").
+			Game(task.PUUID, mi.riotClient.region, "").
+			Meta("ingested", ingested).
+			Err(err).
+			Duration(time.Since(start)).
+			Log()
+		return err
+	}
+
+	mi.logger.Info("match_ingest_completed").
+		Component("match_ingest").
+		Operation("ingest").
+		Game(task.PUUID, mi.riotClient.region, "").
+		Meta("ingested", ingested).
+		Duration(time.Since(start)).
+		Log()
+	return nil
+}
+
+// GetMatchIngestCursor returns the last match ingested for the player, or an
+// empty string when the player was never ingested.
+func (dm *DatabaseManager) GetMatchIngestCursor(ctx context.Context, puuid string) (string, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_match_ingest_cursor")
+	defer span.End()
+
+	var lastMatchID string
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		SELECT last_match_id FROM match_ingest_cursors WHERE puuid = $1
+	`), puuid).Scan(&lastMatchID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	recordSpanError(span, err)
+	return lastMatchID, err
+}
+
+// IngestMatch archives the match, enqueues its ingested event and moves the
+// player's cursor in one transaction.
+func (dm *DatabaseManager) IngestMatch(ctx context.Context, puuid string, match *Match, event MatchIngestedEvent) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "ingest_match")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := dm.archiveMatchTx(ctx, tx, match); err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	if err := dm.enqueueEvent(ctx, tx, matchIngestedSubject, event); err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO match_ingest_cursors (puuid, last_match_id, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (puuid) DO UPDATE SET
+			last_match_id = EXCLUDED.last_match_id,
+			updated_at = EXCLUDED.updated_at
+	`), puuid, match.Metadata.MatchID, event.IngestedAt)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	err = tx.Commit()
+	recordSpanError(span, err)
+	return err
+}
@@ -0,0 +1,29 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchIDsSince(t *testing.T) {
+	tests := []struct {
+		name     string
+		matchIDs []string
+		lastSeen string
+		expected []string
+	}{
+		{name: "first ingest", matchIDs: []string{"BR1_3", "BR1_2", "BR1_1"}, lastSeen: "", expected: []string{"BR1_1", "BR1_2", "BR1_3"}},
+		{name: "new matches since cursor", matchIDs: []string{"BR1_5", "BR1_4", "BR1_3", "BR1_2"}, lastSeen: "BR1_3", expected: []string{"BR1_4", "BR1_5"}},
+		{name: "nothing new", matchIDs: []string{"BR1_3", "BR1_2"}, lastSeen: "BR1_3", expected: nil},
+		{name: "cursor outside window", matchIDs: []string{"BR1_9", "BR1_8"}, lastSeen: "BR1_1", expected: []string{"BR1_8", "BR1_9"}},
+		{name: "no matches", matchIDs: nil, lastSeen: "BR1_1", expected: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := matchIDsSince(tt.matchIDs, tt.lastSeen); !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("matchIDsSince() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	memcacheVirtualNodes = 160
+	memcacheMaxKeyLength = 250
+	memcacheMaxIdleConns = 8
+	// Expirations above 30 days are read by memcached as unix timestamps.
+	memcacheMaxRelativeTTL = 30 * 24 * time.Hour
+)
+
+type hashRing struct {
+	points []uint32
+	nodes  map[uint32]string
+}
+
+// newHashRing places each node at memcacheVirtualNodes points so adding or
+// removing a server only remaps the keys that hashed to its points.
+func newHashRing(nodes []string) *hashRing {
+	ring := &hashRing{nodes: make(map[uint32]string)}
+	for _, node := range nodes {
+		for i := 0; i < memcacheVirtualNodes; i++ {
+			point := crc32.ChecksumIEEE([]byte(node + "-" + strconv.Itoa(i)))
+			if _, exists := ring.nodes[point]; exists {
+				continue
+			}
+			ring.nodes[point] = node
+			ring.points = append(ring.points, point)
+		}
+	}
+	sort.Slice(ring.points, func(i, j int) bool { return ring.points[i] < ring.points[j] })
+	return ring
+}
+
+func (hr *hashRing) Node(key string) string {
+	if len(hr.points) == 0 {
+		return ""
+	}
+	hash := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(hr.points), func(i int) bool { return hr.points[i] >= hash })
+	if i == len(hr.points) {
+		i = 0
+	}
+	return hr.nodes[hr.points[i]]
+}
+
+type memcacheNode struct {
+	addr    string
+	timeout time.Duration
+	idle    chan net.Conn
+}
+
+func (n *memcacheNode) conn(ctx context.Context) (net.Conn, error) {
+	select {
+	case conn := <-n.idle:
+		return conn, nil
+	default:
+	}
+	dialer := net.Dialer{Timeout: n.timeout}
+	return dialer.DialContext(ctx, "tcp", n.addr)
+}
+
+func (n *memcacheNode) release(conn net.Conn, healthy bool) {
+	if !healthy {
+		conn.Close()
+		return
+	}
+	select {
+	case n.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+// MemcacheBackend speaks the memcached text protocol to a fixed set of
+// servers, picking the server for each key from a consistent hash ring.
+type MemcacheBackend struct {
+	ring  *hashRing
+	nodes map[string]*memcacheNode
+}
+
+func parseMemcachedServers(value string) ([]string, error) {
+	var servers []string
+	for _, server := range strings.Split(value, ",") {
+		server = strings.TrimSpace(server)
+		if server == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return nil, fmt.Errorf("invalid memcached server %q: %w", server, err)
+		}
+		servers = append(servers, server)
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no memcached servers configured")
+	}
+	return servers, nil
+}
+
+func NewMemcacheBackend(servers []string, timeout time.Duration) *MemcacheBackend {
+	nodes := make(map[string]*memcacheNode, len(servers))
+	for _, server := range servers {
+		nodes[server] = &memcacheNode{addr: server, timeout: timeout, idle: make(chan net.Conn, memcacheMaxIdleConns)}
+	}
+	return &MemcacheBackend{ring: newHashRing(servers), nodes: nodes}
+}
+
+// memcacheKey maps keys memcached would reject (too long, whitespace or
+// control characters) to a stable digest.
+func memcacheKey(key string) string {
+	valid := len(key) <= memcacheMaxKeyLength
+	for i := 0; valid && i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			valid = false
+		}
+	}
+	if valid {
+		return key
+	}
+	sum := sha1.Sum([]byte(key))
+	return "tft:h:" + hex.EncodeToString(sum[:])
+}
+
+func memcacheExpiration(ttl time.Duration) int64 {
+	switch {
+	case ttl <= 0:
+		return 0
+	case ttl > memcacheMaxRelativeTTL:
+		return time.Now().Add(ttl).Unix()
+	case ttl < time.Second:
+		return 1
+	default:
+		return int64(ttl / time.Second)
+	}
+}
+
+func (mb *MemcacheBackend) do(ctx context.Context, key string, run func(rw *bufio.ReadWriter) error) error {
+	node := mb.nodes[mb.ring.Node(key)]
+	if node == nil {
+		return errors.New("no memcached servers configured")
+	}
+	return mb.doNode(ctx, node, run)
+}
+
+func (mb *MemcacheBackend) doNode(ctx context.Context, node *memcacheNode, run func(rw *bufio.ReadWriter) error) error {
+	conn, err := node.conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(node.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	conn.SetDeadline(deadline)
+
+	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
+	err = run(rw)
+
+	// Protocol-level answers leave the connection usable; anything else may
+	// have left unread bytes behind.
+	var serverErr *memcacheServerError
+	node.release(conn, err == nil || errors.Is(err, ErrCacheMiss) || errors.As(err, &serverErr))
+	return err
+}
+
+type memcacheServerError struct {
+	line string
+}
+
+func (e *memcacheServerError) Error() string {
+	return "memcached: " + e.line
+}
+
+func readMemcacheLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "ERROR" || strings.HasPrefix(line, "CLIENT_ERROR") || strings.HasPrefix(line, "SERVER_ERROR") {
+		return "", &memcacheServerError{line: line}
+	}
+	return line, nil
+}
+
+func (mb *MemcacheBackend) Get(ctx context.Context, key string) ([]byte, error) {
+	key = memcacheKey(key)
+
+	var value []byte
+	err := mb.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		fmt.Fprintf(rw, "get %s\r\n", key)
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		line, err := readMemcacheLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+		if line == "END" {
+			return ErrCacheMiss
+		}
+
+		// VALUE <key> <flags> <bytes>
+		fields := strings.Fields(line)
+		if len(fields) != 4 || fields[0] != "VALUE" {
+			return fmt.Errorf("memcached: unexpected response %q", line)
+		}
+		size, err := strconv.Atoi(fields[3])
+		if err != nil {
+			return fmt.Errorf("memcached: invalid value size %q", fields[3])
+		}
+
+		value = make([]byte, size+2)
+		if _, err := io.ReadFull(rw, value); err != nil {
+			return err
+		}
+		value = value[:size]
+
+		line, err = readMemcacheLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+		if line != "END" {
+			return fmt.Errorf("memcached: unexpected response %q", line)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// GetMulti sends one multi-key get per server holding any of the keys.
+func (mb *MemcacheBackend) GetMulti(ctx context.Context, keys []string) (map[string][]byte, error) {
+	byNode := make(map[string][]string)
+	original := make(map[string]string, len(keys))
+	for _, key := range keys {
+		mapped := memcacheKey(key)
+		original[mapped] = key
+		node := mb.ring.Node(mapped)
+		byNode[node] = append(byNode[node], mapped)
+	}
+
+	found := make(map[string][]byte, len(keys))
+	var errs []error
+	for addr, nodeKeys := range byNode {
+		node := mb.nodes[addr]
+		if node == nil {
+			return nil, errors.New("no memcached servers configured")
+		}
+		err := mb.doNode(ctx, node, func(rw *bufio.ReadWriter) error {
+			fmt.Fprintf(rw, "get %s\r\n", strings.Join(nodeKeys, " "))
+			if err := rw.Flush(); err != nil {
+				return err
+			}
+
+			for {
+				line, err := readMemcacheLine(rw.Reader)
+				if err != nil {
+					return err
+				}
+				if line == "END" {
+					return nil
+				}
+
+				fields := strings.Fields(line)
+				if len(fields) != 4 || fields[0] != "VALUE" {
+					return fmt.Errorf("memcached: unexpected response %q", line)
+				}
+				size, err := strconv.Atoi(fields[3])
+				if err != nil {
+					return fmt.Errorf("memcached: invalid value size %q", fields[3])
+				}
+				value := make([]byte, size+2)
+				if _, err := io.ReadFull(rw, value); err != nil {
+					return err
+				}
+				found[original[fields[1]]] = value[:size]
+			}
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// Values from healthy servers are still returned when another fails.
+	return found, errors.Join(errs...)
+}
+
+func (mb *MemcacheBackend) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	key = memcacheKey(key)
+
+	return mb.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		fmt.Fprintf(rw, "set %s 0 %d %d\r\n", key, memcacheExpiration(ttl), len(value))
+		rw.Write(value)
+		rw.WriteString("\r\n")
+		if err := rw.Flush(); err != nil {
+			return err
+		}
+
+		line, err := readMemcacheLine(rw.Reader)
+		if err != nil {
+			return err
+		}
+		if line != "STORED" {
+			return fmt.Errorf("memcached: unexpected response %q", line)
+		}
+		return nil
+	})
+}
+
+func (mb *MemcacheBackend) Delete(ctx context.Context, keys ...string) error {
+	var errs []error
+	for _, key := range keys {
+		key = memcacheKey(key)
+		err := mb.do(ctx, key, func(rw *bufio.ReadWriter) error {
+			fmt.Fprintf(rw, "delete %s\r\n", key)
+			if err := rw.Flush(); err != nil {
+				return err
+			}
+
+			line, err := readMemcacheLine(rw.Reader)
+			if err != nil {
+				return err
+			}
+			if line != "DELETED" && line != "NOT_FOUND" {
+				return fmt.Errorf("memcached: unexpected response %q", line)
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (mb *MemcacheBackend) Ping(ctx context.Context) error {
+	var errs []error
+	for addr, node := range mb.nodes {
+		err := mb.doNode(ctx, node, func(rw *bufio.ReadWriter) error {
+			rw.WriteString("version\r\n")
+			if err := rw.Flush(); err != nil {
+				return err
+			}
+
+			line, err := readMemcacheLine(rw.Reader)
+			if err != nil {
+				return err
+			}
+			if !strings.HasPrefix(line, "VERSION") {
+				return fmt.Errorf("unexpected response %q", line)
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+		}
+	}
+	return errors.Join(errs...)
+}
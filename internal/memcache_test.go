@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeMemcached implements the subset of the text protocol used by
+// MemcacheBackend.
+type fakeMemcached struct {
+	listener net.Listener
+	mu       sync.Mutex
+	items    map[string][]byte
+}
+
+func newFakeMemcached(t *testing.T) *fakeMemcached {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	fake := &fakeMemcached{listener: listener, items: make(map[string][]byte)}
+	go fake.serve()
+	t.Cleanup(func() { listener.Close() })
+	return fake
+}
+
+func (f *fakeMemcached) Addr() string {
+	return f.listener.Addr().String()
+}
+
+func (f *fakeMemcached) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeMemcached) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		f.mu.Lock()
+		switch fields[0] {
+		case "get":
+			for _, key := range fields[1:] {
+				if value, exists := f.items[key]; exists {
+					fmt.Fprintf(conn, "VALUE %s 0 %d\r\n%s\r\n", key, len(value), value)
+				}
+			}
+			io.WriteString(conn, "END\r\n")
+		case "set":
+			size, _ := strconv.Atoi(fields[4])
+			value := make([]byte, size+2)
+			io.ReadFull(r, value)
+			f.items[fields[1]] = value[:size]
+			io.WriteString(conn, "STORED\r\n")
+		case "delete":
+			if _, exists := f.items[fields[1]]; exists {
+				delete(f.items, fields[1])
+				io.WriteString(conn, "DELETED\r\n")
+			} else {
+				io.WriteString(conn, "NOT_FOUND\r\n")
+			}
+		case "version":
+			io.WriteString(conn, "VERSION 1.6.0\r\n")
+		default:
+			io.WriteString(conn, "ERROR\r\n")
+		}
+		f.mu.Unlock()
+	}
+}
+
+func TestMemcacheBackend_RoundTrip(t *testing.T) {
+	first := newFakeMemcached(t)
+	second := newFakeMemcached(t)
+	backend := NewMemcacheBackend([]string{first.Addr(), second.Addr()}, time.Second)
+	ctx := context.Background()
+
+	if err := backend.Ping(ctx); err != nil {
+		t.Fatalf("Ping() error = %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		key := fmt.Sprintf("tft:summoner:BR1:%d", i)
+		if err := backend.Set(ctx, key, []byte(`{"n":`+strconv.Itoa(i)+`}`), time.Minute); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+
+	value, err := backend.Get(ctx, "tft:summoner:BR1:7")
+	if err != nil || string(value) != `{"n":7}` {
+		t.Errorf("Get() = %q, %v; expected stored value", value, err)
+	}
+	if len(first.items) == 0 || len(second.items) == 0 {
+		t.Errorf("expected keys on both servers, got %d and %d", len(first.items), len(second.items))
+	}
+
+	values, err := backend.GetMulti(ctx, []string{"tft:summoner:BR1:3", "missing", "tft:summoner:BR1:12", "tft:summoner:BR1:7"})
+	if err != nil {
+		t.Fatalf("GetMulti() error = %v", err)
+	}
+	if len(values) != 3 || string(values["tft:summoner:BR1:3"]) != `{"n":3}` || string(values["tft:summoner:BR1:12"]) != `{"n":12}` {
+		t.Errorf("GetMulti() = %q, expected the three stored keys", values)
+	}
+
+	if err := backend.Delete(ctx, "tft:summoner:BR1:7", "missing"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := backend.Get(ctx, "tft:summoner:BR1:7"); err != ErrCacheMiss {
+		t.Errorf("Get() after delete error = %v, expected ErrCacheMiss", err)
+	}
+}
+
+func TestHashRing_Stability(t *testing.T) {
+	before := newHashRing([]string{"a:11211", "b:11211", "c:11211"})
+	after := newHashRing([]string{"a:11211", "b:11211", "c:11211", "d:11211"})
+
+	moved := 0
+	for i := 0; i < 1000; i++ {
+		key := "key-" + strconv.Itoa(i)
+		from, to := before.Node(key), after.Node(key)
+		if from != to {
+			if to != "d:11211" {
+				t.Fatalf("key %s moved from %s to %s, expected only moves to the new node", key, from, to)
+			}
+			moved++
+		}
+	}
+
+	if moved == 0 || moved > 400 {
+		t.Errorf("moved %d of 1000 keys, expected roughly a quarter", moved)
+	}
+}
+
+func TestMemcacheKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		hashed bool
+	}{
+		{name: "plain key", key: "tft:summoner:BR1:abc", hashed: false},
+		{name: "whitespace", key: "tft:response:/a b", hashed: true},
+		{name: "too long", key: strings.Repeat("k", 251), hashed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := memcacheKey(tt.key)
+			if (result != tt.key) != tt.hashed {
+				t.Errorf("memcacheKey(%q) = %q, hashed expected %v", tt.key, result, tt.hashed)
+			}
+			if len(result) > memcacheMaxKeyLength {
+				t.Errorf("memcacheKey() length = %d", len(result))
+			}
+		})
+	}
+}
+
+func TestMemcacheExpiration(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		expected int64
+	}{
+		{name: "no expiry", ttl: 0, expected: 0},
+		{name: "sub second rounds up", ttl: 200 * time.Millisecond, expected: 1},
+		{name: "relative seconds", ttl: 5 * time.Minute, expected: 300},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := memcacheExpiration(tt.ttl); got != tt.expected {
+				t.Errorf("memcacheExpiration(%v) = %d, expected %d", tt.ttl, got, tt.expected)
+			}
+		})
+	}
+
+	if got := memcacheExpiration(60 * 24 * time.Hour); got < time.Now().Unix() {
+		t.Errorf("expected absolute timestamp for long ttl, got %d", got)
+	}
+}
+
+func TestParseMemcachedServers(t *testing.T) {
+	servers, err := parseMemcachedServers("mc1:11211, mc2:11211")
+	if err != nil || len(servers) != 2 || servers[1] != "mc2:11211" {
+		t.Errorf("parseMemcachedServers() = %v, %v", servers, err)
+	}
+	if _, err := parseMemcachedServers("mc1"); err == nil {
+		t.Error("expected error for server without port")
+	}
+	if _, err := parseMemcachedServers(" "); err == nil {
+		t.Error("expected error for empty list")
+	}
+}
@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	compKindTrait = "trait"
+	compKindUnit  = "unit"
+)
+
+// MatchComp is a trait or unit a participant fielded in a match.
+type MatchComp struct {
+	Kind string
+	Name string
+}
+
+// participantComps lists the active traits and the distinct units on a
+// participant's final board; inactive traits only mean a single unit of the
+// trait was placed.
+func participantComps(participant MatchParticipant) []MatchComp {
+	comps := make([]MatchComp, 0, len(participant.Traits)+len(participant.Units))
+	for _, trait := range participant.Traits {
+		if trait.Name != "" && trait.TierCurrent > 0 {
+			comps = append(comps, MatchComp{Kind: compKindTrait, Name: trait.Name})
+		}
+	}
+	seen := make(map[string]bool, len(participant.Units))
+	for _, unit := range participant.Units {
+		if unit.CharacterID == "" || seen[unit.CharacterID] {
+			continue
+		}
+		seen[unit.CharacterID] = true
+		comps = append(comps, MatchComp{Kind: compKindUnit, Name: unit.CharacterID})
+	}
+	return comps
+}
+
+// CompUsage is how often a player fielded a comp among their archived matches.
+type CompUsage struct {
+	PUUID            string
+	Games            int
+	TotalGames       int
+	AveragePlacement float64
+	Top4             int
+}
+
+// MetaPlayer is a ladder player who plays the requested comp.
+type MetaPlayer struct {
+	PUUID            string  `json:"puuid"`
+	Position         int     `json:"position"`
+	LeaguePoints     int     `json:"leaguePoints"`
+	Games            int     `json:"games"`
+	TotalGames       int     `json:"totalGames"`
+	PlayRate         float64 `json:"playRate"`
+	AveragePlacement float64 `json:"averagePlacement"`
+	Top4Rate         float64 `json:"top4Rate"`
+}
+
+type MetaTopPlayers struct {
+	Region     string       `json:"region"`
+	Tier       string       `json:"tier"`
+	Kind       string       `json:"kind"`
+	Name       string       `json:"name"`
+	Since      time.Time    `json:"since"`
+	MinGames   int          `json:"minGames"`
+	SnapshotAt *time.Time   `json:"snapshotAt,omitempty"`
+	Players    []MetaPlayer `json:"players"`
+}
+
+// GetCompUsage aggregates, per player, the archived matches since since in
+// which the comp was fielded, along with the player's total archived matches
+// over the same window.
+func (dm *DatabaseManager) GetCompUsage(ctx context.Context, kind, name string, since time.Time) ([]CompUsage, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_comp_usage")
+	defer span.End()
+
+	rows, err := dm.queryAnalytics(ctx, dm.rebind(`
+		SELECT c.puuid, COUNT(*), AVG(mp.placement),
+			SUM(CASE WHEN mp.placement <= 4 THEN 1 ELSE 0 END),
+			(SELECT COUNT(*) FROM match_participants t WHERE t.puuid = c.puuid AND t.played_at >= $3)
+		FROM match_participant_comps c
+		JOIN match_participants mp ON mp.puuid = c.puuid AND mp.match_id = c.match_id
+		WHERE c.kind = $1 AND c.name = $2 AND c.played_at >= $3
+		GROUP BY c.puuid
+	`), kind, name, since)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var usage []CompUsage
+	for rows.Next() {
+		var u CompUsage
+		if err := rows.Scan(&u.PUUID, &u.Games, &u.AveragePlacement, &u.Top4, &u.TotalGames); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+	err = rows.Err()
+	recordSpanError(span, err)
+	return usage, err
+}
+
+// rankMetaPlayers keeps the ladder players with at least minGames with the
+// comp and orders them by ladder position, best first. Positions follow LP
+// order in the snapshot.
+func rankMetaPlayers(ladder []LadderSnapshotEntry, usage []CompUsage, minGames, limit int) []MetaPlayer {
+	byPUUID := make(map[string]CompUsage, len(usage))
+	for _, u := range usage {
+		byPUUID[u.PUUID] = u
+	}
+
+	sorted := append([]LadderSnapshotEntry(nil), ladder...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].LeaguePoints > sorted[j].LeaguePoints })
+
+	players := []MetaPlayer{}
+	for i, entry := range sorted {
+		u, ok := byPUUID[entry.PUUID]
+		if !ok || u.Games < minGames {
+			continue
+		}
+		total := max(u.TotalGames, u.Games)
+		players = append(players, MetaPlayer{
+			PUUID:            entry.PUUID,
+			Position:         i + 1,
+			LeaguePoints:     entry.LeaguePoints,
+			Games:            u.Games,
+			TotalGames:       total,
+			PlayRate:         float64(u.Games) / float64(total),
+			AveragePlacement: u.AveragePlacement,
+			Top4Rate:         float64(u.Top4) / float64(u.Games),
+		})
+		if len(players) == limit {
+			break
+		}
+	}
+	return players
+}
+
+// MetaTopPlayers lists the best placed players on the latest ladder snapshot
+// of region who fielded the comp in at least minGames archived matches since
+// since. Only matches archived by the watchlist count, so players outside it
+// do not show up however often they play the comp.
+func (dm *DatabaseManager) MetaTopPlayers(ctx context.Context, region, kind, name string, since time.Time, minGames, limit int) (*MetaTopPlayers, error) {
+	result := &MetaTopPlayers{
+		Region:   region,
+		Tier:     ladderSnapshotTier,
+		Kind:     kind,
+		Name:     name,
+		Since:    since,
+		MinGames: minGames,
+		Players:  []MetaPlayer{},
+	}
+
+	snapshot, err := dm.GetLeagueSnapshot(ctx, region, ladderSnapshotTier, time.Now().UTC(), false)
+	if err != nil || snapshot == nil {
+		return result, err
+	}
+	result.SnapshotAt = &snapshot.TakenAt
+
+	usage, err := dm.GetCompUsage(ctx, kind, name, since)
+	if err != nil {
+		return nil, err
+	}
+	result.Players = rankMetaPlayers(snapshot.Entries, usage, minGames, limit)
+	return result, nil
+}
@@ -0,0 +1,65 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParticipantComps(t *testing.T) {
+	participant := MatchParticipant{
+		PUUID:     "a",
+		Placement: 2,
+		Traits: []MatchTrait{
+			{Name: "TFT13_Sniper", NumUnits: 4, TierCurrent: 2},
+			{Name: "TFT13_Bruiser", NumUnits: 1, TierCurrent: 0},
+		},
+		Units: []MatchUnit{
+			{CharacterID: "TFT13_Jinx"},
+			{CharacterID: "TFT13_Vi"},
+			{CharacterID: "TFT13_Jinx"},
+		},
+	}
+
+	expected := []MatchComp{
+		{Kind: compKindTrait, Name: "TFT13_Sniper"},
+		{Kind: compKindUnit, Name: "TFT13_Jinx"},
+		{Kind: compKindUnit, Name: "TFT13_Vi"},
+	}
+	if got := participantComps(participant); !reflect.DeepEqual(got, expected) {
+		t.Errorf("participantComps() = %v, expected %v", got, expected)
+	}
+}
+
+func TestRankMetaPlayers(t *testing.T) {
+	ladder := []LadderSnapshotEntry{
+		{PUUID: "third", LeaguePoints: 900},
+		{PUUID: "first", LeaguePoints: 1500},
+		{PUUID: "second", LeaguePoints: 1200},
+		{PUUID: "fourth", LeaguePoints: 800},
+	}
+	usage := []CompUsage{
+		{PUUID: "third", Games: 6, TotalGames: 10, AveragePlacement: 3.5, Top4: 4},
+		{PUUID: "first", Games: 2, TotalGames: 20, AveragePlacement: 1, Top4: 2},
+		{PUUID: "fourth", Games: 5, TotalGames: 5, AveragePlacement: 4, Top4: 3},
+		{PUUID: "off-ladder", Games: 30, TotalGames: 30, AveragePlacement: 2, Top4: 25},
+	}
+
+	players := rankMetaPlayers(ladder, usage, 3, 10)
+	if len(players) != 2 {
+		t.Fatalf("rankMetaPlayers() = %+v, expected third and fourth only", players)
+	}
+	third := players[0]
+	if third.PUUID != "third" || third.Position != 3 || third.PlayRate != 0.6 || third.Top4Rate != 4.0/6 {
+		t.Errorf("players[0] = %+v, expected third at position 3 with 60%% play rate", third)
+	}
+	if players[1].PUUID != "fourth" || players[1].Position != 4 {
+		t.Errorf("players[1] = %+v, expected fourth at position 4", players[1])
+	}
+
+	if players := rankMetaPlayers(ladder, usage, 1, 1); len(players) != 1 || players[0].PUUID != "first" {
+		t.Errorf("rankMetaPlayers(limit 1) = %+v, expected only the ladder leader", players)
+	}
+	if players := rankMetaPlayers(nil, usage, 1, 10); players == nil || len(players) != 0 {
+		t.Errorf("rankMetaPlayers(no ladder) = %#v, expected an empty list", players)
+	}
+}
@@ -1,31 +1,71 @@
 package internal
 
 import (
-	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type MetricsCollector struct {
 	logger *Logger
+	clock  Clock
 
 	requestCount     map[string]int64
-	requestDuration  map[string][]int64
+	requestDuration  map[string]*LatencyHistogram
 	cacheHits        int64
 	cacheMisses      int64
+	cacheEarly       int64
 	apiErrors        map[string]int64
+	requestBytes     map[string]int64
+	responseBytes    map[string]int64
 	workerQueueDepth map[string]int64
+	countingSince    time.Time
+	snapshotStore    *CacheManager
+	snapshotKey      string
+	snapshotStop     chan struct{}
+	snapshotWG       sync.WaitGroup
+	redisProvider    *RedisProvider
+	slo              *SLOTracker
+	inFlight         int64
+	upstreamCalls    []time.Time
+	verification     map[string]*VerificationStats
+	endpointInUse    map[string]int64
+	endpointRejected map[string]int64
+	requestTimeouts  map[string]int64
+	panics           map[string]int64
+	prunedCacheKeys  map[string]int64
+	connections      map[string]*ConnectionStats
+	apiKeyRequests   map[string]int64
+	deprecatedUsage  map[string]map[string]int64
+	dedupedTasks     map[string]int64
+	deadLetters      map[string]int64
 
 	mu sync.RWMutex
 }
 
-func NewMetricsCollector(logger *Logger) *MetricsCollector {
+func NewMetricsCollector(logger *Logger, clock Clock) *MetricsCollector {
+	clock = clockOrSystem(clock)
 	mc := &MetricsCollector{
 		logger:           logger,
+		clock:            clock,
 		requestCount:     make(map[string]int64),
-		requestDuration:  make(map[string][]int64),
+		requestDuration:  make(map[string]*LatencyHistogram),
 		apiErrors:        make(map[string]int64),
+		requestBytes:     make(map[string]int64),
+		responseBytes:    make(map[string]int64),
 		workerQueueDepth: make(map[string]int64),
+		verification:     make(map[string]*VerificationStats),
+		endpointInUse:    make(map[string]int64),
+		endpointRejected: make(map[string]int64),
+		requestTimeouts:  make(map[string]int64),
+		panics:           make(map[string]int64),
+		prunedCacheKeys:  make(map[string]int64),
+		connections:      make(map[string]*ConnectionStats),
+		apiKeyRequests:   make(map[string]int64),
+		deprecatedUsage:  make(map[string]map[string]int64),
+		dedupedTasks:     make(map[string]int64),
+		deadLetters:      make(map[string]int64),
+		countingSince:    clock.Now().UTC(),
 	}
 
 	go mc.startMetricsReporter()
@@ -37,12 +77,23 @@ func (mc *MetricsCollector) RecordRequest(endpoint string, duration time.Duratio
 	defer mc.mu.Unlock()
 
 	mc.requestCount[endpoint]++
-	mc.requestDuration[endpoint] = append(mc.requestDuration[endpoint], duration.Milliseconds())
+	histogram, exists := mc.requestDuration[endpoint]
+	if !exists {
+		histogram = NewLatencyHistogram()
+		mc.requestDuration[endpoint] = histogram
+	}
+	histogram.Observe(duration.Milliseconds())
 
 	if statusCode >= 400 {
 		mc.apiErrors[endpoint]++
 	}
 
+	if endpoint == "riot_api" {
+		mc.recordUpstreamCall(mc.clock.Now())
+	} else if mc.slo != nil {
+		mc.slo.Record(endpoint, duration, statusCode)
+	}
+
 	mc.logger.Info("request_completed").
 		Component("metrics").
 		Operation("record_request").
@@ -52,6 +103,40 @@ func (mc *MetricsCollector) RecordRequest(endpoint string, duration time.Duratio
 		Log()
 }
 
+// RecordTransfer adds the body sizes of a request served by endpoint, so
+// bandwidth-heavy endpoints stand out in /metrics.
+func (mc *MetricsCollector) RecordTransfer(endpoint string, requestBytes, responseBytes int64) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.requestBytes[endpoint] += requestBytes
+	mc.responseBytes[endpoint] += responseBytes
+}
+
+func (mc *MetricsCollector) IncInFlight() {
+	atomic.AddInt64(&mc.inFlight, 1)
+}
+
+func (mc *MetricsCollector) DecInFlight() {
+	atomic.AddInt64(&mc.inFlight, -1)
+}
+
+func (mc *MetricsCollector) recordUpstreamCall(now time.Time) {
+	mc.upstreamCalls = append(mc.upstreamCalls, now)
+	mc.pruneUpstreamCalls(now)
+}
+
+func (mc *MetricsCollector) pruneUpstreamCalls(now time.Time) {
+	window := riotRateLimits[len(riotRateLimits)-1].window
+	cutoff := now.Add(-window)
+
+	idx := 0
+	for idx < len(mc.upstreamCalls) && mc.upstreamCalls[idx].Before(cutoff) {
+		idx++
+	}
+	mc.upstreamCalls = mc.upstreamCalls[idx:]
+}
+
 func (mc *MetricsCollector) RecordCacheHit(key string) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -78,6 +163,21 @@ func (mc *MetricsCollector) RecordCacheMiss(key string) {
 		Log()
 }
 
+// RecordCacheEarlyRefresh counts reads that found the key but refreshed it
+// ahead of its expiry; they count as neither hits nor misses.
+func (mc *MetricsCollector) RecordCacheEarlyRefresh(key string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.cacheEarly++
+
+	mc.logger.Debug("cache_early_refresh").
+		Component("metrics").
+		Operation("record_cache").
+		Cache(true, key).
+		Log()
+}
+
 func (mc *MetricsCollector) RecordWorkerQueueDepth(workerType string, depth int) {
 	mc.mu.Lock()
 	defer mc.mu.Unlock()
@@ -91,11 +191,184 @@ func (mc *MetricsCollector) RecordWorkerQueueDepth(workerType string, depth int)
 		Log()
 }
 
+func (mc *MetricsCollector) RecordEndpointConcurrency(class string, inUse, limit int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.endpointInUse[class] = int64(inUse)
+
+	if inUse >= limit {
+		mc.logger.Debug("endpoint_concurrency_saturated").
+			Component("metrics").
+			Operation("record_concurrency").
+			Meta("class", class).
+			Meta("limit", limit).
+			Log()
+	}
+}
+
+func (mc *MetricsCollector) RecordEndpointRejection(class, reason string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.endpointRejected[class+":"+reason]++
+}
+
+func (mc *MetricsCollector) RecordRequestTimeout(path string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.requestTimeouts[path]++
+}
+
+func (mc *MetricsCollector) RecordPanic(path string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.panics[path]++
+}
+
+func (mc *MetricsCollector) RecordDeduplicatedTask(subject string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.dedupedTasks[subject]++
+}
+
+func (mc *MetricsCollector) RecordDeadLetter(worker string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.deadLetters[worker]++
+}
+
+func (mc *MetricsCollector) RecordCachePrune(keyType string, deleted int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.prunedCacheKeys[keyType] += int64(deleted)
+}
+
+type ConnectionStats struct {
+	New             int64   `json:"new"`
+	Reused          int64   `json:"reused"`
+	ReusedIdle      int64   `json:"reused_idle"`
+	ReuseRate       float64 `json:"reuse_rate"`
+	AvgNewWaitMs    float64 `json:"avg_new_wait_ms"`
+	AvgReusedWaitMs float64 `json:"avg_reused_wait_ms"`
+
+	newWait    time.Duration
+	reusedWait time.Duration
+}
+
+func (mc *MetricsCollector) RecordOutboundConnection(target string, reused, wasIdle bool, wait time.Duration) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	stats, exists := mc.connections[target]
+	if !exists {
+		stats = &ConnectionStats{}
+		mc.connections[target] = stats
+	}
+
+	if reused {
+		stats.Reused++
+		stats.reusedWait += wait
+		if wasIdle {
+			stats.ReusedIdle++
+		}
+		stats.AvgReusedWaitMs = float64(stats.reusedWait.Microseconds()) / 1000 / float64(stats.Reused)
+	} else {
+		stats.New++
+		stats.newWait += wait
+		stats.AvgNewWaitMs = float64(stats.newWait.Microseconds()) / 1000 / float64(stats.New)
+	}
+	stats.ReuseRate = float64(stats.Reused) / float64(stats.Reused+stats.New)
+}
+
+func (mc *MetricsCollector) RecordAPIKeyRequest(keyID string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.apiKeyRequests[keyID]++
+}
+
+func (mc *MetricsCollector) APIKeyRequests(keyID string) int64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+
+	return mc.apiKeyRequests[keyID]
+}
+
+// maxDeprecatedUsageConsumers caps the consumers counted per deprecated
+// field. The User-Agent is client-controlled, so past the cap new consumers
+// are counted together under deprecatedUsageOther.
+const maxDeprecatedUsageConsumers = 100
+
+const deprecatedUsageOther = "other"
+
+func (mc *MetricsCollector) RecordDeprecatedFieldUsage(field, consumer string) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	consumers, exists := mc.deprecatedUsage[field]
+	if !exists {
+		consumers = make(map[string]int64)
+		mc.deprecatedUsage[field] = consumers
+	}
+	if _, tracked := consumers[consumer]; !tracked && len(consumers) >= maxDeprecatedUsageConsumers {
+		consumer = deprecatedUsageOther
+	}
+	consumers[consumer]++
+}
+
+func (mc *MetricsCollector) copyDeprecatedUsage() map[string]map[string]int64 {
+	result := make(map[string]map[string]int64, len(mc.deprecatedUsage))
+	for field, consumers := range mc.deprecatedUsage {
+		result[field] = copyCounters(consumers)
+	}
+	return result
+}
+
+type VerificationStats struct {
+	Sampled   int64     `json:"sampled"`
+	Drifted   int64     `json:"drifted"`
+	DriftRate float64   `json:"drift_rate"`
+	LastRunAt time.Time `json:"last_run_at"`
+}
+
+func (mc *MetricsCollector) RecordCacheVerification(kind string, sampled, drifted int) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	stats, exists := mc.verification[kind]
+	if !exists {
+		stats = &VerificationStats{}
+		mc.verification[kind] = stats
+	}
+
+	stats.Sampled += int64(sampled)
+	stats.Drifted += int64(drifted)
+	if stats.Sampled > 0 {
+		stats.DriftRate = float64(stats.Drifted) / float64(stats.Sampled)
+	}
+	stats.LastRunAt = mc.clock.Now().UTC()
+
+	mc.logger.Debug("cache_verification_recorded").
+		Component("metrics").
+		Operation("record_verification").
+		Meta("kind", kind).
+		Meta("sampled", sampled).
+		Meta("drifted", drifted).
+		Meta("drift_rate", stats.DriftRate).
+		Log()
+}
+
 func (mc *MetricsCollector) startMetricsReporter() {
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := mc.clock.NewTicker(1 * time.Minute)
 	defer ticker.Stop()
 
-	for range ticker.C {
+	for range ticker.C() {
 		mc.reportMetrics()
 	}
 }
@@ -123,13 +396,13 @@ func (mc *MetricsCollector) reportMetrics() {
 }
 
 func (mc *MetricsCollector) reportEndpointPerformance() {
-	for endpoint, durations := range mc.requestDuration {
-		if len(durations) == 0 {
+	for endpoint, histogram := range mc.requestDuration {
+		if histogram.Count() == 0 {
 			continue
 		}
 
-		avg := mc.calculateAverage(durations)
-		p95 := mc.calculatePercentile(durations, 0.95)
+		avg := histogram.Average()
+		p95 := histogram.Percentile(0.95)
 
 		mc.logger.Info("endpoint_performance").
 			Component("metrics").
@@ -139,6 +412,8 @@ func (mc *MetricsCollector) reportEndpointPerformance() {
 			Meta("avg_duration_ms", avg).
 			Meta("p95_duration_ms", p95).
 			Meta("error_count", mc.apiErrors[endpoint]).
+			Meta("request_bytes", mc.requestBytes[endpoint]).
+			Meta("response_bytes", mc.responseBytes[endpoint]).
 			Log()
 	}
 }
@@ -159,46 +434,107 @@ func (mc *MetricsCollector) calculateCacheHitRate() float64 {
 	return float64(mc.cacheHits) / float64(total) * 100
 }
 
-func (mc *MetricsCollector) calculateAverage(values []int64) float64 {
-	if len(values) == 0 {
-		return 0
+func (mc *MetricsCollector) copyLatency() map[string]HistogramSnapshot {
+	result := make(map[string]HistogramSnapshot, len(mc.requestDuration))
+	for endpoint, histogram := range mc.requestDuration {
+		result[endpoint] = histogram.Snapshot()
 	}
-
-	sum := int64(0)
-	for _, v := range values {
-		sum += v
-	}
-
-	return float64(sum) / float64(len(values))
+	return result
 }
 
-func (mc *MetricsCollector) calculatePercentile(values []int64, percentile float64) int64 {
-	if len(values) == 0 {
-		return 0
-	}
-
-	sortedValues := make([]int64, len(values))
-	copy(sortedValues, values)
-	sort.Slice(sortedValues, func(i, j int) bool {
-		return sortedValues[i] < sortedValues[j]
-	})
+func (mc *MetricsCollector) SetRedisProvider(provider *RedisProvider) {
+	mc.mu.Lock()
+	mc.redisProvider = provider
+	mc.mu.Unlock()
+}
 
-	index := int(percentile * float64(len(sortedValues)-1))
-	return sortedValues[index]
+// SetSLOTracker feeds every served request, outbound Riot calls excluded,
+// to tracker.
+func (mc *MetricsCollector) SetSLOTracker(tracker *SLOTracker) {
+	mc.mu.Lock()
+	mc.slo = tracker
+	mc.mu.Unlock()
 }
 
 func (mc *MetricsCollector) GetMetrics() map[string]interface{} {
 	mc.mu.RLock()
 	defer mc.mu.RUnlock()
 
-	return map[string]interface{}{
+	metrics := map[string]interface{}{
 		"cache": map[string]interface{}{
-			"hits":     mc.cacheHits,
-			"misses":   mc.cacheMisses,
-			"hit_rate": mc.calculateCacheHitRate(),
+			"hits":            mc.cacheHits,
+			"misses":          mc.cacheMisses,
+			"hit_rate":        mc.calculateCacheHitRate(),
+			"early_refreshes": mc.cacheEarly,
+		},
+		"requests": copyCounters(mc.requestCount),
+		"errors":   copyCounters(mc.apiErrors),
+		"latency":  mc.copyLatency(),
+		"bytes": map[string]interface{}{
+			"request":  copyCounters(mc.requestBytes),
+			"response": copyCounters(mc.responseBytes),
 		},
-		"requests":     mc.requestCount,
-		"errors":       mc.apiErrors,
-		"queue_depths": mc.workerQueueDepth,
+		"queue_depths": copyCounters(mc.workerQueueDepth),
+		"verification": mc.copyVerification(),
+		"endpoint_limits": map[string]interface{}{
+			"in_use":   copyCounters(mc.endpointInUse),
+			"rejected": copyCounters(mc.endpointRejected),
+		},
+		"timeouts":           copyCounters(mc.requestTimeouts),
+		"panics":             copyCounters(mc.panics),
+		"pruned_cache_keys":  copyCounters(mc.prunedCacheKeys),
+		"connections":        mc.copyConnections(),
+		"api_keys":           copyCounters(mc.apiKeyRequests),
+		"deprecations":       mc.copyDeprecatedUsage(),
+		"deduplicated_tasks": copyCounters(mc.dedupedTasks),
+		"dead_letters":       copyCounters(mc.deadLetters),
+		"counting_since":     mc.countingSince,
+	}
+	if mc.redisProvider != nil {
+		metrics["redis_pool"] = mc.redisProvider.Stats()
+	}
+	return metrics
+}
+
+func (mc *MetricsCollector) copyVerification() map[string]VerificationStats {
+	result := make(map[string]VerificationStats, len(mc.verification))
+	for kind, stats := range mc.verification {
+		result[kind] = *stats
+	}
+	return result
+}
+
+func (mc *MetricsCollector) copyConnections() map[string]ConnectionStats {
+	result := make(map[string]ConnectionStats, len(mc.connections))
+	for target, stats := range mc.connections {
+		result[target] = *stats
+	}
+	return result
+}
+
+func (mc *MetricsCollector) GetScalingSignals() map[string]interface{} {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.pruneUpstreamCalls(mc.clock.Now())
+	budget := riotRateLimits[len(riotRateLimits)-1]
+
+	durations := NewLatencyHistogram()
+	for endpoint, histogram := range mc.requestDuration {
+		if endpoint == "riot_api" {
+			continue
+		}
+		durations.Merge(histogram)
+	}
+
+	return map[string]interface{}{
+		"in_flight_requests":      atomic.LoadInt64(&mc.inFlight),
+		"queue_depth_total":       mc.sumMapValues(mc.workerQueueDepth),
+		"queue_depths":            copyCounters(mc.workerQueueDepth),
+		"riot_budget_used":        len(mc.upstreamCalls),
+		"riot_budget_limit":       budget.requests,
+		"riot_budget_utilization": float64(len(mc.upstreamCalls)) / float64(budget.requests),
+		"p95_latency_ms":          durations.Percentile(0.95),
+		"timestamp":               mc.clock.Now().Unix(),
 	}
 }
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// metricsSnapshotTTL lets the snapshot of an instance that is never started
+// again (a renamed or removed replica) expire instead of staying in Redis.
+const metricsSnapshotTTL = 7 * 24 * time.Hour
+
+type MetricsSnapshot struct {
+	RequestCount  map[string]int64 `json:"requestCount"`
+	APIErrors     map[string]int64 `json:"apiErrors"`
+	RequestBytes  map[string]int64 `json:"requestBytes,omitempty"`
+	ResponseBytes map[string]int64 `json:"responseBytes,omitempty"`
+	CacheHits     int64            `json:"cacheHits"`
+	CacheMisses   int64            `json:"cacheMisses"`
+	CountingSince time.Time        `json:"countingSince"`
+	SavedAt       time.Time        `json:"savedAt"`
+}
+
+// instanceName identifies this process among the replicas sharing Redis.
+func instanceName() string {
+	host, _ := os.Hostname()
+	if host == "" {
+		host = "unknown"
+	}
+	return host
+}
+
+// EnablePersistence restores and then periodically saves the counters under
+// a key scoped to instance, so replicas sharing Redis do not overwrite or
+// double count each other's totals. The instance comes from
+// METRICS_INSTANCE_ID rather than the hostname, which changes on every
+// redeploy. validate() rejects a non-positive METRICS_SNAPSHOT_INTERVAL.
+func (mc *MetricsCollector) EnablePersistence(cache *CacheManager, interval time.Duration, instance string) {
+	mc.mu.Lock()
+	mc.snapshotStore = cache
+	mc.snapshotKey = cache.Key("metrics", "snapshot", instance)
+	mc.mu.Unlock()
+
+	mc.restoreSnapshot(context.Background())
+
+	stop := make(chan struct{})
+	mc.mu.Lock()
+	mc.snapshotStop = stop
+	mc.mu.Unlock()
+
+	mc.snapshotWG.Add(1)
+	go func() {
+		defer mc.snapshotWG.Done()
+		ticker := mc.clock.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C():
+				mc.SaveSnapshot(context.Background())
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	mc.logger.Info("metrics_persistence_enabled").
+		Component("metrics").
+		Operation("enable_persistence").
+		Meta("interval", interval.String()).
+		Meta("instance", instance).
+		Log()
+}
+
+// StopPersistence stops the periodic saves and writes a final snapshot. It
+// is a no-op when persistence was never enabled.
+func (mc *MetricsCollector) StopPersistence(ctx context.Context) {
+	mc.mu.Lock()
+	stop := mc.snapshotStop
+	mc.snapshotStop = nil
+	mc.mu.Unlock()
+
+	if stop == nil {
+		return
+	}
+	close(stop)
+	mc.snapshotWG.Wait()
+	mc.SaveSnapshot(ctx)
+}
+
+func (mc *MetricsCollector) SaveSnapshot(ctx context.Context) {
+	mc.mu.RLock()
+	store, key := mc.snapshotStore, mc.snapshotKey
+	snapshot := MetricsSnapshot{
+		RequestCount:  copyCounters(mc.requestCount),
+		APIErrors:     copyCounters(mc.apiErrors),
+		RequestBytes:  copyCounters(mc.requestBytes),
+		ResponseBytes: copyCounters(mc.responseBytes),
+		CacheHits:     mc.cacheHits,
+		CacheMisses:   mc.cacheMisses,
+		CountingSince: mc.countingSince,
+		SavedAt:       mc.clock.Now().UTC(),
+	}
+	mc.mu.RUnlock()
+
+	if store == nil {
+		return
+	}
+
+	if err := store.Set(ctx, key, snapshot, metricsSnapshotTTL); err != nil {
+		mc.logger.Error("metrics_snapshot_save_failed").
+			Component("metrics").
+			Operation("save_snapshot").
+			Err(err).
+			Log()
+		return
+	}
+
+	mc.logger.Debug("metrics_snapshot_saved").
+		Component("metrics").
+		Operation("save_snapshot").
+		Log()
+}
+
+func (mc *MetricsCollector) restoreSnapshot(ctx context.Context) {
+	var snapshot MetricsSnapshot
+	if err := mc.snapshotStore.Get(ctx, mc.snapshotKey, &snapshot); err != nil {
+		mc.logger.Info("metrics_snapshot_not_found").
+			Component("metrics").
+			Operation("restore_snapshot").
+			Log()
+		return
+	}
+
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	mc.applySnapshot(snapshot)
+
+	mc.logger.Info("metrics_snapshot_restored").
+		Component("metrics").
+		Operation("restore_snapshot").
+		Meta("saved_at", snapshot.SavedAt).
+		Meta("counting_since", mc.countingSince).
+		Log()
+}
+
+func (mc *MetricsCollector) applySnapshot(snapshot MetricsSnapshot) {
+	for endpoint, count := range snapshot.RequestCount {
+		mc.requestCount[endpoint] += count
+	}
+	for endpoint, count := range snapshot.APIErrors {
+		mc.apiErrors[endpoint] += count
+	}
+	for endpoint, count := range snapshot.RequestBytes {
+		mc.requestBytes[endpoint] += count
+	}
+	for endpoint, count := range snapshot.ResponseBytes {
+		mc.responseBytes[endpoint] += count
+	}
+	mc.cacheHits += snapshot.CacheHits
+	mc.cacheMisses += snapshot.CacheMisses
+
+	if !snapshot.CountingSince.IsZero() && snapshot.CountingSince.Before(mc.countingSince) {
+		mc.countingSince = snapshot.CountingSince
+	}
+}
+
+func copyCounters(m map[string]int64) map[string]int64 {
+	result := make(map[string]int64, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
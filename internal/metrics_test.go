@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMetricsCollector_RiotBudgetWindow(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	metrics := NewMetricsCollector(newTestLogger(), clock)
+	window := riotRateLimits[len(riotRateLimits)-1].window
+
+	metrics.RecordRequest("riot_api", 10*time.Millisecond, 200)
+	clock.Advance(window / 2)
+	metrics.RecordRequest("riot_api", 10*time.Millisecond, 200)
+
+	if used := metrics.GetScalingSignals()["riot_budget_used"]; used != 2 {
+		t.Errorf("riot_budget_used = %v, expected 2 within the window", used)
+	}
+	clock.Advance(window/2 + time.Second)
+	if used := metrics.GetScalingSignals()["riot_budget_used"]; used != 1 {
+		t.Errorf("riot_budget_used = %v, expected the first call to leave the window", used)
+	}
+}
+
+func TestMetricsCollector_Reporter(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	NewMetricsCollector(logger, clock)
+	clock.waitForTimers(t, 1)
+
+	clock.Advance(time.Minute)
+	deadline := time.Now().Add(time.Second)
+	for logs.Count("metrics_report") == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	logs.Expect(t, expectedEvent{Message: "metrics_report", Component: "metrics", Operation: "report"})
+}
+
+func TestMetricsCollector_PersistencePerInstance(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	cache, backend := newPersistentCache(clock, nil)
+
+	replicas := map[string]int{"api-a": 3, "api-b": 5}
+	for instance, requests := range replicas {
+		metrics := NewMetricsCollector(newTestLogger(), clock)
+		metrics.EnablePersistence(cache, time.Minute, instance)
+		for i := 0; i < requests; i++ {
+			metrics.RecordRequest("/league/challenger", time.Millisecond, 200)
+		}
+		metrics.SaveSnapshot(t.Context())
+	}
+	if _, err := backend.Get(t.Context(), "tft:metrics:snapshot:api-a"); err != nil {
+		t.Errorf("expected the snapshot under the instance key: %v", err)
+	}
+
+	for instance, requests := range replicas {
+		restored := NewMetricsCollector(newTestLogger(), clock)
+		restored.EnablePersistence(cache, time.Minute, instance)
+		restored.mu.RLock()
+		got := restored.requestCount["/league/challenger"]
+		restored.mu.RUnlock()
+		if got != int64(requests) {
+			t.Errorf("%s restored %d requests, expected only its own %d", instance, got, requests)
+		}
+	}
+}
+
+func TestMetricsCollector_PersistenceSavesOnInterval(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	cache, backend := newPersistentCache(clock, nil)
+	metrics := NewMetricsCollector(newTestLogger(), clock)
+	clock.waitForTimers(t, 1)
+
+	metrics.EnablePersistence(cache, 30*time.Second, "api-a")
+	clock.waitForTimers(t, 2)
+	metrics.RecordRequest("/league/challenger", time.Millisecond, 200)
+	clock.Advance(30 * time.Second)
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		if _, err := backend.Get(t.Context(), "tft:metrics:snapshot:api-a"); err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a snapshot to be saved after one interval")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMetricsCollector_StopPersistence(t *testing.T) {
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	cache, backend := newPersistentCache(clock, nil)
+	metrics := NewMetricsCollector(newTestLogger(), clock)
+
+	metrics.EnablePersistence(cache, time.Minute, "api-a")
+	metrics.RecordRequest("/league/challenger", time.Millisecond, 200)
+	metrics.StopPersistence(t.Context())
+	// A second stop, as from a deferred call after an explicit one, is a no-op.
+	metrics.StopPersistence(t.Context())
+
+	key := "tft:metrics:snapshot:api-a"
+	if _, err := backend.Get(t.Context(), key); err != nil {
+		t.Fatalf("expected a final snapshot on stop: %v", err)
+	}
+	if ttl := backend.ttl(key); ttl != metricsSnapshotTTL {
+		t.Errorf("snapshot ttl = %v, expected %v", ttl, metricsSnapshotTTL)
+	}
+}
+
+func TestMetricsCollector_DeprecatedUsageCapsConsumers(t *testing.T) {
+	metrics := NewMetricsCollector(newTestLogger(), nil)
+	field := "/league/challenger#summonerName"
+
+	for i := 0; i < maxDeprecatedUsageConsumers+5; i++ {
+		metrics.RecordDeprecatedFieldUsage(field, "ua:client-"+strconv.Itoa(i))
+	}
+	metrics.RecordDeprecatedFieldUsage(field, "ua:client-0")
+
+	usage := metrics.copyDeprecatedUsage()[field]
+	if len(usage) > maxDeprecatedUsageConsumers+1 {
+		t.Errorf("tracked %d consumers, expected at most %d plus %q", len(usage), maxDeprecatedUsageConsumers, deprecatedUsageOther)
+	}
+	if usage[deprecatedUsageOther] != 5 {
+		t.Errorf("%s = %d, expected the 5 consumers past the cap", deprecatedUsageOther, usage[deprecatedUsageOther])
+	}
+	if usage["ua:client-0"] != 2 {
+		t.Errorf("ua:client-0 = %d, expected a tracked consumer to keep counting", usage["ua:client-0"])
+	}
+}
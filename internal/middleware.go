@@ -2,10 +2,15 @@ package internal
 
 import (
 	"context"
+	"io"
 	"net/http"
 	"time"
 
-	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type contextKey string
@@ -18,21 +23,35 @@ const (
 type LoggingMiddleware struct {
 	logger  *Logger
 	metrics *MetricsCollector
+	clock   Clock
+	ids     IDGenerator
 }
 
-func NewLoggingMiddleware(logger *Logger, metrics *MetricsCollector) *LoggingMiddleware {
+func NewLoggingMiddleware(logger *Logger, metrics *MetricsCollector, clock Clock, ids IDGenerator) *LoggingMiddleware {
 	return &LoggingMiddleware{
 		logger:  logger,
 		metrics: metrics,
+		clock:   clockOrSystem(clock),
+		ids:     idsOrUUID(ids),
 	}
 }
 
 func (lm *LoggingMiddleware) Handler(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		startTime := time.Now()
-		requestID := uuid.New().String()
-
-		ctx := context.WithValue(r.Context(), RequestIDKey, requestID)
+		startTime := lm.clock.Now()
+		requestID := lm.ids.NewID()
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		route := requestRoute(r)
+		ctx, span := tracer().Start(ctx, r.Method+" "+route, trace.WithSpanKind(trace.SpanKindServer))
+		defer span.End()
+		span.SetAttributes(
+			attribute.String("http.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("request.id", requestID),
+		)
+
+		ctx = context.WithValue(ctx, RequestIDKey, requestID)
 		ctx = context.WithValue(ctx, StartTimeKey, startTime)
 		r = r.WithContext(ctx)
 
@@ -43,32 +62,67 @@ func (lm *LoggingMiddleware) Handler(next http.HandlerFunc) http.HandlerFunc {
 			Request(r.UserAgent(), r.RemoteAddr, requestID).
 			Log()
 
+		if lm.metrics != nil {
+			lm.metrics.IncInFlight()
+			defer lm.metrics.DecInFlight()
+		}
+
 		wrapped := &responseWriter{
 			ResponseWriter: w,
 			statusCode:     http.StatusOK,
 		}
+		var body *countingBody
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &countingBody{ReadCloser: r.Body}
+			r.Body = body
+		}
 
 		next(wrapped, r)
 
-		duration := time.Since(startTime)
+		requestBytes := body.size(r.ContentLength)
+
+		duration := lm.clock.Now().Sub(startTime)
+		span.SetAttributes(
+			attribute.Int("http.status_code", wrapped.statusCode),
+			attribute.Int64("http.request.body.size", requestBytes),
+			attribute.Int64("http.response.body.size", wrapped.bytesWritten),
+		)
+		if wrapped.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(wrapped.statusCode))
+		}
 
 		lm.logger.Info("request_completed").
 			Component("http").
 			Operation("handle_request").
 			HTTP(r.Method, r.URL.Path, wrapped.statusCode).
 			Request(r.UserAgent(), r.RemoteAddr, requestID).
+			Bytes(requestBytes, wrapped.bytesWritten).
 			Duration(duration).
 			Log()
 
 		if lm.metrics != nil {
-			lm.metrics.RecordRequest(r.URL.Path, duration, wrapped.statusCode)
+			lm.metrics.RecordRequest(route, duration, wrapped.statusCode)
+			lm.metrics.RecordTransfer(route, requestBytes, wrapped.bytesWritten)
 		}
 	}
 }
 
+// requestRoute names the route serving r for spans, metrics, timeouts and
+// deprecations. It is the pattern the mux matched, e.g.
+// /players/{gameName}/{tagLine} or /schemas/, so each player or file does not
+// become a route of its own; for an exact route that is its path. Handlers
+// called outside the mux fall back to the path.
+func requestRoute(r *http.Request) string {
+	if r.Pattern != "" {
+		return r.Pattern
+	}
+	return r.URL.Path
+}
+
 type responseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int64
 }
 
 func (rw *responseWriter) WriteHeader(code int) {
@@ -76,6 +130,39 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytesWritten += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the connection's Flush and
+// deadlines through the wrapper.
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// countingBody counts the request body bytes the handler reads.
+type countingBody struct {
+	io.ReadCloser
+	read int64
+}
+
+func (cb *countingBody) Read(p []byte) (int, error) {
+	n, err := cb.ReadCloser.Read(p)
+	cb.read += int64(n)
+	return n, err
+}
+
+// size is the larger of what was read and the declared Content-Length, so a
+// body the handler rejected without reading still counts.
+func (cb *countingBody) size(contentLength int64) int64 {
+	if cb == nil {
+		return 0
+	}
+	return max(cb.read, contentLength)
+}
+
 func GetRequestID(ctx context.Context) string {
 	if id, ok := ctx.Value(RequestIDKey).(string); ok {
 		return id
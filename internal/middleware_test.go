@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoggingMiddleware_TracksBytes(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	metrics := NewMetricsCollector(logger, nil)
+	middleware := NewLoggingMiddleware(logger, metrics, nil, nil)
+
+	handler := middleware.Handler(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		w.Write([]byte(`{"status":"ok"}`))
+		w.Write([]byte("\n"))
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/watchlist", strings.NewReader(`{"puuid":"abc"}`))
+		handler(httptest.NewRecorder(), req)
+	}
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/watchlist", nil))
+
+	entry := logs.Expect(t, expectedEvent{Message: "request_completed", Component: "http"})
+	if entry.RequestBytes != 15 || entry.ResponseBytes != 16 {
+		t.Errorf("entry bytes = %d/%d, expected 15 in and 16 out", entry.RequestBytes, entry.ResponseBytes)
+	}
+
+	bytes := metrics.GetMetrics()["bytes"].(map[string]interface{})
+	if got := bytes["request"].(map[string]int64)["/watchlist"]; got != 30 {
+		t.Errorf("request bytes = %d, expected 30", got)
+	}
+	if got := bytes["response"].(map[string]int64)["/watchlist"]; got != 48 {
+		t.Errorf("response bytes = %d, expected 48", got)
+	}
+}
+
+func TestLoggingMiddleware_UnreadBodyCountsContentLength(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	handler := NewLoggingMiddleware(logger, nil, nil, nil).Handler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/watchlist/import", strings.NewReader(strings.Repeat("x", 64))))
+
+	entry := logs.Expect(t, expectedEvent{Message: "request_completed", Component: "http"})
+	if entry.RequestBytes != 64 || entry.ResponseBytes != 0 || entry.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("entry = %+v, expected the declared 64 bytes and an empty 413", entry)
+	}
+}
+
+type fixedID string
+
+func (id fixedID) NewID() string { return string(id) }
+
+func TestLoggingMiddleware_ClockAndRequestID(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	handler := NewLoggingMiddleware(logger, nil, clock, fixedID("req-1")).Handler(func(w http.ResponseWriter, r *http.Request) {
+		if id := GetRequestID(r.Context()); id != "req-1" {
+			t.Errorf("request ID = %q, expected req-1", id)
+		}
+		clock.Advance(250 * time.Millisecond)
+	})
+
+	handler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	entry := logs.Expect(t, expectedEvent{Message: "request_completed", Component: "http"})
+	if entry.RequestID != "req-1" || entry.Duration != 250 {
+		t.Errorf("entry request ID %q, duration %dms, expected req-1 and 250ms", entry.RequestID, entry.Duration)
+	}
+}
+
+func TestResponseWriter_Unwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	wrapped := &responseWriter{ResponseWriter: rec, statusCode: http.StatusOK}
+
+	if err := http.NewResponseController(wrapped).Flush(); err != nil {
+		t.Fatalf("Flush() through the wrapper error = %v", err)
+	}
+	if !rec.Flushed {
+		t.Error("recorder was not flushed")
+	}
+}
@@ -0,0 +1,473 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+const (
+	SchemaMismatchRefuse   = "refuse"
+	SchemaMismatchReadOnly = "read-only"
+)
+
+var ErrSchemaMismatch = errors.New("schema version mismatch")
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_summoner_cache",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS summoner_cache (
+				puuid        VARCHAR(100) PRIMARY KEY,
+				game_name    VARCHAR(100) NOT NULL,
+				tag_line     VARCHAR(20)  NOT NULL,
+				summoner_id  VARCHAR(100),
+				region       VARCHAR(10)  NOT NULL,
+				last_updated TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 2,
+		Name:    "create_crawl_pages",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS crawl_pages (
+				region        VARCHAR(10) NOT NULL,
+				tier          VARCHAR(20) NOT NULL,
+				division      VARCHAR(5)  NOT NULL,
+				page          INTEGER     NOT NULL,
+				entries_count INTEGER     NOT NULL,
+				has_more      BOOLEAN     NOT NULL,
+				crawled_at    TIMESTAMP   NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (region, tier, division, page)
+			)`,
+	},
+	{
+		Version: 3,
+		Name:    "create_riot_id_lookups",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS riot_id_lookups (
+				normalized_key VARCHAR(130) PRIMARY KEY,
+				puuid          VARCHAR(100) NOT NULL,
+				game_name      VARCHAR(100) NOT NULL,
+				tag_line       VARCHAR(20)  NOT NULL,
+				updated_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 4,
+		Name:    "create_riot_request_journal",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS riot_request_journal (
+				id                      BIGSERIAL PRIMARY KEY,
+				method                  VARCHAR(100) NOT NULL,
+				url                     TEXT         NOT NULL,
+				status_code             INTEGER      NOT NULL,
+				latency_ms              INTEGER      NOT NULL,
+				app_rate_limit          VARCHAR(100),
+				app_rate_limit_count    VARCHAR(100),
+				method_rate_limit       VARCHAR(100),
+				method_rate_limit_count VARCHAR(100),
+				retry_after             VARCHAR(20),
+				requested_at            TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_riot_request_journal_method_time
+				ON riot_request_journal (method, requested_at)`,
+	},
+	{
+		Version: 5,
+		Name:    "create_watchlist",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS watchlist (
+				puuid             VARCHAR(100) PRIMARY KEY,
+				region            VARCHAR(10)  NOT NULL,
+				tier              VARCHAR(20)  NOT NULL DEFAULT '',
+				rank              VARCHAR(5)   NOT NULL DEFAULT '',
+				league_points     INTEGER      NOT NULL DEFAULT 0,
+				wins              INTEGER      NOT NULL DEFAULT 0,
+				losses            INTEGER      NOT NULL DEFAULT 0,
+				last_match_id     VARCHAR(50)  NOT NULL DEFAULT '',
+				added_at          TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				last_refreshed_at TIMESTAMP
+			)`,
+	},
+	{
+		Version: 6,
+		Name:    "create_matches",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS matches (
+				match_id      VARCHAR(50)  PRIMARY KEY,
+				set_number    INTEGER      NOT NULL DEFAULT 0,
+				game_version  VARCHAR(100) NOT NULL DEFAULT '',
+				played_at     TIMESTAMP    NOT NULL,
+				data          JSONB        NOT NULL,
+				archived_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_matches_participants ON matches USING GIN ((data->'metadata'->'participants'));
+			CREATE INDEX IF NOT EXISTS idx_matches_played_at ON matches (played_at DESC)`,
+	},
+	{
+		Version: 7,
+		Name:    "create_api_keys",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS api_keys (
+				id          BIGSERIAL    PRIMARY KEY,
+				name        VARCHAR(100) NOT NULL,
+				role        VARCHAR(20)  NOT NULL,
+				key_hash    CHAR(64)     NOT NULL UNIQUE,
+				prefix      VARCHAR(12)  NOT NULL,
+				created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				revoked_at  TIMESTAMP
+			)`,
+	},
+	{
+		Version: 8,
+		Name:    "create_match_participants",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS match_participants (
+				puuid      VARCHAR(78) NOT NULL,
+				match_id   VARCHAR(50) NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+				placement  INTEGER     NOT NULL,
+				played_at  TIMESTAMP   NOT NULL,
+				PRIMARY KEY (puuid, match_id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_match_participants_puuid_played_at ON match_participants (puuid, played_at DESC);
+			CREATE INDEX IF NOT EXISTS idx_match_participants_match_id ON match_participants (match_id);
+			INSERT INTO match_participants (puuid, match_id, placement, played_at)
+			SELECT p->>'puuid', m.match_id, (p->>'placement')::INTEGER, m.played_at
+			FROM matches m, jsonb_array_elements(m.data->'info'->'participants') AS p
+			WHERE COALESCE((p->>'placement')::INTEGER, 0) > 0
+			ON CONFLICT DO NOTHING`,
+	},
+	{
+		Version: 9,
+		Name:    "create_event_outbox",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS event_outbox (
+				id           BIGSERIAL    PRIMARY KEY,
+				subject      VARCHAR(100) NOT NULL,
+				payload      JSONB        NOT NULL,
+				attempts     INTEGER      NOT NULL DEFAULT 0,
+				last_error   TEXT,
+				created_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				published_at TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox (id) WHERE published_at IS NULL`,
+	},
+	{
+		Version: 10,
+		Name:    "add_watchlist_mini_series",
+		SQL:     `ALTER TABLE watchlist ADD COLUMN IF NOT EXISTS mini_series VARCHAR(10) NOT NULL DEFAULT ''`,
+	},
+	{
+		Version: 11,
+		Name:    "create_summoner_profile_history",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS summoner_profile_history (
+				id              BIGSERIAL    PRIMARY KEY,
+				puuid           VARCHAR(100) NOT NULL,
+				region          VARCHAR(10)  NOT NULL,
+				profile_icon_id INTEGER      NOT NULL,
+				summoner_level  INTEGER      NOT NULL,
+				observed_at     TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_summoner_profile_history_puuid ON summoner_profile_history (puuid, id DESC)`,
+	},
+	{
+		Version: 12,
+		Name:    "create_player_verifications",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS player_verifications (
+				key_id          VARCHAR(100) NOT NULL,
+				puuid           VARCHAR(100) NOT NULL,
+				region          VARCHAR(10)  NOT NULL,
+				code            VARCHAR(20)  NOT NULL,
+				profile_icon_id INTEGER      NOT NULL,
+				status          VARCHAR(10)  NOT NULL DEFAULT 'pending',
+				created_at      TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				expires_at      TIMESTAMP    NOT NULL,
+				verified_at     TIMESTAMP,
+				PRIMARY KEY (key_id, puuid)
+			)`,
+	},
+	{
+		Version: 13,
+		Name:    "create_watchlist_subscriptions",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS watchlist_subscriptions (
+				tenant_id VARCHAR(100) NOT NULL,
+				puuid     VARCHAR(100) NOT NULL REFERENCES watchlist (puuid) ON DELETE CASCADE,
+				added_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (tenant_id, puuid)
+			);
+			CREATE INDEX IF NOT EXISTS idx_watchlist_subscriptions_puuid ON watchlist_subscriptions (puuid);
+			INSERT INTO watchlist_subscriptions (tenant_id, puuid, added_at)
+			SELECT 'anonymous', puuid, added_at FROM watchlist
+			ON CONFLICT DO NOTHING`,
+	},
+	{
+		Version: 14,
+		Name:    "create_match_ingest_cursors",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS match_ingest_cursors (
+				puuid         VARCHAR(100) PRIMARY KEY,
+				last_match_id VARCHAR(50)  NOT NULL,
+				updated_at    TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 15,
+		Name:    "create_watchlist_imports",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS watchlist_import_jobs (
+				id          BIGSERIAL    PRIMARY KEY,
+				tenant_id   VARCHAR(100) NOT NULL,
+				status      VARCHAR(20)  NOT NULL,
+				total       INTEGER      NOT NULL,
+				created_at  TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				finished_at TIMESTAMP
+			);
+			CREATE TABLE IF NOT EXISTS watchlist_import_rows (
+				job_id     BIGINT       NOT NULL REFERENCES watchlist_import_jobs (id) ON DELETE CASCADE,
+				row_number INTEGER      NOT NULL,
+				riot_id    TEXT         NOT NULL,
+				status     VARCHAR(20)  NOT NULL,
+				puuid      VARCHAR(100),
+				error      TEXT,
+				PRIMARY KEY (job_id, row_number)
+			)`,
+	},
+	{
+		Version: 16,
+		Name:    "create_league_snapshots",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS league_snapshots (
+				id       BIGSERIAL   PRIMARY KEY,
+				region   VARCHAR(10) NOT NULL,
+				tier     VARCHAR(20) NOT NULL,
+				taken_at TIMESTAMP   NOT NULL,
+				entries  JSONB       NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_league_snapshots_lookup ON league_snapshots (region, tier, taken_at DESC)`,
+	},
+	{
+		Version: 17,
+		Name:    "create_league_points_history",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS league_points_history (
+				id            BIGSERIAL    PRIMARY KEY,
+				puuid         VARCHAR(100) NOT NULL,
+				region        VARCHAR(10)  NOT NULL,
+				tier          VARCHAR(20)  NOT NULL,
+				rank          VARCHAR(5)   NOT NULL,
+				league_points INTEGER      NOT NULL,
+				wins          INTEGER      NOT NULL,
+				losses        INTEGER      NOT NULL,
+				observed_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_league_points_history_puuid ON league_points_history (puuid, id DESC)`,
+	},
+	{
+		Version: 18,
+		Name:    "create_league_last_known_good",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS league_last_known_good (
+				region     VARCHAR(10) NOT NULL,
+				tier       VARCHAR(20) NOT NULL,
+				league     JSONB       NOT NULL,
+				fetched_at TIMESTAMP   NOT NULL,
+				PRIMARY KEY (region, tier)
+			)`,
+	},
+	{
+		Version: 19,
+		Name:    "index_summoner_cache_last_updated",
+		SQL:     `CREATE INDEX IF NOT EXISTS idx_summoner_cache_updated ON summoner_cache (last_updated)`,
+	},
+	{
+		Version: 20,
+		Name:    "create_match_participant_comps",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS match_participant_comps (
+				kind       VARCHAR(5)   NOT NULL,
+				name       VARCHAR(100) NOT NULL,
+				puuid      VARCHAR(78)  NOT NULL,
+				match_id   VARCHAR(50)  NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+				played_at  TIMESTAMP    NOT NULL,
+				PRIMARY KEY (kind, name, puuid, match_id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_match_participant_comps_match_id ON match_participant_comps (match_id);
+			INSERT INTO match_participant_comps (kind, name, puuid, match_id, played_at)
+			SELECT 'trait', t->>'name', p->>'puuid', m.match_id, m.played_at
+			FROM matches m, jsonb_array_elements(m.data->'info'->'participants') AS p,
+				jsonb_array_elements(COALESCE(p->'traits', '[]'::jsonb)) AS t
+			WHERE COALESCE((p->>'placement')::INTEGER, 0) > 0 AND COALESCE((t->>'tier_current')::INTEGER, 0) > 0
+			ON CONFLICT DO NOTHING;
+			INSERT INTO match_participant_comps (kind, name, puuid, match_id, played_at)
+			SELECT 'unit', u->>'character_id', p->>'puuid', m.match_id, m.played_at
+			FROM matches m, jsonb_array_elements(m.data->'info'->'participants') AS p,
+				jsonb_array_elements(COALESCE(p->'units', '[]'::jsonb)) AS u
+			WHERE COALESCE((p->>'placement')::INTEGER, 0) > 0 AND COALESCE(u->>'character_id', '') <> ''
+			ON CONFLICT DO NOTHING`,
+	},
+	{
+		Version: 21,
+		Name:    "create_scheduled_jobs",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS scheduled_jobs (
+				name         VARCHAR(100) NOT NULL PRIMARY KEY,
+				next_run_at  TIMESTAMP    NOT NULL,
+				last_run_at  TIMESTAMP,
+				last_error   TEXT,
+				updated_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 22,
+		Name:    "create_dead_letter_tasks",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS dead_letter_tasks (
+				id          BIGSERIAL    PRIMARY KEY,
+				subject     VARCHAR(100) NOT NULL,
+				worker      VARCHAR(50)  NOT NULL,
+				payload     TEXT         NOT NULL,
+				attempts    INTEGER      NOT NULL,
+				last_error  TEXT         NOT NULL,
+				failed_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				requeued_at TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_dead_letter_tasks_pending ON dead_letter_tasks (id) WHERE requeued_at IS NULL`,
+	},
+	{
+		Version: 23,
+		Name:    "create_cache_snapshots",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS cache_snapshots (
+				cache_key  VARCHAR(255) NOT NULL PRIMARY KEY,
+				value      TEXT         NOT NULL,
+				fetched_at TIMESTAMP    NOT NULL
+			)`,
+	},
+	{
+		Version: 24,
+		Name:    "add_watchlist_import_claims",
+		SQL:     `ALTER TABLE watchlist_import_jobs ADD COLUMN IF NOT EXISTS claimed_until TIMESTAMP`,
+	},
+	{
+		Version: 25,
+		Name:    "index_cache_snapshots_fetched_at",
+		SQL:     `CREATE INDEX IF NOT EXISTS idx_cache_snapshots_fetched_at ON cache_snapshots (fetched_at)`,
+	},
+}
+
+func LatestSchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+func (dm *DatabaseManager) Migrate(ctx context.Context) error {
+	if !dm.Enabled {
+		return nil
+	}
+
+	_, err := dm.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			name       VARCHAR(100) NOT NULL,
+			applied_at TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`)
+	if err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	current, err := dm.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, migration := range dm.migrations() {
+		if migration.Version <= current {
+			continue
+		}
+
+		if err := dm.applyMigration(ctx, migration); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Applied migration %d: %s", migration.Version, migration.Name)
+	}
+
+	return nil
+}
+
+// CheckSchemaCompatibility compares the version recorded in schema_migrations
+// with the newest migration compiled into this binary. Both an older schema
+// (migrations not applied) and a newer one (binary older than the last deploy)
+// are reported as ErrSchemaMismatch.
+func (dm *DatabaseManager) CheckSchemaCompatibility(ctx context.Context) error {
+	exists, err := dm.schemaMigrationsExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	current := 0
+	if exists {
+		if current, err = dm.SchemaVersion(ctx); err != nil {
+			return err
+		}
+	}
+
+	available := dm.migrations()
+	return schemaCompatibility(current, available[len(available)-1].Version)
+}
+
+func schemaCompatibility(current, expected int) error {
+	if current != expected {
+		return fmt.Errorf("%w: database is at version %d, binary expects %d", ErrSchemaMismatch, current, expected)
+	}
+	return nil
+}
+
+func (dm *DatabaseManager) schemaMigrationsExists(ctx context.Context) (bool, error) {
+	query := `SELECT to_regclass('schema_migrations') IS NOT NULL`
+	if dm.Dialect == DatabaseDriverSQLite {
+		query = `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`
+	}
+
+	var exists bool
+	err := dm.DB.QueryRowContext(ctx, query).Scan(&exists)
+	return exists, err
+}
+
+func (dm *DatabaseManager) SchemaVersion(ctx context.Context) (int, error) {
+	var version int
+	err := dm.DB.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
+
+func (dm *DatabaseManager) applyMigration(ctx context.Context, migration Migration) error {
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, migration.SQL); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		dm.rebind(`INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`),
+		migration.Version, migration.Name,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
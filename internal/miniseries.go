@@ -0,0 +1,83 @@
+package internal
+
+import "strings"
+
+const (
+	MiniSeriesInProgress = "in_progress"
+	MiniSeriesWon        = "won"
+	MiniSeriesLost       = "lost"
+
+	PromotionStarted  = "started"
+	PromotionPromoted = "promoted"
+	PromotionFailed   = "failed"
+)
+
+// annotate expands Riot's progress string, one character per game (W win,
+// L loss, N or - not played yet), into per-game results and what is left.
+func (ms *MiniSeries) annotate() {
+	progress := strings.ToUpper(ms.Progress)
+
+	games := make([]string, 0, len(progress))
+	wins, losses := 0, 0
+	for _, game := range progress {
+		switch game {
+		case 'W':
+			wins++
+			games = append(games, "win")
+		case 'L':
+			losses++
+			games = append(games, "loss")
+		default:
+			games = append(games, "pending")
+		}
+	}
+
+	if ms.Target == 0 {
+		ms.Target = len(progress)/2 + 1
+	}
+	if ms.Wins == 0 && ms.Losses == 0 {
+		ms.Wins, ms.Losses = wins, losses
+	}
+
+	ms.Games = games
+	ms.GamesLeft = len(progress) - ms.Wins - ms.Losses
+	if ms.GamesLeft < 0 {
+		ms.GamesLeft = 0
+	}
+	ms.WinsNeeded = ms.Target - ms.Wins
+	if ms.WinsNeeded < 0 {
+		ms.WinsNeeded = 0
+	}
+
+	switch {
+	case ms.Wins >= ms.Target:
+		ms.Status = MiniSeriesWon
+	case ms.WinsNeeded > ms.GamesLeft:
+		ms.Status = MiniSeriesLost
+	default:
+		ms.Status = MiniSeriesInProgress
+	}
+}
+
+func annotateMiniSeries(entries []LeagueEntry) {
+	for i := range entries {
+		if entries[i].MiniSeries != nil {
+			entries[i].MiniSeries.annotate()
+		}
+	}
+}
+
+// promotionTransition reports whether a watched player entered or left a
+// promotion series between two refreshes. Riot drops miniSeries once the
+// series ends, so the outcome is read from whether the rank moved.
+func promotionTransition(previousProgress, currentProgress string, rankMoved bool) string {
+	switch {
+	case previousProgress == "" && currentProgress != "":
+		return PromotionStarted
+	case previousProgress != "" && currentProgress == "" && rankMoved:
+		return PromotionPromoted
+	case previousProgress != "" && currentProgress == "":
+		return PromotionFailed
+	}
+	return ""
+}
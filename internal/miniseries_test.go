@@ -0,0 +1,84 @@
+package internal
+
+import "testing"
+
+func TestMiniSeries_Annotate(t *testing.T) {
+	tests := []struct {
+		name       string
+		series     MiniSeries
+		games      []string
+		winsNeeded int
+		gamesLeft  int
+		status     string
+	}{
+		{
+			name:       "best of five in progress",
+			series:     MiniSeries{Target: 3, Wins: 1, Losses: 1, Progress: "WLNNN"},
+			games:      []string{"win", "loss", "pending", "pending", "pending"},
+			winsNeeded: 2,
+			gamesLeft:  3,
+			status:     MiniSeriesInProgress,
+		},
+		{
+			name:       "target and counts derived from progress",
+			series:     MiniSeries{Progress: "WW-"},
+			games:      []string{"win", "win", "pending"},
+			winsNeeded: 0,
+			gamesLeft:  1,
+			status:     MiniSeriesWon,
+		},
+		{
+			name:       "cannot reach target",
+			series:     MiniSeries{Target: 2, Wins: 0, Losses: 2, Progress: "LLN"},
+			games:      []string{"loss", "loss", "pending"},
+			winsNeeded: 2,
+			gamesLeft:  1,
+			status:     MiniSeriesLost,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series := tt.series
+			series.annotate()
+
+			if len(series.Games) != len(tt.games) {
+				t.Fatalf("Games = %v, expected %v", series.Games, tt.games)
+			}
+			for i := range tt.games {
+				if series.Games[i] != tt.games[i] {
+					t.Errorf("Games = %v, expected %v", series.Games, tt.games)
+					break
+				}
+			}
+			if series.WinsNeeded != tt.winsNeeded || series.GamesLeft != tt.gamesLeft || series.Status != tt.status {
+				t.Errorf("got winsNeeded=%d gamesLeft=%d status=%s, expected %d %d %s",
+					series.WinsNeeded, series.GamesLeft, series.Status, tt.winsNeeded, tt.gamesLeft, tt.status)
+			}
+		})
+	}
+}
+
+func TestPromotionTransition(t *testing.T) {
+	tests := []struct {
+		name      string
+		previous  string
+		current   string
+		rankMoved bool
+		expected  string
+	}{
+		{name: "no series", expected: ""},
+		{name: "series starts", current: "NNN", expected: PromotionStarted},
+		{name: "series continues", previous: "NNN", current: "WNN", expected: ""},
+		{name: "series won", previous: "WLN", rankMoved: true, expected: PromotionPromoted},
+		{name: "series lost", previous: "WLN", expected: PromotionFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := promotionTransition(tt.previous, tt.current, tt.rankMoved); got != tt.expected {
+				t.Errorf("promotionTransition(%q, %q, %v) = %q, expected %q", tt.previous, tt.current, tt.rankMoved, got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,5 +1,10 @@
 package internal
 
+import (
+	"fmt"
+	"time"
+)
+
 type LeagueEntry struct {
 	LeagueID     string      `json:"leagueId"`
 	PUUID        string      `json:"puuid"`
@@ -16,6 +21,11 @@ type LeagueEntry struct {
 	FreshBlood   bool        `json:"freshBlood"`
 	Inactive     bool        `json:"inactive"`
 	MiniSeries   *MiniSeries `json:"miniSeries,omitempty"`
+	NamePending  bool        `json:"namePending,omitempty"`
+
+	// Set only with ?include=profile.
+	SummonerLevel *int `json:"summonerLevel,omitempty"`
+	ProfileIconID *int `json:"profileIconId,omitempty"`
 }
 
 func (le *LeagueEntry) GetUniqueID() string {
@@ -29,34 +39,48 @@ func (le *LeagueEntry) GetUniqueID() string {
 }
 
 type MiniSeries struct {
-	Target   int    `json:"target"`
-	Wins     int    `json:"wins"`
-	Losses   int    `json:"losses"`
-	Progress string `json:"progress"`
+	Target     int      `json:"target"`
+	Wins       int      `json:"wins"`
+	Losses     int      `json:"losses"`
+	Progress   string   `json:"progress"`
+	Games      []string `json:"games,omitempty"`
+	WinsNeeded int      `json:"winsNeeded"`
+	GamesLeft  int      `json:"gamesLeft"`
+	Status     string   `json:"status,omitempty"`
 }
 
 type ChallengerLeague struct {
-	LeagueID string        `json:"leagueId"`
-	Entries  []LeagueEntry `json:"entries"`
-	Tier     string        `json:"tier"`
-	Name     string        `json:"name"`
-	Queue    string        `json:"queue"`
+	LeagueID      string         `json:"leagueId"`
+	Entries       []LeagueEntry  `json:"entries"`
+	Tier          string         `json:"tier"`
+	Name          string         `json:"name"`
+	Queue         string         `json:"queue"`
+	LastKnownGood *LastKnownGood `json:"lastKnownGood,omitempty"`
+}
+
+// LastKnownGood marks a league served from the copy persisted in the
+// database because Riot could not be reached and the cache had expired.
+type LastKnownGood struct {
+	FetchedAt  time.Time `json:"fetchedAt"`
+	AgeSeconds int64     `json:"ageSeconds"`
 }
 
 type GrandmasterLeague struct {
-	LeagueID string        `json:"leagueId"`
-	Entries  []LeagueEntry `json:"entries"`
-	Tier     string        `json:"tier"`
-	Name     string        `json:"name"`
-	Queue    string        `json:"queue"`
+	LeagueID      string         `json:"leagueId"`
+	Entries       []LeagueEntry  `json:"entries"`
+	Tier          string         `json:"tier"`
+	Name          string         `json:"name"`
+	Queue         string         `json:"queue"`
+	LastKnownGood *LastKnownGood `json:"lastKnownGood,omitempty"`
 }
 
 type MasterLeague struct {
-	LeagueID string        `json:"leagueId"`
-	Entries  []LeagueEntry `json:"entries"`
-	Tier     string        `json:"tier"`
-	Name     string        `json:"name"`
-	Queue    string        `json:"queue"`
+	LeagueID      string         `json:"leagueId"`
+	Entries       []LeagueEntry  `json:"entries"`
+	Tier          string         `json:"tier"`
+	Name          string         `json:"name"`
+	Queue         string         `json:"queue"`
+	LastKnownGood *LastKnownGood `json:"lastKnownGood,omitempty"`
 }
 
 type LeagueEntriesResponse struct {
@@ -64,20 +88,61 @@ type LeagueEntriesResponse struct {
 	Page     int           `json:"page"`
 	Tier     string        `json:"tier"`
 	Division string        `json:"division"`
-	HasMore  bool          `json:"hasMore"`
+	HasMore  bool          `json:"-"`
+
+	Pagination *PageInfo `json:"pagination,omitempty"`
 }
 
 type LeagueUpdateTask struct {
-	Type     string `json:"type"`
-	Tier     string `json:"tier,omitempty"`
-	Division string `json:"division,omitempty"`
-	Region   string `json:"region"`
-	Page     int    `json:"page,omitempty"`
+	Type     string    `json:"type"`
+	Tier     string    `json:"tier,omitempty"`
+	Division string    `json:"division,omitempty"`
+	Region   string    `json:"region"`
+	Regions  []string  `json:"regions,omitempty"`
+	Page     int       `json:"page,omitempty"`
+	Deadline time.Time `json:"deadline,omitempty"`
+}
+
+const (
+	NameStatusPending = "pending"
+	NameStatusFailed  = "failed"
+)
+
+type SummonerNamePending struct {
+	Status     string    `json:"status"`
+	EnqueuedAt time.Time `json:"enqueuedAt"`
+	Attempts   int       `json:"attempts"`
+	RetryAfter time.Time `json:"retryAfter,omitempty"`
+}
+
+type SummonerNameFailures struct {
+	Count       int       `json:"count"`
+	LastFailure time.Time `json:"lastFailure"`
+}
+
+type MatchIngestTask struct {
+	PUUID string `json:"puuid"`
 }
 
 type SummonerNameTask struct {
-	PUUID  string `json:"puuid"`
-	Region string `json:"region"`
+	PUUID    string `json:"puuid"`
+	Region   string `json:"region"`
+	Priority string `json:"priority,omitempty"`
+}
+
+// APIKeyRevokedEvent tells every instance to drop its cached API keys.
+type APIKeyRevokedEvent struct {
+	KeyID     string    `json:"keyId"`
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+type SummonerNameResolvedEvent struct {
+	PUUID      string    `json:"puuid"`
+	Region     string    `json:"region,omitempty"`
+	Name       string    `json:"name"`
+	GameName   string    `json:"gameName"`
+	TagLine    string    `json:"tagLine,omitempty"`
+	ResolvedAt time.Time `json:"resolvedAt"`
 }
 
 type Summoner struct {
@@ -90,8 +155,89 @@ type Summoner struct {
 	SummonerLevel int    `json:"summonerLevel"`
 }
 
+func (s *Summoner) Validate(expectedPUUID string) error {
+	if s.PUUID == "" {
+		return fmt.Errorf("invalid summoner payload: missing puuid")
+	}
+	if expectedPUUID != "" && s.PUUID != expectedPUUID {
+		return fmt.Errorf("invalid summoner payload: puuid mismatch")
+	}
+	if s.SummonerLevel < 0 {
+		return fmt.Errorf("invalid summoner payload: negative summonerLevel %d", s.SummonerLevel)
+	}
+	if s.RevisionDate < 0 {
+		return fmt.Errorf("invalid summoner payload: negative revisionDate %d", s.RevisionDate)
+	}
+	return nil
+}
+
 type AccountData struct {
 	PUUID    string `json:"puuid"`
 	GameName string `json:"gameName"`
 	TagLine  string `json:"tagLine"`
 }
+
+type SummonerNameMatch struct {
+	PUUID    string `json:"puuid"`
+	Name     string `json:"name"`
+	GameName string `json:"gameName"`
+	TagLine  string `json:"tagLine"`
+}
+
+type Match struct {
+	Metadata MatchMetadata `json:"metadata"`
+	Info     MatchInfo     `json:"info"`
+}
+
+type MatchMetadata struct {
+	DataVersion  string   `json:"data_version"`
+	MatchID      string   `json:"match_id"`
+	Participants []string `json:"participants"`
+}
+
+type MatchInfo struct {
+	GameDatetime int64              `json:"game_datetime"`
+	GameLength   float64            `json:"game_length"`
+	GameVersion  string             `json:"game_version"`
+	QueueID      int                `json:"queue_id"`
+	TFTSetNumber int                `json:"tft_set_number"`
+	Participants []MatchParticipant `json:"participants"`
+}
+
+type MatchParticipant struct {
+	PUUID                string       `json:"puuid"`
+	Placement            int          `json:"placement"`
+	Level                int          `json:"level"`
+	LastRound            int          `json:"last_round"`
+	TimeEliminated       float64      `json:"time_eliminated"`
+	TotalDamageToPlayers int          `json:"total_damage_to_players"`
+	Traits               []MatchTrait `json:"traits"`
+	Units                []MatchUnit  `json:"units"`
+}
+
+type MatchTrait struct {
+	Name        string `json:"name"`
+	NumUnits    int    `json:"num_units"`
+	Style       int    `json:"style"`
+	TierCurrent int    `json:"tier_current"`
+}
+
+type MatchUnit struct {
+	CharacterID string   `json:"character_id"`
+	Tier        int      `json:"tier"`
+	Rarity      int      `json:"rarity"`
+	ItemNames   []string `json:"itemNames"`
+}
+
+func (m *Match) PlayedAt() time.Time {
+	return time.UnixMilli(m.Info.GameDatetime).UTC()
+}
+
+func (m *Match) Participant(puuid string) *MatchParticipant {
+	for i := range m.Info.Participants {
+		if m.Info.Participants[i].PUUID == puuid {
+			return &m.Info.Participants[i]
+		}
+	}
+	return nil
+}
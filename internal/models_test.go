@@ -62,3 +62,46 @@ func TestLeagueEntry_GetUniqueID_Benchmark(t *testing.T) {
 		entry.GetUniqueID()
 	}
 }
+
+func TestSummoner_Validate(t *testing.T) {
+	tests := []struct {
+		name      string
+		summoner  Summoner
+		puuid     string
+		expectErr bool
+	}{
+		{
+			name:      "valid summoner",
+			summoner:  Summoner{PUUID: "puuid-123", SummonerLevel: 250, RevisionDate: 1700000000000},
+			puuid:     "puuid-123",
+			expectErr: false,
+		},
+		{
+			name:      "missing puuid",
+			summoner:  Summoner{SummonerLevel: 250},
+			puuid:     "puuid-123",
+			expectErr: true,
+		},
+		{
+			name:      "puuid mismatch",
+			summoner:  Summoner{PUUID: "other-puuid"},
+			puuid:     "puuid-123",
+			expectErr: true,
+		},
+		{
+			name:      "negative level",
+			summoner:  Summoner{PUUID: "puuid-123", SummonerLevel: -1},
+			puuid:     "puuid-123",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.summoner.Validate(tt.puuid)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("Validate() error = %v, expected error %v", err, tt.expectErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package internal
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+)
+
+const (
+	NamePriorityHigh   = "high"
+	NamePriorityNormal = "normal"
+	NamePriorityLow    = "low"
+)
+
+// summonerNameSubjects are consumed in this order by the name worker pool.
+// Normal keeps the original subject so tasks from older publishers still run.
+var summonerNameSubjects = []string{
+	"tft.summoner.name.fetch.high",
+	"tft.summoner.name.fetch",
+	"tft.summoner.name.fetch.low",
+}
+
+// summonerNameSubject returns the subject for a priority and shard. A
+// negative shard means sharding is disabled.
+func summonerNameSubject(priority string, shard int) string {
+	subject := summonerNameSubjects[1]
+	switch priority {
+	case NamePriorityHigh:
+		subject = summonerNameSubjects[0]
+	case NamePriorityLow:
+		subject = summonerNameSubjects[2]
+	}
+
+	if shard < 0 {
+		return subject
+	}
+	return subject + ".s" + strconv.Itoa(shard)
+}
+
+// summonerNamePriority ranks entries by how visible they are: apex ladders
+// first, then upper tiers and the first page of any tier, then the long tail.
+func summonerNamePriority(tier string, page int) string {
+	switch strings.ToUpper(tier) {
+	case "CHALLENGER", "GRANDMASTER", "MASTER":
+		return NamePriorityHigh
+	case "DIAMOND", "EMERALD", "PLATINUM":
+		return NamePriorityNormal
+	}
+	if page <= 1 {
+		return NamePriorityNormal
+	}
+	return NamePriorityLow
+}
+
+// summonerNameShard maps a PUUID to a shard deterministically, so every
+// publisher routes the same PUUID to the instance that owns that shard.
+func summonerNameShard(puuid string, shards int) int {
+	if shards <= 0 {
+		return -1
+	}
+	h := fnv.New32a()
+	h.Write([]byte(puuid))
+	return int(h.Sum32() % uint32(shards))
+}
+
+// summonerNameWorkerSubjects lists what this instance consumes, priority
+// first and then shard, matching the lane order of the worker pool.
+func summonerNameWorkerSubjects(shards int, owned []int) []string {
+	if shards <= 0 {
+		return summonerNameSubjects
+	}
+
+	subjects := make([]string, 0, len(summonerNameSubjects)*len(owned))
+	for _, priority := range []string{NamePriorityHigh, NamePriorityNormal, NamePriorityLow} {
+		for _, shard := range owned {
+			subjects = append(subjects, summonerNameSubject(priority, shard))
+		}
+	}
+	return subjects
+}
+
+// parseShardIDs reads NATS_NAME_SHARD_IDS ("0,2"); empty means every shard.
+func parseShardIDs(value string, shards int) ([]int, error) {
+	if strings.TrimSpace(value) == "" {
+		owned := make([]int, shards)
+		for i := range owned {
+			owned[i] = i
+		}
+		return owned, nil
+	}
+
+	seen := make(map[int]bool)
+	var owned []int
+	for _, part := range strings.Split(value, ",") {
+		shard, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || shard < 0 || shard >= shards {
+			return nil, fmt.Errorf("invalid shard %q (must be between 0 and %d)", part, shards-1)
+		}
+		if !seen[shard] {
+			seen[shard] = true
+			owned = append(owned, shard)
+		}
+	}
+	return owned, nil
+}
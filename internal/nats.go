@@ -5,179 +5,465 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nats-io/nats.go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type NATSClient struct {
-	Conn *nats.Conn
+	Conn    *nats.Conn
+	logger  *Logger
+	metrics *MetricsCollector
+
+	summonerPool WorkerPoolConfig
+	leaguePool   WorkerPoolConfig
+	matchPool    WorkerPoolConfig
+	notifyPool   WorkerPoolConfig
+	nameShards   int
+	ownedShards  []int
+
+	dedup       *TaskDeduplicator
+	retryPolicy TaskRetryPolicy
+	deadLetters deadLetterStore
+
+	pools    []*workerPool
+	mu       sync.Mutex
+	draining atomic.Bool
+	drained  chan struct{}
 }
 
-func NewNATSClient(cfg *Config) (*NATSClient, error) {
+func NewNATSClient(cfg *Config, logger *Logger, metrics *MetricsCollector) (*NATSClient, error) {
+	nc := &NATSClient{
+		logger:  logger,
+		metrics: metrics,
+		summonerPool: WorkerPoolConfig{
+			Workers:      cfg.NATSSummonerWorkers,
+			MaxInFlight:  cfg.NATSMaxInFlight,
+			PendingLimit: cfg.NATSPendingLimit,
+		},
+		leaguePool: WorkerPoolConfig{
+			Workers:      cfg.NATSLeagueWorkers,
+			MaxInFlight:  cfg.NATSMaxInFlight,
+			PendingLimit: cfg.NATSPendingLimit,
+		},
+		matchPool: WorkerPoolConfig{
+			Workers:      cfg.NATSMatchIngestWorkers,
+			MaxInFlight:  cfg.NATSMaxInFlight,
+			PendingLimit: cfg.NATSPendingLimit,
+		},
+		notifyPool: WorkerPoolConfig{
+			Workers:      cfg.NATSNotifyWorkers,
+			MaxInFlight:  cfg.NATSMaxInFlight,
+			PendingLimit: cfg.NATSPendingLimit,
+		},
+		nameShards: cfg.NATSNameShards,
+		retryPolicy: TaskRetryPolicy{
+			MaxAttempts: cfg.NATSTaskMaxAttempts,
+			Backoff:     cfg.NATSTaskRetryBackoff,
+			MaxBackoff:  cfg.NATSTaskRetryMaxBackoff,
+		},
+	}
+	if nc.nameShards > 0 {
+		owned, err := parseShardIDs(cfg.NATSNameShardIDs, nc.nameShards)
+		if err != nil {
+			return nil, err
+		}
+		nc.ownedShards = owned
+	}
+
 	conn, err := nats.Connect(cfg.NATSUrl,
 		nats.Name(cfg.NATSClientID),
 		nats.Timeout(5*time.Second),
+		nats.MaxReconnects(cfg.NATSMaxReconnects),
+		nats.ReconnectWait(cfg.NATSReconnectWait),
+		nats.DisconnectErrHandler(nc.handleDisconnect),
+		nats.ReconnectHandler(nc.handleReconnect),
+		nats.ClosedHandler(nc.handleClosed),
+		nats.DrainTimeout(cfg.NATSDrainTimeout),
+		nats.ErrorHandler(func(_ *nats.Conn, sub *nats.Subscription, err error) {
+			builder := logger.Warn("nats_async_error").
+				Component("nats").
+				Operation("consume").
+				Err(err)
+			if sub != nil {
+				dropped, _ := sub.Dropped()
+				builder = builder.Meta("subject", sub.Subject).Meta("dropped", dropped)
+			}
+			if err == nats.ErrSlowConsumer {
+				builder = builder.ErrorCode("slow_consumer")
+			}
+			builder.Log()
+		}),
 	)
 	if err != nil {
 		return nil, err
 	}
-	return &NATSClient{Conn: conn}, nil
+
+	nc.Conn = conn
+	return nc, nil
 }
 
-func (nc *NATSClient) Publish(subject string, data []byte) error {
-	return nc.Conn.Publish(subject, data)
+func (nc *NATSClient) handleDisconnect(conn *nats.Conn, err error) {
+	nc.logger.Warn("nats_disconnected").
+		Component("nats").
+		Operation("connection").
+		Err(err).
+		Meta("url", conn.ConnectedUrlRedacted()).
+		Log()
 }
 
-func (nc *NATSClient) PublishLeagueUpdateTask(task LeagueUpdateTask) error {
-	data, err := json.Marshal(task)
-	if err != nil {
-		return err
-	}
-	return nc.Publish("tft.league.update", data)
+func (nc *NATSClient) handleReconnect(conn *nats.Conn) {
+	nc.logger.Info("nats_reconnected").
+		Component("nats").
+		Operation("connection").
+		Meta("url", conn.ConnectedUrlRedacted()).
+		Meta("reconnects", conn.Stats().Reconnects).
+		Log()
+
+	nc.resubscribeInvalidPools(conn)
 }
 
-func (nc *NATSClient) PublishSummonerNameTask(task SummonerNameTask) error {
-	data, err := json.Marshal(task)
-	if err != nil {
-		return err
+func (nc *NATSClient) handleClosed(conn *nats.Conn) {
+	if nc.draining.Load() {
+		nc.logger.Info("nats_connection_closed").
+			Component("nats").
+			Operation("drain").
+			Log()
+		return
 	}
-	return nc.Publish("tft.summoner.name.fetch", data)
+
+	nc.logger.Error("nats_connection_closed").
+		Component("nats").
+		Operation("connection").
+		Err(conn.LastError()).
+		Log()
 }
 
-func (nc *NATSClient) StartSummonerNameWorker(riotClient *RiotAPIClient, cacheManager *CacheManager) (*nats.Subscription, error) {
-	handler := func(msg *nats.Msg) {
-		processSummonerNameTask(msg, riotClient, cacheManager)
+func (nc *NATSClient) resubscribeInvalidPools(conn *nats.Conn) {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+
+	for _, pool := range nc.pools {
+		if pool.valid() {
+			continue
+		}
+
+		if err := pool.subscribe(conn); err != nil {
+			nc.logger.Error("nats_resubscribe_failed").
+				Component("nats").
+				Operation("resubscribe").
+				Worker(pool.name, pool.subjects(), 0).
+				Err(err).
+				Log()
+			continue
+		}
+
+		nc.logger.Info("nats_resubscribed").
+			Component("nats").
+			Operation("resubscribe").
+			Worker(pool.name, pool.subjects(), 0).
+			Log()
 	}
+}
 
-	sub, err := nc.Conn.QueueSubscribe("tft.summoner.name.fetch", "name-workers", handler)
-	if err != nil {
-		return nil, err
+// drainStarted is closed once Drain begins, waking tasks waiting to retry.
+func (nc *NATSClient) drainStarted() chan struct{} {
+	nc.mu.Lock()
+	defer nc.mu.Unlock()
+	if nc.drained == nil {
+		nc.drained = make(chan struct{})
 	}
-	log.Println("Summoner Name Worker started, waiting for messages...")
-	return sub, nil
+	return nc.drained
 }
 
-func processSummonerNameTask(msg *nats.Msg, riotClient *RiotAPIClient, cacheManager *CacheManager) {
-	var task SummonerNameTask
-	if err := json.Unmarshal(msg.Data, &task); err != nil {
-		log.Printf("Error unmarshaling summoner name task: %v", err)
-		return
+func (nc *NATSClient) Drain(timeout time.Duration) error {
+	if nc.draining.CompareAndSwap(false, true) {
+		close(nc.drainStarted())
 	}
+	deadline := time.Now().Add(timeout)
 
-	log.Printf("Processing summoner name task: PUUID=%s", task.PUUID[:30]+"...")
+	if err := nc.Conn.Drain(); err != nil {
+		nc.Conn.Close()
+		return err
+	}
 
-	ctx := context.Background()
+	for !nc.Conn.IsClosed() {
+		if time.Now().After(deadline) {
+			nc.Conn.Close()
+			return fmt.Errorf("nats drain timed out after %s", timeout)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
 
-	if shouldSkipTask(task.PUUID, cacheManager, ctx) {
-		return
+	nc.mu.Lock()
+	pools := nc.pools
+	nc.mu.Unlock()
+
+	for _, pool := range pools {
+		if err := pool.shutdown(time.Until(deadline)); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	accountData, err := riotClient.GetAccountByPUUID(task.PUUID)
-	if err != nil {
-		log.Printf("Error fetching account data for PUUID %s: %v", task.PUUID[:30]+"...", err)
-		return
+func (nc *NATSClient) Status() string {
+	if nc == nil || nc.Conn == nil {
+		return "disabled"
 	}
 
-	cacheSummonerName(accountData, task.PUUID, cacheManager, ctx)
+	switch nc.Conn.Status() {
+	case nats.CONNECTED:
+		return "connected"
+	case nats.RECONNECTING, nats.CONNECTING:
+		return "reconnecting"
+	case nats.CLOSED:
+		return "closed"
+	default:
+		return "disconnected"
+	}
 }
 
-func shouldSkipTask(puuid string, cacheManager *CacheManager, ctx context.Context) bool {
-	if cachedName, err := cacheManager.GetSummonerName(ctx, puuid); err == nil && cachedName != "" {
-		log.Printf("Name already exists in cache for PUUID %s: %s", puuid[:30]+"...", cachedName)
-		return true
+func (nc *NATSClient) Publish(ctx context.Context, subject string, data []byte) error {
+	return nc.publish(ctx, subject, "", data)
+}
+
+// PublishWithID sets Nats-Msg-Id so JetStream and idempotent consumers can
+// drop redeliveries of the same message.
+func (nc *NATSClient) PublishWithID(ctx context.Context, subject, msgID string, data []byte) error {
+	return nc.publish(ctx, subject, msgID, data)
+}
+
+func (nc *NATSClient) publish(ctx context.Context, subject, msgID string, data []byte) error {
+	ctx, span := tracer().Start(ctx, "nats.publish "+subject, trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+	span.SetAttributes(attribute.String("messaging.system", "nats"), attribute.String("messaging.destination", subject))
+
+	msg := nats.NewMsg(subject)
+	msg.Data = data
+	if msgID != "" {
+		msg.Header.Set(nats.MsgIdHdr, msgID)
 	}
-	return false
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(msg.Header))
+
+	err := nc.Conn.PublishMsg(msg)
+	recordSpanError(span, err)
+	return err
 }
 
-func cacheSummonerName(accountData *AccountData, puuid string, cacheManager *CacheManager, ctx context.Context) {
-	if accountData.GameName != "" {
-		fullName := buildFullName(accountData)
+// Flush waits until the server has processed everything published so far;
+// ctx must carry a deadline.
+func (nc *NATSClient) Flush(ctx context.Context) error {
+	return nc.Conn.FlushWithContext(ctx)
+}
+
+// SetDeduplicator makes task publishing drop identical tasks published
+// within the deduplicator's window.
+func (nc *NATSClient) SetDeduplicator(dedup *TaskDeduplicator) {
+	nc.dedup = dedup
+}
 
-		if err := cacheManager.SetSummonerName(ctx, puuid, fullName); err != nil {
-			log.Printf("Error caching summoner name: %v", err)
-		} else {
-			log.Printf("Name cached successfully: PUUID=%s, Name=%s", puuid[:30]+"...", fullName)
+// publishTask publishes data unless an identical task, as identified by
+// identity, was published within the dedup window. A dropped duplicate is
+// not an error: the task it repeats is already queued. A failed publish clears
+// the marker so the task can be retried within the window.
+func (nc *NATSClient) publishTask(ctx context.Context, subject string, identity, data []byte) error {
+	if !nc.dedup.First(ctx, subject, identity) {
+		if nc.metrics != nil {
+			nc.metrics.RecordDeduplicatedTask(subject)
 		}
-	} else {
-		log.Printf("GameName not found in account data: %+v", accountData)
+		nc.logger.Debug("nats_task_deduplicated").
+			Component("nats").
+			Operation("publish").
+			Meta("subject", subject).
+			Log()
+		return nil
+	}
+	if err := nc.Publish(ctx, subject, data); err != nil {
+		nc.dedup.Forget(context.WithoutCancel(ctx), subject, identity)
+		return err
 	}
+	return nil
 }
 
-func buildFullName(accountData *AccountData) string {
-	fullName := accountData.GameName
-	if accountData.TagLine != "" {
-		fullName = fmt.Sprintf("%s#%s", accountData.GameName, accountData.TagLine)
+func (nc *NATSClient) PublishLeagueUpdateTask(ctx context.Context, task LeagueUpdateTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
 	}
-	return fullName
+
+	// The deadline moves with every publish, so it does not tell tasks apart.
+	identity := task
+	identity.Deadline = time.Time{}
+	identityData, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	return nc.publishTask(ctx, "tft.league.update", identityData, data)
 }
 
-func (nc *NATSClient) StartLeagueUpdateWorker(riotClient *RiotAPIClient, cacheManager *CacheManager) (*nats.Subscription, error) {
-	handler := func(msg *nats.Msg) {
-		processLeagueUpdateTask(msg, riotClient, cacheManager, nc)
+func (nc *NATSClient) PublishSummonerNameTask(ctx context.Context, task SummonerNameTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
 	}
+	return nc.publishTask(ctx, summonerNameSubject(task.Priority, summonerNameShard(task.PUUID, nc.nameShards)), data, data)
+}
 
-	sub, err := nc.Conn.QueueSubscribe("tft.league.update", "league-workers", handler)
+func (nc *NATSClient) PublishMatchIngestTask(ctx context.Context, task MatchIngestTask) error {
+	data, err := json.Marshal(task)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	log.Println("League Update Worker started, waiting for messages...")
-	return sub, nil
+	return nc.Publish(ctx, matchIngestSubject, data)
 }
 
-func processLeagueUpdateTask(msg *nats.Msg, riotClient *RiotAPIClient, cacheManager *CacheManager, nc *NATSClient) {
-	var task LeagueUpdateTask
-	if err := json.Unmarshal(msg.Data, &task); err != nil {
-		log.Printf("Error unmarshaling league task: %v", err)
-		return
+const (
+	rankChangedSubject     = "tft.watchlist.rank_changed"
+	promotionSeriesSubject = "tft.watchlist.promotion_series"
+	streakSubject          = "tft.watchlist.streak"
+	profileChangedSubject  = "tft.summoner.profile_changed"
+	matchIngestSubject     = "tft.match.ingest"
+	matchIngestedSubject   = "tft.match.ingested"
+
+	summonerNameResolvedSubject = "tft.summoner.name.resolved"
+	apiKeyRevokedSubject        = "tft.auth.api_key.revoked"
+)
+
+func startConsumerSpan(msg *nats.Msg) (context.Context, trace.Span) {
+	ctx := context.Background()
+	if msg.Header != nil {
+		ctx = otel.GetTextMapPropagator().Extract(ctx, propagation.HeaderCarrier(msg.Header))
 	}
 
-	log.Printf("Processing league update task: %+v", task)
+	ctx, span := tracer().Start(ctx, "nats.process "+msg.Subject, trace.WithSpanKind(trace.SpanKindConsumer))
+	span.SetAttributes(attribute.String("messaging.system", "nats"), attribute.String("messaging.destination", msg.Subject))
+	return ctx, span
+}
 
-	updateFuncs := map[string]func() error{
-		"challenger":  func() error { return nc.updateChallengerLeague(riotClient, cacheManager, task.Region) },
-		"grandmaster": func() error { return nc.updateGrandmasterLeague(riotClient, cacheManager, task.Region) },
-		"master":      func() error { return nc.updateMasterLeague(riotClient, cacheManager, task.Region) },
+func (nc *NATSClient) StartSummonerNameWorker(riotClient *RiotAPIClient, cacheManager *CacheManager) error {
+	handler := func(msg *nats.Msg) {
+		ctx, span := startConsumerSpan(msg)
+		defer span.End()
+		nc.handleTask(ctx, "summoner_name", msg, func(ctx context.Context) error {
+			return processSummonerNameTask(ctx, msg, riotClient, cacheManager, nc.publishSummonerNameResolved)
+		})
 	}
 
-	if updateFunc, exists := updateFuncs[task.Type]; exists {
-		if err := updateFunc(); err != nil {
-			log.Printf("Error updating %s league: %v", task.Type, err)
-		}
-	} else {
-		log.Printf("Unknown task type: %s", task.Type)
+	if _, err := nc.startWorkerPool("summoner_name", summonerNameWorkerSubjects(nc.nameShards, nc.ownedShards), "name-workers", nc.summonerPool, handler); err != nil {
+		return err
 	}
+	log.Println("Summoner Name Worker started, waiting for messages...")
+	return nil
 }
 
-func (nc *NATSClient) updateChallengerLeague(riotClient *RiotAPIClient, cacheManager *CacheManager, region string) error {
-	result, err := riotClient.GetChallengerLeague()
+func processSummonerNameTask(ctx context.Context, msg *nats.Msg, riotClient *RiotAPIClient, cacheManager *CacheManager, resolved func(context.Context, SummonerNameResolvedEvent)) error {
+	var task SummonerNameTask
+	if err := json.Unmarshal(msg.Data, &task); err != nil {
+		log.Printf("Error unmarshaling summoner name task: %v", err)
+		return permanentTask(err)
+	}
+
+	log.Printf("Processing summoner name task: PUUID=%s", task.PUUID[:30]+"...")
+
+	if shouldSkipTask(task.PUUID, cacheManager, ctx) {
+		return nil
+	}
+
+	accountData, err := riotClient.GetAccountByPUUID(ctx, task.PUUID)
 	if err != nil {
+		backoff, _ := cacheManager.MarkSummonerNameFailed(ctx, task.PUUID)
+		log.Printf("Error fetching account data for PUUID %s: %v (retry in %s)", task.PUUID[:30]+"...", err, backoff)
 		return err
 	}
 
-	return cacheLeagueResult(cacheManager, "challenger", region, result)
+	if cacheSummonerName(accountData, task.PUUID, cacheManager, ctx) {
+		resolved(ctx, summonerNameResolved(task, accountData, time.Now().UTC()))
+	}
+	return nil
+}
+
+func summonerNameResolved(task SummonerNameTask, accountData *AccountData, now time.Time) SummonerNameResolvedEvent {
+	return SummonerNameResolvedEvent{
+		PUUID:      task.PUUID,
+		Region:     task.Region,
+		Name:       buildFullName(accountData),
+		GameName:   accountData.GameName,
+		TagLine:    accountData.TagLine,
+		ResolvedAt: now,
+	}
 }
 
-func (nc *NATSClient) updateGrandmasterLeague(riotClient *RiotAPIClient, cacheManager *CacheManager, region string) error {
-	result, err := riotClient.GetGrandmasterLeague()
+// publishSummonerNameResolved announces a name the worker just resolved. The
+// name only lives in the cache, so there is no transaction to tie an outbox
+// row to; a lost event costs subscribers a cache read, not the task a retry.
+func (nc *NATSClient) publishSummonerNameResolved(ctx context.Context, event SummonerNameResolvedEvent) {
+	data, err := json.Marshal(event)
+	if err == nil {
+		err = nc.Publish(ctx, summonerNameResolvedSubject, data)
+	}
 	if err != nil {
-		return err
+		nc.logger.Warn("summoner_name_resolved_publish_failed").
+			Component("nats").
+			Operation("publish").
+			Game(event.PUUID, event.Region, "").
+			Err(err).
+			Log()
 	}
+}
 
-	return cacheLeagueResult(cacheManager, "grandmaster", region, result)
+func shouldSkipTask(puuid string, cacheManager *CacheManager, ctx context.Context) bool {
+	if cachedName, err := cacheManager.GetSummonerName(ctx, puuid); err == nil && cachedName != "" {
+		log.Printf("Name already exists in cache for PUUID %s: %s", puuid[:30]+"...", cachedName)
+		cacheManager.ClearSummonerNamePending(ctx, puuid)
+		return true
+	}
+	return false
 }
 
-func (nc *NATSClient) updateMasterLeague(riotClient *RiotAPIClient, cacheManager *CacheManager, region string) error {
-	result, err := riotClient.GetMasterLeague()
-	if err != nil {
-		return err
+func cacheSummonerName(accountData *AccountData, puuid string, cacheManager *CacheManager, ctx context.Context) bool {
+	if accountData.GameName == "" {
+		log.Printf("GameName not found in account data: %+v", accountData)
+		cacheManager.MarkSummonerNameFailed(ctx, puuid)
+		return false
 	}
 
-	return cacheLeagueResult(cacheManager, "master", region, result)
+	fullName := buildFullName(accountData)
+	if err := cacheManager.SetSummonerName(ctx, puuid, fullName); err != nil {
+		log.Printf("Error caching summoner name: %v", err)
+	} else {
+		log.Printf("Name cached successfully: PUUID=%s, Name=%s", puuid[:30]+"...", fullName)
+	}
+	cacheManager.ClearSummonerNamePending(ctx, puuid)
+	return true
 }
 
-func cacheLeagueResult(cacheManager *CacheManager, leagueType, region string, result interface{}) error {
-	ctx := context.Background()
-	cacheKey := cacheManager.Key(leagueType, region)
-	return cacheManager.Set(ctx, cacheKey, result, 30*time.Minute)
+func buildFullName(accountData *AccountData) string {
+	fullName := accountData.GameName
+	if accountData.TagLine != "" {
+		fullName = fmt.Sprintf("%s#%s", accountData.GameName, accountData.TagLine)
+	}
+	return fullName
+}
+
+func (nc *NATSClient) StartLeagueUpdateWorker(refresher *LeagueRefresher) error {
+	handler := func(msg *nats.Msg) {
+		ctx, span := startConsumerSpan(msg)
+		defer span.End()
+		nc.handleTask(ctx, "league_update", msg, func(ctx context.Context) error {
+			return refresher.process(ctx, msg)
+		})
+	}
+
+	if _, err := nc.startWorkerPool("league_update", []string{"tft.league.update"}, "league-workers", nc.leaguePool, handler); err != nil {
+		return err
+	}
+	log.Println("League Update Worker started, waiting for messages...")
+	return nil
 }
@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TaskDeduplicator lets the first publish of a task through and drops
+// identical ones for a window, across every instance sharing Redis. Name
+// enrichment checks the pending marker before publishing, but concurrent
+// requests for the same ladder page all pass that check before any of them
+// sets it.
+type TaskDeduplicator struct {
+	client *redis.Client
+	window time.Duration
+	logger *Logger
+}
+
+func NewTaskDeduplicator(cfg *Config, client *redis.Client, logger *Logger) *TaskDeduplicator {
+	return &TaskDeduplicator{
+		client: client,
+		window: cfg.NATSDedupWindow,
+		logger: logger,
+	}
+}
+
+// taskDedupKey hashes the encoded task; identical tasks marshal to identical
+// bytes because the task structs have a fixed field order.
+func taskDedupKey(subject string, data []byte) string {
+	sum := sha256.Sum256(data)
+	return "tft:dedup:" + subject + ":" + hex.EncodeToString(sum[:16])
+}
+
+// First reports whether the task should be published. Without Redis, with
+// the window disabled or when Redis fails it returns true: a duplicate task
+// only costs a repeated lookup, a dropped one a missing refresh.
+func (td *TaskDeduplicator) First(ctx context.Context, subject string, data []byte) bool {
+	if td == nil || td.client == nil || td.window <= 0 {
+		return true
+	}
+
+	first, err := td.client.SetNX(ctx, taskDedupKey(subject, data), 1, td.window).Result()
+	if err != nil {
+		td.logger.Warn("nats_dedup_failed").
+			Component("nats").
+			Operation("dedup").
+			Meta("subject", subject).
+			Err(err).
+			Log()
+		return true
+	}
+	return first
+}
+
+// Forget clears the marker of a task whose publish failed, so a retry is not
+// dropped as a duplicate of a message that never went out.
+func (td *TaskDeduplicator) Forget(ctx context.Context, subject string, data []byte) {
+	if td == nil || td.client == nil || td.window <= 0 {
+		return
+	}
+
+	if err := td.client.Del(ctx, taskDedupKey(subject, data)).Err(); err != nil {
+		td.logger.Warn("nats_dedup_forget_failed").
+			Component("nats").
+			Operation("dedup").
+			Meta("subject", subject).
+			Err(err).
+			Log()
+	}
+}
@@ -0,0 +1,178 @@
+package internal
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestTaskDedupKey(t *testing.T) {
+	key := taskDedupKey("tft.summoner.name.high", []byte(`{"puuid":"a"}`))
+	if key != taskDedupKey("tft.summoner.name.high", []byte(`{"puuid":"a"}`)) {
+		t.Error("expected identical tasks to share a key")
+	}
+	if key == taskDedupKey("tft.summoner.name.high", []byte(`{"puuid":"b"}`)) {
+		t.Error("expected different tasks to get different keys")
+	}
+	if key == taskDedupKey("tft.summoner.name.low", []byte(`{"puuid":"a"}`)) {
+		t.Error("expected the same task on another subject to get a different key")
+	}
+	if cacheKeyType(key) != "dedup" {
+		t.Errorf("key %q must use a key type the cache pruner knows", key)
+	}
+}
+
+func TestTaskDeduplicator_FailsOpen(t *testing.T) {
+	unreachable := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", DialTimeout: 100 * time.Millisecond, MaxRetries: -1})
+	defer unreachable.Close()
+
+	tests := []struct {
+		name  string
+		dedup *TaskDeduplicator
+	}{
+		{name: "nil deduplicator"},
+		{name: "without redis", dedup: NewTaskDeduplicator(&Config{NATSDedupWindow: time.Minute}, nil, newTestLogger())},
+		{name: "window disabled", dedup: NewTaskDeduplicator(&Config{}, unreachable, newTestLogger())},
+		{name: "redis unavailable", dedup: NewTaskDeduplicator(&Config{NATSDedupWindow: time.Minute}, unreachable, newTestLogger())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 2; i++ {
+				if !tt.dedup.First(t.Context(), "tft.league.update", []byte(`{}`)) {
+					t.Errorf("First() call %d = false, expected every task to be published", i+1)
+				}
+			}
+		})
+	}
+}
+
+// fakeRedis implements the SET NX and DEL commands used by TaskDeduplicator.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	keys     map[string]string
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	fake := &fakeRedis{listener: listener, keys: make(map[string]string)}
+	go fake.serve()
+	t.Cleanup(func() { listener.Close() })
+	return fake
+}
+
+func (f *fakeRedis) Client(t *testing.T) *redis.Client {
+	client := redis.NewClient(&redis.Options{Addr: f.listener.Addr().String(), Protocol: 2, DisableIdentity: true})
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func (f *fakeRedis) Len() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.keys)
+}
+
+func (f *fakeRedis) serve() {
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		go f.handle(conn)
+	}
+}
+
+func (f *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		args, err := readRESPArray(r)
+		if err != nil {
+			return
+		}
+
+		f.mu.Lock()
+		reply := "-ERR unknown command\r\n"
+		switch strings.ToUpper(args[0]) {
+		case "SET":
+			if _, exists := f.keys[args[1]]; exists {
+				reply = "$-1\r\n"
+			} else {
+				f.keys[args[1]] = args[2]
+				reply = "+OK\r\n"
+			}
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, exists := f.keys[key]; exists {
+					delete(f.keys, key)
+					deleted++
+				}
+			}
+			reply = ":" + strconv.Itoa(deleted) + "\r\n"
+		}
+		f.mu.Unlock()
+		conn.Write([]byte(reply))
+	}
+}
+
+func readRESPArray(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+func TestPublishTask_ForgetsFailedPublish(t *testing.T) {
+	fake := newFakeRedis(t)
+	metrics := NewMetricsCollector(newTestLogger(), nil)
+	nc := &NATSClient{logger: newTestLogger(), metrics: metrics}
+	nc.SetDeduplicator(NewTaskDeduplicator(&Config{NATSDedupWindow: time.Minute}, fake.Client(t), newTestLogger()))
+
+	task := []byte(`{"type":"challenger"}`)
+	for i := 0; i < 2; i++ {
+		// Without a connection every publish fails, so a retry must not be
+		// dropped as a duplicate.
+		if err := nc.publishTask(t.Context(), "tft.league.update", task, task); err == nil {
+			t.Fatalf("publishTask() call %d error = nil, expected the publish failure", i+1)
+		}
+		if fake.Len() != 0 {
+			t.Fatalf("dedup keys = %d after a failed publish, expected the marker to be cleared", fake.Len())
+		}
+	}
+	if deduped := metrics.dedupedTasks["tft.league.update"]; deduped != 0 {
+		t.Errorf("deduplicated tasks = %d, expected none", deduped)
+	}
+
+	dedup := nc.dedup
+	if !dedup.First(t.Context(), "tft.league.update", task) || dedup.First(t.Context(), "tft.league.update", task) {
+		t.Error("expected the fake to deduplicate tasks that were not forgotten")
+	}
+}
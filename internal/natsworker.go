@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+type WorkerPoolConfig struct {
+	Workers      int
+	MaxInFlight  int
+	PendingLimit int
+}
+
+type workerPool struct {
+	name    string
+	queue   string
+	cfg     WorkerPoolConfig
+	lanes   []*workerLane
+	ready   chan struct{}
+	handler nats.MsgHandler
+	logger  *Logger
+	metrics *MetricsCollector
+	wg      sync.WaitGroup
+	stop    chan struct{}
+}
+
+// workerLane is one subscribed subject. Lanes are ordered by priority: a
+// worker always takes from the first lane that has a message buffered.
+type workerLane struct {
+	subject string
+	sub     *nats.Subscription
+	tasks   chan *nats.Msg
+}
+
+func newWorkerPool(name, queue string, subjects []string, cfg WorkerPoolConfig, handler nats.MsgHandler, logger *Logger, metrics *MetricsCollector) *workerPool {
+	if cfg.Workers < 1 {
+		cfg.Workers = 1
+	}
+	if cfg.MaxInFlight < cfg.Workers {
+		cfg.MaxInFlight = cfg.Workers
+	}
+
+	lanes := make([]*workerLane, 0, len(subjects))
+	for _, subject := range subjects {
+		lanes = append(lanes, &workerLane{subject: subject, tasks: make(chan *nats.Msg, cfg.MaxInFlight)})
+	}
+
+	return &workerPool{
+		name:    name,
+		queue:   queue,
+		cfg:     cfg,
+		lanes:   lanes,
+		ready:   make(chan struct{}, cfg.MaxInFlight*len(lanes)),
+		handler: handler,
+		logger:  logger,
+		metrics: metrics,
+		stop:    make(chan struct{}),
+	}
+}
+
+func (nc *NATSClient) startWorkerPool(name string, subjects []string, queue string, cfg WorkerPoolConfig, handler nats.MsgHandler) (*workerPool, error) {
+	pool := newWorkerPool(name, queue, subjects, cfg, handler, nc.logger, nc.metrics)
+
+	if err := pool.subscribe(nc.Conn); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < pool.cfg.Workers; i++ {
+		pool.wg.Add(1)
+		go pool.run()
+	}
+	go pool.reportQueueDepth(10 * time.Second)
+
+	nc.mu.Lock()
+	nc.pools = append(nc.pools, pool)
+	nc.mu.Unlock()
+
+	nc.logger.Info("worker_pool_started").
+		Component("nats").
+		Operation("start_worker").
+		Worker(name, pool.subjects(), 0).
+		Meta("workers", pool.cfg.Workers).
+		Meta("max_in_flight", pool.cfg.MaxInFlight).
+		Meta("pending_limit", pool.cfg.PendingLimit).
+		Log()
+
+	return pool, nil
+}
+
+func (p *workerPool) subjects() string {
+	subjects := make([]string, 0, len(p.lanes))
+	for _, lane := range p.lanes {
+		subjects = append(subjects, lane.subject)
+	}
+	return strings.Join(subjects, ",")
+}
+
+// subscribe (re)creates the subscription of every lane that is not valid,
+// which covers both the first start and a reconnect.
+func (p *workerPool) subscribe(conn *nats.Conn) error {
+	for _, lane := range p.lanes {
+		if lane.sub != nil && lane.sub.IsValid() {
+			continue
+		}
+
+		tasks := lane.tasks
+		sub, err := conn.QueueSubscribe(lane.subject, p.queue, func(msg *nats.Msg) {
+			tasks <- msg
+			p.ready <- struct{}{}
+		})
+		if err != nil {
+			return err
+		}
+
+		if p.cfg.PendingLimit > 0 {
+			if err := sub.SetPendingLimits(p.cfg.PendingLimit, -1); err != nil {
+				sub.Unsubscribe()
+				return err
+			}
+		}
+		lane.sub = sub
+	}
+	return nil
+}
+
+func (p *workerPool) valid() bool {
+	for _, lane := range p.lanes {
+		if lane.sub == nil || !lane.sub.IsValid() {
+			return false
+		}
+	}
+	return true
+}
+
+// run consumes one ready token per buffered message, so a lane scan after
+// receiving a token always finds something to process.
+func (p *workerPool) run() {
+	defer p.wg.Done()
+	for range p.ready {
+		if msg := p.take(); msg != nil {
+			p.handler(msg)
+		}
+	}
+}
+
+func (p *workerPool) take() *nats.Msg {
+	for _, lane := range p.lanes {
+		select {
+		case msg := <-lane.tasks:
+			return msg
+		default:
+		}
+	}
+	return nil
+}
+
+func (p *workerPool) shutdown(timeout time.Duration) error {
+	close(p.stop)
+	close(p.ready)
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.logger.Info("worker_pool_stopped").
+			Component("nats").
+			Operation("drain").
+			Worker(p.name, p.subjects(), 0).
+			Log()
+		return nil
+	case <-time.After(timeout):
+		return fmt.Errorf("worker pool %s did not finish in-flight tasks within %s", p.name, timeout)
+	}
+}
+
+func (p *workerPool) QueueDepth() int {
+	depth := 0
+	for _, lane := range p.lanes {
+		depth += len(lane.tasks)
+		if lane.sub != nil {
+			if pending, _, err := lane.sub.Pending(); err == nil {
+				depth += pending
+			}
+		}
+	}
+	return depth
+}
+
+func (p *workerPool) reportQueueDepth(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			if p.metrics != nil {
+				p.metrics.RecordWorkerQueueDepth(p.name, p.QueueDepth())
+			}
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+func TestWorkerPool_ShutdownWaitsForInFlight(t *testing.T) {
+	tests := []struct {
+		name      string
+		delay     time.Duration
+		timeout   time.Duration
+		expectErr bool
+	}{
+		{
+			name:      "in-flight tasks finish before timeout",
+			delay:     10 * time.Millisecond,
+			timeout:   time.Second,
+			expectErr: false,
+		},
+		{
+			name:      "slow tasks exceed timeout",
+			delay:     500 * time.Millisecond,
+			timeout:   20 * time.Millisecond,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var processed int32
+			pool := newWorkerPool("test", "test-workers", []string{"test"}, WorkerPoolConfig{Workers: 1, MaxInFlight: 4}, func(msg *nats.Msg) {
+				time.Sleep(tt.delay)
+				atomic.AddInt32(&processed, 1)
+			}, newTestLogger(), nil)
+
+			pool.wg.Add(1)
+			go pool.run()
+
+			enqueue(pool, 0, &nats.Msg{})
+			enqueue(pool, 0, &nats.Msg{})
+
+			err := pool.shutdown(tt.timeout)
+			if (err != nil) != tt.expectErr {
+				t.Errorf("shutdown() error = %v, expected error %v", err, tt.expectErr)
+			}
+			if !tt.expectErr && atomic.LoadInt32(&processed) != 2 {
+				t.Errorf("processed = %d, expected %d", processed, 2)
+			}
+		})
+	}
+}
+
+func enqueue(pool *workerPool, lane int, msg *nats.Msg) {
+	pool.lanes[lane].tasks <- msg
+	pool.ready <- struct{}{}
+}
+
+func TestWorkerPool_TakesHigherPriorityLaneFirst(t *testing.T) {
+	var order []string
+	pool := newWorkerPool("test", "test-workers", summonerNameSubjects, WorkerPoolConfig{Workers: 1, MaxInFlight: 4}, func(msg *nats.Msg) {
+		order = append(order, msg.Subject)
+	}, newTestLogger(), nil)
+
+	enqueue(pool, 2, &nats.Msg{Subject: "low"})
+	enqueue(pool, 1, &nats.Msg{Subject: "normal"})
+	enqueue(pool, 0, &nats.Msg{Subject: "high"})
+
+	pool.wg.Add(1)
+	go pool.run()
+	if err := pool.shutdown(time.Second); err != nil {
+		t.Fatalf("shutdown() error = %v", err)
+	}
+
+	expected := []string{"high", "normal", "low"}
+	if len(order) != len(expected) {
+		t.Fatalf("processed %v, expected %v", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("processed %v, expected %v", order, expected)
+			break
+		}
+	}
+}
+
+func TestSummonerNamePriority(t *testing.T) {
+	tests := []struct {
+		tier     string
+		page     int
+		expected string
+		subject  string
+	}{
+		{tier: "CHALLENGER", page: 1, expected: NamePriorityHigh, subject: "tft.summoner.name.fetch.high"},
+		{tier: "master", page: 3, expected: NamePriorityHigh, subject: "tft.summoner.name.fetch.high"},
+		{tier: "DIAMOND", page: 7, expected: NamePriorityNormal, subject: "tft.summoner.name.fetch"},
+		{tier: "IRON", page: 1, expected: NamePriorityNormal, subject: "tft.summoner.name.fetch"},
+		{tier: "IRON", page: 50, expected: NamePriorityLow, subject: "tft.summoner.name.fetch.low"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tier, func(t *testing.T) {
+			priority := summonerNamePriority(tt.tier, tt.page)
+			if priority != tt.expected {
+				t.Errorf("summonerNamePriority(%s, %d) = %s, expected %s", tt.tier, tt.page, priority, tt.expected)
+			}
+			if subject := summonerNameSubject(priority, -1); subject != tt.subject {
+				t.Errorf("summonerNameSubject(%s) = %s, expected %s", priority, subject, tt.subject)
+			}
+		})
+	}
+}
+
+func TestSummonerNameShard(t *testing.T) {
+	if shard := summonerNameShard("puuid-a", 0); shard != -1 {
+		t.Errorf("summonerNameShard() with sharding disabled = %d, expected -1", shard)
+	}
+
+	counts := make([]int, 4)
+	for i := 0; i < 400; i++ {
+		puuid := fmt.Sprintf("puuid-%d", i)
+		shard := summonerNameShard(puuid, 4)
+		if shard != summonerNameShard(puuid, 4) {
+			t.Fatalf("shard for %s is not deterministic", puuid)
+		}
+		counts[shard]++
+	}
+	for shard, count := range counts {
+		if count == 0 {
+			t.Errorf("shard %d received no PUUIDs: %v", shard, counts)
+		}
+	}
+
+	if subject := summonerNameSubject(NamePriorityHigh, 3); subject != "tft.summoner.name.fetch.high.s3" {
+		t.Errorf("summonerNameSubject() = %s", subject)
+	}
+}
+
+func TestSummonerNameWorkerSubjects(t *testing.T) {
+	subjects := summonerNameWorkerSubjects(4, []int{1, 3})
+	expected := []string{
+		"tft.summoner.name.fetch.high.s1",
+		"tft.summoner.name.fetch.high.s3",
+		"tft.summoner.name.fetch.s1",
+		"tft.summoner.name.fetch.s3",
+		"tft.summoner.name.fetch.low.s1",
+		"tft.summoner.name.fetch.low.s3",
+	}
+	if strings.Join(subjects, ",") != strings.Join(expected, ",") {
+		t.Errorf("summonerNameWorkerSubjects() = %v, expected %v", subjects, expected)
+	}
+}
+
+func TestParseShardIDs(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  []int
+		expectErr bool
+	}{
+		{name: "empty owns every shard", value: "", expected: []int{0, 1, 2}},
+		{name: "subset", value: "2, 0", expected: []int{2, 0}},
+		{name: "duplicates collapse", value: "1,1", expected: []int{1}},
+		{name: "out of range", value: "3", expectErr: true},
+		{name: "not a number", value: "a", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			owned, err := parseShardIDs(tt.value, 3)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if fmt.Sprint(owned) != fmt.Sprint(tt.expected) {
+				t.Errorf("parseShardIDs(%q) = %v, expected %v", tt.value, owned, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessSummonerNameTask_PublishesResolvedName(t *testing.T) {
+	dir := t.TempDir()
+	resolvedPUUID := strings.Repeat("r", 40)
+	namelessPUUID := strings.Repeat("n", 40)
+	writeFixture(t, dir, "riot/account/v1/accounts/by-puuid/"+resolvedPUUID+".json", `{"puuid":"`+resolvedPUUID+`","gameName":"Ranked","tagLine":"BR1"}`)
+	writeFixture(t, dir, "riot/account/v1/accounts/by-puuid/"+namelessPUUID+".json", `{"puuid":"`+namelessPUUID+`"}`)
+	client := newCachedFixtureClient(dir)
+
+	tests := []struct {
+		name     string
+		puuid    string
+		expected string
+	}{
+		{name: "resolved", puuid: resolvedPUUID, expected: "Ranked#BR1"},
+		{name: "no game name", puuid: namelessPUUID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(SummonerNameTask{PUUID: tt.puuid, Region: "BR1"})
+			var events []SummonerNameResolvedEvent
+			err := processSummonerNameTask(t.Context(), &nats.Msg{Data: data}, client, client.cache, func(_ context.Context, event SummonerNameResolvedEvent) {
+				events = append(events, event)
+			})
+			if err != nil {
+				t.Fatalf("processSummonerNameTask() error = %v", err)
+			}
+
+			if tt.expected == "" {
+				if len(events) != 0 {
+					t.Errorf("events = %+v, expected none", events)
+				}
+				return
+			}
+			if len(events) != 1 {
+				t.Fatalf("events = %+v, expected one", events)
+			}
+			event := events[0]
+			if event.PUUID != tt.puuid || event.Region != "BR1" || event.Name != tt.expected || event.GameName != "Ranked" || event.TagLine != "BR1" || event.ResolvedAt.IsZero() {
+				t.Errorf("event = %+v", event)
+			}
+		})
+	}
+}
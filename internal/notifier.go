@@ -0,0 +1,398 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const notificationDeadLetterSubject = "tft.notify.dead_letter"
+
+// notifyMaxRetryAfter is the longest Retry-After a sink waits for; a
+// destination asking for more is given up on and the event dead-lettered.
+const notifyMaxRetryAfter = time.Minute
+
+// NotificationSink delivers a rank change to one external destination.
+type NotificationSink interface {
+	Name() string
+	Send(ctx context.Context, event RankChangeEvent) error
+}
+
+// permanentError marks a delivery failure that retrying will not fix, such as
+// a 4xx other than 429 from the destination.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// retryAfterError is a 429 that named how long to wait before the next try.
+type retryAfterError struct {
+	err   error
+	delay time.Duration
+}
+
+func (e retryAfterError) Error() string { return e.err.Error() }
+func (e retryAfterError) Unwrap() error { return e.err }
+
+// parseRetryAfter reads Retry-After as seconds or an HTTP date; zero means
+// absent or unusable.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// NotificationDeadLetter is published to tft.notify.dead_letter when a sink
+// gives up on an event.
+type NotificationDeadLetter struct {
+	Sink     string          `json:"sink"`
+	Event    RankChangeEvent `json:"event"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	FailedAt time.Time       `json:"failedAt"`
+}
+
+// parseNotifyURLs reads a comma-separated list of http(s) URLs.
+func parseNotifyURLs(value string) ([]string, error) {
+	var urls []string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		u, err := url.Parse(entry)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid URL %q", entry)
+		}
+		urls = append(urls, entry)
+	}
+	return urls, nil
+}
+
+// signWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>", so a
+// captured request cannot be replayed with a different timestamp.
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON sends body and maps the response status to an error; 4xx other
+// than 408 and 429 are permanent, and a 429 carries its Retry-After.
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected status %d", resp.StatusCode)
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if delay := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); delay > 0 {
+			return retryAfterError{err: err, delay: delay}
+		}
+	}
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
+
+// webhookSink posts the event as JSON. With a secret configured every request
+// carries X-TFT-Timestamp and X-TFT-Signature: sha256=<hmac>.
+type webhookSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func (s *webhookSink) Name() string {
+	if u, err := url.Parse(s.url); err == nil {
+		return "webhook:" + u.Host
+	}
+	return "webhook"
+}
+
+func (s *webhookSink) Send(ctx context.Context, event RankChangeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return permanentError{err}
+	}
+
+	header := http.Header{}
+	header.Set("X-TFT-Event", rankChangedSubject)
+	if s.secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		header.Set("X-TFT-Timestamp", timestamp)
+		header.Set("X-TFT-Signature", "sha256="+signWebhook(s.secret, timestamp, body))
+	}
+	return postJSON(ctx, s.client, s.url, body, header)
+}
+
+// discordSink posts a short message to a Discord channel webhook.
+type discordSink struct {
+	url    string
+	client *http.Client
+}
+
+func (s *discordSink) Name() string {
+	return "discord"
+}
+
+func discordMessage(event RankChangeEvent) map[string]any {
+	formatRank := func(rank RankSnapshot) string {
+		if rank.Tier == "" {
+			return "Unranked"
+		}
+		return fmt.Sprintf("%s %s %d LP", rank.Tier, rank.Rank, rank.LeaguePoints)
+	}
+
+	color := 0x2ecc71
+	if event.LPDelta < 0 {
+		color = 0xe74c3c
+	}
+	return map[string]any{
+		"embeds": []map[string]any{{
+			"title":       fmt.Sprintf("Rank change on %s", event.Region),
+			"description": fmt.Sprintf("`%s`\n%s → %s (%+d LP)", event.PUUID, formatRank(event.Previous), formatRank(event.Current), event.LPDelta),
+			"color":       color,
+			"timestamp":   event.ChangedAt.Format(time.RFC3339),
+		}},
+	}
+}
+
+func (s *discordSink) Send(ctx context.Context, event RankChangeEvent) error {
+	body, err := json.Marshal(discordMessage(event))
+	if err != nil {
+		return permanentError{err}
+	}
+	return postJSON(ctx, s.client, s.url, body, nil)
+}
+
+// Notifier fans rank changes out to every sink. Each sink is delivered in its
+// own goroutine and retries on its own with exponential backoff, or after the
+// destination's Retry-After on a 429, and an event a sink gives up on is published to
+// tft.notify.dead_letter so it can be inspected or replayed.
+type Notifier struct {
+	sinks       []NotificationSink
+	logger      *Logger
+	maxAttempts int
+	backoff     time.Duration
+
+	deadLetter func(ctx context.Context, subject string, data []byte) error
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+func NewNotifier(cfg *Config, logger *Logger) *Notifier {
+	timeout := cfg.NotifyTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	client := &http.Client{Timeout: timeout}
+
+	// validate() rejects malformed lists at startup.
+	webhooks, _ := parseNotifyURLs(cfg.NotifyWebhookURLs)
+	discord, _ := parseNotifyURLs(cfg.NotifyDiscordWebhookURLs)
+
+	var sinks []NotificationSink
+	for _, target := range webhooks {
+		sinks = append(sinks, &webhookSink{url: target, secret: cfg.NotifyWebhookSecret, client: client})
+	}
+	for _, target := range discord {
+		sinks = append(sinks, &discordSink{url: target, client: client})
+	}
+
+	return newNotifier(sinks, cfg.NotifyMaxAttempts, cfg.NotifyRetryBackoff, logger)
+}
+
+func newNotifier(sinks []NotificationSink, maxAttempts int, backoff time.Duration, logger *Logger) *Notifier {
+	if maxAttempts < 1 {
+		maxAttempts = 5
+	}
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	return &Notifier{
+		sinks:       sinks,
+		logger:      logger,
+		maxAttempts: maxAttempts,
+		backoff:     backoff,
+		sleep:       sleepContext,
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (n *Notifier) Enabled() bool {
+	return len(n.sinks) > 0
+}
+
+func (nc *NATSClient) StartNotificationWorker(notifier *Notifier) error {
+	notifier.deadLetter = nc.Publish
+
+	handler := func(msg *nats.Msg) {
+		ctx, span := startConsumerSpan(msg)
+		defer span.End()
+		notifier.process(ctx, msg)
+	}
+
+	if _, err := nc.startWorkerPool("notifier", []string{rankChangedSubject}, "notification-workers", nc.notifyPool, handler); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (n *Notifier) process(ctx context.Context, msg *nats.Msg) {
+	var event RankChangeEvent
+	if err := json.Unmarshal(msg.Data, &event); err != nil || event.PUUID == "" {
+		if err == nil {
+			err = errors.New("missing puuid")
+		}
+		n.logger.Warn("notification_invalid_event").
+			Component("notifier").
+			Operation("decode").
+			Err(err).
+			Log()
+		return
+	}
+	n.Notify(ctx, event)
+}
+
+// Notify delivers event to every sink concurrently and returns once each has
+// succeeded or given up; a slow or failing sink does not hold back the others.
+func (n *Notifier) Notify(ctx context.Context, event RankChangeEvent) {
+	var wg sync.WaitGroup
+	for _, sink := range n.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n.deliver(ctx, sink, event)
+		}()
+	}
+	wg.Wait()
+}
+
+func (n *Notifier) deliver(ctx context.Context, sink NotificationSink, event RankChangeEvent) {
+	start := time.Now()
+	backoff := n.backoff
+	wait := backoff
+	attempts := 0
+	var err error
+	for attempts < n.maxAttempts {
+		if attempts > 0 {
+			if sleepErr := n.sleep(ctx, wait); sleepErr != nil {
+				break
+			}
+			backoff *= 2
+			wait = backoff
+		}
+		attempts++
+		if err = sink.Send(ctx, event); err == nil {
+			n.logger.Info("notification_delivered").
+				Component("notifier").
+				Operation("deliver").
+				Game(event.PUUID, event.Region, event.Current.Tier).
+				Meta("sink", sink.Name()).
+				Meta("attempts", attempts).
+				Duration(time.Since(start)).
+				Log()
+			return
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) {
+			break
+		}
+		var throttled retryAfterError
+		if errors.As(err, &throttled) {
+			if throttled.delay > notifyMaxRetryAfter {
+				break
+			}
+			wait = throttled.delay
+		}
+	}
+
+	n.logger.Error("notification_failed").
+		Component("notifier").
+		Operation("deliver").
+		Game(event.PUUID, event.Region, event.Current.Tier).
+		Meta("sink", sink.Name()).
+		Meta("attempts", attempts).
+		Err(err).
+		Duration(time.Since(start)).
+		Log()
+	n.sendDeadLetter(ctx, sink, event, attempts, err)
+}
+
+func (n *Notifier) sendDeadLetter(ctx context.Context, sink NotificationSink, event RankChangeEvent, attempts int, cause error) {
+	if n.deadLetter == nil {
+		return
+	}
+
+	letter := NotificationDeadLetter{
+		Sink:     sink.Name(),
+		Event:    event,
+		Attempts: attempts,
+		FailedAt: time.Now().UTC(),
+	}
+	if cause != nil {
+		letter.Error = cause.Error()
+	}
+	data, err := json.Marshal(letter)
+	if err == nil {
+		// The event's own context may be the reason delivery stopped.
+		err = n.deadLetter(context.WithoutCancel(ctx), notificationDeadLetterSubject, data)
+	}
+	if err != nil {
+		n.logger.Error("notification_dead_letter_failed").
+			Component("notifier").
+			Operation("dead_letter").
+			Game(event.PUUID, event.Region, event.Current.Tier).
+			Meta("sink", sink.Name()).
+			Err(err).
+			Log()
+	}
+}
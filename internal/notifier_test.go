@@ -0,0 +1,243 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testRankChange() RankChangeEvent {
+	return RankChangeEvent{
+		PUUID:     "puuid-1",
+		Region:    "BR1",
+		Previous:  RankSnapshot{Tier: "GOLD", Rank: "I", LeaguePoints: 90},
+		Current:   RankSnapshot{Tier: "PLATINUM", Rank: "IV", LeaguePoints: 10},
+		LPDelta:   20,
+		ChangedAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestParseNotifyURLs(t *testing.T) {
+	urls, err := parseNotifyURLs("https://example.com/hook, http://10.0.0.1:8080/x")
+	if err != nil || len(urls) != 2 {
+		t.Fatalf("parseNotifyURLs() = %v, %v, expected two URLs", urls, err)
+	}
+	if urls, err := parseNotifyURLs(""); urls != nil || err != nil {
+		t.Errorf("parseNotifyURLs(empty) = %v, %v, expected nothing", urls, err)
+	}
+	for _, value := range []string{"example.com/hook", "ftp://example.com", "https://"} {
+		if _, err := parseNotifyURLs(value); err == nil {
+			t.Errorf("parseNotifyURLs(%q) expected error but got nil", value)
+		}
+	}
+}
+
+func TestWebhookSink_Signature(t *testing.T) {
+	var got RankChangeEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		expected := "sha256=" + signWebhook("secret", r.Header.Get("X-TFT-Timestamp"), body)
+		if r.Header.Get("X-TFT-Signature") != expected {
+			t.Errorf("signature = %q, expected %q", r.Header.Get("X-TFT-Signature"), expected)
+		}
+		if r.Header.Get("X-TFT-Event") != rankChangedSubject {
+			t.Errorf("X-TFT-Event = %q", r.Header.Get("X-TFT-Event"))
+		}
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	sink := &webhookSink{url: server.URL, secret: "secret", client: server.Client()}
+	if err := sink.Send(context.Background(), testRankChange()); err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if got.PUUID != "puuid-1" || got.Current.Tier != "PLATINUM" {
+		t.Errorf("delivered event = %+v", got)
+	}
+}
+
+func TestDiscordMessage(t *testing.T) {
+	embeds := discordMessage(testRankChange())["embeds"].([]map[string]any)
+	expected := "`puuid-1`\nGOLD I 90 LP → PLATINUM IV 10 LP (+20 LP)"
+	if embeds[0]["description"] != expected {
+		t.Errorf("description = %q, expected %q", embeds[0]["description"], expected)
+	}
+}
+
+type stubSink struct {
+	calls atomic.Int32
+	send  func(call int) error
+}
+
+func (s *stubSink) Name() string { return "stub" }
+
+func (s *stubSink) Send(ctx context.Context, event RankChangeEvent) error {
+	return s.send(int(s.calls.Add(1)))
+}
+
+func TestNotifier_Deliver(t *testing.T) {
+	transient := postJSONStatus(t, http.StatusServiceUnavailable)
+	permanent := postJSONStatus(t, http.StatusNotFound)
+
+	tests := []struct {
+		name         string
+		send         func(call int) error
+		expectedCall int
+		deadLetter   bool
+	}{
+		{name: "first attempt", send: func(int) error { return nil }, expectedCall: 1},
+		{name: "retried until success", send: func(call int) error {
+			if call < 3 {
+				return transient
+			}
+			return nil
+		}, expectedCall: 3},
+		{name: "gives up after max attempts", send: func(int) error { return transient }, expectedCall: 4, deadLetter: true},
+		{name: "permanent failure is not retried", send: func(int) error { return permanent }, expectedCall: 1, deadLetter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &stubSink{send: tt.send}
+			notifier := newNotifier([]NotificationSink{sink}, 4, time.Millisecond, newTestLogger())
+			var sleeps []time.Duration
+			notifier.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}
+			var letters []NotificationDeadLetter
+			notifier.deadLetter = func(ctx context.Context, subject string, data []byte) error {
+				if subject != notificationDeadLetterSubject {
+					t.Errorf("dead letter subject = %q", subject)
+				}
+				var letter NotificationDeadLetter
+				_ = json.Unmarshal(data, &letter)
+				letters = append(letters, letter)
+				return nil
+			}
+
+			notifier.Notify(context.Background(), testRankChange())
+
+			if int(sink.calls.Load()) != tt.expectedCall {
+				t.Errorf("sends = %d, expected %d", sink.calls.Load(), tt.expectedCall)
+			}
+			for i := 1; i < len(sleeps); i++ {
+				if sleeps[i] != 2*sleeps[i-1] {
+					t.Errorf("backoff = %v, expected each wait to double", sleeps)
+				}
+			}
+			if (len(letters) == 1) != tt.deadLetter {
+				t.Fatalf("dead letters = %d, expected dead letter %v", len(letters), tt.deadLetter)
+			}
+			if tt.deadLetter && (letters[0].Attempts != tt.expectedCall || letters[0].Event.PUUID != "puuid-1" || letters[0].Error == "") {
+				t.Errorf("dead letter = %+v", letters[0])
+			}
+		})
+	}
+}
+
+func TestNotifier_HonorsRetryAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryAfter    string
+		expectedCalls int32
+		expectedSleep []time.Duration
+		deadLetter    bool
+	}{
+		{name: "waits the requested seconds", retryAfter: "7", expectedCalls: 2, expectedSleep: []time.Duration{7 * time.Second}},
+		{name: "without retry-after falls back to backoff", expectedCalls: 2, expectedSleep: []time.Duration{time.Millisecond}},
+		{name: "gives up on a wait beyond the cap", retryAfter: "3600", expectedCalls: 1, deadLetter: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			}))
+			defer server.Close()
+
+			sink := &webhookSink{url: server.URL, client: server.Client()}
+			notifier := newNotifier([]NotificationSink{sink}, 4, time.Millisecond, newTestLogger())
+			var sleeps []time.Duration
+			notifier.sleep = func(ctx context.Context, d time.Duration) error {
+				sleeps = append(sleeps, d)
+				return nil
+			}
+			letters := 0
+			notifier.deadLetter = func(ctx context.Context, subject string, data []byte) error {
+				letters++
+				return nil
+			}
+
+			notifier.Notify(context.Background(), testRankChange())
+
+			if calls.Load() != tt.expectedCalls {
+				t.Errorf("sends = %d, expected %d", calls.Load(), tt.expectedCalls)
+			}
+			if !reflect.DeepEqual(sleeps, tt.expectedSleep) {
+				t.Errorf("sleeps = %v, expected %v", sleeps, tt.expectedSleep)
+			}
+			if (letters == 1) != tt.deadLetter {
+				t.Errorf("dead letters = %d, expected dead letter %v", letters, tt.deadLetter)
+			}
+		})
+	}
+}
+
+func TestNotifier_DeliversSinksConcurrently(t *testing.T) {
+	fastDone := make(chan struct{})
+	slow := &stubSink{send: func(int) error {
+		select {
+		case <-fastDone:
+			return nil
+		case <-time.After(5 * time.Second):
+			return permanentError{errors.New("fast sink waited behind the slow one")}
+		}
+	}}
+	fast := &stubSink{send: func(int) error {
+		close(fastDone)
+		return nil
+	}}
+
+	notifier := newNotifier([]NotificationSink{slow, fast}, 1, time.Millisecond, newTestLogger())
+	letters := 0
+	notifier.deadLetter = func(ctx context.Context, subject string, data []byte) error {
+		letters++
+		return nil
+	}
+
+	notifier.Notify(context.Background(), testRankChange())
+
+	if slow.calls.Load() != 1 || fast.calls.Load() != 1 || letters != 0 {
+		t.Errorf("slow sends = %d, fast sends = %d, dead letters = %d; expected both delivered", slow.calls.Load(), fast.calls.Load(), letters)
+	}
+}
+
+// postJSONStatus returns the error postJSON reports for a response status.
+func postJSONStatus(t *testing.T, status int) error {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	err := postJSON(context.Background(), server.Client(), server.URL, []byte("{}"), nil)
+	if err == nil {
+		t.Fatalf("postJSON() with status %d expected error but got nil", status)
+	}
+	return err
+}
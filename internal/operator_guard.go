@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// OperatorGuard restricts operator routes (/metrics and /admin/*) to callers
+// holding OPERATOR_TOKEN and/or connecting from OPERATOR_ALLOWED_IPS. It sits
+// in front of API key auth: with both configured a request must pass both
+// checks, and with neither it lets every request through.
+type OperatorGuard struct {
+	token   string
+	allowed []netip.Prefix
+	logger  *Logger
+}
+
+func NewOperatorGuard(cfg *Config, logger *Logger) *OperatorGuard {
+	allowed, err := parseIPAllowlist(cfg.OperatorAllowedIPs)
+	if err != nil {
+		// validate() rejects this at startup; fail closed if it slips through.
+		logger.Error("operator_allowlist_invalid").
+			Component("operator_guard").
+			Operation("init").
+			Err(err).
+			Meta("value", cfg.OperatorAllowedIPs).
+			Log()
+		allowed = []netip.Prefix{}
+	}
+
+	return &OperatorGuard{
+		token:   cfg.OperatorToken,
+		allowed: allowed,
+		logger:  logger,
+	}
+}
+
+// parseIPAllowlist reads a comma-separated list of addresses and CIDR
+// ranges. A nil result means no allowlist is configured.
+func parseIPAllowlist(value string) ([]netip.Prefix, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or range %q", entry)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// allowedAddr matches the connection's address; X-Forwarded-For is not
+// trusted, so behind a proxy the allowlist has to name the proxy.
+func (g *OperatorGuard) allowedAddr(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range g.allowed {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// operatorToken reads the token from X-Operator-Token or, as Prometheus
+// sends it, Authorization: Bearer. fromAuthorization reports the latter so
+// the header can be dropped before API key auth reads it.
+func operatorToken(r *http.Request) (token string, fromAuthorization bool) {
+	if token := r.Header.Get("X-Operator-Token"); token != "" {
+		return token, false
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")), true
+	}
+	return "", false
+}
+
+func (g *OperatorGuard) Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		if g.allowed != nil && !g.allowedAddr(r.RemoteAddr) {
+			g.reject(w, r, "address", NewAPIError("Address not allowed", http.StatusForbidden))
+			return
+		}
+
+		if g.token != "" {
+			token, fromAuthorization := operatorToken(r)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(g.token)) != 1 {
+				g.reject(w, r, "token", NewAPIError("Operator token required", http.StatusUnauthorized))
+				return
+			}
+			if fromAuthorization {
+				r = r.Clone(r.Context())
+				r.Header.Del("Authorization")
+			}
+		}
+
+		next(w, r)
+	}
+}
+
+func (g *OperatorGuard) reject(w http.ResponseWriter, r *http.Request, check string, err APIError) {
+	g.logger.Warn("operator_route_rejected").
+		Component("operator_guard").
+		Operation("authorize").
+		Request(r.UserAgent(), r.RemoteAddr, GetRequestID(r.Context())).
+		Meta("path", r.URL.Path).
+		Meta("check", check).
+		Meta("status", err.Status).
+		Log()
+
+	setCORSHeaders(w, r)
+	writeError(w, err, g.logger, r)
+}
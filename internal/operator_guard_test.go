@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseIPAllowlist(t *testing.T) {
+	prefixes, err := parseIPAllowlist("10.0.0.0/8, 192.168.1.7,::1")
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if len(prefixes) != 3 || prefixes[1].Bits() != 32 || prefixes[2].Bits() != 128 {
+		t.Errorf("parseIPAllowlist() = %v, expected a range and two single addresses", prefixes)
+	}
+
+	if prefixes, err := parseIPAllowlist(" "); prefixes != nil || err != nil {
+		t.Errorf("parseIPAllowlist(empty) = %v, %v, expected no allowlist", prefixes, err)
+	}
+	for _, value := range []string{"10.0.0.0/33", "prometheus", "10.0.0.1:9090"} {
+		if _, err := parseIPAllowlist(value); err == nil {
+			t.Errorf("parseIPAllowlist(%q) expected error but got nil", value)
+		}
+	}
+}
+
+func TestOperatorGuard_Handler(t *testing.T) {
+	tests := []struct {
+		name       string
+		token      string
+		allowed    string
+		remoteAddr string
+		header     string
+		value      string
+		expected   int
+		keepsAuth  bool
+	}{
+		{name: "unconfigured", remoteAddr: "203.0.113.9:4000", expected: http.StatusOK, keepsAuth: true},
+		{name: "allowed address", allowed: "10.0.0.0/8", remoteAddr: "10.1.2.3:4000", expected: http.StatusOK, keepsAuth: true},
+		{name: "mapped ipv4 address", allowed: "10.0.0.0/8", remoteAddr: "[::ffff:10.1.2.3]:4000", expected: http.StatusOK, keepsAuth: true},
+		{name: "address outside allowlist", allowed: "10.0.0.0/8", remoteAddr: "203.0.113.9:4000", expected: http.StatusForbidden},
+		{name: "missing token", token: "scrape", remoteAddr: "10.1.2.3:4000", expected: http.StatusUnauthorized},
+		{name: "wrong token", token: "scrape", remoteAddr: "10.1.2.3:4000", header: "X-Operator-Token", value: "nope", expected: http.StatusUnauthorized},
+		{name: "token header", token: "scrape", remoteAddr: "10.1.2.3:4000", header: "X-Operator-Token", value: "scrape", expected: http.StatusOK, keepsAuth: true},
+		{name: "bearer token", token: "scrape", remoteAddr: "10.1.2.3:4000", header: "Authorization", value: "Bearer scrape", expected: http.StatusOK},
+		{name: "token from outside allowlist", token: "scrape", allowed: "10.0.0.0/8", remoteAddr: "203.0.113.9:4000", header: "X-Operator-Token", value: "scrape", expected: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guard := NewOperatorGuard(&Config{OperatorToken: tt.token, OperatorAllowedIPs: tt.allowed}, newTestLogger())
+
+			var authorization string
+			handler := guard.Handler(func(w http.ResponseWriter, r *http.Request) {
+				authorization = r.Header.Get("Authorization")
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set("Authorization", "Bearer api-key")
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.expected {
+				t.Fatalf("status = %d, expected %d (%s)", rec.Code, tt.expected, rec.Body.String())
+			}
+			if rec.Code == http.StatusOK && (authorization != "") != tt.keepsAuth {
+				t.Errorf("Authorization reaching the handler = %q, expected kept: %v", authorization, tt.keepsAuth)
+			}
+		})
+	}
+}
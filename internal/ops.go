@@ -0,0 +1,161 @@
+package internal
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	opsKindDegraded = "degraded"
+	opsKindDisabled = "disabled"
+	opsKindOverride = "override"
+)
+
+// OpsToggle is one piece of non-default operational state.
+type OpsToggle struct {
+	Kind    string    `json:"kind"`
+	Name    string    `json:"name"`
+	Value   string    `json:"value"`
+	Default string    `json:"default,omitempty"`
+	SetBy   string    `json:"setBy"`
+	SetAt   time.Time `json:"setAt"`
+}
+
+// opsSettings are the settings on-call changes during incidents, disabled
+// subsystems first. One is active while its value differs from the default
+// LoadConfig falls back to.
+var opsSettings = []struct {
+	key   string
+	kind  string
+	def   string
+	value func(*Config) string
+}{
+	{"CACHE_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.CacheEnabled) }},
+	{"RESPONSE_CACHE_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.ResponseCacheEnabled) }},
+	{"DATABASE_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.DatabaseEnabled) }},
+	{"CACHE_VERIFY_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.CacheVerifyEnabled) }},
+	{"CACHE_PRUNE_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.CachePruneEnabled) }},
+	{"SUMMONER_CACHE_PRUNE_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.SummonerCachePruneEnabled) }},
+	{"STATIC_DATA_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.StaticDataEnabled) }},
+	{"METRICS_PERSISTENCE_ENABLED", opsKindDisabled, "true", func(c *Config) string { return strconv.FormatBool(c.MetricsPersistenceEnabled) }},
+	{"RIOT_MODE", opsKindOverride, RiotModeLive, func(c *Config) string { return c.RiotMode }},
+	{"LOG_LEVEL", opsKindOverride, "info", func(c *Config) string { return c.LogLevel }},
+	{"LOG_SAMPLING", opsKindOverride, "", func(c *Config) string { return c.LogSampling }},
+	{"RATE_LIMIT_METHOD_LIMITS", opsKindOverride, "", func(c *Config) string { return c.RateLimitMethodLimits }},
+	{"ENDPOINT_LIMITS", opsKindOverride, "", func(c *Config) string { return c.EndpointLimits }},
+	{"REQUEST_TIMEOUTS", opsKindOverride, "", func(c *Config) string { return c.RequestTimeouts }},
+	{"BRANCH_TIMEOUTS", opsKindOverride, "", func(c *Config) string { return c.BranchTimeouts }},
+	{"RESPONSE_CACHE_TTL", opsKindOverride, "5s", func(c *Config) string { return c.ResponseCacheTTL.String() }},
+	{"API_KEY_CACHE_TTL", opsKindOverride, "1m0s", func(c *Config) string { return c.APIKeyCacheTTL.String() }},
+	{"ACCOUNT_NEGATIVE_CACHE_TTL", opsKindOverride, "5m0s", func(c *Config) string { return c.AccountNegativeCacheTTL.String() }},
+	{"LEAGUE_LAST_KNOWN_GOOD_MAX_AGE", opsKindOverride, "24h0m0s", func(c *Config) string { return c.LeagueLastKnownGoodMaxAge.String() }},
+	{"PLACEMENT_STATS_CACHE_TTL", opsKindOverride, "5m0s", func(c *Config) string { return c.PlacementStatsCacheTTL.String() }},
+}
+
+func isReloadableSetting(key string) bool {
+	for _, setting := range reloadableSettings {
+		if setting.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveSettings lists the ops settings away from their defaults. Settings a
+// reload cannot change are read from the startup configuration, since that
+// is what is running even if the environment has changed since.
+func (cw *ConfigWatcher) ActiveSettings() []OpsToggle {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	var active []OpsToggle
+	for _, setting := range opsSettings {
+		cfg := cw.startup
+		if isReloadableSetting(setting.key) {
+			cfg = cw.current
+		}
+		value := setting.value(cfg)
+		if value == setting.def {
+			continue
+		}
+
+		source, ok := cw.sources[setting.key]
+		if !ok {
+			source = settingSource{by: "startup", at: cw.startedAt}
+		}
+		active = append(active, OpsToggle{
+			Kind:    setting.kind,
+			Name:    setting.key,
+			Value:   value,
+			Default: setting.def,
+			SetBy:   source.by,
+			SetAt:   source.at,
+		})
+	}
+	return active
+}
+
+// degradedSubsystems reports optional startup steps that never came up and a
+// database opened read-only after a schema mismatch. Both last until restart.
+func degradedSubsystems(boot *Bootstrapper, db *DatabaseManager) []OpsToggle {
+	var degraded []OpsToggle
+	var startedAt time.Time
+	if boot != nil {
+		progress := boot.Progress()
+		startedAt = progress.StartedAt
+		for _, step := range progress.Steps {
+			if step.Status != stepSkipped {
+				continue
+			}
+			degraded = append(degraded, OpsToggle{
+				Kind:  opsKindDegraded,
+				Name:  step.Name,
+				Value: step.LastError,
+				SetBy: "bootstrap",
+				SetAt: startedAt,
+			})
+		}
+	}
+	// The read-only fallback is chosen by the database startup step.
+	if db != nil && db.ReadOnly {
+		degraded = append(degraded, OpsToggle{
+			Kind:  opsKindDegraded,
+			Name:  "database_read_only",
+			Value: "true",
+			SetBy: "SCHEMA_MISMATCH_MODE",
+			SetAt: startedAt,
+		})
+	}
+	return degraded
+}
+
+// requestActor names who made an admin request in logs and ops records.
+func requestActor(r *http.Request) string {
+	if principal := GetPrincipal(r.Context()); principal != nil {
+		return principal.Name
+	}
+	return AnonymousTenant
+}
+
+// OpsHandler serves /admin/ops: everything currently running in a non-default
+// state, so on-call can see at a glance what was switched off or overridden.
+func OpsHandler(watcher *ConfigWatcher, boot *Bootstrapper, db *DatabaseManager, logger *Logger) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+			return
+		}
+
+		active := append(degradedSubsystems(boot, db), watcher.ActiveSettings()...)
+		if active == nil {
+			active = []OpsToggle{}
+		}
+
+		writeJSON(w, map[string]interface{}{
+			"active":      active,
+			"count":       len(active),
+			"generatedAt": time.Now().UTC(),
+		}, logger, r)
+	})
+}
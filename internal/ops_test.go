@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func setOpsTestEnv(t *testing.T) {
+	t.Setenv("RIOT_API_KEY", "test-key")
+	t.Setenv("RIOT_BASE_URL", "https://br1.api.riotgames.com")
+	t.Setenv("CONFIG_FILE", "")
+	for _, setting := range opsSettings {
+		t.Setenv(setting.key, "")
+	}
+}
+
+func TestOpsSettings_DefaultsMatchLoadConfig(t *testing.T) {
+	setOpsTestEnv(t)
+	t.Setenv("POSTGRES_USER", "tft")
+	t.Setenv("POSTGRES_PASSWORD", "secret")
+	t.Setenv("POSTGRES_DB", "tft")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	for _, setting := range opsSettings {
+		if got := setting.value(cfg); got != setting.def {
+			t.Errorf("%s default = %q, LoadConfig gives %q", setting.key, setting.def, got)
+		}
+	}
+}
+
+func TestConfigWatcher_ActiveSettings(t *testing.T) {
+	setOpsTestEnv(t)
+	t.Setenv("DATABASE_ENABLED", "false")
+	t.Setenv("LOG_LEVEL", "error")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	watcher := NewConfigWatcher(cfg, newTestLogger())
+
+	t.Setenv("RESPONSE_CACHE_TTL", "30s")
+	// Not reloadable: keeps reporting the value the process started with.
+	t.Setenv("CACHE_ENABLED", "false")
+	if _, err := watcher.Reload("oncall"); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	active := make(map[string]OpsToggle)
+	for _, toggle := range watcher.ActiveSettings() {
+		active[toggle.Name] = toggle
+	}
+
+	if len(active) != 3 {
+		t.Fatalf("ActiveSettings() = %v, expected 3 toggles", active)
+	}
+	if toggle := active["DATABASE_ENABLED"]; toggle.Kind != opsKindDisabled || toggle.SetBy != "startup" {
+		t.Errorf("DATABASE_ENABLED = %+v, expected disabled at startup", toggle)
+	}
+	if toggle := active["LOG_LEVEL"]; toggle.Value != "error" || toggle.SetBy != "startup" {
+		t.Errorf("LOG_LEVEL = %+v, expected error set at startup", toggle)
+	}
+	toggle := active["RESPONSE_CACHE_TTL"]
+	if toggle.Kind != opsKindOverride || toggle.Value != "30s" || toggle.Default != "5s" || toggle.SetBy != "oncall" || toggle.SetAt.IsZero() {
+		t.Errorf("RESPONSE_CACHE_TTL = %+v, expected 30s override set by oncall", toggle)
+	}
+}
+
+func TestOpsHandler(t *testing.T) {
+	setOpsTestEnv(t)
+	t.Setenv("DATABASE_ENABLED", "false")
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.StartupMaxAttempts = 1
+	logger := newTestLogger()
+
+	boot := NewBootstrapper(cfg, logger, false)
+	boot.Step("nats", false, func(ctx context.Context) error { return errors.New("connection refused") })
+	boot.Step("redis", false, func(ctx context.Context) error { return nil })
+	if err := boot.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	handler := OpsHandler(NewConfigWatcher(cfg, logger), boot, &DatabaseManager{ReadOnly: true}, logger)
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/admin/ops", nil))
+
+	var body struct {
+		Active []OpsToggle `json:"active"`
+		Count  int         `json:"count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	var names []string
+	for _, toggle := range body.Active {
+		names = append(names, toggle.Kind+":"+toggle.Name)
+	}
+	expected := []string{"degraded:nats", "degraded:database_read_only", "disabled:DATABASE_ENABLED"}
+	if body.Count != len(expected) || len(names) != len(expected) {
+		t.Fatalf("active = %v, expected %v", names, expected)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("active[%d] = %s, expected %s", i, names[i], expected[i])
+		}
+	}
+	if body.Active[0].Value != "connection refused" {
+		t.Errorf("nats value = %q, expected the startup error", body.Active[0].Value)
+	}
+
+	rec = httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/admin/ops", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, expected 405", rec.Code)
+	}
+}
@@ -0,0 +1,214 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+// outboxRetention is how long published rows are kept for inspection before
+// the relay prunes them.
+const outboxRetention = 7 * 24 * time.Hour
+
+// outboxFlushTimeout bounds the wait for the server to acknowledge a batch.
+const outboxFlushTimeout = 5 * time.Second
+
+// outboxPublisher is the part of NATSClient the relay uses.
+type outboxPublisher interface {
+	PublishWithID(ctx context.Context, subject, msgID string, data []byte) error
+	Flush(ctx context.Context) error
+}
+
+// OutboxMessage is an event to be written to the outbox by a DatabaseManager
+// method alongside the state change that caused it.
+type OutboxMessage struct {
+	Subject string
+	Payload interface{}
+}
+
+type outboxEvent struct {
+	ID      int64
+	Subject string
+	Payload []byte
+}
+
+// enqueueEvent writes an event in the caller's transaction, so it exists if
+// and only if the state change that produced it was committed.
+func (dm *DatabaseManager) enqueueEvent(ctx context.Context, tx *sql.Tx, subject string, event interface{}) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO event_outbox (subject, payload) VALUES ($1, $2)
+	`), subject, string(data))
+	return err
+}
+
+// outboxMessageID is stable across relay attempts, letting consumers drop a
+// message published again after a relay crash between publish and commit.
+func outboxMessageID(id int64) string {
+	return "outbox-" + strconv.FormatInt(id, 10)
+}
+
+type OutboxRelay struct {
+	db         *DatabaseManager
+	natsClient outboxPublisher
+	logger     *Logger
+	interval   time.Duration
+	batchSize  int
+}
+
+func NewOutboxRelay(cfg *Config, db *DatabaseManager, natsClient *NATSClient, logger *Logger) *OutboxRelay {
+	return &OutboxRelay{
+		db:         db,
+		natsClient: natsClient,
+		logger:     logger,
+		interval:   cfg.OutboxRelayInterval,
+		batchSize:  cfg.OutboxBatchSize,
+	}
+}
+
+func (or *OutboxRelay) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "outbox_relay",
+		Interval: or.interval,
+		Run: func(ctx context.Context) error {
+			_, err := or.RelayPending(ctx)
+			return err
+		},
+	}
+}
+
+// RelayPending publishes pending events in insertion order. Rows are locked
+// for the duration of the batch so concurrent instances skip them, and the
+// batch stops at the first failure to keep per-subject ordering. Publishing
+// only buffers the message, so rows are marked published after a flush
+// confirms the server received them; a failed flush leaves the whole batch
+// pending for the next run.
+func (or *OutboxRelay) RelayPending(ctx context.Context) (int, error) {
+	ctx, span := startDatabaseSpan(ctx, or.db.Dialect, "relay_outbox")
+	defer span.End()
+
+	tx, err := or.db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, subject, payload
+		FROM event_outbox
+		WHERE published_at IS NULL
+		ORDER BY id
+		LIMIT $1`
+	if or.db.Dialect == DatabaseDriverPostgres {
+		query += ` FOR UPDATE SKIP LOCKED`
+	}
+
+	events, err := or.loadPending(ctx, tx, query)
+	if err != nil {
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	sent, failed, sendErr := or.publish(ctx, events)
+	if len(sent) > 0 {
+		flushCtx, cancel := context.WithTimeout(ctx, outboxFlushTimeout)
+		err := or.natsClient.Flush(flushCtx)
+		cancel()
+		if err != nil {
+			or.logger.Warn("outbox_flush_failed").
+				Component("outbox").
+				Operation("relay").
+				Meta("events", len(sent)).
+				Err(err).
+				Log()
+			failed, sent, sendErr = append(sent, failed...), nil, err
+		}
+	}
+
+	for _, event := range failed {
+		_, err = tx.ExecContext(ctx, or.db.rebind(`
+			UPDATE event_outbox SET attempts = attempts + 1, last_error = $2 WHERE id = $1
+		`), event.ID, sendErr.Error())
+		if err != nil {
+			recordSpanError(span, err)
+			return 0, err
+		}
+	}
+
+	published := 0
+	for _, event := range sent {
+		_, err = tx.ExecContext(ctx, or.db.rebind(`
+			UPDATE event_outbox SET published_at = CURRENT_TIMESTAMP, attempts = attempts + 1, last_error = NULL WHERE id = $1
+		`), event.ID)
+		if err != nil {
+			recordSpanError(span, err)
+			return 0, err
+		}
+		published++
+	}
+
+	if _, err := tx.ExecContext(ctx, or.db.rebind(`
+		DELETE FROM event_outbox WHERE published_at < $1
+	`), time.Now().UTC().Add(-outboxRetention)); err != nil {
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		recordSpanError(span, err)
+		return 0, err
+	}
+
+	if published > 0 {
+		or.logger.Info("outbox_relayed").
+			Component("outbox").
+			Operation("relay").
+			Meta("published", published).
+			Meta("pending", len(events)-published).
+			Log()
+	}
+	return published, nil
+}
+
+// publish sends events until one fails, returning those sent and, on
+// failure, the one that failed.
+func (or *OutboxRelay) publish(ctx context.Context, events []outboxEvent) (sent, failed []outboxEvent, err error) {
+	for i, event := range events {
+		if err := or.natsClient.PublishWithID(ctx, event.Subject, outboxMessageID(event.ID), event.Payload); err != nil {
+			or.logger.Warn("outbox_publish_failed").
+				Component("outbox").
+				Operation("relay").
+				Meta("event_id", event.ID).
+				Meta("subject", event.Subject).
+				Err(err).
+				Log()
+			return events[:i], events[i : i+1], err
+		}
+	}
+	return events, nil, nil
+}
+
+func (or *OutboxRelay) loadPending(ctx context.Context, tx *sql.Tx, query string) ([]outboxEvent, error) {
+	rows, err := tx.QueryContext(ctx, or.db.rebind(query), or.batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []outboxEvent
+	for rows.Next() {
+		var event outboxEvent
+		if err := rows.Scan(&event.ID, &event.Subject, &event.Payload); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
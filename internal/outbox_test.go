@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeOutboxPublisher records published message ids; failAt fails that
+// publish (1-based) and flushErr fails the flush.
+type fakeOutboxPublisher struct {
+	mu       sync.Mutex
+	ids      []string
+	failAt   int
+	flushErr error
+	flushes  int
+}
+
+func (p *fakeOutboxPublisher) PublishWithID(ctx context.Context, subject, msgID string, data []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failAt == len(p.ids)+1 {
+		return errors.New("publish failed")
+	}
+	p.ids = append(p.ids, msgID)
+	return nil
+}
+
+func (p *fakeOutboxPublisher) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flushes++
+	if _, ok := ctx.Deadline(); !ok {
+		return errors.New("flush needs a deadline")
+	}
+	return p.flushErr
+}
+
+func enqueueTestEvents(t *testing.T, db *DatabaseManager, n int) {
+	t.Helper()
+	tx, err := db.DB.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	defer tx.Rollback()
+	for i := range n {
+		if err := db.enqueueEvent(t.Context(), tx, "tft.test", map[string]int{"n": i}); err != nil {
+			t.Fatalf("enqueueEvent() error = %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+}
+
+type outboxRow struct {
+	published bool
+	attempts  int
+	lastError bool
+}
+
+func loadOutboxRows(t *testing.T, db *DatabaseManager) []outboxRow {
+	t.Helper()
+	rows, err := db.DB.QueryContext(t.Context(), `SELECT published_at IS NOT NULL, attempts, last_error IS NOT NULL FROM event_outbox ORDER BY id`)
+	if err != nil {
+		t.Fatalf("query outbox: %v", err)
+	}
+	defer rows.Close()
+
+	var result []outboxRow
+	for rows.Next() {
+		var row outboxRow
+		if err := rows.Scan(&row.published, &row.attempts, &row.lastError); err != nil {
+			t.Fatalf("scan outbox: %v", err)
+		}
+		result = append(result, row)
+	}
+	return result
+}
+
+func TestOutboxMessageID_IsStable(t *testing.T) {
+	if outboxMessageID(42) != outboxMessageID(42) {
+		t.Error("message id should be stable across attempts")
+	}
+	if outboxMessageID(42) == outboxMessageID(43) {
+		t.Error("message ids should differ per outbox row")
+	}
+	if got := outboxMessageID(42); got != "outbox-42" {
+		t.Errorf("outboxMessageID(42) = %s, expected outbox-42", got)
+	}
+}
+
+func TestOutboxRelay_Task(t *testing.T) {
+	relay := NewOutboxRelay(&Config{OutboxRelayInterval: 5 * time.Second, OutboxBatchSize: 100}, &DatabaseManager{}, nil, newTestLogger())
+
+	task := relay.Task()
+	if task.Name != "outbox_relay" || task.Interval != 5*time.Second {
+		t.Errorf("unexpected task %s every %s", task.Name, task.Interval)
+	}
+}
+
+func TestOutboxRelay_RelayPending(t *testing.T) {
+	sent := outboxRow{published: true, attempts: 1}
+	failed := outboxRow{attempts: 1, lastError: true}
+	pending := outboxRow{}
+
+	tests := []struct {
+		name      string
+		publisher *fakeOutboxPublisher
+		published int
+		rows      []outboxRow
+	}{
+		{name: "all published", publisher: &fakeOutboxPublisher{}, published: 3, rows: []outboxRow{sent, sent, sent}},
+		{name: "stops at the first failed publish", publisher: &fakeOutboxPublisher{failAt: 2}, published: 1, rows: []outboxRow{sent, failed, pending}},
+		{name: "failed flush leaves the batch pending", publisher: &fakeOutboxPublisher{flushErr: errors.New("timeout")}, rows: []outboxRow{failed, failed, failed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newSQLiteTestDB(t)
+			enqueueTestEvents(t, db, 3)
+
+			relay := NewOutboxRelay(&Config{OutboxBatchSize: 10}, db, nil, newTestLogger())
+			relay.natsClient = tt.publisher
+			published, err := relay.RelayPending(t.Context())
+			if err != nil {
+				t.Fatalf("RelayPending() error = %v", err)
+			}
+			if published != tt.published {
+				t.Errorf("published = %d, expected %d", published, tt.published)
+			}
+			if tt.publisher.flushes != 1 {
+				t.Errorf("flushed %d times, expected once", tt.publisher.flushes)
+			}
+
+			rows := loadOutboxRows(t, db)
+			if len(rows) != len(tt.rows) {
+				t.Fatalf("rows = %+v, expected %+v", rows, tt.rows)
+			}
+			for i := range rows {
+				if rows[i] != tt.rows[i] {
+					t.Errorf("row %d = %+v, expected %+v", i, rows[i], tt.rows[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEnqueueEvent_FollowsTransaction(t *testing.T) {
+	db := newSQLiteTestDB(t)
+
+	tx, err := db.DB.BeginTx(t.Context(), nil)
+	if err != nil {
+		t.Fatalf("BeginTx() error = %v", err)
+	}
+	if err := db.enqueueEvent(t.Context(), tx, "tft.test", map[string]string{"state": "rolled back"}); err != nil {
+		t.Fatalf("enqueueEvent() error = %v", err)
+	}
+	tx.Rollback()
+	if rows := loadOutboxRows(t, db); len(rows) != 0 {
+		t.Fatalf("rolled back event was kept: %+v", rows)
+	}
+
+	enqueueTestEvents(t, db, 1)
+	if rows := loadOutboxRows(t, db); len(rows) != 1 || rows[0] != (outboxRow{}) {
+		t.Errorf("rows = %+v, expected one pending event", rows)
+	}
+}
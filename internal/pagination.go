@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+const leagueEntriesPageSize = 200
+
+type EntriesCursor struct {
+	Tier     string `json:"t"`
+	Division string `json:"d"`
+	Page     int    `json:"p"`
+}
+
+type PageInfo struct {
+	Cursor       string `json:"cursor"`
+	NextCursor   string `json:"nextCursor,omitempty"`
+	PrevCursor   string `json:"prevCursor,omitempty"`
+	Next         string `json:"next,omitempty"`
+	Prev         string `json:"prev,omitempty"`
+	HasMore      bool   `json:"hasMore"`
+	HasMoreKnown bool   `json:"hasMoreKnown"`
+	TotalKnown   bool   `json:"totalKnown"`
+	TotalEntries int    `json:"totalEntries,omitempty"`
+	TotalPages   int    `json:"totalPages,omitempty"`
+}
+
+func EncodeEntriesCursor(cursor EntriesCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeEntriesCursor(value string) (EntriesCursor, error) {
+	var cursor EntriesCursor
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	if cursor.Tier == "" || cursor.Division == "" || cursor.Page < 1 {
+		return cursor, fmt.Errorf("invalid cursor")
+	}
+	return cursor, nil
+}
+
+func (dm *DatabaseManager) GetCrawlExtent(ctx context.Context, region, tier, division string) (lastPage, total int, complete bool, err error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_crawl_extent")
+	defer span.End()
+
+	query := `
+		SELECT
+			COALESCE(MAX(page) FILTER (WHERE entries_count > 0), 0),
+			COALESCE(SUM(entries_count), 0),
+			COALESCE(BOOL_OR(NOT has_more), false)
+		FROM crawl_pages
+		WHERE region = $1 AND tier = $2 AND division = $3
+	`
+
+	err = dm.DB.QueryRowContext(ctx, query, region, tier, division).Scan(&lastPage, &total, &complete)
+	recordSpanError(span, err)
+	return lastPage, total, complete, err
+}
+
+// buildPageInfo uses crawl coverage when the whole ladder segment has been
+// seen; otherwise a full page only suggests that another page exists.
+func buildPageInfo(tier, division string, page, entriesCount, lastPage, total int, complete bool) PageInfo {
+	info := PageInfo{
+		Cursor: EncodeEntriesCursor(EntriesCursor{Tier: tier, Division: division, Page: page}),
+	}
+
+	switch {
+	case entriesCount < leagueEntriesPageSize:
+		info.HasMore = false
+		info.HasMoreKnown = true
+	case complete:
+		info.HasMore = page < lastPage
+		info.HasMoreKnown = true
+	default:
+		info.HasMore = true
+	}
+
+	if complete {
+		info.TotalKnown = true
+		info.TotalEntries = total
+		info.TotalPages = lastPage
+	}
+
+	if info.HasMore {
+		info.NextCursor = EncodeEntriesCursor(EntriesCursor{Tier: tier, Division: division, Page: page + 1})
+	}
+	if page > 1 {
+		info.PrevCursor = EncodeEntriesCursor(EntriesCursor{Tier: tier, Division: division, Page: page - 1})
+	}
+	return info
+}
+
+func (c *RiotAPIClient) paginateEntries(ctx context.Context, result *LeagueEntriesResponse) {
+	var lastPage, total int
+	var complete bool
+	if c.database != nil && c.database.Enabled {
+		var err error
+		lastPage, total, complete, err = c.database.GetCrawlExtent(ctx, c.region, result.Tier, result.Division)
+		if err != nil {
+			c.logger.Warn("crawl_extent_failed").
+				Component("riot_api").
+				Operation("paginate_entries").
+				Game("", c.region, result.Tier).
+				Meta("division", result.Division).
+				Err(err).
+				Log()
+			complete = false
+		}
+	}
+
+	info := buildPageInfo(result.Tier, result.Division, result.Page, len(result.Entries), lastPage, total, complete)
+	result.HasMore = info.HasMore
+	result.Pagination = &info
+}
+
+// SetLinks builds the next and previous links; extra carries query
+// parameters such as filters that every page of the listing keeps.
+func (p *PageInfo) SetLinks(path string, extra url.Values) {
+	suffix := ""
+	if encoded := extra.Encode(); encoded != "" {
+		suffix = "&" + encoded
+	}
+	if p.NextCursor != "" {
+		p.Next = path + "?cursor=" + url.QueryEscape(p.NextCursor) + suffix
+	}
+	if p.PrevCursor != "" {
+		p.Prev = path + "?cursor=" + url.QueryEscape(p.PrevCursor) + suffix
+	}
+}
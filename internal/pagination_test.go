@@ -0,0 +1,98 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEntriesCursor_RoundTrip(t *testing.T) {
+	cursor := EntriesCursor{Tier: "DIAMOND", Division: "II", Page: 3}
+
+	decoded, err := DecodeEntriesCursor(EncodeEntriesCursor(cursor))
+	if err != nil {
+		t.Fatalf("expected no error but got: %v", err)
+	}
+	if decoded != cursor {
+		t.Errorf("DecodeEntriesCursor() = %+v, expected %+v", decoded, cursor)
+	}
+}
+
+func TestDecodeEntriesCursor_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{name: "not base64", value: "%%%"},
+		{name: "not json", value: "bm90LWpzb24"},
+		{name: "missing page", value: EncodeEntriesCursor(EntriesCursor{Tier: "GOLD", Division: "I"})},
+		{name: "missing tier", value: EncodeEntriesCursor(EntriesCursor{Division: "I", Page: 1})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeEntriesCursor(tt.value); err == nil {
+				t.Error("expected error but got nil")
+			}
+		})
+	}
+}
+
+func TestBuildPageInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		page         int
+		entriesCount int
+		lastPage     int
+		total        int
+		complete     bool
+		hasMore      bool
+		hasMoreKnown bool
+		totalKnown   bool
+	}{
+		{name: "partial page is last", page: 4, entriesCount: 37, hasMore: false, hasMoreKnown: true},
+		{name: "full page with unknown coverage", page: 2, entriesCount: 200, hasMore: true},
+		{name: "full page before known end", page: 2, entriesCount: 200, lastPage: 3, total: 450, complete: true, hasMore: true, hasMoreKnown: true, totalKnown: true},
+		{name: "full page at known end", page: 3, entriesCount: 200, lastPage: 3, total: 600, complete: true, hasMore: false, hasMoreKnown: true, totalKnown: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := buildPageInfo("GOLD", "I", tt.page, tt.entriesCount, tt.lastPage, tt.total, tt.complete)
+			if info.HasMore != tt.hasMore {
+				t.Errorf("HasMore = %v, expected %v", info.HasMore, tt.hasMore)
+			}
+			if info.HasMoreKnown != tt.hasMoreKnown {
+				t.Errorf("HasMoreKnown = %v, expected %v", info.HasMoreKnown, tt.hasMoreKnown)
+			}
+			if info.TotalKnown != tt.totalKnown {
+				t.Errorf("TotalKnown = %v, expected %v", info.TotalKnown, tt.totalKnown)
+			}
+			if tt.totalKnown && info.TotalEntries != tt.total {
+				t.Errorf("TotalEntries = %d, expected %d", info.TotalEntries, tt.total)
+			}
+			if (info.NextCursor != "") != tt.hasMore {
+				t.Errorf("NextCursor = %q, expected present = %v", info.NextCursor, tt.hasMore)
+			}
+			if (info.PrevCursor != "") != (tt.page > 1) {
+				t.Errorf("PrevCursor = %q, expected present = %v", info.PrevCursor, tt.page > 1)
+			}
+		})
+	}
+}
+
+func TestPageInfo_SetLinks(t *testing.T) {
+	info := buildPageInfo("GOLD", "I", 2, 200, 0, 0, false)
+	info.SetLinks("/league/entries", nil)
+
+	if !strings.HasPrefix(info.Next, "/league/entries?cursor=") {
+		t.Errorf("Next = %q, expected cursor link", info.Next)
+	}
+	if !strings.HasPrefix(info.Prev, "/league/entries?cursor=") {
+		t.Errorf("Prev = %q, expected cursor link", info.Prev)
+	}
+
+	next, err := DecodeEntriesCursor(strings.TrimPrefix(info.Next, "/league/entries?cursor="))
+	if err != nil || next.Page != 3 {
+		t.Errorf("next cursor = %+v (err %v), expected page 3", next, err)
+	}
+}
@@ -0,0 +1,258 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+type PlacementRecord struct {
+	MatchID   string    `json:"matchId"`
+	Placement int       `json:"placement"`
+	Set       int       `json:"set"`
+	PlayedAt  time.Time `json:"playedAt"`
+}
+
+type PlacementStreak struct {
+	Kind   string `json:"kind"`
+	Length int    `json:"length"`
+}
+
+type PlacementSummary struct {
+	PUUID             string            `json:"puuid"`
+	Matches           int               `json:"matches"`
+	AveragePlacement  float64           `json:"averagePlacement"`
+	Top4Rate          float64           `json:"top4Rate"`
+	Wins              int               `json:"wins"`
+	CurrentStreak     PlacementStreak   `json:"currentStreak"`
+	CurrentWinStreak  int               `json:"currentWinStreak"`
+	LongestTop4Streak int               `json:"longestTop4Streak"`
+	Form              PlacementForm     `json:"form"`
+	Placements        []PlacementRecord `json:"placements"`
+}
+
+const (
+	streakTop4    = "top4"
+	streakBottom4 = "bottom4"
+)
+
+// ArchiveMatch stores the raw match together with one match_participants row
+// per player, so per-player history is served from an index instead of the
+// JSONB document.
+func (dm *DatabaseManager) ArchiveMatch(ctx context.Context, match *Match) error {
+	if dm == nil || !dm.Enabled {
+		return nil
+	}
+
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "archive_match")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := dm.archiveMatchTx(ctx, tx, match); err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	err = tx.Commit()
+	recordSpanError(span, err)
+	return err
+}
+
+func (dm *DatabaseManager) archiveMatchTx(ctx context.Context, tx *sql.Tx, match *Match) error {
+	data, err := json.Marshal(match)
+	if err != nil {
+		return err
+	}
+
+	playedAt := match.PlayedAt()
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO matches (match_id, set_number, game_version, played_at, data)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (match_id) DO NOTHING
+	`), match.Metadata.MatchID, match.Info.TFTSetNumber, match.Info.GameVersion, playedAt, string(data))
+	if err != nil {
+		return err
+	}
+
+	for _, participant := range matchParticipantRows(match) {
+		_, err = tx.ExecContext(ctx, dm.rebind(`
+			INSERT INTO match_participants (puuid, match_id, placement, played_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (puuid, match_id) DO NOTHING
+		`), participant.PUUID, match.Metadata.MatchID, participant.Placement, playedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, comp := range participantComps(participant) {
+			_, err = tx.ExecContext(ctx, dm.rebind(`
+				INSERT INTO match_participant_comps (kind, name, puuid, match_id, played_at)
+				VALUES ($1, $2, $3, $4, $5)
+				ON CONFLICT (kind, name, puuid, match_id) DO NOTHING
+			`), comp.Kind, comp.Name, participant.PUUID, match.Metadata.MatchID, playedAt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func matchParticipantRows(match *Match) []MatchParticipant {
+	rows := make([]MatchParticipant, 0, len(match.Info.Participants))
+	for _, participant := range match.Info.Participants {
+		if participant.PUUID == "" || participant.Placement == 0 {
+			continue
+		}
+		rows = append(rows, participant)
+	}
+	return rows
+}
+
+func (dm *DatabaseManager) GetPlacementHistory(ctx context.Context, puuid string, limit int) ([]PlacementRecord, error) {
+	return dm.placementHistory(ctx, "get_placement_history", dm.queryAnalytics, puuid, limit)
+}
+
+// GetLatestPlacementHistory reads from the primary, for callers that just
+// archived matches and cannot wait for the replica to catch up.
+func (dm *DatabaseManager) GetLatestPlacementHistory(ctx context.Context, puuid string, limit int) ([]PlacementRecord, error) {
+	return dm.placementHistory(ctx, "get_latest_placement_history", dm.DB.QueryContext, puuid, limit)
+}
+
+func (dm *DatabaseManager) placementHistory(ctx context.Context, operation string, query func(context.Context, string, ...interface{}) (*sql.Rows, error), puuid string, limit int) ([]PlacementRecord, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, operation)
+	defer span.End()
+
+	rows, err := query(ctx, dm.rebind(`
+		SELECT mp.match_id, mp.placement, m.set_number, mp.played_at
+		FROM match_participants mp
+		JOIN matches m ON m.match_id = mp.match_id
+		WHERE mp.puuid = $1
+		ORDER BY mp.played_at DESC
+		LIMIT $2
+	`), puuid, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := []PlacementRecord{}
+	for rows.Next() {
+		var record PlacementRecord
+		if err := rows.Scan(&record.MatchID, &record.Placement, &record.Set, &record.PlayedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	return records, rows.Err()
+}
+
+// computePlacementStats expects records ordered from most recent to oldest.
+func computePlacementStats(puuid string, records []PlacementRecord) PlacementSummary {
+	summary := PlacementSummary{PUUID: puuid, Placements: []PlacementRecord{}}
+
+	total, top4, run := 0, 0, 0
+	currentOpen, winsOpen := true, true
+	for _, record := range records {
+		if record.Placement == 0 {
+			continue
+		}
+
+		summary.Placements = append(summary.Placements, record)
+		total += record.Placement
+
+		kind := streakBottom4
+		if record.Placement <= 4 {
+			kind = streakTop4
+			top4++
+			run++
+			if run > summary.LongestTop4Streak {
+				summary.LongestTop4Streak = run
+			}
+		} else {
+			run = 0
+		}
+		if record.Placement == 1 {
+			summary.Wins++
+		}
+		if winsOpen {
+			if record.Placement == 1 {
+				summary.CurrentWinStreak++
+			} else {
+				winsOpen = false
+			}
+		}
+
+		if currentOpen {
+			if summary.CurrentStreak.Kind == "" || summary.CurrentStreak.Kind == kind {
+				summary.CurrentStreak.Kind = kind
+				summary.CurrentStreak.Length++
+			} else {
+				currentOpen = false
+			}
+		}
+	}
+
+	summary.Matches = len(summary.Placements)
+	summary.Form = computePlacementForm(summary.Placements)
+	if summary.Matches > 0 {
+		summary.AveragePlacement = float64(total) / float64(summary.Matches)
+		summary.Top4Rate = float64(top4) / float64(summary.Matches)
+	}
+	return summary
+}
+
+type PlacementStats struct {
+	db     *DatabaseManager
+	cache  *CacheManager
+	logger *Logger
+	ttl    reloadableDuration
+}
+
+func NewPlacementStats(cfg *Config, db *DatabaseManager, cache *CacheManager, logger *Logger) *PlacementStats {
+	return &PlacementStats{
+		db:     db,
+		cache:  cache,
+		logger: logger,
+		ttl:    newReloadableDuration(cfg.PlacementStatsCacheTTL),
+	}
+}
+
+func (ps *PlacementStats) ApplyConfig(cfg *Config) {
+	ps.ttl.Store(cfg.PlacementStatsCacheTTL)
+}
+
+func (ps *PlacementStats) Get(ctx context.Context, puuid string, count int) (*PlacementSummary, error) {
+	cacheKey := ps.cache.Key("placements", puuid, strconv.Itoa(count))
+
+	var cached PlacementSummary
+	if err := ps.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return &cached, nil
+	}
+
+	records, err := ps.db.GetPlacementHistory(ctx, puuid, count)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := computePlacementStats(puuid, records)
+	if err := ps.cache.Set(ctx, cacheKey, summary, ps.ttl.Load()); err != nil {
+		ps.logger.Warn("placement_stats_cache_failed").
+			Component("placements").
+			Operation("cache_set").
+			Game(puuid, "", "").
+			Err(err).
+			Log()
+	}
+	return &summary, nil
+}
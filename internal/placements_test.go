@@ -0,0 +1,123 @@
+package internal
+
+import "testing"
+
+func placementMatch(id, puuid string, placement int) Match {
+	return Match{
+		Metadata: MatchMetadata{MatchID: id, Participants: []string{puuid, "other"}},
+		Info: MatchInfo{
+			GameDatetime: 1700000000000,
+			TFTSetNumber: 13,
+			Participants: []MatchParticipant{
+				{PUUID: "other", Placement: 9 - placement},
+				{PUUID: puuid, Placement: placement},
+			},
+		},
+	}
+}
+
+func TestComputePlacementStats(t *testing.T) {
+	matches := []Match{
+		placementMatch("BR1_6", "p1", 1),
+		placementMatch("BR1_5", "p1", 3),
+		placementMatch("BR1_4", "p1", 6),
+		placementMatch("BR1_3", "p1", 2),
+		placementMatch("BR1_2", "p1", 4),
+		placementMatch("BR1_1", "p1", 4),
+	}
+
+	summary := computePlacementStats("p1", placementRecords("p1", matches))
+
+	if summary.Matches != 6 {
+		t.Fatalf("Matches = %d, expected 6", summary.Matches)
+	}
+	if summary.AveragePlacement != 20.0/6.0 {
+		t.Errorf("AveragePlacement = %v, expected %v", summary.AveragePlacement, 20.0/6.0)
+	}
+	if summary.Top4Rate != 5.0/6.0 {
+		t.Errorf("Top4Rate = %v, expected %v", summary.Top4Rate, 5.0/6.0)
+	}
+	if summary.Wins != 1 {
+		t.Errorf("Wins = %d, expected 1", summary.Wins)
+	}
+	if summary.CurrentStreak != (PlacementStreak{Kind: streakTop4, Length: 2}) {
+		t.Errorf("CurrentStreak = %+v, expected top4 x2", summary.CurrentStreak)
+	}
+	if summary.LongestTop4Streak != 3 {
+		t.Errorf("LongestTop4Streak = %d, expected 3", summary.LongestTop4Streak)
+	}
+	if summary.CurrentWinStreak != 1 {
+		t.Errorf("CurrentWinStreak = %d, expected 1", summary.CurrentWinStreak)
+	}
+	if summary.Placements[0].MatchID != "BR1_6" || summary.Placements[0].Set != 13 {
+		t.Errorf("Placements[0] = %+v, expected most recent match first", summary.Placements[0])
+	}
+}
+
+func TestComputePlacementStats_BottomStreakAndMissingPlayer(t *testing.T) {
+	matches := []Match{
+		placementMatch("BR1_4", "p1", 8),
+		placementMatch("BR1_3", "p2", 1),
+		placementMatch("BR1_2", "p1", 5),
+		placementMatch("BR1_1", "p1", 1),
+	}
+
+	summary := computePlacementStats("p1", placementRecords("p1", matches))
+
+	if summary.Matches != 3 {
+		t.Fatalf("Matches = %d, expected 3", summary.Matches)
+	}
+	if summary.CurrentStreak != (PlacementStreak{Kind: streakBottom4, Length: 2}) {
+		t.Errorf("CurrentStreak = %+v, expected bottom4 x2", summary.CurrentStreak)
+	}
+	if summary.CurrentWinStreak != 0 {
+		t.Errorf("CurrentWinStreak = %d, expected 0", summary.CurrentWinStreak)
+	}
+}
+
+func TestComputePlacementStats_Empty(t *testing.T) {
+	summary := computePlacementStats("p1", nil)
+
+	if summary.Matches != 0 || summary.AveragePlacement != 0 || summary.CurrentStreak.Length != 0 {
+		t.Errorf("unexpected summary for no matches: %+v", summary)
+	}
+	if summary.Placements == nil {
+		t.Error("Placements should be an empty slice, not nil")
+	}
+}
+
+func TestMatchParticipantRows(t *testing.T) {
+	match := placementMatch("BR1_1", "p1", 2)
+	match.Info.Participants = append(match.Info.Participants,
+		MatchParticipant{PUUID: "", Placement: 3},
+		MatchParticipant{PUUID: "p3", Placement: 0},
+	)
+
+	rows := matchParticipantRows(&match)
+
+	if len(rows) != 2 {
+		t.Fatalf("len(rows) = %d, expected 2", len(rows))
+	}
+	if rows[0].PUUID != "other" || rows[0].Placement != 7 || rows[1].PUUID != "p1" || rows[1].Placement != 2 {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
+
+// placementRecords extracts the player's placements from full match payloads,
+// skipping matches the player did not take part in.
+func placementRecords(puuid string, matches []Match) []PlacementRecord {
+	records := []PlacementRecord{}
+	for i := range matches {
+		participant := matches[i].Participant(puuid)
+		if participant == nil || participant.Placement == 0 {
+			continue
+		}
+		records = append(records, PlacementRecord{
+			MatchID:   matches[i].Metadata.MatchID,
+			Placement: participant.Placement,
+			Set:       matches[i].Info.TFTSetNumber,
+			PlayedAt:  matches[i].PlayedAt(),
+		})
+	}
+	return records
+}
@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strings"
+	"time"
+)
+
+const (
+	pprofPathPrefix = "/debug/pprof/"
+	// pprofMaxSeconds keeps CPU profiles and traces inside the default 10s
+	// HTTP_WRITE_TIMEOUT.
+	pprofMaxSeconds     = 8
+	pprofDefaultSeconds = 5
+
+	// PProfTimeout is the route deadline for /debug/pprof/. The default 8s
+	// REQUEST_TIMEOUT would cut off the longest profile, so the route gets a
+	// second more, still inside the default HTTP_WRITE_TIMEOUT.
+	PProfTimeout = (pprofMaxSeconds + 1) * time.Second
+)
+
+// PProfHandler serves the runtime profiles under /debug/pprof/ in the format
+// `go tool pprof` reads. It is built on runtime/pprof rather than
+// net/http/pprof, whose import registers unguarded handlers on
+// http.DefaultServeMux.
+func PProfHandler(logger *Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, pprofPathPrefix)
+		v := NewValidator(r.URL.Query())
+
+		switch name {
+		case "":
+			writeJSON(w, pprofIndex(), logger, r)
+		case "profile", "trace":
+			seconds := v.IntRange("seconds", pprofDefaultSeconds, 1, pprofMaxSeconds)
+			if err := v.Err(); err != nil {
+				writeError(w, err, logger, r)
+				return
+			}
+			writeTimedProfile(w, r, name, time.Duration(seconds)*time.Second, logger)
+		default:
+			profile := pprof.Lookup(name)
+			if profile == nil {
+				writeError(w, NewAPIError(fmt.Sprintf("Unknown profile %q", name), http.StatusNotFound), logger, r)
+				return
+			}
+			debug := v.IntRange("debug", 0, 0, 2)
+			gc := v.Bool("gc")
+			if err := v.Err(); err != nil {
+				writeError(w, err, logger, r)
+				return
+			}
+			if name == "heap" && gc != nil && *gc {
+				runtime.GC()
+			}
+
+			var buf bytes.Buffer
+			if err := profile.WriteTo(&buf, debug); err != nil {
+				writeError(w, NewAPIError("Failed to write profile", http.StatusInternalServerError).WithCause(err), logger, r)
+				return
+			}
+			writeProfile(w, name, debug, buf.Bytes())
+		}
+	}
+}
+
+func pprofIndex() map[string]interface{} {
+	profiles := make([]map[string]interface{}, 0)
+	for _, profile := range pprof.Profiles() {
+		profiles = append(profiles, map[string]interface{}{
+			"name":  profile.Name(),
+			"count": profile.Count(),
+			"path":  pprofPathPrefix + profile.Name(),
+		})
+	}
+	return map[string]interface{}{
+		"profiles": profiles,
+		"cpu":      pprofPathPrefix + "profile?seconds={1-8}",
+		"trace":    pprofPathPrefix + "trace?seconds={1-8}",
+	}
+}
+
+// writeTimedProfile records a CPU profile or an execution trace for d. Only
+// one of each can run per process, so a concurrent request gets 409.
+func writeTimedProfile(w http.ResponseWriter, r *http.Request, name string, d time.Duration, logger *Logger) {
+	var buf bytes.Buffer
+	var start func() error
+	var stop func()
+	if name == "trace" {
+		start, stop = func() error { return trace.Start(&buf) }, trace.Stop
+	} else {
+		start, stop = func() error { return pprof.StartCPUProfile(&buf) }, pprof.StopCPUProfile
+	}
+
+	if err := start(); err != nil {
+		writeError(w, NewAPIError(fmt.Sprintf("A %s is already being recorded", name), http.StatusConflict).WithCause(err), logger, r)
+		return
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-r.Context().Done():
+		stop()
+		return
+	}
+	stop()
+
+	logger.Info("pprof_profile_served").
+		Component("pprof").
+		Operation(name).
+		Duration(d).
+		Meta("bytes", buf.Len()).
+		Log()
+	writeProfile(w, name, 0, buf.Bytes())
+}
+
+func writeProfile(w http.ResponseWriter, name string, debug int, data []byte) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if debug > 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".prof"))
+	}
+	w.Write(data)
+}
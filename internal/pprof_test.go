@@ -0,0 +1,86 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPProfHandler(t *testing.T) {
+	handler := PProfHandler(newTestLogger())
+
+	tests := []struct {
+		name        string
+		url         string
+		status      int
+		contentType string
+	}{
+		{"index", "/debug/pprof/", http.StatusOK, "application/json"},
+		{"heap profile", "/debug/pprof/heap?gc=true", http.StatusOK, "application/octet-stream"},
+		{"goroutine as text", "/debug/pprof/goroutine?debug=1", http.StatusOK, "text/plain; charset=utf-8"},
+		{"unknown profile", "/debug/pprof/nope", http.StatusNotFound, "application/json"},
+		{"debug out of range", "/debug/pprof/heap?debug=5", http.StatusBadRequest, ""},
+		{"cpu profile too long", "/debug/pprof/profile?seconds=60", http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, expected %d: %s", w.Code, tt.status, w.Body.String())
+			}
+			if tt.contentType != "" && !strings.HasPrefix(w.Header().Get("Content-Type"), tt.contentType) {
+				t.Errorf("Content-Type = %q, expected %q", w.Header().Get("Content-Type"), tt.contentType)
+			}
+		})
+	}
+}
+
+func TestPProfTimeout(t *testing.T) {
+	t.Setenv("RIOT_API_KEY", "test-key")
+	t.Setenv("RIOT_BASE_URL", "https://br1.api.riotgames.com")
+	t.Setenv("DATABASE_ENABLED", "false")
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("REQUEST_TIMEOUT", "")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "")
+
+	defaults, err := LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+
+	longest := pprofMaxSeconds * time.Second
+	if PProfTimeout <= longest || PProfTimeout <= defaults.RequestTimeout {
+		t.Errorf("PProfTimeout = %v, expected more than the %v profile and the %v REQUEST_TIMEOUT", PProfTimeout, longest, defaults.RequestTimeout)
+	}
+	if PProfTimeout >= defaults.HTTPWriteTimeout {
+		t.Errorf("PProfTimeout = %v, expected less than the %v HTTP_WRITE_TIMEOUT", PProfTimeout, defaults.HTTPWriteTimeout)
+	}
+}
+
+func TestPProfHandler_Index(t *testing.T) {
+	w := httptest.NewRecorder()
+	PProfHandler(newTestLogger())(w, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+
+	var index struct {
+		Profiles []struct {
+			Name string `json:"name"`
+			Path string `json:"path"`
+		} `json:"profiles"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &index); err != nil {
+		t.Fatalf("decode index: %v", err)
+	}
+	found := false
+	for _, profile := range index.Profiles {
+		found = found || profile.Name == "heap" && profile.Path == "/debug/pprof/heap"
+	}
+	if !found {
+		t.Errorf("index = %+v, expected the heap profile", index.Profiles)
+	}
+}
@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	includeProfile = "profile"
+
+	summonerProfileTTL        = 24 * time.Hour
+	profileEnrichConcurrency  = 4
+	defaultProfileEnrichLimit = 25
+)
+
+var includeOptions = []string{includeProfile}
+
+// EntryProfile is the part of a summoner attached to league entries with
+// ?include=profile.
+type EntryProfile struct {
+	SummonerLevel int `json:"summonerLevel"`
+	ProfileIconID int `json:"profileIconId"`
+}
+
+// parseInclude reads the comma-separated include parameter.
+func parseInclude(query url.Values) (map[string]bool, error) {
+	include := make(map[string]bool)
+	v := NewValidator(query)
+	for _, value := range strings.Split(query.Get("include"), ",") {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		known := false
+		for _, option := range includeOptions {
+			known = known || value == option
+		}
+		if !known {
+			v.Fail("include", "must be a comma-separated list of "+strings.Join(includeOptions, ", "))
+			break
+		}
+		include[value] = true
+	}
+	return include, v.Err()
+}
+
+func (cm *CacheManager) GetSummonerProfiles(ctx context.Context, puuids []string) map[string]EntryProfile {
+	profiles := make(map[string]EntryProfile, len(puuids))
+	if !cm.enabled || cm.backend == nil || len(puuids) == 0 {
+		return profiles
+	}
+
+	keys := make([]string, len(puuids))
+	for i, puuid := range puuids {
+		keys[i] = cm.Key("summoner_profile", puuid)
+	}
+	values, _ := cm.backend.GetMulti(ctx, keys)
+	for i, puuid := range puuids {
+		var profile EntryProfile
+		if data := values[keys[i]]; len(data) > 0 && json.Unmarshal(data, &profile) == nil {
+			profiles[puuid] = profile
+		}
+	}
+	return profiles
+}
+
+func (cm *CacheManager) SetSummonerProfile(ctx context.Context, puuid string, profile EntryProfile) error {
+	return cm.Set(ctx, cm.Key("summoner_profile", puuid), profile, summonerProfileTTL)
+}
+
+// EnrichProfiles attaches summonerLevel and profileIconId to the first
+// PROFILE_ENRICH_LIMIT entries. Profiles are cached for a day next to the
+// names, so only entries new to the ladder cost a summoner lookup; an entry
+// whose lookup fails is returned without them.
+func (c *RiotAPIClient) EnrichProfiles(ctx context.Context, entries []LeagueEntry) {
+	limit := c.profileEnrichLimit
+	if limit <= 0 {
+		limit = defaultProfileEnrichLimit
+	}
+	top := entries[:min(limit, len(entries))]
+
+	puuids := make([]string, 0, len(top))
+	for _, entry := range top {
+		if entry.PUUID != "" {
+			puuids = append(puuids, entry.PUUID)
+		}
+	}
+	profiles := c.cache.GetSummonerProfiles(ctx, puuids)
+
+	var mu sync.Mutex
+	var g errgroup.Group
+	g.SetLimit(profileEnrichConcurrency)
+	for _, puuid := range puuids {
+		if _, ok := profiles[puuid]; ok {
+			continue
+		}
+		g.Go(func() error {
+			summoner, err := c.GetSummonerByPUUID(ctx, puuid)
+			if err != nil {
+				c.logger.Warn("profile_enrich_failed").
+					Component("riot_api").
+					Operation("enrich_profile").
+					Game(puuid, c.region, "").
+					Err(err).
+					Log()
+				return nil
+			}
+
+			profile := EntryProfile{SummonerLevel: summoner.SummonerLevel, ProfileIconID: summoner.ProfileIconID}
+			c.cache.SetSummonerProfile(ctx, puuid, profile)
+			mu.Lock()
+			profiles[puuid] = profile
+			mu.Unlock()
+			return nil
+		})
+	}
+	g.Wait()
+
+	for i := range top {
+		if profile, ok := profiles[top[i].PUUID]; ok {
+			level, icon := profile.SummonerLevel, profile.ProfileIconID
+			top[i].SummonerLevel = &level
+			top[i].ProfileIconID = &icon
+		}
+	}
+}
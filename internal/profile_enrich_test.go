@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseInclude(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  map[string]bool
+		expectErr bool
+	}{
+		{name: "absent", value: "", expected: map[string]bool{}},
+		{name: "profile", value: "profile", expected: map[string]bool{"profile": true}},
+		{name: "case and spacing", value: " Profile , ", expected: map[string]bool{"profile": true}},
+		{name: "unknown", value: "profile,matches", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			include, err := parseInclude(url.Values{"include": {tt.value}})
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(include, tt.expected) {
+				t.Errorf("parseInclude(%q) = %v, %v, expected %v", tt.value, include, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRiotAPIClient_EnrichProfiles(t *testing.T) {
+	server := newFakeMemcached(t)
+	cfg := &Config{
+		RiotMode:           RiotModeFixtures,
+		RiotFixturesDir:    "../fixtures/riot",
+		RiotRegion:         "BR1",
+		RiotBaseURL:        fixtureBaseURL,
+		RiotHTTPTimeout:    time.Second,
+		CacheEnabled:       true,
+		CacheBackend:       CacheBackendMemcached,
+		MemcachedServers:   server.Addr(),
+		MemcachedTimeout:   time.Second,
+		ProfileEnrichLimit: 3,
+	}
+	cache := NewCacheManager(cfg, nil, nil, nil)
+	client := NewRiotAPIClient(cfg, cache, newTestLogger(), nil)
+	ctx := t.Context()
+
+	fixture := "fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixtureP"
+	cache.SetSummonerProfile(ctx, "cached-puuid", EntryProfile{SummonerLevel: 80, ProfileIconID: 0})
+	entries := []LeagueEntry{{PUUID: fixture}, {PUUID: "cached-puuid"}, {PUUID: "missing-puuid"}, {PUUID: "beyond-limit"}}
+
+	client.EnrichProfiles(ctx, entries)
+
+	if entries[0].SummonerLevel == nil || *entries[0].SummonerLevel != 312 || *entries[0].ProfileIconID != 29 {
+		t.Errorf("entry 0 = %+v, expected the fixture summoner's profile", entries[0])
+	}
+	if entries[1].SummonerLevel == nil || *entries[1].SummonerLevel != 80 || entries[1].ProfileIconID == nil || *entries[1].ProfileIconID != 0 {
+		t.Errorf("entry 1 = %+v, expected the cached profile", entries[1])
+	}
+	if entries[2].SummonerLevel != nil || entries[3].SummonerLevel != nil {
+		t.Errorf("entries 2 and 3 = %+v, %+v, expected no profile for a failed lookup or past the limit", entries[2], entries[3])
+	}
+
+	if cached := cache.GetSummonerProfiles(ctx, []string{fixture}); cached[fixture].SummonerLevel != 312 {
+		t.Errorf("cached profiles = %v, expected the fetched profile to be cached", cached)
+	}
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type ProfileSnapshot struct {
+	ProfileIconID int       `json:"profileIconId"`
+	SummonerLevel int       `json:"summonerLevel"`
+	ObservedAt    time.Time `json:"observedAt"`
+}
+
+type ProfileChangeEvent struct {
+	PUUID       string          `json:"puuid"`
+	Region      string          `json:"region"`
+	Previous    ProfileSnapshot `json:"previous"`
+	Current     ProfileSnapshot `json:"current"`
+	LeveledUp   bool            `json:"leveledUp"`
+	IconChanged bool            `json:"iconChanged"`
+	ChangedAt   time.Time       `json:"changedAt"`
+}
+
+// SummonerProfile is the /summoner response when profile history or form is
+// requested. ProfileHistory is null when only form was requested.
+type SummonerProfile struct {
+	*Summoner
+	ProfileHistory []ProfileSnapshot `json:"profileHistory"`
+	Form           *PlayerForm       `json:"form,omitempty"`
+	Degraded       []DegradedSection `json:"degraded,omitempty"`
+}
+
+// profileChange compares the last stored snapshot with a fresh one. The first
+// observation of a player is a baseline, not a change.
+func profileChange(puuid, region string, previous *ProfileSnapshot, current ProfileSnapshot) *ProfileChangeEvent {
+	if previous == nil {
+		return nil
+	}
+
+	event := &ProfileChangeEvent{
+		PUUID:       puuid,
+		Region:      region,
+		Previous:    *previous,
+		Current:     current,
+		LeveledUp:   current.SummonerLevel > previous.SummonerLevel,
+		IconChanged: current.ProfileIconID != previous.ProfileIconID,
+		ChangedAt:   current.ObservedAt,
+	}
+	if !event.IconChanged && current.SummonerLevel == previous.SummonerLevel {
+		return nil
+	}
+	return event
+}
+
+// RecordSummonerProfile stores a snapshot when the icon or level differs from
+// the last one seen and, with publish set, enqueues a profile change event
+// in the same transaction. Callers without NATS leave publish off, as no
+// relay would drain the outbox.
+func (dm *DatabaseManager) RecordSummonerProfile(ctx context.Context, region string, summoner *Summoner, publish bool) (*ProfileChangeEvent, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "record_summoner_profile")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var previous *ProfileSnapshot
+	var last ProfileSnapshot
+	err = tx.QueryRowContext(ctx, dm.rebind(`
+		SELECT profile_icon_id, summoner_level, observed_at
+		FROM summoner_profile_history
+		WHERE puuid = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`), summoner.PUUID).Scan(&last.ProfileIconID, &last.SummonerLevel, &last.ObservedAt)
+	switch {
+	case err == nil:
+		previous = &last
+	case err != sql.ErrNoRows:
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	current := ProfileSnapshot{
+		ProfileIconID: summoner.ProfileIconID,
+		SummonerLevel: summoner.SummonerLevel,
+		ObservedAt:    time.Now().UTC(),
+	}
+	event := profileChange(summoner.PUUID, region, previous, current)
+	if previous != nil && event == nil {
+		return nil, nil
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO summoner_profile_history (puuid, region, profile_icon_id, summoner_level, observed_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`), summoner.PUUID, region, current.ProfileIconID, current.SummonerLevel, current.ObservedAt)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	if event != nil && publish {
+		if err := dm.enqueueEvent(ctx, tx, profileChangedSubject, event); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetSummonerProfileHistory returns the newest snapshots first.
+func (dm *DatabaseManager) GetSummonerProfileHistory(ctx context.Context, puuid string, limit int) ([]ProfileSnapshot, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_summoner_profile_history")
+	defer span.End()
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(`
+		SELECT profile_icon_id, summoner_level, observed_at
+		FROM summoner_profile_history
+		WHERE puuid = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`), puuid, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []ProfileSnapshot{}
+	for rows.Next() {
+		var snapshot ProfileSnapshot
+		if err := rows.Scan(&snapshot.ProfileIconID, &snapshot.SummonerLevel, &snapshot.ObservedAt); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		history = append(history, snapshot)
+	}
+	recordSpanError(span, rows.Err())
+	return history, rows.Err()
+}
@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileChange(t *testing.T) {
+	now := time.Now().UTC()
+	previous := &ProfileSnapshot{ProfileIconID: 29, SummonerLevel: 120, ObservedAt: now.Add(-time.Hour)}
+
+	tests := []struct {
+		name        string
+		previous    *ProfileSnapshot
+		current     ProfileSnapshot
+		expectEvent bool
+		leveledUp   bool
+		iconChanged bool
+	}{
+		{name: "first observation is a baseline", current: ProfileSnapshot{ProfileIconID: 29, SummonerLevel: 120}},
+		{name: "unchanged", previous: previous, current: ProfileSnapshot{ProfileIconID: 29, SummonerLevel: 120}},
+		{name: "level up", previous: previous, current: ProfileSnapshot{ProfileIconID: 29, SummonerLevel: 121}, expectEvent: true, leveledUp: true},
+		{name: "icon change", previous: previous, current: ProfileSnapshot{ProfileIconID: 4568, SummonerLevel: 120}, expectEvent: true, iconChanged: true},
+		{name: "both", previous: previous, current: ProfileSnapshot{ProfileIconID: 4568, SummonerLevel: 122}, expectEvent: true, leveledUp: true, iconChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.current.ObservedAt = now
+			event := profileChange("puuid-1", "BR1", tt.previous, tt.current)
+			if (event != nil) != tt.expectEvent {
+				t.Fatalf("profileChange() = %+v, expected event = %v", event, tt.expectEvent)
+			}
+			if event == nil {
+				return
+			}
+			if event.LeveledUp != tt.leveledUp || event.IconChanged != tt.iconChanged {
+				t.Errorf("leveledUp = %v, iconChanged = %v, expected %v, %v", event.LeveledUp, event.IconChanged, tt.leveledUp, tt.iconChanged)
+			}
+			if event.Previous != *tt.previous || event.Current != tt.current || !event.ChangedAt.Equal(now) {
+				t.Errorf("unexpected snapshots in event %+v", event)
+			}
+		})
+	}
+}
+
+func TestRecordSummonerProfile_OutboxOnlyWhenPublishing(t *testing.T) {
+	for _, publish := range []bool{false, true} {
+		db := newSQLiteTestDB(t)
+		summoner := &Summoner{PUUID: "p1", ProfileIconID: 1, SummonerLevel: 100}
+		if _, err := db.RecordSummonerProfile(t.Context(), "BR1", summoner, publish); err != nil {
+			t.Fatalf("RecordSummonerProfile() error = %v", err)
+		}
+
+		summoner.SummonerLevel = 101
+		event, err := db.RecordSummonerProfile(t.Context(), "BR1", summoner, publish)
+		if err != nil || event == nil || !event.LeveledUp {
+			t.Fatalf("RecordSummonerProfile() = %+v, %v, expected a level up", event, err)
+		}
+
+		expected := 0
+		if publish {
+			expected = 1
+		}
+		if rows := loadOutboxRows(t, db); len(rows) != expected {
+			t.Errorf("publish=%v: outbox has %d events, expected %d", publish, len(rows), expected)
+		}
+	}
+}
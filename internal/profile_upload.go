@@ -0,0 +1,166 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProfileUploader stores captured profiles in an S3-compatible bucket (AWS
+// S3, MinIO, R2, ...), so profiles survive containers without a persistent
+// disk. Objects are addressed path-style, which every compatible store
+// accepts, and requests are signed with AWS Signature Version 4.
+type ProfileUploader struct {
+	endpoint  *url.URL
+	bucket    string
+	region    string
+	prefix    string
+	host      string
+	accessKey string
+	secretKey string
+	client    *http.Client
+	now       func() time.Time
+}
+
+// NewProfileUploader returns nil when PROFILE_UPLOAD_ENDPOINT is unset.
+func NewProfileUploader(cfg *Config) *ProfileUploader {
+	endpoint, err := parseProfileUploadEndpoint(cfg.ProfileUploadEndpoint)
+	if err != nil || endpoint == nil {
+		return nil
+	}
+
+	return &ProfileUploader{
+		endpoint:  endpoint,
+		bucket:    cfg.ProfileUploadBucket,
+		region:    cfg.ProfileUploadRegion,
+		prefix:    strings.Trim(cfg.ProfileUploadPrefix, "/"),
+		host:      instanceName(),
+		accessKey: cfg.ProfileUploadAccessKey,
+		secretKey: cfg.ProfileUploadSecretKey,
+		client:    &http.Client{Timeout: cfg.ProfileUploadTimeout},
+		now:       time.Now,
+	}
+}
+
+func parseProfileUploadEndpoint(value string) (*url.URL, error) {
+	if value == "" {
+		return nil, nil
+	}
+	endpoint, err := url.Parse(strings.TrimRight(value, "/"))
+	if err != nil {
+		return nil, err
+	}
+	if endpoint.Scheme != "http" && endpoint.Scheme != "https" || endpoint.Host == "" {
+		return nil, fmt.Errorf("%q must be an http(s) URL", value)
+	}
+	return endpoint, nil
+}
+
+// objectKey groups profiles by host, since every replica captures its own.
+func (u *ProfileUploader) objectKey(filename string) string {
+	if u.prefix == "" {
+		return u.host + "/" + filename
+	}
+	return u.prefix + "/" + u.host + "/" + filename
+}
+
+// Upload stores data under the object key derived from filename and returns
+// that key.
+func (u *ProfileUploader) Upload(ctx context.Context, filename string, data []byte) (string, error) {
+	key := u.objectKey(filename)
+	target := *u.endpoint
+	target.Path = u.endpoint.Path + "/" + u.bucket + "/" + key
+	target.RawPath = awsURIEscape(u.endpoint.Path + "/" + u.bucket + "/" + key)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	u.sign(req, data)
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("upload %s: status %d: %s", key, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return key, nil
+}
+
+// sign adds the SigV4 headers for a single-chunk payload.
+func (u *ProfileUploader) sign(req *http.Request, payload []byte) {
+	now := u.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	const signedHeaders = "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		"host:" + req.URL.Host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + u.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+u.secretKey), date)
+	key = hmacSHA256(key, u.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsURIEscape percent-encodes everything but the unreserved characters, as
+// SigV4 requires, keeping the path separators; url.PathEscape leaves some
+// reserved characters alone.
+func awsURIEscape(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileUploader_Upload(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	uploader := NewProfileUploader(&Config{
+		ProfileUploadEndpoint:  server.URL + "/",
+		ProfileUploadBucket:    "profiles",
+		ProfileUploadRegion:    "us-east-1",
+		ProfileUploadPrefix:    "/tft-core/",
+		ProfileUploadAccessKey: "AKIDEXAMPLE",
+		ProfileUploadSecretKey: "secret",
+		ProfileUploadTimeout:   time.Second,
+	})
+	uploader.host = "api-1"
+	uploader.now = func() time.Time { return time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC) }
+
+	key, err := uploader.Upload(context.Background(), "mem_1.prof", []byte("profile"))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if key != "tft-core/api-1/mem_1.prof" {
+		t.Errorf("key = %q, expected tft-core/api-1/mem_1.prof", key)
+	}
+	if got.Method != http.MethodPut || got.URL.Path != "/profiles/tft-core/api-1/mem_1.prof" || string(body) != "profile" {
+		t.Errorf("request = %s %s %q", got.Method, got.URL.Path, body)
+	}
+	if got.Header.Get("X-Amz-Date") != "20260301T120000Z" || got.Header.Get("X-Amz-Content-Sha256") != sha256Hex([]byte("profile")) {
+		t.Errorf("amz headers = %v", got.Header)
+	}
+	auth := got.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260301/us-east-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	first := auth
+	uploader.Upload(context.Background(), "mem_1.prof", []byte("other"))
+	if got.Header.Get("Authorization") == first {
+		t.Error("signature did not change with the payload")
+	}
+
+	status = http.StatusForbidden
+	if _, err := uploader.Upload(context.Background(), "mem_2.prof", []byte("profile")); err == nil {
+		t.Error("Upload() with a 403 response expected error but got nil")
+	}
+}
+
+func TestAWSURIEscape(t *testing.T) {
+	if got := awsURIEscape("/bucket/a b+c=d~e.prof"); got != "/bucket/a%20b%2Bc%3Dd~e.prof" {
+		t.Errorf("awsURIEscape() = %q", got)
+	}
+}
+
+func TestNewProfileUploader_Disabled(t *testing.T) {
+	if NewProfileUploader(&Config{}) != nil {
+		t.Error("NewProfileUploader() without an endpoint expected nil")
+	}
+}
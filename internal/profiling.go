@@ -1,9 +1,10 @@
 package internal
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-
+	"io"
 	"os"
 	"runtime"
 	"runtime/pprof"
@@ -11,15 +12,16 @@ import (
 )
 
 type Profiler struct {
-	enabled bool
-	logger  *Logger
+	enabled  bool
+	uploader *ProfileUploader
+	logger   *Logger
 }
 
-func NewProfiler(logger *Logger) *Profiler {
-	enabled := os.Getenv("ENABLE_PROFILING") == "true"
+func NewProfiler(cfg *Config, logger *Logger) *Profiler {
 	return &Profiler{
-		enabled: enabled,
-		logger:  logger,
+		enabled:  cfg.ProfilingEnabled,
+		uploader: NewProfileUploader(cfg),
+		logger:   logger,
 	}
 }
 
@@ -44,60 +46,111 @@ func (p *Profiler) StartMemoryProfiling() {
 }
 
 func (p *Profiler) captureMemoryProfile() {
-	filename := fmt.Sprintf("mem_%d.prof", time.Now().Unix())
+	runtime.GC()
+	p.capture("memory", "capture_memory", fmt.Sprintf("mem_%d.prof", time.Now().Unix()), pprof.WriteHeapProfile)
+}
+
+// capture writes a profile to filename in the working directory or, with
+// PROFILE_UPLOAD_ENDPOINT set, uploads it instead of touching the disk.
+func (p *Profiler) capture(kind, operation, filename string, write func(io.Writer) error) {
+	if p.uploader != nil {
+		var buf bytes.Buffer
+		if err := write(&buf); err != nil {
+			p.logger.Error(kind + "_profile_write_failed").
+				Component("profiler").
+				Operation(operation).
+				Err(err).
+				Log()
+			return
+		}
+		p.upload(kind, operation, filename, buf.Bytes())
+		return
+	}
 
 	f, err := os.Create(filename)
 	if err != nil {
-		p.logger.Error("memory_profile_create_failed").
+		p.logger.Error(kind + "_profile_create_failed").
 			Component("profiler").
-			Operation("capture_memory").
+			Operation(operation).
 			Err(err).
 			Log()
 		return
 	}
 	defer f.Close()
 
-	runtime.GC()
-
-	if err := pprof.WriteHeapProfile(f); err != nil {
-		p.logger.Error("memory_profile_write_failed").
+	if err := write(f); err != nil {
+		p.logger.Error(kind + "_profile_write_failed").
 			Component("profiler").
-			Operation("capture_memory").
+			Operation(operation).
 			Err(err).
 			Log()
 		return
 	}
 
-	p.logger.Info("memory_profile_captured").
+	p.logger.Info(kind+"_profile_captured").
 		Component("profiler").
-		Operation("capture_memory").
+		Operation(operation).
 		Meta("filename", filename).
 		Log()
 }
 
-func (p *Profiler) StartCPUProfiling() {
-	if !p.enabled {
-		return
-	}
+func (p *Profiler) upload(kind, operation, filename string, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.uploader.client.Timeout)
+	defer cancel()
 
-	filename := fmt.Sprintf("cpu_%d.prof", time.Now().Unix())
-	f, err := os.Create(filename)
+	key, err := p.uploader.Upload(ctx, filename, data)
 	if err != nil {
-		p.logger.Error("cpu_profile_create_failed").
+		p.logger.Error(kind+"_profile_upload_failed").
 			Component("profiler").
-			Operation("start_cpu").
+			Operation(operation).
+			Meta("filename", filename).
 			Err(err).
 			Log()
 		return
 	}
 
-	if err := pprof.StartCPUProfile(f); err != nil {
+	p.logger.Info(kind+"_profile_captured").
+		Component("profiler").
+		Operation(operation).
+		Meta("object", key).
+		Meta("bytes", len(data)).
+		Log()
+}
+
+func (p *Profiler) StartCPUProfiling() {
+	if !p.enabled {
+		return
+	}
+
+	filename := fmt.Sprintf("cpu_%d.prof", time.Now().Unix())
+	var out io.Writer
+	var buf bytes.Buffer
+	var f *os.File
+	if p.uploader != nil {
+		out = &buf
+	} else {
+		var err error
+		f, err = os.Create(filename)
+		if err != nil {
+			p.logger.Error("cpu_profile_create_failed").
+				Component("profiler").
+				Operation("start_cpu").
+				Err(err).
+				Log()
+			return
+		}
+		out = f
+	}
+
+	if err := pprof.StartCPUProfile(out); err != nil {
 		p.logger.Error("cpu_profile_start_failed").
 			Component("profiler").
 			Operation("start_cpu").
 			Err(err).
 			Log()
-		f.Close()
+		if f != nil {
+			f.Close()
+		}
 		return
 	}
 
@@ -110,13 +163,19 @@ func (p *Profiler) StartCPUProfiling() {
 	go func() {
 		time.Sleep(30 * time.Second)
 		pprof.StopCPUProfile()
-		f.Close()
+		if f != nil {
+			f.Close()
+		}
 
 		p.logger.Info("cpu_profiling_stopped").
 			Component("profiler").
 			Operation("stop_cpu").
 			Meta("filename", filename).
 			Log()
+
+		if p.uploader != nil {
+			p.upload("cpu", "stop_cpu", filename, buf.Bytes())
+		}
 	}()
 }
 
@@ -164,33 +223,9 @@ func (p *Profiler) CaptureGoroutineProfile() {
 		return
 	}
 
-	filename := fmt.Sprintf("goroutine_%d.prof", time.Now().Unix())
-	f, err := os.Create(filename)
-	if err != nil {
-		p.logger.Error("goroutine_profile_create_failed").
-			Component("profiler").
-			Operation("capture_goroutine").
-			Err(err).
-			Log()
-		return
-	}
-	defer f.Close()
-
-	if err := pprof.Lookup("goroutine").WriteTo(f, 0); err != nil {
-		p.logger.Error("goroutine_profile_write_failed").
-			Component("profiler").
-			Operation("capture_goroutine").
-			Err(err).
-			Log()
-		return
-	}
-
-	p.logger.Info("goroutine_profile_captured").
-		Component("profiler").
-		Operation("capture_goroutine").
-		Meta("filename", filename).
-		Meta("goroutines", runtime.NumGoroutine()).
-		Log()
+	p.capture("goroutine", "capture_goroutine", fmt.Sprintf("goroutine_%d.prof", time.Now().Unix()), func(w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 0)
+	})
 }
 
 func (p *Profiler) MonitorHighMemoryUsage(thresholdMB uint64) {
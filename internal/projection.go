@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+)
+
+var ErrNotEnoughLPHistory = errors.New("not enough LP history")
+
+const (
+	divisionLP = 100
+	tierLP     = divisionLP * 4
+	// MASTER and above share one LP pool with no divisions; the grandmaster
+	// and challenger cutoffs move with the ladder.
+	apexTierIndex = 7
+	apexBaseLP    = apexTierIndex * tierLP
+)
+
+type LPHistoryPoint struct {
+	Tier         string    `json:"tier"`
+	Rank         string    `json:"rank"`
+	LeaguePoints int       `json:"leaguePoints"`
+	Wins         int       `json:"wins"`
+	Losses       int       `json:"losses"`
+	ObservedAt   time.Time `json:"observedAt"`
+}
+
+func (p LPHistoryPoint) Games() int {
+	return p.Wins + p.Losses
+}
+
+type ProjectedRank struct {
+	Games        int    `json:"games"`
+	Tier         string `json:"tier"`
+	Rank         string `json:"rank"`
+	LeaguePoints int    `json:"leaguePoints"`
+}
+
+type LPProjection struct {
+	PUUID           string          `json:"puuid"`
+	Current         RankSnapshot    `json:"current"`
+	Observations    int             `json:"observations"`
+	GamesAnalyzed   int             `json:"gamesAnalyzed"`
+	LPPerGame       float64         `json:"lpPerGame"`
+	AverageGain     float64         `json:"averageGain"`
+	AverageLoss     float64         `json:"averageLoss"`
+	NextTier        string          `json:"nextTier,omitempty"`
+	LPToNextTier    int             `json:"lpToNextTier,omitempty"`
+	GamesToNextTier *int            `json:"gamesToNextTier"`
+	Trajectory      []ProjectedRank `json:"trajectory"`
+}
+
+func tierIndex(tier string) int {
+	for i, valid := range validTiers {
+		if valid == tier {
+			return i
+		}
+	}
+	return -1
+}
+
+// ladderLP maps a rank to a single LP scale: 400 per tier and 100 per
+// division from IRON IV at 0, with the apex tiers stacked on top of DIAMOND I.
+func ladderLP(tier, rank string, leaguePoints int) (int, bool) {
+	index := tierIndex(tier)
+	if index < 0 {
+		return 0, false
+	}
+	if index >= apexTierIndex {
+		return apexBaseLP + leaguePoints, true
+	}
+
+	for i, division := range validDivisions {
+		if division == rank {
+			return index*tierLP + (len(validDivisions)-1-i)*divisionLP + leaguePoints, true
+		}
+	}
+	return 0, false
+}
+
+// rankAtLadderLP is the inverse of ladderLP. Apex values keep the player's
+// current apex tier since the cutoffs between them are not fixed.
+func rankAtLadderLP(value int, currentTier string) ProjectedRank {
+	if value < 0 {
+		value = 0
+	}
+	if value >= apexBaseLP {
+		tier := "MASTER"
+		if tierIndex(currentTier) >= apexTierIndex {
+			tier = currentTier
+		}
+		return ProjectedRank{Tier: tier, Rank: "I", LeaguePoints: value - apexBaseLP}
+	}
+
+	within := value % tierLP
+	return ProjectedRank{
+		Tier:         validTiers[value/tierLP],
+		Rank:         validDivisions[len(validDivisions)-1-within/divisionLP],
+		LeaguePoints: within % divisionLP,
+	}
+}
+
+// projectLP fits LP against games played over the history (newest first, as
+// stored) and extends the line horizon games past the latest observation.
+// Only observations since the last drop in games played are used, so a season
+// reset starts a fresh history.
+func projectLP(puuid string, history []LPHistoryPoint, horizon int) (*LPProjection, error) {
+	var points []LPHistoryPoint
+	for _, point := range history {
+		if _, ok := ladderLP(point.Tier, point.Rank, point.LeaguePoints); !ok {
+			continue
+		}
+		if len(points) > 0 && point.Games() > points[len(points)-1].Games() {
+			break
+		}
+		points = append(points, point)
+	}
+	for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+		points[i], points[j] = points[j], points[i]
+	}
+	if len(points) < 2 || points[0].Games() == points[len(points)-1].Games() {
+		return nil, ErrNotEnoughLPHistory
+	}
+
+	values := make([]float64, len(points))
+	var meanGames, meanLP float64
+	for i, point := range points {
+		value, _ := ladderLP(point.Tier, point.Rank, point.LeaguePoints)
+		values[i] = float64(value)
+		meanGames += float64(point.Games())
+		meanLP += values[i]
+	}
+	meanGames /= float64(len(points))
+	meanLP /= float64(len(points))
+
+	var covariance, variance float64
+	for i, point := range points {
+		dx := float64(point.Games()) - meanGames
+		covariance += dx * (values[i] - meanLP)
+		variance += dx * dx
+	}
+	slope := covariance / variance
+
+	var gained, lost float64
+	var gainGames, lossGames int
+	for i := 1; i < len(points); i++ {
+		games := points[i].Games() - points[i-1].Games()
+		delta := values[i] - values[i-1]
+		switch {
+		case games == 0:
+		case delta > 0:
+			gained += delta
+			gainGames += games
+		case delta < 0:
+			lost += delta
+			lossGames += games
+		}
+	}
+
+	latest := points[len(points)-1]
+	current := int(values[len(values)-1])
+	projection := &LPProjection{
+		PUUID:         puuid,
+		Current:       RankSnapshot{Tier: latest.Tier, Rank: latest.Rank, LeaguePoints: latest.LeaguePoints},
+		Observations:  len(points),
+		GamesAnalyzed: latest.Games() - points[0].Games(),
+		LPPerGame:     roundTo(slope, 2),
+		Trajectory:    make([]ProjectedRank, 0, horizon),
+	}
+	if gainGames > 0 {
+		projection.AverageGain = roundTo(gained/float64(gainGames), 2)
+	}
+	if lossGames > 0 {
+		projection.AverageLoss = roundTo(lost/float64(lossGames), 2)
+	}
+
+	if index := tierIndex(latest.Tier); index < apexTierIndex {
+		projection.NextTier = validTiers[index+1]
+		projection.LPToNextTier = (index+1)*tierLP - current
+		if slope > 0 {
+			games := int(math.Ceil(float64(projection.LPToNextTier) / slope))
+			projection.GamesToNextTier = &games
+		}
+	}
+
+	for games := 1; games <= horizon; games++ {
+		rank := rankAtLadderLP(current+int(math.Round(slope*float64(games))), latest.Tier)
+		rank.Games = games
+		projection.Trajectory = append(projection.Trajectory, rank)
+	}
+	return projection, nil
+}
+
+func roundTo(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
+
+// recordLeaguePointsTx appends the entry's rank to the LP history when it
+// differs from the last observation of the player.
+func (dm *DatabaseManager) recordLeaguePointsTx(ctx context.Context, tx *sql.Tx, entry *WatchlistEntry) error {
+	if entry.Tier == "" {
+		return nil
+	}
+
+	var last LPHistoryPoint
+	err := tx.QueryRowContext(ctx, dm.rebind(`
+		SELECT tier, rank, league_points, wins, losses
+		FROM league_points_history
+		WHERE puuid = $1
+		ORDER BY id DESC
+		LIMIT 1
+	`), entry.PUUID).Scan(&last.Tier, &last.Rank, &last.LeaguePoints, &last.Wins, &last.Losses)
+	switch {
+	case err == nil:
+		if last.Tier == entry.Tier && last.Rank == entry.Rank && last.LeaguePoints == entry.LeaguePoints &&
+			last.Wins == entry.Wins && last.Losses == entry.Losses {
+			return nil
+		}
+	case err != sql.ErrNoRows:
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO league_points_history (puuid, region, tier, rank, league_points, wins, losses, observed_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`), entry.PUUID, entry.Region, entry.Tier, entry.Rank, entry.LeaguePoints, entry.Wins, entry.Losses, time.Now().UTC())
+	return err
+}
+
+// GetLeaguePointsHistory returns the newest observations first.
+func (dm *DatabaseManager) GetLeaguePointsHistory(ctx context.Context, puuid string, limit int) ([]LPHistoryPoint, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_league_points_history")
+	defer span.End()
+
+	rows, err := dm.queryAnalytics(ctx, dm.rebind(`
+		SELECT tier, rank, league_points, wins, losses, observed_at
+		FROM league_points_history
+		WHERE puuid = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`), puuid, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []LPHistoryPoint{}
+	for rows.Next() {
+		var point LPHistoryPoint
+		if err := rows.Scan(&point.Tier, &point.Rank, &point.LeaguePoints, &point.Wins, &point.Losses, &point.ObservedAt); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		history = append(history, point)
+	}
+	recordSpanError(span, rows.Err())
+	return history, rows.Err()
+}
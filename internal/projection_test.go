@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLadderLP(t *testing.T) {
+	tests := []struct {
+		tier     string
+		rank     string
+		lp       int
+		expected int
+		ok       bool
+	}{
+		{tier: "IRON", rank: "IV", lp: 0, expected: 0, ok: true},
+		{tier: "IRON", rank: "I", lp: 50, expected: 350, ok: true},
+		{tier: "GOLD", rank: "II", lp: 75, expected: 1475, ok: true},
+		{tier: "DIAMOND", rank: "I", lp: 99, expected: 2799, ok: true},
+		{tier: "MASTER", rank: "I", lp: 0, expected: 2800, ok: true},
+		{tier: "CHALLENGER", rank: "I", lp: 1200, expected: 4000, ok: true},
+		{tier: "GOLD", rank: "V", lp: 10},
+		{tier: "", rank: "", lp: 10},
+	}
+
+	for _, tt := range tests {
+		value, ok := ladderLP(tt.tier, tt.rank, tt.lp)
+		if ok != tt.ok || value != tt.expected {
+			t.Errorf("ladderLP(%s, %s, %d) = %d, %v, expected %d, %v", tt.tier, tt.rank, tt.lp, value, ok, tt.expected, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		rank := rankAtLadderLP(value, tt.tier)
+		if rank.Tier != tt.tier || rank.Rank != tt.rank || rank.LeaguePoints != tt.lp {
+			t.Errorf("rankAtLadderLP(%d) = %+v, expected %s %s %d", value, rank, tt.tier, tt.rank, tt.lp)
+		}
+	}
+
+	if rank := rankAtLadderLP(2850, "DIAMOND"); rank.Tier != "MASTER" || rank.LeaguePoints != 50 {
+		t.Errorf("rankAtLadderLP past DIAMOND = %+v, expected MASTER 50", rank)
+	}
+	if rank := rankAtLadderLP(-40, "IRON"); rank.Tier != "IRON" || rank.Rank != "IV" || rank.LeaguePoints != 0 {
+		t.Errorf("rankAtLadderLP below zero = %+v, expected IRON IV 0", rank)
+	}
+}
+
+func TestProjectLP(t *testing.T) {
+	// Newest first, as returned by GetLeaguePointsHistory.
+	history := []LPHistoryPoint{
+		{Tier: "GOLD", Rank: "I", LeaguePoints: 60, Wins: 8, Losses: 12},
+		{Tier: "GOLD", Rank: "I", LeaguePoints: 20, Wins: 7, Losses: 11},
+		{Tier: "GOLD", Rank: "II", LeaguePoints: 80, Wins: 6, Losses: 10},
+		{Tier: "GOLD", Rank: "II", LeaguePoints: 40, Wins: 5, Losses: 9},
+		{Tier: "GOLD", Rank: "II", LeaguePoints: 60, Wins: 4, Losses: 8},
+		// Previous season: more games than the observation after it.
+		{Tier: "PLATINUM", Rank: "IV", LeaguePoints: 10, Wins: 40, Losses: 40},
+	}
+
+	projection, err := projectLP("puuid-1", history, 5)
+	if err != nil {
+		t.Fatalf("projectLP() error = %v", err)
+	}
+
+	if projection.Observations != 5 || projection.GamesAnalyzed != 8 {
+		t.Errorf("observations = %d, games = %d, expected 5 and 8", projection.Observations, projection.GamesAnalyzed)
+	}
+	if projection.LPPerGame != 14 {
+		t.Errorf("LPPerGame = %v, expected 14", projection.LPPerGame)
+	}
+	if projection.AverageGain != 20 || projection.AverageLoss != -10 {
+		t.Errorf("gain = %v, loss = %v, expected 20 and -10", projection.AverageGain, projection.AverageLoss)
+	}
+	if projection.NextTier != "PLATINUM" || projection.LPToNextTier != 40 {
+		t.Errorf("next tier = %s at %d LP, expected PLATINUM at 40", projection.NextTier, projection.LPToNextTier)
+	}
+	if projection.GamesToNextTier == nil || *projection.GamesToNextTier != 3 {
+		t.Errorf("GamesToNextTier = %v, expected 3", projection.GamesToNextTier)
+	}
+	if len(projection.Trajectory) != 5 {
+		t.Fatalf("trajectory has %d points, expected 5", len(projection.Trajectory))
+	}
+	if last := projection.Trajectory[4]; last.Games != 5 || last.Tier != "PLATINUM" || last.Rank != "IV" || last.LeaguePoints != 30 {
+		t.Errorf("last trajectory point = %+v, expected PLATINUM IV 30 after 5 games", last)
+	}
+}
+
+func TestProjectLPDeclining(t *testing.T) {
+	history := []LPHistoryPoint{
+		{Tier: "MASTER", Rank: "I", LeaguePoints: 40, Wins: 20, Losses: 22},
+		{Tier: "MASTER", Rank: "I", LeaguePoints: 100, Wins: 20, Losses: 20},
+	}
+
+	projection, err := projectLP("puuid-1", history, 3)
+	if err != nil {
+		t.Fatalf("projectLP() error = %v", err)
+	}
+	if projection.LPPerGame != -30 || projection.GamesToNextTier != nil || projection.NextTier != "" {
+		t.Errorf("projection = %+v, expected -30 LP per game and no next tier", projection)
+	}
+	if last := projection.Trajectory[2]; last.Tier != "DIAMOND" || last.Rank != "I" || last.LeaguePoints != 50 {
+		t.Errorf("last trajectory point = %+v, expected DIAMOND I 50", last)
+	}
+}
+
+func TestProjectLPNotEnoughHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		history []LPHistoryPoint
+	}{
+		{name: "empty"},
+		{name: "single observation", history: []LPHistoryPoint{{Tier: "GOLD", Rank: "I", LeaguePoints: 10, Wins: 1, Losses: 1}}},
+		{name: "no games between observations", history: []LPHistoryPoint{
+			{Tier: "GOLD", Rank: "I", LeaguePoints: 10, Wins: 1, Losses: 1},
+			{Tier: "GOLD", Rank: "I", LeaguePoints: 0, Wins: 1, Losses: 1},
+		}},
+		{name: "unranked", history: []LPHistoryPoint{{LeaguePoints: 10, Wins: 2}, {Wins: 1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := projectLP("puuid-1", tt.history, 5); !errors.Is(err, ErrNotEnoughLPHistory) {
+				t.Errorf("projectLP() error = %v, expected ErrNotEnoughLPHistory", err)
+			}
+		})
+	}
+}
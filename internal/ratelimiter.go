@@ -3,15 +3,22 @@ package internal
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
 type RateLimiter struct {
-	client *redis.Client
-	prefix string
-	logger *Logger
+	client     *redis.Client
+	prefix     string
+	logger     *Logger
+	multiplier int
+
+	methodLimits map[string][]RateLimit
+	limitsMu     sync.RWMutex
 }
 
 type RateLimit struct {
@@ -24,60 +31,272 @@ var riotRateLimits = []RateLimit{
 	{requests: 100, window: 2 * time.Minute},
 }
 
-func NewRateLimiter(cfg *Config, logger *Logger) *RateLimiter {
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
-	})
+var defaultMethodRateLimits = map[string][]RateLimit{
+	"summoner":        {{requests: 1600, window: 1 * time.Minute}},
+	"search":          {{requests: 1000, window: 1 * time.Minute}},
+	"challenger":      {{requests: 30, window: 10 * time.Second}, {requests: 500, window: 10 * time.Minute}},
+	"grandmaster":     {{requests: 30, window: 10 * time.Second}, {requests: 500, window: 10 * time.Minute}},
+	"master":          {{requests: 30, window: 10 * time.Second}, {requests: 500, window: 10 * time.Minute}},
+	"entries":         {{requests: 50, window: 10 * time.Second}},
+	"league-by-puuid": {{requests: 270, window: 1 * time.Minute}},
+}
+
+func NewRateLimiter(cfg *Config, client *redis.Client, logger *Logger) *RateLimiter {
+	methodLimits, err := parseMethodRateLimits(cfg.RateLimitMethodLimits)
+	if err != nil {
+		logger.Error("method_rate_limits_invalid").
+			Component("rate_limiter").
+			Operation("init").
+			Err(err).
+			Meta("value", cfg.RateLimitMethodLimits).
+			Log()
+		methodLimits = map[string][]RateLimit{}
+	}
+
+	multiplier := 1
+	if cfg.IsSandbox() && cfg.SandboxRateLimitMultiplier > 1 {
+		multiplier = cfg.SandboxRateLimitMultiplier
+	}
 
 	return &RateLimiter{
-		client: client,
-		prefix: cfg.RateLimitRedisPrefix,
-		logger: logger,
+		client:       client,
+		prefix:       cfg.RateLimitRedisPrefix,
+		logger:       logger,
+		multiplier:   multiplier,
+		methodLimits: withDefaultMethodRateLimits(methodLimits),
 	}
 }
 
-func (rl *RateLimiter) Allow(ctx context.Context, key string) (bool, error) {
-	for _, limit := range riotRateLimits {
-		allowed, err := rl.checkLimit(ctx, key, limit)
-		if err != nil {
-			rl.logger.Error("rate_limit_check_failed").
-				Component("rate_limiter").
-				Operation("check_limit").
-				Err(err).
-				Meta("key", key).
-				Log()
-			return false, err
+// ApplyConfig swaps the per-method limits. Counters already in Redis are
+// kept, so a tightened limit applies to the current window immediately. An
+// invalid value keeps the limits in force.
+func (rl *RateLimiter) ApplyConfig(cfg *Config) {
+	methodLimits, err := parseMethodRateLimits(cfg.RateLimitMethodLimits)
+	if err != nil {
+		rl.logger.Error("method_rate_limits_invalid").
+			Component("rate_limiter").
+			Operation("reload").
+			Err(err).
+			Meta("value", cfg.RateLimitMethodLimits).
+			Log()
+		return
+	}
+
+	limits := withDefaultMethodRateLimits(methodLimits)
+	rl.limitsMu.Lock()
+	rl.methodLimits = limits
+	rl.limitsMu.Unlock()
+}
+
+func withDefaultMethodRateLimits(methodLimits map[string][]RateLimit) map[string][]RateLimit {
+	limits := make(map[string][]RateLimit, len(defaultMethodRateLimits))
+	for method, methodLimit := range defaultMethodRateLimits {
+		limits[method] = methodLimit
+	}
+	for method, methodLimit := range methodLimits {
+		limits[method] = methodLimit
+	}
+	return limits
+}
+
+func parseMethodRateLimits(value string) (map[string][]RateLimit, error) {
+	limits := make(map[string][]RateLimit)
+	if strings.TrimSpace(value) == "" {
+		return limits, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		method, spec, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found || method == "" {
+			return nil, fmt.Errorf("invalid method rate limit rule %q", rule)
 		}
-		if !allowed {
-			rl.logger.Debug("rate_limit_blocked").
-				Component("rate_limiter").
-				Operation("check_limit").
-				Meta("key", key).
-				Meta("limit_requests", limit.requests).
-				Meta("limit_window", limit.window.String()).
-				Log()
-			return false, nil
+
+		for _, part := range strings.Split(spec, "+") {
+			requestsStr, windowStr, found := strings.Cut(part, "/")
+			if !found {
+				return nil, fmt.Errorf("invalid rate limit %q for method %s", part, method)
+			}
+
+			requests, err := strconv.Atoi(requestsStr)
+			if err != nil || requests <= 0 {
+				return nil, fmt.Errorf("invalid request count %q for method %s", requestsStr, method)
+			}
+
+			window, err := time.ParseDuration(windowStr)
+			if err != nil || window <= 0 {
+				return nil, fmt.Errorf("invalid window %q for method %s", windowStr, method)
+			}
+
+			limits[method] = append(limits[method], RateLimit{requests: requests, window: window})
 		}
 	}
-	return true, nil
+
+	return limits, nil
+}
+
+func (rl *RateLimiter) Ping(ctx context.Context) error {
+	return rl.client.Ping(ctx).Err()
+}
+
+// RateLimitResult describes the most constrained bucket for a request: its
+// capacity, whole tokens left and how long until it is full again.
+// RetryAfter is zero when the request was allowed.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
 }
 
-func (rl *RateLimiter) checkLimit(ctx context.Context, key string, limit RateLimit) (bool, error) {
-	redisKey := fmt.Sprintf("%s:%s:%d", rl.prefix, key, int(limit.window.Seconds()))
+// tokenBucketScript refills and checks every bucket of a request before
+// taking its cost from any of them (the last argument; a bucket is charged
+// at most its capacity, so a costly request empties it rather than never
+// fitting), so a request denied by one limit does not
+// use up another and concurrent callers cannot overshoot a limit. Redis TIME
+// keeps all instances on one clock. Buckets expire after a window without
+// requests, when they would be full anyway.
+var tokenBucketScript = redis.NewScript(`
+local t = redis.call("TIME")
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+local tokens, rates, costs = {}, {}, {}
+local allowed, retry = 1, 0
+local cost = tonumber(ARGV[2 * #KEYS + 1])
+
+for i = 1, #KEYS do
+	local capacity = tonumber(ARGV[2 * i - 1])
+	local window = tonumber(ARGV[2 * i])
+	local rate = capacity / window
+	local state = redis.call("HMGET", KEYS[i], "tokens", "ts")
+	local current, ts = tonumber(state[1]), tonumber(state[2])
+	if current == nil or ts == nil then
+		current = capacity
+	else
+		current = math.min(capacity, current + math.max(0, now - ts) * rate)
+	end
+	tokens[i], rates[i], costs[i] = current, rate, math.min(cost, capacity)
+	if current < costs[i] then
+		allowed = 0
+		retry = math.max(retry, math.ceil((costs[i] - current) / rate))
+	end
+end
+
+local limit, remaining, reset = 0, -1, 0
+for i = 1, #KEYS do
+	local capacity = tonumber(ARGV[2 * i - 1])
+	if allowed == 1 then
+		tokens[i] = tokens[i] - costs[i]
+	end
+	redis.call("HSET", KEYS[i], "tokens", tostring(tokens[i]), "ts", now)
+	redis.call("PEXPIRE", KEYS[i], ARGV[2 * i])
+	local left = math.floor(tokens[i])
+	if remaining < 0 or left < remaining then
+		limit, remaining = capacity, left
+		reset = math.ceil((capacity - tokens[i]) / rates[i])
+	end
+end
+
+return {allowed, limit, remaining, reset, retry}
+`)
+
+type bucket struct {
+	key      string
+	capacity int
+	window   time.Duration
+}
 
-	count, err := rl.client.Incr(ctx, redisKey).Result()
+func (rl *RateLimiter) Allow(ctx context.Context, key string) (bool, error) {
+	result, err := rl.Check(ctx, key)
 	if err != nil {
 		return false, err
 	}
+	return result.Allowed, nil
+}
+
+// Check takes a token for key from the global Riot buckets and, when the
+// method has its own limits, from the caller's method buckets.
+func (rl *RateLimiter) Check(ctx context.Context, key string) (*RateLimitResult, error) {
+	return rl.CheckN(ctx, key, 1)
+}
 
-	if count == 1 {
-		err = rl.client.Expire(ctx, redisKey, limit.window).Err()
-		if err != nil {
-			return false, err
+// CheckN is Check for a request that costs n tokens, such as a GraphQL query
+// charged by its estimated upstream calls.
+func (rl *RateLimiter) CheckN(ctx context.Context, key string, n int) (*RateLimitResult, error) {
+	buckets := rl.buckets(key, key, riotRateLimits, 1)
+
+	rl.limitsMu.RLock()
+	limits, exists := rl.methodLimits[key]
+	rl.limitsMu.RUnlock()
+	if exists {
+		if principal := GetPrincipal(ctx); principal != nil {
+			buckets = append(buckets, rl.buckets("key:"+principal.KeyID+":method:"+key, key, limits, principal.RateLimitMultiplier())...)
+		} else {
+			buckets = append(buckets, rl.buckets("method:"+key, key, limits, 1)...)
 		}
 	}
 
-	return int(count) <= limit.requests, nil
+	result, err := rl.take(ctx, buckets, max(n, 1))
+	if err != nil {
+		rl.logger.Error("rate_limit_check_failed").
+			Component("rate_limiter").
+			Operation("check_limit").
+			Err(err).
+			Meta("key", key).
+			Meta("cost", n).
+			Log()
+		return nil, err
+	}
+	if !result.Allowed {
+		rl.logger.Debug("rate_limit_blocked").
+			Component("rate_limiter").
+			Operation("check_limit").
+			Meta("key", key).
+			Meta("limit_requests", result.Limit).
+			Meta("retry_after", result.RetryAfter.String()).
+			Log()
+	}
+	return result, nil
+}
+
+// Bucket keys differ from the old fixed-window counters so both can coexist
+// in Redis while the counters expire.
+func (rl *RateLimiter) buckets(scope, key string, limits []RateLimit, multiplier int) []bucket {
+	buckets := make([]bucket, 0, len(limits))
+	for _, limit := range limits {
+		buckets = append(buckets, bucket{
+			key:      fmt.Sprintf("%s:bucket:%s:%d", rl.prefix, scope, limit.window.Milliseconds()),
+			capacity: limit.requests * rl.multiplier * multiplier,
+			window:   limit.window,
+		})
+	}
+	return buckets
+}
+
+func (rl *RateLimiter) take(ctx context.Context, buckets []bucket, cost int) (*RateLimitResult, error) {
+	keys := make([]string, 0, len(buckets))
+	args := make([]interface{}, 0, 2*len(buckets)+1)
+	for _, b := range buckets {
+		keys = append(keys, b.key)
+		args = append(args, b.capacity, b.window.Milliseconds())
+	}
+	args = append(args, cost)
+
+	values, err := tokenBucketScript.Run(ctx, rl.client, keys, args...).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+	return parseTokenBucketResult(values)
+}
+
+func parseTokenBucketResult(values []int64) (*RateLimitResult, error) {
+	if len(values) != 5 {
+		return nil, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+	return &RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      int(values[1]),
+		Remaining:  int(values[2]),
+		Reset:      time.Duration(values[3]) * time.Millisecond,
+		RetryAfter: time.Duration(values[4]) * time.Millisecond,
+	}, nil
 }
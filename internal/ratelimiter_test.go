@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMethodRateLimits(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  map[string][]RateLimit
+		expectErr bool
+	}{
+		{
+			name:     "empty value",
+			value:    "",
+			expected: map[string][]RateLimit{},
+		},
+		{
+			name:  "single method single limit",
+			value: "summoner=1600/1m",
+			expected: map[string][]RateLimit{
+				"summoner": {{requests: 1600, window: time.Minute}},
+			},
+		},
+		{
+			name:  "multiple methods and layered limits",
+			value: "challenger=30/10s+500/10m, entries=50/10s",
+			expected: map[string][]RateLimit{
+				"challenger": {{requests: 30, window: 10 * time.Second}, {requests: 500, window: 10 * time.Minute}},
+				"entries":    {{requests: 50, window: 10 * time.Second}},
+			},
+		},
+		{
+			name:      "missing method",
+			value:     "=30/10s",
+			expectErr: true,
+		},
+		{
+			name:      "invalid request count",
+			value:     "summoner=abc/1m",
+			expectErr: true,
+		},
+		{
+			name:      "invalid window",
+			value:     "summoner=10/forever",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseMethodRateLimits(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseMethodRateLimits() returned %d methods, expected %d", len(result), len(tt.expected))
+			}
+			for method, limits := range tt.expected {
+				if len(result[method]) != len(limits) {
+					t.Errorf("method %s has %d limits, expected %d", method, len(result[method]), len(limits))
+					continue
+				}
+				for i, limit := range limits {
+					if result[method][i] != limit {
+						t.Errorf("method %s limit %d = %+v, expected %+v", method, i, result[method][i], limit)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Buckets(t *testing.T) {
+	rl := NewRateLimiter(&Config{RateLimitRedisPrefix: "tft:ratelimit", AppEnv: "sandbox", SandboxRateLimitMultiplier: 3}, nil, newTestLogger())
+	limits := []RateLimit{{requests: 30, window: 10 * time.Second}, {requests: 5, window: 500 * time.Millisecond}}
+
+	expected := []bucket{
+		{key: "tft:ratelimit:bucket:method:master:10000", capacity: 180, window: 10 * time.Second},
+		{key: "tft:ratelimit:bucket:method:master:500", capacity: 30, window: 500 * time.Millisecond},
+	}
+	if buckets := rl.buckets("method:master", "master", limits, 2); !reflect.DeepEqual(buckets, expected) {
+		t.Errorf("buckets() = %+v, expected %+v", buckets, expected)
+	}
+}
+
+func TestParseTokenBucketResult(t *testing.T) {
+	result, err := parseTokenBucketResult([]int64{0, 20, 0, 1000, 50})
+	if err != nil {
+		t.Fatalf("parseTokenBucketResult() error = %v", err)
+	}
+	expected := &RateLimitResult{Allowed: false, Limit: 20, Remaining: 0, Reset: time.Second, RetryAfter: 50 * time.Millisecond}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("parseTokenBucketResult() = %+v, expected %+v", result, expected)
+	}
+
+	if _, err := parseTokenBucketResult([]int64{1}); err == nil {
+		t.Error("expected an error for a short result")
+	}
+}
+
+func TestSetRateLimitHeaders(t *testing.T) {
+	tests := []struct {
+		name       string
+		result     RateLimitResult
+		reset      string
+		retryAfter string
+	}{
+		{name: "allowed", result: RateLimitResult{Allowed: true, Limit: 20, Remaining: 12, Reset: 400 * time.Millisecond}, reset: "1"},
+		{name: "denied rounds up", result: RateLimitResult{Limit: 100, Reset: 119500 * time.Millisecond, RetryAfter: 1200 * time.Millisecond}, reset: "120", retryAfter: "2"},
+		{name: "denied waits at least a second", result: RateLimitResult{Limit: 20, Reset: time.Second, RetryAfter: 0}, reset: "1", retryAfter: "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			setRateLimitHeaders(recorder, &tt.result)
+
+			header := recorder.Header()
+			if header.Get("X-RateLimit-Limit") == "" || header.Get("X-RateLimit-Remaining") == "" {
+				t.Errorf("headers = %v, expected limit and remaining", header)
+			}
+			if header.Get("X-RateLimit-Reset") != tt.reset {
+				t.Errorf("X-RateLimit-Reset = %q, expected %q", header.Get("X-RateLimit-Reset"), tt.reset)
+			}
+			if header.Get("Retry-After") != tt.retryAfter {
+				t.Errorf("Retry-After = %q, expected %q", header.Get("Retry-After"), tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	logger := newTestLogger()
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/league/challenger", nil)
+	req.Header.Set("Origin", "https://app.example")
+	withCORS(func(w http.ResponseWriter, r *http.Request) {
+		result := &RateLimitResult{Limit: 30, Reset: 10 * time.Second, RetryAfter: 2300 * time.Millisecond}
+		setRateLimitHeaders(w, result)
+		writeError(w, rateLimitError(result), logger, r)
+	})(rec, req)
+
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "3" {
+		t.Fatalf("status %d, Retry-After %q, expected 429 and 3", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	var body struct {
+		RetryAfter int `json:"retryAfter"`
+		Limit      int `json:"limit"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.RetryAfter != 3 || body.Limit != 30 {
+		t.Errorf("body = %s, expected retryAfter 3 and limit 30", rec.Body.String())
+	}
+
+	exposed := rec.Header().Get("Access-Control-Expose-Headers")
+	for _, header := range []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"} {
+		if !strings.Contains(exposed, header) {
+			t.Errorf("Access-Control-Expose-Headers = %q, expected %s for browser clients", exposed, header)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// RecoveryMiddleware turns a handler panic into a logged, counted 500 instead
+// of a dropped connection. It must run inside LoggingMiddleware so the
+// request ID is known and the request is still recorded as completed.
+type RecoveryMiddleware struct {
+	logger  *Logger
+	metrics *MetricsCollector
+}
+
+func NewRecoveryMiddleware(logger *Logger, metrics *MetricsCollector) *RecoveryMiddleware {
+	return &RecoveryMiddleware{
+		logger:  logger,
+		metrics: metrics,
+	}
+}
+
+func (rm *RecoveryMiddleware) Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rw := &recoveryWriter{ResponseWriter: w}
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			// net/http uses ErrAbortHandler to abort a response on purpose.
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			rm.recovered(rw, r, p, debug.Stack())
+		}()
+
+		next(rw, r)
+	}
+}
+
+func (rm *RecoveryMiddleware) recovered(rw *recoveryWriter, r *http.Request, p interface{}, stack []byte) {
+	requestID := GetRequestID(r.Context())
+
+	if rm.metrics != nil {
+		rm.metrics.RecordPanic(requestRoute(r))
+	}
+
+	rm.logger.Error("handler_panic").
+		Component("http").
+		Operation("recover").
+		HTTP(r.Method, r.URL.Path, http.StatusInternalServerError).
+		Request(r.UserAgent(), r.RemoteAddr, requestID).
+		Err(fmt.Errorf("panic: %v", p)).
+		Meta("stack", string(stack)).
+		Meta("response_started", rw.wroteHeader).
+		Log()
+
+	// Once the status line is out the client can only see a truncated body, so
+	// abort the connection rather than let it pass for a complete response.
+	if rw.wroteHeader {
+		panic(http.ErrAbortHandler)
+	}
+
+	problem := ProblemDetails{
+		Type:      "about:blank",
+		Title:     "Internal server error",
+		Code:      ErrorCodeInternal,
+		Status:    http.StatusInternalServerError,
+		Detail:    "The request could not be completed due to an unexpected error",
+		Instance:  r.URL.Path,
+		RequestID: requestID,
+	}
+
+	setCORSHeaders(rw, r)
+	rw.Header().Set("Content-Type", "application/problem+json")
+	rw.WriteHeader(http.StatusInternalServerError)
+	body, _ := json.Marshal(problem)
+	rw.Write(append(body, '\n'))
+}
+
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (rw *recoveryWriter) WriteHeader(code int) {
+	rw.wroteHeader = true
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recoveryWriter) Write(p []byte) (int, error) {
+	rw.wroteHeader = true
+	return rw.ResponseWriter.Write(p)
+}
+
+func (rw *recoveryWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
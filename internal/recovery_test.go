@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoveryMiddleware_ReturnsProblem(t *testing.T) {
+	metrics := &MetricsCollector{panics: make(map[string]int64)}
+	recovery := NewRecoveryMiddleware(newTestLogger(), metrics)
+
+	handler := recovery.Handler(func(w http.ResponseWriter, r *http.Request) {
+		var entries []LeagueEntry
+		_ = entries[3]
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/league/entries", nil)
+	req = req.WithContext(context.WithValue(req.Context(), RequestIDKey, "req-123"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, expected %d", rec.Code, http.StatusInternalServerError)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type = %q, expected application/problem+json", contentType)
+	}
+
+	var problem ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid problem body: %v", err)
+	}
+	if problem.RequestID != "req-123" || problem.Status != http.StatusInternalServerError || problem.Instance != "/league/entries" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+	if metrics.panics["/league/entries"] != 1 {
+		t.Errorf("panics = %v, expected one for /league/entries", metrics.panics)
+	}
+}
+
+func TestRecoveryMiddleware_AbortsStartedResponse(t *testing.T) {
+	metrics := &MetricsCollector{panics: make(map[string]int64)}
+	recovery := NewRecoveryMiddleware(newTestLogger(), metrics)
+
+	handler := recovery.Handler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		panic("encoder exploded")
+	})
+
+	rec := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("recovered %v, expected http.ErrAbortHandler", p)
+			}
+		}()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/league/challenger", nil))
+	}()
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("response = %d %q, expected the started response to be left untouched", rec.Code, rec.Body.String())
+	}
+	if metrics.panics["/league/challenger"] != 1 {
+		t.Errorf("panics = %v, expected the panic to be counted before aborting", metrics.panics)
+	}
+}
+
+func TestRecoveryMiddleware_RepanicsAbort(t *testing.T) {
+	recovery := NewRecoveryMiddleware(newTestLogger(), nil)
+	handler := recovery.Handler(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if p := recover(); p != http.ErrAbortHandler {
+			t.Errorf("recovered %v, expected http.ErrAbortHandler", p)
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/healthz", nil))
+}
@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisProvider owns the single pooled Redis client shared by the rate
+// limiter, the cache and anything else that talks to Redis.
+type RedisProvider struct {
+	client *redis.Client
+}
+
+type RedisPoolStats struct {
+	Hits           uint32 `json:"hits"`
+	Misses         uint32 `json:"misses"`
+	Timeouts       uint32 `json:"timeouts"`
+	WaitCount      uint32 `json:"wait_count"`
+	WaitDurationMs int64  `json:"wait_duration_ms"`
+	TotalConns     uint32 `json:"total_conns"`
+	IdleConns      uint32 `json:"idle_conns"`
+	StaleConns     uint32 `json:"stale_conns"`
+	PoolSize       int    `json:"pool_size"`
+}
+
+func NewRedisProvider(cfg *Config) *RedisProvider {
+	return &RedisProvider{
+		client: redis.NewClient(&redis.Options{
+			Addr:         fmt.Sprintf("%s:%s", cfg.RedisHost, cfg.RedisPort),
+			Password:     cfg.RedisPassword,
+			DB:           cfg.RedisDB,
+			PoolSize:     cfg.RedisPoolSize,
+			MinIdleConns: cfg.RedisMinIdleConns,
+			PoolTimeout:  cfg.RedisPoolTimeout,
+		}),
+	}
+}
+
+func (rp *RedisProvider) Client() *redis.Client {
+	return rp.client
+}
+
+func (rp *RedisProvider) Ping(ctx context.Context) error {
+	return rp.client.Ping(ctx).Err()
+}
+
+func (rp *RedisProvider) Stats() RedisPoolStats {
+	stats := rp.client.PoolStats()
+	return RedisPoolStats{
+		Hits:           stats.Hits,
+		Misses:         stats.Misses,
+		Timeouts:       stats.Timeouts,
+		WaitCount:      stats.WaitCount,
+		WaitDurationMs: stats.WaitDurationNs / 1e6,
+		TotalConns:     stats.TotalConns,
+		IdleConns:      stats.IdleConns,
+		StaleConns:     stats.StaleConns,
+		PoolSize:       rp.client.Options().PoolSize,
+	}
+}
+
+func (rp *RedisProvider) Close() error {
+	return rp.client.Close()
+}
@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedisProvider_SharedClientAndStats(t *testing.T) {
+	cfg := &Config{
+		RedisHost:         "localhost",
+		RedisPort:         "6379",
+		RedisPoolSize:     7,
+		RedisMinIdleConns: 0,
+		RedisPoolTimeout:  time.Second,
+		CacheEnabled:      true,
+		CacheBackend:      CacheBackendRedis,
+	}
+	provider := NewRedisProvider(cfg)
+	defer provider.Close()
+
+	limiter := NewRateLimiter(cfg, provider.Client(), newTestLogger())
+	cache := NewCacheManager(cfg, provider.Client(), nil, nil)
+	if limiter.client != provider.Client() || cache.redis != provider.Client() {
+		t.Error("expected rate limiter and cache to share the provider client")
+	}
+
+	stats := provider.Stats()
+	if stats.PoolSize != 7 {
+		t.Errorf("PoolSize = %d, expected 7", stats.PoolSize)
+	}
+
+	mc := &MetricsCollector{logger: newTestLogger()}
+	if _, exists := mc.GetMetrics()["redis_pool"]; exists {
+		t.Error("redis_pool should be absent without a provider")
+	}
+	mc.SetRedisProvider(provider)
+	if _, ok := mc.GetMetrics()["redis_pool"].(RedisPoolStats); !ok {
+		t.Error("expected redis_pool stats in metrics")
+	}
+}
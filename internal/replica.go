@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// replicaCooldown is how long analytics queries stay on the primary after the
+// replica fails before it is tried again.
+const replicaCooldown = 30 * time.Second
+
+type readReplica struct {
+	db        *sql.DB
+	downUntil int64
+}
+
+func (rr *readReplica) available(now time.Time) bool {
+	return now.UnixNano() >= atomic.LoadInt64(&rr.downUntil)
+}
+
+func (rr *readReplica) markDown(now time.Time) {
+	atomic.StoreInt64(&rr.downUntil, now.Add(replicaCooldown).UnixNano())
+}
+
+// AttachReplica opens a pool against a Postgres read replica. The pool is
+// lazy: an unreachable replica only shows up when queryAnalytics falls back.
+func (dm *DatabaseManager) AttachReplica(dsn string) error {
+	if dsn == "" || dm.Dialect != DatabaseDriverPostgres {
+		return nil
+	}
+
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	db.SetMaxOpenConns(10)
+	db.SetMaxIdleConns(2)
+	db.SetConnMaxLifetime(30 * time.Minute)
+
+	dm.replica = &readReplica{db: db}
+	log.Println("Database read replica configured")
+	return nil
+}
+
+// queryAnalytics runs heavy read-only queries (history, aggregates) on the
+// replica when one is configured and healthy, falling back to the primary.
+func (dm *DatabaseManager) queryAnalytics(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if rr := dm.replica; rr != nil && rr.available(time.Now()) {
+		rows, err := rr.db.QueryContext(ctx, query, args...)
+		if err == nil {
+			return rows, nil
+		}
+		if ctx.Err() != nil {
+			return nil, err
+		}
+
+		rr.markDown(time.Now())
+		log.Printf("Read replica query failed, using primary for %s: %v", replicaCooldown, err)
+	}
+	return dm.DB.QueryContext(ctx, query, args...)
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadReplica_Cooldown(t *testing.T) {
+	replica := &readReplica{}
+	now := time.Now()
+
+	if !replica.available(now) {
+		t.Fatal("new replica should be available")
+	}
+
+	replica.markDown(now)
+	if replica.available(now.Add(replicaCooldown / 2)) {
+		t.Error("replica should stay unavailable during the cooldown")
+	}
+	if !replica.available(now.Add(replicaCooldown)) {
+		t.Error("replica should be retried once the cooldown elapses")
+	}
+}
+
+func TestDatabaseManager_AttachReplicaSkipsSQLite(t *testing.T) {
+	dm := &DatabaseManager{Dialect: DatabaseDriverSQLite}
+	if err := dm.AttachReplica("host=replica dbname=tft"); err != nil {
+		t.Fatalf("AttachReplica() error = %v", err)
+	}
+	if dm.replica != nil {
+		t.Error("replica should not be attached for sqlite")
+	}
+}
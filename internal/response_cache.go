@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// conditionalRequestHeaders are dropped from the request a shared call
+// runs, so the stored response is the full one; each caller's own
+// conditions are checked when it is written.
+var conditionalRequestHeaders = []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"}
+
+// cachedResponseHeaders are kept from a response, for the cache and for the
+// callers sharing its call. Last-known-good responses are no-store and only
+// ever reach the latter.
+var cachedResponseHeaders = []string{"Content-Type", "Content-Disposition", "ETag", "Cache-Control", "X-Last-Known-Good", "X-Last-Known-Good-Age"}
+
+type CachedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers"`
+	Body    []byte            `json:"body"`
+}
+
+type ResponseCache struct {
+	cache   *CacheManager
+	ttl     reloadableDuration
+	enabled bool
+	logger  *Logger
+	metrics *MetricsCollector
+	group   singleflight.Group
+}
+
+func NewResponseCache(cfg *Config, cache *CacheManager, logger *Logger, metrics *MetricsCollector) *ResponseCache {
+	return &ResponseCache{
+		cache:   cache,
+		ttl:     newReloadableDuration(cfg.ResponseCacheTTL),
+		enabled: cfg.ResponseCacheEnabled && cfg.CacheEnabled,
+		logger:  logger,
+		metrics: metrics,
+	}
+}
+
+func (rc *ResponseCache) ApplyConfig(cfg *Config) {
+	rc.ttl.Store(cfg.ResponseCacheTTL)
+}
+
+func (rc *ResponseCache) Handler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !rc.enabled || r.Method != http.MethodGet || r.Header.Get("Authorization") != "" {
+			next(w, r)
+			return
+		}
+
+		key := rc.cache.Key("response", r.URL.Path, r.URL.Query().Encode())
+
+		var cached CachedResponse
+		if err := rc.cache.Get(r.Context(), key, &cached); err == nil {
+			if rc.metrics != nil {
+				rc.metrics.RecordCacheHit(key)
+			}
+			rc.write(w, r, &cached, "HIT")
+			return
+		}
+
+		if rc.metrics != nil {
+			rc.metrics.RecordCacheMiss(key)
+		}
+
+		results := rc.group.DoChan(key, func() (interface{}, error) {
+			shared, cancel := sharedRequest(r)
+			defer cancel()
+			recorder := newCaptureWriter()
+			next(recorder, shared)
+
+			response := &CachedResponse{
+				Status:  recorder.status,
+				Headers: make(map[string]string),
+				Body:    recorder.body.Bytes(),
+			}
+			for _, header := range cachedResponseHeaders {
+				if value := recorder.header.Get(header); value != "" {
+					response.Headers[header] = value
+				}
+			}
+
+			if response.Status == http.StatusOK && !strings.Contains(response.Headers["Cache-Control"], "no-store") {
+				if err := rc.cache.Set(context.Background(), key, response, rc.ttl.Load()); err != nil {
+					rc.logger.Error("response_cache_store_failed").
+						Component("response_cache").
+						Operation("store").
+						Cache(false, key).
+						Err(err).
+						Log()
+				}
+			}
+			return response, nil
+		})
+
+		select {
+		case result := <-results:
+			status := "MISS"
+			if result.Shared {
+				status = "SHARED"
+			}
+			rc.write(w, r, result.Val.(*CachedResponse), status)
+		case <-r.Context().Done():
+			// The shared call carries on for the other callers and the cache.
+			writeError(w, NewAPIError("Request timed out", http.StatusGatewayTimeout).WithCause(r.Context().Err()), rc.logger, r)
+		}
+	}
+}
+
+// sharedRequest is r for a call whose response other callers wait on: it
+// keeps r's deadline but not its cancellation, so the first caller going
+// away does not fail everyone else, and carries no conditional headers.
+func sharedRequest(r *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithoutCancel(r.Context()), context.CancelFunc(func() {})
+	if deadline, ok := r.Context().Deadline(); ok {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	}
+
+	shared := r.Clone(ctx)
+	for _, header := range conditionalRequestHeaders {
+		shared.Header.Del(header)
+	}
+	return shared, cancel
+}
+
+func (rc *ResponseCache) write(w http.ResponseWriter, r *http.Request, response *CachedResponse, status string) {
+	setCORSHeaders(w, r)
+	for header, value := range response.Headers {
+		w.Header().Set(header, value)
+	}
+	w.Header().Set("X-Response-Cache", status)
+
+	if etag := response.Headers["ETag"]; etag != "" && response.Status == http.StatusOK &&
+		etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.WriteHeader(response.Status)
+	w.Write(response.Body)
+}
+
+type captureWriter struct {
+	header http.Header
+	body   bytes.Buffer
+	status int
+}
+
+func newCaptureWriter() *captureWriter {
+	return &captureWriter{header: make(http.Header), status: http.StatusOK}
+}
+
+func (cw *captureWriter) Header() http.Header {
+	return cw.header
+}
+
+func (cw *captureWriter) Write(data []byte) (int, error) {
+	return cw.body.Write(data)
+}
+
+func (cw *captureWriter) WriteHeader(status int) {
+	cw.status = status
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestResponseCache() *ResponseCache {
+	cache := &CacheManager{backend: newMapBackend(), enabled: true, clock: SystemClock}
+	return NewResponseCache(&Config{ResponseCacheEnabled: true, CacheEnabled: true, ResponseCacheTTL: time.Minute}, cache, newTestLogger(), nil)
+}
+
+func TestResponseCache_ConditionalRequestsPerCaller(t *testing.T) {
+	var seen []string
+	handler := newTestResponseCache().Handler(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.Header.Get("If-None-Match"))
+		etag := `"v1"`
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("body"))
+	})
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+		body        string
+		cache       string
+	}{
+		{name: "revalidating first caller", ifNoneMatch: `"v1"`, status: http.StatusNotModified, cache: "MISS"},
+		{name: "plain caller gets the stored body", status: http.StatusOK, body: "body", cache: "HIT"},
+		{name: "stale etag", ifNoneMatch: `"v0"`, status: http.StatusOK, body: "body", cache: "HIT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/leaderboard", nil)
+			if tt.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tt.ifNoneMatch)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			if rec.Code != tt.status || rec.Body.String() != tt.body {
+				t.Errorf("response = %d %q, expected %d %q", rec.Code, rec.Body.String(), tt.status, tt.body)
+			}
+			if got := rec.Header().Get("X-Response-Cache"); got != tt.cache {
+				t.Errorf("X-Response-Cache = %q, expected %q", got, tt.cache)
+			}
+		})
+	}
+	if len(seen) != 1 || seen[0] != "" {
+		t.Errorf("handler saw If-None-Match %q, expected one call without it", seen)
+	}
+}
+
+func TestResponseCache_SharedCallOutlivesFirstCaller(t *testing.T) {
+	started, release := make(chan struct{}), make(chan struct{})
+	var once sync.Once
+	var sharedErr error
+	handler := newTestResponseCache().Handler(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() { close(started) })
+		<-release
+		sharedErr = r.Context().Err()
+		w.Write([]byte("body"))
+	})
+
+	ctx, cancel := context.WithCancel(t.Context())
+	first := httptest.NewRecorder()
+	firstDone := make(chan struct{})
+	go func() {
+		handler(first, httptest.NewRequest(http.MethodGet, "/leaderboard", nil).WithContext(ctx))
+		close(firstDone)
+	}()
+	<-started
+	cancel()
+	<-firstDone
+	if first.Code != http.StatusGatewayTimeout {
+		t.Errorf("cancelled caller status = %d, expected %d", first.Code, http.StatusGatewayTimeout)
+	}
+
+	second := httptest.NewRecorder()
+	secondDone := make(chan struct{})
+	go func() {
+		handler(second, httptest.NewRequest(http.MethodGet, "/leaderboard", nil))
+		close(secondDone)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	<-secondDone
+
+	if second.Code != http.StatusOK || second.Body.String() != "body" {
+		t.Errorf("waiting caller got %d %q, expected the shared response", second.Code, second.Body.String())
+	}
+	if sharedErr != nil {
+		t.Errorf("shared call context = %v, expected it to survive the first caller", sharedErr)
+	}
+}
+
+func TestResponseCache_SkipsLastKnownGood(t *testing.T) {
+	calls := 0
+	handler := newTestResponseCache().Handler(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		setLastKnownGoodHeader(w, &LastKnownGood{FetchedAt: time.Now().Add(-time.Hour), AgeSeconds: 3600})
+		writeJSON(w, map[string]string{"tier": "CHALLENGER"}, newTestLogger(), r)
+	})
+
+	for i := range 2 {
+		rec := httptest.NewRecorder()
+		handler(rec, httptest.NewRequest(http.MethodGet, "/challenger", nil))
+		if got := rec.Header().Get("X-Response-Cache"); got != "MISS" {
+			t.Errorf("request %d: X-Response-Cache = %q, expected MISS", i, got)
+		}
+		if rec.Header().Get("Cache-Control") != "no-store" || rec.Header().Get("X-Last-Known-Good-Age") != "3600" {
+			t.Errorf("request %d: headers = %v", i, rec.Header())
+		}
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, expected the response not to be cached", calls)
+	}
+}
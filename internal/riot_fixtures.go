@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	RiotModeLive     = "live"
+	RiotModeFixtures = "fixtures"
+
+	// fixtureBaseURL stands in for RIOT_BASE_URL in fixture mode; the host is
+	// never contacted.
+	fixtureBaseURL = "https://fixtures.invalid"
+)
+
+// fixtureTransport answers Riot API requests from JSON files under dir
+// instead of the network. A request for /tft/league/v1/entries/GOLD/I?page=2
+// is served from tft/league/v1/entries/GOLD/I_page=2.json when present and
+// from tft/league/v1/entries/GOLD/I.json otherwise; the host is ignored, so
+// platform and regional endpoints share one tree. Missing fixtures answer 404
+// like Riot does for unknown players.
+type fixtureTransport struct {
+	dir string
+}
+
+func newFixtureTransport(dir string) *fixtureTransport {
+	return &fixtureTransport{dir: dir}
+}
+
+func (ft *fixtureTransport) candidates(r *http.Request) []string {
+	// Cleaning against the root keeps .. segments inside dir.
+	base := filepath.Join(ft.dir, filepath.FromSlash(path.Clean("/"+r.URL.Path)))
+	if query := r.URL.Query(); len(query) > 0 {
+		withQuery := base + "_" + strings.ReplaceAll(query.Encode(), "&", "_") + ".json"
+		return []string{withQuery, base + ".json"}
+	}
+	return []string{base + ".json"}
+}
+
+func (ft *fixtureTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	for _, candidate := range ft.candidates(r) {
+		body, err := os.ReadFile(candidate)
+		if err == nil {
+			return fixtureResponse(r, http.StatusOK, body), nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	body := fmt.Sprintf(`{"status":{"message":"Data not found - no fixture for %s","status_code":404}}`, r.URL.Path)
+	return fixtureResponse(r, http.StatusNotFound, []byte(body)), nil
+}
+
+func fixtureResponse(r *http.Request, status int, body []byte) *http.Response {
+	header := make(http.Header)
+	header.Set("Content-Type", "application/json;charset=utf-8")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       r,
+	}
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newFixtureClient(t *testing.T, dir string) *RiotAPIClient {
+	t.Helper()
+	return NewRiotAPIClient(&Config{
+		RiotMode:        RiotModeFixtures,
+		RiotFixturesDir: dir,
+		RiotRegion:      "BR1",
+		RiotBaseURL:     fixtureBaseURL,
+		RiotHTTPTimeout: time.Second,
+	}, nil, newTestLogger(), nil)
+}
+
+func writeFixture(t *testing.T, dir, name, body string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFixtureTransport(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "tft/match/v1/matches/by-puuid/p1/ids.json", `["BR1_2", "BR1_1"]`)
+	writeFixture(t, dir, "tft/match/v1/matches/by-puuid/p1/ids_count=1.json", `["BR1_2"]`)
+	writeFixture(t, dir, "secret.json", `{}`)
+	client := newFixtureClient(t, dir)
+
+	ids, err := client.GetRecentMatchIDs(t.Context(), "p1", 20)
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("GetRecentMatchIDs(count=20) = %v, %v, expected the fallback fixture", ids, err)
+	}
+
+	ids, err = client.GetRecentMatchIDs(t.Context(), "p1", 1)
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("GetRecentMatchIDs(count=1) = %v, %v, expected the query fixture", ids, err)
+	}
+
+	_, err = client.GetRecentMatchIDs(t.Context(), "p2", 20)
+	var riotErr *RiotAPIError
+	if !errors.As(err, &riotErr) || riotErr.StatusCode != http.StatusNotFound {
+		t.Fatalf("missing fixture error = %v, expected a Riot 404", err)
+	}
+
+	transport := newFixtureTransport(filepath.Join(dir, "tft"))
+	req, _ := http.NewRequest(http.MethodGet, fixtureBaseURL+"/../secret", nil)
+	for _, candidate := range transport.candidates(req) {
+		if filepath.Dir(candidate) != filepath.Join(dir, "tft") {
+			t.Errorf("candidate %s escapes the fixtures dir", candidate)
+		}
+	}
+}
+
+func TestSampleFixtures(t *testing.T) {
+	client := newFixtureClient(t, filepath.Join("..", "fixtures", "riot"))
+	puuid := "fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixtureP"
+
+	summoner, err := client.FetchSummonerByPUUID(t.Context(), puuid)
+	if err != nil || summoner.PUUID != puuid {
+		t.Fatalf("FetchSummonerByPUUID() = %+v, %v", summoner, err)
+	}
+
+	account, err := client.FetchAccountByPUUID(t.Context(), puuid)
+	if err != nil || account.GameName != "Fixture" {
+		t.Fatalf("FetchAccountByPUUID() = %+v, %v", account, err)
+	}
+
+	ids, err := client.GetRecentMatchIDs(t.Context(), puuid, 20)
+	if err != nil || len(ids) == 0 {
+		t.Fatalf("GetRecentMatchIDs() = %v, %v", ids, err)
+	}
+}
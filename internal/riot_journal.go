@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type RiotRequestRecord struct {
+	Method               string
+	URL                  string
+	StatusCode           int
+	Latency              time.Duration
+	AppRateLimit         string
+	AppRateLimitCount    string
+	MethodRateLimit      string
+	MethodRateLimitCount string
+	RetryAfter           string
+	RequestedAt          time.Time
+}
+
+type RiotMethodUsage struct {
+	Method               string  `json:"method"`
+	Requests             int64   `json:"requests"`
+	Errors               int64   `json:"errors"`
+	RateLimited          int64   `json:"rateLimited"`
+	AvgLatencyMs         float64 `json:"avgLatencyMs"`
+	MaxLatencyMs         int64   `json:"maxLatencyMs"`
+	LastMethodRateLimit  string  `json:"lastMethodRateLimit,omitempty"`
+	LastMethodLimitCount string  `json:"lastMethodRateLimitCount,omitempty"`
+}
+
+type RiotJournal struct {
+	db      *DatabaseManager
+	logger  *Logger
+	records chan RiotRequestRecord
+}
+
+var riotMethodPrefixes = []struct {
+	prefix string
+	method string
+}{
+	{"/tft/summoner/v1/summoners/by-puuid/", "tft-summoner-v1.by-puuid"},
+	{"/riot/account/v1/accounts/by-puuid/", "account-v1.by-puuid"},
+	{"/riot/account/v1/accounts/by-riot-id/", "account-v1.by-riot-id"},
+	{"/tft/match/v1/matches/by-puuid/", "tft-match-v1.by-puuid"},
+	{"/tft/match/v1/matches/", "tft-match-v1.match"},
+	{"/tft/league/v1/entries/", "tft-league-v1.entries"},
+	{"/tft/league/v1/by-puuid/", "tft-league-v1.by-puuid"},
+	{"/tft/league/v1/challenger", "tft-league-v1.challenger"},
+	{"/tft/league/v1/grandmaster", "tft-league-v1.grandmaster"},
+	{"/tft/league/v1/master", "tft-league-v1.master"},
+}
+
+func NewRiotJournal(db *DatabaseManager, logger *Logger, bufferSize int) *RiotJournal {
+	journal := &RiotJournal{
+		db:      db,
+		logger:  logger,
+		records: make(chan RiotRequestRecord, bufferSize),
+	}
+
+	go journal.run()
+	return journal
+}
+
+func riotMethodFromURL(rawURL string) string {
+	path := rawURL
+	if idx := strings.Index(path, "://"); idx >= 0 {
+		path = path[idx+3:]
+		if slash := strings.Index(path, "/"); slash >= 0 {
+			path = path[slash:]
+		}
+	}
+	if idx := strings.Index(path, "?"); idx >= 0 {
+		path = path[:idx]
+	}
+
+	for _, candidate := range riotMethodPrefixes {
+		if strings.HasPrefix(path, candidate.prefix) {
+			return candidate.method
+		}
+	}
+	return path
+}
+
+func (j *RiotJournal) Record(url string, resp *http.Response, latency time.Duration) {
+	if j == nil {
+		return
+	}
+
+	record := RiotRequestRecord{
+		Method:      riotMethodFromURL(url),
+		URL:         url,
+		Latency:     latency,
+		RequestedAt: time.Now().UTC(),
+	}
+	if resp != nil {
+		record.StatusCode = resp.StatusCode
+		record.AppRateLimit = resp.Header.Get("X-App-Rate-Limit")
+		record.AppRateLimitCount = resp.Header.Get("X-App-Rate-Limit-Count")
+		record.MethodRateLimit = resp.Header.Get("X-Method-Rate-Limit")
+		record.MethodRateLimitCount = resp.Header.Get("X-Method-Rate-Limit-Count")
+		record.RetryAfter = resp.Header.Get("Retry-After")
+	}
+
+	select {
+	case j.records <- record:
+	default:
+		j.logger.Warn("riot_journal_buffer_full").
+			Component("riot_journal").
+			Operation("record").
+			Meta("method", record.Method).
+			Log()
+	}
+}
+
+func (j *RiotJournal) run() {
+	cleanup := time.NewTicker(time.Hour)
+	defer cleanup.Stop()
+
+	for {
+		select {
+		case record := <-j.records:
+			j.write(record)
+		case <-cleanup.C:
+			j.prune()
+		}
+	}
+}
+
+func (j *RiotJournal) write(record RiotRequestRecord) {
+	if j.db == nil || !j.db.Enabled {
+		return
+	}
+
+	query := `
+		INSERT INTO riot_request_journal (
+			method, url, status_code, latency_ms,
+			app_rate_limit, app_rate_limit_count,
+			method_rate_limit, method_rate_limit_count,
+			retry_after, requested_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := j.db.DB.ExecContext(context.Background(), query,
+		record.Method,
+		record.URL,
+		record.StatusCode,
+		record.Latency.Milliseconds(),
+		record.AppRateLimit,
+		record.AppRateLimitCount,
+		record.MethodRateLimit,
+		record.MethodRateLimitCount,
+		record.RetryAfter,
+		record.RequestedAt,
+	)
+	if err != nil {
+		j.logger.Error("riot_journal_write_failed").
+			Component("riot_journal").
+			Operation("write").
+			Err(err).
+			Meta("method", record.Method).
+			Log()
+	}
+}
+
+func (j *RiotJournal) prune() {
+	if j.db == nil || !j.db.Enabled {
+		return
+	}
+
+	result, err := j.db.DB.ExecContext(context.Background(),
+		`DELETE FROM riot_request_journal WHERE requested_at < NOW() - INTERVAL '7 days'`)
+	if err != nil {
+		j.logger.Error("riot_journal_prune_failed").
+			Component("riot_journal").
+			Operation("prune").
+			Err(err).
+			Log()
+		return
+	}
+
+	deleted, _ := result.RowsAffected()
+	j.logger.Info("riot_journal_pruned").
+		Component("riot_journal").
+		Operation("prune").
+		Meta("deleted", deleted).
+		Log()
+}
+
+func (dm *DatabaseManager) GetRiotUsage(ctx context.Context, window TimeWindow) ([]RiotMethodUsage, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_riot_usage")
+	defer span.End()
+
+	query := `
+		SELECT method,
+			COUNT(*),
+			COUNT(*) FILTER (WHERE status_code >= 400 OR status_code = 0),
+			COUNT(*) FILTER (WHERE status_code = 429),
+			COALESCE(AVG(latency_ms), 0),
+			COALESCE(MAX(latency_ms), 0),
+			COALESCE((ARRAY_AGG(method_rate_limit ORDER BY requested_at DESC))[1], ''),
+			COALESCE((ARRAY_AGG(method_rate_limit_count ORDER BY requested_at DESC))[1], '')
+		FROM riot_request_journal
+		WHERE requested_at >= $1 AND requested_at < $2
+		GROUP BY method
+		ORDER BY COUNT(*) DESC
+	`
+
+	rows, err := dm.queryAnalytics(ctx, query, window.From, window.To)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := []RiotMethodUsage{}
+	for rows.Next() {
+		var u RiotMethodUsage
+		if err := rows.Scan(
+			&u.Method,
+			&u.Requests,
+			&u.Errors,
+			&u.RateLimited,
+			&u.AvgLatencyMs,
+			&u.MaxLatencyMs,
+			&u.LastMethodRateLimit,
+			&u.LastMethodLimitCount,
+		); err != nil {
+			return nil, err
+		}
+		usage = append(usage, u)
+	}
+
+	return usage, rows.Err()
+}
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
-	"net/url"
 	"strconv"
 	"strings"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -27,8 +29,21 @@ type RiotAPIClient struct {
 	cache      *CacheManager
 	region     string
 	natsClient *NATSClient
+	database   *DatabaseManager
+	journal    *RiotJournal
 	logger     *Logger
-	metrics    *MetricsCollector
+
+	negativeLookupTTL   reloadableDuration
+	lastKnownGoodMaxAge reloadableDuration
+	metrics             *MetricsCollector
+
+	snapshotInterval  time.Duration
+	snapshotRetention time.Duration
+
+	crossShardTimeout time.Duration
+	shardProbeRegions []string
+
+	profileEnrichLimit int
 }
 
 func NewRiotAPIClient(cfg *Config, cache *CacheManager, logger *Logger, metrics *MetricsCollector) *RiotAPIClient {
@@ -40,48 +55,116 @@ func NewRiotAPIClient(cfg *Config, cache *CacheManager, logger *Logger, metrics
 		cache:      cache,
 		logger:     logger,
 		metrics:    metrics,
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+
+		negativeLookupTTL:   newReloadableDuration(cfg.AccountNegativeCacheTTL),
+		lastKnownGoodMaxAge: newReloadableDuration(cfg.LeagueLastKnownGoodMaxAge),
+
+		snapshotInterval:  cfg.LeagueSnapshotInterval,
+		snapshotRetention: cfg.LeagueSnapshotRetention,
+
+		crossShardTimeout: cfg.CrossShardTimeout,
+		shardProbeRegions: parseShardProbeRegions(cfg.CrossShardProbeRegions, cfg.RiotRegion),
+
+		profileEnrichLimit: cfg.ProfileEnrichLimit,
+
+		client: newRiotHTTPClient(cfg),
 	}
 }
 
+// accountAPIURLs maps each platform region to its regional routing host.
+var accountAPIURLs = map[string]string{
+	"BR1":  AmericasAPIURL,
+	"LA1":  AmericasAPIURL,
+	"LA2":  AmericasAPIURL,
+	"NA1":  AmericasAPIURL,
+	"EUW1": EuropeAPIURL,
+	"EUN1": EuropeAPIURL,
+	"TR1":  EuropeAPIURL,
+	"RU":   EuropeAPIURL,
+	"JP1":  AsiaAPIURL,
+	"KR":   AsiaAPIURL,
+	"OC1":  SeaAPIURL,
+}
+
 func getAccountAPIURL(region string) string {
-	regionMap := map[string]string{
-		"BR1":  AmericasAPIURL,
-		"LA1":  AmericasAPIURL,
-		"LA2":  AmericasAPIURL,
-		"NA1":  AmericasAPIURL,
-		"EUW1": EuropeAPIURL,
-		"EUN1": EuropeAPIURL,
-		"TR1":  EuropeAPIURL,
-		"RU":   EuropeAPIURL,
-		"JP1":  AsiaAPIURL,
-		"KR":   AsiaAPIURL,
-		"OC1":  SeaAPIURL,
-	}
-
-	if url, exists := regionMap[region]; exists {
+	if url, exists := accountAPIURLs[region]; exists {
 		return url
 	}
 	return AmericasAPIURL
 }
 
+func getPlatformAPIURL(region string) string {
+	return "https://" + strings.ToLower(region) + ".api.riotgames.com"
+}
+
+// ForRegion returns a client for another platform region sharing this
+// client's HTTP pool, cache, database and NATS connection. RIOT_BASE_URL only
+// applies to the home region; other regions use Riot's platform host.
+func (c *RiotAPIClient) ForRegion(region string) *RiotAPIClient {
+	if region == c.region {
+		return c
+	}
+
+	clone := *c
+	clone.region = region
+	clone.baseURL = getPlatformAPIURL(region)
+	clone.accountURL = getAccountAPIURL(region)
+	return &clone
+}
+
 func (c *RiotAPIClient) SetNATSClient(natsClient *NATSClient) {
 	c.natsClient = natsClient
 }
 
-func (c *RiotAPIClient) doRequest(url string) ([]byte, error) {
+func (c *RiotAPIClient) SetDatabase(db *DatabaseManager) {
+	c.database = db
+}
+
+func (c *RiotAPIClient) SetJournal(journal *RiotJournal) {
+	c.journal = journal
+}
+
+func (c *RiotAPIClient) ApplyConfig(cfg *Config) {
+	c.negativeLookupTTL.Store(cfg.AccountNegativeCacheTTL)
+	c.lastKnownGoodMaxAge.Store(cfg.LeagueLastKnownGoodMaxAge)
+}
+
+func (c *RiotAPIClient) recordCrawlPage(ctx context.Context, tier, division string, page, entriesCount int, hasMore bool) {
+	if c.database == nil {
+		return
+	}
+
+	if err := c.database.RecordCrawlPage(ctx, c.region, tier, division, page, entriesCount, hasMore); err != nil {
+		c.logger.Error("crawl_page_record_failed").
+			Component("riot_api").
+			Operation("record_crawl").
+			Game("", c.region, tier).
+			Meta("division", division).
+			Meta("page", page).
+			Err(err).
+			Log()
+	}
+}
+
+func (c *RiotAPIClient) doRequest(ctx context.Context, url string) ([]byte, error) {
 	start := time.Now()
 
-	req, err := http.NewRequest("GET", url, nil)
+	ctx, span := tracer().Start(ctx, "riot_api.request", trace.WithSpanKind(trace.SpanKindClient))
+	defer span.End()
+	span.SetAttributes(attribute.String("http.method", "GET"), attribute.String("http.url", url))
+
+	req, err := http.NewRequestWithContext(withConnectionTrace(ctx, "riot_api", c.metrics), "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
+	// The span stays local: traceparent and baggage would only leak internal
+	// trace IDs and baggage to Riot, which does not join our traces.
 	req.Header.Set("X-Riot-Token", c.apiKey)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
+		c.journal.Record(url, nil, time.Since(start))
+		recordSpanError(span, err)
 		c.logger.Error("riot_api_request_failed").
 			Component("riot_api").
 			Operation("http_request").
@@ -99,6 +182,8 @@ func (c *RiotAPIClient) doRequest(url string) ([]byte, error) {
 	}
 
 	duration := time.Since(start)
+	c.journal.Record(url, resp, duration)
+
 	c.logger.Debug("riot_api_request_completed").
 		Component("riot_api").
 		Operation("http_request").
@@ -110,50 +195,124 @@ func (c *RiotAPIClient) doRequest(url string) ([]byte, error) {
 		c.metrics.RecordRequest("riot_api", duration, resp.StatusCode)
 	}
 
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("riot API error: %s - %s", resp.Status, string(body))
+		err := &RiotAPIError{StatusCode: resp.StatusCode, Status: resp.Status, URL: url, Body: string(body)}
+		if seconds, parseErr := strconv.Atoi(resp.Header.Get("Retry-After")); parseErr == nil && seconds > 0 {
+			err.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		recordSpanError(span, err)
+		return nil, err
 	}
 
 	return body, nil
 }
 
-func (c *RiotAPIClient) GetSummonerByPUUID(puuid string) (map[string]interface{}, error) {
-	ctx := context.Background()
+type RiotAPIError struct {
+	StatusCode int
+	Status     string
+	URL        string
+	Body       string
+	// RetryAfter is Riot's Retry-After on a 429, zero when it sent none.
+	RetryAfter time.Duration
+}
+
+func (e *RiotAPIError) Error() string {
+	return fmt.Sprintf("riot API error: %s - %s", e.Status, e.Body)
+}
+
+func (c *RiotAPIClient) GetSummonerByPUUID(ctx context.Context, puuid string) (*Summoner, error) {
 	cacheKey := c.cache.Key("summoner", c.region, puuid)
 
-	var cached map[string]interface{}
-	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
+	var cached Summoner
+	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil && cached.PUUID != "" {
 		if c.metrics != nil {
 			c.metrics.RecordCacheHit(cacheKey)
 		}
-		return cached, nil
+		return &cached, nil
 	}
 
 	if c.metrics != nil {
 		c.metrics.RecordCacheMiss(cacheKey)
 	}
 
+	result, err := c.FetchSummonerByPUUID(ctx, puuid)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.Set(ctx, cacheKey, result, time.Hour)
+	c.recordProfile(ctx, result)
+	return result, nil
+}
+
+// recordProfile runs on cache misses only, so icon and level changes are
+// noticed at most once per summoner cache TTL.
+func (c *RiotAPIClient) recordProfile(ctx context.Context, summoner *Summoner) {
+	if c.database == nil || c.database.ReadOnly {
+		return
+	}
+
+	event, err := c.database.RecordSummonerProfile(ctx, c.region, summoner, c.natsClient != nil)
+	if err != nil {
+		c.logger.Error("summoner_profile_record_failed").
+			Component("riot_api").
+			Operation("record_profile").
+			Game(summoner.PUUID, c.region, "").
+			Err(err).
+			Log()
+		return
+	}
+	if event != nil {
+		c.logger.Info("summoner_profile_changed").
+			Component("riot_api").
+			Operation("record_profile").
+			Game(summoner.PUUID, c.region, "").
+			Meta("leveled_up", event.LeveledUp).
+			Meta("icon_changed", event.IconChanged).
+			Meta("summoner_level", event.Current.SummonerLevel).
+			Log()
+	}
+}
+
+func (c *RiotAPIClient) ProfileHistory(ctx context.Context, puuid string, limit int) ([]ProfileSnapshot, error) {
+	if c.database == nil || !c.database.Enabled {
+		return nil, NewAPIError("Database unavailable", http.StatusServiceUnavailable)
+	}
+	history, err := c.database.GetSummonerProfileHistory(ctx, puuid, limit)
+	if err != nil {
+		return nil, NewAPIError("Failed to load profile history", http.StatusInternalServerError).WithCause(err)
+	}
+	return history, nil
+}
+
+func (c *RiotAPIClient) FetchSummonerByPUUID(ctx context.Context, puuid string) (*Summoner, error) {
 	url := fmt.Sprintf("%s/tft/summoner/v1/summoners/by-puuid/%s", c.baseURL, puuid)
-	data, err := c.doRequest(url)
+	data, err := c.doRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
-	var result map[string]interface{}
+	var result Summoner
 	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid summoner payload: %w", err)
+	}
+	if err := result.Validate(puuid); err != nil {
 		return nil, err
 	}
-
-	c.cache.Set(ctx, cacheKey, result, time.Hour)
-	return result, nil
+	return &result, nil
 }
 
-func (c *RiotAPIClient) GetAccountByPUUID(puuid string) (*AccountData, error) {
-	ctx := context.Background()
+func (c *RiotAPIClient) GetAccountByPUUID(ctx context.Context, puuid string) (*AccountData, error) {
 	cacheKey := c.cache.Key("account_puuid", c.region, puuid)
 
 	var cached AccountData
-	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
+	err := c.cache.Get(ctx, cacheKey, &cached)
+	if err != nil {
+		err = c.cache.Restore(ctx, cacheKey, &cached, 6*time.Hour)
+	}
+	if err == nil {
 		if c.metrics != nil {
 			c.metrics.RecordCacheHit(cacheKey)
 		}
@@ -164,8 +323,19 @@ func (c *RiotAPIClient) GetAccountByPUUID(puuid string) (*AccountData, error) {
 		c.metrics.RecordCacheMiss(cacheKey)
 	}
 
+	result, err := c.FetchAccountByPUUID(ctx, puuid)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache.Set(ctx, cacheKey, result, 6*time.Hour)
+	c.cache.Persist(ctx, cacheKey, result)
+	return result, nil
+}
+
+func (c *RiotAPIClient) FetchAccountByPUUID(ctx context.Context, puuid string) (*AccountData, error) {
 	url := fmt.Sprintf("%s/riot/account/v1/accounts/by-puuid/%s", c.accountURL, puuid)
-	data, err := c.doRequest(url)
+	data, err := c.doRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -174,178 +344,268 @@ func (c *RiotAPIClient) GetAccountByPUUID(puuid string) (*AccountData, error) {
 	if err := json.Unmarshal(data, &result); err != nil {
 		return nil, err
 	}
-
-	c.cache.Set(ctx, cacheKey, result, 6*time.Hour)
 	return &result, nil
 }
 
-func (c *RiotAPIClient) GetAccountByGameName(gameName, tagLine string) (*AccountData, error) {
-	ctx := context.Background()
+func (c *RiotAPIClient) GetAccountByGameName(ctx context.Context, gameName, tagLine string) (*AccountData, error) {
+	id, err := NewRiotID(gameName, tagLine)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetAccountByRiotID(ctx, id, false)
+}
 
-	cleanGameName := strings.TrimSpace(gameName)
-	cleanTagLine := strings.TrimSpace(tagLine)
+func (c *RiotAPIClient) GetAccountByRiotID(ctx context.Context, id RiotID, exact bool) (*AccountData, error) {
+	normalizedKey := id.Key()
+	cacheKey := c.cache.Key("account_name", c.region, normalizedKey)
+	missKey := c.cache.Key("account_name_miss", c.region, normalizedKey)
+	apiURL := c.accountURL + "/riot/account/v1/accounts/by-riot-id/" + id.Path()
+
+	if !exact {
+		if account, ok := c.lookupKnownRiotID(ctx, cacheKey, missKey, normalizedKey); ok {
+			if account == nil {
+				// Callers tell a missing account apart with isNotFound, so a cached
+				// miss must look like the 404 it stands for.
+				return nil, &RiotAPIError{
+					StatusCode: http.StatusNotFound,
+					Status:     "404 Not Found",
+					URL:        apiURL,
+					Body:       "negative lookup cached for " + normalizedKey,
+				}
+			}
+			return account, nil
+		}
+	}
 
-	if cleanGameName == "" {
-		return nil, fmt.Errorf("gameName cannot be empty")
+	data, err := c.doRequest(ctx, apiURL)
+	if err != nil {
+		if isNotFound(err) {
+			c.cache.Set(ctx, missKey, true, c.negativeLookupTTL.Load())
+		}
+		return nil, err
 	}
-	if cleanTagLine == "" {
-		cleanTagLine = "BR1"
+
+	var result AccountData
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
 	}
 
-	cacheKey := c.cache.Key("account_name", c.region, cleanGameName, cleanTagLine)
+	if result.PUUID == "" {
+		return nil, fmt.Errorf("invalid account data: empty PUUID")
+	}
+
+	c.rememberRiotID(ctx, cacheKey, missKey, normalizedKey, &result)
+	return &result, nil
+}
 
+func (c *RiotAPIClient) lookupKnownRiotID(ctx context.Context, cacheKey, missKey, normalizedKey string) (*AccountData, bool) {
 	var cached AccountData
 	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
 		if c.metrics != nil {
 			c.metrics.RecordCacheHit(cacheKey)
 		}
-		return &cached, nil
+		return &cached, true
+	}
+
+	var miss bool
+	if err := c.cache.Get(ctx, missKey, &miss); err == nil && miss {
+		if c.metrics != nil {
+			c.metrics.RecordCacheHit(missKey)
+		}
+		return nil, true
 	}
 
 	if c.metrics != nil {
 		c.metrics.RecordCacheMiss(cacheKey)
 	}
 
-	encodedGameName := strings.ReplaceAll(url.QueryEscape(cleanGameName), "+", "%20")
-	encodedTagLine := strings.ReplaceAll(url.QueryEscape(cleanTagLine), "+", "%20")
-
-	apiURL := fmt.Sprintf("%s/riot/account/v1/accounts/by-riot-id/%s/%s",
-		c.accountURL, encodedGameName, encodedTagLine)
+	if c.database == nil || !c.database.Enabled {
+		return nil, false
+	}
 
-	data, err := c.doRequest(apiURL)
+	account, err := c.database.GetRiotIDLookup(ctx, normalizedKey)
 	if err != nil {
-		return nil, err
+		return nil, false
 	}
 
-	var result AccountData
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
+	c.cache.Set(ctx, cacheKey, account, 6*time.Hour)
+	return account, true
+}
 
-	if result.PUUID == "" {
-		return nil, fmt.Errorf("invalid account data: empty PUUID")
+func (c *RiotAPIClient) rememberRiotID(ctx context.Context, cacheKey, missKey, normalizedKey string, account *AccountData) {
+	c.cache.Set(ctx, cacheKey, account, 6*time.Hour)
+	c.cache.Delete(ctx, missKey)
+
+	if c.database == nil || !c.database.Enabled {
+		return
 	}
 
-	c.cache.Set(ctx, cacheKey, result, 6*time.Hour)
-	return &result, nil
+	if err := c.database.SetRiotIDLookup(ctx, normalizedKey, account); err != nil {
+		c.logger.Error("riot_id_lookup_save_failed").
+			Component("riot_api").
+			Operation("remember_riot_id").
+			Game(account.PUUID, c.region, "").
+			Err(err).
+			Log()
+	}
 }
 
-func (c *RiotAPIClient) GetChallengerLeague() (*ChallengerLeague, error) {
-	return c.getHighTierLeague("challenger", "CHALLENGER")
+func (c *RiotAPIClient) GetChallengerLeague(ctx context.Context) (*ChallengerLeague, error) {
+	return c.getHighTierLeague(ctx, "challenger", "CHALLENGER")
 }
 
-func (c *RiotAPIClient) GetGrandmasterLeague() (*GrandmasterLeague, error) {
-	result, err := c.getHighTierLeague("grandmaster", "GRANDMASTER")
+func (c *RiotAPIClient) GetGrandmasterLeague(ctx context.Context) (*GrandmasterLeague, error) {
+	result, err := c.getHighTierLeague(ctx, "grandmaster", "GRANDMASTER")
 	if err != nil {
 		return nil, err
 	}
 	return &GrandmasterLeague{
-		LeagueID: result.LeagueID,
-		Entries:  result.Entries,
-		Tier:     result.Tier,
-		Name:     result.Name,
-		Queue:    result.Queue,
+		LeagueID:      result.LeagueID,
+		Entries:       result.Entries,
+		Tier:          result.Tier,
+		Name:          result.Name,
+		Queue:         result.Queue,
+		LastKnownGood: result.LastKnownGood,
 	}, nil
 }
 
-func (c *RiotAPIClient) GetMasterLeague() (*MasterLeague, error) {
-	result, err := c.getHighTierLeague("master", "MASTER")
+func (c *RiotAPIClient) GetMasterLeague(ctx context.Context) (*MasterLeague, error) {
+	result, err := c.getHighTierLeague(ctx, "master", "MASTER")
 	if err != nil {
 		return nil, err
 	}
 	return &MasterLeague{
-		LeagueID: result.LeagueID,
-		Entries:  result.Entries,
-		Tier:     result.Tier,
-		Name:     result.Name,
-		Queue:    result.Queue,
+		LeagueID:      result.LeagueID,
+		Entries:       result.Entries,
+		Tier:          result.Tier,
+		Name:          result.Name,
+		Queue:         result.Queue,
+		LastKnownGood: result.LastKnownGood,
 	}, nil
 }
 
-func (c *RiotAPIClient) getHighTierLeague(endpoint, tier string) (*ChallengerLeague, error) {
-	ctx := context.Background()
+func (c *RiotAPIClient) getHighTierLeague(ctx context.Context, endpoint, tier string) (*ChallengerLeague, error) {
 	cacheKey := c.cache.Key(endpoint, c.region)
 
 	var cached ChallengerLeague
-	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
+	refresh, err := c.cache.GetEarly(ctx, cacheKey, &cached)
+	if err != nil {
+		err = c.cache.Restore(ctx, cacheKey, &cached, 30*time.Minute)
+	}
+	if err == nil && !refresh {
 		if c.metrics != nil {
 			c.metrics.RecordCacheHit(cacheKey)
 		}
 		if len(cached.Entries) > 10 {
 			cached.Entries = cached.Entries[:10]
 		}
-		c.enrichEntries(cached.Entries, tier)
+		c.enrichEntries(ctx, cached.Entries, tier, 1)
 		return &cached, nil
 	}
 
 	if c.metrics != nil {
-		c.metrics.RecordCacheMiss(cacheKey)
+		if refresh {
+			c.metrics.RecordCacheEarlyRefresh(cacheKey)
+		} else {
+			c.metrics.RecordCacheMiss(cacheKey)
+		}
 	}
 
+	start := time.Now()
 	url := fmt.Sprintf("%s/tft/league/v1/%s", c.baseURL, endpoint)
-	data, err := c.doRequest(url)
+	data, err := c.doRequest(ctx, url)
+	var result ChallengerLeague
+	if err == nil {
+		err = json.Unmarshal(data, &result)
+	}
 	if err != nil {
+		// The early refresh failed but the cached league has not expired.
+		if refresh {
+			c.enrichEntries(ctx, cached.Entries, tier, 1)
+			return &cached, nil
+		}
+		if stale := c.lastKnownLeague(ctx, tier, err); stale != nil {
+			c.enrichEntries(ctx, stale.Entries, tier, 1)
+			return stale, nil
+		}
 		return nil, err
 	}
+	delta := time.Since(start)
 
-	var result ChallengerLeague
-	if err := json.Unmarshal(data, &result); err != nil {
-		return nil, err
-	}
+	c.recordCrawlPage(ctx, tier, "I", 1, len(result.Entries), false)
+	c.recordLeagueSnapshot(ctx, tier, result.Entries)
 
 	if len(result.Entries) > 10 {
 		result.Entries = result.Entries[:10]
 	}
 
-	c.enrichEntries(result.Entries, tier)
-	c.cache.Set(ctx, cacheKey, result, 30*time.Minute)
+	c.enrichEntries(ctx, result.Entries, tier, 1)
+	c.cache.SetEarly(ctx, cacheKey, result, 30*time.Minute, delta)
+	c.cache.Persist(ctx, cacheKey, result)
+	c.saveLastKnownLeague(ctx, tier, &result)
 	return &result, nil
 }
 
-func (c *RiotAPIClient) GetLeagueEntries(tier, division string, page int) (*LeagueEntriesResponse, error) {
-	ctx := context.Background()
+func (c *RiotAPIClient) GetLeagueEntries(ctx context.Context, tier, division string, page int) (*LeagueEntriesResponse, error) {
 	cacheKey := c.cache.Key("entries", c.region, tier, division, strconv.Itoa(page))
 
 	var cached LeagueEntriesResponse
-	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
+	refresh, err := c.cache.GetEarly(ctx, cacheKey, &cached)
+	if err != nil {
+		err = c.cache.Restore(ctx, cacheKey, &cached, 30*time.Minute)
+	}
+	if err == nil && !refresh {
 		if c.metrics != nil {
 			c.metrics.RecordCacheHit(cacheKey)
 		}
-		c.enrichEntries(cached.Entries, tier)
+		c.enrichEntries(ctx, cached.Entries, tier, page)
+		c.paginateEntries(ctx, &cached)
 		return &cached, nil
 	}
 
 	if c.metrics != nil {
-		c.metrics.RecordCacheMiss(cacheKey)
+		if refresh {
+			c.metrics.RecordCacheEarlyRefresh(cacheKey)
+		} else {
+			c.metrics.RecordCacheMiss(cacheKey)
+		}
 	}
 
+	start := time.Now()
 	url := fmt.Sprintf("%s/tft/league/v1/entries/%s/%s?page=%d", c.baseURL, tier, division, page)
-	data, err := c.doRequest(url)
-	if err != nil {
-		return nil, err
-	}
-
+	data, err := c.doRequest(ctx, url)
 	var entries []LeagueEntry
-	if err := json.Unmarshal(data, &entries); err != nil {
+	if err == nil {
+		err = json.Unmarshal(data, &entries)
+	}
+	if err != nil {
+		if refresh {
+			c.enrichEntries(ctx, cached.Entries, tier, page)
+			c.paginateEntries(ctx, &cached)
+			return &cached, nil
+		}
 		return nil, err
 	}
+	delta := time.Since(start)
 
-	c.enrichEntries(entries, tier)
+	c.enrichEntries(ctx, entries, tier, page)
 
 	result := &LeagueEntriesResponse{
 		Entries:  entries,
 		Page:     page,
 		Tier:     tier,
 		Division: division,
-		HasMore:  len(entries) == 200,
+		HasMore:  len(entries) == leagueEntriesPageSize,
 	}
 
-	c.cache.Set(ctx, cacheKey, result, 30*time.Minute)
+	c.recordCrawlPage(ctx, tier, division, page, len(entries), result.HasMore)
+	c.cache.SetEarly(ctx, cacheKey, result, 30*time.Minute, delta)
+	c.cache.Persist(ctx, cacheKey, result)
+	c.paginateEntries(ctx, result)
 	return result, nil
 }
 
-func (c *RiotAPIClient) GetLeagueByPUUID(puuid string) ([]LeagueEntry, error) {
-	ctx := context.Background()
+func (c *RiotAPIClient) GetLeagueByPUUID(ctx context.Context, puuid string) ([]LeagueEntry, error) {
 	cacheKey := c.cache.Key("league_by_puuid", c.region, puuid)
 
 	var cached []LeagueEntry
@@ -353,6 +613,7 @@ func (c *RiotAPIClient) GetLeagueByPUUID(puuid string) ([]LeagueEntry, error) {
 		if c.metrics != nil {
 			c.metrics.RecordCacheHit(cacheKey)
 		}
+		annotateMiniSeries(cached)
 		return cached, nil
 	}
 
@@ -360,8 +621,12 @@ func (c *RiotAPIClient) GetLeagueByPUUID(puuid string) ([]LeagueEntry, error) {
 		c.metrics.RecordCacheMiss(cacheKey)
 	}
 
+	return c.RefreshLeagueByPUUID(ctx, puuid)
+}
+
+func (c *RiotAPIClient) RefreshLeagueByPUUID(ctx context.Context, puuid string) ([]LeagueEntry, error) {
 	url := fmt.Sprintf("%s/tft/league/v1/by-puuid/%s", c.baseURL, puuid)
-	data, err := c.doRequest(url)
+	data, err := c.doRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -371,41 +636,111 @@ func (c *RiotAPIClient) GetLeagueByPUUID(puuid string) ([]LeagueEntry, error) {
 		return nil, err
 	}
 
-	c.cache.Set(ctx, cacheKey, result, time.Hour)
+	annotateMiniSeries(result)
+	c.cache.Set(ctx, c.cache.Key("league_by_puuid", c.region, puuid), result, time.Hour)
+	return result, nil
+}
+
+func (c *RiotAPIClient) GetRecentMatchIDs(ctx context.Context, puuid string, count int) ([]string, error) {
+	url := fmt.Sprintf("%s/tft/match/v1/matches/by-puuid/%s/ids?count=%d", c.accountURL, puuid, count)
+	data, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
-func (c *RiotAPIClient) enrichEntries(entries []LeagueEntry, tier string) {
-	ctx := context.Background()
+func (c *RiotAPIClient) GetMatch(ctx context.Context, matchID string) (*Match, error) {
+	cacheKey := c.cache.Key("match", matchID)
+
+	var cached Match
+	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
+		if c.metrics != nil {
+			c.metrics.RecordCacheHit(cacheKey)
+		}
+		return &cached, nil
+	}
+
+	if c.metrics != nil {
+		c.metrics.RecordCacheMiss(cacheKey)
+	}
 
+	url := fmt.Sprintf("%s/tft/match/v1/matches/%s", c.accountURL, matchID)
+	data, err := c.doRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Match
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("invalid match payload: %w", err)
+	}
+	if result.Metadata.MatchID == "" {
+		return nil, fmt.Errorf("invalid match payload: missing match_id")
+	}
+
+	c.cache.Set(ctx, cacheKey, result, 24*time.Hour)
+	return &result, nil
+}
+
+func (c *RiotAPIClient) enrichEntries(ctx context.Context, entries []LeagueEntry, tier string, page int) {
+	priority := summonerNamePriority(tier, page)
+	annotateMiniSeries(entries)
+
+	var lookup []string
 	for i := range entries {
 		entries[i].Tier = tier
 
-		if entries[i].SummonerName != "" &&
-			entries[i].SummonerName != "Unknown" &&
-			entries[i].SummonerName != "Loading..." {
+		if entries[i].SummonerName != "" && entries[i].SummonerName != "Unknown" {
+			entries[i].NamePending = false
 			continue
 		}
-
 		if entries[i].PUUID == "" {
 			entries[i].SummonerName = "Unknown"
 			continue
 		}
+		lookup = append(lookup, entries[i].PUUID)
+	}
+	if len(lookup) == 0 {
+		return
+	}
 
-		name, err := c.cache.GetSummonerName(ctx, entries[i].PUUID)
-		if err == nil && name != "" {
+	names, _ := c.cache.GetSummonerNames(ctx, lookup)
+	var unnamed []string
+	for i := range entries {
+		if entries[i].PUUID == "" || (entries[i].SummonerName != "" && entries[i].SummonerName != "Unknown") {
+			continue
+		}
+		if name := names[entries[i].PUUID]; name != "" {
 			entries[i].SummonerName = name
+			entries[i].NamePending = false
 			continue
 		}
+		entries[i].SummonerName = ""
+		entries[i].NamePending = true
+		unnamed = append(unnamed, entries[i].PUUID)
+	}
+	if len(unnamed) == 0 || c.natsClient == nil {
+		return
+	}
 
-		if c.natsClient != nil {
-			task := SummonerNameTask{
-				PUUID:  entries[i].PUUID,
-				Region: c.region,
-			}
-			c.natsClient.PublishSummonerNameTask(task)
+	pending := c.cache.PendingSummonerNames(ctx, unnamed)
+	for _, puuid := range unnamed {
+		if pending[puuid] {
+			continue
+		}
+		task := SummonerNameTask{
+			PUUID:    puuid,
+			Region:   c.region,
+			Priority: priority,
+		}
+		if err := c.natsClient.PublishSummonerNameTask(ctx, task); err == nil {
+			c.cache.MarkSummonerNamePending(ctx, puuid)
 		}
-
-		entries[i].SummonerName = "Loading..."
 	}
 }
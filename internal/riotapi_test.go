@@ -1,6 +1,12 @@
 package internal
 
-import "testing"
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestGetAccountAPIURL(t *testing.T) {
 	tests := []struct {
@@ -135,3 +141,249 @@ func TestFindTFTLeague(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeRiotIDKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		gameName string
+		tagLine  string
+		expected string
+	}{
+		{
+			name:     "already lowercase",
+			gameName: "player",
+			tagLine:  "br1",
+			expected: "player#br1",
+		},
+		{
+			name:     "mixed casing collapses",
+			gameName: "PlAyEr",
+			tagLine:  "Br1",
+			expected: "player#br1",
+		},
+		{
+			name:     "spaces preserved",
+			gameName: "Big Player",
+			tagLine:  "NA1",
+			expected: "big player#na1",
+		},
+		{
+			name:     "decomposed accents compose",
+			gameName: "Jose\u0301",
+			tagLine:  "BR1",
+			expected: "jos\u00e9#br1",
+		},
+		{
+			name:     "zero width and control characters stripped",
+			gameName: "\u200BPla\u200Dyer\u0007",
+			tagLine:  "\uFEFFBR1",
+			expected: "player#br1",
+		},
+		{
+			name:     "internal whitespace collapses",
+			gameName: "  Big \t  Player ",
+			tagLine:  " NA1 ",
+			expected: "big player#na1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := normalizeRiotIDKey(tt.gameName, tt.tagLine)
+			if result != tt.expected {
+				t.Errorf("normalizeRiotIDKey() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRiotMethodFromURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected string
+	}{
+		{
+			name:     "summoner by puuid",
+			url:      "https://br1.api.riotgames.com/tft/summoner/v1/summoners/by-puuid/abc",
+			expected: "tft-summoner-v1.by-puuid",
+		},
+		{
+			name:     "account by riot id",
+			url:      AmericasAPIURL + "/riot/account/v1/accounts/by-riot-id/Player/BR1",
+			expected: "account-v1.by-riot-id",
+		},
+		{
+			name:     "entries with query string",
+			url:      "https://br1.api.riotgames.com/tft/league/v1/entries/GOLD/I?page=2",
+			expected: "tft-league-v1.entries",
+		},
+		{
+			name:     "challenger",
+			url:      "https://br1.api.riotgames.com/tft/league/v1/challenger",
+			expected: "tft-league-v1.challenger",
+		},
+		{
+			name:     "match by id",
+			url:      AmericasAPIURL + "/tft/match/v1/matches/BR1_123456",
+			expected: "tft-match-v1.match",
+		},
+		{
+			name:     "unknown path falls back to path",
+			url:      "https://br1.api.riotgames.com/tft/status/v1/platform-data",
+			expected: "/tft/status/v1/platform-data",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := riotMethodFromURL(tt.url)
+			if result != tt.expected {
+				t.Errorf("riotMethodFromURL() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewRiotID(t *testing.T) {
+	tests := []struct {
+		name     string
+		gameName string
+		tagLine  string
+		expected RiotID
+		fields   []string
+	}{
+		{name: "plain", gameName: "Faker", tagLine: "KR1", expected: RiotID{GameName: "Faker", TagLine: "KR1"}},
+		{name: "default tag", gameName: "Faker", expected: RiotID{GameName: "Faker", TagLine: "BR1"}},
+		{name: "normalized", gameName: " \u200BHide  on\tbush ", tagLine: " KR1\u0007", expected: RiotID{GameName: "Hide on bush", TagLine: "KR1"}},
+		{name: "decomposed accents", gameName: "Jose\u0301", tagLine: "BR1", expected: RiotID{GameName: "Jos\u00e9", TagLine: "BR1"}},
+		{name: "16 runes of cjk", gameName: "一二三四五六七八九十一二三四五六", tagLine: "KR1", expected: RiotID{GameName: "一二三四五六七八九十一二三四五六", TagLine: "KR1"}},
+		{name: "empty game name", gameName: " \u200B ", tagLine: "BR1", fields: []string{"gameName"}},
+		{name: "long game name", gameName: "abcdefghijklmnopq", tagLine: "BR1", fields: []string{"gameName"}},
+		{name: "long tag", gameName: "Faker", tagLine: "KR1234", fields: []string{"tagLine"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := NewRiotID(tt.gameName, tt.tagLine)
+			if len(tt.fields) > 0 {
+				var verr *ValidationError
+				if !errors.As(err, &verr) {
+					t.Fatalf("NewRiotID() error = %v, expected a ValidationError", err)
+				}
+				for i, field := range verr.Fields {
+					if i >= len(tt.fields) || field.Name != tt.fields[i] {
+						t.Errorf("fields = %+v, expected %v", verr.Fields, tt.fields)
+					}
+				}
+				return
+			}
+			if err != nil || id != tt.expected {
+				t.Errorf("NewRiotID() = %+v, %v, expected %+v", id, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRiotID(t *testing.T) {
+	tests := []struct {
+		riotID   string
+		expected RiotID
+		ok       bool
+	}{
+		{riotID: "Faker#KR1", expected: RiotID{GameName: "Faker", TagLine: "KR1"}, ok: true},
+		{riotID: " Hide on  bush #KR1 ", expected: RiotID{GameName: "Hide on bush", TagLine: "KR1"}, ok: true},
+		{riotID: "Name#With#Hash", expected: RiotID{GameName: "Name#With", TagLine: "Hash"}, ok: true},
+		{riotID: "NoTag", ok: false},
+		{riotID: "Faker#", ok: false},
+		{riotID: "Faker# \u200B", ok: false},
+		{riotID: "#KR1", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.riotID, func(t *testing.T) {
+			id, err := ParseRiotID(tt.riotID)
+			if (err == nil) != tt.ok || tt.ok && id != tt.expected {
+				t.Errorf("ParseRiotID(%q) = %+v, %v, expected %+v (ok %v)", tt.riotID, id, err, tt.expected, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRiotIDPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       RiotID
+		expected string
+	}{
+		{name: "plain", id: RiotID{GameName: "Faker", TagLine: "KR1"}, expected: "Faker/KR1"},
+		{name: "space", id: RiotID{GameName: "Hide on bush", TagLine: "KR1"}, expected: "Hide%20on%20bush/KR1"},
+		{name: "plus stays literal", id: RiotID{GameName: "C++", TagLine: "BR1"}, expected: "C++/BR1"},
+		{name: "slash", id: RiotID{GameName: "AC/DC", TagLine: "BR1"}, expected: "AC%2FDC/BR1"},
+		{name: "query and fragment", id: RiotID{GameName: "Who?#Me", TagLine: "BR1"}, expected: "Who%3F%23Me/BR1"},
+		{name: "percent", id: RiotID{GameName: "100%", TagLine: "BR1"}, expected: "100%25/BR1"},
+		{name: "dot segments", id: RiotID{GameName: "..", TagLine: "."}, expected: "../."},
+		{name: "accents", id: RiotID{GameName: "José", TagLine: "BR1"}, expected: "Jos%C3%A9/BR1"},
+		{name: "hangul", id: RiotID{GameName: "페이커", TagLine: "KR1"}, expected: "%ED%8E%98%EC%9D%B4%EC%BB%A4/KR1"},
+		{name: "emoji", id: RiotID{GameName: "🐉", TagLine: "EUW"}, expected: "%F0%9F%90%89/EUW"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if path := tt.id.Path(); path != tt.expected {
+				t.Errorf("Path() = %q, expected %q", path, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRiotIDKey(t *testing.T) {
+	a, _ := NewRiotID("Hide On Bush", "kr1")
+	b, _ := NewRiotID(" hide  on bush\u200B", "KR1")
+	if a.Key() != b.Key() || a.Key() != "hide on bush#kr1" {
+		t.Errorf("Key() = %q, %q, expected both to be %q", a.Key(), b.Key(), "hide on bush#kr1")
+	}
+	if a.String() != "Hide On Bush#kr1" {
+		t.Errorf("String() = %q, expected the original casing", a.String())
+	}
+}
+
+func TestGetAccountByRiotID_EscapedNames(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{"Hide on bush", "Who?", "José", "페이커", "C++", "100%"}
+	for _, name := range names {
+		writeFixture(t, dir, "riot/account/v1/accounts/by-riot-id/"+name+"/KR1.json",
+			`{"puuid":"puuid-`+name+`","gameName":"`+name+`","tagLine":"KR1"}`)
+	}
+	client := newCachedFixtureClient(dir)
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			account, err := client.GetAccountByGameName(t.Context(), name, "KR1")
+			if err != nil || account.PUUID != "puuid-"+name {
+				t.Errorf("GetAccountByGameName(%q) = %+v, %v, expected the fixture account", name, account, err)
+			}
+		})
+	}
+
+	if _, err := client.GetAccountByGameName(t.Context(), "", "KR1"); err == nil {
+		t.Error("expected an error for an empty game name")
+	}
+}
+
+func TestGetAccountByRiotID_NegativeCacheReturnsNotFound(t *testing.T) {
+	client := newCachedFixtureClient("../fixtures/riot")
+	client.cache, _ = newPersistentCache(newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)), nil)
+	client.negativeLookupTTL = newReloadableDuration(time.Minute)
+
+	for _, source := range []string{"riot", "negative cache"} {
+		_, err := client.GetAccountByGameName(t.Context(), "Nobody", "BR1")
+		var riotErr *RiotAPIError
+		if !errors.As(err, &riotErr) || riotErr.StatusCode != http.StatusNotFound {
+			t.Fatalf("%s: error = %v, expected a 404 *RiotAPIError", source, err)
+		}
+		if source == "negative cache" && !strings.Contains(riotErr.Body, "negative lookup cached") {
+			t.Errorf("error = %v, expected the miss to be served from the negative cache", err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	defaultTagLine    = "BR1"
+	maxGameNameLength = 16
+	maxTagLineLength  = 5
+)
+
+// RiotID is a normalized gameName#tagLine. Build it with NewRiotID or
+// ParseRiotID so it is normalized and validated once, at the edge.
+type RiotID struct {
+	GameName string
+	TagLine  string
+}
+
+// NewRiotID normalizes both parts and defaults an empty tagLine to BR1. The
+// error is a *ValidationError naming the offending field.
+func NewRiotID(gameName, tagLine string) (RiotID, error) {
+	id := RiotID{GameName: normalizeRiotIDPart(gameName), TagLine: normalizeRiotIDPart(tagLine)}
+	if id.TagLine == "" {
+		id.TagLine = defaultTagLine
+	}
+	return id, id.validate()
+}
+
+// ParseRiotID reads gameName#tagLine, splitting on the last '#' since game
+// names may contain one. Unlike NewRiotID, the tagLine is required.
+func ParseRiotID(value string) (RiotID, error) {
+	i := strings.LastIndex(value, "#")
+	if i < 0 || normalizeRiotIDPart(value[i+1:]) == "" {
+		v := NewValidator(nil)
+		v.Fail("riotId", "must be gameName#tagLine")
+		return RiotID{}, v.Err()
+	}
+	return NewRiotID(value[:i], value[i+1:])
+}
+
+func (id RiotID) validate() error {
+	v := NewValidator(nil)
+	if id.GameName == "" {
+		v.Fail("gameName", "is required")
+	}
+	if len([]rune(id.GameName)) > maxGameNameLength {
+		v.Fail("gameName", fmt.Sprintf("must have at most %d characters", maxGameNameLength))
+	}
+	if len([]rune(id.TagLine)) > maxTagLineLength {
+		v.Fail("tagLine", fmt.Sprintf("must have at most %d characters", maxTagLineLength))
+	}
+	return v.Err()
+}
+
+func (id RiotID) String() string {
+	return id.GameName + "#" + id.TagLine
+}
+
+// Key is the case-insensitive form used for cache and database lookups.
+func (id RiotID) Key() string {
+	return strings.ToLower(id.GameName) + "#" + strings.ToLower(id.TagLine)
+}
+
+// Path is the by-riot-id path suffix, each part escaped as a single path
+// segment so spaces, '/', '?' and '#' in names cannot alter the URL.
+func (id RiotID) Path() string {
+	return url.PathEscape(id.GameName) + "/" + url.PathEscape(id.TagLine)
+}
+
+func normalizeRiotIDPart(value string) string {
+	var b strings.Builder
+	b.Grow(len(value))
+
+	pendingSpace := false
+	for _, r := range norm.NFC.String(value) {
+		switch {
+		case isZeroWidth(r):
+			continue
+		case unicode.IsSpace(r):
+			pendingSpace = b.Len() > 0
+		case unicode.IsControl(r):
+			continue
+		default:
+			if pendingSpace {
+				b.WriteByte(' ')
+				pendingSpace = false
+			}
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
+
+func isZeroWidth(r rune) bool {
+	switch r {
+	case '\u200B', '\u200C', '\u200D', '\u2060', '\uFEFF':
+		return true
+	}
+	return false
+}
+
+func normalizeRiotIDKey(gameName, tagLine string) string {
+	return RiotID{GameName: normalizeRiotIDPart(gameName), TagLine: normalizeRiotIDPart(tagLine)}.Key()
+}
+
+func (dm *DatabaseManager) GetRiotIDLookup(ctx context.Context, normalizedKey string) (*AccountData, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_riot_id_lookup")
+	defer span.End()
+
+	var account AccountData
+	query := `
+		SELECT puuid, game_name, tag_line
+		FROM riot_id_lookups
+		WHERE normalized_key = $1 AND updated_at > NOW() - INTERVAL '7 days'
+	`
+
+	err := dm.DB.QueryRowContext(ctx, query, normalizedKey).Scan(
+		&account.PUUID,
+		&account.GameName,
+		&account.TagLine,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+func (dm *DatabaseManager) SetRiotIDLookup(ctx context.Context, normalizedKey string, account *AccountData) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "set_riot_id_lookup")
+	defer span.End()
+
+	query := `
+		INSERT INTO riot_id_lookups (normalized_key, puuid, game_name, tag_line)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (normalized_key) DO UPDATE SET
+			puuid = $2,
+			game_name = $3,
+			tag_line = $4,
+			updated_at = CURRENT_TIMESTAMP
+	`
+
+	_, err := dm.DB.ExecContext(ctx, query, normalizedKey, account.PUUID, account.GameName, account.TagLine)
+	recordSpanError(span, err)
+	return err
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a handler. In a list, the first one runs first.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Router registers routes on a ServeMux through groups that share a path
+// prefix and a middleware chain, so the admin or stats surface can grow
+// without repeating the whole wrapper chain on every route.
+type Router struct {
+	table  *routeTable
+	prefix string
+	chain  []Middleware
+}
+
+type routeTable struct {
+	mux    *http.ServeMux
+	logger *Logger
+
+	mu       sync.RWMutex
+	timeouts map[string]time.Duration
+}
+
+func NewRouter(logger *Logger) *Router {
+	return &Router{table: &routeTable{
+		mux:      http.NewServeMux(),
+		logger:   logger,
+		timeouts: make(map[string]time.Duration),
+	}}
+}
+
+// Group returns a router for routes under prefix that run middleware after
+// this router's own.
+func (rt *Router) Group(prefix string, middleware ...Middleware) *Router {
+	return &Router{
+		table:  rt.table,
+		prefix: rt.prefix + prefix,
+		chain:  append(slices.Clip(rt.chain), middleware...),
+	}
+}
+
+type routeConfig struct {
+	methods    []string
+	middleware []Middleware
+	timeout    time.Duration
+	hasTimeout bool
+}
+
+type RouteOption func(*routeConfig)
+
+// Methods restricts a route to the given methods; others get a 405 with
+// Allow. HEAD follows GET and CORS preflights always pass.
+func Methods(methods ...string) RouteOption {
+	return func(rc *routeConfig) {
+		rc.methods = append(rc.methods, methods...)
+	}
+}
+
+// Use adds middleware that runs after the group's, for this route only.
+func Use(middleware ...Middleware) RouteOption {
+	return func(rc *routeConfig) {
+		rc.middleware = append(rc.middleware, middleware...)
+	}
+}
+
+// Timeout sets the route's own request timeout, which REQUEST_TIMEOUTS can
+// still override; zero disables the deadline.
+func Timeout(timeout time.Duration) RouteOption {
+	return func(rc *routeConfig) {
+		rc.timeout, rc.hasTimeout = timeout, true
+	}
+}
+
+func (rt *Router) Handle(pattern string, handler http.HandlerFunc, options ...RouteOption) {
+	var config routeConfig
+	for _, option := range options {
+		option(&config)
+	}
+
+	pattern = rt.prefix + pattern
+	for i := len(config.middleware) - 1; i >= 0; i-- {
+		handler = config.middleware[i](handler)
+	}
+	if len(config.methods) > 0 {
+		handler = allowMethods(config.methods, handler, rt.table.logger)
+	}
+	for i := len(rt.chain) - 1; i >= 0; i-- {
+		handler = rt.chain[i](handler)
+	}
+
+	if config.hasTimeout {
+		rt.table.mu.Lock()
+		rt.table.timeouts[pattern] = config.timeout
+		rt.table.mu.Unlock()
+	}
+	rt.table.mux.HandleFunc(pattern, handler)
+}
+
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.table.mux.ServeHTTP(w, r)
+}
+
+// Handler reports the handler and pattern a request would be routed to.
+func (rt *Router) Handler(r *http.Request) (http.Handler, string) {
+	return rt.table.mux.Handler(r)
+}
+
+// RouteTimeout returns the timeout declared with the Timeout option.
+func (rt *Router) RouteTimeout(pattern string) (time.Duration, bool) {
+	rt.table.mu.RLock()
+	defer rt.table.mu.RUnlock()
+	timeout, ok := rt.table.timeouts[pattern]
+	return timeout, ok
+}
+
+func allowMethods(methods []string, next http.HandlerFunc, logger *Logger) http.HandlerFunc {
+	allowed := append([]string(nil), methods...)
+	if slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	allow := strings.Join(append(allowed, http.MethodOptions), ", ")
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || slices.Contains(allowed, r.Method) {
+			next(w, r)
+			return
+		}
+		setCORSHeaders(w, r)
+		w.Header().Set("Allow", allow)
+		writeError(w, NewAPIError("Method not allowed", http.StatusMethodNotAllowed), logger, r)
+	}
+}
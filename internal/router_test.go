@@ -0,0 +1,129 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRouter_GroupsAndMiddlewareOrder(t *testing.T) {
+	var calls []string
+	mark := func(name string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next(w, r)
+			}
+		}
+	}
+
+	router := NewRouter(newTestLogger())
+	api := router.Group("", mark("root"))
+	admin := api.Group("/admin", mark("admin"))
+	api.Handle("/healthz", func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "healthz") })
+	admin.Handle("/ops", func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "ops") }, Use(mark("route")))
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "/healthz", expected: "root,healthz"},
+		{path: "/admin/ops", expected: "root,admin,route,ops"},
+		{path: "/ops", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls = nil
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if got := strings.Join(calls, ","); got != tt.expected {
+				t.Errorf("calls = %q, expected %q", got, tt.expected)
+			}
+			if tt.expected == "" && rec.Code != http.StatusNotFound {
+				t.Errorf("status = %d, expected 404", rec.Code)
+			}
+		})
+	}
+}
+
+func TestRouter_Methods(t *testing.T) {
+	router := NewRouter(newTestLogger())
+	router.Handle("/watchlist", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}, Methods(http.MethodGet, http.MethodPost))
+
+	tests := []struct {
+		method   string
+		expected int
+	}{
+		{method: http.MethodGet, expected: http.StatusNoContent},
+		{method: http.MethodHead, expected: http.StatusNoContent},
+		{method: http.MethodPost, expected: http.StatusNoContent},
+		{method: http.MethodOptions, expected: http.StatusNoContent},
+		{method: http.MethodDelete, expected: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(tt.method, "/watchlist", nil))
+
+			if rec.Code != tt.expected {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expected)
+			}
+			if tt.expected == http.StatusMethodNotAllowed {
+				if allow := rec.Header().Get("Allow"); allow != "GET, POST, HEAD, OPTIONS" {
+					t.Errorf("Allow = %q", allow)
+				}
+				if !strings.Contains(rec.Body.String(), "Method not allowed") {
+					t.Errorf("body = %q, expected a JSON error", rec.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRouter_RouteTimeouts(t *testing.T) {
+	router := NewRouter(newTestLogger())
+	slow := func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(100 * time.Millisecond):
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+	router.Handle("/declared", slow, Timeout(20*time.Millisecond))
+	router.Handle("/overridden", slow, Timeout(20*time.Millisecond))
+	router.Handle("/players/{puuid}", slow, Timeout(20*time.Millisecond))
+	router.Handle("/default", slow)
+
+	timeouts := NewTimeoutMiddleware(&Config{
+		RequestTimeout:  time.Minute,
+		RequestTimeouts: "/overridden=0",
+	}, newTestLogger(), nil)
+	handler := timeouts.Handler(router)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{path: "/declared", expected: http.StatusGatewayTimeout},
+		{path: "/players/abc", expected: http.StatusGatewayTimeout},
+		{path: "/overridden", expected: http.StatusNoContent},
+		{path: "/default", expected: http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.expected {
+				t.Errorf("status = %d, expected %d", rec.Code, tt.expected)
+			}
+		})
+	}
+}
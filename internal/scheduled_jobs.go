@@ -0,0 +1,206 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"time"
+)
+
+const (
+	// jobStoreTimeout bounds each JobStore call, not the task itself.
+	jobStoreTimeout = 5 * time.Second
+	// jobRetryDelay is how soon a persistent task looks again when the store
+	// is unavailable or another replica is running it.
+	jobRetryDelay = 30 * time.Second
+)
+
+// JobStore keeps persistent tasks' next runs outside the process.
+type JobStore interface {
+	// ScheduleJob stores nextRunAt for a job it has never seen and returns
+	// the next run it has stored.
+	ScheduleJob(ctx context.Context, name string, nextRunAt time.Time) (time.Time, error)
+	// LockJob takes a lock held by at most one replica. ok is false when
+	// another replica holds it; otherwise unlock must be called.
+	LockJob(ctx context.Context, name string) (unlock func(), ok bool, err error)
+	RecordJobRun(ctx context.Context, name string, ranAt, nextRunAt time.Time, runErr error) error
+}
+
+// runPersistent waits for the stored next run instead of a fresh interval,
+// so a run that fell due while no replica was up happens straight away.
+// Missed runs are coalesced into one.
+func (s *Scheduler) runPersistent(task ScheduledTask) {
+	ctx, cancel := context.WithTimeout(context.Background(), jobStoreTimeout)
+	next, err := s.store.ScheduleJob(ctx, task.Name, s.clock.Now().Add(s.nextInterval(task.Name)))
+	cancel()
+
+	wait := next.Sub(s.clock.Now())
+	switch {
+	case err != nil:
+		s.logJobStoreError("load", task.Name, err)
+		wait = s.nextInterval(task.Name)
+	case wait <= 0:
+		s.logger.Info("scheduled_task_catch_up").
+			Component("scheduler").
+			Operation("start").
+			Meta("task", task.Name).
+			Meta("missed_by", (-wait).Round(time.Second).String()).
+			Log()
+	}
+
+	timer := s.clock.NewTimer(wait)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-timer.C():
+			timer.Reset(s.runLocked(task))
+		}
+	}
+}
+
+// runLocked runs task under its lock, unless another replica already ran
+// it this cycle, and returns how long to wait for the next one.
+func (s *Scheduler) runLocked(task ScheduledTask) time.Duration {
+	ctx, cancel := context.WithTimeout(context.Background(), jobStoreTimeout)
+	defer cancel()
+
+	unlock, locked, err := s.store.LockJob(ctx, task.Name)
+	if err != nil {
+		s.logJobStoreError("lock", task.Name, err)
+		return jobRetryDelay
+	}
+	if !locked {
+		s.logger.Debug("scheduled_task_locked_elsewhere").
+			Component("scheduler").
+			Operation("lock").
+			Meta("task", task.Name).
+			Log()
+		return jobRetryDelay
+	}
+	defer unlock()
+
+	// Re-read under the lock: a replica that ran the task while this one
+	// waited has already moved the next run forward.
+	next, err := s.store.ScheduleJob(ctx, task.Name, s.clock.Now())
+	if err != nil {
+		s.logJobStoreError("load", task.Name, err)
+		return jobRetryDelay
+	}
+	if wait := next.Sub(s.clock.Now()); wait > 0 {
+		// The job itself is healthy, so this replica's health should say so.
+		s.recordRun(task.Name, s.clock.Now(), nil)
+		return wait
+	}
+
+	start := s.clock.Now()
+	runErr := s.execute(task)
+	next = s.clock.Now().Add(s.nextInterval(task.Name))
+
+	recordCtx, recordCancel := context.WithTimeout(context.Background(), jobStoreTimeout)
+	defer recordCancel()
+	if err := s.store.RecordJobRun(recordCtx, task.Name, start, next, runErr); err != nil {
+		s.logJobStoreError("record", task.Name, err)
+	}
+	return next.Sub(s.clock.Now())
+}
+
+func (s *Scheduler) logJobStoreError(operation, name string, err error) {
+	s.logger.Warn("scheduled_job_store_failed").
+		Component("scheduler").
+		Operation(operation).
+		Meta("task", name).
+		Err(err).
+		Log()
+}
+
+func (dm *DatabaseManager) ScheduleJob(ctx context.Context, name string, nextRunAt time.Time) (time.Time, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "schedule_job")
+	defer span.End()
+
+	if _, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		INSERT INTO scheduled_jobs (name, next_run_at) VALUES ($1, $2)
+		ON CONFLICT (name) DO NOTHING
+	`), name, nextRunAt.UTC()); err != nil {
+		recordSpanError(span, err)
+		return time.Time{}, err
+	}
+
+	var stored time.Time
+	if err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		SELECT next_run_at FROM scheduled_jobs WHERE name = $1
+	`), name).Scan(&stored); err != nil {
+		recordSpanError(span, err)
+		return time.Time{}, err
+	}
+	return stored, nil
+}
+
+func (dm *DatabaseManager) RecordJobRun(ctx context.Context, name string, ranAt, nextRunAt time.Time, runErr error) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "record_job_run")
+	defer span.End()
+
+	var lastError sql.NullString
+	if runErr != nil {
+		lastError = sql.NullString{String: runErr.Error(), Valid: true}
+	}
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		INSERT INTO scheduled_jobs (name, next_run_at, last_run_at, last_error, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (name) DO UPDATE SET
+			next_run_at = EXCLUDED.next_run_at,
+			last_run_at = EXCLUDED.last_run_at,
+			last_error = EXCLUDED.last_error,
+			updated_at = EXCLUDED.updated_at
+	`), name, nextRunAt.UTC(), ranAt.UTC(), lastError)
+	if err != nil {
+		recordSpanError(span, err)
+	}
+	return err
+}
+
+// LockJob takes a session advisory lock on a connection of its own, which
+// stays out of the pool until the lock is released. SQLite has a single
+// writer process, so there is nobody to lock against.
+func (dm *DatabaseManager) LockJob(ctx context.Context, name string) (func(), bool, error) {
+	if dm.Dialect == DatabaseDriverSQLite {
+		return func() {}, true, nil
+	}
+
+	conn, err := dm.DB.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := jobLockKey(name)
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, key).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), jobStoreTimeout)
+		defer cancel()
+		if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_unlock($1)`, key); err != nil {
+			// A connection still holding the lock must not go back to the
+			// pool; closing the session is what releases it.
+			conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		conn.Close()
+	}, true, nil
+}
+
+func jobLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("tft:scheduled_job:" + name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,357 @@
+package internal
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ScheduleConfig struct {
+	Task     string
+	Enabled  bool
+	Interval time.Duration
+}
+
+type ScheduledTask struct {
+	Name     string
+	Interval time.Duration
+	Run      func(ctx context.Context) error
+	// Persistent tasks keep their next run in the scheduler's JobStore, so
+	// a run missed while the service was down happens right after restart
+	// and only one replica runs each cycle.
+	Persistent bool
+}
+
+type JobHealth struct {
+	Name        string     `json:"name"`
+	Interval    string     `json:"interval"`
+	LastRunAt   *time.Time `json:"lastRunAt,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccessAt,omitempty"`
+	LastError   string     `json:"lastError,omitempty"`
+	Stale       bool       `json:"stale"`
+}
+
+type jobState struct {
+	interval    time.Duration
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastError   string
+}
+
+type Scheduler struct {
+	logger    *Logger
+	jitter    float64
+	tasks     []ScheduledTask
+	store     JobStore
+	clock     Clock
+	stop      chan struct{}
+	wg        sync.WaitGroup
+	startedAt time.Time
+
+	jobs   map[string]*jobState
+	jobsMu sync.RWMutex
+}
+
+// A job is stale once it has gone this many (jittered) intervals without a
+// successful run, so a single slow or failed run does not flip health.
+const jobStaleFactor = 2
+
+var leagueScheduleTasks = []string{"challenger", "grandmaster", "master"}
+
+func loadLeagueSchedules(env configEnv) []ScheduleConfig {
+	schedules := make([]ScheduleConfig, 0, len(leagueScheduleTasks))
+	for _, task := range leagueScheduleTasks {
+		prefix := "SCHEDULE_" + strings.ToUpper(task)
+		schedules = append(schedules, ScheduleConfig{
+			Task:     task,
+			Enabled:  env.getBool(prefix+"_ENABLED", true),
+			Interval: env.getDuration(prefix+"_INTERVAL", 30*time.Minute),
+		})
+	}
+	return schedules
+}
+
+func NewScheduler(jitter float64, clock Clock, logger *Logger) *Scheduler {
+	if jitter < 0 {
+		jitter = 0
+	}
+	if jitter > 1 {
+		jitter = 1
+	}
+
+	return &Scheduler{
+		logger: logger,
+		jitter: jitter,
+		clock:  clockOrSystem(clock),
+		stop:   make(chan struct{}),
+		jobs:   make(map[string]*jobState),
+	}
+}
+
+func (s *Scheduler) Register(task ScheduledTask) {
+	if task.Interval <= 0 {
+		s.logger.Warn("scheduled_task_skipped").
+			Component("scheduler").
+			Operation("register").
+			Meta("task", task.Name).
+			Meta("reason", "non_positive_interval").
+			Log()
+		return
+	}
+	s.tasks = append(s.tasks, task)
+
+	s.jobsMu.Lock()
+	s.jobs[task.Name] = &jobState{interval: task.Interval}
+	s.jobsMu.Unlock()
+}
+
+// SetStore makes persistent tasks keep their schedule in store. It must be
+// called before Start; without a store they run like any other task.
+func (s *Scheduler) SetStore(store JobStore) {
+	s.store = store
+}
+
+func (s *Scheduler) Start() {
+	s.jobsMu.Lock()
+	s.startedAt = s.clock.Now()
+	s.jobsMu.Unlock()
+
+	for _, task := range s.tasks {
+		s.wg.Add(1)
+		go s.run(task)
+
+		s.logger.Info("scheduled_task_started").
+			Component("scheduler").
+			Operation("start").
+			Meta("task", task.Name).
+			Meta("interval", task.Interval.String()).
+			Meta("jitter", s.jitter).
+			Log()
+	}
+}
+
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Scheduler) run(task ScheduledTask) {
+	defer s.wg.Done()
+
+	if task.Persistent && s.store != nil {
+		s.runPersistent(task)
+		return
+	}
+
+	timer := s.clock.NewTimer(s.nextInterval(task.Name))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-timer.C():
+			s.execute(task)
+			timer.Reset(s.nextInterval(task.Name))
+		}
+	}
+}
+
+func (s *Scheduler) execute(task ScheduledTask) error {
+	start := s.clock.Now()
+	err := task.Run(context.Background())
+	s.recordRun(task.Name, start, err)
+	if err != nil {
+		s.logger.Error("scheduled_task_failed").
+			Component("scheduler").
+			Operation("run").
+			Duration(s.clock.Now().Sub(start)).
+			Meta("task", task.Name).
+			Err(err).
+			Log()
+	} else {
+		s.logger.Debug("scheduled_task_completed").
+			Component("scheduler").
+			Operation("run").
+			Duration(s.clock.Now().Sub(start)).
+			Meta("task", task.Name).
+			Log()
+	}
+	return err
+}
+
+func (s *Scheduler) nextInterval(name string) time.Duration {
+	return jitteredInterval(s.interval(name), s.jitter, rand.Float64())
+}
+
+func (s *Scheduler) interval(name string) time.Duration {
+	s.jobsMu.RLock()
+	defer s.jobsMu.RUnlock()
+	return s.jobs[name].interval
+}
+
+// SetInterval changes a registered task's interval. The wait already in
+// progress is not shortened; the new interval applies from the next run.
+func (s *Scheduler) SetInterval(name string, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	s.jobsMu.Lock()
+	state, ok := s.jobs[name]
+	if !ok || state.interval == interval {
+		s.jobsMu.Unlock()
+		return
+	}
+	previous := state.interval
+	state.interval = interval
+	s.jobsMu.Unlock()
+
+	s.logger.Info("scheduled_task_interval_changed").
+		Component("scheduler").
+		Operation("set_interval").
+		Meta("task", name).
+		Meta("previous", previous.String()).
+		Meta("interval", interval.String()).
+		Log()
+}
+
+func (s *Scheduler) ApplyConfig(cfg *Config) {
+	intervals := map[string]time.Duration{
+		"watchlist_refresh":    cfg.WatchlistRefreshInterval,
+		"outbox_relay":         cfg.OutboxRelayInterval,
+		"cache_verification":   cfg.CacheVerifyInterval,
+		"cache_prune":          cfg.CachePruneInterval,
+		"summoner_cache_prune": cfg.SummonerCachePruneInterval,
+		"static_data_refresh":  cfg.StaticDataRefreshInterval,
+	}
+	regions, _ := parseRiotRegions(cfg.RiotRegions, cfg.RiotRegion)
+	for _, schedule := range cfg.LeagueSchedules {
+		for _, region := range regions {
+			intervals[leagueScheduleName(schedule.Task, region, cfg.RiotRegions != "")] = schedule.Interval
+		}
+	}
+
+	for name, interval := range intervals {
+		s.SetInterval(name, interval)
+	}
+}
+
+func (s *Scheduler) recordRun(name string, at time.Time, err error) {
+	s.jobsMu.Lock()
+	defer s.jobsMu.Unlock()
+
+	state, ok := s.jobs[name]
+	if !ok {
+		return
+	}
+	state.lastRun = at
+	if err != nil {
+		state.lastError = err.Error()
+		return
+	}
+	state.lastSuccess = at
+	state.lastError = ""
+}
+
+func (s *Scheduler) Health(now time.Time) []JobHealth {
+	if s == nil {
+		return []JobHealth{}
+	}
+
+	s.jobsMu.RLock()
+	defer s.jobsMu.RUnlock()
+
+	health := make([]JobHealth, 0, len(s.jobs))
+	for name, state := range s.jobs {
+		job := JobHealth{
+			Name:      name,
+			Interval:  state.interval.String(),
+			LastError: state.lastError,
+		}
+		if !state.lastRun.IsZero() {
+			lastRun := state.lastRun.UTC()
+			job.LastRunAt = &lastRun
+		}
+
+		since := s.startedAt
+		if !state.lastSuccess.IsZero() {
+			lastSuccess := state.lastSuccess.UTC()
+			job.LastSuccess = &lastSuccess
+			since = state.lastSuccess
+		}
+		if !since.IsZero() {
+			maxAge := time.Duration(jobStaleFactor * (1 + s.jitter) * float64(state.interval))
+			job.Stale = now.Sub(since) > maxAge
+		}
+		health = append(health, job)
+	}
+
+	sort.Slice(health, func(i, j int) bool { return health[i].Name < health[j].Name })
+	return health
+}
+
+func jitteredInterval(interval time.Duration, jitter, r float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+	offset := (r*2 - 1) * jitter * float64(interval)
+	return interval + time.Duration(offset)
+}
+
+func RegisterLeagueUpdateTasks(s *Scheduler, natsClient *NATSClient, claims *LeagueScheduleClaims, cfg *Config) {
+	// validate already rejected malformed regions.
+	refreshRegions, _ := parseLeagueRefreshRegions(cfg.LeagueRefreshRegions, cfg.RiotRegion)
+	regions, _ := parseRiotRegions(cfg.RiotRegions, cfg.RiotRegion)
+	sharded := cfg.RiotRegions != ""
+
+	for _, schedule := range cfg.LeagueSchedules {
+		if !schedule.Enabled {
+			s.logger.Info("scheduled_task_disabled").
+				Component("scheduler").
+				Operation("register").
+				Meta("task", schedule.Task).
+				Log()
+			continue
+		}
+
+		for _, region := range regions {
+			name := leagueScheduleName(schedule.Task, region, sharded)
+			task := LeagueUpdateTask{Type: schedule.Task, Region: region}
+			if len(refreshRegions) > 1 {
+				task.Regions = refreshRegions
+			}
+			s.Register(ScheduledTask{
+				Name:       name,
+				Interval:   schedule.Interval,
+				Persistent: true,
+				Run: func(ctx context.Context) error {
+					interval := s.interval(name)
+					if sharded {
+						claimed, err := claims.Claim(ctx, task.Type, task.Region, interval)
+						if err != nil {
+							return err
+						}
+						if !claimed {
+							s.logger.Debug("league_update_claimed_elsewhere").
+								Component("scheduler").
+								Operation("claim").
+								Game("", task.Region, task.Type).
+								Log()
+							return nil
+						}
+					}
+
+					// A refresh still running when the next one is published
+					// would only duplicate work, so each cycle gets one interval.
+					task := task
+					task.Deadline = s.clock.Now().Add(interval).UTC()
+					return natsClient.PublishLeagueUpdateTask(ctx, task)
+				},
+			})
+		}
+	}
+}
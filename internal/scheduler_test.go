@@ -0,0 +1,310 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJitteredInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		jitter   float64
+		r        float64
+		expected time.Duration
+	}{
+		{
+			name:     "no jitter",
+			interval: 30 * time.Minute,
+			jitter:   0,
+			r:        0.9,
+			expected: 30 * time.Minute,
+		},
+		{
+			name:     "lower bound",
+			interval: 30 * time.Minute,
+			jitter:   0.1,
+			r:        0,
+			expected: 27 * time.Minute,
+		},
+		{
+			name:     "midpoint",
+			interval: 30 * time.Minute,
+			jitter:   0.1,
+			r:        0.5,
+			expected: 30 * time.Minute,
+		},
+		{
+			name:     "upper bound",
+			interval: 30 * time.Minute,
+			jitter:   0.1,
+			r:        1,
+			expected: 33 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := jitteredInterval(tt.interval, tt.jitter, tt.r)
+			if result != tt.expected {
+				t.Errorf("jitteredInterval() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadLeagueSchedules(t *testing.T) {
+	t.Setenv("SCHEDULE_MASTER_ENABLED", "false")
+	t.Setenv("SCHEDULE_CHALLENGER_INTERVAL", "5m")
+
+	expected := map[string]ScheduleConfig{
+		"challenger":  {Task: "challenger", Enabled: true, Interval: 5 * time.Minute},
+		"grandmaster": {Task: "grandmaster", Enabled: true, Interval: 30 * time.Minute},
+		"master":      {Task: "master", Enabled: false, Interval: 30 * time.Minute},
+	}
+
+	for _, schedule := range loadLeagueSchedules(nil) {
+		if schedule != expected[schedule.Task] {
+			t.Errorf("loadLeagueSchedules() %s = %+v, expected %+v", schedule.Task, schedule, expected[schedule.Task])
+		}
+	}
+}
+
+func TestScheduler_RunsTasks(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	scheduler := NewScheduler(0, clock, logger)
+
+	runs := make(chan time.Time, 1)
+	scheduler.Register(ScheduledTask{
+		Name:     "test",
+		Interval: time.Minute,
+		Run: func(ctx context.Context) error {
+			runs <- clock.Now()
+			return nil
+		},
+	})
+	scheduler.Register(ScheduledTask{Name: "invalid", Interval: 0})
+
+	if len(scheduler.tasks) != 1 {
+		t.Errorf("registered tasks = %d, expected 1", len(scheduler.tasks))
+	}
+
+	scheduler.Start()
+	defer scheduler.Stop()
+	clock.waitForTimers(t, 1)
+
+	clock.Advance(59 * time.Second)
+	select {
+	case <-runs:
+		t.Fatal("task ran before its interval")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	for i := 1; i <= 2; i++ {
+		clock.Advance(time.Second)
+		select {
+		case ranAt := <-runs:
+			if expected := scheduler.startedAt.Add(time.Duration(i) * time.Minute); !ranAt.Equal(expected) {
+				t.Errorf("run %d at %v, expected %v", i, ranAt, expected)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("run %d did not happen", i)
+		}
+		clock.waitForTimers(t, 1)
+		clock.Advance(59 * time.Second)
+	}
+
+	logs.Expect(t, expectedEvent{Level: LogLevelWarn, Message: "scheduled_task_skipped", Component: "scheduler", Meta: map[string]interface{}{"task": "invalid"}})
+	logs.Expect(t, expectedEvent{Level: LogLevelInfo, Message: "scheduled_task_started", Component: "scheduler", Operation: "start", Meta: map[string]interface{}{"task": "test", "interval": "1m0s"}})
+	logs.Expect(t, expectedEvent{Level: LogLevelDebug, Message: "scheduled_task_completed", Component: "scheduler", Operation: "run", Meta: map[string]interface{}{"task": "test"}})
+}
+
+func TestScheduler_Health(t *testing.T) {
+	scheduler := NewScheduler(0, nil, newTestLogger())
+	scheduler.Register(ScheduledTask{Name: "fresh", Interval: time.Minute})
+	scheduler.Register(ScheduledTask{Name: "failing", Interval: time.Minute})
+	scheduler.Register(ScheduledTask{Name: "never_run", Interval: time.Hour})
+
+	now := time.Now()
+	scheduler.startedAt = now.Add(-10 * time.Minute)
+	scheduler.recordRun("fresh", now.Add(-30*time.Second), nil)
+	scheduler.recordRun("failing", now.Add(-5*time.Minute), nil)
+	scheduler.recordRun("failing", now.Add(-time.Minute), errors.New("riot unavailable"))
+
+	expected := map[string]bool{"fresh": false, "failing": true, "never_run": false}
+
+	health := scheduler.Health(now)
+	if len(health) != len(expected) {
+		t.Fatalf("Health() returned %d jobs, expected %d", len(health), len(expected))
+	}
+	for _, job := range health {
+		if job.Stale != expected[job.Name] {
+			t.Errorf("%s stale = %v, expected %v", job.Name, job.Stale, expected[job.Name])
+		}
+	}
+	if health[0].Name != "failing" || health[0].LastError != "riot unavailable" {
+		t.Errorf("failing job = %+v, expected last error to be reported", health[0])
+	}
+}
+
+func TestScheduler_HealthNil(t *testing.T) {
+	var scheduler *Scheduler
+	if health := scheduler.Health(time.Now()); len(health) != 0 {
+		t.Errorf("Health() on nil scheduler = %v, expected empty", health)
+	}
+}
+
+type fakeJobStore struct {
+	mu       sync.Mutex
+	next     map[string]time.Time
+	lockedBy string
+	runs     []time.Time
+}
+
+func (f *fakeJobStore) ScheduleJob(ctx context.Context, name string, nextRunAt time.Time) (time.Time, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.next[name]; !ok {
+		f.next[name] = nextRunAt
+	}
+	return f.next[name], nil
+}
+
+func (f *fakeJobStore) LockJob(ctx context.Context, name string) (func(), bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.lockedBy != "" {
+		return nil, false, nil
+	}
+	f.lockedBy = name
+	return func() {
+		f.mu.Lock()
+		f.lockedBy = ""
+		f.mu.Unlock()
+	}, true, nil
+}
+
+func (f *fakeJobStore) RecordJobRun(ctx context.Context, name string, ranAt, nextRunAt time.Time, runErr error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.next[name] = nextRunAt
+	f.runs = append(f.runs, ranAt)
+	return nil
+}
+
+func TestScheduler_PersistentCatchUp(t *testing.T) {
+	logger, logs := newCaptureLogger()
+	clock := newFakeClock(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC))
+	store := &fakeJobStore{next: map[string]time.Time{"league_update_challenger": clock.Now().Add(-2 * time.Hour)}}
+	scheduler := NewScheduler(0, clock, logger)
+	scheduler.SetStore(store)
+
+	runs := make(chan struct{}, 2)
+	for _, name := range []string{"league_update_challenger", "league_update_master"} {
+		scheduler.Register(ScheduledTask{
+			Name:       name,
+			Interval:   time.Hour,
+			Persistent: true,
+			Run: func(ctx context.Context) error {
+				runs <- struct{}{}
+				return nil
+			},
+		})
+	}
+
+	scheduler.Start()
+	select {
+	case <-runs:
+	case <-time.After(time.Second):
+		t.Fatal("missed run was not caught up")
+	}
+	scheduler.Stop()
+
+	if len(runs) != 0 {
+		t.Error("a job without a missed run ran before its interval")
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.runs) != 1 {
+		t.Fatalf("recorded runs = %d, expected 1", len(store.runs))
+	}
+	for name, next := range store.next {
+		if wait := next.Sub(clock.Now()); wait != time.Hour {
+			t.Errorf("%s next run in %v, expected an hour", name, wait)
+		}
+	}
+	logs.Expect(t, expectedEvent{Level: LogLevelInfo, Message: "scheduled_task_catch_up", Component: "scheduler", Operation: "start", Meta: map[string]interface{}{"task": "league_update_challenger"}})
+}
+
+func TestScheduler_RunLocked(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		next      time.Time
+		lockedBy  string
+		expectRun bool
+		wait      time.Duration
+	}{
+		{
+			name:      "due",
+			next:      now.Add(-time.Second),
+			expectRun: true,
+			wait:      time.Hour,
+		},
+		{
+			name:     "locked by another replica",
+			next:     now.Add(-time.Second),
+			lockedBy: "other",
+			wait:     jobRetryDelay,
+		},
+		{
+			name: "already run by another replica",
+			next: now.Add(10 * time.Minute),
+			wait: 10 * time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeJobStore{next: map[string]time.Time{"job": tt.next}, lockedBy: tt.lockedBy}
+			scheduler := NewScheduler(0, newFakeClock(now), newTestLogger())
+			scheduler.SetStore(store)
+
+			ran := false
+			task := ScheduledTask{
+				Name:       "job",
+				Interval:   time.Hour,
+				Persistent: true,
+				Run: func(ctx context.Context) error {
+					ran = true
+					return nil
+				},
+			}
+			scheduler.Register(task)
+
+			wait := scheduler.runLocked(task)
+			if ran != tt.expectRun {
+				t.Errorf("ran = %v, expected %v", ran, tt.expectRun)
+			}
+			if wait != tt.wait {
+				t.Errorf("wait = %v, expected %v", wait, tt.wait)
+			}
+			if store.lockedBy != tt.lockedBy {
+				t.Errorf("lock held by %q after run, expected %q", store.lockedBy, tt.lockedBy)
+			}
+		})
+	}
+}
+
+func TestJobLockKey(t *testing.T) {
+	if jobLockKey("league_update_challenger") != jobLockKey("league_update_challenger") {
+		t.Error("jobLockKey() is not stable")
+	}
+	if jobLockKey("league_update_challenger") == jobLockKey("league_update_master") {
+		t.Error("jobLockKey() collides for different jobs")
+	}
+}
@@ -0,0 +1,144 @@
+package internal
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type EventSchema struct {
+	Event   string
+	Version string
+	Subject string
+	Type    reflect.Type
+}
+
+var eventSchemas = []EventSchema{
+	{Event: "league.update", Version: "v1", Subject: "tft.league.update", Type: reflect.TypeOf(LeagueUpdateTask{})},
+	{Event: "summoner.name.fetch", Version: "v1", Subject: "tft.summoner.name.fetch", Type: reflect.TypeOf(SummonerNameTask{})},
+	{Event: "summoner.name.resolved", Version: "v1", Subject: "tft.summoner.name.resolved", Type: reflect.TypeOf(SummonerNameResolvedEvent{})},
+	{Event: "watchlist.rank_changed", Version: "v1", Subject: "tft.watchlist.rank_changed", Type: reflect.TypeOf(RankChangeEvent{})},
+	{Event: "watchlist.promotion_series", Version: "v1", Subject: "tft.watchlist.promotion_series", Type: reflect.TypeOf(PromotionSeriesEvent{})},
+	{Event: "watchlist.streak", Version: "v1", Subject: "tft.watchlist.streak", Type: reflect.TypeOf(StreakEvent{})},
+	{Event: "summoner.profile_changed", Version: "v1", Subject: "tft.summoner.profile_changed", Type: reflect.TypeOf(ProfileChangeEvent{})},
+	{Event: "match.ingest", Version: "v1", Subject: "tft.match.ingest", Type: reflect.TypeOf(MatchIngestTask{})},
+	{Event: "match.ingested", Version: "v1", Subject: "tft.match.ingested", Type: reflect.TypeOf(MatchIngestedEvent{})},
+	{Event: "auth.api_key.revoked", Version: "v1", Subject: "tft.auth.api_key.revoked", Type: reflect.TypeOf(APIKeyRevokedEvent{})},
+}
+
+func findEventSchema(event, version string) (EventSchema, bool) {
+	for _, schema := range eventSchemas {
+		if schema.Event == event && schema.Version == version {
+			return schema, true
+		}
+	}
+	return EventSchema{}, false
+}
+
+func listEventSchemas() []map[string]string {
+	result := make([]map[string]string, 0, len(eventSchemas))
+	for _, schema := range eventSchemas {
+		result = append(result, map[string]string{
+			"event":   schema.Event,
+			"version": schema.Version,
+			"subject": schema.Subject,
+			"url":     "/schemas/" + schema.Event + "/" + schema.Version,
+		})
+	}
+	return result
+}
+
+func buildEventSchemaDocument(schema EventSchema) map[string]interface{} {
+	document := GenerateJSONSchema(schema.Type)
+	document["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	document["$id"] = "/schemas/" + schema.Event + "/" + schema.Version
+	document["title"] = schema.Event
+	document["x-subject"] = schema.Subject
+	document["x-version"] = schema.Version
+	return document
+}
+
+func GenerateJSONSchema(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": GenerateJSONSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": GenerateJSONSchema(t.Elem())}
+	case reflect.Struct:
+		return generateStructSchema(t)
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func generateStructSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, omitEmpty := parseJSONTag(field)
+		if name == "-" {
+			continue
+		}
+
+		properties[name] = GenerateJSONSchema(field.Type)
+		if !omitEmpty && field.Type.Kind() != reflect.Ptr {
+			required = append(required, name)
+		}
+	}
+
+	sort.Strings(required)
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func parseJSONTag(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "" {
+		return field.Name, false
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	omitEmpty := false
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty
+}
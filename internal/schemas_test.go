@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	schema := GenerateJSONSchema(reflect.TypeOf(LeagueUpdateTask{}))
+
+	if schema["type"] != "object" {
+		t.Fatalf("type = %v, expected object", schema["type"])
+	}
+
+	properties, ok := schema["properties"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected properties map")
+	}
+
+	expectedTypes := map[string]string{
+		"type":     "string",
+		"tier":     "string",
+		"division": "string",
+		"region":   "string",
+		"page":     "integer",
+	}
+	for name, expectedType := range expectedTypes {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			t.Errorf("missing property %s", name)
+			continue
+		}
+		if property["type"] != expectedType {
+			t.Errorf("property %s type = %v, expected %v", name, property["type"], expectedType)
+		}
+	}
+
+	required, _ := schema["required"].([]string)
+	expectedRequired := []string{"region", "type"}
+	if !reflect.DeepEqual(required, expectedRequired) {
+		t.Errorf("required = %v, expected %v", required, expectedRequired)
+	}
+}
+
+func TestFindEventSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		event    string
+		version  string
+		expected bool
+	}{
+		{
+			name:     "known event",
+			event:    "summoner.name.fetch",
+			version:  "v1",
+			expected: true,
+		},
+		{
+			name:     "unknown version",
+			event:    "summoner.name.fetch",
+			version:  "v9",
+			expected: false,
+		},
+		{
+			name:     "unknown event",
+			event:    "unknown",
+			version:  "v1",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, found := findEventSchema(tt.event, tt.version)
+			if found != tt.expected {
+				t.Errorf("findEventSchema() found = %v, expected %v", found, tt.expected)
+			}
+		})
+	}
+}
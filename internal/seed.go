@@ -0,0 +1,298 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+// SeedOptions controls the fixture data written by the seed command.
+type SeedOptions struct {
+	Region  string
+	Players int
+	Matches int
+	// TTL applies to the seeded cache keys; the service's own TTLs are short
+	// enough that a seeded instance would otherwise fall back to Riot within
+	// the hour.
+	TTL  time.Duration
+	Seed int64
+	Now  time.Time
+}
+
+type SeedReport struct {
+	Region    string `json:"region"`
+	Players   int    `json:"players"`
+	Matches   int    `json:"matches"`
+	CacheKeys int    `json:"cacheKeys"`
+	Database  bool   `json:"database"`
+	// SamplePUUID and SampleRiotID identify a Challenger player that has
+	// matches, to start exploring the API from.
+	SamplePUUID  string `json:"samplePuuid"`
+	SampleRiotID string `json:"sampleRiotId"`
+}
+
+type seedPlayer struct {
+	Account  AccountData
+	Summoner Summoner
+	Entry    LeagueEntry
+}
+
+type seedData struct {
+	Players []seedPlayer
+	Matches []Match
+}
+
+var (
+	seedApexTiers = []string{"CHALLENGER", "GRANDMASTER", "MASTER"}
+	seedDivisions = []struct{ Tier, Rank string }{
+		{"DIAMOND", "I"}, {"DIAMOND", "II"}, {"EMERALD", "I"}, {"PLATINUM", "II"}, {"GOLD", "I"},
+	}
+	seedNameFirst = []string{"Silent", "Lucky", "Golden", "Rolling", "Frozen", "Arcane", "Crimson", "Hyper", "Lazy", "Wild"}
+	seedNameLast  = []string{"Penguin", "Tactician", "Reroll", "Carousel", "Dragon", "Sniper", "Augment", "Fiesta", "Scout", "Bard"}
+	seedTraits    = []string{"TFT13_Sniper", "TFT13_Sorcerer", "TFT13_Bruiser", "TFT13_Ambusher", "TFT13_Academy", "TFT13_Crime", "TFT13_Warband", "TFT13_Rebel"}
+	seedUnits     = []string{"TFT13_Jinx", "TFT13_Vi", "TFT13_Caitlyn", "TFT13_Ekko", "TFT13_Heimerdinger", "TFT13_Jayce", "TFT13_Silco", "TFT13_Vander", "TFT13_Ambessa", "TFT13_Mel", "TFT13_Warwick", "TFT13_Sevika"}
+)
+
+// seedPUUID pads the index to the 78 characters of a real PUUID so
+// validation and key layouts behave as they do in production.
+func seedPUUID(i int) string {
+	return strings.Repeat(fmt.Sprintf("seedPlayer%04d", i), 6)[:78]
+}
+
+// generateSeedData builds a deterministic data set for opts.Seed: the first
+// players fill the apex ladders, the rest are spread over a few divisions,
+// and each match draws eight distinct players.
+func generateSeedData(opts SeedOptions) seedData {
+	rng := rand.New(rand.NewSource(opts.Seed))
+	apexSize := opts.Players / (2 * len(seedApexTiers))
+
+	players := make([]seedPlayer, opts.Players)
+	for i := range players {
+		puuid := seedPUUID(i)
+		gameName := fmt.Sprintf("%s %s %d", seedNameFirst[rng.Intn(len(seedNameFirst))], seedNameLast[rng.Intn(len(seedNameLast))], i)
+		tagLine := opts.Region
+
+		entry := LeagueEntry{
+			PUUID:        puuid,
+			SummonerID:   fmt.Sprintf("seed-summoner-%04d", i),
+			SummonerName: gameName + "#" + tagLine,
+			QueueType:    "RANKED_TFT",
+			Wins:         20 + rng.Intn(200),
+			Losses:       20 + rng.Intn(200),
+			HotStreak:    rng.Intn(5) == 0,
+			Veteran:      rng.Intn(3) == 0,
+		}
+		if apexSize > 0 && i < apexSize*len(seedApexTiers) {
+			tier := i / apexSize
+			entry.Tier = seedApexTiers[tier]
+			entry.Rank = "I"
+			entry.LeaguePoints = 1800 - tier*600 - (i%apexSize)*(400/apexSize)
+		} else {
+			division := seedDivisions[i%len(seedDivisions)]
+			entry.Tier = division.Tier
+			entry.Rank = division.Rank
+			entry.LeaguePoints = rng.Intn(100)
+		}
+		entry.LeagueID = "seed-" + strings.ToLower(entry.Tier)
+
+		players[i] = seedPlayer{
+			Account: AccountData{PUUID: puuid, GameName: gameName, TagLine: tagLine},
+			Summoner: Summoner{
+				ID:            entry.SummonerID,
+				AccountID:     fmt.Sprintf("seed-account-%04d", i),
+				PUUID:         puuid,
+				ProfileIconID: 1 + rng.Intn(50),
+				RevisionDate:  opts.Now.UnixMilli(),
+				SummonerLevel: 30 + rng.Intn(500),
+			},
+			Entry: entry,
+		}
+	}
+
+	var matches []Match
+	if len(players) >= 8 {
+		for m := 0; m < opts.Matches; m++ {
+			playedAt := opts.Now.Add(-time.Duration(rng.Int63n(int64(14 * 24 * time.Hour))))
+			picks := rng.Perm(len(players))[:8]
+			// Matches that include the sample player keep it explorable.
+			if m%2 == 0 && !slices.Contains(picks, 0) {
+				picks[0] = 0
+			}
+
+			match := Match{
+				Metadata: MatchMetadata{
+					DataVersion: "5",
+					MatchID:     fmt.Sprintf("%s_9%09d", opts.Region, m+1),
+				},
+				Info: MatchInfo{
+					GameDatetime: playedAt.UnixMilli(),
+					GameLength:   1500 + float64(rng.Intn(900)),
+					GameVersion:  "Version 14.24.1",
+					QueueID:      1100,
+					TFTSetNumber: 13,
+				},
+			}
+			for placement, idx := range rng.Perm(8) {
+				participant := seedParticipant(rng, players[picks[idx]].Account.PUUID, placement+1)
+				match.Metadata.Participants = append(match.Metadata.Participants, participant.PUUID)
+				match.Info.Participants = append(match.Info.Participants, participant)
+			}
+			matches = append(matches, match)
+		}
+	}
+
+	return seedData{Players: players, Matches: matches}
+}
+
+func seedParticipant(rng *rand.Rand, puuid string, placement int) MatchParticipant {
+	participant := MatchParticipant{
+		PUUID:                puuid,
+		Placement:            placement,
+		Level:                7 + rng.Intn(3),
+		LastRound:            40 - placement*2 + rng.Intn(3),
+		TimeEliminated:       float64(2400 - placement*90),
+		TotalDamageToPlayers: 40 + (9-placement)*12 + rng.Intn(20),
+	}
+	for _, i := range rng.Perm(len(seedTraits))[:3] {
+		participant.Traits = append(participant.Traits, MatchTrait{
+			Name:        seedTraits[i],
+			NumUnits:    2 + rng.Intn(4),
+			Style:       1 + rng.Intn(3),
+			TierCurrent: 1 + rng.Intn(3),
+		})
+	}
+	for _, i := range rng.Perm(len(seedUnits))[:participant.Level] {
+		participant.Units = append(participant.Units, MatchUnit{
+			CharacterID: seedUnits[i],
+			Tier:        1 + rng.Intn(3),
+			Rarity:      rng.Intn(5),
+		})
+	}
+	return participant
+}
+
+// Seed writes a generated data set through the same cache keys and tables the
+// service reads, so ladders, lookups, match history and stats respond without
+// calling Riot. Re-running it with the same options overwrites the same keys
+// and rows.
+func Seed(ctx context.Context, cfg *Config, cache *CacheManager, db *DatabaseManager, opts SeedOptions) (*SeedReport, error) {
+	if opts.Region == "" {
+		opts.Region = cfg.RiotRegion
+	}
+	if opts.Players < 8 {
+		return nil, fmt.Errorf("at least 8 players are needed to build a match")
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now().UTC()
+	}
+
+	data := generateSeedData(opts)
+	report := &SeedReport{
+		Region:       opts.Region,
+		Players:      len(data.Players),
+		Matches:      len(data.Matches),
+		SamplePUUID:  data.Players[0].Account.PUUID,
+		SampleRiotID: data.Players[0].Account.GameName + "#" + data.Players[0].Account.TagLine,
+	}
+
+	set := func(key string, value interface{}) error {
+		if err := cache.Set(ctx, key, value, opts.TTL); err != nil {
+			return fmt.Errorf("cache %s: %w", key, err)
+		}
+		if cache.enabled && cache.backend != nil {
+			report.CacheKeys++
+		}
+		return nil
+	}
+
+	apex := make(map[string][]LeagueEntry)
+	divisions := make(map[string][]LeagueEntry)
+	for _, player := range data.Players {
+		puuid := player.Account.PUUID
+		name := player.Account.GameName + "#" + player.Account.TagLine
+		if err := cache.SetSummonerName(ctx, puuid, name); err != nil {
+			return nil, fmt.Errorf("summoner name %s: %w", puuid, err)
+		}
+		if err := set(cache.Key("summoner", opts.Region, puuid), player.Summoner); err != nil {
+			return nil, err
+		}
+		if err := set(cache.Key("account_puuid", opts.Region, puuid), player.Account); err != nil {
+			return nil, err
+		}
+		riotIDKey := normalizeRiotIDKey(player.Account.GameName, player.Account.TagLine)
+		if err := set(cache.Key("account_name", opts.Region, riotIDKey), player.Account); err != nil {
+			return nil, err
+		}
+		if err := set(cache.Key("league_by_puuid", opts.Region, puuid), []LeagueEntry{player.Entry}); err != nil {
+			return nil, err
+		}
+		if db != nil && db.Enabled && !db.ReadOnly {
+			account := player.Account
+			if err := db.SetRiotIDLookup(ctx, riotIDKey, &account); err != nil {
+				return nil, fmt.Errorf("riot id %s: %w", riotIDKey, err)
+			}
+		}
+
+		if slices.Contains(seedApexTiers, player.Entry.Tier) {
+			apex[player.Entry.Tier] = append(apex[player.Entry.Tier], player.Entry)
+		} else {
+			key := player.Entry.Tier + "/" + player.Entry.Rank
+			divisions[key] = append(divisions[key], player.Entry)
+		}
+	}
+
+	for _, tier := range seedApexTiers {
+		league := ChallengerLeague{
+			LeagueID: "seed-" + strings.ToLower(tier),
+			Entries:  apex[tier],
+			Tier:     tier,
+			Name:     "Seeded " + strings.ToLower(tier),
+			Queue:    "RANKED_TFT",
+		}
+		if err := set(cache.Key(strings.ToLower(tier), opts.Region), league); err != nil {
+			return nil, err
+		}
+		if tier == ladderSnapshotTier && db != nil && db.Enabled && !db.ReadOnly {
+			snapshot := make([]LadderSnapshotEntry, 0, len(league.Entries))
+			for _, entry := range league.Entries {
+				snapshot = append(snapshot, LadderSnapshotEntry{
+					PUUID:        entry.PUUID,
+					LeaguePoints: entry.LeaguePoints,
+					Wins:         entry.Wins,
+					Losses:       entry.Losses,
+				})
+			}
+			if _, err := db.RecordLeagueSnapshot(ctx, opts.Region, tier, snapshot, opts.Now, 0, cfg.LeagueSnapshotRetention); err != nil {
+				return nil, fmt.Errorf("league snapshot: %w", err)
+			}
+		}
+	}
+	for _, division := range seedDivisions {
+		entries := divisions[division.Tier+"/"+division.Rank]
+		page := LeagueEntriesResponse{Entries: entries, Page: 1, Tier: division.Tier, Division: division.Rank}
+		if err := set(cache.Key("entries", opts.Region, division.Tier, division.Rank, "1"), page); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range data.Matches {
+		match := &data.Matches[i]
+		if err := set(cache.Key("match", match.Metadata.MatchID), match); err != nil {
+			return nil, err
+		}
+		if db != nil && db.Enabled && !db.ReadOnly {
+			if err := db.ArchiveMatch(ctx, match); err != nil {
+				return nil, fmt.Errorf("match %s: %w", match.Metadata.MatchID, err)
+			}
+		}
+	}
+
+	report.Database = db != nil && db.Enabled && !db.ReadOnly
+	return report, nil
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGenerateSeedData(t *testing.T) {
+	opts := SeedOptions{Region: "BR1", Players: 60, Matches: 20, Seed: 7, Now: time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)}
+	data := generateSeedData(opts)
+
+	if !reflect.DeepEqual(data, generateSeedData(opts)) {
+		t.Fatal("generateSeedData() is not deterministic for the same seed")
+	}
+	if len(data.Players) != 60 || len(data.Matches) != 20 {
+		t.Fatalf("generated %d players and %d matches, expected 60 and 20", len(data.Players), len(data.Matches))
+	}
+
+	tiers := make(map[string]int)
+	for _, player := range data.Players {
+		tiers[player.Entry.Tier]++
+		if len(player.Account.PUUID) != 78 {
+			t.Errorf("PUUID %q has %d characters, expected 78", player.Account.PUUID, len(player.Account.PUUID))
+		}
+	}
+	for _, tier := range seedApexTiers {
+		if tiers[tier] != 10 {
+			t.Errorf("%s has %d players, expected 10", tier, tiers[tier])
+		}
+	}
+
+	sample := data.Players[0].Account.PUUID
+	sampleMatches := 0
+	for _, match := range data.Matches {
+		seen := make(map[string]bool)
+		placements := make(map[int]bool)
+		for _, participant := range match.Info.Participants {
+			seen[participant.PUUID] = true
+			placements[participant.Placement] = true
+		}
+		if len(seen) != 8 || len(placements) != 8 {
+			t.Errorf("match %s has %d players and %d placements, expected 8 of each", match.Metadata.MatchID, len(seen), len(placements))
+		}
+		if seen[sample] {
+			sampleMatches++
+		}
+		if played := match.PlayedAt(); played.After(opts.Now) || played.Before(opts.Now.Add(-14*24*time.Hour)) {
+			t.Errorf("match %s played at %v, expected within the last 14 days", match.Metadata.MatchID, played)
+		}
+	}
+	if sampleMatches < 10 {
+		t.Errorf("sample player is in %d matches, expected at least half of them", sampleMatches)
+	}
+}
+
+func TestSeed_ServesFromCache(t *testing.T) {
+	server := newFakeMemcached(t)
+	cfg := &Config{RiotRegion: "BR1", RiotBaseURL: fixtureBaseURL, CacheEnabled: true, CacheBackend: CacheBackendMemcached, MemcachedServers: server.Addr(), MemcachedTimeout: time.Second}
+	cache := NewCacheManager(cfg, nil, nil, nil)
+	ctx := t.Context()
+
+	report, err := Seed(ctx, cfg, cache, nil, SeedOptions{Players: 24, Matches: 4, Seed: 1})
+	if err != nil {
+		t.Fatalf("Seed() error = %v", err)
+	}
+	if report.Region != "BR1" || report.CacheKeys == 0 || report.Database {
+		t.Errorf("report = %+v", report)
+	}
+
+	client := NewRiotAPIClient(cfg, cache, newTestLogger(), nil)
+	league, err := client.GetChallengerLeague(ctx)
+	if err != nil {
+		t.Fatalf("GetChallengerLeague() error = %v", err)
+	}
+	if len(league.Entries) != 4 || league.Entries[0].PUUID != report.SamplePUUID || league.Entries[0].SummonerName != report.SampleRiotID {
+		t.Errorf("challenger = %+v, expected the seeded ladder led by the sample player", league.Entries)
+	}
+
+	id, err := ParseRiotID(report.SampleRiotID)
+	if err != nil {
+		t.Fatalf("ParseRiotID(%q) error = %v", report.SampleRiotID, err)
+	}
+	account, err := client.GetAccountByRiotID(ctx, id, false)
+	if err != nil || account.PUUID != report.SamplePUUID {
+		t.Errorf("GetAccountByRiotID() = %+v, %v, expected the sample player", account, err)
+	}
+
+	match, err := client.GetMatch(ctx, "BR1_9000000001")
+	if err != nil || match.Participant(report.SamplePUUID) == nil {
+		t.Errorf("GetMatch() = %v, expected a seeded match with the sample player", err)
+	}
+
+	if _, err := Seed(ctx, cfg, cache, nil, SeedOptions{Players: 4}); err == nil {
+		t.Error("Seed() with fewer than 8 players expected error but got nil")
+	}
+}
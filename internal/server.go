@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"net/http"
+)
+
+// NewHTTPServer builds the API server from the HTTP_* settings. With
+// HTTP_H2C it also accepts HTTP/2 without TLS (prior knowledge), for load
+// balancers and meshes that speak h2c to their backends.
+func NewHTTPServer(cfg *Config, handler http.Handler) *http.Server {
+	port := cfg.AppPort
+	if port == "" {
+		port = "8000"
+	}
+
+	server := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		MaxHeaderBytes:    cfg.HTTPMaxHeaderBytes,
+	}
+	if cfg.HTTPH2C {
+		var protocols http.Protocols
+		protocols.SetHTTP1(true)
+		protocols.SetUnencryptedHTTP2(true)
+		server.Protocols = &protocols
+	}
+	return server
+}
+
+// ServeHTTPServer listens with TLS when TLS_CERT_FILE is set, in which case
+// HTTP/2 is negotiated through ALPN, and in cleartext otherwise.
+func ServeHTTPServer(cfg *Config, server *http.Server) error {
+	if cfg.TLSCertFile != "" {
+		return server.ListenAndServeTLS(cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+	return server.ListenAndServe()
+}
+
+// HTTPServerScheme names how the server is listening, for the startup log.
+func HTTPServerScheme(cfg *Config) string {
+	switch {
+	case cfg.TLSCertFile != "":
+		return "https"
+	case cfg.HTTPH2C:
+		return "h2c"
+	}
+	return "http"
+}
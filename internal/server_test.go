@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	cfg := &Config{
+		HTTPReadHeaderTimeout: 2 * time.Second,
+		HTTPReadTimeout:       3 * time.Second,
+		HTTPWriteTimeout:      4 * time.Second,
+		HTTPIdleTimeout:       5 * time.Second,
+		HTTPMaxHeaderBytes:    8 << 10,
+	}
+	server := NewHTTPServer(cfg, http.NotFoundHandler())
+
+	if server.Addr != ":8000" {
+		t.Errorf("Addr = %q, expected the default port", server.Addr)
+	}
+	if server.ReadHeaderTimeout != 2*time.Second || server.ReadTimeout != 3*time.Second ||
+		server.WriteTimeout != 4*time.Second || server.IdleTimeout != 5*time.Second || server.MaxHeaderBytes != 8<<10 {
+		t.Errorf("server limits = %+v, expected the configured ones", server)
+	}
+	if server.Protocols != nil {
+		t.Errorf("Protocols = %v, expected the net/http default without HTTP_H2C", server.Protocols)
+	}
+	if scheme := HTTPServerScheme(cfg); scheme != "http" {
+		t.Errorf("HTTPServerScheme() = %q, expected http", scheme)
+	}
+}
+
+func TestNewHTTPServer_H2C(t *testing.T) {
+	cfg := &Config{HTTPH2C: true, HTTPReadHeaderTimeout: time.Second}
+	server := NewHTTPServer(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	t.Cleanup(func() { server.Close() })
+
+	tests := []struct {
+		name     string
+		http2    bool
+		expected string
+	}{
+		{name: "prior knowledge", http2: true, expected: "HTTP/2.0"},
+		{name: "http/1.1 still served", http2: false, expected: "HTTP/1.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var protocols http.Protocols
+			protocols.SetHTTP1(!tt.http2)
+			protocols.SetUnencryptedHTTP2(tt.http2)
+			client := &http.Client{Transport: &http.Transport{Protocols: &protocols}, Timeout: 5 * time.Second}
+
+			resp, err := client.Get("http://" + listener.Addr().String() + "/")
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.Proto != tt.expected {
+				t.Errorf("Proto = %q, expected %q", resp.Proto, tt.expected)
+			}
+		})
+	}
+
+	if scheme := HTTPServerScheme(cfg); scheme != "h2c" {
+		t.Errorf("HTTPServerScheme() = %q, expected h2c", scheme)
+	}
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const shardLocationTTL = 6 * time.Hour
+
+// ShardLocation is cached per PUUID; an empty Region records that the player
+// was not found on any other platform.
+type ShardLocation struct {
+	Region string `json:"region"`
+}
+
+// parseShardProbeRegions drops the home region, which has already answered
+// 404 by the time probing starts. Config validation rejects unknown regions.
+func parseShardProbeRegions(raw, home string) []string {
+	regions, _ := parseRegionList(raw, nil)
+	probes := make([]string, 0, len(regions))
+	for _, region := range regions {
+		if region != home {
+			probes = append(probes, region)
+		}
+	}
+	return probes
+}
+
+func isRiotNotFound(err error) bool {
+	var riotErr *RiotAPIError
+	return errors.As(err, &riotErr) && riotErr.StatusCode == http.StatusNotFound
+}
+
+// LocateShard looks for a PUUID the home platform does not know on the other
+// platforms: first with Riot's active shard lookup, then, when
+// CROSS_SHARD_PROBE_REGIONS is set, by asking those platforms directly. The
+// whole search shares CROSS_SHARD_TIMEOUT so a miss adds a bounded delay to
+// the 404 it precedes.
+func (c *RiotAPIClient) LocateShard(ctx context.Context, puuid string) (string, bool) {
+	if c.crossShardTimeout <= 0 {
+		return "", false
+	}
+
+	cacheKey := c.cache.Key("shard", c.region, puuid)
+	var cached ShardLocation
+	if err := c.cache.Get(ctx, cacheKey, &cached); err == nil {
+		return cached.Region, cached.Region != ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.crossShardTimeout)
+	defer cancel()
+
+	start := time.Now()
+	region, method, err := c.activeShard(ctx, puuid)
+	if region == "" && len(c.shardProbeRegions) > 0 && ctx.Err() == nil {
+		region, err = c.probeShards(ctx, puuid)
+		method = "probe"
+	}
+
+	// A search cut short says nothing about where the player is, so only
+	// complete answers are cached.
+	if region != "" || err == nil {
+		c.cache.Set(context.WithoutCancel(ctx), cacheKey, ShardLocation{Region: region}, shardLocationTTL)
+	}
+
+	c.logger.Info("cross_shard_lookup").
+		Component("riot_api").
+		Operation("locate_shard").
+		Game(puuid, c.region, "").
+		Meta("found_region", region).
+		Meta("method", method).
+		Err(err).
+		Duration(time.Since(start)).
+		Log()
+	return region, region != ""
+}
+
+func (c *RiotAPIClient) activeShard(ctx context.Context, puuid string) (string, string, error) {
+	url := fmt.Sprintf("%s/riot/account/v1/active-shards/by-game/tft/by-puuid/%s", c.accountURL, puuid)
+	data, err := c.doRequest(ctx, url)
+	if err != nil {
+		if isRiotNotFound(err) {
+			return "", "active_shard", nil
+		}
+		return "", "active_shard", err
+	}
+
+	var result struct {
+		ActiveShard string `json:"activeShard"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", "active_shard", fmt.Errorf("invalid active shard payload: %w", err)
+	}
+
+	region := strings.ToUpper(result.ActiveShard)
+	if _, known := accountAPIURLs[region]; !known || region == c.region {
+		return "", "active_shard", nil
+	}
+	return region, "active_shard", nil
+}
+
+// probeShards asks every probe region at once and returns the first that
+// knows the PUUID, cancelling the rest. err is nil only when every region
+// answered 404.
+func (c *RiotAPIClient) probeShards(ctx context.Context, puuid string) (string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type probe struct {
+		region string
+		err    error
+	}
+	results := make(chan probe, len(c.shardProbeRegions))
+	for _, region := range c.shardProbeRegions {
+		go func() {
+			_, err := c.ForRegion(region).FetchSummonerByPUUID(ctx, puuid)
+			results <- probe{region: region, err: err}
+		}()
+	}
+
+	var incomplete error
+	for range c.shardProbeRegions {
+		result := <-results
+		if result.err == nil {
+			return result.region, nil
+		}
+		if !isRiotNotFound(result.err) {
+			incomplete = result.err
+		}
+	}
+	return "", incomplete
+}
+
+// wrongRegionError turns a 404 for a player found on another platform into a
+// 421 naming that platform, so clients can retry against the right region.
+func wrongRegionError(ctx context.Context, riotClient *RiotAPIClient, err error, puuid string) (APIError, bool) {
+	if !isRiotNotFound(err) {
+		return APIError{}, false
+	}
+	region, found := riotClient.LocateShard(ctx, puuid)
+	if !found {
+		return APIError{}, false
+	}
+	return NewAPIError("Summoner belongs to another region", http.StatusMisdirectedRequest).WithField("region", region), true
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const shardTestPUUID = "fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixturePlayer0fixtureP"
+
+// shardTransport answers by host: bodies maps a host to the JSON it returns,
+// every other host answers 404.
+type shardTransport struct {
+	bodies map[string]string
+
+	mu    sync.Mutex
+	hosts []string
+}
+
+func (st *shardTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	st.mu.Lock()
+	st.hosts = append(st.hosts, r.URL.Host)
+	st.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	if body, ok := st.bodies[r.URL.Host]; ok {
+		rec.WriteString(body)
+	} else {
+		rec.WriteHeader(http.StatusNotFound)
+	}
+	resp := rec.Result()
+	resp.Request = r
+	return resp, nil
+}
+
+func newShardTestClient(probeRegions string, timeout time.Duration, bodies map[string]string) (*RiotAPIClient, *shardTransport) {
+	cfg := &Config{
+		RiotRegion:             "BR1",
+		RiotBaseURL:            "https://br1.api.riotgames.com",
+		RiotHTTPTimeout:        time.Second,
+		CrossShardTimeout:      timeout,
+		CrossShardProbeRegions: probeRegions,
+	}
+	client := NewRiotAPIClient(cfg, NewCacheManager(cfg, nil, nil, nil), newTestLogger(), nil)
+	transport := &shardTransport{bodies: bodies}
+	client.client.Transport = transport
+	return client, transport
+}
+
+func TestParseShardProbeRegions(t *testing.T) {
+	got := parseShardProbeRegions("br1, NA1,kr", "BR1")
+	if !reflect.DeepEqual(got, []string{"NA1", "KR"}) {
+		t.Errorf("parseShardProbeRegions() = %v, expected the home region dropped", got)
+	}
+}
+
+func TestLocateShard(t *testing.T) {
+	summoner := `{"puuid":"` + shardTestPUUID + `","summonerLevel":100}`
+
+	tests := []struct {
+		name     string
+		probes   string
+		timeout  time.Duration
+		bodies   map[string]string
+		expected string
+		requests int
+	}{
+		{
+			name:     "active shard",
+			bodies:   map[string]string{"americas.api.riotgames.com": `{"puuid":"x","game":"tft","activeShard":"euw1"}`},
+			timeout:  time.Second,
+			expected: "EUW1",
+			requests: 1,
+		},
+		{
+			name:     "active shard is home",
+			bodies:   map[string]string{"americas.api.riotgames.com": `{"activeShard":"br1"}`},
+			timeout:  time.Second,
+			requests: 1,
+		},
+		{
+			name:     "probe finds region",
+			probes:   "NA1,KR,EUW1",
+			bodies:   map[string]string{"kr.api.riotgames.com": summoner},
+			timeout:  time.Second,
+			expected: "KR",
+		},
+		{
+			name:     "not found anywhere",
+			probes:   "NA1,KR",
+			timeout:  time.Second,
+			requests: 3,
+		},
+		{
+			name:     "disabled",
+			probes:   "NA1",
+			bodies:   map[string]string{"na1.api.riotgames.com": summoner},
+			requests: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, transport := newShardTestClient(tt.probes, tt.timeout, tt.bodies)
+
+			region, found := client.LocateShard(t.Context(), shardTestPUUID)
+			if region != tt.expected || found != (tt.expected != "") {
+				t.Errorf("LocateShard() = %q, %v, expected %q", region, found, tt.expected)
+			}
+			// Probes that lose the race may be cancelled before being sent.
+			if tt.requests > 0 || tt.timeout == 0 {
+				if len(transport.hosts) != tt.requests {
+					t.Errorf("requests = %v, expected %d", transport.hosts, tt.requests)
+				}
+			}
+		})
+	}
+}
+
+func TestWrongRegionError(t *testing.T) {
+	client, _ := newShardTestClient("", time.Second, map[string]string{
+		"americas.api.riotgames.com": `{"activeShard":"na1"}`,
+	})
+
+	notFound := &RiotAPIError{StatusCode: http.StatusNotFound, Status: "404 Not Found"}
+	if _, found := wrongRegionError(t.Context(), client, &RiotAPIError{StatusCode: http.StatusBadGateway}, shardTestPUUID); found {
+		t.Error("wrongRegionError() located a shard for an upstream failure")
+	}
+
+	apiErr, found := wrongRegionError(t.Context(), client, notFound, shardTestPUUID)
+	if !found {
+		t.Fatal("wrongRegionError() did not locate the shard")
+	}
+
+	rec := httptest.NewRecorder()
+	writeError(rec, apiErr, newTestLogger(), httptest.NewRequest(http.MethodGet, "/summoner", nil))
+	if rec.Code != http.StatusMisdirectedRequest {
+		t.Fatalf("status = %d, expected 421", rec.Code)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `"code":"wrong_region"`) || !strings.Contains(body, `"region":"NA1"`) {
+		t.Errorf("body = %s, expected wrong_region pointing at NA1", body)
+	}
+}
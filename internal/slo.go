@@ -0,0 +1,323 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	sloDefaultRoute = "*"
+	sloBucketWidth  = time.Minute
+	// sloMaxRoutes bounds memory when prefix routes such as /schemas/ see
+	// arbitrary paths; routes past the limit are not tracked.
+	sloMaxRoutes = 200
+	// sloMinAlertRequests keeps a couple of failures on an idle route from
+	// reading as a budget burning at 100x.
+	sloMinAlertRequests = 20
+)
+
+// SLOObjective is the target for one route: Availability percent of requests
+// must not fail with 5xx, and LatencyTarget percent must finish within
+// LatencyThreshold. A zero LatencyThreshold tracks availability only.
+type SLOObjective struct {
+	Availability     float64
+	LatencyThreshold time.Duration
+	LatencyTarget    float64
+}
+
+// parseSLOObjectives reads route=availability[:latency@target] rules, e.g.
+// *=99.5:1s@99,/summoner=99:2s@95. The * route applies to every route
+// without a rule of its own.
+func parseSLOObjectives(value string) (map[string]SLOObjective, error) {
+	objectives := make(map[string]SLOObjective)
+	if strings.TrimSpace(value) == "" {
+		return objectives, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		route, spec, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found || (route != sloDefaultRoute && !strings.HasPrefix(route, "/")) {
+			return nil, fmt.Errorf("invalid SLO rule %q", rule)
+		}
+
+		availability, latency, hasLatency := strings.Cut(spec, ":")
+		objective := SLOObjective{}
+		var err error
+		if objective.Availability, err = parseSLOPercent(availability); err != nil {
+			return nil, fmt.Errorf("route %s: availability: %w", route, err)
+		}
+		if hasLatency {
+			threshold, target, found := strings.Cut(latency, "@")
+			if !found {
+				return nil, fmt.Errorf("route %s: latency must be threshold@target, e.g. 1s@99", route)
+			}
+			objective.LatencyThreshold, err = time.ParseDuration(threshold)
+			if err != nil || objective.LatencyThreshold <= 0 {
+				return nil, fmt.Errorf("route %s: invalid latency threshold %q", route, threshold)
+			}
+			if objective.LatencyTarget, err = parseSLOPercent(target); err != nil {
+				return nil, fmt.Errorf("route %s: latency target: %w", route, err)
+			}
+		}
+		objectives[route] = objective
+	}
+
+	return objectives, nil
+}
+
+func parseSLOPercent(value string) (float64, error) {
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || percent <= 0 || percent >= 100 {
+		return 0, fmt.Errorf("%q must be a percentage between 0 and 100, exclusive", value)
+	}
+	return percent, nil
+}
+
+type sloBucket struct {
+	minute int64
+	total  int64
+	failed int64
+	slow   int64
+}
+
+type sloRoute struct {
+	objective SLOObjective
+	buckets   []sloBucket
+}
+
+// SLOWindow counts the requests of one route over a window. BurnRate is how
+// many times faster than sustainable the error budget is being spent: 1
+// exhausts it exactly at the end of the SLO period, 14.4 in 1/14.4 of it.
+type SLOWindow struct {
+	Requests int64   `json:"requests"`
+	Bad      int64   `json:"bad"`
+	Ratio    float64 `json:"good_ratio"`
+	BurnRate float64 `json:"burn_rate"`
+}
+
+type SLIReport struct {
+	Short SLOWindow `json:"short"`
+	Long  SLOWindow `json:"long"`
+	// BudgetRemaining is the share of the long window's error budget left;
+	// it goes negative once the budget is overspent.
+	BudgetRemaining float64 `json:"budget_remaining"`
+	Burning         bool    `json:"burning"`
+}
+
+type SLORouteReport struct {
+	Objective struct {
+		Availability       float64 `json:"availability"`
+		LatencyThresholdMs int64   `json:"latency_threshold_ms,omitempty"`
+		LatencyTarget      float64 `json:"latency_target,omitempty"`
+	} `json:"objective"`
+	Availability SLIReport  `json:"availability"`
+	Latency      *SLIReport `json:"latency,omitempty"`
+}
+
+type SLOReport struct {
+	ShortWindow   string                    `json:"short_window"`
+	LongWindow    string                    `json:"long_window"`
+	BurnRateAlert float64                   `json:"burn_rate_alert"`
+	Routes        map[string]SLORouteReport `json:"routes"`
+}
+
+// SLOTracker keeps per-route request counts in one-minute buckets covering
+// the long window and derives burn rates from them. It alerts the way
+// multiwindow burn-rate alerts do: only when both the short and the long
+// window burn faster than SLO_BURN_RATE_ALERT, so a brief spike that is
+// already over does not warn, and neither does a slow burn.
+type SLOTracker struct {
+	objectives    map[string]SLOObjective
+	shortWindow   time.Duration
+	longWindow    time.Duration
+	burnRateAlert float64
+	interval      time.Duration
+	logger        *Logger
+	now           func() time.Time
+
+	mu     sync.Mutex
+	routes map[string]*sloRoute
+}
+
+// NewSLOTracker returns nil when SLO_ENABLED is false or no objective applies.
+func NewSLOTracker(cfg *Config, logger *Logger) *SLOTracker {
+	if !cfg.SLOEnabled {
+		return nil
+	}
+	objectives, err := parseSLOObjectives(cfg.SLOObjectives)
+	if err != nil || len(objectives) == 0 {
+		return nil
+	}
+
+	return &SLOTracker{
+		objectives:    objectives,
+		shortWindow:   cfg.SLOShortWindow,
+		longWindow:    cfg.SLOLongWindow,
+		burnRateAlert: cfg.SLOBurnRateAlert,
+		interval:      cfg.SLOEvaluationInterval,
+		logger:        logger,
+		now:           time.Now,
+		routes:        make(map[string]*sloRoute),
+	}
+}
+
+func (st *SLOTracker) objectiveFor(route string) (SLOObjective, bool) {
+	if objective, ok := st.objectives[route]; ok {
+		return objective, true
+	}
+	objective, ok := st.objectives[sloDefaultRoute]
+	return objective, ok
+}
+
+// Record counts a served request. 4xx responses count as good: they are the
+// caller's mistake, not the service's.
+func (st *SLOTracker) Record(route string, duration time.Duration, statusCode int) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	tracked, ok := st.routes[route]
+	if !ok {
+		objective, applies := st.objectiveFor(route)
+		if !applies || len(st.routes) >= sloMaxRoutes {
+			return
+		}
+		tracked = &sloRoute{objective: objective, buckets: make([]sloBucket, st.bucketCount())}
+		st.routes[route] = tracked
+	}
+
+	minute := st.now().Unix() / int64(sloBucketWidth/time.Second)
+	bucket := &tracked.buckets[minute%int64(len(tracked.buckets))]
+	if bucket.minute != minute {
+		*bucket = sloBucket{minute: minute}
+	}
+	bucket.total++
+	if statusCode >= http.StatusInternalServerError {
+		bucket.failed++
+	}
+	if tracked.objective.LatencyThreshold > 0 && duration > tracked.objective.LatencyThreshold {
+		bucket.slow++
+	}
+}
+
+func (st *SLOTracker) bucketCount() int {
+	return max(int(st.longWindow/sloBucketWidth), 1)
+}
+
+// sum adds the buckets of the last window, the current partial minute
+// included.
+func (st *SLOTracker) sum(route *sloRoute, window time.Duration) (total, failed, slow int64) {
+	now := st.now().Unix() / int64(sloBucketWidth/time.Second)
+	oldest := now - int64(window/sloBucketWidth) + 1
+	for _, bucket := range route.buckets {
+		if bucket.minute >= oldest && bucket.minute <= now {
+			total += bucket.total
+			failed += bucket.failed
+			slow += bucket.slow
+		}
+	}
+	return total, failed, slow
+}
+
+func sloWindow(total, bad int64, target float64) SLOWindow {
+	window := SLOWindow{Requests: total, Bad: bad, Ratio: 1}
+	if total > 0 {
+		errorRate := float64(bad) / float64(total)
+		window.Ratio = 1 - errorRate
+		window.BurnRate = errorRate / (1 - target/100)
+	}
+	return window
+}
+
+func (st *SLOTracker) sliReport(shortTotal, shortBad, longTotal, longBad int64, target float64) SLIReport {
+	report := SLIReport{
+		Short:           sloWindow(shortTotal, shortBad, target),
+		Long:            sloWindow(longTotal, longBad, target),
+		BudgetRemaining: 1,
+	}
+	if longTotal > 0 {
+		report.BudgetRemaining = 1 - float64(longBad)/(float64(longTotal)*(1-target/100))
+	}
+	report.Burning = longTotal >= sloMinAlertRequests &&
+		report.Short.BurnRate >= st.burnRateAlert && report.Long.BurnRate >= st.burnRateAlert
+	return report
+}
+
+func (st *SLOTracker) Report() SLOReport {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	report := SLOReport{
+		ShortWindow:   st.shortWindow.String(),
+		LongWindow:    st.longWindow.String(),
+		BurnRateAlert: st.burnRateAlert,
+		Routes:        make(map[string]SLORouteReport, len(st.routes)),
+	}
+	for name, route := range st.routes {
+		shortTotal, shortFailed, shortSlow := st.sum(route, st.shortWindow)
+		longTotal, longFailed, longSlow := st.sum(route, st.longWindow)
+
+		routeReport := SLORouteReport{
+			Availability: st.sliReport(shortTotal, shortFailed, longTotal, longFailed, route.objective.Availability),
+		}
+		routeReport.Objective.Availability = route.objective.Availability
+		routeReport.Objective.LatencyThresholdMs = route.objective.LatencyThreshold.Milliseconds()
+		routeReport.Objective.LatencyTarget = route.objective.LatencyTarget
+		if route.objective.LatencyThreshold > 0 {
+			latency := st.sliReport(shortTotal, shortSlow, longTotal, longSlow, route.objective.LatencyTarget)
+			routeReport.Latency = &latency
+		}
+		report.Routes[name] = routeReport
+	}
+	return report
+}
+
+func (st *SLOTracker) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "slo_evaluation",
+		Interval: st.interval,
+		Run:      st.Evaluate,
+	}
+}
+
+// Evaluate logs slo_budget_burning for every route and indicator burning its
+// budget too fast in both windows.
+func (st *SLOTracker) Evaluate(ctx context.Context) error {
+	report := st.Report()
+	for route, routeReport := range report.Routes {
+		st.warnIfBurning(route, "availability", routeReport.Availability)
+		if routeReport.Latency != nil {
+			st.warnIfBurning(route, "latency", *routeReport.Latency)
+		}
+	}
+	return nil
+}
+
+func (st *SLOTracker) warnIfBurning(route, indicator string, sli SLIReport) {
+	if !sli.Burning {
+		return
+	}
+
+	st.logger.Warn("slo_budget_burning").
+		Component("slo").
+		Operation("evaluate").
+		Meta("route", route).
+		Meta("indicator", indicator).
+		Meta("burn_rate_short", sli.Short.BurnRate).
+		Meta("burn_rate_long", sli.Long.BurnRate).
+		Meta("burn_rate_alert", st.burnRateAlert).
+		Meta("budget_remaining", sli.BudgetRemaining).
+		Meta("requests", sli.Long.Requests).
+		Meta("bad", sli.Long.Bad).
+		Log()
+}
+
+func SLOHandler(logger *Logger, tracker *SLOTracker) http.HandlerFunc {
+	return withCORS(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, tracker.Report(), logger, r)
+	})
+}
@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseSLOObjectives(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  map[string]SLOObjective
+		expectErr bool
+	}{
+		{"empty", "", map[string]SLOObjective{}, false},
+		{
+			"default and route",
+			"*=99.5:1s@99, /summoner=99%",
+			map[string]SLOObjective{
+				"*":         {Availability: 99.5, LatencyThreshold: time.Second, LatencyTarget: 99},
+				"/summoner": {Availability: 99},
+			},
+			false,
+		},
+		{"route without slash", "summoner=99", nil, true},
+		{"availability of 100", "*=100", nil, true},
+		{"latency without target", "*=99:1s", nil, true},
+		{"negative latency", "*=99:-1s@95", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSLOObjectives(tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parseSLOObjectives() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if tt.expectErr {
+				return
+			}
+			if len(got) != len(tt.expected) {
+				t.Fatalf("parseSLOObjectives() = %v, expected %v", got, tt.expected)
+			}
+			for route, objective := range tt.expected {
+				if got[route] != objective {
+					t.Errorf("objective for %s = %+v, expected %+v", route, got[route], objective)
+				}
+			}
+		})
+	}
+}
+
+func newTestSLOTracker(t *testing.T, objectives string, now *time.Time) (*SLOTracker, *logCapture) {
+	t.Helper()
+	logger, logs := newCaptureLogger()
+	tracker := NewSLOTracker(&Config{
+		SLOEnabled:       true,
+		SLOObjectives:    objectives,
+		SLOShortWindow:   5 * time.Minute,
+		SLOLongWindow:    time.Hour,
+		SLOBurnRateAlert: 14.4,
+	}, logger)
+	tracker.now = func() time.Time { return *now }
+	return tracker, logs
+}
+
+func TestSLOTracker_BurnRates(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker, _ := newTestSLOTracker(t, "*=99:500ms@90,/healthz=99.9", &now)
+
+	// 50 minutes ago: 100 good requests, outside the short window.
+	now = now.Add(-50 * time.Minute)
+	for i := 0; i < 100; i++ {
+		tracker.Record("/league/challenger", 100*time.Millisecond, http.StatusOK)
+	}
+	// Now: 100 requests, 10 failing and 20 slow.
+	now = now.Add(50 * time.Minute)
+	for i := 0; i < 100; i++ {
+		status, duration := http.StatusOK, 100*time.Millisecond
+		if i < 10 {
+			status = http.StatusBadGateway
+		}
+		if i >= 80 {
+			duration = time.Second
+		}
+		tracker.Record("/league/challenger", duration, status)
+	}
+	tracker.Record("/healthz", time.Millisecond, http.StatusNotFound)
+
+	report := tracker.Report()
+	route := report.Routes["/league/challenger"]
+	if route.Availability.Short.Requests != 100 || route.Availability.Long.Requests != 200 {
+		t.Fatalf("requests = %d short, %d long, expected 100 and 200", route.Availability.Short.Requests, route.Availability.Long.Requests)
+	}
+	// 10% errors against a 1% budget burns at 10x; 5% over the hour at 5x.
+	if !approxEqual(route.Availability.Short.BurnRate, 10) || !approxEqual(route.Availability.Long.BurnRate, 5) {
+		t.Errorf("availability burn rates = %v short, %v long, expected 10 and 5", route.Availability.Short.BurnRate, route.Availability.Long.BurnRate)
+	}
+	if !approxEqual(route.Availability.BudgetRemaining, -4) {
+		t.Errorf("budget remaining = %v, expected -4", route.Availability.BudgetRemaining)
+	}
+	if route.Latency == nil || !approxEqual(route.Latency.Short.BurnRate, 2) || route.Latency.Burning {
+		t.Errorf("latency = %+v, expected a 2x burn that is not alerting", route.Latency)
+	}
+	if route.Objective.LatencyThresholdMs != 500 {
+		t.Errorf("objective = %+v", route.Objective)
+	}
+
+	health := report.Routes["/healthz"]
+	if health.Availability.Long.Bad != 0 || health.Latency != nil || health.Objective.Availability != 99.9 {
+		t.Errorf("/healthz = %+v, expected a 404 to count as good and no latency objective", health)
+	}
+
+	// An hour later everything has left both windows.
+	now = now.Add(time.Hour)
+	if got := tracker.Report().Routes["/league/challenger"].Availability.Long.Requests; got != 0 {
+		t.Errorf("requests after the window = %d, expected 0", got)
+	}
+}
+
+func TestSLOTracker_Evaluate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tracker, logs := newTestSLOTracker(t, "/summoner=99", &now)
+
+	for i := 0; i < 40; i++ {
+		status := http.StatusOK
+		if i%2 == 0 {
+			status = http.StatusServiceUnavailable
+		}
+		tracker.Record("/summoner", time.Millisecond, status)
+		tracker.Record("/league/master", time.Millisecond, http.StatusInternalServerError)
+	}
+
+	if err := tracker.Evaluate(context.Background()); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	logs.Expect(t, expectedEvent{Level: LogLevelWarn, Message: "slo_budget_burning", Component: "slo", Operation: "evaluate", Meta: map[string]interface{}{"route": "/summoner", "indicator": "availability"}})
+	if _, tracked := tracker.Report().Routes["/league/master"]; tracked {
+		t.Error("/league/master has no objective and no default, expected it untracked")
+	}
+}
+
+func TestNewSLOTracker_Disabled(t *testing.T) {
+	if NewSLOTracker(&Config{SLOObjectives: "*=99"}, newTestLogger()) != nil {
+		t.Error("NewSLOTracker() with SLO_ENABLED=false expected nil")
+	}
+}
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
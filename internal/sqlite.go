@@ -0,0 +1,414 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+)
+
+const (
+	DatabaseDriverPostgres = "postgres"
+	DatabaseDriverSQLite   = "sqlite"
+)
+
+// sqliteDriverName is the database/sql driver registered by
+// modernc.org/sqlite, linked in only when building with -tags sqlite.
+const sqliteDriverName = "sqlite"
+
+func sqliteDriverLinked() bool {
+	return slices.Contains(sql.Drivers(), sqliteDriverName)
+}
+
+var postgresPlaceholder = regexp.MustCompile(`\$(\d+)`)
+
+// sqliteMigrations mirror the Postgres migrations version for version so
+// SchemaVersion means the same thing on both backends.
+var sqliteMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create_summoner_cache",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS summoner_cache (
+				puuid        TEXT      PRIMARY KEY,
+				game_name    TEXT      NOT NULL,
+				tag_line     TEXT      NOT NULL,
+				summoner_id  TEXT,
+				region       TEXT      NOT NULL,
+				last_updated TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 2,
+		Name:    "create_crawl_pages",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS crawl_pages (
+				region        TEXT      NOT NULL,
+				tier          TEXT      NOT NULL,
+				division      TEXT      NOT NULL,
+				page          INTEGER   NOT NULL,
+				entries_count INTEGER   NOT NULL,
+				has_more      BOOLEAN   NOT NULL,
+				crawled_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (region, tier, division, page)
+			)`,
+	},
+	{
+		Version: 3,
+		Name:    "create_riot_id_lookups",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS riot_id_lookups (
+				normalized_key TEXT      PRIMARY KEY,
+				puuid          TEXT      NOT NULL,
+				game_name      TEXT      NOT NULL,
+				tag_line       TEXT      NOT NULL,
+				updated_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 4,
+		Name:    "create_riot_request_journal",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS riot_request_journal (
+				id                      INTEGER   PRIMARY KEY AUTOINCREMENT,
+				method                  TEXT      NOT NULL,
+				url                     TEXT      NOT NULL,
+				status_code             INTEGER   NOT NULL,
+				latency_ms              INTEGER   NOT NULL,
+				app_rate_limit          TEXT,
+				app_rate_limit_count    TEXT,
+				method_rate_limit       TEXT,
+				method_rate_limit_count TEXT,
+				retry_after             TEXT,
+				requested_at            TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_riot_request_journal_method_time
+				ON riot_request_journal (method, requested_at)`,
+	},
+	{
+		Version: 5,
+		Name:    "create_watchlist",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS watchlist (
+				puuid             TEXT      PRIMARY KEY,
+				region            TEXT      NOT NULL,
+				tier              TEXT      NOT NULL DEFAULT '',
+				rank              TEXT      NOT NULL DEFAULT '',
+				league_points     INTEGER   NOT NULL DEFAULT 0,
+				wins              INTEGER   NOT NULL DEFAULT 0,
+				losses            INTEGER   NOT NULL DEFAULT 0,
+				last_match_id     TEXT      NOT NULL DEFAULT '',
+				added_at          TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				last_refreshed_at TIMESTAMP
+			)`,
+	},
+	{
+		Version: 6,
+		Name:    "create_matches",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS matches (
+				match_id      TEXT      PRIMARY KEY,
+				set_number    INTEGER   NOT NULL DEFAULT 0,
+				game_version  TEXT      NOT NULL DEFAULT '',
+				played_at     TIMESTAMP NOT NULL,
+				data          TEXT      NOT NULL,
+				archived_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_matches_played_at ON matches (played_at DESC)`,
+	},
+	{
+		Version: 7,
+		Name:    "create_api_keys",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS api_keys (
+				id          INTEGER   PRIMARY KEY AUTOINCREMENT,
+				name        TEXT      NOT NULL,
+				role        TEXT      NOT NULL,
+				key_hash    TEXT      NOT NULL UNIQUE,
+				prefix      TEXT      NOT NULL,
+				created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				revoked_at  TIMESTAMP
+			)`,
+	},
+	{
+		Version: 8,
+		Name:    "create_match_participants",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS match_participants (
+				puuid      TEXT      NOT NULL,
+				match_id   TEXT      NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+				placement  INTEGER   NOT NULL,
+				played_at  TIMESTAMP NOT NULL,
+				PRIMARY KEY (puuid, match_id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_match_participants_puuid_played_at ON match_participants (puuid, played_at DESC);
+			CREATE INDEX IF NOT EXISTS idx_match_participants_match_id ON match_participants (match_id);
+			INSERT OR IGNORE INTO match_participants (puuid, match_id, placement, played_at)
+			SELECT json_extract(p.value, '$.puuid'), m.match_id, json_extract(p.value, '$.placement'), m.played_at
+			FROM matches m, json_each(m.data, '$.info.participants') AS p
+			WHERE COALESCE(json_extract(p.value, '$.placement'), 0) > 0`,
+	},
+	{
+		Version: 9,
+		Name:    "create_event_outbox",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS event_outbox (
+				id           INTEGER   PRIMARY KEY AUTOINCREMENT,
+				subject      TEXT      NOT NULL,
+				payload      TEXT      NOT NULL,
+				attempts     INTEGER   NOT NULL DEFAULT 0,
+				last_error   TEXT,
+				created_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				published_at TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_event_outbox_pending ON event_outbox (id) WHERE published_at IS NULL`,
+	},
+	{
+		Version: 10,
+		Name:    "add_watchlist_mini_series",
+		SQL:     `ALTER TABLE watchlist ADD COLUMN mini_series TEXT NOT NULL DEFAULT ''`,
+	},
+	{
+		Version: 11,
+		Name:    "create_summoner_profile_history",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS summoner_profile_history (
+				id              INTEGER   PRIMARY KEY AUTOINCREMENT,
+				puuid           TEXT      NOT NULL,
+				region          TEXT      NOT NULL,
+				profile_icon_id INTEGER   NOT NULL,
+				summoner_level  INTEGER   NOT NULL,
+				observed_at     TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_summoner_profile_history_puuid ON summoner_profile_history (puuid, id DESC)`,
+	},
+	{
+		Version: 12,
+		Name:    "create_player_verifications",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS player_verifications (
+				key_id          TEXT      NOT NULL,
+				puuid           TEXT      NOT NULL,
+				region          TEXT      NOT NULL,
+				code            TEXT      NOT NULL,
+				profile_icon_id INTEGER   NOT NULL,
+				status          TEXT      NOT NULL DEFAULT 'pending',
+				created_at      TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				expires_at      TIMESTAMP NOT NULL,
+				verified_at     TIMESTAMP,
+				PRIMARY KEY (key_id, puuid)
+			)`,
+	},
+	{
+		Version: 13,
+		Name:    "create_watchlist_subscriptions",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS watchlist_subscriptions (
+				tenant_id TEXT      NOT NULL,
+				puuid     TEXT      NOT NULL REFERENCES watchlist (puuid) ON DELETE CASCADE,
+				added_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				PRIMARY KEY (tenant_id, puuid)
+			);
+			CREATE INDEX IF NOT EXISTS idx_watchlist_subscriptions_puuid ON watchlist_subscriptions (puuid);
+			INSERT OR IGNORE INTO watchlist_subscriptions (tenant_id, puuid, added_at)
+			SELECT 'anonymous', puuid, added_at FROM watchlist`,
+	},
+	{
+		Version: 14,
+		Name:    "create_match_ingest_cursors",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS match_ingest_cursors (
+				puuid         TEXT      PRIMARY KEY,
+				last_match_id TEXT      NOT NULL,
+				updated_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 15,
+		Name:    "create_watchlist_imports",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS watchlist_import_jobs (
+				id          INTEGER   PRIMARY KEY AUTOINCREMENT,
+				tenant_id   TEXT      NOT NULL,
+				status      TEXT      NOT NULL,
+				total       INTEGER   NOT NULL,
+				created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				finished_at TIMESTAMP
+			);
+			CREATE TABLE IF NOT EXISTS watchlist_import_rows (
+				job_id     INTEGER NOT NULL REFERENCES watchlist_import_jobs (id) ON DELETE CASCADE,
+				row_number INTEGER NOT NULL,
+				riot_id    TEXT    NOT NULL,
+				status     TEXT    NOT NULL,
+				puuid      TEXT,
+				error      TEXT,
+				PRIMARY KEY (job_id, row_number)
+			)`,
+	},
+	{
+		Version: 16,
+		Name:    "create_league_snapshots",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS league_snapshots (
+				id       INTEGER   PRIMARY KEY AUTOINCREMENT,
+				region   TEXT      NOT NULL,
+				tier     TEXT      NOT NULL,
+				taken_at TIMESTAMP NOT NULL,
+				entries  TEXT      NOT NULL
+			);
+			CREATE INDEX IF NOT EXISTS idx_league_snapshots_lookup ON league_snapshots (region, tier, taken_at DESC)`,
+	},
+	{
+		Version: 17,
+		Name:    "create_league_points_history",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS league_points_history (
+				id            INTEGER   PRIMARY KEY AUTOINCREMENT,
+				puuid         TEXT      NOT NULL,
+				region        TEXT      NOT NULL,
+				tier          TEXT      NOT NULL,
+				rank          TEXT      NOT NULL,
+				league_points INTEGER   NOT NULL,
+				wins          INTEGER   NOT NULL,
+				losses        INTEGER   NOT NULL,
+				observed_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_league_points_history_puuid ON league_points_history (puuid, id DESC)`,
+	},
+	{
+		Version: 18,
+		Name:    "create_league_last_known_good",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS league_last_known_good (
+				region     TEXT      NOT NULL,
+				tier       TEXT      NOT NULL,
+				league     TEXT      NOT NULL,
+				fetched_at TIMESTAMP NOT NULL,
+				PRIMARY KEY (region, tier)
+			)`,
+	},
+	{
+		Version: 19,
+		Name:    "index_summoner_cache_last_updated",
+		SQL:     `CREATE INDEX IF NOT EXISTS idx_summoner_cache_updated ON summoner_cache (last_updated)`,
+	},
+	{
+		Version: 20,
+		Name:    "create_match_participant_comps",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS match_participant_comps (
+				kind       TEXT      NOT NULL,
+				name       TEXT      NOT NULL,
+				puuid      TEXT      NOT NULL,
+				match_id   TEXT      NOT NULL REFERENCES matches (match_id) ON DELETE CASCADE,
+				played_at  TIMESTAMP NOT NULL,
+				PRIMARY KEY (kind, name, puuid, match_id)
+			);
+			CREATE INDEX IF NOT EXISTS idx_match_participant_comps_match_id ON match_participant_comps (match_id);
+			INSERT OR IGNORE INTO match_participant_comps (kind, name, puuid, match_id, played_at)
+			SELECT 'trait', json_extract(t.value, '$.name'), json_extract(p.value, '$.puuid'), m.match_id, m.played_at
+			FROM matches m, json_each(m.data, '$.info.participants') AS p, json_each(p.value, '$.traits') AS t
+			WHERE COALESCE(json_extract(p.value, '$.placement'), 0) > 0 AND COALESCE(json_extract(t.value, '$.tier_current'), 0) > 0;
+			INSERT OR IGNORE INTO match_participant_comps (kind, name, puuid, match_id, played_at)
+			SELECT 'unit', json_extract(u.value, '$.character_id'), json_extract(p.value, '$.puuid'), m.match_id, m.played_at
+			FROM matches m, json_each(m.data, '$.info.participants') AS p, json_each(p.value, '$.units') AS u
+			WHERE COALESCE(json_extract(p.value, '$.placement'), 0) > 0 AND COALESCE(json_extract(u.value, '$.character_id'), '') <> ''`,
+	},
+	{
+		Version: 21,
+		Name:    "create_scheduled_jobs",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS scheduled_jobs (
+				name         TEXT         NOT NULL PRIMARY KEY,
+				next_run_at  TIMESTAMP    NOT NULL,
+				last_run_at  TIMESTAMP,
+				last_error   TEXT,
+				updated_at   TIMESTAMP    NOT NULL DEFAULT CURRENT_TIMESTAMP
+			)`,
+	},
+	{
+		Version: 22,
+		Name:    "create_dead_letter_tasks",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS dead_letter_tasks (
+				id          INTEGER   PRIMARY KEY AUTOINCREMENT,
+				subject     TEXT      NOT NULL,
+				worker      TEXT      NOT NULL,
+				payload     TEXT      NOT NULL,
+				attempts    INTEGER   NOT NULL,
+				last_error  TEXT      NOT NULL,
+				failed_at   TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+				requeued_at TIMESTAMP
+			);
+			CREATE INDEX IF NOT EXISTS idx_dead_letter_tasks_pending ON dead_letter_tasks (id) WHERE requeued_at IS NULL`,
+	},
+	{
+		Version: 23,
+		Name:    "create_cache_snapshots",
+		SQL: `
+			CREATE TABLE IF NOT EXISTS cache_snapshots (
+				cache_key  TEXT      NOT NULL PRIMARY KEY,
+				value      TEXT      NOT NULL,
+				fetched_at TIMESTAMP NOT NULL
+			)`,
+	},
+	{
+		Version: 24,
+		Name:    "add_watchlist_import_claims",
+		SQL:     `ALTER TABLE watchlist_import_jobs ADD COLUMN claimed_until TIMESTAMP`,
+	},
+	{
+		Version: 25,
+		Name:    "index_cache_snapshots_fetched_at",
+		SQL:     `CREATE INDEX IF NOT EXISTS idx_cache_snapshots_fetched_at ON cache_snapshots (fetched_at)`,
+	},
+}
+
+func connectSQLite(cfg *Config, readOnly bool) (*DatabaseManager, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", cfg.SQLitePath)
+	if readOnly {
+		dsn += "&_pragma=query_only(1)"
+	}
+
+	db, err := sql.Open(sqliteDriverName, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open sqlite (build with -tags sqlite): %w", err)
+	}
+
+	// SQLite allows a single writer; one connection avoids SQLITE_BUSY under
+	// concurrent handlers.
+	db.SetMaxOpenConns(1)
+
+	if err := db.PingContext(context.Background()); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Printf("SQLite database opened at %s", cfg.SQLitePath)
+	return &DatabaseManager{
+		DB:             db,
+		Enabled:        true,
+		Dialect:        DatabaseDriverSQLite,
+		ReadOnly:       readOnly,
+		watchlistQuota: cfg.WatchlistMaxPlayers,
+	}, nil
+}
+
+// rebind rewrites Postgres $N placeholders into SQLite's ?N form, which binds
+// by the same ordinal and may be repeated.
+func (dm *DatabaseManager) rebind(query string) string {
+	if dm.Dialect != DatabaseDriverSQLite {
+		return query
+	}
+	return postgresPlaceholder.ReplaceAllString(query, "?$1")
+}
+
+func (dm *DatabaseManager) migrations() []Migration {
+	if dm.Dialect == DatabaseDriverSQLite {
+		return sqliteMigrations
+	}
+	return migrations
+}
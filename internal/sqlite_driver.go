@@ -0,0 +1,5 @@
+//go:build sqlite
+
+package internal
+
+import _ "modernc.org/sqlite"
@@ -0,0 +1,183 @@
+package internal
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newSQLiteTestDB opens a migrated database in a temporary directory; tests
+// that need one skip unless built with -tags sqlite.
+func newSQLiteTestDB(t *testing.T) *DatabaseManager {
+	t.Helper()
+	if !sqliteDriverLinked() {
+		t.Skip("needs -tags sqlite")
+	}
+
+	dm, err := ConnectDatabase(&Config{
+		DatabaseDriver:      DatabaseDriverSQLite,
+		SQLitePath:          filepath.Join(t.TempDir(), "test.db"),
+		WatchlistMaxPlayers: 50,
+	})
+	if err != nil {
+		t.Fatalf("ConnectDatabase() error = %v", err)
+	}
+	t.Cleanup(func() { dm.DB.Close() })
+	if err := dm.Migrate(t.Context()); err != nil {
+		t.Fatalf("Migrate() error = %v", err)
+	}
+	return dm
+}
+
+func TestDatabaseManager_Rebind(t *testing.T) {
+	query := `INSERT INTO t (a, b) VALUES ($1, $2) ON CONFLICT (a) DO UPDATE SET b = $2, c = $10`
+
+	tests := []struct {
+		name     string
+		dialect  string
+		expected string
+	}{
+		{
+			name:     "postgres keeps placeholders",
+			dialect:  DatabaseDriverPostgres,
+			expected: query,
+		},
+		{
+			name:     "sqlite uses numbered question marks",
+			dialect:  DatabaseDriverSQLite,
+			expected: `INSERT INTO t (a, b) VALUES (?1, ?2) ON CONFLICT (a) DO UPDATE SET b = ?2, c = ?10`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dm := &DatabaseManager{Dialect: tt.dialect}
+			if result := dm.rebind(query); result != tt.expected {
+				t.Errorf("rebind() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSQLiteMigrationsMatchPostgres(t *testing.T) {
+	if len(sqliteMigrations) != len(migrations) {
+		t.Fatalf("sqlite has %d migrations, postgres has %d", len(sqliteMigrations), len(migrations))
+	}
+	for i := range migrations {
+		if sqliteMigrations[i].Version != migrations[i].Version || sqliteMigrations[i].Name != migrations[i].Name {
+			t.Errorf("migration %d: sqlite %d/%s, postgres %d/%s", i,
+				sqliteMigrations[i].Version, sqliteMigrations[i].Name, migrations[i].Version, migrations[i].Name)
+		}
+	}
+}
+
+func TestConfig_ValidateSQLiteWithoutPostgres(t *testing.T) {
+	if !sqliteDriverLinked() {
+		t.Skip("sqlite driver not linked in; build with -tags sqlite")
+	}
+	cfg := Config{
+		RiotAPIKey:      "test-key",
+		RiotBaseURL:     "https://test.api.com",
+		DatabaseEnabled: true,
+		DatabaseDriver:  DatabaseDriverSQLite,
+	}
+	if err := cfg.validate(); err != nil {
+		t.Errorf("validate() error = %v, expected sqlite to need no postgres credentials", err)
+	}
+
+	cfg.DatabaseDriver = "mysql"
+	if err := cfg.validate(); err == nil {
+		t.Error("validate() expected error for unknown driver")
+	}
+}
+
+func TestSchemaCompatibility(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		expected int
+		mismatch bool
+	}{
+		{name: "matching", current: 8, expected: 8},
+		{name: "database behind", current: 7, expected: 8, mismatch: true},
+		{name: "database ahead", current: 9, expected: 8, mismatch: true},
+		{name: "no migrations table", current: 0, expected: 8, mismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schemaCompatibility(tt.current, tt.expected)
+			if got := errors.Is(err, ErrSchemaMismatch); got != tt.mismatch {
+				t.Errorf("schemaCompatibility(%d, %d) = %v, expected mismatch=%v", tt.current, tt.expected, err, tt.mismatch)
+			}
+		})
+	}
+}
+
+func TestAddToWatchlist_ConcurrentAddsRespectQuota(t *testing.T) {
+	dm := newSQLiteTestDB(t)
+	dm.watchlistQuota = 5
+
+	const adds = 20
+	errs := make(chan error, adds)
+	var wg sync.WaitGroup
+	for i := 0; i < adds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- dm.AddToWatchlist(t.Context(), "tenant-a", "puuid-"+strconv.Itoa(i), "BR1")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	added, rejected := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			added++
+		case errors.Is(err, ErrWatchlistQuotaExceeded):
+			rejected++
+		default:
+			t.Fatalf("AddToWatchlist() error = %v", err)
+		}
+	}
+	if added != dm.watchlistQuota || rejected != adds-dm.watchlistQuota {
+		t.Errorf("added %d and rejected %d, expected exactly the quota of %d to be added", added, rejected, dm.watchlistQuota)
+	}
+
+	var watched int
+	if err := dm.DB.QueryRowContext(t.Context(), `SELECT COUNT(*) FROM watchlist_subscriptions WHERE tenant_id = 'tenant-a'`).Scan(&watched); err != nil {
+		t.Fatal(err)
+	}
+	if watched != dm.watchlistQuota {
+		t.Errorf("subscriptions = %d, expected %d", watched, dm.watchlistQuota)
+	}
+	if watchlistLockKey("tenant-a") == watchlistLockKey("tenant-b") {
+		t.Error("expected tenants to lock independently")
+	}
+}
+
+func TestDeleteStaleCacheSnapshots(t *testing.T) {
+	dm := newSQLiteTestDB(t)
+	now := time.Now().UTC()
+	for i, age := range []time.Duration{time.Hour, 7 * time.Hour, 8 * time.Hour, 9 * time.Hour} {
+		if err := dm.SaveCacheSnapshot(t.Context(), "tft:v1:account_puuid:br1:"+strconv.Itoa(i), []byte(`{}`), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cutoff := now.Add(-cacheSnapshotRetention)
+	if n, err := dm.DeleteStaleCacheSnapshots(t.Context(), cutoff, 2); err != nil || n != 2 {
+		t.Fatalf("first batch = %d, %v; expected 2 deleted", n, err)
+	}
+	if n, err := dm.DeleteStaleCacheSnapshots(t.Context(), cutoff, 2); err != nil || n != 1 {
+		t.Fatalf("second batch = %d, %v; expected the last stale row", n, err)
+	}
+	if _, _, err := dm.LoadCacheSnapshot(t.Context(), "tft:v1:account_puuid:br1:0"); err != nil {
+		t.Errorf("fresh snapshot was deleted: %v", err)
+	}
+}
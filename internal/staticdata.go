@@ -0,0 +1,304 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type StaticUnit struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Set    int      `json:"set"`
+	Cost   int      `json:"cost"`
+	Icon   string   `json:"icon"`
+	Traits []string `json:"traits,omitempty"`
+}
+
+type StaticTrait struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Set  int    `json:"set"`
+	Icon string `json:"icon"`
+}
+
+type StaticItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Icon string `json:"icon"`
+}
+
+type staticDataSnapshot struct {
+	Version  string
+	Units    []StaticUnit
+	Traits   []StaticTrait
+	Items    []StaticItem
+	Latest   int
+	LoadedAt time.Time
+}
+
+type ddragonImage struct {
+	Full string `json:"full"`
+}
+
+type ddragonEntry struct {
+	ID     string       `json:"id"`
+	Name   string       `json:"name"`
+	Tier   int          `json:"tier"`
+	Traits []string     `json:"traits"`
+	Image  ddragonImage `json:"image"`
+}
+
+type ddragonFile struct {
+	Data map[string]ddragonEntry `json:"data"`
+}
+
+type StaticDataService struct {
+	baseURL  string
+	locale   string
+	interval time.Duration
+	client   *http.Client
+	logger   *Logger
+
+	snapshot *staticDataSnapshot
+	mu       sync.RWMutex
+	loading  singleflight.Group
+}
+
+var setPattern = regexp.MustCompile(`^TFT(\d+)`)
+
+func NewStaticDataService(cfg *Config, logger *Logger) *StaticDataService {
+	return &StaticDataService{
+		baseURL:  strings.TrimSuffix(cfg.StaticDataURL, "/"),
+		locale:   cfg.StaticDataLocale,
+		interval: cfg.StaticDataRefreshInterval,
+		logger:   logger,
+		client: &http.Client{
+			Timeout: 15 * time.Second,
+		},
+	}
+}
+
+func (s *StaticDataService) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "static_data_refresh",
+		Interval: s.interval,
+		Run:      s.Refresh,
+	}
+}
+
+func detectSet(id string) int {
+	match := setPattern.FindStringSubmatch(id)
+	if match == nil {
+		return 0
+	}
+	set, _ := strconv.Atoi(match[1])
+	return set
+}
+
+func (s *StaticDataService) Refresh(ctx context.Context) error {
+	version, err := s.latestVersion(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	current := s.snapshot
+	s.mu.RUnlock()
+	if current != nil && current.Version == version {
+		return nil
+	}
+
+	champions, err := s.fetchFile(ctx, version, "tft-champion")
+	if err != nil {
+		return err
+	}
+	traits, err := s.fetchFile(ctx, version, "tft-trait")
+	if err != nil {
+		return err
+	}
+	items, err := s.fetchFile(ctx, version, "tft-item")
+	if err != nil {
+		return err
+	}
+
+	snapshot := &staticDataSnapshot{Version: version, LoadedAt: time.Now().UTC()}
+	for _, entry := range champions.Data {
+		set := detectSet(entry.ID)
+		snapshot.Units = append(snapshot.Units, StaticUnit{
+			ID:     entry.ID,
+			Name:   entry.Name,
+			Set:    set,
+			Cost:   entry.Tier,
+			Icon:   s.iconURL(version, "tft-champion", entry.Image.Full),
+			Traits: entry.Traits,
+		})
+		if set > snapshot.Latest {
+			snapshot.Latest = set
+		}
+	}
+	for _, entry := range traits.Data {
+		snapshot.Traits = append(snapshot.Traits, StaticTrait{
+			ID:   entry.ID,
+			Name: entry.Name,
+			Set:  detectSet(entry.ID),
+			Icon: s.iconURL(version, "tft-trait", entry.Image.Full),
+		})
+	}
+	for _, entry := range items.Data {
+		snapshot.Items = append(snapshot.Items, StaticItem{
+			ID:   entry.ID,
+			Name: entry.Name,
+			Icon: s.iconURL(version, "tft-item", entry.Image.Full),
+		})
+	}
+
+	sort.Slice(snapshot.Units, func(i, j int) bool { return snapshot.Units[i].ID < snapshot.Units[j].ID })
+	sort.Slice(snapshot.Traits, func(i, j int) bool { return snapshot.Traits[i].ID < snapshot.Traits[j].ID })
+	sort.Slice(snapshot.Items, func(i, j int) bool { return snapshot.Items[i].ID < snapshot.Items[j].ID })
+
+	s.mu.Lock()
+	s.snapshot = snapshot
+	s.mu.Unlock()
+
+	s.logger.Info("static_data_loaded").
+		Component("static_data").
+		Operation("refresh").
+		Meta("version", version).
+		Meta("latest_set", snapshot.Latest).
+		Meta("units", len(snapshot.Units)).
+		Meta("traits", len(snapshot.Traits)).
+		Meta("items", len(snapshot.Items)).
+		Log()
+
+	return nil
+}
+
+func (s *StaticDataService) latestVersion(ctx context.Context) (string, error) {
+	var versions []string
+	if err := s.getJSON(ctx, s.baseURL+"/api/versions.json", &versions); err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("static data: no versions available")
+	}
+	return versions[0], nil
+}
+
+func (s *StaticDataService) fetchFile(ctx context.Context, version, name string) (*ddragonFile, error) {
+	var file ddragonFile
+	url := fmt.Sprintf("%s/cdn/%s/data/%s/%s.json", s.baseURL, version, s.locale, name)
+	if err := s.getJSON(ctx, url, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (s *StaticDataService) getJSON(ctx context.Context, url string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("static data error: %s - %s", resp.Status, string(body))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (s *StaticDataService) iconURL(version, group, file string) string {
+	if file == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s/cdn/%s/img/%s/%s", s.baseURL, version, group, file)
+}
+
+func (s *StaticDataService) current(ctx context.Context) (*staticDataSnapshot, error) {
+	s.mu.RLock()
+	snapshot := s.snapshot
+	s.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+
+	// Until the first load succeeds, callers share one refresh instead of each
+	// fetching Data Dragon. It keeps going for the others if the caller that
+	// started it goes away.
+	results := s.loading.DoChan("refresh", func() (interface{}, error) {
+		return nil, s.Refresh(context.WithoutCancel(ctx))
+	})
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snapshot, nil
+}
+
+func (s *StaticDataService) Units(ctx context.Context, set int) (string, int, []StaticUnit, error) {
+	snapshot, err := s.current(ctx)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if set == 0 {
+		set = snapshot.Latest
+	}
+
+	units := []StaticUnit{}
+	for _, unit := range snapshot.Units {
+		if unit.Set == set {
+			units = append(units, unit)
+		}
+	}
+	return snapshot.Version, set, units, nil
+}
+
+func (s *StaticDataService) Traits(ctx context.Context, set int) (string, int, []StaticTrait, error) {
+	snapshot, err := s.current(ctx)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if set == 0 {
+		set = snapshot.Latest
+	}
+
+	traits := []StaticTrait{}
+	for _, trait := range snapshot.Traits {
+		if trait.Set == set {
+			traits = append(traits, trait)
+		}
+	}
+	return snapshot.Version, set, traits, nil
+}
+
+func (s *StaticDataService) Items(ctx context.Context) (string, []StaticItem, error) {
+	snapshot, err := s.current(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	return snapshot.Version, snapshot.Items, nil
+}
@@ -0,0 +1,139 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDetectSet(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected int
+	}{
+		{name: "single digit set", id: "TFT9_Ahri", expected: 9},
+		{name: "double digit set", id: "TFT13_Jinx", expected: 13},
+		{name: "mid-set suffix", id: "TFT9b_Ryze", expected: 9},
+		{name: "trait id", id: "TFT13_Sniper", expected: 13},
+		{name: "unknown prefix", id: "Set13_Ahri", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := detectSet(tt.id)
+			if result != tt.expected {
+				t.Errorf("detectSet() = %v, expected %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStaticDataService_Refresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/versions.json":
+			w.Write([]byte(`["14.1.1","14.0.1"]`))
+		case "/cdn/14.1.1/data/en_US/tft-champion.json":
+			w.Write([]byte(`{"data":{
+				"a":{"id":"TFT12_Ahri","name":"Ahri","tier":4,"image":{"full":"ahri12.png"}},
+				"b":{"id":"TFT13_Jinx","name":"Jinx","tier":5,"image":{"full":"jinx.png"}},
+				"c":{"id":"TFT13_Vi","name":"Vi","tier":1,"image":{"full":"vi.png"}}
+			}}`))
+		case "/cdn/14.1.1/data/en_US/tft-trait.json":
+			w.Write([]byte(`{"data":{"a":{"id":"TFT13_Sniper","name":"Sniper","image":{"full":"sniper.png"}}}}`))
+		case "/cdn/14.1.1/data/en_US/tft-item.json":
+			w.Write([]byte(`{"data":{"a":{"id":"TFT_Item_BFSword","name":"B.F. Sword","image":{"full":"bf.png"}}}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	service := NewStaticDataService(&Config{
+		StaticDataURL:             server.URL,
+		StaticDataLocale:          "en_US",
+		StaticDataRefreshInterval: time.Hour,
+	}, newTestLogger())
+
+	version, set, units, err := service.Units(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("Units() error = %v", err)
+	}
+	if version != "14.1.1" {
+		t.Errorf("version = %v, expected %v", version, "14.1.1")
+	}
+	if set != 13 {
+		t.Errorf("set = %v, expected %v", set, 13)
+	}
+	if len(units) != 2 {
+		t.Errorf("units = %d, expected %d", len(units), 2)
+	}
+
+	_, _, units, _ = service.Units(context.Background(), 12)
+	if len(units) != 1 || units[0].Icon != server.URL+"/cdn/14.1.1/img/tft-champion/ahri12.png" {
+		t.Errorf("set 12 units = %+v, expected Ahri with icon URL", units)
+	}
+
+	_, _, traits, _ := service.Traits(context.Background(), 0)
+	if len(traits) != 1 || traits[0].Name != "Sniper" {
+		t.Errorf("traits = %+v, expected Sniper", traits)
+	}
+}
+
+func TestStaticDataService_SharesFirstLoad(t *testing.T) {
+	var versionCalls atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/versions.json":
+			versionCalls.Add(1)
+			<-release
+			w.Write([]byte(`["14.1.1"]`))
+		default:
+			w.Write([]byte(`{"data":{}}`))
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	service := NewStaticDataService(&Config{StaticDataURL: server.URL, StaticDataLocale: "en_US"}, newTestLogger())
+
+	// A caller that gives up does not fail the refresh for the others.
+	canceled, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, _, _, err := service.Units(canceled, 0)
+		first <- err
+	}()
+	for versionCalls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	const callers = 10
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			_, _, err := service.Items(context.Background())
+			errs <- err
+		}()
+	}
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Errorf("canceled caller error = %v, expected context.Canceled", err)
+	}
+
+	release <- struct{}{}
+	for i := 0; i < callers; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Items() error = %v", err)
+		}
+	}
+	if calls := versionCalls.Load(); calls != 1 {
+		t.Errorf("versions fetched %d times, expected one shared load", calls)
+	}
+}
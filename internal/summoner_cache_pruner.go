@@ -0,0 +1,159 @@
+package internal
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// SummonerCachePruneStats is reported by /admin/db/stats.
+type SummonerCachePruneStats struct {
+	Retention    string     `json:"retention"`
+	BatchSize    int        `json:"batchSize"`
+	MaxBatches   int        `json:"maxBatches"`
+	Runs         int        `json:"runs"`
+	TotalDeleted int64      `json:"totalDeleted"`
+	LastRunAt    *time.Time `json:"lastRunAt,omitempty"`
+	LastDeleted  int64      `json:"lastDeleted"`
+	LastBatches  int        `json:"lastBatches"`
+	LastDuration string     `json:"lastDuration,omitempty"`
+	LastError    string     `json:"lastError,omitempty"`
+	// LastSnapshotsDeleted counts the cache_snapshots rows past
+	// cacheSnapshotRetention deleted by the last run.
+	LastSnapshotsDeleted int64 `json:"lastSnapshotsDeleted"`
+	// Backlog is true when the last run stopped at MaxBatches with stale rows
+	// left behind.
+	Backlog bool `json:"backlog"`
+}
+
+// SummonerCachePruner deletes summoner_cache rows not refreshed within the
+// retention. Each batch is its own short statement so autovacuum can reclaim
+// the dead tuples between runs instead of after one long delete, and a run
+// stops after maxBatches to bound the load it puts on the primary. The same
+// run then prunes cache_snapshots rows too old for Restore to serve.
+type SummonerCachePruner struct {
+	logger     *Logger
+	interval   time.Duration
+	retention  time.Duration
+	batchSize  int
+	maxBatches int
+
+	deleteBatch     func(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+	deleteSnapshots func(ctx context.Context, cutoff time.Time, limit int) (int64, error)
+
+	mu    sync.Mutex
+	stats SummonerCachePruneStats
+}
+
+func NewSummonerCachePruner(cfg *Config, db *DatabaseManager, logger *Logger) *SummonerCachePruner {
+	batchSize := cfg.SummonerCachePruneBatchSize
+	if batchSize < 1 {
+		batchSize = 1000
+	}
+	maxBatches := cfg.SummonerCachePruneMaxBatches
+	if maxBatches < 1 {
+		maxBatches = 50
+	}
+
+	return &SummonerCachePruner{
+		logger:          logger,
+		interval:        cfg.SummonerCachePruneInterval,
+		retention:       cfg.SummonerCacheRetention,
+		batchSize:       batchSize,
+		maxBatches:      maxBatches,
+		deleteBatch:     db.DeleteStaleSummonerCache,
+		deleteSnapshots: db.DeleteStaleCacheSnapshots,
+		stats: SummonerCachePruneStats{
+			Retention:  cfg.SummonerCacheRetention.String(),
+			BatchSize:  batchSize,
+			MaxBatches: maxBatches,
+		},
+	}
+}
+
+func (sp *SummonerCachePruner) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "summoner_cache_prune",
+		Interval: sp.interval,
+		Run:      sp.Run,
+	}
+}
+
+func (sp *SummonerCachePruner) Stats() SummonerCachePruneStats {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.stats
+}
+
+func (sp *SummonerCachePruner) cutoff(now time.Time) time.Time {
+	return now.UTC().Add(-sp.retention)
+}
+
+func (sp *SummonerCachePruner) Run(ctx context.Context) error {
+	start := time.Now()
+	cutoff := sp.cutoff(start)
+
+	deleted, batches, backlog, err := sp.prune(ctx, sp.deleteBatch, cutoff)
+	var snapshotsDeleted int64
+	if err == nil {
+		snapshotsDeleted, _, _, err = sp.prune(ctx, sp.deleteSnapshots, start.UTC().Add(-cacheSnapshotRetention))
+	}
+	duration := time.Since(start)
+
+	sp.mu.Lock()
+	sp.stats.Runs++
+	sp.stats.TotalDeleted += deleted
+	sp.stats.LastRunAt = &start
+	sp.stats.LastDeleted = deleted
+	sp.stats.LastBatches = batches
+	sp.stats.LastDuration = duration.String()
+	sp.stats.LastError = ""
+	if err != nil {
+		sp.stats.LastError = err.Error()
+	}
+	sp.stats.Backlog = backlog
+	sp.stats.LastSnapshotsDeleted = snapshotsDeleted
+	sp.mu.Unlock()
+
+	if err != nil {
+		sp.logger.Warn("summoner_cache_prune_failed").
+			Component("summoner_cache_pruner").
+			Operation("prune").
+			Meta("deleted", deleted).
+			Meta("batches", batches).
+			Meta("snapshots_deleted", snapshotsDeleted).
+			Err(err).
+			Log()
+		return err
+	}
+
+	sp.logger.Info("summoner_cache_prune_completed").
+		Component("summoner_cache_pruner").
+		Operation("prune").
+		Meta("deleted", deleted).
+		Meta("batches", batches).
+		Meta("cutoff", cutoff.Format(time.RFC3339)).
+		Meta("backlog", backlog).
+		Meta("snapshots_deleted", snapshotsDeleted).
+		Duration(duration).
+		Log()
+	return nil
+}
+
+// prune runs deleteBatch until a batch comes back short or maxBatches is
+// reached; backlog reports stopping at the cap with rows possibly left.
+func (sp *SummonerCachePruner) prune(ctx context.Context, deleteBatch func(context.Context, time.Time, int) (int64, error), cutoff time.Time) (deleted int64, batches int, backlog bool, err error) {
+	for batches < sp.maxBatches {
+		var n int64
+		if n, err = deleteBatch(ctx, cutoff, sp.batchSize); err != nil {
+			break
+		}
+		batches++
+		deleted += n
+		if n < int64(sp.batchSize) {
+			break
+		}
+		backlog = batches == sp.maxBatches
+	}
+	return deleted, batches, backlog, err
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestSummonerCachePruner deletes from a pool of stale rows instead of the
+// database and records the limits it was called with.
+func newTestSummonerCachePruner(stale int64, failAfter int) (*SummonerCachePruner, *[]int) {
+	cfg := &Config{
+		SummonerCacheRetention:       30 * 24 * time.Hour,
+		SummonerCachePruneBatchSize:  10,
+		SummonerCachePruneMaxBatches: 3,
+	}
+	sp := NewSummonerCachePruner(cfg, &DatabaseManager{}, newTestLogger())
+
+	var calls []int
+	sp.deleteBatch = func(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+		if time.Since(cutoff) < cfg.SummonerCacheRetention {
+			return 0, errors.New("cutoff newer than the retention")
+		}
+		if failAfter >= 0 && len(calls) == failAfter {
+			return 0, errors.New("statement timeout")
+		}
+		calls = append(calls, limit)
+		n := min(stale, int64(limit))
+		stale -= n
+		return n, nil
+	}
+	return sp, &calls
+}
+
+func TestSummonerCachePruner_Run(t *testing.T) {
+	tests := []struct {
+		name      string
+		stale     int64
+		failAfter int
+		deleted   int64
+		batches   int
+		backlog   bool
+		expectErr bool
+	}{
+		{name: "nothing stale", stale: 0, failAfter: -1, deleted: 0, batches: 1},
+		{name: "partial last batch", stale: 25, failAfter: -1, deleted: 25, batches: 3},
+		{name: "stops at max batches", stale: 45, failAfter: -1, deleted: 30, batches: 3, backlog: true},
+		{name: "exact multiple", stale: 20, failAfter: -1, deleted: 20, batches: 3},
+		{name: "failure keeps earlier batches", stale: 45, failAfter: 1, deleted: 10, batches: 1, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sp, calls := newTestSummonerCachePruner(tt.stale, tt.failAfter)
+
+			err := sp.Run(t.Context())
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Run() error = %v, expectErr %v", err, tt.expectErr)
+			}
+			for _, limit := range *calls {
+				if limit != 10 {
+					t.Errorf("batch limit = %d, expected 10", limit)
+				}
+			}
+
+			stats := sp.Stats()
+			if stats.LastDeleted != tt.deleted || stats.LastBatches != tt.batches || stats.Backlog != tt.backlog {
+				t.Errorf("stats = %+v, expected %d deleted in %d batches, backlog %v", stats, tt.deleted, tt.batches, tt.backlog)
+			}
+			if stats.Runs != 1 || stats.LastRunAt == nil || (stats.LastError != "") != tt.expectErr {
+				t.Errorf("stats = %+v, expected one recorded run", stats)
+			}
+		})
+	}
+}
+
+func TestSummonerCachePruner_Logs(t *testing.T) {
+	sp, _ := newTestSummonerCachePruner(15, -1)
+	logger, logs := newCaptureLogger()
+	sp.logger = logger
+
+	if err := sp.Run(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sp.Run(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+
+	logs.Expect(t, expectedEvent{
+		Level:     LogLevelInfo,
+		Message:   "summoner_cache_prune_completed",
+		Component: "summoner_cache_pruner",
+		Operation: "prune",
+		Meta:      map[string]interface{}{"deleted": 15, "batches": 2},
+	})
+	if stats := sp.Stats(); stats.Runs != 2 || stats.TotalDeleted != 15 || stats.LastDeleted != 0 {
+		t.Errorf("stats = %+v, expected totals across both runs", stats)
+	}
+}
+
+func TestSummonerCachePruner_PrunesCacheSnapshots(t *testing.T) {
+	sp, _ := newTestSummonerCachePruner(0, -1)
+	stale := int64(12)
+	sp.deleteSnapshots = func(ctx context.Context, cutoff time.Time, limit int) (int64, error) {
+		if age := time.Since(cutoff); age < cacheSnapshotRetention || age > cacheSnapshotRetention+time.Minute {
+			return 0, errors.New("cutoff does not match the snapshot retention")
+		}
+		n := min(stale, int64(limit))
+		stale -= n
+		return n, nil
+	}
+
+	if err := sp.Run(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if stats := sp.Stats(); stats.LastSnapshotsDeleted != 12 || stats.LastDeleted != 0 {
+		t.Errorf("stats = %+v, expected 12 snapshots and no summoner rows deleted", stats)
+	}
+}
+
+func TestDatabaseStatsHandler_DatabaseDisabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	DatabaseStatsHandler(&DatabaseManager{}, nil, newTestLogger())(rec, httptest.NewRequest(http.MethodGet, "/admin/db/stats", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, expected 503", rec.Code)
+	}
+}
@@ -0,0 +1,224 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeoutMiddleware bounds how long a route may run. Handlers see the
+// deadline through r.Context(), so Riot, Redis and database calls made with
+// that context are cancelled; if the handler still has not answered the
+// client gets a 504 and anything written afterwards is discarded.
+type TimeoutMiddleware struct {
+	defaultTimeout time.Duration
+	routes         map[string]time.Duration
+	logger         *Logger
+	metrics        *MetricsCollector
+}
+
+func NewTimeoutMiddleware(cfg *Config, logger *Logger, metrics *MetricsCollector) *TimeoutMiddleware {
+	routes, err := parseRouteTimeouts(cfg.RequestTimeouts)
+	if err != nil {
+		logger.Error("request_timeouts_invalid").
+			Component("timeout").
+			Operation("init").
+			Err(err).
+			Meta("value", cfg.RequestTimeouts).
+			Log()
+		routes = map[string]time.Duration{}
+	}
+	if _, set := routes[LeagueEntriesStreamPath]; !set {
+		routes[LeagueEntriesStreamPath] = 0
+	}
+
+	return &TimeoutMiddleware{
+		defaultTimeout: cfg.RequestTimeout,
+		routes:         routes,
+		logger:         logger,
+		metrics:        metrics,
+	}
+}
+
+func parseRouteTimeouts(value string) (map[string]time.Duration, error) {
+	routes := make(map[string]time.Duration)
+	if strings.TrimSpace(value) == "" {
+		return routes, nil
+	}
+
+	for _, rule := range strings.Split(value, ",") {
+		path, spec, found := strings.Cut(strings.TrimSpace(rule), "=")
+		if !found || !strings.HasPrefix(path, "/") {
+			return nil, fmt.Errorf("invalid request timeout rule %q", rule)
+		}
+		timeout, err := time.ParseDuration(spec)
+		if err != nil || timeout < 0 {
+			return nil, fmt.Errorf("invalid timeout %q for route %s", spec, path)
+		}
+		routes[path] = timeout
+	}
+
+	return routes, nil
+}
+
+// TimeoutFor returns the timeout for a path; zero disables the deadline.
+func (tm *TimeoutMiddleware) TimeoutFor(path string) time.Duration {
+	if timeout, exists := tm.routes[path]; exists {
+		return timeout
+	}
+	return tm.defaultTimeout
+}
+
+// routeLookup is implemented by *http.ServeMux and *Router.
+type routeLookup interface {
+	Handler(r *http.Request) (http.Handler, string)
+}
+
+// timeoutFor resolves a request's timeout: REQUEST_TIMEOUTS first, then a
+// timeout the route declared on the Router, then the default.
+func (tm *TimeoutMiddleware) timeoutFor(next http.Handler, r *http.Request) (string, time.Duration) {
+	// This runs before the mux, so the route pattern is looked up here.
+	route := r.URL.Path
+	mux, ok := next.(routeLookup)
+	if !ok {
+		return route, tm.TimeoutFor(route)
+	}
+
+	_, pattern := mux.Handler(r)
+	if strings.Contains(pattern, "{") {
+		route = pattern
+	}
+	if timeout, exists := tm.routes[route]; exists {
+		return route, timeout
+	}
+	if router, ok := next.(*Router); ok {
+		if timeout, declared := router.RouteTimeout(pattern); declared {
+			return route, timeout
+		}
+	}
+	return route, tm.defaultTimeout
+}
+
+func (tm *TimeoutMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, timeout := tm.timeoutFor(next, r)
+		if timeout <= 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{w: w, header: make(http.Header)}
+		done := make(chan struct{})
+		panicked := make(chan interface{}, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicked <- p
+				}
+			}()
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicked:
+			panic(p)
+		case <-done:
+			tw.flush()
+		case <-ctx.Done():
+			select {
+			case <-done:
+				tw.flush()
+				return
+			default:
+			}
+			tw.expire()
+			tm.reject(w, r, route, timeout)
+		}
+	})
+}
+
+func (tm *TimeoutMiddleware) reject(w http.ResponseWriter, r *http.Request, route string, timeout time.Duration) {
+	if tm.metrics != nil {
+		tm.metrics.RecordRequestTimeout(route)
+	}
+
+	tm.logger.Warn("request_timeout").
+		Component("timeout").
+		Operation(route).
+		Request(r.UserAgent(), r.RemoteAddr, GetRequestID(r.Context())).
+		Meta("timeout", timeout.String()).
+		Log()
+
+	setCORSHeaders(w, r)
+	writeError(w, NewAPIError("Request timed out", http.StatusGatewayTimeout), tm.logger, r)
+}
+
+// timeoutWriter buffers the response so a handler that finishes late cannot
+// interleave its output with the 504 already sent.
+type timeoutWriter struct {
+	w      http.ResponseWriter
+	header http.Header
+	buf    bytes.Buffer
+	code   int
+
+	mu       sync.Mutex
+	timedOut bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.code != 0 {
+		return
+	}
+	tw.code = code
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) expire() {
+	tw.mu.Lock()
+	tw.timedOut = true
+	tw.mu.Unlock()
+}
+
+func (tw *timeoutWriter) flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.code)
+	tw.w.Write(tw.buf.Bytes())
+}
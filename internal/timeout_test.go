@@ -0,0 +1,137 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseRouteTimeouts(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  map[string]time.Duration
+		expectErr bool
+	}{
+		{name: "empty value", value: "", expected: map[string]time.Duration{}},
+		{
+			name:  "multiple routes",
+			value: "/admin/riot-usage=20s, /league/entries=500ms",
+			expected: map[string]time.Duration{
+				"/admin/riot-usage": 20 * time.Second,
+				"/league/entries":   500 * time.Millisecond,
+			},
+		},
+		{name: "zero disables", value: "/metrics=0", expected: map[string]time.Duration{"/metrics": 0}},
+		{name: "missing slash", value: "metrics=1s", expectErr: true},
+		{name: "invalid duration", value: "/metrics=soon", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseRouteTimeouts(tt.value)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("parseRouteTimeouts() returned %d routes, expected %d", len(result), len(tt.expected))
+			}
+			for path, timeout := range tt.expected {
+				if result[path] != timeout {
+					t.Errorf("route %s = %v, expected %v", path, result[path], timeout)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeoutMiddleware_Returns504(t *testing.T) {
+	metrics := &MetricsCollector{requestTimeouts: make(map[string]int64)}
+	timeouts := NewTimeoutMiddleware(&Config{RequestTimeout: 20 * time.Millisecond}, newTestLogger(), metrics)
+
+	release := make(chan struct{})
+	handler := timeouts.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		<-release
+		w.Write([]byte("late"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/summoner", nil))
+	close(release)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusGatewayTimeout)
+	}
+	if metrics.requestTimeouts["/summoner"] != 1 {
+		t.Errorf("timeouts = %v, expected one for /summoner", metrics.requestTimeouts)
+	}
+}
+
+func TestTimeoutMiddleware_PassesFastResponses(t *testing.T) {
+	timeouts := NewTimeoutMiddleware(&Config{
+		RequestTimeout:  time.Nanosecond,
+		RequestTimeouts: "/healthz=1s",
+	}, newTestLogger(), nil)
+
+	handler := timeouts.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expected request context to carry a deadline")
+		}
+		w.Header().Set("X-Test", "ok")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("body"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusCreated || rec.Body.String() != "body" || rec.Header().Get("X-Test") != "ok" {
+		t.Errorf("unexpected response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+}
+
+func TestTimeoutMiddleware_PathPatternRoutes(t *testing.T) {
+	metrics := &MetricsCollector{requestTimeouts: make(map[string]int64)}
+	timeouts := NewTimeoutMiddleware(&Config{
+		RequestTimeout:  time.Minute,
+		RequestTimeouts: "/players/{gameName}/{tagLine}=20ms",
+	}, newTestLogger(), metrics)
+
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/players/{gameName}/{tagLine}", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		<-release
+	})
+
+	rec := httptest.NewRecorder()
+	timeouts.Handler(mux).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/players/Some%20Player/BR1", nil))
+	close(release)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, expected the pattern's timeout to apply", rec.Code)
+	}
+	if metrics.requestTimeouts["/players/{gameName}/{tagLine}"] != 1 {
+		t.Errorf("timeouts = %v, expected one recorded under the pattern", metrics.requestTimeouts)
+	}
+}
+
+func TestTimeoutMiddleware_StreamingRouteExempt(t *testing.T) {
+	timeouts := NewTimeoutMiddleware(&Config{RequestTimeout: 8 * time.Second}, newTestLogger(), nil)
+	if timeout := timeouts.TimeoutFor(LeagueEntriesStreamPath); timeout != 0 {
+		t.Errorf("TimeoutFor(%s) = %v, expected no deadline by default", LeagueEntriesStreamPath, timeout)
+	}
+
+	timeouts = NewTimeoutMiddleware(&Config{RequestTimeout: 8 * time.Second, RequestTimeouts: LeagueEntriesStreamPath + "=5m"}, newTestLogger(), nil)
+	if timeout := timeouts.TimeoutFor(LeagueEntriesStreamPath); timeout != 5*time.Minute {
+		t.Errorf("TimeoutFor(%s) = %v, expected the configured 5m", LeagueEntriesStreamPath, timeout)
+	}
+}
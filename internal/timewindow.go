@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type TimeWindow struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+func (tw TimeWindow) Duration() time.Duration {
+	return tw.To.Sub(tw.From)
+}
+
+func ParseTimeWindow(query url.Values, defaultPeriod, maxSpan time.Duration, now time.Time) (TimeWindow, error) {
+	now = now.UTC()
+	to := now
+
+	if value := query.Get("to"); value != "" {
+		parsed, err := parseTimeParam(value)
+		if err != nil {
+			return TimeWindow{}, NewAPIError("to must be RFC3339 or unix seconds", http.StatusBadRequest)
+		}
+		if parsed.Before(now) {
+			to = parsed
+		}
+	}
+
+	var from time.Time
+	fromValue := query.Get("from")
+	periodValue := query.Get("period")
+
+	switch {
+	case fromValue != "" && periodValue != "":
+		return TimeWindow{}, NewAPIError("from and period cannot be combined", http.StatusBadRequest)
+	case fromValue != "":
+		parsed, err := parseTimeParam(fromValue)
+		if err != nil {
+			return TimeWindow{}, NewAPIError("from must be RFC3339 or unix seconds", http.StatusBadRequest)
+		}
+		from = parsed
+	case periodValue != "":
+		period, err := parsePeriod(periodValue)
+		if err != nil {
+			return TimeWindow{}, NewAPIError("period must look like 24h, 7d or 30d", http.StatusBadRequest)
+		}
+		from = to.Add(-period)
+	default:
+		from = to.Add(-defaultPeriod)
+	}
+
+	if !from.Before(to) {
+		return TimeWindow{}, NewAPIError("from must be before to", http.StatusBadRequest)
+	}
+	if maxSpan > 0 && to.Sub(from) > maxSpan {
+		return TimeWindow{}, NewAPIError(fmt.Sprintf("time window cannot exceed %s", formatPeriod(maxSpan)), http.StatusBadRequest)
+	}
+
+	return TimeWindow{From: from, To: to}, nil
+}
+
+func parseTimeParam(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parsed.UTC(), nil
+}
+
+func parsePeriod(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid period %q", value)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+
+	period, err := time.ParseDuration(value)
+	if err != nil || period <= 0 {
+		return 0, fmt.Errorf("invalid period %q", value)
+	}
+	return period, nil
+}
+
+func formatPeriod(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return d.String()
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseTimeWindow(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		expectedFrom time.Time
+		expectedTo   time.Time
+		expectErr    bool
+	}{
+		{
+			name:         "default period",
+			query:        url.Values{},
+			expectedFrom: now.Add(-24 * time.Hour),
+			expectedTo:   now,
+		},
+		{
+			name:         "period in days",
+			query:        url.Values{"period": {"7d"}},
+			expectedFrom: now.Add(-7 * 24 * time.Hour),
+			expectedTo:   now,
+		},
+		{
+			name:         "period in hours",
+			query:        url.Values{"period": {"6h"}},
+			expectedFrom: now.Add(-6 * time.Hour),
+			expectedTo:   now,
+		},
+		{
+			name:         "explicit from and to",
+			query:        url.Values{"from": {"2025-05-30T00:00:00Z"}, "to": {"2025-05-31T00:00:00Z"}},
+			expectedFrom: time.Date(2025, 5, 30, 0, 0, 0, 0, time.UTC),
+			expectedTo:   time.Date(2025, 5, 31, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "unix seconds",
+			query:        url.Values{"from": {"1748649600"}},
+			expectedFrom: time.Unix(1748649600, 0).UTC(),
+			expectedTo:   now,
+		},
+		{
+			name:         "future to is clamped to now",
+			query:        url.Values{"period": {"1d"}, "to": {"2030-01-01T00:00:00Z"}},
+			expectedFrom: now.Add(-24 * time.Hour),
+			expectedTo:   now,
+		},
+		{
+			name:      "from and period combined",
+			query:     url.Values{"from": {"2025-05-30T00:00:00Z"}, "period": {"7d"}},
+			expectErr: true,
+		},
+		{
+			name:      "invalid period",
+			query:     url.Values{"period": {"soon"}},
+			expectErr: true,
+		},
+		{
+			name:      "from after to",
+			query:     url.Values{"from": {"2025-05-31T00:00:00Z"}, "to": {"2025-05-30T00:00:00Z"}},
+			expectErr: true,
+		},
+		{
+			name:      "exceeds max span",
+			query:     url.Values{"period": {"60d"}},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := ParseTimeWindow(tt.query, 24*time.Hour, 30*24*time.Hour, now)
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected error but got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if !window.From.Equal(tt.expectedFrom) {
+				t.Errorf("From = %v, expected %v", window.From, tt.expectedFrom)
+			}
+			if !window.To.Equal(tt.expectedTo) {
+				t.Errorf("To = %v, expected %v", window.To, tt.expectedTo)
+			}
+		})
+	}
+}
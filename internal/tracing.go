@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/robertasolimandonofreo/tft-core"
+
+func InitTracing(ctx context.Context, cfg *Config, logger *Logger) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.TracingEnabled {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.TracingSampleRatio))),
+		sdktrace.WithResource(tracingResource(cfg)),
+	)
+	otel.SetTracerProvider(provider)
+
+	logger.Info("tracing_enabled").
+		Component("tracing").
+		Operation("init").
+		Meta("endpoint", cfg.OTLPEndpoint).
+		Meta("sample_ratio", cfg.TracingSampleRatio).
+		Log()
+
+	return provider.Shutdown, nil
+}
+
+func tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+func recordSpanError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+func startCacheSpan(ctx context.Context, operation, key string) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, "cache."+operation, trace.WithSpanKind(trace.SpanKindClient))
+	span.SetAttributes(
+		attribute.String("db.system", "redis"),
+		attribute.String("cache.key", key),
+	)
+	return ctx, span
+}
+
+// startDatabaseSpan records a query on the database behind driver
+// (DatabaseManager.Dialect), named with the semantic convention's db.system.
+func startDatabaseSpan(ctx context.Context, driver, operation string) (context.Context, trace.Span) {
+	ctx, span := tracer().Start(ctx, "database."+operation, trace.WithSpanKind(trace.SpanKindClient))
+	system := "postgresql"
+	if driver == DatabaseDriverSQLite {
+		system = "sqlite"
+	}
+	span.SetAttributes(attribute.String("db.system", system))
+	return ctx, span
+}
+
+func tracingResource(cfg *Config) *resource.Resource {
+	return resource.NewWithAttributes("",
+		attribute.String("service.name", "tft-core"),
+		attribute.String("deployment.environment", cfg.AppEnv),
+	)
+}
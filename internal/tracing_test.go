@@ -0,0 +1,181 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestTracer records every span in memory until the test ends.
+func newTestTracer(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return recorder
+}
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestInitTracing_Disabled(t *testing.T) {
+	shutdown, err := InitTracing(t.Context(), &Config{}, newTestLogger())
+	if err != nil {
+		t.Fatalf("InitTracing() error = %v", err)
+	}
+	if err := shutdown(t.Context()); err != nil {
+		t.Errorf("shutdown() error = %v", err)
+	}
+
+	fields := otel.GetTextMapPropagator().Fields()
+	for _, field := range []string{"traceparent", "baggage"} {
+		if !slices.Contains(fields, field) {
+			t.Errorf("propagator fields = %v, expected %s even with tracing disabled", fields, field)
+		}
+	}
+}
+
+func TestStartSpans(t *testing.T) {
+	recorder := newTestTracer(t)
+
+	_, cacheSpan := startCacheSpan(t.Context(), "get", "tft:summoner:BR1:abc")
+	cacheSpan.End()
+	_, dbSpan := startDatabaseSpan(t.Context(), DatabaseDriverPostgres, "add_to_watchlist")
+	recordSpanError(dbSpan, nil)
+	dbSpan.End()
+	_, sqliteSpan := startDatabaseSpan(t.Context(), DatabaseDriverSQLite, "add_to_watchlist")
+	sqliteSpan.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("ended spans = %d, expected 3", len(spans))
+	}
+	tests := []struct {
+		span   sdktrace.ReadOnlySpan
+		name   string
+		system string
+	}{
+		{span: spans[0], name: "cache.get", system: "redis"},
+		{span: spans[1], name: "database.add_to_watchlist", system: "postgresql"},
+		{span: spans[2], name: "database.add_to_watchlist", system: "sqlite"},
+	}
+	for _, tt := range tests {
+		if tt.span.Name() != tt.name || tt.span.SpanKind() != trace.SpanKindClient {
+			t.Errorf("span = %s (%v), expected client span %s", tt.span.Name(), tt.span.SpanKind(), tt.name)
+		}
+		if system, _ := spanAttribute(tt.span, "db.system"); system.AsString() != tt.system {
+			t.Errorf("%s db.system = %q, expected %q", tt.name, system.AsString(), tt.system)
+		}
+		if tt.span.Status().Code == codes.Error {
+			t.Errorf("%s status = %v, expected no error recorded", tt.name, tt.span.Status())
+		}
+	}
+	if key, _ := spanAttribute(spans[0], "cache.key"); key.AsString() != "tft:summoner:BR1:abc" {
+		t.Errorf("cache.key = %q", key.AsString())
+	}
+}
+
+func TestRecordSpanError(t *testing.T) {
+	recorder := newTestTracer(t)
+
+	_, span := tracer().Start(t.Context(), "riot_api.request")
+	recordSpanError(span, errors.New("riot unavailable"))
+	span.End()
+
+	ended := recorder.Ended()[0]
+	if status := ended.Status(); status.Code != codes.Error || status.Description != "riot unavailable" {
+		t.Errorf("status = %+v, expected the error", status)
+	}
+	if events := ended.Events(); len(events) != 1 || events[0].Name != "exception" {
+		t.Errorf("events = %+v, expected the error recorded as an exception", events)
+	}
+}
+
+func TestRiotRequest_DoesNotPropagateTrace(t *testing.T) {
+	recorder := newTestTracer(t)
+	InitTracing(t.Context(), &Config{}, newTestLogger())
+
+	headers := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	cfg := &Config{RiotAPIKey: "riot-key", RiotBaseURL: server.URL, RiotRegion: "BR1", RiotHTTPTimeout: time.Second}
+	client := NewRiotAPIClient(cfg, NewCacheManager(cfg, nil, nil, nil), newTestLogger(), nil)
+
+	member, _ := baggage.NewMember("tenant", "internal-tenant")
+	bag, _ := baggage.New(member)
+	ctx, parent := tracer().Start(baggage.ContextWithBaggage(context.Background(), bag), "http.request")
+	if _, err := client.doRequest(ctx, server.URL+"/tft/league/v1/challenger"); err != nil {
+		t.Fatalf("doRequest() error = %v", err)
+	}
+	parent.End()
+
+	header := <-headers
+	if header.Get("X-Riot-Token") != "riot-key" {
+		t.Errorf("X-Riot-Token = %q, expected the API key", header.Get("X-Riot-Token"))
+	}
+	for _, name := range []string{"Traceparent", "Tracestate", "Baggage"} {
+		if value := header.Get(name); value != "" {
+			t.Errorf("%s = %q sent to Riot, expected no trace context", name, value)
+		}
+	}
+
+	var request sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.Name() == "riot_api.request" {
+			request = span
+		}
+	}
+	if request == nil || request.Parent().TraceID() != parent.SpanContext().TraceID() {
+		t.Fatal("expected a riot_api.request span in the caller's trace")
+	}
+	if status, _ := spanAttribute(request, "http.status_code"); status.AsInt64() != http.StatusOK {
+		t.Errorf("http.status_code = %v, expected 200", status.AsInt64())
+	}
+}
+
+func TestLoggingMiddleware_SpanUsesRoutePattern(t *testing.T) {
+	recorder := newTestTracer(t)
+	middleware := NewLoggingMiddleware(newTestLogger(), nil, nil, nil)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/schemas/", middleware.Handler(func(w http.ResponseWriter, r *http.Request) {}))
+	mux.HandleFunc("/players/{gameName}/{tagLine}", middleware.Handler(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, path := range []string{"/schemas/summoner.json", "/schemas/league.json", "/players/Some%20Player/BR1"} {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	names := make(map[string]int)
+	for _, span := range recorder.Ended() {
+		names[span.Name()]++
+		route, _ := spanAttribute(span, "http.route")
+		if "GET "+route.AsString() != span.Name() {
+			t.Errorf("http.route = %q, expected it to match the span name %q", route.AsString(), span.Name())
+		}
+	}
+	if names["GET /schemas/"] != 2 || names["GET /players/{gameName}/{tagLine}"] != 1 {
+		t.Errorf("span names = %v, expected one name per route pattern", names)
+	}
+}
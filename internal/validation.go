@@ -0,0 +1,169 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var puuidPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{78}$`)
+
+var (
+	validTiers     = []string{"IRON", "BRONZE", "SILVER", "GOLD", "PLATINUM", "EMERALD", "DIAMOND", "MASTER", "GRANDMASTER", "CHALLENGER"}
+	validDivisions = []string{"I", "II", "III", "IV"}
+)
+
+type FieldError struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		reasons = append(reasons, field.Name+": "+field.Reason)
+	}
+	return "validation failed: " + strings.Join(reasons, "; ")
+}
+
+type ProblemDetails struct {
+	Type          string       `json:"type"`
+	Title         string       `json:"title"`
+	Code          string       `json:"code"`
+	Status        int          `json:"status"`
+	Detail        string       `json:"detail"`
+	Instance      string       `json:"instance"`
+	RequestID     string       `json:"requestId,omitempty"`
+	InvalidParams []FieldError `json:"invalidParams,omitempty"`
+}
+
+// Validator collects every failed check so a single response can list all
+// invalid fields instead of stopping at the first one.
+type Validator struct {
+	query  url.Values
+	fields []FieldError
+}
+
+func NewValidator(query url.Values) *Validator {
+	return &Validator{query: query}
+}
+
+func (v *Validator) Fail(field, reason string) {
+	v.fields = append(v.fields, FieldError{Name: field, Reason: reason})
+}
+
+func (v *Validator) Required(field string) string {
+	value := strings.TrimSpace(v.query.Get(field))
+	if value == "" {
+		v.Fail(field, "is required")
+	}
+	return value
+}
+
+func (v *Validator) CheckPUUID(field, value string) string {
+	switch {
+	case value == "":
+		v.Fail(field, "is required")
+	case !puuidPattern.MatchString(value):
+		v.Fail(field, "must be a 78 character Riot PUUID")
+	}
+	return value
+}
+
+func (v *Validator) PUUID(field string) string {
+	return v.CheckPUUID(field, strings.TrimSpace(v.query.Get(field)))
+}
+
+func (v *Validator) Enum(field string, allowed []string) string {
+	value := strings.ToUpper(v.Required(field))
+	if value == "" {
+		return ""
+	}
+	for _, candidate := range allowed {
+		if value == candidate {
+			return value
+		}
+	}
+	v.Fail(field, "must be one of "+strings.Join(allowed, ", "))
+	return ""
+}
+
+func (v *Validator) IntRange(field string, defaultValue, min, max int) int {
+	raw := strings.TrimSpace(v.query.Get(field))
+	if raw == "" {
+		return defaultValue
+	}
+
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < min || value > max {
+		v.Fail(field, fmt.Sprintf("must be an integer between %d and %d", min, max))
+		return defaultValue
+	}
+	return value
+}
+
+// Bool returns nil when the field is absent so callers can tell "not
+// filtered" from false.
+func (v *Validator) Bool(field string) *bool {
+	switch strings.ToLower(strings.TrimSpace(v.query.Get(field))) {
+	case "":
+		return nil
+	case "true":
+		value := true
+		return &value
+	case "false":
+		value := false
+		return &value
+	}
+	v.Fail(field, "must be true or false")
+	return nil
+}
+
+func (v *Validator) MinLength(field, value string, min int) {
+	if value != "" && len([]rune(value)) < min {
+		v.Fail(field, fmt.Sprintf("must have at least %d characters", min))
+	}
+}
+
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: v.fields}
+}
+
+func writeProblem(w http.ResponseWriter, verr *ValidationError, logger *Logger, r *http.Request) {
+	requestID := GetRequestID(r.Context())
+
+	logger.Warn("request_validation_failed").
+		Component("http").
+		Operation("validate").
+		HTTP(r.Method, r.URL.Path, http.StatusBadRequest).
+		Request(r.UserAgent(), r.RemoteAddr, requestID).
+		Err(verr).
+		Log()
+
+	problem := ProblemDetails{
+		Type:          "about:blank",
+		Title:         "Invalid request parameters",
+		Code:          ErrorCodeInvalidRequest,
+		Status:        http.StatusBadRequest,
+		Detail:        fmt.Sprintf("%d parameter(s) failed validation", len(verr.Fields)),
+		Instance:      r.URL.Path,
+		RequestID:     requestID,
+		InvalidParams: verr.Fields,
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(http.StatusBadRequest)
+	body, _ := json.Marshal(problem)
+	w.Write(append(body, '\n'))
+}
@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestValidateEntriesParams(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedFields []string
+		tier           string
+		division       string
+		page           int
+	}{
+		{name: "valid with default page", query: "tier=gold&division=ii", tier: "GOLD", division: "II", page: 1},
+		{name: "valid with page", query: "tier=DIAMOND&division=I&page=3", tier: "DIAMOND", division: "I", page: 3},
+		{name: "missing everything", query: "", expectedFields: []string{"tier", "division"}},
+		{name: "every field invalid", query: "tier=WOOD&division=V&page=0", expectedFields: []string{"tier", "division", "page"}},
+		{name: "invalid cursor", query: "cursor=%25%25", expectedFields: []string{"cursor"}},
+		{name: "cursor with an unknown tier", query: "cursor=" + EncodeEntriesCursor(EntriesCursor{Tier: "WOOD", Division: "IV", Page: 2}), expectedFields: []string{"cursor"}},
+		{name: "cursor with an unknown division", query: "cursor=" + EncodeEntriesCursor(EntriesCursor{Tier: "SILVER", Division: "V", Page: 2}), expectedFields: []string{"cursor"}},
+		{name: "cursor past the last page", query: "cursor=" + EncodeEntriesCursor(EntriesCursor{Tier: "SILVER", Division: "IV", Page: 1001}), expectedFields: []string{"cursor"}},
+		{
+			name:  "cursor wins over params",
+			query: "tier=WOOD&cursor=" + EncodeEntriesCursor(EntriesCursor{Tier: "SILVER", Division: "IV", Page: 2}),
+			tier:  "SILVER", division: "IV", page: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			tier, division, page, err := validateEntriesParams(query)
+
+			if len(tt.expectedFields) > 0 {
+				verr, ok := err.(*ValidationError)
+				if !ok {
+					t.Fatalf("expected *ValidationError but got %v", err)
+				}
+				if len(verr.Fields) != len(tt.expectedFields) {
+					t.Fatalf("invalid fields = %+v, expected %v", verr.Fields, tt.expectedFields)
+				}
+				for i, field := range tt.expectedFields {
+					if verr.Fields[i].Name != field {
+						t.Errorf("field %d = %s, expected %s", i, verr.Fields[i].Name, field)
+					}
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("expected no error but got: %v", err)
+			}
+			if tier != tt.tier || division != tt.division || page != tt.page {
+				t.Errorf("got %s/%s/%d, expected %s/%s/%d", tier, division, page, tt.tier, tt.division, tt.page)
+			}
+		})
+	}
+}
+
+func TestValidator_PUUID(t *testing.T) {
+	valid := strings.Repeat("a", 40) + "_" + strings.Repeat("B", 36) + "-"
+
+	tests := []struct {
+		name    string
+		value   string
+		isValid bool
+	}{
+		{name: "valid", value: valid, isValid: true},
+		{name: "missing", value: "", isValid: false},
+		{name: "too short", value: "abc", isValid: false},
+		{name: "bad characters", value: strings.Repeat("a", 77) + "!", isValid: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewValidator(url.Values{"puuid": {tt.value}})
+			v.PUUID("puuid")
+			if (v.Err() == nil) != tt.isValid {
+				t.Errorf("PUUID(%q) error = %v, expected valid = %v", tt.value, v.Err(), tt.isValid)
+			}
+		})
+	}
+}
+
+func TestWriteError_ProblemDetails(t *testing.T) {
+	v := NewValidator(url.Values{"count": {"500"}})
+	v.PUUID("puuid")
+	v.IntRange("count", 20, 1, 100)
+
+	rec := httptest.NewRecorder()
+	writeError(rec, v.Err(), newTestLogger(), httptest.NewRequest(http.MethodGet, "/player/placements?count=500", nil))
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, expected %d", rec.Code, http.StatusBadRequest)
+	}
+	if contentType := rec.Header().Get("Content-Type"); contentType != "application/problem+json" {
+		t.Errorf("Content-Type = %q, expected application/problem+json", contentType)
+	}
+
+	var problem ProblemDetails
+	if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	if problem.Instance != "/player/placements" || problem.Status != http.StatusBadRequest {
+		t.Errorf("problem = %+v, expected instance and status to be set", problem)
+	}
+	if len(problem.InvalidParams) != 2 {
+		t.Errorf("invalidParams = %+v, expected puuid and count", problem.InvalidParams)
+	}
+}
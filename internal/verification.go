@@ -0,0 +1,230 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	VerificationPending  = "pending"
+	VerificationVerified = "verified"
+	VerificationExpired  = "expired"
+)
+
+// Profile icons 0-28 are owned by every account, so any player can complete
+// a challenge without buying an icon.
+const verificationIconCount = 29
+
+type PlayerVerification struct {
+	PUUID         string     `json:"puuid"`
+	Region        string     `json:"region"`
+	Code          string     `json:"code"`
+	ProfileIconID int        `json:"profileIconId"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ExpiresAt     time.Time  `json:"expiresAt"`
+	VerifiedAt    *time.Time `json:"verifiedAt,omitempty"`
+}
+
+// verificationIcon picks a default icon from n that differs from the one the
+// player already uses, so a verified check proves the icon was changed.
+func verificationIcon(current int, n uint32) int {
+	icon := int(n % verificationIconCount)
+	if icon == current {
+		icon = (icon + 1) % verificationIconCount
+	}
+	return icon
+}
+
+func newVerificationChallenge(currentIcon int) (string, int, error) {
+	buf := make([]byte, 7)
+	if _, err := rand.Read(buf); err != nil {
+		return "", 0, err
+	}
+	code := "TFT-" + strings.ToUpper(hex.EncodeToString(buf[:3]))
+	return code, verificationIcon(currentIcon, binary.BigEndian.Uint32(buf[3:])), nil
+}
+
+// Verifier proves that the caller of an API key controls a Riot account by
+// asking them to switch to a given profile icon. Verifications are scoped to
+// the API key that started them.
+type Verifier struct {
+	db         *DatabaseManager
+	riotClient *RiotAPIClient
+	logger     *Logger
+	ttl        time.Duration
+}
+
+func NewVerifier(cfg *Config, db *DatabaseManager, riotClient *RiotAPIClient, logger *Logger) *Verifier {
+	return &Verifier{
+		db:         db,
+		riotClient: riotClient,
+		logger:     logger,
+		ttl:        cfg.VerificationTTL,
+	}
+}
+
+func (v *Verifier) available() bool {
+	return v.db != nil && v.db.Enabled
+}
+
+// Start issues a new challenge, replacing a pending or expired one. A player
+// already verified for the key stays verified.
+func (v *Verifier) Start(ctx context.Context, keyID, puuid string) (*PlayerVerification, error) {
+	summoner, err := v.riotClient.FetchSummonerByPUUID(ctx, puuid)
+	if err != nil {
+		return nil, err
+	}
+
+	code, icon, err := newVerificationChallenge(summoner.ProfileIconID)
+	if err != nil {
+		return nil, NewAPIError("Failed to generate verification code", http.StatusInternalServerError).WithCause(err)
+	}
+
+	now := time.Now().UTC()
+	challenge := &PlayerVerification{
+		PUUID:         puuid,
+		Region:        v.riotClient.region,
+		Code:          code,
+		ProfileIconID: icon,
+		Status:        VerificationPending,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(v.ttl),
+	}
+
+	verification, err := v.db.StartVerification(ctx, keyID, challenge, now)
+	if err != nil {
+		return nil, NewAPIError("Failed to start verification", http.StatusInternalServerError).WithCause(err)
+	}
+	return verification, nil
+}
+
+func (v *Verifier) Get(ctx context.Context, keyID, puuid string) (*PlayerVerification, error) {
+	verification, err := v.db.GetVerification(ctx, keyID, puuid, time.Now().UTC())
+	if err != nil {
+		return nil, NewAPIError("Failed to load verification", http.StatusInternalServerError).WithCause(err)
+	}
+	if verification == nil {
+		return nil, NewAPIError("No verification started for this player", http.StatusNotFound)
+	}
+	return verification, nil
+}
+
+// Check reads the player's current icon straight from Riot, bypassing the
+// summoner cache, and completes the verification when it matches.
+func (v *Verifier) Check(ctx context.Context, keyID, puuid, code string) (*PlayerVerification, error) {
+	verification, err := v.Get(ctx, keyID, puuid)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case verification.Status == VerificationVerified:
+		return verification, nil
+	case verification.Status == VerificationExpired:
+		return nil, NewAPIError("Verification expired, start a new one", http.StatusGone)
+	case !strings.EqualFold(code, verification.Code):
+		return nil, NewAPIError("Verification code does not match", http.StatusBadRequest)
+	}
+
+	summoner, err := v.riotClient.FetchSummonerByPUUID(ctx, puuid)
+	if err != nil {
+		return nil, err
+	}
+	v.riotClient.recordProfile(ctx, summoner)
+
+	if summoner.ProfileIconID != verification.ProfileIconID {
+		return nil, NewAPIError("Profile icon does not match the requested icon yet", http.StatusConflict)
+	}
+
+	now := time.Now().UTC()
+	if err := v.db.MarkVerified(ctx, keyID, puuid, now); err != nil {
+		return nil, NewAPIError("Failed to complete verification", http.StatusInternalServerError).WithCause(err)
+	}
+	verification.Status = VerificationVerified
+	verification.VerifiedAt = &now
+
+	v.logger.Info("player_verified").
+		Component("verification").
+		Operation("check").
+		Game(puuid, verification.Region, "").
+		Meta("key_id", keyID).
+		Log()
+	return verification, nil
+}
+
+func (dm *DatabaseManager) StartVerification(ctx context.Context, keyID string, challenge *PlayerVerification, now time.Time) (*PlayerVerification, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "start_verification")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		INSERT INTO player_verifications (key_id, puuid, region, code, profile_icon_id, status, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (key_id, puuid) DO UPDATE SET
+			region = EXCLUDED.region,
+			code = EXCLUDED.code,
+			profile_icon_id = EXCLUDED.profile_icon_id,
+			status = EXCLUDED.status,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at,
+			verified_at = NULL
+		WHERE player_verifications.status <> 'verified'
+	`), keyID, challenge.PUUID, challenge.Region, challenge.Code, challenge.ProfileIconID, challenge.Status, challenge.CreatedAt, challenge.ExpiresAt)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	return dm.GetVerification(ctx, keyID, challenge.PUUID, now)
+}
+
+// GetVerification returns nil when the key never started a verification for
+// the player. Pending challenges past their expiry are reported as expired.
+func (dm *DatabaseManager) GetVerification(ctx context.Context, keyID, puuid string, now time.Time) (*PlayerVerification, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_verification")
+	defer span.End()
+
+	var verification PlayerVerification
+	var verifiedAt sql.NullTime
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		SELECT puuid, region, code, profile_icon_id, status, created_at, expires_at, verified_at
+		FROM player_verifications
+		WHERE key_id = $1 AND puuid = $2
+	`), keyID, puuid).Scan(
+		&verification.PUUID, &verification.Region, &verification.Code, &verification.ProfileIconID,
+		&verification.Status, &verification.CreatedAt, &verification.ExpiresAt, &verifiedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	if verifiedAt.Valid {
+		verification.VerifiedAt = &verifiedAt.Time
+	}
+	if verification.Status == VerificationPending && now.After(verification.ExpiresAt) {
+		verification.Status = VerificationExpired
+	}
+	return &verification, nil
+}
+
+func (dm *DatabaseManager) MarkVerified(ctx context.Context, keyID, puuid string, at time.Time) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "mark_verified")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		UPDATE player_verifications SET status = 'verified', verified_at = $3
+		WHERE key_id = $1 AND puuid = $2
+	`), keyID, puuid, at)
+	recordSpanError(span, err)
+	return err
+}
@@ -0,0 +1,41 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerificationIcon(t *testing.T) {
+	tests := []struct {
+		name     string
+		current  int
+		n        uint32
+		expected int
+	}{
+		{name: "different icon kept", current: 4568, n: 7, expected: 7},
+		{name: "wraps into default range", current: 4568, n: 29 + 3, expected: 3},
+		{name: "current icon skipped", current: 7, n: 7, expected: 8},
+		{name: "last default icon wraps", current: 28, n: 28, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := verificationIcon(tt.current, tt.n); result != tt.expected {
+				t.Errorf("verificationIcon(%d, %d) = %d, expected %d", tt.current, tt.n, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNewVerificationChallenge(t *testing.T) {
+	code, icon, err := newVerificationChallenge(3)
+	if err != nil {
+		t.Fatalf("newVerificationChallenge() error = %v", err)
+	}
+	if !strings.HasPrefix(code, "TFT-") || len(code) != 10 {
+		t.Errorf("code = %q, expected TFT- followed by six hex characters", code)
+	}
+	if icon == 3 || icon < 0 || icon >= verificationIconCount {
+		t.Errorf("icon = %d, expected a default icon other than 3", icon)
+	}
+}
@@ -0,0 +1,550 @@
+package internal
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"hash/fnv"
+	"time"
+)
+
+var ErrWatchlistQuotaExceeded = errors.New("watchlist quota exceeded")
+
+type WatchlistEntry struct {
+	PUUID           string     `json:"puuid"`
+	Region          string     `json:"region"`
+	Tier            string     `json:"tier"`
+	Rank            string     `json:"rank"`
+	LeaguePoints    int        `json:"leaguePoints"`
+	Wins            int        `json:"wins"`
+	Losses          int        `json:"losses"`
+	LastMatchID     string     `json:"lastMatchId,omitempty"`
+	MiniSeries      string     `json:"miniSeries,omitempty"`
+	AddedAt         time.Time  `json:"addedAt"`
+	LastRefreshedAt *time.Time `json:"lastRefreshedAt,omitempty"`
+}
+
+type RankSnapshot struct {
+	Tier         string `json:"tier"`
+	Rank         string `json:"rank"`
+	LeaguePoints int    `json:"leaguePoints"`
+}
+
+type RankChangeEvent struct {
+	PUUID       string       `json:"puuid"`
+	Region      string       `json:"region"`
+	Previous    RankSnapshot `json:"previous"`
+	Current     RankSnapshot `json:"current"`
+	LPDelta     int          `json:"lpDelta"`
+	NewMatchIDs []string     `json:"newMatchIds,omitempty"`
+	ChangedAt   time.Time    `json:"changedAt"`
+}
+
+type PromotionSeriesEvent struct {
+	PUUID      string      `json:"puuid"`
+	Region     string      `json:"region"`
+	Status     string      `json:"status"`
+	Tier       string      `json:"tier"`
+	Rank       string      `json:"rank"`
+	MiniSeries *MiniSeries `json:"miniSeries,omitempty"`
+	OccurredAt time.Time   `json:"occurredAt"`
+}
+
+func (e *WatchlistEntry) Snapshot() RankSnapshot {
+	return RankSnapshot{Tier: e.Tier, Rank: e.Rank, LeaguePoints: e.LeaguePoints}
+}
+
+func rankChanged(previous, current RankSnapshot) bool {
+	return previous.Tier != current.Tier || previous.Rank != current.Rank
+}
+
+// AddToWatchlist subscribes tenant to a player, creating the shared watchlist
+// row on first use. Adding a player the tenant already watches is a no-op and
+// does not count against the quota.
+func (dm *DatabaseManager) AddToWatchlist(ctx context.Context, tenant, puuid, region string) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "add_to_watchlist")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Concurrent adds for one tenant would all pass the count below before any
+	// of them inserts. The transaction lock serializes them until commit;
+	// SQLite runs on a single connection, so its transactions never overlap.
+	if dm.Dialect == DatabaseDriverPostgres {
+		if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, watchlistLockKey(tenant)); err != nil {
+			recordSpanError(span, err)
+			return err
+		}
+	}
+
+	var subscribed, watched int
+	err = tx.QueryRowContext(ctx, dm.rebind(`
+		SELECT
+			COUNT(*) FILTER (WHERE puuid = $2),
+			COUNT(*)
+		FROM watchlist_subscriptions
+		WHERE tenant_id = $1
+	`), tenant, puuid).Scan(&subscribed, &watched)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	if subscribed > 0 {
+		return nil
+	}
+	if dm.watchlistQuota > 0 && watched >= dm.watchlistQuota {
+		return ErrWatchlistQuotaExceeded
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO watchlist (puuid, region) VALUES ($1, $2)
+		ON CONFLICT (puuid) DO NOTHING
+	`), puuid, region)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		INSERT INTO watchlist_subscriptions (tenant_id, puuid) VALUES ($1, $2)
+		ON CONFLICT (tenant_id, puuid) DO NOTHING
+	`), tenant, puuid)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	err = tx.Commit()
+	recordSpanError(span, err)
+	return err
+}
+
+func watchlistLockKey(tenant string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("tft:watchlist:" + tenant))
+	return int64(h.Sum64())
+}
+
+// RemoveFromWatchlist drops the tenant's subscription and stops refreshing the
+// player once no tenant watches it.
+func (dm *DatabaseManager) RemoveFromWatchlist(ctx context.Context, tenant, puuid string) (bool, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "remove_from_watchlist")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, dm.rebind(`
+		DELETE FROM watchlist_subscriptions WHERE tenant_id = $1 AND puuid = $2
+	`), tenant, puuid)
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return false, nil
+	}
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		DELETE FROM watchlist
+		WHERE puuid = $1 AND NOT EXISTS (SELECT 1 FROM watchlist_subscriptions WHERE puuid = $1)
+	`), puuid)
+	if err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		recordSpanError(span, err)
+		return false, err
+	}
+	return true, nil
+}
+
+func (dm *DatabaseManager) ListWatchlist(ctx context.Context, tenant string, limit, offset int) ([]WatchlistEntry, error) {
+	return dm.queryWatchlist(ctx, "list_watchlist", dm.rebind(`
+		SELECT w.puuid, w.region, w.tier, w.rank, w.league_points, w.wins, w.losses, w.last_match_id, w.mini_series, s.added_at, w.last_refreshed_at
+		FROM watchlist_subscriptions s
+		JOIN watchlist w ON w.puuid = s.puuid
+		WHERE s.tenant_id = $1
+		ORDER BY s.added_at
+		LIMIT $2 OFFSET $3
+	`), tenant, limit, offset)
+}
+
+func (dm *DatabaseManager) CountWatchlist(ctx context.Context, tenant string) (int, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "count_watchlist")
+	defer span.End()
+
+	var count int
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		SELECT COUNT(*) FROM watchlist_subscriptions WHERE tenant_id = $1
+	`), tenant).Scan(&count)
+	recordSpanError(span, err)
+	return count, err
+}
+
+// WatchlistCountsByTenant feeds the per-key usage report.
+func (dm *DatabaseManager) WatchlistCountsByTenant(ctx context.Context) (map[string]int, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "watchlist_counts_by_tenant")
+	defer span.End()
+
+	rows, err := dm.DB.QueryContext(ctx, `
+		SELECT tenant_id, COUNT(*) FROM watchlist_subscriptions GROUP BY tenant_id
+	`)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tenant string
+		var count int
+		if err := rows.Scan(&tenant, &count); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		counts[tenant] = count
+	}
+	return counts, rows.Err()
+}
+
+func (dm *DatabaseManager) WatchlistQuota() int {
+	return dm.watchlistQuota
+}
+
+func (dm *DatabaseManager) WatchlistDueForRefresh(ctx context.Context, olderThan time.Duration, limit int) ([]WatchlistEntry, error) {
+	return dm.queryWatchlist(ctx, "watchlist_due_for_refresh", `
+		SELECT puuid, region, tier, rank, league_points, wins, losses, last_match_id, mini_series, added_at, last_refreshed_at
+		FROM watchlist
+		WHERE last_refreshed_at IS NULL OR last_refreshed_at < $1
+		ORDER BY last_refreshed_at NULLS FIRST
+		LIMIT $2
+	`, time.Now().UTC().Add(-olderThan), limit)
+}
+
+func (dm *DatabaseManager) queryWatchlist(ctx context.Context, operation, query string, args ...interface{}) ([]WatchlistEntry, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, operation)
+	defer span.End()
+
+	rows, err := dm.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []WatchlistEntry{}
+	for rows.Next() {
+		var entry WatchlistEntry
+		var refreshedAt sql.NullTime
+		if err := rows.Scan(
+			&entry.PUUID,
+			&entry.Region,
+			&entry.Tier,
+			&entry.Rank,
+			&entry.LeaguePoints,
+			&entry.Wins,
+			&entry.Losses,
+			&entry.LastMatchID,
+			&entry.MiniSeries,
+			&entry.AddedAt,
+			&refreshedAt,
+		); err != nil {
+			return nil, err
+		}
+		if refreshedAt.Valid {
+			entry.LastRefreshedAt = &refreshedAt.Time
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// UpdateWatchlistEntry stores the refreshed snapshot, its LP history point and
+// the events it produced in the same transaction via the outbox.
+func (dm *DatabaseManager) UpdateWatchlistEntry(ctx context.Context, entry *WatchlistEntry, events []OutboxMessage) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "update_watchlist_entry")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE watchlist SET
+			tier = $2,
+			rank = $3,
+			league_points = $4,
+			wins = $5,
+			losses = $6,
+			last_match_id = $7,
+			mini_series = $8,
+			last_refreshed_at = CURRENT_TIMESTAMP
+		WHERE puuid = $1
+	`, entry.PUUID, entry.Tier, entry.Rank, entry.LeaguePoints, entry.Wins, entry.Losses, entry.LastMatchID, entry.MiniSeries)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	if err := dm.recordLeaguePointsTx(ctx, tx, entry); err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+
+	for _, event := range events {
+		if err := dm.enqueueEvent(ctx, tx, event.Subject, event.Payload); err != nil {
+			recordSpanError(span, err)
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	recordSpanError(span, err)
+	return err
+}
+
+type WatchlistRefresher struct {
+	db         *DatabaseManager
+	riotClient *RiotAPIClient
+	natsClient *NATSClient
+	logger     *Logger
+	interval   time.Duration
+	batchSize  int
+
+	streakThreshold int
+}
+
+func NewWatchlistRefresher(cfg *Config, db *DatabaseManager, riotClient *RiotAPIClient, natsClient *NATSClient, logger *Logger) *WatchlistRefresher {
+	return &WatchlistRefresher{
+		db:         db,
+		riotClient: riotClient,
+		natsClient: natsClient,
+		logger:     logger,
+		interval:   cfg.WatchlistRefreshInterval,
+		batchSize:  cfg.WatchlistBatchSize,
+
+		streakThreshold: cfg.WatchlistStreakThreshold,
+	}
+}
+
+func (wr *WatchlistRefresher) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "watchlist_refresh",
+		Interval: wr.interval,
+		Run: func(ctx context.Context) error {
+			wr.RefreshDue(ctx)
+			return nil
+		},
+	}
+}
+
+func (wr *WatchlistRefresher) RefreshDue(ctx context.Context) {
+	entries, err := wr.db.WatchlistDueForRefresh(ctx, wr.interval, wr.batchSize)
+	if err != nil {
+		wr.logger.Error("watchlist_load_failed").
+			Component("watchlist").
+			Operation("refresh").
+			Err(err).
+			Log()
+		return
+	}
+
+	for i := range entries {
+		if err := wr.refreshEntry(ctx, &entries[i]); err != nil {
+			wr.logger.Error("watchlist_refresh_failed").
+				Component("watchlist").
+				Operation("refresh").
+				Game(entries[i].PUUID, entries[i].Region, entries[i].Tier).
+				Err(err).
+				Log()
+		}
+	}
+
+	wr.logger.Info("watchlist_refresh_completed").
+		Component("watchlist").
+		Operation("refresh").
+		Meta("entries", len(entries)).
+		Log()
+}
+
+func (wr *WatchlistRefresher) refreshEntry(ctx context.Context, entry *WatchlistEntry) error {
+	leagues, err := wr.riotClient.RefreshLeagueByPUUID(ctx, entry.PUUID)
+	if err != nil {
+		return err
+	}
+
+	previous := entry.Snapshot()
+	previousSeries := entry.MiniSeries
+	var series *MiniSeries
+	if league := findTFTLeague(leagues); league != nil {
+		entry.Tier = league.Tier
+		entry.Rank = league.Rank
+		entry.LeaguePoints = league.LeaguePoints
+		entry.Wins = league.Wins
+		entry.Losses = league.Losses
+		series = league.MiniSeries
+	}
+	entry.MiniSeries = ""
+	if series != nil {
+		entry.MiniSeries = series.Progress
+	}
+
+	newMatchIDs := wr.fetchNewMatchIDs(ctx, entry)
+
+	var events []OutboxMessage
+	current := entry.Snapshot()
+	if entry.LastRefreshedAt != nil && rankChanged(previous, current) {
+		event := &RankChangeEvent{
+			PUUID:       entry.PUUID,
+			Region:      entry.Region,
+			Previous:    previous,
+			Current:     current,
+			LPDelta:     current.LeaguePoints - previous.LeaguePoints,
+			NewMatchIDs: newMatchIDs,
+			ChangedAt:   time.Now().UTC(),
+		}
+		wr.logRankChange(event)
+		events = append(events, OutboxMessage{Subject: rankChangedSubject, Payload: event})
+	}
+
+	if status := promotionTransition(previousSeries, entry.MiniSeries, rankChanged(previous, current)); entry.LastRefreshedAt != nil && status != "" {
+		event := &PromotionSeriesEvent{
+			PUUID:      entry.PUUID,
+			Region:     entry.Region,
+			Status:     status,
+			Tier:       entry.Tier,
+			Rank:       entry.Rank,
+			MiniSeries: series,
+			OccurredAt: time.Now().UTC(),
+		}
+		wr.logger.Info("watchlist_promotion_series").
+			Component("watchlist").
+			Operation("promotion_series").
+			Game(event.PUUID, event.Region, event.Tier).
+			Meta("status", status).
+			Meta("progress", entry.MiniSeries).
+			Log()
+		events = append(events, OutboxMessage{Subject: promotionSeriesSubject, Payload: event})
+	}
+
+	if entry.LastRefreshedAt != nil {
+		if event := wr.checkStreak(ctx, entry, newMatchIDs); event != nil {
+			events = append(events, OutboxMessage{Subject: streakSubject, Payload: event})
+		}
+	}
+
+	// Without NATS there is no relay to drain the outbox.
+	if wr.natsClient == nil {
+		events = nil
+	}
+
+	return wr.db.UpdateWatchlistEntry(ctx, entry, events)
+}
+
+// checkStreak looks at the stored placements, which include the matches just
+// archived, when the refresh found new games.
+func (wr *WatchlistRefresher) checkStreak(ctx context.Context, entry *WatchlistEntry, newMatchIDs []string) *StreakEvent {
+	if wr.streakThreshold <= 0 || len(newMatchIDs) == 0 {
+		return nil
+	}
+
+	records, err := wr.db.GetLatestPlacementHistory(ctx, entry.PUUID, formWindow)
+	if err != nil {
+		wr.logger.Warn("watchlist_streak_failed").
+			Component("watchlist").
+			Operation("streak").
+			Game(entry.PUUID, entry.Region, "").
+			Err(err).
+			Log()
+		return nil
+	}
+
+	summary := computePlacementStats(entry.PUUID, records)
+	event := streakEvent(entry, &summary, newMatchIDs, wr.streakThreshold)
+	if event != nil {
+		wr.logger.Info("watchlist_streak").
+			Component("watchlist").
+			Operation("streak").
+			Game(entry.PUUID, entry.Region, entry.Tier).
+			Meta("kind", event.Streak.Kind).
+			Meta("length", event.Streak.Length).
+			Meta("form", event.Form.Direction).
+			Log()
+	}
+	return event
+}
+
+func (wr *WatchlistRefresher) fetchNewMatchIDs(ctx context.Context, entry *WatchlistEntry) []string {
+	matchIDs, err := wr.riotClient.GetRecentMatchIDs(ctx, entry.PUUID, 20)
+	if err != nil {
+		wr.logger.Warn("watchlist_matches_failed").
+			Component("watchlist").
+			Operation("refresh_matches").
+			Game(entry.PUUID, entry.Region, "").
+			Err(err).
+			Log()
+		return nil
+	}
+	if len(matchIDs) == 0 {
+		return nil
+	}
+
+	var newMatchIDs []string
+	for _, matchID := range matchIDs {
+		if matchID == entry.LastMatchID {
+			break
+		}
+		newMatchIDs = append(newMatchIDs, matchID)
+	}
+
+	entry.LastMatchID = matchIDs[0]
+	wr.archiveMatches(ctx, entry, newMatchIDs)
+	return newMatchIDs
+}
+
+func (wr *WatchlistRefresher) archiveMatches(ctx context.Context, entry *WatchlistEntry, matchIDs []string) {
+	for _, matchID := range matchIDs {
+		match, err := wr.riotClient.GetMatch(ctx, matchID)
+		if err == nil {
+			err = wr.db.ArchiveMatch(ctx, match)
+		}
+		if err != nil {
+			wr.logger.Warn("watchlist_match_archive_failed").
+				Component("watchlist").
+				Operation("archive_match").
+				Game(entry.PUUID, entry.Region, "").
+				Meta("match_id", matchID).
+				Err(err).
+				Log()
+		}
+	}
+}
+
+func (wr *WatchlistRefresher) logRankChange(event *RankChangeEvent) {
+	wr.logger.Info("watchlist_rank_changed").
+		Component("watchlist").
+		Operation("rank_change").
+		Game(event.PUUID, event.Region, event.Current.Tier).
+		Meta("previous_tier", event.Previous.Tier).
+		Meta("previous_rank", event.Previous.Rank).
+		Meta("current_rank", event.Current.Rank).
+		Meta("lp_delta", event.LPDelta).
+		Log()
+}
@@ -0,0 +1,596 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+
+	ImportRowPending = "pending"
+	ImportRowAdded   = "added"
+	ImportRowFailed  = "failed"
+)
+
+const maxWatchlistImportRows = 500
+
+type WatchlistImportRow struct {
+	Row    int    `json:"row"`
+	RiotID string `json:"riotId"`
+	Status string `json:"status"`
+	PUUID  string `json:"puuid,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type WatchlistImportJob struct {
+	ID         int64                `json:"id"`
+	Status     string               `json:"status"`
+	Total      int                  `json:"total"`
+	Added      int                  `json:"added"`
+	Failed     int                  `json:"failed"`
+	Pending    int                  `json:"pending"`
+	CreatedAt  time.Time            `json:"createdAt"`
+	FinishedAt *time.Time           `json:"finishedAt,omitempty"`
+	Rows       []WatchlistImportRow `json:"rows,omitempty"`
+}
+
+func (job *WatchlistImportJob) count() {
+	job.Added, job.Failed, job.Pending = 0, 0, 0
+	for _, row := range job.Rows {
+		switch row.Status {
+		case ImportRowAdded:
+			job.Added++
+		case ImportRowFailed:
+			job.Failed++
+		default:
+			job.Pending++
+		}
+	}
+}
+
+var riotIDHeaders = map[string]bool{"riot_id": true, "riotid": true, "riot id": true, "gamename": true, "game_name": true}
+
+// parseWatchlistImport reads one Riot ID per row from a CSV body (either
+// "name#tag" or "name,tag" columns, with an optional header) or from a JSON
+// array of "name#tag" strings.
+func parseWatchlistImport(contentType string, body []byte) ([]string, error) {
+	mediaType := "application/json"
+	if contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			return nil, NewAPIError("Invalid Content-Type", http.StatusUnsupportedMediaType)
+		}
+		mediaType = parsed
+	}
+
+	switch mediaType {
+	case "text/csv":
+		return parseWatchlistImportCSV(body)
+	case "application/json":
+		var riotIDs []string
+		if err := json.Unmarshal(body, &riotIDs); err != nil {
+			return nil, NewAPIError("Body must be a JSON array of Riot IDs", http.StatusBadRequest)
+		}
+		return riotIDs, nil
+	default:
+		return nil, NewAPIError("Content-Type must be text/csv or application/json", http.StatusUnsupportedMediaType)
+	}
+}
+
+func parseWatchlistImportCSV(body []byte) ([]string, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var riotIDs []string
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return riotIDs, nil
+		}
+		if err != nil {
+			return nil, NewAPIError("Invalid CSV: "+err.Error(), http.StatusBadRequest)
+		}
+		if first && riotIDHeaders[strings.ToLower(strings.TrimSpace(record[0]))] {
+			continue
+		}
+
+		riotID := record[0]
+		if len(record) > 1 && !strings.Contains(riotID, "#") && strings.TrimSpace(record[1]) != "" {
+			riotID += "#" + record[1]
+		}
+		riotIDs = append(riotIDs, riotID)
+	}
+}
+
+const (
+	// Each instance resolves watchlistImportWorkers jobs at once and queues
+	// up to watchlistImportQueued more; past that Submit answers 503.
+	watchlistImportWorkers = 2
+	watchlistImportQueued  = 20
+	// watchlistImportLease is how long a job stays claimed by the instance
+	// resolving it without progress. Once it lapses, because that instance
+	// stopped or crashed, any instance resumes the job.
+	watchlistImportLease = 2 * time.Minute
+)
+
+// WatchlistImporter resolves imported Riot IDs in the background and adds
+// every resolvable player to the tenant's watchlist. Jobs and per-row results
+// are stored in the database so any instance can report on them.
+type WatchlistImporter struct {
+	db         *DatabaseManager
+	riotClient *RiotAPIClient
+	logger     *Logger
+
+	// slots counts jobs queued or running here; queue never blocks, since
+	// a job only enters it holding a slot.
+	slots  chan struct{}
+	queue  chan watchlistImportTask
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type watchlistImportTask struct {
+	jobID  int64
+	tenant string
+	rows   []WatchlistImportRow
+}
+
+func NewWatchlistImporter(db *DatabaseManager, riotClient *RiotAPIClient, logger *Logger) *WatchlistImporter {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WatchlistImporter{
+		db:         db,
+		riotClient: riotClient,
+		logger:     logger,
+		slots:      make(chan struct{}, watchlistImportWorkers+watchlistImportQueued),
+		queue:      make(chan watchlistImportTask, watchlistImportWorkers+watchlistImportQueued),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+func (wi *WatchlistImporter) available() bool {
+	return wi.db != nil && wi.db.Enabled && !wi.db.ReadOnly
+}
+
+// Start runs the workers and resumes jobs whose instance went away.
+func (wi *WatchlistImporter) Start() {
+	if !wi.available() {
+		return
+	}
+	for range watchlistImportWorkers {
+		wi.wg.Add(1)
+		go wi.work()
+	}
+	if err := wi.resume(wi.ctx); err != nil {
+		wi.logger.Error("watchlist_import_resume_failed").
+			Component("watchlist").
+			Operation("import").
+			Err(err).
+			Log()
+	}
+}
+
+// Stop lets each worker finish its current row, then releases the jobs not
+// done so another instance, or this one once restarted, resumes them.
+func (wi *WatchlistImporter) Stop() {
+	wi.cancel()
+	wi.wg.Wait()
+
+	for {
+		select {
+		case task := <-wi.queue:
+			wi.release(task.jobID)
+		default:
+			return
+		}
+	}
+}
+
+// Task resumes jobs whose lease lapsed while every instance kept running.
+func (wi *WatchlistImporter) Task() ScheduledTask {
+	return ScheduledTask{
+		Name:     "watchlist_import_resume",
+		Interval: watchlistImportLease,
+		Run:      wi.resume,
+	}
+}
+
+// Submit stores the job and queues it. Rows that are not a valid Riot ID
+// fail immediately; the others stay pending until resolved.
+func (wi *WatchlistImporter) Submit(ctx context.Context, tenant string, riotIDs []string) (*WatchlistImportJob, error) {
+	if len(riotIDs) == 0 {
+		return nil, NewAPIError("Import has no rows", http.StatusBadRequest)
+	}
+	if len(riotIDs) > maxWatchlistImportRows {
+		return nil, NewAPIError(fmt.Sprintf("Import is limited to %d rows", maxWatchlistImportRows), http.StatusRequestEntityTooLarge)
+	}
+
+	rows := make([]WatchlistImportRow, len(riotIDs))
+	for i, riotID := range riotIDs {
+		rows[i] = WatchlistImportRow{Row: i + 1, RiotID: strings.TrimSpace(riotID), Status: ImportRowPending}
+		if _, err := ParseRiotID(riotID); err != nil {
+			rows[i].Status = ImportRowFailed
+			rows[i].Error = "invalid Riot ID, expected gameName#tagLine"
+		}
+	}
+
+	select {
+	case wi.slots <- struct{}{}:
+	default:
+		return nil, NewAPIError("Too many imports in progress, try again later", http.StatusServiceUnavailable)
+	}
+
+	job, err := wi.db.CreateWatchlistImport(ctx, tenant, rows, time.Now().UTC().Add(watchlistImportLease))
+	if err != nil {
+		<-wi.slots
+		return nil, NewAPIError("Failed to create import", http.StatusInternalServerError).WithCause(err)
+	}
+
+	wi.queue <- watchlistImportTask{jobID: job.ID, tenant: tenant, rows: rows}
+	return job, nil
+}
+
+// resume claims jobs whose lease lapsed, as many as there are free slots,
+// and queues their pending rows.
+func (wi *WatchlistImporter) resume(ctx context.Context) error {
+	free := cap(wi.slots) - len(wi.slots)
+	if free <= 0 || wi.ctx.Err() != nil {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	claimed, err := wi.db.ClaimWatchlistImports(ctx, now, now.Add(watchlistImportLease), free)
+	if err != nil {
+		return err
+	}
+
+	for _, claim := range claimed {
+		job, err := wi.db.GetWatchlistImport(ctx, claim.tenant, claim.jobID)
+		if err != nil || job == nil {
+			wi.release(claim.jobID)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		select {
+		case wi.slots <- struct{}{}:
+		default:
+			// Submit took the slot meanwhile.
+			wi.release(claim.jobID)
+			continue
+		}
+		wi.logger.Info("watchlist_import_resumed").
+			Component("watchlist").
+			Operation("import").
+			Meta("job_id", job.ID).
+			Meta("pending", job.Pending).
+			Log()
+		wi.queue <- watchlistImportTask{jobID: job.ID, tenant: claim.tenant, rows: job.Rows}
+	}
+	return nil
+}
+
+func (wi *WatchlistImporter) work() {
+	defer wi.wg.Done()
+	for {
+		select {
+		case <-wi.ctx.Done():
+			return
+		case task := <-wi.queue:
+			wi.run(task)
+			<-wi.slots
+		}
+	}
+}
+
+func (wi *WatchlistImporter) run(task watchlistImportTask) {
+	ctx := wi.ctx
+	start := time.Now()
+
+	for _, row := range task.rows {
+		if row.Status != ImportRowPending {
+			continue
+		}
+
+		row = wi.resolve(ctx, task.tenant, row)
+		if ctx.Err() != nil {
+			// Shutting down: the row stays pending for whoever resumes.
+			wi.release(task.jobID)
+			return
+		}
+		if err := wi.db.UpdateWatchlistImportRow(ctx, task.jobID, row, time.Now().UTC().Add(watchlistImportLease)); err != nil {
+			wi.logger.Warn("watchlist_import_row_failed").
+				Component("watchlist").
+				Operation("import").
+				Meta("job_id", task.jobID).
+				Meta("row", row.Row).
+				Err(err).
+				Log()
+		}
+	}
+
+	if err := wi.db.FinishWatchlistImport(context.WithoutCancel(ctx), task.jobID, time.Now().UTC()); err != nil {
+		wi.logger.Error("watchlist_import_finish_failed").
+			Component("watchlist").
+			Operation("import").
+			Meta("job_id", task.jobID).
+			Err(err).
+			Log()
+		return
+	}
+
+	wi.logger.Info("watchlist_import_completed").
+		Component("watchlist").
+		Operation("import").
+		Meta("job_id", task.jobID).
+		Meta("rows", len(task.rows)).
+		Duration(time.Since(start)).
+		Log()
+}
+
+// release gives up the claim on a job this instance will not finish.
+func (wi *WatchlistImporter) release(jobID int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := wi.db.ReleaseWatchlistImport(ctx, jobID); err != nil {
+		wi.logger.Warn("watchlist_import_release_failed").
+			Component("watchlist").
+			Operation("import").
+			Meta("job_id", jobID).
+			Err(err).
+			Log()
+	}
+}
+
+func (wi *WatchlistImporter) resolve(ctx context.Context, tenant string, row WatchlistImportRow) WatchlistImportRow {
+	id, _ := ParseRiotID(row.RiotID)
+
+	account, err := wi.riotClient.GetAccountByRiotID(ctx, id, false)
+	if err != nil {
+		row.Status = ImportRowFailed
+		row.Error = "failed to resolve Riot ID"
+		if isNotFound(err) {
+			row.Error = "Riot ID not found"
+		}
+		return row
+	}
+	row.PUUID = account.PUUID
+
+	if err := wi.db.AddToWatchlist(ctx, tenant, account.PUUID, wi.riotClient.region); err != nil {
+		row.Status = ImportRowFailed
+		row.Error = "failed to add to watchlist"
+		if errors.Is(err, ErrWatchlistQuotaExceeded) {
+			row.Error = "watchlist limit of " + strconv.Itoa(wi.db.WatchlistQuota()) + " players reached"
+		}
+		return row
+	}
+
+	row.Status = ImportRowAdded
+	return row
+}
+
+func (wi *WatchlistImporter) Get(ctx context.Context, tenant string, id int64) (*WatchlistImportJob, error) {
+	job, err := wi.db.GetWatchlistImport(ctx, tenant, id)
+	if err != nil {
+		return nil, NewAPIError("Failed to load import", http.StatusInternalServerError).WithCause(err)
+	}
+	if job == nil {
+		return nil, NewAPIError("Import not found", http.StatusNotFound)
+	}
+	return job, nil
+}
+
+// CreateWatchlistImport stores the job claimed by the caller until
+// claimedUntil.
+func (dm *DatabaseManager) CreateWatchlistImport(ctx context.Context, tenant string, rows []WatchlistImportRow, claimedUntil time.Time) (*WatchlistImportJob, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "create_watchlist_import")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	job := &WatchlistImportJob{Status: ImportJobRunning, Total: len(rows), CreatedAt: time.Now().UTC(), Rows: rows}
+	err = tx.QueryRowContext(ctx, dm.rebind(`
+		INSERT INTO watchlist_import_jobs (tenant_id, status, total, created_at, claimed_until)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`), tenant, job.Status, job.Total, job.CreatedAt, claimedUntil).Scan(&job.ID)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	for _, row := range rows {
+		_, err = tx.ExecContext(ctx, dm.rebind(`
+			INSERT INTO watchlist_import_rows (job_id, row_number, riot_id, status, error)
+			VALUES ($1, $2, $3, $4, $5)
+		`), job.ID, row.Row, row.RiotID, row.Status, sql.NullString{String: row.Error, Valid: row.Error != ""})
+		if err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	job.count()
+	return job, nil
+}
+
+// UpdateWatchlistImportRow stores a resolved row and extends the job's claim
+// to claimedUntil.
+func (dm *DatabaseManager) UpdateWatchlistImportRow(ctx context.Context, jobID int64, row WatchlistImportRow, claimedUntil time.Time) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "update_watchlist_import_row")
+	defer span.End()
+
+	tx, err := dm.DB.BeginTx(ctx, nil)
+	if err != nil {
+		recordSpanError(span, err)
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, dm.rebind(`
+		UPDATE watchlist_import_rows SET status = $3, puuid = $4, error = $5
+		WHERE job_id = $1 AND row_number = $2
+	`), jobID, row.Row, row.Status,
+		sql.NullString{String: row.PUUID, Valid: row.PUUID != ""},
+		sql.NullString{String: row.Error, Valid: row.Error != ""})
+	if err == nil {
+		_, err = tx.ExecContext(ctx, dm.rebind(`
+			UPDATE watchlist_import_jobs SET claimed_until = $2 WHERE id = $1
+		`), jobID, claimedUntil)
+	}
+	if err == nil {
+		err = tx.Commit()
+	}
+	recordSpanError(span, err)
+	return err
+}
+
+type watchlistImportClaim struct {
+	jobID  int64
+	tenant string
+}
+
+// ClaimWatchlistImports claims up to limit running jobs nobody holds, or
+// whose claim lapsed by now, until claimedUntil.
+func (dm *DatabaseManager) ClaimWatchlistImports(ctx context.Context, now, claimedUntil time.Time, limit int) ([]watchlistImportClaim, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "claim_watchlist_imports")
+	defer span.End()
+
+	lock := ""
+	if dm.Dialect == DatabaseDriverPostgres {
+		lock = " FOR UPDATE SKIP LOCKED"
+	}
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(`
+		UPDATE watchlist_import_jobs SET claimed_until = $1
+		WHERE id IN (
+			SELECT id FROM watchlist_import_jobs
+			WHERE status = $2 AND (claimed_until IS NULL OR claimed_until < $3)
+			ORDER BY id
+			LIMIT $4`+lock+`
+		)
+		RETURNING id, tenant_id
+	`), claimedUntil, ImportJobRunning, now, limit)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var claimed []watchlistImportClaim
+	for rows.Next() {
+		var claim watchlistImportClaim
+		if err := rows.Scan(&claim.jobID, &claim.tenant); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		claimed = append(claimed, claim)
+	}
+	recordSpanError(span, rows.Err())
+	return claimed, rows.Err()
+}
+
+func (dm *DatabaseManager) ReleaseWatchlistImport(ctx context.Context, jobID int64) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "release_watchlist_import")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		UPDATE watchlist_import_jobs SET claimed_until = NULL WHERE id = $1
+	`), jobID)
+	recordSpanError(span, err)
+	return err
+}
+
+func (dm *DatabaseManager) FinishWatchlistImport(ctx context.Context, jobID int64, at time.Time) error {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "finish_watchlist_import")
+	defer span.End()
+
+	_, err := dm.DB.ExecContext(ctx, dm.rebind(`
+		UPDATE watchlist_import_jobs SET status = $2, finished_at = $3, claimed_until = NULL WHERE id = $1
+	`), jobID, ImportJobCompleted, at)
+	recordSpanError(span, err)
+	return err
+}
+
+// GetWatchlistImport returns nil when the job does not exist or belongs to
+// another tenant.
+func (dm *DatabaseManager) GetWatchlistImport(ctx context.Context, tenant string, id int64) (*WatchlistImportJob, error) {
+	ctx, span := startDatabaseSpan(ctx, dm.Dialect, "get_watchlist_import")
+	defer span.End()
+
+	job := &WatchlistImportJob{ID: id}
+	var finishedAt sql.NullTime
+	err := dm.DB.QueryRowContext(ctx, dm.rebind(`
+		SELECT status, total, created_at, finished_at
+		FROM watchlist_import_jobs
+		WHERE id = $1 AND tenant_id = $2
+	`), id, tenant).Scan(&job.Status, &job.Total, &job.CreatedAt, &finishedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+
+	rows, err := dm.DB.QueryContext(ctx, dm.rebind(`
+		SELECT row_number, riot_id, status, puuid, error
+		FROM watchlist_import_rows
+		WHERE job_id = $1
+		ORDER BY row_number
+	`), id)
+	if err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row WatchlistImportRow
+		var puuid, rowError sql.NullString
+		if err := rows.Scan(&row.Row, &row.RiotID, &row.Status, &puuid, &rowError); err != nil {
+			recordSpanError(span, err)
+			return nil, err
+		}
+		row.PUUID = puuid.String
+		row.Error = rowError.String
+		job.Rows = append(job.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		recordSpanError(span, err)
+		return nil, err
+	}
+
+	job.count()
+	return job, nil
+}
@@ -0,0 +1,147 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseWatchlistImport(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		expected    []string
+		status      int
+	}{
+		{name: "json list", contentType: "application/json", body: `["Faker#KR1", "Player#BR1"]`, expected: []string{"Faker#KR1", "Player#BR1"}},
+		{name: "json without content type", body: `["Faker#KR1"]`, expected: []string{"Faker#KR1"}},
+		{name: "json object rejected", contentType: "application/json", body: `{"riotIds": []}`, status: http.StatusBadRequest},
+		{name: "csv single column with header", contentType: "text/csv", body: "riot_id\nFaker#KR1\nPlayer#BR1\n", expected: []string{"Faker#KR1", "Player#BR1"}},
+		{name: "csv name and tag columns", contentType: "text/csv; charset=utf-8", body: "gameName,tagLine\nFaker, KR1\nPlayer,BR1\n", expected: []string{"Faker#KR1", "Player#BR1"}},
+		{name: "csv keeps invalid rows", contentType: "text/csv", body: "Faker#KR1\nno tag\n", expected: []string{"Faker#KR1", "no tag"}},
+		{name: "unsupported type", contentType: "application/xml", body: "<ids/>", status: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseWatchlistImport(tt.contentType, []byte(tt.body))
+			if tt.status != 0 {
+				var apiErr APIError
+				if !errors.As(err, &apiErr) || apiErr.Status != tt.status {
+					t.Fatalf("parseWatchlistImport() error = %v, expected status %d", err, tt.status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseWatchlistImport() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseWatchlistImport() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWatchlistImportJobCount(t *testing.T) {
+	job := &WatchlistImportJob{Rows: []WatchlistImportRow{
+		{Status: ImportRowAdded}, {Status: ImportRowFailed}, {Status: ImportRowPending}, {Status: ImportRowAdded},
+	}}
+	job.count()
+	if job.Added != 2 || job.Failed != 1 || job.Pending != 1 {
+		t.Errorf("count() = added %d, failed %d, pending %d, expected 2, 1, 1", job.Added, job.Failed, job.Pending)
+	}
+}
+
+func waitForWatchlistImport(t *testing.T, db *DatabaseManager, tenant string, id int64) *WatchlistImportJob {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := db.GetWatchlistImport(t.Context(), tenant, id)
+		if err != nil {
+			t.Fatalf("GetWatchlistImport() error = %v", err)
+		}
+		if job.Status == ImportJobCompleted {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("import %d still %s: %+v", id, job.Status, job.Rows)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWatchlistImporter_SubmitAndResume(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	importer := NewWatchlistImporter(db, newCachedFixtureClient("../fixtures/riot"), newTestLogger())
+
+	// A job claimed by an instance that went away, and one another live
+	// instance still holds.
+	now := time.Now().UTC()
+	orphaned, err := db.CreateWatchlistImport(t.Context(), "tenant", []WatchlistImportRow{{Row: 1, RiotID: "Fixture#BR1", Status: ImportRowPending}}, now.Add(-time.Minute))
+	if err != nil {
+		t.Fatalf("CreateWatchlistImport() error = %v", err)
+	}
+	held, err := db.CreateWatchlistImport(t.Context(), "tenant", []WatchlistImportRow{{Row: 1, RiotID: "Fixture#BR1", Status: ImportRowPending}}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("CreateWatchlistImport() error = %v", err)
+	}
+
+	importer.Start()
+	defer importer.Stop()
+
+	job, err := importer.Submit(t.Context(), "tenant", []string{"Fixture#BR1", "Nobody#BR1", "not a riot id"})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	job = waitForWatchlistImport(t, db, "tenant", job.ID)
+	if job.Added != 1 || job.Failed != 2 {
+		t.Errorf("submitted job = %+v, expected 1 added and 2 failed", job)
+	}
+
+	if job := waitForWatchlistImport(t, db, "tenant", orphaned.ID); job.Added != 1 {
+		t.Errorf("resumed job = %+v, expected its row added", job)
+	}
+	if job, _ := db.GetWatchlistImport(t.Context(), "tenant", held.ID); job.Status != ImportJobRunning {
+		t.Errorf("job held by another instance = %s, expected it left alone", job.Status)
+	}
+}
+
+func TestWatchlistImporter_StopReleasesQueuedJobs(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	importer := NewWatchlistImporter(db, newCachedFixtureClient("../fixtures/riot"), newTestLogger())
+
+	// Without Start no worker picks the job up.
+	job, err := importer.Submit(t.Context(), "tenant", []string{"Fixture#BR1"})
+	if err != nil {
+		t.Fatalf("Submit() error = %v", err)
+	}
+	now := time.Now().UTC()
+	if claimed, _ := db.ClaimWatchlistImports(t.Context(), now, now.Add(time.Minute), 10); len(claimed) != 0 {
+		t.Fatalf("claimed %+v while this instance holds the job", claimed)
+	}
+
+	importer.Stop()
+	claimed, err := db.ClaimWatchlistImports(t.Context(), now, now.Add(time.Minute), 10)
+	if err != nil || len(claimed) != 1 || claimed[0].jobID != job.ID {
+		t.Errorf("ClaimWatchlistImports() = %+v, %v, expected the released job", claimed, err)
+	}
+}
+
+func TestWatchlistImporter_SubmitWhenFull(t *testing.T) {
+	db := newSQLiteTestDB(t)
+	importer := NewWatchlistImporter(db, newCachedFixtureClient("../fixtures/riot"), newTestLogger())
+
+	for range cap(importer.slots) {
+		if _, err := importer.Submit(t.Context(), "tenant", []string{"Fixture#BR1"}); err != nil {
+			t.Fatalf("Submit() error = %v", err)
+		}
+	}
+	_, err := importer.Submit(t.Context(), "tenant", []string{"Fixture#BR1"})
+	var apiErr APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusServiceUnavailable {
+		t.Errorf("Submit() error = %v, expected 503 once the queue is full", err)
+	}
+}